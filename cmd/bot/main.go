@@ -0,0 +1,108 @@
+// Command bot runs a Discord bot that responds to messages and fixes broken link previews.
+//
+// Usage:
+//
+//	bot [run]                  connect to Discord and handle events until interrupted
+//	bot init                   ask for a token and write a .env file to get started
+//	bot register-commands      register the slash commands and exit
+//	bot migrate                apply pending storage migrations
+//	bot fix <text>             print what the link fixers would repost for some text
+//	bot replay <fixture.json>  replay captured gateway payloads and print what the bot does
+//	bot fleet <operation>      post an announcement, change a setting or toggle a module in many guilds at once
+//	bot announce <message>     post an announcement to many guilds at once, like bot fleet announce
+//	bot install-service        write a systemd unit, or register a Windows service
+//	bot version                print the build version
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/secrets"
+	"go-discord-bot/internal/service"
+)
+
+// init loads the environment variables from a .env file, and the one of
+// the profile BOT_ENV names over it, then from the secrets store
+// SECRETS_SOURCE selects, if any. Windows services look for the .env files
+// beside the executable.
+// It should be called automatically before the main function.
+func init() {
+	if err := service.Workdir(); err != nil {
+		log.Println("Error changing to the bot's folder:", err)
+	}
+	profile := os.Getenv("BOT_ENV")
+	if profile == "" {
+		if vars, err := godotenv.Read(); err == nil {
+			profile = vars["BOT_ENV"]
+		}
+	}
+	// Earlier files win, as loading never overrides a variable already set
+	for _, file := range config.EnvFiles(profile) {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		if err := godotenv.Load(file); err != nil {
+			log.Printf("Error loading %s file: %v\n", file, err)
+		}
+	}
+	if _, err := loadSecrets(); err != nil {
+		log.Println("Error loading secrets:", err)
+	}
+}
+
+// loadSecrets fetches the secrets kept in the store SECRETS_SOURCE selects
+// and sets them as environment variables, over any from .env. It returns
+// them, or nil without a store.
+func loadSecrets() (map[string]string, error) {
+	source, err := secrets.FromEnv(os.Getenv)
+	if err != nil || source == nil {
+		return nil, err
+	}
+	values, err := source.Fetch(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		os.Setenv(key, value)
+	}
+	return values, nil
+}
+
+// subcommands maps each CLI subcommand to its implementation.
+// Each receives the arguments following the subcommand name.
+var subcommands = map[string]func(args []string) error{
+	"run":               runBot,
+	"init":              initEnv,
+	"register-commands": registerCommands,
+	"migrate":           migrate,
+	"fix":               fix,
+	"replay":            replayFixture,
+	"fleet":             runFleet,
+	"announce":          announce,
+	"install-service":   installService,
+	"version":           printVersion,
+}
+
+// main is the entry point of the application.
+// It dispatches to a subcommand, running the bot when none is given.
+func main() {
+	name, args := "run", os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := subcommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\nusage: bot [run|init|register-commands|migrate|fix|replay|fleet|announce|install-service|version] [flags]\n", name)
+		os.Exit(2)
+	}
+	if err := service.Run(func() error { return cmd(args) }); err != nil {
+		log.Fatal(err)
+	}
+}
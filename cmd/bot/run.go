@@ -0,0 +1,1202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/abuse"
+	"go-discord-bot/internal/access"
+	"go-discord-bot/internal/announcements"
+	"go-discord-bot/internal/api"
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/canonical"
+	"go-discord-bot/internal/channels"
+	"go-discord-bot/internal/chaos"
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/crosspost"
+	"go-discord-bot/internal/dashboard"
+	"go-discord-bot/internal/dedupe"
+	"go-discord-bot/internal/digest"
+	"go-discord-bot/internal/domains"
+	"go-discord-bot/internal/eventqueue"
+	"go-discord-bot/internal/events"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/feeds"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/flags"
+	"go-discord-bot/internal/fleet"
+	"go-discord-bot/internal/flood"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/gamestores"
+	"go-discord-bot/internal/handlers"
+	"go-discord-bot/internal/health"
+	"go-discord-bot/internal/httpclient"
+	"go-discord-bot/internal/intents"
+	"go-discord-bot/internal/invite"
+	"go-discord-bot/internal/janitor"
+	"go-discord-bot/internal/lifecycle"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/maintenance"
+	"go-discord-bot/internal/members"
+	"go-discord-bot/internal/mirror"
+	"go-discord-bot/internal/nitter"
+	"go-discord-bot/internal/notify"
+	"go-discord-bot/internal/odesli"
+	"go-discord-bot/internal/outages"
+	"go-discord-bot/internal/outbound"
+	"go-discord-bot/internal/overlap"
+	"go-discord-bot/internal/pending"
+	"go-discord-bot/internal/phishing"
+	"go-discord-bot/internal/preview"
+	"go-discord-bot/internal/proxy"
+	"go-discord-bot/internal/purge"
+	"go-discord-bot/internal/redis"
+	"go-discord-bot/internal/responders"
+	"go-discord-bot/internal/retry"
+	"go-discord-bot/internal/rolemenus"
+	"go-discord-bot/internal/service"
+	"go-discord-bot/internal/shards"
+	"go-discord-bot/internal/stats"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/syndication"
+	"go-discord-bot/internal/telemetry"
+	"go-discord-bot/internal/tracing"
+	"go-discord-bot/internal/trash"
+	"go-discord-bot/internal/trends"
+	"go-discord-bot/internal/unshorten"
+	"go-discord-bot/internal/updates"
+	"go-discord-bot/internal/version"
+	"go-discord-bot/internal/voice"
+	"go-discord-bot/internal/watchdog"
+	"go-discord-bot/internal/workerpool"
+)
+
+// pendingTTL is how long a fix held for a guild in reaction mode can still be
+// asked for.
+const pendingTTL = 24 * time.Hour
+
+// traceExportInterval is how often finished spans are sent to the collector.
+const traceExportInterval = 5 * time.Second
+
+// runBot sets up the Discord session, registers event handlers,
+// and keeps the bot running until interrupted.
+func runBot(args []string) error {
+	started := time.Now()
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	register := fs.Bool("register", true, "register slash commands globally when connecting")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if cfg.LogFile != "" {
+		logFile, err := logging.OpenRotating(cfg.LogFile, cfg.LogMaxSize, cfg.LogMaxAge, cfg.LogMaxBackups)
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+		log.SetOutput(io.MultiWriter(os.Stderr, logFile))
+	}
+
+	// Load has checked the proxy settings already
+	routes, _ := cfg.Proxies()
+	clients := httpclient.New(routes, cfg.HTTPRequestsPerHost)
+
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	pending, err := storage.Pending(store)
+	if err != nil {
+		return fmt.Errorf("checking schema version: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("the data store needs %d migrations; run `bot migrate` first", len(pending))
+	}
+
+	logging.SetPrivacy(privacyLookup(store))
+
+	featureFlags, err := flags.Load(cfg.FlagsFile)
+	if err != nil {
+		return fmt.Errorf("loading feature flags: %w", err)
+	}
+
+	// ctx is cancelled once shutdown gives up waiting for in-flight work
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go featureFlags.Watch(ctx, cfg.FlagsPollInterval)
+
+	nitterInstances := nitter.New(cfg.NitterInstances, clients.Twitter(0))
+	go nitterInstances.Run(ctx, cfg.NitterCheckInterval)
+
+	checker, err := newPhishingChecker(cfg, clients)
+	if err != nil {
+		return fmt.Errorf("loading phishing blocklist: %w", err)
+	}
+
+	announcer, err := voice.New(cfg.VoiceSoundFile, cfg.VoiceTTSCommand)
+	if err != nil {
+		return fmt.Errorf("loading voice notice: %w", err)
+	}
+	defer announcer.Close()
+
+	// Custom Twitter sites are checked once the main bot can warn guilds about them
+	sites := &domains.Monitor{Checker: domains.NewChecker(clients.Links(0)), Store: store}
+	budgets := budget.New(budget.Limits{budget.Lookups: cfg.DailyLookups, budget.Mirrors: cfg.DailyMirrors})
+	tweets := fxtwitter.New("", clients.Twitter(0))
+	songs := odesli.New("", cfg.SongLinkKey, clients.Other(0))
+	pipeline := newPipeline(cfg, store, featureFlags, nitterInstances, sites, budgets, tweets, songs)
+	cleanup := janitor.New()
+	collector := stats.New()
+	collector.Store = store
+	bus := events.New()
+	previews := preview.New(clients.Links(10 * time.Second))
+	unshortener := unshorten.New(clients.Links(5 * time.Second))
+	resolved := canonical.New(store, canonical.DefaultTTL)
+	archive := digest.New(store)
+	bin := trash.New(store, cfg.DeletedRetention)
+	cleanup.Add("deleted messages", bin)
+	publisher := crosspost.New()
+	known := channels.New()
+	people := members.New()
+	others := overlap.New(store)
+	tracer := newTracer(cfg, clients)
+	go tracer.Run(ctx, traceExportInterval)
+	defer func() {
+		// Export what the last messages handled before shutting down
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tracer.Flush(flushCtx); err != nil {
+			log.Println("Error exporting traces:", err)
+		}
+	}()
+	dog := watchdog.New()
+	dog.Add("goroutines", cfg.MaxGoroutines, watchdog.Goroutines)
+	// Caches are pruned by the janitor and watched for growing anyway
+	watchCache := func(name string, cache interface {
+		janitor.Pruner
+		Len() int
+	}) {
+		cleanup.Add(name, cache)
+		dog.Add(name, cfg.MaxCacheSize, cache.Len)
+	}
+	watchCache("unshortened links", unshortener)
+	watchCache("stored canonical links", resolved)
+	watchCache("guild budgets", budgets)
+	watchCache("announcement channels", publisher)
+	watchCache("channels", known)
+	watchCache("members", people)
+	watchCache("shared tweets", others)
+
+	mode, err := maintenance.New(store)
+	if err != nil {
+		return fmt.Errorf("loading maintenance mode: %w", err)
+	}
+	modules, err := lifecycle.New(store)
+	if err != nil {
+		return fmt.Errorf("loading stopped modules: %w", err)
+	}
+	modules.Register(lifecycle.Fixers, "Fixes the links in messages", nil)
+	modules.Register(lifecycle.Greeter, "Welcomes new members", nil)
+	modules.Register(lifecycle.Starboard, "Reposts starred messages to starboards", nil)
+	// Probes are added once the parts they check are running
+	monitor := health.New()
+	shared := trends.New(store)
+	var replicas *redis.Client
+	if cfg.RedisURL != "" {
+		client, err := redis.New(cfg.RedisURL)
+		if err != nil {
+			return fmt.Errorf("loading Redis URL: %w", err)
+		}
+		defer client.Close()
+		replicas = client.WithPrefix(cfg.RedisPrefix)
+		// Replicas go on by themselves while Redis is down
+		if err := replicas.Ping(ctx); err != nil {
+			log.Println("Error connecting to Redis:", err)
+		}
+	}
+	var bots []*bot
+	// Maintenance covers every bot, whichever one the owner switched it with
+	maintained := func(on bool, held []maintenance.Held) {
+		for _, b := range bots {
+			for _, s := range b.manager.Sessions {
+				setMaintenanceStatus(s, on)
+			}
+			if !on {
+				b.handler.FixHeld(b.manager.Sessions[0], held)
+			}
+		}
+	}
+	deps := botDeps{
+		cfg:        cfg,
+		routes:     routes,
+		clients:    clients,
+		store:      store,
+		pipeline:   pipeline,
+		bus:        bus,
+		collector:  collector,
+		bin:        bin,
+		checker:    checker,
+		others:     others,
+		trends:     shared,
+		mode:       mode,
+		maintained: maintained,
+		modules:    modules,
+		monitor:    monitor,
+		started:    started,
+		register:   *register,
+	}
+	for _, identity := range cfg.Bots() {
+		b, err := newBot(ctx, identity, deps)
+		if err != nil {
+			return fmt.Errorf("creating Discord sessions for %s bot: %w", identity.Name, err)
+		}
+		b.handler.Previews = previews
+		b.handler.Phishing = checker
+		b.handler.Unshortener = unshortener
+		b.handler.AuthorPermissions = b.manager.AuthorPermissions
+		b.handler.Canonical = resolved
+		b.handler.Budget = budgets
+		b.handler.Digest = archive
+		b.handler.Trends = shared
+		b.handler.Trash = bin
+		b.handler.Crossposts = publisher
+		b.handler.Channels = known
+		b.handler.Members = people
+		b.manager.AddUrgentHandler(func(_ *discordgo.Session, r *discordgo.Ready) {
+			others.Ours(r.User.ID)
+		})
+		b.handler.Voice = announcer
+		b.handler.Tracer = tracer
+		b.handler.Maintenance = mode
+		b.handler.Modules = modules
+		if replicas != nil {
+			// Replicas of one bot coordinate; different bots fix messages on their own
+			bucket := replicas.WithPrefix(b.name + ":")
+			b.handler.Claims = dedupe.NewClaims(bucket)
+			b.registry.Claims, b.registry.Shared = b.handler.Claims, bucket
+			if b.handler.Duplicates != nil {
+				b.handler.Duplicates.Shared = bucket
+			}
+			if b.handler.Abuse != nil {
+				b.handler.Abuse.Shared = bucket
+			}
+			if b.handler.Flood != nil {
+				b.handler.Flood.Shared = bucket
+			}
+			b.handler.Responses.Shared = bucket
+		}
+		if err := b.setIntents(cfg); err != nil {
+			return err
+		}
+		watchCache(b.name+" bot reposts", b.handler.Duplicates)
+		watchCache(b.name+" bot flood channels", b.handler.Flood)
+		watchCache(b.name+" bot abuse counts", b.handler.Abuse)
+		watchCache(b.name+" bot auto-response cooldowns", b.handler.Responses)
+		watchCache(b.name+" bot unavailable guilds", b.handler.Outages)
+		watchCache(b.name+" bot command cooldowns and pages", b.registry)
+		watchCache(b.name+" bot pending reposts", b.handler.Pending)
+		watchCache(b.name+" bot reposts awaiting confirmation", b.handler.Confirmations)
+		watchCache(b.name+" bot paced channels", b.queue)
+		dog.Add(b.name+" bot preview timers", cfg.MaxTimers, b.handler.PendingPreviews)
+		// Warns once the queue is full and events are being dropped
+		dog.Add(b.name+" bot gateway event queue", cfg.EventQueueSize-1, b.events.Len)
+		bots = append(bots, b)
+	}
+	go cleanup.Run(ctx, cfg.CleanupInterval)
+
+	var queues []*eventqueue.Queue
+	var pools []*workerpool.Pool
+	for _, b := range bots {
+		if cfg.ResumeWindow > 0 {
+			if n := b.manager.Restore(store, b.name, cfg.ResumeWindow); n > 0 {
+				log.Printf("%sResuming the sessions of %d shards from before the restart\n", b.label, n)
+			}
+		}
+		if err := b.manager.Open(); err != nil {
+			return fmt.Errorf("opening connection for %s bot: %w", b.name, intents.Explain(err, b.intents))
+		}
+		defer b.manager.Close()
+		queues = append(queues, b.events)
+		pools = append(pools, b.handler.Pool)
+		fmt.Printf("%sThe bot is now running %d of %d shards.\n", b.label, len(b.manager.Sessions), b.manager.Count)
+	}
+
+	notifier := newNotifier(cfg, clients, bots[0].manager.Sessions[0])
+	dog.Alert = func(ctx context.Context, warning string) {
+		notifier.Notify(ctx, notify.Alert{Severity: notify.Warning, Source: "Watchdog", Text: warning})
+	}
+	go dog.Run(ctx, cfg.WatchdogInterval)
+	go alertErrors(ctx, bus, notifier)
+	// Shards disconnect when shutting down too, which isn't worth an alert
+	var stopping atomic.Bool
+	for _, b := range bots {
+		b.manager.AddUrgentHandler(func(s *discordgo.Session, _ *discordgo.Disconnect) {
+			if stopping.Load() {
+				return
+			}
+			notifier.Notify(ctx, notify.Alert{
+				Severity: notify.Info,
+				Source:   "Gateway",
+				Text:     fmt.Sprintf("%sshard %d disconnected, reconnecting", b.label, s.ShardID),
+			})
+		})
+	}
+
+	if cfg.UpdateCheckInterval > 0 {
+		checker := updates.New(cfg.UpdateURL, version.Version, clients.Other(0))
+		checker.Notify = notifyOwner(bots[0].manager.Sessions[0])
+		go checker.Run(ctx, cfg.UpdateCheckInterval)
+	}
+
+	if cfg.TelemetryURL != "" {
+		reporter := telemetry.New(cfg.TelemetryURL, version.Version, clients.Other(0))
+		reporter.Stats = collector
+		reporter.Guilds = func() int {
+			count := 0
+			for _, b := range bots {
+				count += b.manager.GuildCount()
+			}
+			return count
+		}
+		log.Println("Sending anonymous usage counts to", cfg.TelemetryURL)
+		go reporter.Run(ctx, cfg.TelemetryInterval)
+	}
+
+	// Feeds, announcements and daily digests are posted by the main bot
+	feedWatcher := &feeds.Watcher{Store: store, Fetcher: feeds.NewFetcher(clients.Links(20 * time.Second)), Session: bots[0].manager.Sessions[0]}
+	modules.Register(lifecycle.Feeds, "Posts new items from RSS and Atom feeds", func(ctx context.Context) { feedWatcher.Run(ctx, time.Minute) })
+	modules.Start(ctx)
+	scheduler := &announcements.Scheduler{Store: store, Session: bots[0].manager.Sessions[0]}
+	archive.Locale = func(guildID string) string {
+		if g, ok := bots[0].manager.Guild(guildID); ok {
+			return g.PreferredLocale
+		}
+		return ""
+	}
+	scheduler.Jobs = append(scheduler.Jobs, func(ctx context.Context, now time.Time) {
+		archive.Flush(ctx, bots[0].manager.Sessions[0], now)
+	}, func(ctx context.Context, now time.Time) {
+		if err := shared.Flush(now); err != nil {
+			log.Println("Error saving trends:", err)
+		}
+	}, func(ctx context.Context, now time.Time) {
+		if err := collector.Flush(now); err != nil {
+			log.Println("Error saving stats:", err)
+		}
+	})
+	// Each bot archives the threads it started
+	for _, b := range bots {
+		scheduler.Jobs = append(scheduler.Jobs, func(ctx context.Context, now time.Time) {
+			b.handler.ArchiveThreads(ctx, b.manager.Sessions[0], now)
+		})
+	}
+	go scheduler.Run(ctx, time.Minute)
+	sites.Session = bots[0].manager.Sessions[0]
+	go sites.Run(ctx, domains.CheckInterval)
+	addHealthProbes(monitor, cfg, bots, store, sites, tweets, nitterInstances)
+
+	if cfg.DashboardAddr != "" {
+		// The main bot's first session is only used for REST calls here
+		dash := dashboard.New(dashboard.Config{ClientID: cfg.ClientID, ClientSecret: cfg.ClientSecret, BaseURL: cfg.DashboardURL, Client: clients.Discord(0)})
+		dash.Store = store
+		dash.Bot = bots[0].manager.Sessions[0]
+		dash.Stats = collector
+		dash.Fixers = pipeline.Names()
+		go serve(ctx, "Dashboard", cfg.DashboardAddr, dash)
+	}
+
+	if cfg.APIAddr != "" {
+		primary := bots[0]
+		admin := api.New(cfg.APIToken)
+		admin.Store = store
+		admin.Bot = primary.manager.Sessions[0]
+		admin.Stats = collector
+		admin.Events = bus
+		admin.Decisions = primary.handler.Decisions
+		admin.Outbound = clients
+		admin.EventQueue = primary.events
+		admin.Purger = newPurger(store, collector, shared)
+		admin.Modules = modules
+		admin.Health = monitor
+		admin.Guilds = primary.manager
+		admin.Fleet = primary.manager.Sessions[0]
+		admin.Reprocess = func(m *discordgo.Message) bool {
+			return primary.handler.Reprocess(primary.manager.Sessions[0], m)
+		}
+		go serve(ctx, "Admin API", cfg.APIAddr, admin)
+	}
+
+	if cfg.Env != "" {
+		log.Println("Using the", cfg.Env, "profile")
+	}
+	if len(cfg.Guilds) > 0 {
+		log.Println("Limited to guilds", strings.Join(cfg.Guilds, ", "))
+	}
+	log.Println("Starting", version.String())
+	fmt.Println("Press CTRL-C to exit.")
+	if err := service.Notify("READY=1"); err != nil {
+		log.Println("Error notifying systemd:", err)
+	}
+	go service.Watchdog(ctx, func() bool {
+		for _, b := range bots {
+			if !b.manager.Alive(service.WatchdogInterval()) {
+				return false
+			}
+		}
+		return true
+	})
+
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP)
+	service.Forward(sc)
+	for sig := range sc {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		if err := featureFlags.Reload(); err != nil {
+			log.Println("Error reloading feature flags:", err)
+		} else {
+			log.Println("Reloaded feature flags on SIGHUP")
+		}
+		rotateTokens(cfg, bots)
+		if checker == nil {
+			continue
+		}
+		if err := checker.Reload(); err != nil {
+			log.Println("Error reloading phishing blocklist:", err)
+		} else {
+			log.Println("Reloaded phishing blocklist on SIGHUP")
+		}
+	}
+
+	stopping.Store(true)
+	service.Notify("STOPPING=1")
+	if cfg.ResumeWindow > 0 {
+		// Disconnecting first leaves no events unhandled after the saved
+		// sequences, so the next start picks up exactly where this one stopped
+		for _, b := range bots {
+			b.manager.Suspend(store, b.name)
+		}
+	}
+	shutdown(cfg.ShutdownTimeout, cancel, queues, pools...)
+	// Counts made since the last flush would be lost otherwise
+	if err := shared.Flush(time.Now()); err != nil {
+		log.Println("Error saving trends:", err)
+	}
+	if err := collector.Flush(time.Now()); err != nil {
+		log.Println("Error saving stats:", err)
+	}
+	return nil
+}
+
+// rotateTokens reads the bots' tokens again and reconnects the ones that
+// changed, so a leaked token can be replaced without a restart. Bots added
+// or removed since startup need one.
+func rotateTokens(cfg config.Config, bots []*bot) {
+	values, err := loadSecrets()
+	if err != nil {
+		log.Println("Error loading secrets:", err)
+		return
+	}
+	fresh, err := cfg.ReloadBots(values)
+	if err != nil {
+		log.Println("Error reloading bot tokens:", err)
+		return
+	}
+	for _, b := range bots {
+		for _, identity := range fresh {
+			if identity.Name != b.name || identity.Token == b.token {
+				continue
+			}
+			if err := b.manager.Rotate(identity.Token); err != nil {
+				log.Printf("%sError rotating bot token: %v\n", b.label, err)
+				break
+			}
+			b.token = identity.Token
+			log.Printf("%sRotated bot token on SIGHUP\n", b.label)
+		}
+	}
+}
+
+// bot is one bot identity run by the process, with its own sessions and handlers.
+type bot struct {
+	name string
+	// token is the one the bot's shards are connected with.
+	token string
+	// label prefixes the bot's log lines, empty for the main bot.
+	label    string
+	manager  *shards.Manager
+	handler  *handlers.Handler
+	registry *commands.Registry
+	// queue paces the requests of every shard.
+	queue *outbound.Queue
+	// events holds the shards' events until they're handled.
+	events *eventqueue.Queue
+	// intents are the gateway intents the bot connects with.
+	intents discordgo.Intent
+}
+
+// botDeps are what every bot the process runs shares: its settings and the
+// parts it's built from, which newBot hands on to newRegistry.
+type botDeps struct {
+	cfg     config.Config
+	routes  proxy.Routes
+	clients *httpclient.Clients
+	store   storage.Store
+	// pipeline fixes the links in messages.
+	pipeline  fixers.Pipeline
+	bus       *events.Bus
+	collector *stats.Collector
+	bin       *trash.Bin
+	checker   *phishing.Checker
+	others    *overlap.Detector
+	trends    *trends.Counter
+	mode      *maintenance.Mode
+	// maintained is called when maintenance is switched on or off, with the
+	// messages held during it.
+	maintained func(on bool, held []maintenance.Held)
+	modules    *lifecycle.Manager
+	monitor    *health.Monitor
+	started    time.Time
+	// register registers the slash commands once a session is ready.
+	register bool
+}
+
+// newBot creates the sessions and handlers for one identity. The configured
+// shard settings apply to the main bot; extra bots run all of their shards.
+func newBot(ctx context.Context, identity config.Bot, d botDeps) (*bot, error) {
+	b := &bot{name: identity.Name, token: identity.Token}
+	shardCount, shardIDs := d.cfg.ShardCount, d.cfg.ShardIDs
+	if identity.Name != config.MainBot {
+		b.label = "[" + identity.Name + "] "
+		shardCount, shardIDs = 0, nil
+	}
+
+	manager, err := shards.New(identity.Token, shardCount, shardIDs, d.routes.Discord)
+	if err != nil {
+		return nil, err
+	}
+	manager.Label = b.label
+	b.manager = manager
+	// Policy was checked when the config loaded
+	policy, _ := eventqueue.ParsePolicy(d.cfg.EventQueuePolicy)
+	b.events = eventqueue.New(d.cfg.EventQueueSize, d.cfg.WorkerCount, policy)
+	b.events.Label = b.label
+	manager.Queue(b.events)
+	// Every shard's requests count against the bot's limits
+	b.queue = outbound.New(outbound.DefaultInterval, outbound.DefaultBurst, outbound.DefaultChannelInterval, outbound.DefaultChannelBurst)
+	var profile *chaos.Profile
+	if d.cfg.Chaos != "" {
+		p, err := chaos.Parse(d.cfg.Chaos)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("%sChaos profile on, Discord's API is made unreliable on purpose: %s\n", b.label, p)
+		profile = &p
+	}
+	for _, sess := range manager.Sessions {
+		base := logging.Transport(sess.Client.Transport)
+		// Failures are injected below the queue, where Discord's come back
+		if profile != nil {
+			base = profile.Transport(base)
+		}
+		sess.Client.Transport = b.queue.Transport(base)
+	}
+
+	b.handler = &handlers.Handler{
+		Name:    b.name,
+		Fixers:  d.pipeline,
+		Pool:    workerpool.New(d.cfg.WorkerCount, d.cfg.WorkerQueueSize),
+		Context: ctx,
+		Timeout: d.cfg.OperationTimeout,
+		Retry:   retry.Default,
+		Store:   d.store,
+		Tweets:  fxtwitter.New("", d.clients.Twitter(0)),
+		Mirror:  mirror.New(d.clients.Media(0)),
+		Events:  d.bus,
+		Stats:   d.collector,
+		Pending: pending.New(pendingTTL),
+		// Authors get as long to confirm a fix as others do to react for one
+		Confirmations: pending.New(pendingTTL),
+		// Each bot keeps its own log, as several may handle the same message
+		Decisions: explain.New(d.cfg.DecisionLogSize),
+		Others:    d.others,
+	}
+	b.handler.Retry.Reporter = events.Reporter{Bus: d.bus}
+	b.handler.Games = gamestores.New("", "", d.cfg.SteamCountry, d.clients.Other(0))
+	if d.cfg.TweetFallbackURL != "none" {
+		b.handler.Fallback = syndication.New(d.cfg.TweetFallbackURL, d.clients.Twitter(0))
+	}
+	if d.cfg.DuplicateWindow > 0 {
+		b.handler.Duplicates = dedupe.New(d.cfg.DuplicateWindow)
+	}
+	if d.cfg.FloodLimit > 0 {
+		b.handler.Flood = flood.New(d.cfg.FloodLimit, d.cfg.FloodCooldown)
+	}
+	b.handler.Outages = outages.New()
+	b.handler.Responses = responders.New(responders.DefaultCooldown)
+	b.handler.UploadLimit = func(guildID string) int64 {
+		if g, ok := b.manager.Guild(guildID); ok {
+			return mirror.LimitFor(g.PremiumTier)
+		}
+		return mirror.Limit
+	}
+	b.handler.Guilds = d.cfg.Guilds
+	b.handler.Leave = d.cfg.Unlisted == "leave"
+	if d.cfg.AbuseLimit > 0 {
+		b.handler.Abuse = abuse.New(d.cfg.AbuseLimit, d.cfg.AbuseMute)
+	}
+
+	backfill := func(ctx context.Context, s *discordgo.Session, guildID, channelID string, count int) (int, error) {
+		return b.handler.Backfill(ctx, s, s.State.User.ID, guildID, channelID, count)
+	}
+	diagnose := func(ctx context.Context, s *discordgo.Session, m *discordgo.Message) explain.Trace {
+		return b.handler.Diagnose(ctx, s, m)
+	}
+	registry := newRegistry(registryDeps{
+		store:      d.store,
+		pipeline:   d.pipeline,
+		started:    d.started,
+		guildCount: manager.GuildCount,
+		bus:        d.bus,
+		collector:  d.collector,
+		backfill:   backfill,
+		bin:        d.bin,
+		decisions:  b.handler.Decisions,
+		diagnose:   diagnose,
+		others:     b.handler.Others,
+		clients:    d.clients,
+		features:   func() []invite.Feature { return invite.Enabled(d.cfg) },
+		checker:    d.checker,
+		mode:       d.mode,
+		maintained: d.maintained,
+		modules:    d.modules,
+		monitor:    d.monitor,
+		guilds:     manager,
+		trends:     d.trends,
+	})
+	registry.AddComponent(commands.ConfirmPrefix, commands.NewConfirmRepost(func(s *discordgo.Session, messageID string, post bool) bool {
+		return b.handler.ConfirmFix(s, messageID, post)
+	}))
+	registry.Context = ctx
+	registry.Timeout = d.cfg.OperationTimeout
+	// Commands are off in guilds the profile doesn't allow
+	disabled := registry.Disabled
+	registry.Disabled = func(guildID, name string) bool {
+		return !d.cfg.GuildAllowed(guildID) || disabled(guildID, name)
+	}
+	registry.Ignore = func(guildID, userID string, roles []string) bool {
+		return config.Ignored(d.store, guildID, userID, roles)
+	}
+	registry.Muted = func(s *discordgo.Session, guildID, userID string) bool {
+		return b.handler.Muted(s, guildID, userID)
+	}
+	registry.Allowed = func(guildID, name string, roles []string) bool {
+		return access.Check(d.store, guildID, access.Command(name), roles)
+	}
+	registry.Trusted = func(guildID string, roles []string) bool {
+		return config.Trusted(d.store, guildID, roles)
+	}
+	registry.Owner = ownerLookup(manager.Sessions[0])
+	registry.Used = d.collector.RecordCommand
+	b.registry = registry
+
+	if d.register {
+		manager.AddUrgentHandler(registry.Ready)
+	}
+	manager.AddUrgentHandler(b.handler.Ready)
+	manager.AddUrgentHandler(func(s *discordgo.Session, r *discordgo.Ready) {
+		// A reconnect or restart forgets the presence
+		if d.mode.On() {
+			setMaintenanceStatus(s, true)
+		}
+	})
+	manager.AddEventHandler(b.handler.MessageCreateEvent, "MESSAGE_CREATE")
+	manager.AddHandler(b.handler.MessageReactionAdd)
+	manager.AddHandler(b.handler.ThreadCreate)
+	manager.AddHandler(b.handler.ThreadUpdate)
+	manager.AddHandler(b.handler.ThreadDelete)
+	manager.AddHandler(b.handler.ChannelUpdate)
+	manager.AddHandler(b.handler.ChannelDelete)
+	manager.AddHandler(b.handler.GuildMembersChunk)
+	manager.AddHandler(b.handler.GuildMemberAdd)
+	manager.AddHandler(b.handler.GuildMemberUpdate)
+	manager.AddHandler(b.handler.GuildMemberRemove)
+	manager.AddHandler(b.handler.GuildCreate)
+	manager.AddHandler(b.handler.GuildDelete)
+	manager.AddUrgentHandler(b.handler.Disconnect)
+	manager.AddUrgentHandler(b.handler.Resumed)
+	manager.AddUrgentHandler(registry.InteractionCreate)
+	return b, nil
+}
+
+// setIntents picks the bot's gateway intents, from the config or else from
+// what its handlers need, and turns off features missing theirs. It fails if
+// the bot couldn't fix links at all.
+func (b *bot) setIntents(cfg config.Config) error {
+	features := []intents.Feature{
+		{Name: "link fixing", Needs: discordgo.IntentsGuildMessages | discordgo.IntentMessageContent, Required: true},
+		{Name: "reaction mode, trigger emoji, starboards and translation flags", Needs: discordgo.IntentsGuildMessageReactions, Disable: func() {
+			// Guilds in reaction mode get their fixes right away instead
+			b.handler.Pending = nil
+		}},
+		{Name: "fixing links in DMs", Needs: discordgo.IntentsDirectMessages, Optional: true},
+		// Members are otherwise remembered from their messages and asked for when needed
+		{Name: "noticing nickname and avatar changes and welcoming new members", Needs: discordgo.IntentsGuildMembers, Optional: true},
+		// Without it the bot still fixes links in threads, it just doesn't join
+		// new ones, notices changed channels only once it forgets them, and
+		// only holds checks while its own connection is down
+		{Name: "joining new threads, keeping up with channel changes and waiting out server outages", Needs: discordgo.IntentsGuilds},
+	}
+	if b.handler.Voice != nil {
+		// Joining voice channels waits for the bot's own voice state
+		features = append(features, intents.Feature{Name: "voice notices", Needs: discordgo.IntentsGuildVoiceStates, Disable: func() {
+			b.handler.Voice = nil
+		}})
+	}
+
+	b.intents = intents.Default(features)
+	if len(cfg.Intents) > 0 {
+		// Load already checked the names
+		b.intents, _ = intents.Parse(cfg.Intents)
+	}
+	notes, err := intents.Advise(b.intents, features)
+	if err != nil {
+		return fmt.Errorf("checking intents for %s bot: %w", b.name, err)
+	}
+	for _, note := range notes {
+		log.Println(b.label + note)
+	}
+	b.manager.SetIntents(b.intents)
+	return nil
+}
+
+// shutdown lets queued events and work finish for up to timeout, then cancels whatever is still running.
+// Events go first, since handling them queues work on the pools.
+func shutdown(timeout time.Duration, cancel context.CancelFunc, queues []*eventqueue.Queue, pools ...*workerpool.Pool) {
+	drained := make(chan struct{})
+	go func() {
+		for _, queue := range queues {
+			queue.Stop()
+		}
+		for _, pool := range pools {
+			pool.Stop()
+		}
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+		log.Println("Shutdown timeout reached, cancelling in-flight work")
+		cancel()
+		<-drained
+	}
+}
+
+// serve runs an HTTP server on addr until ctx is done. name labels its log lines.
+func serve(ctx context.Context, name, addr string, handler http.Handler) {
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	log.Println(name, "listening on", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Error running %s: %v\n", strings.ToLower(name), err)
+	}
+}
+
+// openStore opens the data store named in the config.
+func openStore(cfg config.Config) (*storage.FileStore, error) {
+	store, err := storage.Open(cfg.DataFile)
+	if err != nil {
+		return nil, fmt.Errorf("opening data store: %w", err)
+	}
+	return store, nil
+}
+
+// privacyLookup reports whether a guild has privacy mode enabled.
+// A guild whose config can't be read is treated as private.
+func privacyLookup(st storage.Store) func(guildID string) bool {
+	return func(guildID string) bool {
+		cfg, err := config.LoadGuild(st, guildID)
+		return err != nil || cfg.Privacy
+	}
+}
+
+// gatewayWindow is how long ago a shard's last acknowledged heartbeat may be
+// for /status to count it as connected.
+const gatewayWindow = 2 * time.Minute
+
+// addHealthProbes adds the probes of the bot's parts, shown by /status and
+// GET /healthz.
+func addHealthProbes(monitor *health.Monitor, cfg config.Config, bots []*bot, store *storage.FileStore, sites *domains.Monitor, tweets *fxtwitter.Client, nitterInstances *nitter.Instances) {
+	monitor.Add("Gateway", func(context.Context) (health.State, string) {
+		var connected, total int
+		var latency time.Duration
+		for _, b := range bots {
+			c, t, l := b.manager.Connected(gatewayWindow)
+			connected, total, latency = connected+c, total+t, max(latency, l)
+		}
+		detail := fmt.Sprintf("%d of %d shards connected, %s heartbeat", connected, total, latency.Round(time.Millisecond))
+		switch {
+		case connected == 0:
+			return health.Down, detail
+		case connected < total || latency > time.Second:
+			return health.Degraded, detail
+		}
+		return health.OK, detail
+	})
+	monitor.Add("Storage", func(context.Context) (health.State, string) {
+		if err := store.Err(); err != nil {
+			return health.Down, "saving fails: " + err.Error()
+		}
+		return health.OK, "saving works"
+	})
+	monitor.Add("Fixing sites", func(context.Context) (health.State, string) {
+		checked, failing := sites.Sites()
+		switch {
+		case len(failing) > 0:
+			return health.Degraded, "down: " + strings.Join(failing, ", ")
+		case len(checked) == 0:
+			return health.OK, "no sites checked yet"
+		}
+		return health.OK, fmt.Sprintf("%d sites up", len(checked))
+	})
+	monitor.Add("fx API", func(context.Context) (health.State, string) {
+		failures := tweets.Failures()
+		return health.Level(failures, 1, 5), fmt.Sprintf("%d failed requests in a row", failures)
+	})
+	if len(cfg.NitterInstances) > 0 {
+		monitor.Add("Nitter", func(context.Context) (health.State, string) {
+			if url, ok := nitterInstances.Current(); ok {
+				return health.OK, "using " + url
+			}
+			return health.Degraded, "no instance is up"
+		})
+	}
+	monitor.Add("Scheduler", func(context.Context) (health.State, string) {
+		overdue, err := announcements.Overdue(store, time.Now().Add(-5*time.Minute))
+		if err != nil {
+			return health.Down, "reading announcements failed: " + err.Error()
+		}
+		return health.Level(overdue, 1, 10), fmt.Sprintf("%d announcements overdue", overdue)
+	})
+	for _, b := range bots {
+		name := "Queues"
+		if b.name != config.MainBot {
+			name += " (" + b.name + ")"
+		}
+		monitor.Add(name, func(context.Context) (health.State, string) {
+			events := b.events.Stats()
+			queued, capacity := b.handler.Pool.Len()
+			waiting := b.queue.Waiting()
+			state := max(health.Level(events.Length, events.Capacity/2, events.Capacity), health.Level(queued, capacity/2, capacity))
+			if events.Saturated {
+				state = health.Down
+			}
+			return state, fmt.Sprintf("%d events, %d jobs, %d requests waiting", events.Length, queued, waiting)
+		})
+	}
+}
+
+// newNotifier returns a Notifier sending alerts to the sinks cfg sets up:
+// the operator channel, posted in by session, a webhook and email.
+func newNotifier(cfg config.Config, clients *httpclient.Clients, session *discordgo.Session) *notify.Notifier {
+	notifier := notify.New()
+	// Severities were checked when loading the config
+	if cfg.OperatorChannel != "" {
+		min, _ := notify.ParseSeverity(cfg.OperatorSeverity)
+		notifier.Add("operator channel", min, notify.Discord{Session: session, ChannelID: cfg.OperatorChannel})
+	}
+	if cfg.AlertWebhook != "" {
+		min, _ := notify.ParseSeverity(cfg.WebhookSeverity)
+		notifier.Add("alert webhook", min, notify.Webhook{URL: cfg.AlertWebhook, Client: clients.Other(0)})
+	}
+	if len(cfg.AlertEmailTo) > 0 {
+		min, _ := notify.ParseSeverity(cfg.EmailSeverity)
+		notifier.Add("alert email", min, notify.Email{
+			Addr:     cfg.SMTPAddr,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.AlertEmailFrom,
+			To:       cfg.AlertEmailTo,
+		})
+	}
+	return notifier
+}
+
+// alertErrorInterval is the least time between two error alerts, so a burst
+// of failures sends one alert rather than flooding the sinks.
+const alertErrorInterval = time.Minute
+
+// alertErrors passes the bus's Error events on to notifier until ctx is done.
+// Errors within alertErrorInterval of the last alert are counted in the next.
+func alertErrors(ctx context.Context, bus *events.Bus, notifier *notify.Notifier) {
+	ch, unsubscribe := bus.Subscribe(64)
+	defer unsubscribe()
+	var last time.Time
+	skipped := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-ch:
+			if e.Type != events.Error {
+				continue
+			}
+			if time.Since(last) < alertErrorInterval {
+				skipped++
+				continue
+			}
+			text := e.Detail
+			if skipped > 0 {
+				text += fmt.Sprintf(" (and %d more errors)", skipped)
+			}
+			last, skipped = time.Now(), 0
+			notifier.Notify(ctx, notify.Alert{Severity: notify.Error, Source: "Error", Text: text})
+		}
+	}
+}
+
+// newTracer returns the tracer for the configured collector, or nil if there
+// is none.
+func newTracer(cfg config.Config, clients *httpclient.Clients) *tracing.Tracer {
+	if cfg.TraceEndpoint == "" {
+		return nil
+	}
+	return tracing.New(cfg.TraceEndpoint, cfg.TraceService, clients.Other(0))
+}
+
+// maintenanceStatus is the bot's custom status during maintenance.
+const maintenanceStatus = "⚠ maintenance"
+
+// setMaintenanceStatus shows on a session whether the bot is in maintenance.
+func setMaintenanceStatus(s *discordgo.Session, on bool) {
+	status := ""
+	if on {
+		status = maintenanceStatus
+	}
+	if err := s.UpdateCustomStatus(status); err != nil {
+		log.Println("Error updating the bot's status:", err)
+	}
+}
+
+// applicationOwner returns the ID of the user owning the application s
+// belongs to, or of its team's owner.
+func applicationOwner(s *discordgo.Session) (string, error) {
+	app, err := s.Application("@me")
+	if err != nil {
+		return "", err
+	}
+	if app.Team != nil {
+		return app.Team.OwnerID, nil
+	}
+	if app.Owner != nil {
+		return app.Owner.ID, nil
+	}
+	return "", nil
+}
+
+// ownerLookup returns a function reporting whether a user owns the
+// application s belongs to. The owner is looked up the first time it's needed.
+func ownerLookup(s *discordgo.Session) func(userID string) bool {
+	var (
+		mu    sync.Mutex
+		owner string
+	)
+	return func(userID string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if owner == "" {
+			var err error
+			if owner, err = applicationOwner(s); err != nil {
+				log.Println("Error finding the bot's owner:", err)
+				return false
+			}
+		}
+		return owner != "" && owner == userID
+	}
+}
+
+// notifyOwner returns a function that sends a DM about a newer release to the
+// owner of the application s belongs to.
+func notifyOwner(s *discordgo.Session) func(ctx context.Context, r updates.Release) {
+	return func(ctx context.Context, r updates.Release) {
+		owner, err := applicationOwner(s)
+		if err != nil {
+			log.Println("Error finding the bot's owner:", err)
+			return
+		}
+		if owner == "" {
+			return
+		}
+		channel, err := s.UserChannelCreate(owner, discordgo.WithContext(ctx))
+		if err != nil {
+			log.Println("Error opening a DM with the bot's owner:", err)
+			return
+		}
+		if _, err := s.ChannelMessageSend(channel.ID, r.Notice(version.Version), discordgo.WithContext(ctx)); err != nil {
+			log.Println("Error sending the update notice:", err)
+		}
+	}
+}
+
+// newPhishingChecker returns the checker for the configured blocklists, or nil
+// if there are none.
+func newPhishingChecker(cfg config.Config, clients *httpclient.Clients) (*phishing.Checker, error) {
+	if cfg.PhishingListFile == "" && cfg.SafeBrowsingKey == "" {
+		return nil, nil
+	}
+	checker, err := phishing.New(cfg.PhishingListFile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SafeBrowsingKey != "" {
+		checker.SafeBrowsing = phishing.NewSafeBrowsing(cfg.SafeBrowsingKey, "", clients.Other(0))
+	}
+	return checker, nil
+}
+
+// newPipeline builds the link fixers in the order they run. sites, budgets,
+// tweets and songs may be nil to consider every custom site up, to let guilds
+// look up as much as they like and to never look tweets or songs up.
+func newPipeline(cfg config.Config, store storage.Store, featureFlags *flags.Flags, nitterInstances *nitter.Instances, sites *domains.Monitor, budgets *budget.Budget, tweets *fxtwitter.Client, songs *odesli.Client) fixers.Pipeline {
+	return fixers.Pipeline{
+		fixers.Twitter{Flags: featureFlags, Store: store, Nitter: nitterInstances, Domains: sites, Budget: budgets, EmbedThreshold: cfg.EmbedThreshold, Tweets: tweets},
+		fixers.Twitch{Proxy: cfg.TwitchClipProxy, Flags: featureFlags},
+		fixers.Music{Store: store, Songs: songs, Budget: budgets},
+		fixers.Custom{Store: store},
+		fixers.Cleaner{},
+	}
+}
+
+// newPurger returns the purger deleting guilds' and users' data from every
+// bucket that holds any. Canonical links are shared by every guild and
+// maintenance holds only message IDs for a few minutes, so they're left out.
+func newPurger(store storage.Store, collector *stats.Collector, counter *trends.Counter) *purge.Purger {
+	return &purge.Purger{
+		Store: store,
+		Forgetters: map[string]purge.Forgetter{
+			config.GuildBucket:          config.Forget,
+			overlap.Bucket:              overlap.Forget,
+			trends.Bucket:               trends.Forget,
+			feeds.Bucket:                feeds.Forget,
+			digest.Bucket:               digest.Forget,
+			rolemenus.Bucket:            rolemenus.Forget,
+			announcements.Bucket:        announcements.Forget,
+			trash.Bucket:                trash.Forget,
+			stats.Bucket:                stats.Forget,
+			handlers.StarboardBucket:    handlers.ForgetStarboard,
+			handlers.ExpiryBucket:       handlers.ForgetExpiries,
+			handlers.PreviewCheckBucket: handlers.ForgetPreviewChecks,
+			handlers.ThreadBucket:       handlers.ForgetThreads,
+		},
+		Stats:  collector,
+		Trends: counter,
+	}
+}
+
+// registryDeps are what newRegistry builds the commands from. Only store,
+// started and features are needed when the registry is only used for its
+// definitions; the others may be left out.
+type registryDeps struct {
+	store    storage.Store
+	pipeline fixers.Pipeline
+	started  time.Time
+	// guildCount returns how many guilds the bot is in, for /about.
+	guildCount func() int
+	bus        *events.Bus
+	collector  *stats.Collector
+	backfill   commands.BackfillFunc
+	bin        *trash.Bin
+	decisions  *explain.Log
+	diagnose   commands.DiagnoseFunc
+	others     *overlap.Detector
+	// clients makes the HTTP clients the commands fetching things use.
+	clients *httpclient.Clients
+	// features returns the features turned on, for /invite.
+	features   func() []invite.Feature
+	checker    *phishing.Checker
+	mode       *maintenance.Mode
+	maintained func(on bool, held []maintenance.Held)
+	modules    *lifecycle.Manager
+	monitor    *health.Monitor
+	guilds     fleet.Source
+	trends     *trends.Counter
+}
+
+// newRegistry builds the registry of every slash command the bot offers.
+func newRegistry(d registryDeps) *commands.Registry {
+	registry := commands.NewRegistry()
+	registry.Disabled = func(guildID, name string) bool {
+		cfg, err := config.LoadGuild(d.store, guildID)
+		return err == nil && !cfg.CommandEnabled(name)
+	}
+	registry.Add(commands.NewConfig(d.store, registry.Pager, d.pipeline.Names(), domains.NewChecker(d.clients.Links(0))))
+	registry.Add(commands.NewClean())
+	registry.Add(commands.NewPurge(d.store))
+	registry.Add(commands.NewSlowmode(d.store))
+	registry.Add(commands.NewSetup(d.store))
+	registry.Add(commands.NewPause(d.store))
+	registry.Add(commands.NewResume(d.store))
+	registry.Add(commands.NewFixLinks(d.pipeline))
+	registry.Add(commands.NewFixLink(d.pipeline))
+	registry.Add(commands.NewMedia(fxtwitter.New("", d.clients.Twitter(0))))
+	registry.Add(commands.NewAnnounce(d.store))
+	registry.Add(commands.NewFeed(d.store, feeds.NewFetcher(d.clients.Links(20*time.Second))))
+	registry.Add(commands.NewWelcome(d.store))
+	registry.Add(commands.NewRoleMenu(d.store))
+	registry.Add(commands.NewAutoResponse(d.store))
+	registry.Add(commands.NewFixerBots(d.store, d.others))
+	registry.Add(commands.NewFilters(d.store))
+	registry.Add(commands.NewQuotes(d.store))
+	registry.Add(commands.NewGames(d.store))
+	registry.Add(commands.NewMusic(d.store))
+	registry.Add(commands.NewThreads(d.store))
+	registry.Add(commands.NewFeedBots(d.store))
+	registry.Add(commands.NewFixReaction(d.store))
+	registry.Add(commands.NewPrivacy(d.store, newPurger(d.store, d.collector, d.trends)))
+	registry.Add(commands.NewBackfill(d.store, d.backfill))
+	registry.Add(commands.NewScanLinks(d.checker))
+	registry.Add(commands.NewDeleted(d.bin, registry.Pager))
+	registry.Add(commands.NewExplain(d.decisions))
+	registry.Add(commands.NewWhyNotFixed(d.diagnose))
+	registry.Add(commands.NewSteal(d.clients.Discord(10 * time.Second)))
+	registry.Add(commands.NewStealFromMessage(d.clients.Discord(10 * time.Second)))
+	registry.AddComponent(commands.RemovePrefix, commands.NewRemoveRepost(d.bus))
+	registry.AddComponent(commands.RoleMenuPrefix, commands.NewRoleMenuClick(d.store))
+	registry.Add(commands.NewLeaderboard(d.store, d.collector, registry.Pager))
+	registry.Add(commands.NewStats(d.store, d.collector, registry.Pager))
+	registry.Add(commands.NewActivity(d.collector))
+	registry.Add(commands.NewTrends(d.store, d.trends))
+	registry.Add(commands.NewPoll(d.store, d.trends))
+	registry.Add(commands.NewHelp(d.store, registry))
+	registry.Add(commands.NewAbout(d.started, d.guildCount))
+	registry.Add(commands.NewInvite(d.features))
+	registry.Add(commands.NewMaintenance(d.mode, d.maintained))
+	registry.Add(commands.NewModules(d.modules))
+	registry.Add(commands.NewStatus(d.monitor))
+	registry.Add(commands.NewGuilds(d.store, d.guilds, registry.Pager))
+	registry.Add(commands.NewLogLevel())
+	return registry
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/service"
+)
+
+// serviceWatchdog is how long the bot may go without any shard connected
+// before systemd restarts it.
+const serviceWatchdog = 5 * time.Minute
+
+// installService has the bot run under a supervisor that starts it at boot
+// and restarts it when it fails: a systemd unit, or on Windows a service.
+func installService(args []string) error {
+	fs := flag.NewFlagSet("install-service", flag.ExitOnError)
+	name := fs.String("name", "discord-bot", "name of the service")
+	user := fs.String("user", "", "user the systemd unit runs the bot as, empty for root")
+	output := fs.String("output", "", "file to write the systemd unit to, - to print it (default /etc/systemd/system/<name>.service)")
+	overwrite := fs.Bool("force", false, "replace the unit file if it already exists")
+	fs.Parse(args)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	description := "Discord bot fixing link previews"
+	if runtime.GOOS == "windows" {
+		if err := service.Install(*name, description, exe, "run"); err != nil {
+			return err
+		}
+		fmt.Printf("Registered the %s service, which reads the .env file in %s. Start it with `sc start %s`.\n", *name, filepath.Dir(exe), *name)
+		return nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	unit := service.Unit{
+		Description: description,
+		Exec:        exe,
+		Dir:         dir,
+		User:        *user,
+		// Give queued work its time to finish, then some to disconnect
+		StopTimeout: config.Defaults().ShutdownTimeout + 15*time.Second,
+		Watchdog:    serviceWatchdog,
+	}.Render()
+	if *output == "-" {
+		fmt.Print(unit)
+		return nil
+	}
+	path := *output
+	if path == "" {
+		path = "/etc/systemd/system/" + *name + ".service"
+	}
+	if exists(path) && !*overwrite {
+		return fmt.Errorf("%s already exists; run `bot install-service -force` to replace it", path)
+	}
+	if err := os.WriteFile(path, []byte(unit), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s. Start the bot with `systemctl daemon-reload && systemctl enable --now %s`.\n", path, *name)
+	return nil
+}
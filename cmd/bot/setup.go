@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/invite"
+	"go-discord-bot/internal/setup"
+)
+
+// tokenAttempts is how many times bot init asks for a token that doesn't work.
+const tokenAttempts = 3
+
+// initEnv asks for the settings the bot needs to run, checks the token with
+// Discord and writes them to a .env file, then prints the bot's invite link.
+func initEnv(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	path := fs.String("file", ".env", "file to write the settings to")
+	overwrite := fs.Bool("force", false, "replace the file if it already exists")
+	fs.Parse(args)
+
+	// Fail before asking anything rather than after
+	if exists(*path) && !*overwrite {
+		return fmt.Errorf("%s already exists; edit it, or run `bot init -force` to start over", *path)
+	}
+
+	p := setup.NewPrompter(os.Stdin, os.Stdout)
+	fmt.Println("Create an application at https://discord.com/developers/applications, add a bot to it and copy the bot's token.")
+	var token string
+	var app *discordgo.Application
+	for attempt := 1; ; attempt++ {
+		answer, err := p.Ask("Bot token", "")
+		if err != nil {
+			return err
+		}
+		if app, err = checkToken(answer); err == nil {
+			token = answer
+			break
+		}
+		fmt.Println("That token doesn't work:", err)
+		if attempt == tokenAttempts {
+			return errors.New("no working token given")
+		}
+	}
+	fmt.Printf("Found %s.\n", app.Name)
+
+	dataFile, err := p.Ask("File to keep the bot's data in", config.Defaults().DataFile)
+	if err != nil {
+		return err
+	}
+	operator, err := p.Ask("ID of a channel for warnings meant for you (empty for none)", "")
+	if err != nil {
+		return err
+	}
+
+	content, err := setup.Render([]setup.Setting{
+		{Key: "DISCORD_BOT_TOKEN", Comment: "The bot's token. Keep it secret: anyone who has it can act as the bot.", Value: token},
+		{Key: "DATA_FILE", Comment: "Where guild settings and the bot's other state are kept.", Value: dataFile},
+		{Key: "OPERATOR_CHANNEL_ID", Comment: "A channel the bot posts warnings for whoever runs it to, such as possible leaks.", Value: operator},
+		{Key: "GATEWAY_INTENTS", Comment: "Gateway intents to connect with, by default the ones the enabled features need.\nAdd direct_messages to the defaults to fix links in DMs."},
+		{Key: "EXTRA_BOT_TOKENS", Comment: "More bots to run from this process, as name=token pairs, such as a staging bot."},
+		{Key: "LOG_FILE", Comment: "A file to write logs to as well as the console."},
+	})
+	if err != nil {
+		return err
+	}
+	if err := setup.Write(*path, content, *overwrite); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nWrote %s.\n", *path)
+	fmt.Println("Turn on the Message Content intent under Bot → Privileged Gateway Intents in the developer portal, then invite the bot with:")
+	fmt.Println(invite.URL(app.ID, invite.Permissions(invite.Enabled(config.Defaults()))))
+	fmt.Println("\nStart it with `bot run`.")
+	return nil
+}
+
+// checkToken makes sure token belongs to a bot and returns its application.
+func checkToken(token string) (*discordgo.Application, error) {
+	if token == "" {
+		return nil, errors.New("it's empty")
+	}
+	sess, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := sess.User("@me")
+	if err != nil {
+		return nil, err
+	}
+	if !user.Bot {
+		return nil, errors.New("it isn't a bot's token")
+	}
+	app, err := sess.Application("@me")
+	if err != nil {
+		// A bot's user ID is its application's ID
+		return &discordgo.Application{ID: user.ID, Name: user.Username}, nil
+	}
+	return app, nil
+}
+
+// exists reports whether there's a file at path.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
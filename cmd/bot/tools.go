@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/flags"
+	"go-discord-bot/internal/fleet"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/handlers"
+	"go-discord-bot/internal/invite"
+	"go-discord-bot/internal/nitter"
+	"go-discord-bot/internal/preview"
+	"go-discord-bot/internal/proxy"
+	"go-discord-bot/internal/replay"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/version"
+	"go-discord-bot/internal/workerpool"
+)
+
+// registerCommands registers the slash commands over REST without connecting to the gateway.
+func registerCommands(args []string) error {
+	fs := flag.NewFlagSet("register-commands", flag.ExitOnError)
+	guild := fs.String("guild", "", "register in this guild only instead of globally")
+	printInvite := fs.Bool("invite", false, "print the link that adds the bot with the permissions its enabled features need, instead of registering")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	sess, err := newRESTSession(cfg)
+	if err != nil {
+		return err
+	}
+	features := func() []invite.Feature { return invite.Enabled(cfg) }
+	if *printInvite {
+		// A bot's user ID is its application's ID
+		fmt.Println(invite.URL(sess.State.User.ID, invite.Permissions(features())))
+		return nil
+	}
+
+	// Guild registrations leave out the commands the guild turned off
+	store := storage.Store(storage.NewMemory())
+	if *guild != "" {
+		if store, err = storage.Open(cfg.DataFile); err != nil {
+			return fmt.Errorf("opening data store: %w", err)
+		}
+	}
+	registry := newRegistry(registryDeps{store: store, started: time.Now(), features: features})
+	if err := registry.Register(sess, *guild); err != nil {
+		return fmt.Errorf("registering commands: %w", err)
+	}
+	fmt.Printf("registered %d commands\n", len(registry.GuildDefinitions(*guild)))
+	return nil
+}
+
+// migrate applies pending storage migrations.
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "list pending migrations without applying them")
+	fs.Parse(args)
+
+	store, err := storage.Open(config.Defaults().DataFile)
+	if err != nil {
+		return fmt.Errorf("opening data store: %w", err)
+	}
+
+	if *dryRun {
+		pending, err := storage.Pending(store)
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			fmt.Printf("pending: %d %s\n", m.Version, m.Name)
+		}
+		fmt.Printf("%d migrations pending\n", len(pending))
+		return nil
+	}
+
+	applied, err := storage.Migrate(store)
+	for _, m := range applied {
+		fmt.Printf("applied: %d %s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema is at version %d\n", storage.LatestVersion())
+	return nil
+}
+
+// fix runs text through the link fixers offline and prints the result,
+// as if it had been posted in a channel with no working embeds.
+func fix(args []string) error {
+	fs := flag.NewFlagSet("fix", flag.ExitOnError)
+	guild := fs.String("guild", "", "apply this guild's custom rewrite rules from the data store")
+	fs.Parse(args)
+
+	text := strings.Join(fs.Args(), " ")
+	if text == "" {
+		return errors.New("usage: bot fix [-guild ID] <url or message text>")
+	}
+
+	var store storage.Store = storage.NewMemory()
+	if *guild != "" {
+		fileStore, err := storage.Open(config.Defaults().DataFile)
+		if err != nil {
+			return fmt.Errorf("opening data store: %w", err)
+		}
+		store = fileStore
+	}
+
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: *guild, Content: text}}
+	cfg := config.Defaults()
+	featureFlags, err := flags.Load(cfg.FlagsFile)
+	if err != nil {
+		return fmt.Errorf("loading feature flags: %w", err)
+	}
+	result := newPipeline(cfg, store, featureFlags, nitter.New(cfg.NitterInstances, nil), nil, nil, nil, nil).Apply(context.Background(), m)
+	if result == text {
+		fmt.Fprintln(os.Stderr, "no change")
+	}
+	fmt.Println(result)
+	return nil
+}
+
+// replayFixture feeds a captured fixture of gateway payloads through the message
+// handler against a mock Discord and prints what the bot decided to do.
+func replayFixture(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	useStore := fs.Bool("store", false, "use the guild settings in the data store instead of the fixture's")
+	delay := fs.Duration("preview-delay", handlers.DefaultPreviewDelay, "how long to wait for Discord's embeds before previewing links")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: bot replay [-store] [-preview-delay D] <fixture.json>")
+	}
+
+	f, err := replay.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	store, err := f.Store()
+	if err != nil {
+		return err
+	}
+	cfg := config.Defaults()
+	if *useStore {
+		if store, err = storage.Open(cfg.DataFile); err != nil {
+			return fmt.Errorf("opening data store: %w", err)
+		}
+	}
+	featureFlags, err := flags.Load(cfg.FlagsFile)
+	if err != nil {
+		return fmt.Errorf("loading feature flags: %w", err)
+	}
+	h := &handlers.Handler{
+		Fixers:       newPipeline(cfg, store, featureFlags, nitter.New(cfg.NitterInstances, nil), nil, nil, nil, nil),
+		Pool:         workerpool.New(1, cfg.WorkerQueueSize),
+		Store:        store,
+		Tweets:       fxtwitter.New("", nil),
+		Previews:     preview.New(nil),
+		PreviewDelay: *delay,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	decisions, err := replay.Run(ctx, h, f)
+	if err != nil {
+		return err
+	}
+	if len(decisions) == 0 {
+		fmt.Println("no action")
+	}
+	for _, d := range decisions {
+		fmt.Println(d)
+	}
+	if f.Expected != nil && !slices.Equal(decisions, f.Expected) {
+		return fmt.Errorf("decisions differ from the fixture's expected %q", f.Expected)
+	}
+	return nil
+}
+
+// runFleet applies one change to many guilds at once: an announcement, new
+// settings, or a module turned on or off.
+func runFleet(args []string) error {
+	usage := errors.New("usage: bot fleet announce|set|module [-guilds IDs] [-dry-run] <message> | <settings JSON> | <module> on|off")
+	if len(args) == 0 {
+		return usage
+	}
+	fs := flag.NewFlagSet("fleet "+args[0], flag.ExitOnError)
+	guilds := fs.String("guilds", "", "comma-separated guild IDs to target (default: all guilds)")
+	dryRun := fs.Bool("dry-run", false, "check every guild and show the plan without changing anything")
+	fs.Parse(args[1:])
+	rest := fs.Args()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	var op fleet.Operation
+	switch args[0] {
+	case "announce":
+		message := strings.Join(rest, " ")
+		if message == "" {
+			return usage
+		}
+		op = fleet.NewAnnouncement(message)
+	case "set", "module":
+		if len(rest) == 0 || args[0] == "module" && (len(rest) != 2 || rest[1] != "on" && rest[1] != "off") {
+			return usage
+		}
+		store, err := storage.Open(cfg.DataFile)
+		if err != nil {
+			return fmt.Errorf("opening data store: %w", err)
+		}
+		if args[0] == "set" {
+			op, err = fleet.NewSettingChange(store, json.RawMessage(strings.Join(rest, " ")))
+		} else {
+			op, err = fleet.NewModuleToggle(store, rest[0], rest[1] == "on")
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return usage
+	}
+
+	sess, err := newSession(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	results, err := fleet.Run(ctx, sess, op, *guilds, *dryRun)
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			fmt.Printf("%s (%s): %v\n", r.GuildName, r.GuildID, r.Err)
+		case r.Done:
+			fmt.Printf("%s (%s): done, %s\n", r.GuildName, r.GuildID, r.Plan)
+		default:
+			fmt.Printf("%s (%s): will %s\n", r.GuildName, r.GuildID, r.Plan)
+		}
+	}
+	if err == nil && *dryRun {
+		fmt.Printf("dry run: %d guilds passed their checks\n", len(results))
+	}
+	return err
+}
+
+// announce posts a message to the system channel of many guilds at once,
+// like bot fleet announce.
+func announce(args []string) error {
+	return runFleet(append([]string{"announce"}, args...))
+}
+
+// newSession creates a session for the main bot, reaching Discord through the
+// configured proxy.
+func newSession(cfg config.Config) (*discordgo.Session, error) {
+	sess, err := discordgo.New("Bot " + cfg.Token)
+	if err != nil {
+		return nil, fmt.Errorf("creating Discord session: %w", err)
+	}
+	// Load has checked the proxy settings already
+	routes, _ := cfg.Proxies()
+	proxy.Session(sess, routes.Discord)
+	return sess, nil
+}
+
+// newRESTSession creates a session for REST calls only, with State.User filled in.
+func newRESTSession(cfg config.Config) (*discordgo.Session, error) {
+	sess, err := newSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+	user, err := sess.User("@me")
+	if err != nil {
+		return nil, fmt.Errorf("fetching bot user: %w", err)
+	}
+	sess.State.User = user
+	return sess, nil
+}
+
+// printVersion prints the build version and Go runtime.
+func printVersion(_ []string) error {
+	fmt.Println(version.String(), runtime.Version())
+	return nil
+}
@@ -3,9 +3,12 @@ module go-discord-bot
 go 1.23.0
 
 require (
-	github.com/bwmarrin/discordgo v0.28.1 // direct
-	github.com/gorilla/websocket v1.4.2 // indirect
+	// internal/shards sets Session's unexported sessionID, sequence and gateway
+	// as of v0.29.0; TestSessionFields fails if an upgrade changes them.
+	github.com/bwmarrin/discordgo v0.29.0 // direct
+	github.com/gorilla/websocket v1.4.2 // direct
 	github.com/joho/godotenv v1.5.1 // direct
-	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b // indirect
-	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68 // indirect
+	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68 // direct
 )
+
+require golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b // indirect
@@ -0,0 +1,145 @@
+// Package abuse detects users triggering the bot far more often than anyone
+// normally would, with commands or links to fix, so the bot can stop
+// answering them for a while.
+package abuse
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Window is how long triggers are counted for before the count starts over.
+const Window = time.Minute
+
+// sharedTimeout is how long a call to the Shared store may take.
+const sharedTimeout = 2 * time.Second
+
+// Shared is a store several replicas of the bot share, such as Redis, so
+// they count a user's triggers together.
+type Shared interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// user tracks how often one user triggers the bot in one guild.
+type user struct {
+	windowStart time.Time
+	count       int
+	mutedUntil  time.Time
+}
+
+// Detector counts how often each user triggers the bot in each guild, and
+// mutes users who trigger it more than Limit times within a Window for Mute.
+// A nil Detector mutes nobody.
+type Detector struct {
+	// Shared, if not nil, counts triggers and keeps mutes for every replica
+	// instead. When it fails the detector counts by itself.
+	Shared Shared
+
+	limit int
+	mute  time.Duration
+	now   func() time.Time
+
+	mu    sync.Mutex
+	users map[string]*user
+}
+
+// New returns a Detector muting users for mute once they trigger the bot more
+// than limit times in a minute.
+func New(limit int, mute time.Duration) *Detector {
+	return &Detector{limit: limit, mute: mute, now: time.Now, users: make(map[string]*user)}
+}
+
+// Mute returns how long users are muted for.
+func (d *Detector) Mute() time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.mute
+}
+
+// Observe records a user triggering the bot in a guild and reports whether
+// the bot should still answer them. tripped is true only for the trigger that
+// muted them, so callers can tell moderators once per mute.
+func (d *Detector) Observe(guildID, userID string) (allowed, tripped bool) {
+	if d == nil || guildID == "" {
+		return true, false
+	}
+	key := guildID + "/" + userID
+	if d.Shared != nil {
+		allowed, tripped, err := d.observeShared(key)
+		if err == nil {
+			return allowed, tripped
+		}
+		log.Println("Error counting shared triggers:", err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	u := d.users[key]
+	if u == nil {
+		u = &user{windowStart: now}
+		d.users[key] = u
+	}
+	if now.Before(u.mutedUntil) {
+		return false, false
+	}
+	if now.Sub(u.windowStart) >= Window {
+		u.windowStart, u.count = now, 0
+	}
+	u.count++
+	if u.count > d.limit {
+		u.mutedUntil = now.Add(d.mute)
+		u.windowStart, u.count = u.mutedUntil, 0
+		return false, true
+	}
+	return true, false
+}
+
+// observeShared does the work of Observe with the Shared store.
+func (d *Detector) observeShared(key string) (allowed, tripped bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sharedTimeout)
+	defer cancel()
+	if _, muted, err := d.Shared.Get(ctx, "abuse:mute:"+key); err != nil || muted {
+		return false, false, err
+	}
+	count, err := d.Shared.Incr(ctx, "abuse:count:"+key, Window)
+	if err != nil || count <= int64(d.limit) {
+		return true, false, err
+	}
+	// Only the replica that mutes them trips, so moderators are told once
+	tripped, err = d.Shared.SetNX(ctx, "abuse:mute:"+key, "1", d.mute)
+	return false, tripped, err
+}
+
+// Prune forgets users whose count and mute have run out, and returns how
+// many it forgot.
+func (d *Detector) Prune(now time.Time) int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pruned := 0
+	for key, u := range d.users {
+		if now.Sub(u.windowStart) >= Window && !now.Before(u.mutedUntil) {
+			delete(d.users, key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many users the detector is tracking.
+func (d *Detector) Len() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.users)
+}
@@ -0,0 +1,108 @@
+package abuse
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryShared is a Shared store in memory that never expires keys.
+type memoryShared struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func (m *memoryShared) Get(_ context.Context, key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.keys[key]
+	return v, ok, nil
+}
+
+func (m *memoryShared) SetNX(_ context.Context, key, value string, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[key]; ok {
+		return false, nil
+	}
+	m.keys[key] = value
+	return true, nil
+}
+
+func (m *memoryShared) Incr(_ context.Context, key string, _ time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, _ := strconv.ParseInt(m.keys[key], 10, 64)
+	m.keys[key] = strconv.FormatInt(n+1, 10)
+	return n + 1, nil
+}
+
+func TestDetector(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := New(3, 10*time.Minute)
+	d.now = func() time.Time { return clock }
+
+	testCases := []struct {
+		name     string
+		advance  time.Duration
+		guild    string
+		user     string
+		triggers int
+		allowed  bool
+		tripped  bool
+	}{
+		{name: "Under the limit", guild: "guild", user: "spammer", triggers: 3, allowed: true},
+		{name: "Over the limit mutes", guild: "guild", user: "spammer", triggers: 1, allowed: false, tripped: true},
+		{name: "Others unaffected", guild: "guild", user: "other", triggers: 1, allowed: true},
+		{name: "Other guilds unaffected", guild: "elsewhere", user: "spammer", triggers: 1, allowed: true},
+		{name: "Still muted", advance: 5 * time.Minute, guild: "guild", user: "spammer", triggers: 1, allowed: false},
+		{name: "Unmuted after the mute", advance: 5 * time.Minute, guild: "guild", user: "spammer", triggers: 3, allowed: true},
+		{name: "New window resets the count", advance: time.Minute, guild: "guild", user: "spammer", triggers: 3, allowed: true},
+		{name: "DMs are never muted", user: "spammer", triggers: 10, allowed: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock = clock.Add(tc.advance)
+			var allowed, tripped bool
+			for range tc.triggers {
+				allowed, tripped = d.Observe(tc.guild, tc.user)
+			}
+			if allowed != tc.allowed || tripped != tc.tripped {
+				t.Errorf("Observe = %v, %v; want %v, %v", allowed, tripped, tc.allowed, tc.tripped)
+			}
+		})
+	}
+
+	d.Prune(clock.Add(Window))
+	if d.Len() != 0 {
+		t.Errorf("Prune left %d users; want none", d.Len())
+	}
+}
+
+func TestDetectorShared(t *testing.T) {
+	shared := &memoryShared{keys: make(map[string]string)}
+	first, second := New(3, 10*time.Minute), New(3, 10*time.Minute)
+	first.Shared, second.Shared = shared, shared
+
+	for _, d := range []*Detector{first, second, first} {
+		if allowed, _ := d.Observe("guild", "spammer"); !allowed {
+			t.Fatal("muted under the limit")
+		}
+	}
+	if allowed, tripped := second.Observe("guild", "spammer"); allowed || !tripped {
+		t.Errorf("Observe over the limit across replicas = %v, %v; want muted and tripped", allowed, tripped)
+	}
+	if allowed, tripped := first.Observe("guild", "spammer"); allowed || tripped {
+		t.Errorf("Observe on the other replica = %v, %v; want muted without tripping again", allowed, tripped)
+	}
+}
+
+func TestNilDetector(t *testing.T) {
+	var d *Detector
+	if allowed, _ := d.Observe("guild", "user"); !allowed {
+		t.Errorf("nil detector muted a user")
+	}
+}
@@ -0,0 +1,70 @@
+// Package access decides who may use the bot's features in a guild. Admins
+// can limit a feature, such as having links fixed or one of the commands, to
+// members with certain roles. Features nobody limited are open to everyone,
+// and nothing is limited in DMs.
+package access
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// Features that can be limited besides commands.
+const (
+	// Fixing is having links in one's messages fixed automatically, or with
+	// the trigger emoji.
+	Fixing = "fixing"
+	// Mirror is having copies of the media of one's tweets uploaded.
+	Mirror = "mirror"
+)
+
+// Features are the features besides commands that can be limited, in display order.
+var Features = []string{Fixing, Mirror}
+
+// commandName matches the names of slash commands.
+var commandName = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Command returns the feature name of the slash command called name.
+func Command(name string) string {
+	return "/" + name
+}
+
+// Parse returns the feature named by s, such as "mirror" or "/trends", and
+// reports whether it is one.
+func Parse(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if slices.Contains(Features, s) {
+		return s, true
+	}
+	if name, ok := strings.CutPrefix(s, "/"); ok && commandName.MatchString(name) {
+		return s, true
+	}
+	return "", false
+}
+
+// Allowed reports whether a member with roles may use feature in a guild with
+// the settings cfg.
+func Allowed(cfg config.Guild, feature string, roles []string) bool {
+	allowed := cfg.FeatureRoles[feature]
+	if len(allowed) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(roles, func(role string) bool { return slices.Contains(allowed, role) })
+}
+
+// Check is Allowed for the settings of the guild saved in st. Features are
+// open in DMs and in guilds whose settings can't be read.
+func Check(st storage.Store, guildID, feature string, roles []string) bool {
+	if st == nil || guildID == "" {
+		return true
+	}
+	cfg, err := config.LoadGuild(st, guildID)
+	if err != nil {
+		return true
+	}
+	return Allowed(cfg, feature, roles)
+}
@@ -0,0 +1,61 @@
+package access
+
+import (
+	"testing"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+func TestAllowed(t *testing.T) {
+	cfg := config.Guild{FeatureRoles: map[string][]string{Mirror: {"artists"}, Command("trends"): {"mods", "artists"}}}
+	testCases := []struct {
+		name     string
+		feature  string
+		roles    []string
+		expected bool
+	}{
+		{name: "Open feature", feature: Fixing, expected: true},
+		{name: "Limited feature with the role", feature: Mirror, roles: []string{"fans", "artists"}, expected: true},
+		{name: "Limited feature without the role", feature: Mirror, roles: []string{"fans"}, expected: false},
+		{name: "Limited feature without roles", feature: Mirror, expected: false},
+		{name: "Limited command", feature: Command("trends"), roles: []string{"mods"}, expected: true},
+		{name: "Other command", feature: Command("stats"), expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Allowed(cfg, tc.feature, tc.roles); got != tc.expected {
+				t.Errorf("Allowed(%s, %v) = %v; want %v", tc.feature, tc.roles, got, tc.expected)
+			}
+		})
+	}
+
+	st := storage.NewMemory()
+	config.SaveGuild(st, "guild", cfg)
+	if Check(st, "guild", Mirror, nil) || !Check(st, "", Mirror, nil) || !Check(nil, "guild", Mirror, nil) {
+		t.Error("Check limits a feature outside the guild that limited it, or not inside it")
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{input: "fixing", expected: Fixing, ok: true},
+		{input: " Mirror ", expected: Mirror, ok: true},
+		{input: "/trends", expected: "/trends", ok: true},
+		{input: "trends", ok: false},
+		{input: "/", ok: false},
+		{input: "/two words", ok: false},
+	}
+
+	for _, tc := range testCases {
+		got, ok := Parse(tc.input)
+		if got != tc.expected || ok != tc.ok {
+			t.Errorf("Parse(%q) = %q, %v; want %q, %v", tc.input, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
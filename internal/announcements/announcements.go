@@ -0,0 +1,237 @@
+// Package announcements posts the messages admins schedule, such as event
+// reminders or the rules, to their channels on a cron-like schedule.
+package announcements
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding announcements, keyed by guild ID and
+// announcement ID.
+const Bucket = "announcements"
+
+const (
+	// MaxPerGuild is how many announcements one guild may schedule.
+	MaxPerGuild = 25
+	// MaxChannels is how many channels one announcement may be posted in.
+	MaxChannels = 5
+	// MaxLength caps the length of an announcement's message.
+	MaxLength = 2000
+)
+
+// storeMu serializes read-modify-write updates to announcements, so one
+// being posted can't bring back one removed meanwhile.
+var storeMu sync.Mutex
+
+// Announcement is a message posted to channels on a schedule.
+type Announcement struct {
+	ID         string   `json:"id"`
+	GuildID    string   `json:"guild_id"`
+	ChannelIDs []string `json:"channel_ids"`
+	// Schedule is the cron spec, see ParseSchedule, read in the guild's time zone.
+	Schedule string `json:"schedule"`
+	Message  string `json:"message"`
+	// Next is when the announcement is posted next.
+	Next time.Time `json:"next"`
+}
+
+// AnnouncementID derives the ID of an announcement.
+func AnnouncementID(guildID, spec, message string, channelIDs []string) string {
+	sum := sha256.Sum256([]byte(guildID + " " + spec + " " + message + " " + strings.Join(channelIDs, " ")))
+	return hex.EncodeToString(sum[:4])
+}
+
+// key is where an announcement is stored.
+func key(guildID, id string) string {
+	return guildID + "/" + id
+}
+
+// List returns a guild's announcements, or every announcement if guildID is
+// empty, soonest first.
+func List(st storage.Store, guildID string) ([]Announcement, error) {
+	var all []Announcement
+	for _, k := range st.Keys(Bucket) {
+		if guildID != "" && !strings.HasPrefix(k, guildID+"/") {
+			continue
+		}
+		var a Announcement
+		if _, err := st.Get(Bucket, k, &a); err != nil {
+			return nil, err
+		}
+		all = append(all, a)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Next.Before(all[j].Next) })
+	return all, nil
+}
+
+// Overdue counts the announcements that were due before, which the
+// scheduler should have posted already.
+func Overdue(st storage.Store, before time.Time) (int, error) {
+	all, err := List(st, "")
+	if err != nil {
+		return 0, err
+	}
+	overdue := 0
+	for _, a := range all {
+		if a.Next.Before(before) {
+			overdue++
+		}
+	}
+	return overdue, nil
+}
+
+// Forget deletes guildID's announcements from st, for purges, when userID is
+// empty, and returns how many it deleted. They hold nothing about members.
+func Forget(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" || userID != "" {
+		return 0, nil
+	}
+	deleted := 0
+	for _, k := range st.Keys(Bucket) {
+		if !strings.HasPrefix(k, guildID+"/") {
+			continue
+		}
+		if err := st.Delete(Bucket, k); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Save adds or replaces an announcement.
+func Save(st storage.Store, a Announcement) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	return st.Put(Bucket, key(a.GuildID, a.ID), a)
+}
+
+// Remove deletes a guild's announcement and reports whether it existed.
+func Remove(st storage.Store, guildID, id string) (bool, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	ok, err := st.Get(Bucket, key(guildID, id), &Announcement{})
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, st.Delete(Bucket, key(guildID, id))
+}
+
+// update saves a if it still exists.
+func update(st storage.Store, a Announcement) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if ok, err := st.Get(Bucket, key(a.GuildID, a.ID), &Announcement{}); err != nil || !ok {
+		return err
+	}
+	return st.Put(Bucket, key(a.GuildID, a.ID), a)
+}
+
+// Sender posts messages. *discordgo.Session satisfies it.
+type Sender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// Job is work due at set times, which checks whether it's due itself.
+type Job func(ctx context.Context, now time.Time)
+
+// Scheduler posts announcements when they're due, and runs the other jobs
+// features need done at set times.
+type Scheduler struct {
+	Store   storage.Store
+	Session Sender
+	// Jobs run on every tick after the announcements, for other features
+	// posting at set times, such as the daily link digest.
+	Jobs []Job
+}
+
+// Run posts the announcements that are due, and runs the jobs, every tick until
+// ctx is done.
+func (s *Scheduler) Run(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Post(ctx, now)
+			for _, job := range s.Jobs {
+				job(ctx, now)
+			}
+		}
+	}
+}
+
+// Post posts every announcement due by now and schedules its next post. Those
+// missed while the bot was down, or the guild paused, are posted once rather
+// than once for every time they were missed.
+func (s *Scheduler) Post(ctx context.Context, now time.Time) {
+	all, err := List(s.Store, "")
+	if err != nil {
+		log.Println("Error loading announcements:", err)
+		return
+	}
+	for _, a := range all {
+		if ctx.Err() != nil {
+			return
+		}
+		if a.Next.After(now) {
+			continue
+		}
+		if err := s.post(ctx, a, now); err != nil {
+			log.Printf("Error posting announcement %s for guild %s: %v\n", a.ID, a.GuildID, err)
+		}
+	}
+}
+
+// post posts one announcement, unless its guild is paused, and saves when it's
+// due next.
+func (s *Scheduler) post(ctx context.Context, a Announcement, now time.Time) error {
+	cfg, err := config.LoadGuild(s.Store, a.GuildID)
+	if err != nil {
+		return err
+	}
+	sched, err := ParseSchedule(a.Schedule)
+	if err != nil {
+		return err
+	}
+	a.Next = sched.Next(now, cfg.Location())
+	if a.Next.IsZero() {
+		log.Printf("Announcement %s for guild %s will never be posted again, removing it\n", a.ID, a.GuildID)
+		_, err := Remove(s.Store, a.GuildID, a.ID)
+		return err
+	}
+	// Announcements due while the guild is paused are skipped, not saved up
+	if cfg.Paused {
+		return update(s.Store, a)
+	}
+
+	var errs []error
+	for _, channelID := range a.ChannelIDs {
+		_, err := s.Session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content: a.Message,
+			// Announcements may ping roles and members, but not everyone
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeRoles, discordgo.AllowedMentionTypeUsers},
+			},
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(append(errs, update(s.Store, a))...)
+}
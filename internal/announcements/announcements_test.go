@@ -0,0 +1,121 @@
+package announcements
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Wednesday
+	after := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		spec     string
+		loc      *time.Location
+		expected time.Time
+		wantErr  bool
+	}{
+		{name: "Hourly", spec: "@hourly", loc: time.UTC, expected: time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC)},
+		{name: "Later today", spec: "45 10 * * *", loc: time.UTC, expected: time.Date(2024, 5, 15, 10, 45, 0, 0, time.UTC)},
+		{name: "Tomorrow", spec: "0 9 * * *", loc: time.UTC, expected: time.Date(2024, 5, 16, 9, 0, 0, 0, time.UTC)},
+		{name: "Weekday name", spec: "0 18 * * fri", loc: time.UTC, expected: time.Date(2024, 5, 17, 18, 0, 0, 0, time.UTC)},
+		{name: "Weekday range", spec: "0 8 * * sat-sun", loc: time.UTC, expected: time.Date(2024, 5, 18, 8, 0, 0, 0, time.UTC)},
+		{name: "Sunday as 7", spec: "0 8 * * 7", loc: time.UTC, expected: time.Date(2024, 5, 19, 8, 0, 0, 0, time.UTC)},
+		{name: "Monthly", spec: "@monthly", loc: time.UTC, expected: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Day or weekday", spec: "0 12 20 * mon", loc: time.UTC, expected: time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)},
+		{name: "Step", spec: "0 */6 * * *", loc: time.UTC, expected: time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)},
+		{name: "Month list", spec: "0 0 1 jan,jul *", loc: time.UTC, expected: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Guild time zone", spec: "0 13 * * *", loc: berlin, expected: time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC)},
+		{name: "Leap day", spec: "0 0 29 2 *", loc: time.UTC, expected: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "Never", spec: "0 0 30 2 *", loc: time.UTC},
+		{name: "Every minute", spec: "* * * * *", wantErr: true},
+		{name: "Minute list", spec: "0,30 * * * *", wantErr: true},
+		{name: "Too few fields", spec: "0 18 * *", wantErr: true},
+		{name: "Out of range", spec: "0 24 * * *", wantErr: true},
+		{name: "Backwards", spec: "0 0 * * fri-mon", wantErr: true},
+		{name: "Bad step", spec: "0 */0 * * *", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := ParseSchedule(tc.spec)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseSchedule(%q) error = %v; want error %v", tc.spec, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if next := s.Next(after, tc.loc); !next.Equal(tc.expected) {
+				t.Errorf("Next = %v; want %v", next, tc.expected)
+			}
+		})
+	}
+}
+
+type fakeSender struct {
+	posted []string
+}
+
+func (f *fakeSender) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.posted = append(f.posted, channelID+": "+data.Content)
+	return &discordgo.Message{}, nil
+}
+
+func TestPost(t *testing.T) {
+	now := time.Date(2024, 5, 15, 9, 0, 0, 0, time.UTC)
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "paused", config.Guild{Paused: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []Announcement{
+		{ID: "due", GuildID: "g", ChannelIDs: []string{"a", "b"}, Schedule: "0 9 * * *", Message: "Rules", Next: now},
+		// Missed for days, but posted only once
+		{ID: "missed", GuildID: "g", ChannelIDs: []string{"c"}, Schedule: "0 * * * *", Message: "Hourly", Next: now.Add(-72 * time.Hour)},
+		{ID: "later", GuildID: "g", ChannelIDs: []string{"a"}, Schedule: "0 10 * * *", Message: "Later", Next: now.Add(time.Hour)},
+		{ID: "quiet", GuildID: "paused", ChannelIDs: []string{"d"}, Schedule: "0 9 * * *", Message: "Paused", Next: now},
+	} {
+		if err := Save(st, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &fakeSender{}
+	(&Scheduler{Store: st, Session: s}).Post(context.Background(), now)
+
+	slices.Sort(s.posted)
+	expected := []string{"a: Rules", "b: Rules", "c: Hourly"}
+	if !slices.Equal(s.posted, expected) {
+		t.Errorf("posted %q; want %q", s.posted, expected)
+	}
+
+	all, err := List(st, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := map[string]time.Time{}
+	for _, a := range all {
+		next[a.ID] = a.Next
+	}
+	for id, want := range map[string]time.Time{
+		"due":    now.Add(24 * time.Hour),
+		"missed": now.Add(time.Hour),
+		"later":  now.Add(time.Hour),
+		"quiet":  now.Add(24 * time.Hour),
+	} {
+		if !next[id].Equal(want) {
+			t.Errorf("%s is next due %v; want %v", id, next[id], want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package announcements
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// shorthands are the named schedules a spec may use instead of five fields.
+var shorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// Names months and weekdays may be written with, as in "mon-fri" or "jan,jul".
+var (
+	monthNames   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// lookahead is how far Next looks for a time matching a schedule, so a spec
+// like "0 0 30 2 *" that never fires is caught instead of looping forever.
+const lookahead = 5 * 366 * 24 * time.Hour
+
+// Schedule is a parsed cron spec: the minutes, hours, days of the month,
+// months and weekdays it fires on, each as a bit set.
+type Schedule struct {
+	minute, hour, day, month, weekday uint64
+	// anyDay and anyWeekday record a "*" day or weekday field. When only one
+	// of the two is restricted, that one alone decides the day, as in cron.
+	anyDay, anyWeekday bool
+}
+
+// ParseSchedule parses a five-field cron spec, "minute hour day month
+// weekday", such as "0 18 * * fri" for 6pm every Friday, or one of @hourly,
+// @daily, @weekly, @monthly and @yearly. Fields take numbers, ranges like
+// "1-5", lists like "1,15", steps like "*/2" and English month and weekday
+// abbreviations. The minute must be a single number, so a schedule fires at
+// most once an hour.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if full, ok := shorthands[spec]; ok {
+		spec = full
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("a schedule has five fields, minute hour day month weekday, like `0 18 * * fri`")
+	}
+
+	var s Schedule
+	var err error
+	if _, err := strconv.Atoi(fields[0]); err != nil {
+		return Schedule{}, fmt.Errorf("the minute must be a single number, announcements go out at most once an hour")
+	}
+	if s.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return Schedule{}, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return Schedule{}, fmt.Errorf("hour: %w", err)
+	}
+	if s.day, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return Schedule{}, fmt.Errorf("day: %w", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return Schedule{}, fmt.Errorf("month: %w", err)
+	}
+	// 7 is Sunday too
+	if s.weekday, err = parseField(fields[4], 0, 7, weekdayNames); err != nil {
+		return Schedule{}, fmt.Errorf("weekday: %w", err)
+	}
+	if s.weekday&(1<<7) != 0 {
+		s.weekday |= 1
+	}
+	s.anyDay, s.anyWeekday = fields[2] == "*", fields[4] == "*"
+	return s, nil
+}
+
+// parseField parses one comma-separated field of values from lo to hi. names,
+// if any, are accepted for lo, lo+1 and so on.
+func parseField(field string, lo, hi int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if r, s, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step %q", s)
+			}
+			rng, step = r, n
+		}
+
+		first, last := lo, hi
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if first, err = parseValue(from, lo, hi, names); err != nil {
+				return 0, err
+			}
+			last = first
+			if isRange {
+				if last, err = parseValue(to, lo, hi, names); err != nil {
+					return 0, err
+				}
+				// Weekdays ending on Sunday, as in "sat-sun", end on 7
+				if last == 0 && hi == 7 {
+					last = 7
+				}
+			} else if step > 1 {
+				// "5/15" means every 15 from 5 on
+				last = hi
+			}
+			if last < first {
+				return 0, fmt.Errorf("range %q runs backwards", rng)
+			}
+		}
+		for v := first; v <= last; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// parseValue parses a number from lo to hi, or one of names.
+func parseValue(s string, lo, hi int, names []string) (int, error) {
+	for n, name := range names {
+		if s == name {
+			return lo + n, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < lo || v > hi {
+		return 0, fmt.Errorf("%q isn't between %d and %d", s, lo, hi)
+	}
+	return v, nil
+}
+
+// Next returns the first time after after that the schedule fires in loc, or
+// the zero time if it never does.
+func (s Schedule) Next(after time.Time, loc *time.Location) time.Time {
+	t := after.In(loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.Add(lookahead)
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.onDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !has(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// onDay reports whether the schedule fires on t's day.
+func (s Schedule) onDay(t time.Time) bool {
+	day, weekday := has(s.day, t.Day()), has(s.weekday, int(t.Weekday()))
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// has reports whether bit v is set in bits.
+func has(bits uint64, v int) bool {
+	return bits&(1<<v) != 0
+}
@@ -0,0 +1,692 @@
+// Package api serves a JSON API for administering the bot from external tools,
+// and Go's runtime profiles for debugging it. Every request must carry the
+// configured token as a bearer token.
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/eventqueue"
+	"go-discord-bot/internal/events"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/fleet"
+	"go-discord-bot/internal/health"
+	"go-discord-bot/internal/httpclient"
+	"go-discord-bot/internal/lifecycle"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/purge"
+	"go-discord-bot/internal/stats"
+	"go-discord-bot/internal/storage"
+)
+
+// maxBodySize caps the size of a request body.
+const maxBodySize = 64 << 10
+
+// eventBuffer is how many events a slow event stream client may fall behind by
+// before it starts missing them.
+const eventBuffer = 100
+
+// keepAliveInterval is how often an idle event stream gets a comment line.
+const keepAliveInterval = 30 * time.Second
+
+// guildPageSize is how many guilds are fetched from Discord per request.
+const guildPageSize = 200
+
+// Bot is the part of the bot's Discord connection the API uses.
+// *discordgo.Session implements it.
+type Bot interface {
+	UserGuilds(limit int, beforeID, afterID string, withCounts bool, options ...discordgo.RequestOption) ([]*discordgo.UserGuild, error)
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// Server is the API's HTTP handler.
+type Server struct {
+	// Store holds the guild settings.
+	Store storage.Store
+	// Bot lists the bot's guilds and fetches messages to reprocess.
+	Bot Bot
+	// Stats is returned by the stats and commands endpoints. Nil returns
+	// empty stats.
+	Stats *stats.Collector
+	// Reprocess queues a message to be fixed again, reporting false if the bot
+	// is too busy. Nil disables the reprocess endpoint.
+	Reprocess func(m *discordgo.Message) bool
+	// Events is streamed by the events endpoint. Nil disables the endpoint.
+	Events *events.Bus
+	// Decisions explains how recent messages were handled. Nil disables the
+	// decisions endpoint.
+	Decisions *explain.Log
+	// Outbound counts the bot's outbound HTTP requests per host. Nil disables
+	// the outbound endpoint.
+	Outbound *httpclient.Clients
+	// EventQueue holds the gateway events waiting to be handled. Nil disables
+	// the event queue endpoint.
+	EventQueue *eventqueue.Queue
+	// Purger deletes a guild's or user's data. Nil disables the purge
+	// endpoints.
+	Purger *purge.Purger
+	// Modules enables and disables parts of the bot while it runs. Nil
+	// disables the modules endpoints.
+	Modules *lifecycle.Manager
+	// Guilds lists the bot's guilds and its permissions there for the guild
+	// settings endpoint. Nil disables it.
+	Guilds fleet.Source
+	// Fleet checks and makes changes in many guilds at once for the bulk
+	// settings and fleet endpoints. Nil disables them.
+	Fleet fleet.Session
+	// Health checks each part of the bot for the health endpoint. Nil reports
+	// the bot as ok without checks.
+	Health *health.Monitor
+
+	token string
+	mux   *http.ServeMux
+}
+
+// New returns an API that accepts requests carrying token.
+func New(token string) *Server {
+	s := &Server{token: token, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /api/guilds", s.listGuilds)
+	s.mux.HandleFunc("GET /api/guilds/settings", s.getGuildSettings)
+	s.mux.HandleFunc("PATCH /api/guilds/settings", s.patchGuildSettings)
+	s.mux.HandleFunc("POST /api/fleet/announce", s.fleetAnnounce)
+	s.mux.HandleFunc("POST /api/fleet/set", s.fleetSet)
+	s.mux.HandleFunc("POST /api/fleet/module", s.fleetModule)
+	s.mux.HandleFunc("GET /api/guilds/{id}/config", s.getConfig)
+	s.mux.HandleFunc("PUT /api/guilds/{id}/config", s.putConfig)
+	s.mux.HandleFunc("GET /api/guilds/{id}/stats", s.getStats)
+	s.mux.HandleFunc("GET /api/commands", s.getCommands)
+	s.mux.HandleFunc("POST /api/channels/{channel}/messages/{message}/reprocess", s.reprocess)
+	s.mux.HandleFunc("GET /api/events", s.streamEvents)
+	s.mux.HandleFunc("GET /api/messages/{message}/decision", s.getDecision)
+	s.mux.HandleFunc("GET /api/correlations/{id}", s.getCorrelation)
+	s.mux.HandleFunc("GET /api/logging", s.getLogging)
+	s.mux.HandleFunc("PUT /api/logging", s.putLogging)
+	s.mux.HandleFunc("GET /api/outbound", s.getOutbound)
+	s.mux.HandleFunc("GET /api/gateway/queue", s.getEventQueue)
+	s.mux.HandleFunc("DELETE /api/guilds/{id}/data", s.purgeGuild)
+	s.mux.HandleFunc("DELETE /api/users/{id}/data", s.purgeUser)
+	s.mux.HandleFunc("GET /api/modules", s.getModules)
+	s.mux.HandleFunc("PUT /api/modules", s.putModule)
+	s.mux.HandleFunc("GET /healthz", s.getHealth)
+
+	// Profiles for diagnosing leaks, such as /debug/pprof/heap or
+	// /debug/pprof/goroutine?debug=1, behind the same token as the rest
+	s.mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	s.mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	s.mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	s.mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	s.mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	s.mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		// Load balancers and uptime checks see the bot's state, not why
+		if r.Method == http.MethodGet && r.URL.Path == "/healthz" {
+			report := s.Health.Check(r.Context())
+			writeJSON(w, healthStatus(report.State), struct {
+				State health.State `json:"state"`
+			}{report.State})
+			return
+		}
+		writeError(w, http.StatusUnauthorized, "missing or wrong API token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized reports whether r carries the API token.
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// guild is an entry in the guild list.
+type guild struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (s *Server) listGuilds(w http.ResponseWriter, r *http.Request) {
+	guilds := []guild{}
+	after := ""
+	for {
+		page, err := s.Bot.UserGuilds(guildPageSize, "", after, false, discordgo.WithContext(r.Context()))
+		if err != nil {
+			log.Println("Error listing guilds:", err)
+			writeError(w, http.StatusBadGateway, "couldn't list guilds")
+			return
+		}
+		for _, g := range page {
+			guilds = append(guilds, guild{ID: g.ID, Name: g.Name})
+		}
+		if len(page) < guildPageSize {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+	writeJSON(w, http.StatusOK, guilds)
+}
+
+func (s *Server) getConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, err := config.LoadGuild(s.Store, r.PathValue("id"))
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		writeError(w, http.StatusInternalServerError, "couldn't load settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, cfg)
+}
+
+func (s *Server) putConfig(w http.ResponseWriter, r *http.Request) {
+	var cfg config.Guild
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		writeError(w, http.StatusBadRequest, "body isn't guild settings: "+err.Error())
+		return
+	}
+	if err := fixers.ValidateGuild(cfg); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := config.SaveGuild(s.Store, r.PathValue("id"), cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		writeError(w, http.StatusInternalServerError, "couldn't save settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, cfg)
+}
+
+func (s *Server) getStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Stats.Guild(r.PathValue("id")))
+}
+
+func (s *Server) getCommands(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Stats.Commands(r.URL.Query().Get("guild")))
+}
+
+func (s *Server) getDecision(w http.ResponseWriter, r *http.Request) {
+	if s.Decisions == nil {
+		writeError(w, http.StatusNotImplemented, "the decision log is disabled")
+		return
+	}
+	trace, ok := s.Decisions.Get(r.PathValue("message"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "no recent decision about that message")
+		return
+	}
+	writeJSON(w, http.StatusOK, trace)
+}
+
+// getCorrelation returns the decision about the message handled under a
+// correlation ID, such as one a user quoted from a reply.
+func (s *Server) getCorrelation(w http.ResponseWriter, r *http.Request) {
+	if s.Decisions == nil {
+		writeError(w, http.StatusNotImplemented, "the decision log is disabled")
+		return
+	}
+	trace, ok := s.Decisions.Find(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "no recent decision with that correlation ID")
+		return
+	}
+	writeJSON(w, http.StatusOK, trace)
+}
+
+func (s *Server) getLogging(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, logging.Levels())
+}
+
+// getOutbound lists the outbound HTTP requests sent to each host.
+func (s *Server) getOutbound(w http.ResponseWriter, r *http.Request) {
+	if s.Outbound == nil {
+		writeError(w, http.StatusNotImplemented, "outbound request counts are disabled")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Outbound.Stats())
+}
+
+// getEventQueue describes the gateway event queue, and what it's dropped.
+func (s *Server) getEventQueue(w http.ResponseWriter, r *http.Request) {
+	if s.EventQueue == nil {
+		writeError(w, http.StatusNotImplemented, "the event queue is disabled")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.EventQueue.Stats())
+}
+
+// getHealth checks each part of the bot, answering 503 while one is down.
+func (s *Server) getHealth(w http.ResponseWriter, r *http.Request) {
+	report := s.Health.Check(r.Context())
+	writeJSON(w, healthStatus(report.State), report)
+}
+
+// healthStatus is the status code the health endpoint answers with in state.
+// Degraded parts still work, so only a part that's down fails the check.
+func healthStatus(state health.State) int {
+	if state == health.Down {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// getGuildSettings lists every guild with its key settings and what stops
+// the bot doing what they ask, only those with problems for ?problems=true.
+func (s *Server) getGuildSettings(w http.ResponseWriter, r *http.Request) {
+	if s.Guilds == nil {
+		writeError(w, http.StatusNotImplemented, "guilds can't be listed")
+		return
+	}
+	guilds := []fleet.Guild{}
+	onlyProblems := r.URL.Query().Get("problems") == "true"
+	for _, g := range fleet.Survey(s.Store, s.Guilds) {
+		if !onlyProblems || len(g.Problems) > 0 {
+			guilds = append(guilds, g)
+		}
+	}
+	writeJSON(w, http.StatusOK, guilds)
+}
+
+// patchGuildSettings changes settings in many guilds at once, such as
+// {"guilds": ["1", "2"], "settings": {"repost_mode": "reply"}}, or in every
+// guild with "all": true instead of guilds. Every guild is checked first, and
+// if the bot would lack a permission the settings need in any, none is changed.
+func (s *Server) patchGuildSettings(w http.ResponseWriter, r *http.Request) {
+	if s.Fleet == nil {
+		writeError(w, http.StatusNotImplemented, "guilds can't be changed")
+		return
+	}
+	var body struct {
+		Guilds   []string        `json:"guilds"`
+		All      bool            `json:"all"`
+		Settings json.RawMessage `json:"settings"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	list, ok := "", false
+	if err := dec.Decode(&body); err == nil {
+		list, ok = guildList(body.Guilds, body.All)
+	}
+	if !ok {
+		writeError(w, http.StatusBadRequest, "body isn't settings with guilds or all")
+		return
+	}
+	op, err := fleet.NewSettingChange(s.Store, body.Settings)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results, err := fleet.Run(r.Context(), s.Fleet, op, list, false)
+	if err != nil {
+		writeFleetError(w, results, err)
+		return
+	}
+	changed := make([]string, len(results))
+	for n, res := range results {
+		changed[n] = res.GuildID
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"changed": changed})
+}
+
+// guildList turns the guilds of a request body into a guild list for
+// fleet.Run, where the empty list means every guild. It reports false unless
+// the body names either some guilds or all of them.
+func guildList(guilds []string, all bool) (string, bool) {
+	if all {
+		return "", len(guilds) == 0
+	}
+	for _, id := range guilds {
+		if strings.TrimSpace(id) == "" || strings.Contains(id, ",") {
+			return "", false
+		}
+	}
+	return strings.Join(guilds, ","), len(guilds) > 0
+}
+
+// writeFleetError writes why fleet.Run changed nothing: 400 for a request
+// naming invalid settings or guilds the bot isn't in, 409 with the reason of
+// each guild that failed for a change some guilds couldn't take, and 502 if
+// Discord couldn't be asked.
+func writeFleetError(w http.ResponseWriter, results []fleet.Result, err error) {
+	switch {
+	case errors.Is(err, fleet.ErrInvalid) || errors.Is(err, fleet.ErrUnknownGuild):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case results == nil:
+		log.Println("Error changing guilds:", err)
+		writeError(w, http.StatusBadGateway, "couldn't list guilds")
+	default:
+		failed := make(map[string]string)
+		for _, res := range results {
+			if res.Err != nil {
+				failed[res.GuildID] = res.Err.Error()
+			}
+		}
+		writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error(), "failed": failed})
+	}
+}
+
+// fleetRequest is the part of a fleet endpoint's body saying where to make
+// the change: in guilds, or in every guild with all, only checking them with
+// dry_run.
+type fleetRequest struct {
+	Guilds []string `json:"guilds"`
+	All    bool     `json:"all"`
+	DryRun bool     `json:"dry_run"`
+}
+
+// fleetResult is a fleet.Result as the fleet endpoints return it.
+type fleetResult struct {
+	GuildID   string `json:"guild_id"`
+	GuildName string `json:"guild_name"`
+	Plan      string `json:"plan,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Done      bool   `json:"done"`
+}
+
+// fleetAnnounce posts a message in the system channel of many guilds, such as
+// {"guilds": ["1", "2"], "message": "Back soon", "dry_run": true}.
+func (s *Server) fleetAnnounce(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		fleetRequest
+		Message string `json:"message"`
+	}
+	if !s.decodeFleet(w, r, &body, &body.fleetRequest) {
+		return
+	}
+	if strings.TrimSpace(body.Message) == "" || utf8.RuneCountInString(body.Message) > chunk.MaxMessageLength {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("message must be 1 to %d characters", chunk.MaxMessageLength))
+		return
+	}
+	s.runFleet(w, r, fleet.NewAnnouncement(body.Message), body.fleetRequest)
+}
+
+// fleetSet changes settings in many guilds, such as
+// {"all": true, "settings": {"repost_mode": "reply"}}.
+func (s *Server) fleetSet(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		fleetRequest
+		Settings json.RawMessage `json:"settings"`
+	}
+	if !s.decodeFleet(w, r, &body, &body.fleetRequest) {
+		return
+	}
+	op, err := fleet.NewSettingChange(s.Store, body.Settings)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.runFleet(w, r, op, body.fleetRequest)
+}
+
+// fleetModule turns a module on or off in many guilds, such as
+// {"guilds": ["1"], "module": "translate", "enabled": false}.
+func (s *Server) fleetModule(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		fleetRequest
+		Module  string `json:"module"`
+		Enabled *bool  `json:"enabled"`
+	}
+	if !s.decodeFleet(w, r, &body, &body.fleetRequest) {
+		return
+	}
+	if body.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "body has no enabled")
+		return
+	}
+	op, err := fleet.NewModuleToggle(s.Store, body.Module, *body.Enabled)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.runFleet(w, r, op, body.fleetRequest)
+}
+
+// decodeFleet reads a fleet endpoint's body into body, whose fleetRequest is
+// req, and writes an error and reports false if it can't.
+func (s *Server) decodeFleet(w http.ResponseWriter, r *http.Request, body any, req *fleetRequest) bool {
+	if s.Fleet == nil {
+		writeError(w, http.StatusNotImplemented, "guilds can't be changed")
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return false
+	}
+	if _, ok := guildList(req.Guilds, req.All); !ok {
+		writeError(w, http.StatusBadRequest, "body has neither guilds nor all")
+		return false
+	}
+	return true
+}
+
+// runFleet runs op in the guilds of req and writes the result of each, or
+// with the error if nothing was changed.
+func (s *Server) runFleet(w http.ResponseWriter, r *http.Request, op fleet.Operation, req fleetRequest) {
+	list, _ := guildList(req.Guilds, req.All)
+	results, err := fleet.Run(r.Context(), s.Fleet, op, list, req.DryRun)
+	if err != nil && (results == nil || errors.Is(err, fleet.ErrInvalid)) {
+		writeFleetError(w, results, err)
+		return
+	}
+	out := make([]fleetResult, len(results))
+	for n, res := range results {
+		out[n] = fleetResult{GuildID: res.GuildID, GuildName: res.GuildName, Plan: res.Plan, Done: res.Done}
+		if res.Err != nil {
+			out[n].Error = res.Err.Error()
+		}
+	}
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error(), "results": out})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": out})
+}
+
+// getModules lists the parts of the bot and whether each is enabled.
+func (s *Server) getModules(w http.ResponseWriter, r *http.Request) {
+	if s.Modules == nil {
+		writeError(w, http.StatusNotImplemented, "modules can't be switched")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Modules.Statuses())
+}
+
+// putModule enables or disables a part of the bot, such as
+// {"module": "feeds", "enabled": false}.
+func (s *Server) putModule(w http.ResponseWriter, r *http.Request) {
+	if s.Modules == nil {
+		writeError(w, http.StatusNotImplemented, "modules can't be switched")
+		return
+	}
+	var body struct {
+		Module  string `json:"module"`
+		Enabled *bool  `json:"enabled"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil || body.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "body isn't a module and whether it's enabled")
+		return
+	}
+	set := s.Modules.Disable
+	if *body.Enabled {
+		set = s.Modules.Enable
+	}
+	if err := set(body.Module); errors.Is(err, lifecycle.ErrUnknown) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		log.Println("Error switching module:", err)
+		writeError(w, http.StatusInternalServerError, "couldn't save the modules")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Modules.Statuses())
+}
+
+// purgeGuild deletes everything stored about a guild. The ID is repeated in
+// the confirm query parameter, so a mistyped path can't delete the wrong one.
+func (s *Server) purgeGuild(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.confirmPurge(w, r, id) {
+		return
+	}
+	report, err := s.Purger.Guild(id)
+	s.purged(w, "guild "+id, report, err)
+}
+
+// purgeUser deletes what's stored about a user, in every guild or only the
+// one in the guild query parameter, confirmed like purgeGuild.
+func (s *Server) purgeUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.confirmPurge(w, r, id) {
+		return
+	}
+	report, err := s.Purger.User(r.URL.Query().Get("guild"), id)
+	s.purged(w, "user "+id, report, err)
+}
+
+// confirmPurge reports whether a purge of id can go ahead, writing the error
+// if it can't.
+func (s *Server) confirmPurge(w http.ResponseWriter, r *http.Request, id string) bool {
+	if s.Purger == nil {
+		writeError(w, http.StatusNotImplemented, "purging is disabled")
+		return false
+	}
+	if r.URL.Query().Get("confirm") != id {
+		writeError(w, http.StatusBadRequest, "set confirm to the ID being purged")
+		return false
+	}
+	return true
+}
+
+// purged logs and returns the result of a purge of what.
+func (s *Server) purged(w http.ResponseWriter, what string, report purge.Report, err error) {
+	if err != nil {
+		log.Println("Error purging data:", err)
+		writeError(w, http.StatusInternalServerError, "couldn't delete everything")
+		return
+	}
+	log.Printf("Purged the data of %s through the API: %s", what, report)
+	writeJSON(w, http.StatusOK, report)
+}
+
+// putLogging changes one module's log level until the bot restarts.
+func (s *Server) putLogging(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Module logging.Module `json:"module"`
+		Level  logging.Level  `json:"level"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "body isn't a module and level: "+err.Error())
+		return
+	}
+	if err := logging.SetLevel(body.Module, body.Level); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, logging.Levels())
+}
+
+func (s *Server) reprocess(w http.ResponseWriter, r *http.Request) {
+	if s.Reprocess == nil {
+		writeError(w, http.StatusNotImplemented, "reprocessing is disabled")
+		return
+	}
+	channel, err := s.Bot.Channel(r.PathValue("channel"), discordgo.WithContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "couldn't fetch that channel")
+		return
+	}
+	m, err := s.Bot.ChannelMessage(channel.ID, r.PathValue("message"), discordgo.WithContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "couldn't fetch that message")
+		return
+	}
+	// Messages fetched over REST don't say which guild they're in
+	m.GuildID = channel.GuildID
+	if !s.Reprocess(m) {
+		writeError(w, http.StatusServiceUnavailable, "the bot is too busy, try again later")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// streamEvents sends every event as it happens using server-sent events, one
+// JSON object per "data:" line, until the client disconnects.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	if s.Events == nil {
+		writeError(w, http.StatusNotImplemented, "the event stream is disabled")
+		return
+	}
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	stream, stop := s.Events.Subscribe(eventBuffer)
+	defer stop()
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			// A comment line keeps proxies from closing an idle stream
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case e := <-stream:
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.Println("Error encoding event:", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Error writing API response:", err)
+	}
+}
+
+// writeError writes an error response such as {"error": "message"}.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
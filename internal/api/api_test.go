@@ -0,0 +1,279 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/eventqueue"
+	"go-discord-bot/internal/events"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/health"
+	"go-discord-bot/internal/httpclient"
+	"go-discord-bot/internal/lifecycle"
+	"go-discord-bot/internal/proxy"
+	"go-discord-bot/internal/purge"
+	"go-discord-bot/internal/storage"
+)
+
+// fakeBot is in 250 guilds and can see message "msg" in channel "chan" of guild "1".
+type fakeBot struct{}
+
+func (fakeBot) UserGuilds(limit int, beforeID, afterID string, withCounts bool, options ...discordgo.RequestOption) ([]*discordgo.UserGuild, error) {
+	start := 0
+	if afterID != "" {
+		fmt.Sscan(afterID, &start)
+	}
+	var page []*discordgo.UserGuild
+	for id := start + 1; id <= 250 && len(page) < limit; id++ {
+		page = append(page, &discordgo.UserGuild{ID: fmt.Sprint(id), Name: fmt.Sprint("Guild ", id)})
+	}
+	return page, nil
+}
+
+func (fakeBot) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	if channelID != "chan" {
+		return nil, errors.New("unknown channel")
+	}
+	return &discordgo.Channel{ID: "chan", GuildID: "1"}, nil
+}
+
+func (fakeBot) ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if messageID != "msg" {
+		return nil, errors.New("unknown message")
+	}
+	return &discordgo.Message{ID: "msg", ChannelID: channelID, Content: "https://x.com/user/status/1"}, nil
+}
+
+// fakeGuilds is a fleet.Source with the bot in guilds 1, where it may do
+// anything, and 2, where it may do nothing.
+type fakeGuilds struct{}
+
+func (fakeGuilds) Guilds() []*discordgo.Guild {
+	return []*discordgo.Guild{{ID: "1", Name: "One"}, {ID: "2", Name: "Two"}}
+}
+
+func (fakeGuilds) Permissions(guildID, channelID string) (int64, bool) {
+	if guildID == "1" {
+		return ^int64(0), true
+	}
+	return 0, true
+}
+
+// fakeFleet is a fleet.Session with the bot in the guilds of fakeGuilds, with
+// the same permissions.
+type fakeFleet struct{}
+
+func (fakeFleet) User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error) {
+	return &discordgo.User{ID: "bot"}, nil
+}
+
+func (fakeFleet) UserGuilds(limit int, beforeID, afterID string, withCounts bool, options ...discordgo.RequestOption) ([]*discordgo.UserGuild, error) {
+	return []*discordgo.UserGuild{{ID: "1", Name: "One"}, {ID: "2", Name: "Two"}}, nil
+}
+
+func (fakeFleet) Guild(guildID string, options ...discordgo.RequestOption) (*discordgo.Guild, error) {
+	perms, _ := fakeGuilds{}.Permissions(guildID, "")
+	return &discordgo.Guild{ID: guildID, SystemChannelID: "system" + guildID, Roles: []*discordgo.Role{{ID: guildID, Permissions: perms}}}, nil
+}
+
+func (fakeFleet) GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error) {
+	return &discordgo.Member{User: &discordgo.User{ID: userID}}, nil
+}
+
+func (fakeFleet) UserChannelPermissions(userID, channelID string, fetchOptions ...discordgo.RequestOption) (int64, error) {
+	perms, _ := fakeGuilds{}.Permissions(strings.TrimPrefix(channelID, "system"), channelID)
+	return perms, nil
+}
+
+func (fakeFleet) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return &discordgo.Message{ID: "message" + channelID}, nil
+}
+
+func (fakeFleet) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	return nil
+}
+
+func TestAPI(t *testing.T) {
+	var reprocessed []*discordgo.Message
+	s := New("secret")
+	s.Store = storage.NewMemory()
+	s.Bot = fakeBot{}
+	s.Reprocess = func(m *discordgo.Message) bool {
+		reprocessed = append(reprocessed, m)
+		return true
+	}
+	s.Decisions = explain.New(10)
+	s.Decisions.Start(&discordgo.Message{ID: "msg", ChannelID: "chan"}, "message create", "abc123").Decide("unchanged")
+	s.Outbound = httpclient.New(proxy.Routes{}, 0)
+	s.EventQueue = eventqueue.New(10, 1, eventqueue.DropOldest)
+	defer s.EventQueue.Stop()
+	s.Purger = &purge.Purger{Store: s.Store, Forgetters: map[string]purge.Forgetter{config.GuildBucket: config.Forget}}
+	s.Modules, _ = lifecycle.New(nil)
+	s.Modules.Register(lifecycle.Feeds, "Posts feeds", nil)
+	s.Guilds = fakeGuilds{}
+	s.Fleet = fakeFleet{}
+	s.Health = health.New()
+	s.Health.Add("fx API", func(context.Context) (health.State, string) { return health.Degraded, "3 requests failed in a row" })
+
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		body     string
+		expected int
+		contains string
+	}{
+		{name: "No token", method: http.MethodGet, path: "/api/guilds", expected: http.StatusUnauthorized},
+		{name: "Wrong token", method: http.MethodGet, path: "/api/guilds", token: "guess", expected: http.StatusUnauthorized},
+		{name: "List guilds", method: http.MethodGet, path: "/api/guilds", token: "secret", expected: http.StatusOK, contains: `"name":"Guild 250"`},
+		{name: "Set config", method: http.MethodPut, path: "/api/guilds/1/config", token: "secret", body: `{"repost_mode":"reply"}`, expected: http.StatusOK},
+		{name: "Get config", method: http.MethodGet, path: "/api/guilds/1/config", token: "secret", expected: http.StatusOK, contains: `"repost_mode":"reply"`},
+		{name: "Invalid config", method: http.MethodPut, path: "/api/guilds/1/config", token: "secret", body: `{"repost_mode":"shout"}`, expected: http.StatusBadRequest},
+		{name: "Unknown field", method: http.MethodPut, path: "/api/guilds/1/config", token: "secret", body: `{"colour":"red"}`, expected: http.StatusBadRequest},
+		{name: "Guild settings", method: http.MethodGet, path: "/api/guilds/settings", token: "secret", expected: http.StatusOK, contains: `"repost_mode":"reply"`},
+		{name: "Guilds with problems", method: http.MethodGet, path: "/api/guilds/settings?problems=true", token: "secret", expected: http.StatusOK, contains: `[{"id":"2"`},
+		{name: "Bulk set", method: http.MethodPatch, path: "/api/guilds/settings", token: "secret", body: `{"all":true,"settings":{"twitter_site":"nitter"}}`, expected: http.StatusOK, contains: `{"changed":["1","2"]}`},
+		{name: "Bulk set invalid", method: http.MethodPatch, path: "/api/guilds/settings", token: "secret", body: `{"guilds":["1"],"settings":{"repost_mode":"shout"}}`, expected: http.StatusBadRequest},
+		{name: "Bulk set without guilds", method: http.MethodPatch, path: "/api/guilds/settings", token: "secret", body: `{"settings":{"paused":true}}`, expected: http.StatusBadRequest},
+		{name: "Bulk set unknown guild", method: http.MethodPatch, path: "/api/guilds/settings", token: "secret", body: `{"guilds":["1","3"],"settings":{"paused":true}}`, expected: http.StatusBadRequest, contains: "unknown guild: 3"},
+		{name: "Bulk set empty guild", method: http.MethodPatch, path: "/api/guilds/settings", token: "secret", body: `{"guilds":[""],"settings":{"paused":true}}`, expected: http.StatusBadRequest},
+		{name: "Bulk set duplicate guilds", method: http.MethodPatch, path: "/api/guilds/settings", token: "secret", body: `{"guilds":["2","2"],"settings":{"privacy":true}}`, expected: http.StatusOK, contains: `{"changed":["2"]}`},
+		{name: "Bulk set missing permission", method: http.MethodPatch, path: "/api/guilds/settings", token: "secret", body: `{"all":true,"settings":{"repost_mode":"reaction"}}`, expected: http.StatusConflict, contains: `"failed":{"2":`},
+		{name: "Fleet announce dry run", method: http.MethodPost, path: "/api/fleet/announce", token: "secret", body: `{"guilds":["1"],"message":"hi","dry_run":true}`, expected: http.StatusOK, contains: `{"results":[{"guild_id":"1","guild_name":"One","plan":"post in channel system1","done":false}]}`},
+		{name: "Fleet announce missing permission", method: http.MethodPost, path: "/api/fleet/announce", token: "secret", body: `{"all":true,"message":"hi"}`, expected: http.StatusConflict, contains: `"guild_id":"2","guild_name":"Two","error":`},
+		{name: "Fleet announce empty", method: http.MethodPost, path: "/api/fleet/announce", token: "secret", body: `{"all":true,"message":" "}`, expected: http.StatusBadRequest},
+		{name: "Fleet set", method: http.MethodPost, path: "/api/fleet/set", token: "secret", body: `{"guilds":["1"],"settings":{"twitter_site":"nitter"}}`, expected: http.StatusOK, contains: `"done":true`},
+		{name: "Fleet set invalid", method: http.MethodPost, path: "/api/fleet/set", token: "secret", body: `{"all":true,"settings":{"repost_mode":"shout"}}`, expected: http.StatusBadRequest},
+		{name: "Fleet set unknown guild", method: http.MethodPost, path: "/api/fleet/set", token: "secret", body: `{"guilds":["9"],"settings":{"paused":true}}`, expected: http.StatusBadRequest, contains: "unknown guild"},
+		{name: "Fleet module", method: http.MethodPost, path: "/api/fleet/module", token: "secret", body: `{"all":true,"module":"translate","enabled":false,"dry_run":true}`, expected: http.StatusOK, contains: `"plan":"turn translate off"`},
+		{name: "Fleet module without a state", method: http.MethodPost, path: "/api/fleet/module", token: "secret", body: `{"all":true,"module":"translate"}`, expected: http.StatusBadRequest},
+		{name: "Fleet module unknown", method: http.MethodPost, path: "/api/fleet/module", token: "secret", body: `{"all":true,"module":"dance","enabled":true}`, expected: http.StatusBadRequest},
+		{name: "Stats", method: http.MethodGet, path: "/api/guilds/1/stats", token: "secret", expected: http.StatusOK, contains: `"reposts":0`},
+		{name: "Commands", method: http.MethodGet, path: "/api/commands?guild=1", token: "secret", expected: http.StatusOK},
+		{name: "Reprocess", method: http.MethodPost, path: "/api/channels/chan/messages/msg/reprocess", token: "secret", expected: http.StatusAccepted},
+		{name: "Reprocess unknown message", method: http.MethodPost, path: "/api/channels/chan/messages/gone/reprocess", token: "secret", expected: http.StatusNotFound},
+		{name: "Decision", method: http.MethodGet, path: "/api/messages/msg/decision", token: "secret", expected: http.StatusOK, contains: `"decision":"unchanged"`},
+		{name: "Health", method: http.MethodGet, path: "/healthz", token: "secret", expected: http.StatusOK, contains: `"detail":"3 requests failed in a row"`},
+		{name: "Health without token", method: http.MethodGet, path: "/healthz", expected: http.StatusOK, contains: `{"state":"degraded"}`},
+		{name: "Correlation", method: http.MethodGet, path: "/api/correlations/abc123", token: "secret", expected: http.StatusOK, contains: `"message_id":"msg"`},
+		{name: "Unknown correlation", method: http.MethodGet, path: "/api/correlations/missing", token: "secret", expected: http.StatusNotFound},
+		{name: "Unknown decision", method: http.MethodGet, path: "/api/messages/gone/decision", token: "secret", expected: http.StatusNotFound},
+		{name: "Set log level", method: http.MethodPut, path: "/api/logging", token: "secret", body: `{"module":"http","level":"debug"}`, expected: http.StatusOK, contains: `"http":"debug"`},
+		{name: "Get log levels", method: http.MethodGet, path: "/api/logging", token: "secret", expected: http.StatusOK, contains: `"http":"debug"`},
+		{name: "Unknown log module", method: http.MethodPut, path: "/api/logging", token: "secret", body: `{"module":"cache","level":"debug"}`, expected: http.StatusBadRequest},
+		{name: "Reset log level", method: http.MethodPut, path: "/api/logging", token: "secret", body: `{"module":"http","level":"info"}`, expected: http.StatusOK, contains: `"http":"info"`},
+		{name: "Outbound requests", method: http.MethodGet, path: "/api/outbound", token: "secret", expected: http.StatusOK, contains: "[]"},
+		{name: "Event queue", method: http.MethodGet, path: "/api/gateway/queue", token: "secret", expected: http.StatusOK, contains: `"capacity":10`},
+		{name: "Purge without confirming", method: http.MethodDelete, path: "/api/guilds/1/data", token: "secret", expected: http.StatusBadRequest},
+		{name: "Purge user", method: http.MethodDelete, path: "/api/users/alice/data?confirm=alice", token: "secret", expected: http.StatusOK, contains: "{}"},
+		{name: "Disable module", method: http.MethodPut, path: "/api/modules", token: "secret", body: `{"module":"feeds","enabled":false}`, expected: http.StatusOK, contains: `"enabled":false`},
+		{name: "Modules", method: http.MethodGet, path: "/api/modules", token: "secret", expected: http.StatusOK, contains: `"name":"feeds"`},
+		{name: "Unknown module", method: http.MethodPut, path: "/api/modules", token: "secret", body: `{"module":"games","enabled":true}`, expected: http.StatusBadRequest},
+		{name: "Module without a state", method: http.MethodPut, path: "/api/modules", token: "secret", body: `{"module":"feeds"}`, expected: http.StatusBadRequest},
+		{name: "Profiles without a token", method: http.MethodGet, path: "/debug/pprof/goroutine?debug=1", expected: http.StatusUnauthorized},
+		{name: "Profiles", method: http.MethodGet, path: "/debug/pprof/goroutine?debug=1", token: "secret", expected: http.StatusOK, contains: "goroutine profile"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expected {
+				t.Fatalf("%s %s = %d %s; want %d", tc.method, tc.path, rec.Code, rec.Body, tc.expected)
+			}
+			if !strings.Contains(rec.Body.String(), tc.contains) {
+				t.Errorf("%s %s body = %s; want it to contain %s", tc.method, tc.path, rec.Body, tc.contains)
+			}
+		})
+	}
+
+	if len(reprocessed) != 1 || reprocessed[0].GuildID != "1" {
+		t.Errorf("reprocessed %+v; want msg with its guild filled in", reprocessed)
+	}
+	if cfg, _ := config.LoadGuild(s.Store, "1"); cfg.RepostMode != config.RepostReply {
+		t.Errorf("stored config %+v; want reply mode", cfg)
+	}
+}
+
+func TestListGuildsPages(t *testing.T) {
+	s := New("secret")
+	s.Bot = fakeBot{}
+	req := httptest.NewRequest(http.MethodGet, "/api/guilds", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	var guilds []guild
+	if err := json.Unmarshal(rec.Body.Bytes(), &guilds); err != nil {
+		t.Fatal(err)
+	}
+	if len(guilds) != 250 {
+		t.Errorf("listed %d guilds; want all 250 across pages", len(guilds))
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	s := New("secret")
+	s.Events = events.New()
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/events", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	// The server subscribes just after sending headers, so keep publishing until it hears
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				s.Events.Publish(events.Event{Type: events.Fix, GuildID: "guild"})
+			}
+		}
+	}()
+
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		data, ok := strings.CutPrefix(lines.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e events.Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			t.Fatalf("bad event %q: %v", data, err)
+		}
+		if e.Type != events.Fix || e.GuildID != "guild" {
+			t.Errorf("event = %+v; want the published fix", e)
+		}
+		return
+	}
+	t.Fatalf("stream ended without an event: %v", lines.Err())
+}
@@ -0,0 +1,131 @@
+// Package budget limits how much expensive work, such as tweet lookups and
+// media mirroring, each guild can have the bot do in a day, so one huge
+// server can't use up a shared instance. Past its budget a guild's links are
+// still fixed, just without the extras those requests would have added.
+package budget
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Kind is a kind of expensive work, budgeted separately.
+type Kind string
+
+const (
+	// Lookups are requests to outside services about a link: tweets looked
+	// up for embeds, context, galleries and profiles, page previews and
+	// shortened links expanded.
+	Lookups Kind = "lookups"
+	// Mirrors are messages whose media the bot downloads and uploads again.
+	Mirrors Kind = "mirrors"
+)
+
+// ErrSpent is returned for work skipped because the guild's budget for it
+// is used up today.
+var ErrSpent = errors.New("the server's daily budget for this is used up")
+
+// Limits are how many of each kind of work a guild may use in a day. Kinds
+// missing from it, or at 0, are unlimited.
+type Limits map[Kind]int
+
+// spent is what one guild used of one kind today.
+type spent struct {
+	day    string
+	count  int
+	warned bool
+}
+
+// Budget counts the work each guild uses per UTC day. A nil Budget allows
+// everything. It is safe for concurrent use.
+type Budget struct {
+	limits Limits
+	now    func() time.Time
+
+	mu    sync.Mutex
+	spent map[string]*spent
+}
+
+// New returns a Budget allowing each guild limits a day.
+func New(limits Limits) *Budget {
+	return &Budget{limits: limits, now: time.Now, spent: make(map[string]*spent)}
+}
+
+// day names the UTC day t falls on.
+func day(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// Spend takes n of kind from what guildID has left today, and reports whether
+// there was enough. When there wasn't, nothing is taken, and the first time
+// that happens each day it's logged. Work outside guilds isn't counted.
+func (b *Budget) Spend(guildID string, kind Kind, n int) bool {
+	if b == nil || guildID == "" || b.limits[kind] <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	today := day(b.now())
+	key := guildID + "/" + string(kind)
+	s := b.spent[key]
+	if s == nil || s.day != today {
+		s = &spent{day: today}
+		b.spent[key] = s
+	}
+	if s.count+n > b.limits[kind] {
+		if !s.warned {
+			s.warned = true
+			log.Printf("Guild %s used up its %d %s for today, fixing its links without them until tomorrow\n", guildID, b.limits[kind], kind)
+		}
+		return false
+	}
+	s.count += n
+	return true
+}
+
+// Left returns how much of kind guildID has left today, or -1 if it's
+// unlimited.
+func (b *Budget) Left(guildID string, kind Kind) int {
+	if b == nil || b.limits[kind] <= 0 {
+		return -1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.spent[guildID+"/"+string(kind)]
+	if s == nil || s.day != day(b.now()) {
+		return b.limits[kind]
+	}
+	return b.limits[kind] - s.count
+}
+
+// Prune forgets what guilds spent before now's day, and returns how many
+// counts it removed. It implements janitor.Pruner.
+func (b *Budget) Prune(now time.Time) int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	today := day(now)
+	pruned := 0
+	for key, s := range b.spent {
+		if s.day != today {
+			delete(b.spent, key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many guilds' counts are kept, per kind.
+func (b *Budget) Len() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.spent)
+}
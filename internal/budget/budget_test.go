@@ -0,0 +1,53 @@
+package budget
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpend(t *testing.T) {
+	now := time.Date(2024, 5, 15, 23, 0, 0, 0, time.UTC)
+	b := New(Limits{Lookups: 3, Mirrors: 0})
+	b.now = func() time.Time { return now }
+
+	testCases := []struct {
+		name     string
+		advance  time.Duration
+		guildID  string
+		kind     Kind
+		n        int
+		expected bool
+		left     int
+	}{
+		{name: "Within budget", guildID: "g", kind: Lookups, n: 2, expected: true, left: 1},
+		{name: "Too many at once", guildID: "g", kind: Lookups, n: 2, expected: false, left: 1},
+		{name: "Last one", guildID: "g", kind: Lookups, n: 1, expected: true, left: 0},
+		{name: "Used up", guildID: "g", kind: Lookups, n: 1, expected: false, left: 0},
+		{name: "Other guild", guildID: "other", kind: Lookups, n: 1, expected: true, left: 2},
+		{name: "Unlimited kind", guildID: "g", kind: Mirrors, n: 100, expected: true, left: -1},
+		{name: "Outside guilds", guildID: "", kind: Lookups, n: 100, expected: true, left: 3},
+		{name: "Next day", advance: time.Hour, guildID: "g", kind: Lookups, n: 1, expected: true, left: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.advance)
+			if ok := b.Spend(tc.guildID, tc.kind, tc.n); ok != tc.expected {
+				t.Errorf("Spend(%q, %s, %d) = %v; want %v", tc.guildID, tc.kind, tc.n, ok, tc.expected)
+			}
+			if left := b.Left(tc.guildID, tc.kind); left != tc.left {
+				t.Errorf("Left(%q, %s) = %d; want %d", tc.guildID, tc.kind, left, tc.left)
+			}
+		})
+	}
+
+	// Only the other guild's count from yesterday is left to forget
+	if pruned := b.Prune(now); pruned != 1 || b.Len() != 1 {
+		t.Errorf("Prune = %d, leaving %d; want 1, leaving 1", pruned, b.Len())
+	}
+
+	var none *Budget
+	if !none.Spend("g", Lookups, 1000) || none.Left("g", Lookups) != -1 {
+		t.Error("nil Budget held work back")
+	}
+}
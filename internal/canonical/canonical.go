@@ -0,0 +1,166 @@
+// Package canonical remembers the canonical form of the links the bot
+// resolves, such as where a shortened link leads, without mobile hosts or
+// tracking parameters, so sharing the same link again needs no requests, even
+// after a restart.
+package canonical
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding resolved links, keyed by a hash of the
+// link's canonical form.
+const Bucket = "canonical_links"
+
+const (
+	// DefaultTTL is how long a resolved link is remembered.
+	DefaultTTL = 7 * 24 * time.Hour
+	// MaxEntries bounds how many resolved links are stored. Once it's reached,
+	// links are resolved as usual but not remembered until some expire.
+	MaxEntries = 5000
+)
+
+// mobileHosts maps the mobile sites of platforms to their main site.
+var mobileHosts = map[string]string{
+	"mobile.twitter.com": "twitter.com",
+	"m.twitter.com":      "twitter.com",
+	"mobile.x.com":       "x.com",
+	"m.youtube.com":      "youtube.com",
+	"m.facebook.com":     "facebook.com",
+	"m.twitch.tv":        "twitch.tv",
+	"m.reddit.com":       "reddit.com",
+	"m.tiktok.com":       "tiktok.com",
+}
+
+// Normalize returns the canonical form of link: its scheme and host in lower
+// case, without a default port, on the main site rather than a mobile one,
+// and without tracking parameters. Links that aren't web URLs are returned
+// unchanged.
+func Normalize(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || (!strings.EqualFold(u.Scheme, "http") && !strings.EqualFold(u.Scheme, "https")) || u.Host == "" {
+		return link
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if main, ok := mobileHosts[host]; ok {
+		host = main
+	}
+	if (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host += ":" + port
+	}
+	return fixers.CleanURL(u.String())
+}
+
+// entry is a resolved link.
+type entry struct {
+	URL     string    `json:"url"`
+	Expires time.Time `json:"expires"`
+}
+
+// Cache stores where links resolve to for a while. A nil Cache remembers
+// nothing. It is safe for concurrent use.
+type Cache struct {
+	store storage.Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	// mu keeps concurrent Puts from going over MaxEntries.
+	mu sync.Mutex
+}
+
+// New returns a Cache keeping resolved links in st for ttl.
+func New(st storage.Store, ttl time.Duration) *Cache {
+	return &Cache{store: st, ttl: ttl, now: time.Now}
+}
+
+// key is where link's resolution is stored. Links are hashed since they may be
+// long and hold anything.
+func key(link string) string {
+	sum := sha256.Sum256([]byte(Normalize(link)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Get returns what link resolved to, if that's remembered and hasn't expired.
+func (c *Cache) Get(link string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	var e entry
+	ok, err := c.store.Get(Bucket, key(link), &e)
+	if err != nil {
+		log.Println("Error loading resolved link:", err)
+		return "", false
+	}
+	if !ok || !c.now().Before(e.Expires) {
+		return "", false
+	}
+	return e.URL, true
+}
+
+// Put remembers that link resolved to resolved.
+func (c *Cache) Put(link, resolved string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := key(link)
+	if len(c.store.Keys(Bucket)) >= MaxEntries {
+		if ok, _ := c.store.Get(Bucket, k, &entry{}); !ok && c.prune(c.now()) == 0 {
+			return
+		}
+	}
+	if err := c.store.Put(Bucket, k, entry{URL: resolved, Expires: c.now().Add(c.ttl)}); err != nil {
+		log.Println("Error saving resolved link:", err)
+	}
+}
+
+// Prune forgets expired links and returns how many it removed. It implements
+// janitor.Pruner.
+func (c *Cache) Prune(now time.Time) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.prune(now)
+}
+
+// prune forgets links expired by now. The caller must hold c.mu.
+func (c *Cache) prune(now time.Time) int {
+	pruned := 0
+	for _, k := range c.store.Keys(Bucket) {
+		var e entry
+		if ok, err := c.store.Get(Bucket, k, &e); err != nil || !ok || now.Before(e.Expires) {
+			continue
+		}
+		if err := c.store.Delete(Bucket, k); err != nil {
+			log.Println("Error removing resolved link:", err)
+			continue
+		}
+		pruned++
+	}
+	return pruned
+}
+
+// Len returns how many resolved links are stored.
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.store.Keys(Bucket))
+}
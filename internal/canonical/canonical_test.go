@@ -0,0 +1,70 @@
+package canonical
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go-discord-bot/internal/storage"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "https://example.com/page", expected: "https://example.com/page"},
+		{input: "HTTPS://Example.COM:443/Page", expected: "https://example.com/Page"},
+		{input: "http://example.com:8080/a", expected: "http://example.com:8080/a"},
+		{input: "https://mobile.twitter.com/user/status/1?s=20&t=abc", expected: "https://twitter.com/user/status/1"},
+		{input: "https://m.youtube.com/watch?v=abc&utm_source=share", expected: "https://youtube.com/watch?v=abc"},
+		{input: "https://example.com/?fbclid=1", expected: "https://example.com/"},
+		{input: "mailto:someone@example.com", expected: "mailto:someone@example.com"},
+		{input: "not a link", expected: "not a link"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := Normalize(tc.input); got != tc.expected {
+				t.Errorf("Normalize(%q) = %q; want %q", tc.input, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	now := time.Unix(1000, 0)
+	st := storage.NewMemory()
+	c := New(st, time.Hour)
+	c.now = func() time.Time { return now }
+
+	c.Put("https://bit.ly/abc?utm_source=x", "https://example.com/landing")
+	// Links differing only in tracking share an entry
+	if got, ok := c.Get("https://BIT.LY/abc"); !ok || got != "https://example.com/landing" {
+		t.Errorf("Get = %q, %v; want the landing page", got, ok)
+	}
+	if _, ok := c.Get("https://bit.ly/other"); ok {
+		t.Error("Get found a link never stored")
+	}
+
+	now = now.Add(time.Hour)
+	if _, ok := c.Get("https://bit.ly/abc"); ok {
+		t.Error("Get found an expired link")
+	}
+	if pruned := c.Prune(now); pruned != 1 || c.Len() != 0 {
+		t.Errorf("Prune = %d, leaving %d; want 1, leaving 0", pruned, c.Len())
+	}
+
+	for n := range MaxEntries + 1 {
+		c.Put(fmt.Sprintf("https://bit.ly/%d", n), "https://example.com")
+	}
+	if c.Len() != MaxEntries {
+		t.Errorf("stored %d links; want at most %d", c.Len(), MaxEntries)
+	}
+
+	var none *Cache
+	none.Put("https://bit.ly/abc", "https://example.com")
+	if _, ok := none.Get("https://bit.ly/abc"); ok || none.Len() != 0 || none.Prune(now) != 0 {
+		t.Error("nil Cache remembered a link")
+	}
+}
@@ -0,0 +1,169 @@
+// Package channels remembers what the bot needs to know about the channels it
+// sees messages in, such as their type and, for threads and forum posts, the
+// channel they're in, so it can treat each kind of channel the right way.
+package channels
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ttl is how long a channel is remembered after it was last needed. Channel
+// events keep what's remembered up to date, this only keeps channels nobody
+// posts in from piling up.
+const ttl = 24 * time.Hour
+
+// Session is the subset of *discordgo.Session a Cache uses.
+type Session interface {
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+}
+
+// Info is what a Cache knows about a channel.
+type Info struct {
+	Type discordgo.ChannelType
+	// ParentID is the channel a thread or forum post is in, empty for other channels.
+	ParentID string
+	// AppliedTags are the IDs of the tags a forum post has.
+	AppliedTags []string
+	// AvailableTags are the tags a forum channel's posts can have.
+	AvailableTags []discordgo.ForumTag
+	// NSFW is whether the channel is age-restricted. Threads are if the
+	// channel they're in is.
+	NSFW bool
+}
+
+// Thread reports whether the channel is a thread or forum post.
+func (i Info) Thread() bool {
+	return i.ParentID != ""
+}
+
+// VoiceChat reports whether the channel is the text chat of a voice or stage channel.
+func (i Info) VoiceChat() bool {
+	return i.Type == discordgo.ChannelTypeGuildVoice || i.Type == discordgo.ChannelTypeGuildStageVoice
+}
+
+// entry is a remembered channel.
+type entry struct {
+	info Info
+	seen time.Time
+}
+
+// Cache remembers channels, looking them up the first time they're asked
+// about. A nil Cache knows nothing and treats every channel as a plain text
+// channel. It is safe for concurrent use.
+type Cache struct {
+	mu       sync.Mutex
+	channels map[string]entry
+	now      func() time.Time
+}
+
+// New returns an empty Cache.
+func New() *Cache {
+	return &Cache{channels: make(map[string]entry), now: time.Now}
+}
+
+// Get returns what's known about channelID, looking it up if nothing is.
+func (c *Cache) Get(ctx context.Context, s Session, channelID string) (Info, error) {
+	if c == nil {
+		return Info{}, nil
+	}
+	c.mu.Lock()
+	known, ok := c.channels[channelID]
+	if ok {
+		known.seen = c.now()
+		c.channels[channelID] = known
+	}
+	c.mu.Unlock()
+	if ok {
+		return known.info, nil
+	}
+
+	ch, err := s.Channel(channelID, discordgo.WithContext(ctx))
+	if err != nil {
+		return Info{}, err
+	}
+	c.Remember(ch)
+	return infoOf(ch), nil
+}
+
+// TagNames returns the names of the tags a forum post with info has, looking
+// up the forum it's in if need be.
+func (c *Cache) TagNames(ctx context.Context, s Session, info Info) ([]string, error) {
+	if len(info.AppliedTags) == 0 {
+		return nil, nil
+	}
+	forum, err := c.Get(ctx, s, info.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, tag := range forum.AvailableTags {
+		for _, id := range info.AppliedTags {
+			if tag.ID == id {
+				names = append(names, tag.Name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// Remember notes what ch, such as a channel the gateway announced or
+// updated, is like, saving a lookup later.
+func (c *Cache) Remember(ch *discordgo.Channel) {
+	if c == nil || ch == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.channels[ch.ID] = entry{info: infoOf(ch), seen: c.now()}
+}
+
+// Forget drops channelID, such as when it's deleted.
+func (c *Cache) Forget(channelID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.channels, channelID)
+}
+
+// infoOf returns what a Cache keeps of ch.
+func infoOf(ch *discordgo.Channel) Info {
+	info := Info{Type: ch.Type, AppliedTags: ch.AppliedTags, AvailableTags: ch.AvailableTags, NSFW: ch.NSFW}
+	if ch.IsThread() {
+		info.ParentID = ch.ParentID
+	}
+	return info
+}
+
+// Prune forgets channels not asked about in the last day and returns how many
+// it forgot.
+func (c *Cache) Prune(now time.Time) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pruned := 0
+	for id, known := range c.channels {
+		if now.Sub(known.seen) >= ttl {
+			delete(c.channels, id)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many channels the Cache remembers.
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.channels)
+}
@@ -0,0 +1,101 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeSession serves channels and counts lookups.
+type fakeSession struct {
+	channels map[string]*discordgo.Channel
+	lookups  int
+}
+
+func (f *fakeSession) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.lookups++
+	ch, ok := f.channels[channelID]
+	if !ok {
+		return nil, fmt.Errorf("unknown channel %s", channelID)
+	}
+	return ch, nil
+}
+
+func TestGet(t *testing.T) {
+	s := &fakeSession{channels: map[string]*discordgo.Channel{
+		"text":   {ID: "text", Type: discordgo.ChannelTypeGuildText, ParentID: "category"},
+		"voice":  {ID: "voice", Type: discordgo.ChannelTypeGuildVoice},
+		"thread": {ID: "thread", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "text"},
+		"post":   {ID: "post", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "forum"},
+	}}
+
+	testCases := []struct {
+		name      string
+		channelID string
+		thread    bool
+		parentID  string
+		voiceChat bool
+		wantErr   bool
+	}{
+		{name: "Text channel", channelID: "text"},
+		{name: "Voice channel", channelID: "voice", voiceChat: true},
+		{name: "Thread", channelID: "thread", thread: true, parentID: "text"},
+		{name: "Forum post", channelID: "post", thread: true, parentID: "forum"},
+		{name: "Unknown channel", channelID: "gone", wantErr: true},
+	}
+
+	c := New()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := c.Get(context.Background(), s, tc.channelID)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Get error = %v; want error %v", err, tc.wantErr)
+			}
+			if info.Thread() != tc.thread || info.ParentID != tc.parentID || info.VoiceChat() != tc.voiceChat {
+				t.Errorf("Get = %+v; want thread %v in %q, voice chat %v", info, tc.thread, tc.parentID, tc.voiceChat)
+			}
+		})
+	}
+
+	// Known channels aren't looked up again, including remembered ones
+	c.Remember(&discordgo.Channel{ID: "announced", Type: discordgo.ChannelTypeGuildPrivateThread, ParentID: "text"})
+	lookups := s.lookups
+	for _, id := range []string{"text", "thread", "announced"} {
+		c.Get(context.Background(), s, id)
+	}
+	if s.lookups != lookups {
+		t.Errorf("looked channels up %d more times; want 0", s.lookups-lookups)
+	}
+
+	// Updates replace what's known
+	c.Remember(&discordgo.Channel{ID: "text", Type: discordgo.ChannelTypeGuildNews})
+	if info, _ := c.Get(context.Background(), s, "text"); info.Type != discordgo.ChannelTypeGuildNews {
+		t.Errorf("Get after an update = %+v; want an announcement channel", info)
+	}
+	c.Forget("announced")
+
+	if pruned := c.Prune(time.Now().Add(ttl)); pruned != 4 || c.Len() != 0 {
+		t.Errorf("Prune = %d leaving %d; want 4 leaving 0", pruned, c.Len())
+	}
+
+	var none *Cache
+	if info, err := none.Get(context.Background(), s, "thread"); info.Thread() || err != nil {
+		t.Errorf("nil Get = %+v, %v; want nothing", info, err)
+	}
+}
+
+func TestTagNames(t *testing.T) {
+	s := &fakeSession{channels: map[string]*discordgo.Channel{
+		"forum": {ID: "forum", Type: discordgo.ChannelTypeGuildForum, AvailableTags: []discordgo.ForumTag{{ID: "1", Name: "art"}, {ID: "2", Name: "raw"}}},
+	}}
+	c := New()
+	post := Info{Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "forum", AppliedTags: []string{"2"}}
+	names, err := c.TagNames(context.Background(), s, post)
+	if err != nil || !slices.Equal(names, []string{"raw"}) {
+		t.Errorf("TagNames = %q, %v; want [raw]", names, err)
+	}
+}
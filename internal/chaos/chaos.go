@@ -0,0 +1,185 @@
+// Package chaos makes Discord's API slow and unreliable on purpose, in test
+// profiles, to check the retry, queueing and timeout code copes: requests are
+// delayed, and some are answered with rate limits or server errors instead of
+// reaching Discord. The same seed fails the same requests, so a run that
+// found a problem can be repeated.
+package chaos
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRetryAfter is how long injected rate limits ask clients to wait.
+const DefaultRetryAfter = time.Second
+
+// Profile is how unreliable to make the API.
+type Profile struct {
+	// Seed picks which requests are delayed and failed.
+	Seed uint64
+	// Latency is added to every request, plus up to Jitter more at random.
+	Latency time.Duration
+	Jitter  time.Duration
+	// RateLimits and ServerErrors are the fractions of requests answered
+	// with 429 Too Many Requests and with a 5xx error.
+	RateLimits   float64
+	ServerErrors float64
+	// RetryAfter is how long injected rate limits ask clients to wait.
+	RetryAfter time.Duration
+}
+
+// Parse reads a profile written as comma-separated settings, such as
+// "seed=42,latency=200ms,jitter=300ms,429=0.1,5xx=0.05,retry_after=2s".
+// Settings left out are off.
+func Parse(spec string) (Profile, error) {
+	p := Profile{RetryAfter: DefaultRetryAfter}
+	for _, setting := range strings.Split(spec, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return p, fmt.Errorf("%q isn't name=value", setting)
+		}
+		var err error
+		switch name {
+		case "seed":
+			p.Seed, err = strconv.ParseUint(value, 10, 64)
+		case "latency":
+			p.Latency, err = time.ParseDuration(value)
+		case "jitter":
+			p.Jitter, err = time.ParseDuration(value)
+		case "retry_after":
+			p.RetryAfter, err = time.ParseDuration(value)
+		case "429":
+			p.RateLimits, err = parseFraction(value)
+		case "5xx":
+			p.ServerErrors, err = parseFraction(value)
+		default:
+			return p, fmt.Errorf("unknown setting %q", name)
+		}
+		if err != nil {
+			return p, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if p.Latency < 0 || p.Jitter < 0 || p.RetryAfter < 0 {
+		return p, fmt.Errorf("durations can't be negative")
+	}
+	if p.RateLimits+p.ServerErrors > 1 {
+		return p, fmt.Errorf("429 and 5xx add up to more than every request")
+	}
+	return p, nil
+}
+
+// parseFraction reads a fraction between 0 and 1.
+func parseFraction(value string) (float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f < 0 || f > 1 {
+		return 0, fmt.Errorf("%v isn't between 0 and 1", f)
+	}
+	return f, nil
+}
+
+// String describes the profile for the logs.
+func (p Profile) String() string {
+	return fmt.Sprintf("seed %d, %s latency plus up to %s, %.0f%% rate limited, %.0f%% server errors",
+		p.Seed, p.Latency, p.Jitter, p.RateLimits*100, p.ServerErrors*100)
+}
+
+// serverErrors are the statuses injected server errors have, picked at random.
+var serverErrors = []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable}
+
+// Transport returns a transport passing requests to base, or
+// http.DefaultTransport if it's nil, after the profile's latency, unless it
+// fails them.
+func (p Profile) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{profile: p, base: base, rng: rand.New(rand.NewPCG(p.Seed, p.Seed))}
+}
+
+type transport struct {
+	profile Profile
+	base    http.RoundTripper
+
+	// mu guards rng, whose draws are what make a seed repeatable
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// fate is what happens to one request.
+type fate struct {
+	delay  time.Duration
+	status int
+}
+
+// draw decides the fate of the next request.
+func (t *transport) draw() fate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f := fate{delay: t.profile.Latency}
+	if t.profile.Jitter > 0 {
+		f.delay += time.Duration(t.rng.Int64N(int64(t.profile.Jitter)))
+	}
+	switch roll := t.rng.Float64(); {
+	case roll < t.profile.RateLimits:
+		f.status = http.StatusTooManyRequests
+	case roll < t.profile.RateLimits+t.profile.ServerErrors:
+		f.status = serverErrors[t.rng.IntN(len(serverErrors))]
+	}
+	return f
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f := t.draw()
+	if f.delay > 0 {
+		timer := time.NewTimer(f.delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if f.status == 0 {
+		return t.base.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	var body string
+	if f.status == http.StatusTooManyRequests {
+		seconds := strconv.FormatFloat(t.profile.RetryAfter.Seconds(), 'f', -1, 64)
+		header.Set("Retry-After", seconds)
+		header.Set("X-RateLimit-Scope", "user")
+		body = fmt.Sprintf(`{"message": "You are being rate limited (chaos).", "retry_after": %s, "global": false}`, seconds)
+	} else {
+		body = fmt.Sprintf(`{"message": "%d: %s (chaos)", "code": 0}`, f.status, http.StatusText(f.status))
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.status, http.StatusText(f.status)),
+		StatusCode:    f.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
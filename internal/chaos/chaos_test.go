@@ -0,0 +1,122 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/retry"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		expected Profile
+		wantErr  bool
+	}{
+		{spec: "", expected: Profile{RetryAfter: DefaultRetryAfter}},
+		{
+			spec:     "seed=42, latency=200ms,jitter=300ms,429=0.1,5xx=0.05,retry_after=2s",
+			expected: Profile{Seed: 42, Latency: 200 * time.Millisecond, Jitter: 300 * time.Millisecond, RateLimits: 0.1, ServerErrors: 0.05, RetryAfter: 2 * time.Second},
+		},
+		{spec: "latency", wantErr: true},
+		{spec: "latency=soon", wantErr: true},
+		{spec: "jitter=-1s", wantErr: true},
+		{spec: "429=1.5", wantErr: true},
+		{spec: "429=0.6,5xx=0.6", wantErr: true},
+		{spec: "timeouts=0.1", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			p, err := Parse(tc.spec)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Parse(%q) error = %v; want error %v", tc.spec, err, tc.wantErr)
+			}
+			if !tc.wantErr && p != tc.expected {
+				t.Errorf("Parse(%q) = %+v; want %+v", tc.spec, p, tc.expected)
+			}
+		})
+	}
+}
+
+// statuses sends n requests through a transport for p and returns the status
+// of each.
+func statuses(t *testing.T, p Profile, url string, n int) []int {
+	t.Helper()
+	client := &http.Client{Transport: p.Transport(nil)}
+	var got []int
+	for range n {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		got = append(got, resp.StatusCode)
+	}
+	return got
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	p := Profile{Seed: 7, RateLimits: 0.2, ServerErrors: 0.1, RetryAfter: time.Millisecond}
+
+	got := statuses(t, p, server.URL, 200)
+	if again := statuses(t, p, server.URL, 200); !slices.Equal(got, again) {
+		t.Error("the same seed failed different requests")
+	}
+	counts := make(map[int]int)
+	for _, status := range got {
+		counts[status/100*100]++
+	}
+	if counts[400] < 20 || counts[400] > 60 || counts[500] < 5 || counts[500] > 40 || counts[200] < 120 {
+		t.Errorf("statuses by class = %v; want about 40 rate limits and 20 server errors in 200", counts)
+	}
+
+	// Latency counts against the request's deadline
+	slow := &http.Client{Transport: Profile{Latency: time.Second}.Transport(nil)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := slow.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("slow request error = %v; want the deadline exceeded", err)
+	}
+}
+
+// TestRetryUnderChaos checks the retry policy gets every request through an
+// API failing a third of them, the way it classifies discordgo's errors.
+func TestRetryUnderChaos(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := &http.Client{Transport: Profile{Seed: 3, RateLimits: 0.2, ServerErrors: 0.15, RetryAfter: time.Millisecond}.Transport(nil)}
+	policy := retry.Policy{MaxAttempts: 10, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	retried := 0
+	for range 50 {
+		err := policy.Do(context.Background(), "send message", func() error {
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				retried++
+				return &discordgo.RESTError{Response: resp}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("request failed despite retries: %v", err)
+		}
+	}
+	if retried == 0 {
+		t.Error("no request needed retrying")
+	}
+}
@@ -0,0 +1,59 @@
+// Package chunk splits long text into pieces that fit in a Discord message.
+package chunk
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is the most characters Discord accepts in one message.
+const MaxMessageLength = 2000
+
+// Split breaks content into pieces of at most limit characters.
+// It prefers to break at newlines, then at other whitespace, so links and
+// words stay whole; only a single unbroken run longer than limit is cut mid-run.
+// Whitespace at a break is dropped.
+func Split(content string, limit int) []string {
+	if limit < 1 {
+		limit = MaxMessageLength
+	}
+
+	var pieces []string
+	for utf8.RuneCountInString(content) > limit {
+		// Look one character past the limit so a break right after a full piece counts
+		window := prefix(content, limit+1)
+
+		cut := strings.LastIndexByte(window, '\n')
+		if cut <= 0 {
+			cut = strings.LastIndexFunc(window, unicode.IsSpace)
+		}
+		if cut <= 0 {
+			// No whitespace to break at, cut at the limit
+			piece := prefix(content, limit)
+			pieces = append(pieces, piece)
+			content = content[len(piece):]
+			continue
+		}
+
+		if piece := strings.TrimRightFunc(window[:cut], unicode.IsSpace); piece != "" {
+			pieces = append(pieces, piece)
+		}
+		content = strings.TrimLeftFunc(content[cut:], unicode.IsSpace)
+	}
+	if content != "" {
+		pieces = append(pieces, content)
+	}
+	return pieces
+}
+
+// prefix returns the first n runes of s.
+func prefix(s string, n int) string {
+	i := 0
+	for n > 0 && i < len(s) {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n--
+	}
+	return s[:i]
+}
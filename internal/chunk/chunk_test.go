@@ -0,0 +1,45 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		limit    int
+		expected []string
+	}{
+		{name: "Short message", input: "hello", limit: 10, expected: []string{"hello"}},
+		{name: "Empty message", input: "", limit: 10, expected: nil},
+		{name: "Prefers newlines", input: "one two\nthree four", limit: 12, expected: []string{"one two", "three four"}},
+		{name: "Falls back to spaces", input: "aaa bbb ccc ddd", limit: 8, expected: []string{"aaa bbb", "ccc ddd"}},
+		{name: "Keeps links whole", input: "see https://x.com/a/status/1 ok", limit: 27, expected: []string{"see", "https://x.com/a/status/1 ok"}},
+		{name: "Hard cut without whitespace", input: "abcdefghij", limit: 4, expected: []string{"abcd", "efgh", "ij"}},
+		{name: "Counts runes not bytes", input: "ééé ééé", limit: 3, expected: []string{"ééé", "ééé"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Split(tc.input, tc.limit)
+			if strings.Join(result, "|") != strings.Join(tc.expected, "|") || len(result) != len(tc.expected) {
+				t.Errorf("Split(%q, %d) = %q; want %q", tc.input, tc.limit, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSplitRespectsLimit(t *testing.T) {
+	input := strings.Repeat("word https://fxtwitter.com/user/status/1234567890\n", 200)
+	for _, piece := range Split(input, MaxMessageLength) {
+		if n := utf8.RuneCountInString(piece); n > MaxMessageLength {
+			t.Fatalf("piece of %d characters exceeds the limit", n)
+		}
+		if strings.Contains(piece, "https://fxtwitter.com/user/status/1234567890") == false {
+			t.Fatalf("piece %q lost a link", piece[:40])
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/version"
+)
+
+// NewAbout builds the /about command that shows what build is deployed and how it's doing.
+// started is when the process started; guildCount reports how many guilds this
+// process serves and may be nil when that isn't known.
+func NewAbout(started time.Time, guildCount func() int) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "about",
+			Description: "Show the bot's version, uptime and shard",
+		},
+		Module: ModuleBot,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			guilds := -1
+			if guildCount != nil {
+				guilds = guildCount()
+			}
+			RespondEmbed(ctx, s, i, aboutEmbed(s.ShardID, s.ShardCount, guilds, time.Since(started)))
+		},
+	}
+}
+
+// aboutEmbed renders the /about embed. A negative guild count is shown as unknown.
+func aboutEmbed(shardID, shardCount, guilds int, uptime time.Duration) *discordgo.MessageEmbed {
+	guildText := "unknown"
+	if guilds >= 0 {
+		guildText = fmt.Sprint(guilds)
+	}
+	if shardCount < 1 {
+		shardCount = 1
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: "About this bot",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Version", Value: version.String(), Inline: true},
+			{Name: "Go", Value: runtime.Version(), Inline: true},
+			{Name: "Uptime", Value: uptime.Round(time.Second).String(), Inline: true},
+			{Name: "Shard", Value: fmt.Sprintf("%d of %d", shardID+1, shardCount), Inline: true},
+			{Name: "Guilds", Value: guildText, Inline: true},
+		},
+	}
+}
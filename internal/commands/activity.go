@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/stats"
+)
+
+// Formats /activity can export in.
+const (
+	activityCSV  = "csv"
+	activityJSON = "json"
+)
+
+// activityDays is how many days /activity covers when no start is given.
+const activityDays = 30
+
+// activityDate is how /activity takes and writes dates.
+const activityDate = "2006-01-02"
+
+// NewActivity builds the /activity command, which exports what the bot did
+// in the guild day by day, and which commands were used, as a file for
+// moderators to review, for any of the last stats.KeptDays days.
+func NewActivity(collector *stats.Collector) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "activity",
+			Description:      "Export what the bot did in this server, day by day",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "from", Description: fmt.Sprintf("The first day, like 2024-05-01 (default %d days ago)", activityDays-1), MaxLength: 10},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "to", Description: "The last day, like 2024-05-31 (default today)", MaxLength: 10},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "format",
+					Description: "The file's format (default CSV)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "CSV", Value: activityCSV},
+						{Name: "JSON", Value: activityJSON},
+					},
+				},
+			},
+		},
+		Module:      ModuleStats,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			if logging.Private(i.GuildID) {
+				RespondEphemeral(ctx, s, i, "This server is in privacy mode, so the bot doesn't keep stats.")
+				return
+			}
+			opts := OptionMap(i.ApplicationCommandData().Options)
+			var fromText, toText string
+			if opt, ok := opts["from"]; ok {
+				fromText = opt.StringValue()
+			}
+			if opt, ok := opts["to"]; ok {
+				toText = opt.StringValue()
+			}
+			from, to, err := activityRange(fromText, toText, time.Now())
+			if err != nil {
+				RespondEphemeral(ctx, s, i, "Can't export those days: "+err.Error()+".")
+				return
+			}
+			format := activityCSV
+			if opt, ok := opts["format"]; ok {
+				format = opt.StringValue()
+			}
+			days, err := collector.Days(i.GuildID, from, to)
+			if err != nil {
+				log.Println("Error loading activity:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't load this server's activity, try again later.")
+				return
+			}
+			data, err := activityReport(days, format, i.GuildID, from, to)
+			if err != nil {
+				log.Println("Error encoding activity report:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't export this server's activity.")
+				return
+			}
+
+			contentType := "text/csv"
+			if format == activityJSON {
+				contentType = "application/json"
+			}
+			respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("Here's what the bot did here from %s to %s (UTC), on %d days with activity.",
+					from.Format(activityDate), to.Format(activityDate), len(days)),
+				Files: []*discordgo.File{{
+					Name:        fmt.Sprintf("activity-%s-%s-%s.%s", i.GuildID, from.Format(activityDate), to.Format(activityDate), format),
+					ContentType: contentType,
+					Reader:      bytes.NewReader(data),
+				}},
+				Flags: discordgo.MessageFlagsEphemeral,
+			})
+		},
+	}
+}
+
+// activityRange reads the days /activity was asked for, either of which may
+// be empty for the default, as of now. Both must be among the last
+// stats.KeptDays days.
+func activityRange(fromText, toText string, now time.Time) (time.Time, time.Time, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	to := today
+	if toText != "" {
+		var err error
+		if to, err = time.Parse(activityDate, toText); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("`%s` isn't a date, use one like 2024-05-31", toText)
+		}
+	}
+	from := to.AddDate(0, 0, -activityDays+1)
+	if fromText != "" {
+		var err error
+		if from, err = time.Parse(activityDate, fromText); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("`%s` isn't a date, use one like 2024-05-01", fromText)
+		}
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("the first day comes after the last one")
+	}
+	if oldest := today.AddDate(0, 0, -stats.KeptDays+1); from.Before(oldest) || to.After(today) {
+		return time.Time{}, time.Time{}, fmt.Errorf("only the last %d days are kept, from %s to %s", stats.KeptDays, oldest.Format(activityDate), today.Format(activityDate))
+	}
+	return from, to, nil
+}
+
+// activityReport writes days in format. CSV has a row per count, such as
+// "2024-05-01,command,stats,3"; JSON has the days as stats.Day.
+func activityReport(days []stats.Day, format, guildID string, from, to time.Time) ([]byte, error) {
+	if format == activityJSON {
+		if days == nil {
+			days = []stats.Day{}
+		}
+		return json.MarshalIndent(struct {
+			GuildID string      `json:"guild_id"`
+			From    string      `json:"from"`
+			To      string      `json:"to"`
+			Days    []stats.Day `json:"days"`
+		}{guildID, from.Format(activityDate), to.Format(activityDate), days}, "", "  ")
+	}
+
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"date", "action", "subject", "count"})
+	for _, d := range days {
+		w.Write([]string{d.Date, "repost", "", strconv.Itoa(d.Reposts)})
+		w.Write([]string{d.Date, "links_fixed", "", strconv.Itoa(d.LinksFixed)})
+		w.Write([]string{d.Date, "duplicate", "", strconv.Itoa(d.Duplicates)})
+		for _, user := range slices.Sorted(maps.Keys(d.Users)) {
+			w.Write([]string{d.Date, "user_links", user, strconv.Itoa(d.Users[user])})
+		}
+		for _, name := range slices.Sorted(maps.Keys(d.Commands)) {
+			w.Write([]string{d.Date, "command", name, strconv.Itoa(d.Commands[name])})
+		}
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
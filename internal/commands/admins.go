@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// adminsConfigGroup defines the /config admins subcommands.
+func adminsConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "admins",
+		Description: "Roles that may change the bot's settings without Manage Server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "role",
+				Description: "Let a role use the admin commands, or stop letting it",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "The role", Required: true},
+					{Type: discordgo.ApplicationCommandOptionBoolean, Name: "remove", Description: "Take the role's access away instead"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the admin roles",
+			},
+		},
+	}
+}
+
+// handleAdminsConfig runs a /config admins subcommand.
+func handleAdminsConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+	if sub.Name == "list" {
+		RespondEphemeral(ctx, s, i, formatAdminRoles(cfg))
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	remove := false
+	if opt, ok := opts["remove"]; ok {
+		remove = opt.BoolValue()
+	}
+	cfg.AdminRoles = updateIDList(cfg.AdminRoles, opts["role"].Value.(string), remove)
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatAdminRoles(cfg))
+}
+
+// formatAdminRoles lists the admin roles as mentions.
+func formatAdminRoles(cfg config.Guild) string {
+	if len(cfg.AdminRoles) == 0 {
+		return "Only members with Manage Server can use the admin commands."
+	}
+	mentions := make([]string, len(cfg.AdminRoles))
+	for n, id := range cfg.AdminRoles {
+		mentions[n] = "<@&" + id + ">"
+	}
+	// Discord hides commands from members without their default permissions
+	return fmt.Sprintf("Members with %s can use the admin commands too. Allow the roles to see /config and /setup in Server Settings > Integrations.", strings.Join(mentions, ", "))
+}
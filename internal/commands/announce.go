@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/announcements"
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/timestamp"
+)
+
+// NewAnnounce builds the /announce command, which schedules messages posted
+// again and again, such as event reminders or the rules.
+func NewAnnounce(st storage.Store) Command {
+	channelTypes := []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews}
+	scheduleOptions := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "when",
+			Description: "Minute hour day month weekday, like 0 18 * * fri, or @daily or @weekly",
+			Required:    true,
+			MaxLength:   100,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "message",
+			Description: `What's posted, using \n for new lines`,
+			Required:    true,
+			MaxLength:   announcements.MaxLength,
+		},
+		{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "channel",
+			Description:  "Where it's posted",
+			Required:     true,
+			ChannelTypes: channelTypes,
+		},
+	}
+	for n := 2; n <= announcements.MaxChannels; n++ {
+		scheduleOptions = append(scheduleOptions, &discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         fmt.Sprintf("channel%d", n),
+			Description:  "Another channel it's posted in",
+			ChannelTypes: channelTypes,
+		})
+	}
+
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "announce",
+			Description:      "Post messages on a schedule",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "schedule",
+					Description: "Post a message on a schedule, in the server's time zone",
+					Options:     scheduleOptions,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Stop posting a scheduled message",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "The announcement's ID from /announce list", Required: true},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show the messages scheduled in this server",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+
+			sub := i.ApplicationCommandData().Options[0]
+			switch sub.Name {
+			case "schedule":
+				handleAnnounceSchedule(ctx, s, i, st, sub)
+			case "remove":
+				id := strings.TrimSpace(OptionMap(sub.Options)["id"].StringValue())
+				removed, err := announcements.Remove(st, i.GuildID, id)
+				if err != nil {
+					log.Println("Error removing announcement:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't remove that announcement, try again later.")
+					return
+				}
+				if !removed {
+					RespondEphemeral(ctx, s, i, "There's no announcement `"+id+"` in this server. Use `/announce list` to see the IDs.")
+					return
+				}
+				RespondEphemeral(ctx, s, i, "Removed announcement `"+id+"`.")
+			case "list":
+				all, err := announcements.List(st, i.GuildID)
+				if err != nil {
+					log.Println("Error listing announcements:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't load this server's announcements, try again later.")
+					return
+				}
+				RespondEphemeral(ctx, s, i, formatAnnouncements(all))
+			}
+		},
+	}
+}
+
+// handleAnnounceSchedule runs /announce schedule: it checks the schedule and
+// saves the announcement, due next time the schedule fires.
+func handleAnnounceSchedule(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	opts := OptionMap(sub.Options)
+	a := announcements.Announcement{
+		GuildID:  i.GuildID,
+		Schedule: strings.Join(strings.Fields(opts["when"].StringValue()), " "),
+		Message:  strings.TrimSpace(strings.ReplaceAll(opts["message"].StringValue(), `\n`, "\n")),
+	}
+	for n := 1; n <= announcements.MaxChannels; n++ {
+		name := "channel"
+		if n > 1 {
+			name = fmt.Sprintf("channel%d", n)
+		}
+		if opt, ok := opts[name]; ok && !slices.Contains(a.ChannelIDs, opt.ChannelValue(nil).ID) {
+			a.ChannelIDs = append(a.ChannelIDs, opt.ChannelValue(nil).ID)
+		}
+	}
+	if a.Message == "" {
+		RespondEphemeral(ctx, s, i, "The message can't be empty.")
+		return
+	}
+	sched, err := announcements.ParseSchedule(a.Schedule)
+	if err != nil {
+		RespondEphemeral(ctx, s, i, "That schedule doesn't work: "+err.Error()+".")
+		return
+	}
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+	a.Next = sched.Next(time.Now(), cfg.Location())
+	if a.Next.IsZero() {
+		RespondEphemeral(ctx, s, i, "That schedule never comes round, check the day and month.")
+		return
+	}
+	a.ID = announcements.AnnouncementID(a.GuildID, a.Schedule, a.Message, a.ChannelIDs)
+
+	existing, err := announcements.List(st, i.GuildID)
+	if err != nil {
+		log.Println("Error listing announcements:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's announcements, try again later.")
+		return
+	}
+	if len(existing) >= announcements.MaxPerGuild {
+		RespondEphemeral(ctx, s, i, fmt.Sprintf("This server already has %d announcements, the most it can have. Remove one first.", announcements.MaxPerGuild))
+		return
+	}
+	if err := announcements.Save(st, a); err != nil {
+		log.Println("Error saving announcement:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save the announcement, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, fmt.Sprintf("Scheduled. It'll be posted in %s next %s, times read in %s. Its ID is `%s`.",
+		formatChannels(a.ChannelIDs), timestamp.Format(a.Next, timestamp.LongDateTime), cfg.Location(), a.ID))
+}
+
+// formatChannels mentions each of channelIDs.
+func formatChannels(channelIDs []string) string {
+	mentions := make([]string, len(channelIDs))
+	for n, id := range channelIDs {
+		mentions[n] = "<#" + id + ">"
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// formatAnnouncements lists a guild's announcements.
+func formatAnnouncements(all []announcements.Announcement) string {
+	if len(all) == 0 {
+		return "This server has no announcements. Schedule one with `/announce schedule`."
+	}
+	var b strings.Builder
+	b.WriteString("**Announcements**\n")
+	for _, a := range all {
+		fmt.Fprintf(&b, "`%s` `%s` in %s, next %s: %s\n", a.ID, a.Schedule, formatChannels(a.ChannelIDs), timestamp.Format(a.Next, timestamp.Relative), listed(a.Message))
+	}
+	return chunk.Split(strings.TrimSuffix(b.String(), "\n"), chunk.MaxMessageLength)[0]
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// auditConfigGroup defines the /config audit subcommands.
+func auditConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "audit",
+		Description: "Tell moderators when the bot acts on its own or someone uses /purge or /slowmode",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Choose the channel moderators are told in",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "A channel only moderators can see",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Stop telling moderators",
+			},
+		},
+	}
+}
+
+// handleAuditConfig runs a /config audit subcommand.
+func handleAuditConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	cfg.AuditChannel = ""
+	if sub.Name == "channel" {
+		cfg.AuditChannel = OptionMap(sub.Options)["channel"].ChannelValue(nil).ID
+	}
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	if cfg.AuditChannel == "" {
+		RespondEphemeral(ctx, s, i, "Saved. Moderators won't be told when the bot acts on its own or someone uses /purge or /slowmode.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, fmt.Sprintf("Saved. The bot will tell moderators in <#%s> when it acts on its own or someone uses /purge or /slowmode.", cfg.AuditChannel))
+}
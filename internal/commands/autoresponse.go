@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/responders"
+	"go-discord-bot/internal/storage"
+)
+
+// maxListedLength is how many characters of each message lists show.
+const maxListedLength = 60
+
+// NewAutoResponse builds the /autoresponse command, which manages the replies
+// the bot posts to messages matching a trigger.
+func NewAutoResponse(st storage.Store) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "autoresponse",
+			Description:      "Have the bot reply to messages with certain words in them",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Add an auto-response",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "trigger",
+							Description: "The words, or regular expression, messages are matched against",
+							Required:    true,
+							MaxLength:   responders.MaxTriggerLength,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "response",
+							Description: "What the bot replies",
+							Required:    true,
+							MaxLength:   responders.MaxResponseLength,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "match",
+							Description: "How messages are matched, exactly unless you pick another",
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "The whole message", Value: config.MatchExact},
+								{Name: "Anywhere in the message", Value: config.MatchContains},
+								{Name: "Regular expression", Value: config.MatchRegex},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Remove an auto-response",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionInteger, Name: "number", Description: "Number from /autoresponse list", Required: true},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List this server's auto-responses",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleAutoResponse(ctx, s, i, st, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleAutoResponse runs an /autoresponse subcommand.
+func handleAutoResponse(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	switch sub.Name {
+	case "add":
+		r := config.AutoResponse{
+			Trigger:  opts["trigger"].StringValue(),
+			Match:    config.MatchExact,
+			Response: strings.ReplaceAll(opts["response"].StringValue(), `\n`, "\n"),
+		}
+		if opt, ok := opts["match"]; ok {
+			r.Match = opt.StringValue()
+		}
+		if len(cfg.AutoResponses) >= responders.MaxResponses {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("This server already has the maximum of %d auto-responses.", responders.MaxResponses))
+			return
+		}
+		if err := responders.Validate(r); err != nil {
+			RespondEphemeral(ctx, s, i, "Auto-response not saved: "+err.Error())
+			return
+		}
+		cfg.AutoResponses = append(cfg.AutoResponses, r)
+
+	case "remove":
+		n := int(opts["number"].IntValue())
+		if n < 1 || n > len(cfg.AutoResponses) {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("There is no auto-response number %d.", n))
+			return
+		}
+		cfg.AutoResponses = append(cfg.AutoResponses[:n-1], cfg.AutoResponses[n:]...)
+
+	case "list":
+		RespondEphemeral(ctx, s, i, formatAutoResponses(cfg.AutoResponses))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatAutoResponses(cfg.AutoResponses))
+}
+
+// formatAutoResponses renders a numbered list of auto-responses.
+func formatAutoResponses(responses []config.AutoResponse) string {
+	if len(responses) == 0 {
+		return "No auto-responses configured."
+	}
+	var b strings.Builder
+	for n, r := range responses {
+		fmt.Fprintf(&b, "%d. %s\n", n+1, formatAutoResponse(r))
+	}
+	return b.String()
+}
+
+// formatAutoResponse describes an auto-response on one line.
+func formatAutoResponse(r config.AutoResponse) string {
+	match := r.Match
+	if match == "" {
+		match = config.MatchExact
+	}
+	return fmt.Sprintf("`%s` (%s) → %s", r.Trigger, match, listed(r.Response))
+}
+
+// listed returns the first line of text, cut short to keep lists readable.
+func listed(text string) string {
+	line, _, cut := strings.Cut(text, "\n")
+	if runes := []rune(line); cut || len(runes) > maxListedLength {
+		return strings.TrimSpace(string(runes[:min(len(runes), maxListedLength)])) + "…"
+	}
+	return line
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// MaxBackfill is the most messages /backfill scans, the most Discord returns at once.
+const MaxBackfill = 100
+
+// BackfillFunc queues fixes for links in a channel's last count messages that
+// were never fixed and returns how many messages it queued.
+type BackfillFunc func(ctx context.Context, s *discordgo.Session, guildID, channelID string, count int) (int, error)
+
+// NewBackfill builds the /backfill command, which fixes links in the channel's
+// recent history that the bot missed, such as while it was down.
+func NewBackfill(st storage.Store, backfill BackfillFunc) Command {
+	minCount := 1.0
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "backfill",
+			Description:      "Fix links in this channel's recent messages that the bot missed",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: fmt.Sprintf("How many recent messages to look through (at most %d)", MaxBackfill),
+					Required:    true,
+					MinValue:    &minCount,
+					MaxValue:    MaxBackfill,
+				},
+			},
+		},
+		Module:      ModuleLinks,
+		Permissions: manageGuild,
+		Cooldown:    Cooldown{Channel: time.Minute},
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			cfg, err := config.LoadGuild(st, i.GuildID)
+			if err != nil {
+				log.Println("Error loading guild config:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+				return
+			}
+			if !channelEnabled(ctx, s, cfg, i.ChannelID) {
+				RespondEphemeral(ctx, s, i, "Link fixing is off in this channel.")
+				return
+			}
+
+			count := int(OptionMap(i.ApplicationCommandData().Options)["count"].IntValue())
+			RespondEphemeral(ctx, s, i, backfillResult(backfill(ctx, s, i.GuildID, i.ChannelID, count)))
+		},
+	}
+}
+
+// channelEnabled reports whether links posted in channelID are fixed in a
+// guild with the settings cfg. Threads and forum posts follow the channel
+// they're in unless they were picked themselves.
+func channelEnabled(ctx context.Context, s *discordgo.Session, cfg config.Guild, channelID string) bool {
+	if cfg.ChannelEnabled(channelID) {
+		return true
+	}
+	ch, err := s.Channel(channelID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error looking up channel:", err)
+		return false
+	}
+	return ch.IsThread() && cfg.ChannelEnabled(ch.ParentID)
+}
+
+// backfillResult describes how a backfill went.
+func backfillResult(queued int, err error) string {
+	if err != nil {
+		log.Println("Error backfilling channel:", err)
+		return "Couldn't read this channel's messages. Check the bot can see its history."
+	}
+	if queued == 0 {
+		return "Found nothing to fix."
+	}
+	return fmt.Sprintf("Fixing links in %s.", plural(queued, "message"))
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// blockAuthorConfigGroup defines the /config blockauthor subcommands, which
+// change the blocked accounts of /filters.
+func blockAuthorConfigGroup() *discordgo.ApplicationCommandOption {
+	nameOption := []*discordgo.ApplicationCommandOption{
+		{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "The Twitter/X username, such as @someone", Required: true, MaxLength: 16},
+	}
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "blockauthor",
+		Description: "Twitter/X accounts whose tweets are never fixed",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "add", Description: "Never fix links to an account's tweets", Options: nameOption},
+			{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "remove", Description: "Fix links to an account's tweets again", Options: nameOption},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "action",
+				Description: "Choose what happens to messages linking to blocked accounts' tweets",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "action",
+						Description: "What the bot does, for every content filter",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Don't fix their links", Value: config.FilterSkip},
+							{Name: "Don't fix their links, tell moderators", Value: config.FilterFlag},
+							{Name: "Remove the messages (needs Manage Messages)", Value: config.FilterRemove},
+						},
+					},
+				},
+			},
+			{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "list", Description: "List the blocked accounts"},
+		},
+	}
+}
+
+// handleBlockAuthorConfig runs a /config blockauthor subcommand, the same way
+// /filters does.
+func handleBlockAuthorConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	name := sub.Name
+	if name == "add" || name == "remove" {
+		name = "account " + name
+	}
+	var value string
+	if len(sub.Options) > 0 {
+		value = sub.Options[0].StringValue()
+	}
+	changeFilters(ctx, s, i, st, name, value)
+}
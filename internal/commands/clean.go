@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/fixers"
+)
+
+// NewClean builds the /clean command that strips tracking parameters from a link.
+func NewClean() Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "clean",
+			Description:      "Remove tracking parameters from a link",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "url", Description: "The link to clean", Required: true},
+			},
+		},
+		Module: ModuleModeration,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			link := strings.TrimSpace(OptionMap(i.ApplicationCommandData().Options)["url"].StringValue())
+			u, err := url.Parse(link)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				RespondEphemeral(ctx, s, i, "That doesn't look like a link.")
+				return
+			}
+			RespondEphemeral(ctx, s, i, fixers.CleanURL(link))
+		},
+	}
+}
@@ -0,0 +1,377 @@
+// Package commands implements the bot's slash commands and their dispatcher.
+package commands
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/dedupe"
+)
+
+// Command pairs an application command definition with its handler.
+// The handler's context is cancelled when the command times out or the bot shuts down.
+type Command struct {
+	Definition *discordgo.ApplicationCommand
+	Handler    InteractionHandler
+	// Component handles clicks on buttons and select menus the command sent.
+	// Their custom IDs must start with the command name and a colon, like "setup:mode".
+	Component InteractionHandler
+	// Permissions are the Discord permissions a member needs to use the
+	// command and its components, 0 for anyone. They're registered as the
+	// command's default member permissions and checked again on every use,
+	// since server admins can open commands up to anyone in their settings.
+	Permissions int64
+	// OwnerOnly limits the command to whoever owns the bot, for commands
+	// affecting every server. They're registered for admins only, so the
+	// rest of each server never sees them.
+	OwnerOnly bool
+	// Cooldown limits how often users and channels can use the command.
+	Cooldown Cooldown
+	// Public is set for commands that reply for everyone to see. Handlers
+	// slow enough to be deferred are shown thinking publicly, and can't
+	// reply ephemerally after, so the rest are deferred ephemerally.
+	Public bool
+	// Module groups the command with related ones in /help.
+	Module string
+}
+
+// Modules group commands in /help, in the order they're listed.
+const (
+	ModuleLinks      = "Fixing links"
+	ModuleModeration = "Moderation"
+	ModuleSettings   = "Server settings"
+	ModuleStats      = "Stats"
+	ModuleTools      = "Tools"
+	ModuleBot        = "About the bot"
+)
+
+// modules lists the modules in the order /help shows them. Commands in other
+// modules come last.
+var modules = []string{ModuleLinks, ModuleModeration, ModuleSettings, ModuleStats, ModuleTools, ModuleBot}
+
+// Registry holds every slash command the bot registers, keyed by name.
+type Registry struct {
+	// Context is the parent of every command invocation and is cancelled when
+	// the bot shuts down. Nil means context.Background.
+	Context context.Context
+	// Timeout bounds how long a single command may run, 0 for no limit.
+	Timeout time.Duration
+	// Ignore reports whether a member is blocked from using the bot in a guild.
+	// It is not applied to admin-only commands, so admins can't lock themselves out.
+	// Nil lets everyone through.
+	Ignore func(guildID, userID string, roles []string) bool
+	// Muted counts a member using a command and reports whether they're
+	// ignored for using the bot far too often. Like Ignore it is not applied
+	// to admin-only commands. Nil mutes nobody.
+	Muted func(s *discordgo.Session, guildID, userID string) bool
+	// Allowed reports whether a member's roles let them use the named command,
+	// for guilds that limit commands to some roles. Like Ignore it is not
+	// applied to admin-only commands. Nil allows everyone.
+	Allowed func(guildID, name string, roles []string) bool
+	// Disabled reports whether a guild turned the named command off. Disabled
+	// commands don't run and aren't registered in the guild. Nil disables none.
+	Disabled func(guildID, name string) bool
+	// Trusted reports whether a member's roles let them use commands without
+	// the permissions the commands need, for guilds that hand admin commands
+	// to a role. Nil trusts no roles.
+	Trusted func(guildID string, roles []string) bool
+	// Owner reports whether a user owns the bot, for OwnerOnly commands. Nil
+	// means nobody does.
+	Owner func(userID string) bool
+	// Pager pages through the long replies of the registry's commands. Its
+	// buttons are routed by the registry.
+	Pager *Pager
+	// DeferAfter is how long handlers have to reply before the registry
+	// defers the interaction for them, so they can take longer than Discord
+	// waits. Their replies then edit the deferred response.
+	DeferAfter time.Duration
+	// Used is told about every command that runs, for usage counts. Nil
+	// counts nothing.
+	Used func(guildID, name string)
+	// Claims decides which of the bot's replicas answers each interaction, so
+	// only one of them does. Nil answers every one here.
+	Claims *dedupe.Claims
+	// Shared, if not nil, keeps command cooldowns for every replica of the
+	// bot instead of only this one.
+	Shared Shared
+
+	commands   map[string]Command
+	components map[string]InteractionHandler
+	cooldowns  cooldowns
+	now        func() time.Time
+}
+
+// InteractionHandler handles one interaction. Its context is cancelled when it
+// times out or the bot shuts down.
+type InteractionHandler func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate)
+
+// NewRegistry returns an empty command registry.
+func NewRegistry() *Registry {
+	r := &Registry{
+		Pager:      NewPager(DefaultPageTTL),
+		DeferAfter: DefaultDeferAfter,
+		commands:   make(map[string]Command),
+		components: make(map[string]InteractionHandler),
+		cooldowns:  cooldowns{until: make(map[string]time.Time)},
+		now:        time.Now,
+	}
+	r.AddComponent(PagePrefix, r.Pager.Component)
+	return r
+}
+
+// Prune forgets command cooldowns that have ended and pages that have
+// expired, for the janitor.
+func (r *Registry) Prune(now time.Time) int {
+	return r.cooldowns.Prune(now) + r.Pager.Prune(now)
+}
+
+// Len returns how many cooldowns and paginated replies the registry remembers.
+func (r *Registry) Len() int {
+	return r.cooldowns.Len() + r.Pager.Len()
+}
+
+// Add adds a command to the registry.
+func (r *Registry) Add(cmd Command) {
+	if cmd.Permissions != 0 || cmd.OwnerOnly {
+		perms := cmd.Permissions
+		cmd.Definition.DefaultMemberPermissions = &perms
+	}
+	r.commands[cmd.Definition.Name] = cmd
+	if cmd.Component != nil {
+		r.AddComponent(cmd.Definition.Name, cmd.Component)
+	}
+}
+
+// AddComponent routes clicks on components whose custom ID starts with prefix
+// and a colon to handler, for components that don't belong to a command.
+func (r *Registry) AddComponent(prefix string, handler InteractionHandler) {
+	r.components[prefix] = handler
+}
+
+// Definitions returns the application command definitions of every registered command.
+func (r *Registry) Definitions() []*discordgo.ApplicationCommand {
+	return r.GuildDefinitions("")
+}
+
+// GuildDefinitions returns the definitions of the commands enabled in a guild,
+// or of every command for an empty guildID.
+func (r *Registry) GuildDefinitions(guildID string) []*discordgo.ApplicationCommand {
+	defs := make([]*discordgo.ApplicationCommand, 0, len(r.commands))
+	for name, cmd := range r.commands {
+		if r.disabled(guildID, name) {
+			continue
+		}
+		defs = append(defs, cmd.Definition)
+	}
+	return defs
+}
+
+// Commands returns the commands enabled in a guild, or every command for an
+// empty guildID, sorted by name.
+func (r *Registry) Commands(guildID string) []Command {
+	cmds := make([]Command, 0, len(r.commands))
+	for name, cmd := range r.commands {
+		if !r.disabled(guildID, name) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	slices.SortFunc(cmds, func(a, b Command) int { return strings.Compare(a.Definition.Name, b.Definition.Name) })
+	return cmds
+}
+
+// Register overwrites the bot's application commands with the registry.
+// With an empty guildID the commands are registered globally; otherwise only in
+// that guild, where updates show up immediately, which is useful while testing.
+// Commands the guild disabled are left out of guild registrations; global
+// commands show up everywhere, so those are refused when used instead.
+func (r *Registry) Register(s *discordgo.Session, guildID string) error {
+	_, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, guildID, r.GuildDefinitions(guildID), discordgo.WithContext(r.context()))
+	return err
+}
+
+// disabled applies r.Disabled to a command in a guild.
+func (r *Registry) disabled(guildID, name string) bool {
+	return r.Disabled != nil && guildID != "" && r.Disabled(guildID, name)
+}
+
+// ignored applies r.Ignore to the member who triggered an interaction.
+func (r *Registry) ignored(i *discordgo.InteractionCreate) bool {
+	if r.Ignore == nil || i.Member == nil || i.Member.User == nil {
+		return false
+	}
+	return r.Ignore(i.GuildID, i.Member.User.ID, i.Member.Roles)
+}
+
+// muted applies r.Muted to the user who triggered an interaction.
+func (r *Registry) muted(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	userID := interactionUser(i)
+	return r.Muted != nil && userID != "" && r.Muted(s, i.GuildID, userID)
+}
+
+// allowed applies r.Allowed to the member who triggered an interaction.
+func (r *Registry) allowed(i *discordgo.InteractionCreate, name string) bool {
+	if r.Allowed == nil || i.Member == nil {
+		return true
+	}
+	return r.Allowed(i.GuildID, name, i.Member.Roles)
+}
+
+// permitted reports whether the member behind an interaction may use cmd.
+func (r *Registry) permitted(cmd Command, i *discordgo.InteractionCreate) bool {
+	if cmd.Permissions == 0 {
+		return true
+	}
+	// Commands needing permissions only make sense in a guild
+	if i.Member == nil || i.GuildID == "" {
+		return false
+	}
+	if i.Member.Permissions&cmd.Permissions == cmd.Permissions {
+		return true
+	}
+	return r.Trusted != nil && r.Trusted(i.GuildID, i.Member.Roles)
+}
+
+// owns reports whether the user behind an interaction owns the bot.
+func (r *Registry) owns(i *discordgo.InteractionCreate) bool {
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+	return r.Owner != nil && user != nil && r.Owner(user.ID)
+}
+
+// context returns r.Context, defaulting to context.Background.
+func (r *Registry) context() context.Context {
+	if r.Context == nil {
+		return context.Background()
+	}
+	return r.Context
+}
+
+// Ready registers the slash commands globally once the session is connected.
+// Only shard 0 registers, so a sharded bot doesn't repeat the work per shard.
+func (r *Registry) Ready(s *discordgo.Session, _ *discordgo.Ready) {
+	if s.ShardID != 0 {
+		return
+	}
+	if err := r.Register(s, ""); err != nil {
+		log.Println("Error registering commands:", err)
+	}
+}
+
+// InteractionCreate dispatches slash command invocations and component clicks
+// to the command they belong to.
+func (r *Registry) InteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !r.Claims.Claim(i.GuildID, i.ID) {
+		return
+	}
+	var handler InteractionHandler
+	public := false
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		cmd := r.commands[i.ApplicationCommandData().Name]
+		if cmd.Definition != nil && cmd.Permissions == 0 && r.ignored(i) {
+			RespondEphemeral(r.context(), s, i, "You can't use this bot here.")
+			return
+		}
+		if cmd.Definition != nil && cmd.Permissions == 0 && !cmd.OwnerOnly && r.muted(s, i) {
+			RespondEphemeral(r.context(), s, i, "You're using the bot too much, try again in a few minutes.")
+			return
+		}
+		if cmd.Definition != nil && cmd.Permissions == 0 && !cmd.OwnerOnly && !r.allowed(i, cmd.Definition.Name) {
+			RespondEphemeral(r.context(), s, i, "This command is limited to some roles in this server.")
+			return
+		}
+		if cmd.OwnerOnly && !r.owns(i) {
+			RespondEphemeral(r.context(), s, i, "Only the bot's owner can use this.")
+			return
+		}
+		if !r.permitted(cmd, i) {
+			RespondEphemeral(r.context(), s, i, "You don't have permission to use this.")
+			return
+		}
+		if cmd.Definition != nil && r.disabled(i.GuildID, cmd.Definition.Name) {
+			RespondEphemeral(r.context(), s, i, "This command is turned off in this server.")
+			return
+		}
+		if cmd.Definition != nil {
+			if wait := r.cooldowns.take(cmd, i, r.now(), r.Shared); wait > 0 {
+				RespondEphemeral(r.context(), s, i, cooldownMessage(wait))
+				return
+			}
+		}
+		if r.Used != nil && cmd.Definition != nil {
+			r.Used(i.GuildID, cmd.Definition.Name)
+		}
+		handler = cmd.Handler
+		public = cmd.Public
+	case discordgo.InteractionMessageComponent:
+		prefix, _, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+		if cmd, ok := r.commands[prefix]; ok && !r.permitted(cmd, i) {
+			RespondEphemeral(r.context(), s, i, "You don't have permission to use this.")
+			return
+		}
+		handler = r.components[prefix]
+	}
+	if handler == nil {
+		return
+	}
+
+	// The ID follows the interaction through logs and error reports, and ends
+	// the reply if it fails, for the user to quote
+	ctx := correlation.With(r.context(), correlation.New())
+	var cancel context.CancelFunc
+	if r.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	ctx, reply := withReply(ctx)
+	deferral := time.AfterFunc(r.DeferAfter, func() { reply.deferReply(ctx, s, i, public) })
+	defer func() {
+		deferral.Stop()
+		p := recover()
+		if p != nil {
+			correlation.Println(ctx, "Panic handling interaction:", p)
+		}
+		// The handler's context may be done, but the user still needs a reply.
+		// Without a session, as in tests, there's no one to reply to.
+		if s != nil {
+			reply.finish(context.WithoutCancel(ctx), s, i, p != nil)
+		}
+	}()
+	handler(ctx, s, i)
+}
+
+// RespondEphemeral replies to an interaction with a message only the invoker can see.
+func RespondEphemeral(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// RespondEmbed replies to an interaction with an embed only the invoker can see.
+func RespondEmbed(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Flags:  discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// OptionMap indexes command options by name.
+func OptionMap(opts []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	m := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(opts))
+	for _, opt := range opts {
+		m[opt.Name] = opt
+	}
+	return m
+}
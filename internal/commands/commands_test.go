@@ -0,0 +1,1644 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/dedupe"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/feeds"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/fleet"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/health"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/phishing"
+	"go-discord-bot/internal/rolemenus"
+	"go-discord-bot/internal/stats"
+	"go-discord-bot/internal/trash"
+	"go-discord-bot/internal/trends"
+	"go-discord-bot/internal/unshorten"
+)
+
+func newTestInteraction(t discordgo.InteractionType, name string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: t,
+		Data: discordgo.ApplicationCommandInteractionData{Name: name},
+	}}
+}
+
+func TestRegistryDispatch(t *testing.T) {
+	r := NewRegistry()
+	called := map[string]int{}
+	for _, name := range []string{"one", "two"} {
+		r.Add(Command{
+			Definition: &discordgo.ApplicationCommand{Name: name},
+			Handler:    func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called[name]++ },
+		})
+	}
+
+	r.InteractionCreate(nil, newTestInteraction(discordgo.InteractionApplicationCommand, "two"))
+	r.InteractionCreate(nil, newTestInteraction(discordgo.InteractionApplicationCommand, "unknown"))
+	r.InteractionCreate(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{Type: discordgo.InteractionPing}})
+
+	if called["one"] != 0 || called["two"] != 1 {
+		t.Errorf("handlers called %v; want only two once", called)
+	}
+	if defs := r.Definitions(); len(defs) != 2 {
+		t.Errorf("Definitions returned %d commands; want 2", len(defs))
+	}
+}
+
+func TestFormatRewriteRules(t *testing.T) {
+	if got := formatRewriteRules(nil); got != "No rewrite rules configured." {
+		t.Errorf("formatRewriteRules(nil) = %q", got)
+	}
+
+	rules := []config.RewriteRule{{Pattern: "a", Replacement: "b"}, {Pattern: "c", Replacement: "d"}}
+	expected := "1. `a` → `b`\n2. `c` → `d`\n"
+	if got := formatRewriteRules(rules); got != expected {
+		t.Errorf("formatRewriteRules = %q; want %q", got, expected)
+	}
+}
+
+func TestFormatFixerBots(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      config.Guild
+		noticed  []string
+		expected string
+	}{
+		{name: "None", expected: "Links are left to other link-fixing bots here.\nNo other link-fixing bots are known."},
+		{
+			name:     "Listed and noticed",
+			cfg:      config.Guild{OtherFixers: config.OthersAlert, FixerBots: []string{"1"}},
+			noticed:  []string{"1", "2"},
+			expected: "Links are fixed even with other link-fixing bots here, and moderators are told about them.\nOther link-fixing bots: <@1>, <@2> (noticed)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatFixerBots(tc.cfg, tc.noticed); got != tc.expected {
+				t.Errorf("formatFixerBots = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFormatFilters(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      config.Guild
+		expected string
+	}{
+		{name: "None", expected: "No content filters: every tweet is reposted."},
+		{name: "Accounts", cfg: config.Guild{BlockedAccounts: []string{"spam", "Bots"}}, expected: "Blocked accounts: @spam, @Bots\nLinks to filtered tweets aren't fixed."},
+		{
+			name:     "Keywords flagged",
+			cfg:      config.Guild{BlockedKeywords: []string{"spoiler", "big game"}, FilterAction: config.FilterFlag},
+			expected: "Blocked keywords: \"spoiler\", \"big game\"\nLinks to filtered tweets aren't fixed, and moderators are told in the audit channel.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatFilters(tc.cfg); got != tc.expected {
+				t.Errorf("formatFilters = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFormatGuildSummary(t *testing.T) {
+	g := fleet.Guild{
+		Members:  12,
+		Settings: fleet.Settings{Paused: true, RepostMode: "reply", TwitterSite: "fxtwitter", PhishingAction: "warn", DisabledFixers: []string{"twitch"}},
+		Problems: []string{"missing Manage Messages, for deleting messages"},
+	}
+	expected := "**paused** · 12 members · reply · fxtwitter · phishing warn · without twitch · no audit channel\n⚠️ missing Manage Messages, for deleting messages"
+	if got := formatGuildSummary(g); got != expected {
+		t.Errorf("formatGuildSummary = %q; want %q", got, expected)
+	}
+}
+
+func TestSettingValue(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{value: "reply", expected: `"reply"`},
+		{value: `["twitch"]`, expected: `["twitch"]`},
+		{value: "true", expected: "true"},
+		{value: "null", expected: "null"},
+	}
+
+	for _, tc := range testCases {
+		if got := string(settingValue(tc.value)); got != tc.expected {
+			t.Errorf("settingValue(%q) = %s; want %s", tc.value, got, tc.expected)
+		}
+	}
+}
+
+func TestGuildList(t *testing.T) {
+	testCases := []struct {
+		list     string
+		expected string
+		ok       bool
+	}{
+		{list: "All", expected: "", ok: true},
+		{list: " 1, 2 ", expected: "1, 2", ok: true},
+		{list: " , ", expected: ",", ok: false},
+	}
+
+	for _, tc := range testCases {
+		if got, ok := guildList(tc.list); got != tc.expected || ok != tc.ok {
+			t.Errorf("guildList(%q) = %q, %v; want %q, %v", tc.list, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestFormatFixReaction(t *testing.T) {
+	testCases := []struct {
+		reaction string
+		expected string
+	}{
+		{reaction: "", expected: "Fixed messages aren't reacted to."},
+		{reaction: "✅", expected: "Fixed messages are reacted to with ✅."},
+		{reaction: "fixed:123", expected: "Fixed messages are reacted to with <:fixed:123>."},
+	}
+
+	for _, tc := range testCases {
+		if got := formatFixReaction(config.Guild{SuccessReaction: tc.reaction}); got != tc.expected {
+			t.Errorf("formatFixReaction(%q) = %q; want %q", tc.reaction, got, tc.expected)
+		}
+	}
+}
+
+func TestFormatLogLevels(t *testing.T) {
+	levels := map[logging.Module]logging.Level{logging.Fixers: logging.Info, logging.Storage: logging.Debug, logging.Gateway: logging.Info, logging.HTTP: logging.Info}
+	expected := "Log levels:\n`fixers`: info\n`storage`: debug\n`gateway`: info\n`http`: info"
+	if got := formatLogLevels(levels); got != expected {
+		t.Errorf("formatLogLevels = %q; want %q", got, expected)
+	}
+}
+
+func TestStatusEmbed(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name   string
+		report health.Report
+		title  string
+		color  int
+		fields []string
+	}{
+		{name: "Healthy", report: health.Report{Checks: []health.Check{{Name: "Gateway", Detail: "1 shard connected"}}},
+			title: "🟢 The bot is ok", color: 0x2ecc71, fields: []string{"🟢 Gateway: 1 shard connected"}},
+		{name: "Degraded", report: health.Report{State: health.Down, Checks: []health.Check{
+			{Name: "Gateway", Detail: "1 shard connected"},
+			{Name: "fx API", State: health.Down},
+		}}, title: "🔴 The bot is down", color: 0xe74c3c, fields: []string{"🟢 Gateway: 1 shard connected", "🔴 fx API: down"}},
+		{name: "Nothing checked", title: "🟢 The bot is ok", color: 0x2ecc71},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			embed := statusEmbed(tc.report, now)
+			var fields []string
+			for _, field := range embed.Fields {
+				fields = append(fields, field.Name+": "+field.Value)
+			}
+			if embed.Title != tc.title || embed.Color != tc.color || !slices.Equal(fields, tc.fields) {
+				t.Errorf("statusEmbed = %q %#x %q; want %q %#x %q", embed.Title, embed.Color, fields, tc.title, tc.color, tc.fields)
+			}
+			if embed.Timestamp != "2024-05-01T12:00:00Z" {
+				t.Errorf("Timestamp = %q", embed.Timestamp)
+			}
+		})
+	}
+}
+
+func TestAboutEmbed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		shardID  int
+		count    int
+		guilds   int
+		uptime   time.Duration
+		expected map[string]string
+	}{
+		{name: "Known guilds", shardID: 1, count: 4, guilds: 12, uptime: 90*time.Minute + 400*time.Millisecond,
+			expected: map[string]string{"Shard": "2 of 4", "Guilds": "12", "Uptime": "1h30m0s"}},
+		{name: "Unsharded with unknown guilds", shardID: 0, count: 0, guilds: -1, uptime: time.Second,
+			expected: map[string]string{"Shard": "1 of 1", "Guilds": "unknown", "Uptime": "1s"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			embed := aboutEmbed(tc.shardID, tc.count, tc.guilds, tc.uptime)
+			for _, field := range embed.Fields {
+				if want, ok := tc.expected[field.Name]; ok && field.Value != want {
+					t.Errorf("%s = %q; want %q", field.Name, field.Value, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryDispatchesComponents(t *testing.T) {
+	r := NewRegistry()
+	clicked := ""
+	r.Add(Command{
+		Definition: &discordgo.ApplicationCommand{Name: "setup"},
+		Component: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			clicked = i.MessageComponentData().CustomID
+		},
+	})
+
+	for _, id := range []string{"other:button", "setup:mode"} {
+		r.InteractionCreate(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionMessageComponent,
+			Data: discordgo.MessageComponentInteractionData{CustomID: id},
+		}})
+	}
+	if clicked != "setup:mode" {
+		t.Errorf("component handler got %q; want setup:mode", clicked)
+	}
+}
+
+func TestApplySetupChoice(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      config.Guild
+		customID string
+		values   []string
+		expected config.Guild
+	}{
+		{name: "Pick channels", customID: setupChannelsID, values: []string{"1", "2"},
+			expected: config.Guild{Channels: []string{"1", "2"}}},
+		{name: "Clear channels", cfg: config.Guild{Channels: []string{"1"}}, customID: setupChannelsID, values: []string{},
+			expected: config.Guild{}},
+		{name: "Disable unpicked fixers", customID: setupFixersID, values: []string{"twitter"},
+			expected: config.Guild{DisabledFixers: []string{"twitch", "cleaner"}}},
+		{name: "Keep unmanaged fixers disabled", cfg: config.Guild{DisabledFixers: []string{"custom", "twitch"}}, customID: setupFixersID, values: []string{"twitter", "twitch", "cleaner"},
+			expected: config.Guild{DisabledFixers: []string{"custom"}}},
+		{name: "Pick repost mode", customID: setupModeID, values: []string{config.RepostReply},
+			expected: config.Guild{RepostMode: config.RepostReply}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			applySetupChoice(&cfg, tc.customID, tc.values)
+			if !reflect.DeepEqual(cfg, tc.expected) {
+				t.Errorf("applySetupChoice = %+v; want %+v", cfg, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFormatSetup(t *testing.T) {
+	cfg := config.Guild{Channels: []string{"1"}, DisabledFixers: []string{"twitch", "cleaner"}, RepostMode: config.RepostReply}
+	expected := "Channels: <#1>\nFixing: Twitter / X links\nReposts: Reply to the original message"
+	if got := formatSetup(cfg); got != expected {
+		t.Errorf("formatSetup = %q; want %q", got, expected)
+	}
+}
+
+func TestCanRemove(t *testing.T) {
+	click := func(member *discordgo.Member, user *discordgo.User) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:   discordgo.InteractionMessageComponent,
+			Data:   discordgo.MessageComponentInteractionData{CustomID: RemovePrefix + ":author"},
+			Member: member,
+			User:   user,
+		}}
+	}
+
+	testCases := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		expected    bool
+	}{
+		{name: "Original author", interaction: click(&discordgo.Member{User: &discordgo.User{ID: "author"}}, nil), expected: true},
+		{name: "Moderator", interaction: click(&discordgo.Member{User: &discordgo.User{ID: "mod"}, Permissions: discordgo.PermissionManageMessages}, nil), expected: true},
+		{name: "Someone else", interaction: click(&discordgo.Member{User: &discordgo.User{ID: "other"}}, nil), expected: false},
+		{name: "Author in DMs", interaction: click(nil, &discordgo.User{ID: "author"}), expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := canRemove(tc.interaction); got != tc.expected {
+				t.Errorf("canRemove = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFixedLinks(t *testing.T) {
+	pipeline := fixers.Pipeline{fixers.Twitter{}, fixers.Twitch{Proxy: "clips.fxtwitch.tv"}}
+	workingEmbed := &discordgo.MessageEmbed{Image: &discordgo.MessageEmbedImage{URL: "https://pbs.twimg.com/media/abc.jpg"}}
+
+	testCases := []struct {
+		name     string
+		message  *discordgo.Message
+		expected []string
+	}{
+		{name: "Fixes every link", message: &discordgo.Message{Content: "a https://x.com/u/status/1 b https://clips.twitch.tv/Slug"},
+			expected: []string{"https://fixupx.com/u/status/1", "https://clips.fxtwitch.tv/Slug"}},
+		{name: "Fixes links with working previews", message: &discordgo.Message{Content: "https://twitter.com/u/status/1", Embeds: []*discordgo.MessageEmbed{workingEmbed}},
+			expected: []string{"https://fxtwitter.com/u/status/1"}},
+		{name: "Nothing to fix", message: &discordgo.Message{Content: "no links here"},
+			expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			embeds := len(tc.message.Embeds)
+			if got := fixedLinks(context.Background(), pipeline, tc.message); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("fixedLinks = %q; want %q", got, tc.expected)
+			}
+			if len(tc.message.Embeds) != embeds {
+				t.Errorf("fixedLinks modified the original message")
+			}
+		})
+	}
+}
+
+func TestUserInstallableCommands(t *testing.T) {
+	testCases := []struct {
+		command     Command
+		userInstall bool
+	}{
+		{command: NewFixLinks(nil), userInstall: true},
+		{command: NewFixLink(nil), userInstall: true},
+		{command: NewClean(), userInstall: true},
+		{command: NewConfig(nil, nil, nil, nil), userInstall: false},
+		{command: NewSetup(nil), userInstall: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command.Definition.Name, func(t *testing.T) {
+			def := tc.command.Definition
+			userInstall := def.IntegrationTypes != nil && slices.Contains(*def.IntegrationTypes, discordgo.ApplicationIntegrationUserInstall)
+			dms := def.Contexts != nil && slices.Contains(*def.Contexts, discordgo.InteractionContextPrivateChannel)
+			if userInstall != tc.userInstall || dms != tc.userInstall {
+				t.Errorf("user installable = %v, usable in DMs = %v; want %v", userInstall, dms, tc.userInstall)
+			}
+		})
+	}
+}
+
+func TestRegistryIgnore(t *testing.T) {
+	r := NewRegistry()
+	r.Ignore = func(guildID, userID string, roles []string) bool { return userID == "blocked" }
+	called := map[string]int{}
+	for name, perms := range map[string]int64{"public": 0, "admin": manageGuild} {
+		r.Add(Command{
+			Definition:  &discordgo.ApplicationCommand{Name: name},
+			Handler:     func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called[name]++ },
+			Permissions: perms,
+		})
+	}
+
+	for _, name := range []string{"public", "admin"} {
+		i := newTestInteraction(discordgo.InteractionApplicationCommand, name)
+		i.GuildID = "guild"
+		i.Member = &discordgo.Member{User: &discordgo.User{ID: "allowed"}, Permissions: manageGuild}
+		r.InteractionCreate(nil, i)
+	}
+	i := newTestInteraction(discordgo.InteractionApplicationCommand, "admin")
+	i.GuildID = "guild"
+	i.Member = &discordgo.Member{User: &discordgo.User{ID: "blocked"}, Permissions: manageGuild}
+	r.InteractionCreate(nil, i)
+
+	if called["public"] != 1 || called["admin"] != 2 {
+		t.Errorf("handlers called %v; want public once and admin twice", called)
+	}
+}
+
+func TestRegistryMuted(t *testing.T) {
+	r := NewRegistry()
+	r.Muted = func(s *discordgo.Session, guildID, userID string) bool { return userID == "spammer" }
+	called := map[string]int{}
+	for name, perms := range map[string]int64{"public": 0, "admin": manageGuild} {
+		r.Add(Command{
+			Definition:  &discordgo.ApplicationCommand{Name: name},
+			Handler:     func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called[name]++ },
+			Permissions: perms,
+		})
+	}
+
+	transport := &stubTransport{}
+	s, _ := discordgo.New("Bot test")
+	s.Client = &http.Client{Transport: transport}
+	for _, user := range []string{"spammer", "user"} {
+		for _, name := range []string{"public", "admin"} {
+			i := newTestInteraction(discordgo.InteractionApplicationCommand, name)
+			i.GuildID = "guild"
+			i.Member = &discordgo.Member{User: &discordgo.User{ID: user}, Permissions: manageGuild}
+			r.InteractionCreate(s, i)
+		}
+	}
+
+	if called["public"] != 1 || called["admin"] != 2 || transport.requests != 1 {
+		t.Errorf("handlers called %v with %d refusals; want the spammer refused public commands only", called, transport.requests)
+	}
+}
+
+func TestRegistryAllowed(t *testing.T) {
+	transport := &stubTransport{}
+	s, _ := discordgo.New("Bot test")
+	s.Client = &http.Client{Transport: transport}
+	r := NewRegistry()
+	r.Allowed = func(guildID, name string, roles []string) bool { return slices.Contains(roles, "artists") }
+	called := map[string]int{}
+	for name, perms := range map[string]int64{"public": 0, "admin": manageGuild} {
+		r.Add(Command{
+			Definition:  &discordgo.ApplicationCommand{Name: name},
+			Handler:     func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called[name]++ },
+			Permissions: perms,
+		})
+	}
+
+	for _, roles := range [][]string{{"artists"}, {"fans"}} {
+		for _, name := range []string{"public", "admin"} {
+			i := newTestInteraction(discordgo.InteractionApplicationCommand, name)
+			i.GuildID = "guild"
+			i.Member = &discordgo.Member{User: &discordgo.User{ID: "user"}, Roles: roles, Permissions: manageGuild}
+			r.InteractionCreate(s, i)
+		}
+	}
+
+	// Admin commands stay usable, so admins can't lock themselves out
+	if called["public"] != 1 || called["admin"] != 2 || transport.requests != 1 {
+		t.Errorf("handlers called %v with %d refusals; want public once and admin twice", called, transport.requests)
+	}
+}
+
+// stubTransport answers every Discord API request with 204 No Content and
+// counts them, remembering their methods.
+type stubTransport struct {
+	mu       sync.Mutex
+	requests int
+	methods  []string
+}
+
+func (t *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests++
+	t.methods = append(t.methods, req.Method)
+	return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: make(http.Header), Request: req}, nil
+}
+
+func TestRegistryPermissions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		guildID  string
+		member   *discordgo.Member
+		expected bool
+	}{
+		{name: "Manage Server", guildID: "guild", member: &discordgo.Member{Permissions: discordgo.PermissionManageServer | discordgo.PermissionSendMessages}, expected: true},
+		{name: "Regular member", guildID: "guild", member: &discordgo.Member{Permissions: discordgo.PermissionSendMessages}, expected: false},
+		{name: "Admin role", guildID: "guild", member: &discordgo.Member{Roles: []string{"mods"}}, expected: true},
+		{name: "Other role", guildID: "guild", member: &discordgo.Member{Roles: []string{"fans"}}, expected: false},
+		{name: "DM", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := &stubTransport{}
+			s, _ := discordgo.New("Bot test")
+			s.Client = &http.Client{Transport: transport}
+			r := NewRegistry()
+			r.Trusted = func(guildID string, roles []string) bool { return slices.Contains(roles, "mods") }
+			called := 0
+			r.Add(Command{
+				Definition:  &discordgo.ApplicationCommand{Name: "config"},
+				Handler:     func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called++ },
+				Component:   func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called++ },
+				Permissions: manageGuild,
+			})
+			if perms := r.Definitions()[0].DefaultMemberPermissions; perms == nil || *perms != manageGuild {
+				t.Errorf("registered default member permissions %v; want %d", perms, manageGuild)
+			}
+
+			i := newTestInteraction(discordgo.InteractionApplicationCommand, "config")
+			i.GuildID, i.Member = tc.guildID, tc.member
+			r.InteractionCreate(s, i)
+			click := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				Type:    discordgo.InteractionMessageComponent,
+				Data:    discordgo.MessageComponentInteractionData{CustomID: "config:button"},
+				GuildID: tc.guildID,
+				Member:  tc.member,
+			}}
+			r.InteractionCreate(s, click)
+
+			if tc.expected && (called != 2 || transport.requests != 0) {
+				t.Errorf("handlers called %d times with %d refusals; want both called", called, transport.requests)
+			}
+			if !tc.expected && (called != 0 || transport.requests != 2) {
+				t.Errorf("handlers called %d times with %d refusals; want both refused", called, transport.requests)
+			}
+		})
+	}
+}
+
+func TestRegistryOwnerOnly(t *testing.T) {
+	testCases := []struct {
+		name        string
+		interaction func(*discordgo.InteractionCreate)
+		owner       func(userID string) bool
+		expected    bool
+	}{
+		{name: "Owner in a DM", interaction: func(i *discordgo.InteractionCreate) { i.User = &discordgo.User{ID: "owner"} },
+			owner: func(userID string) bool { return userID == "owner" }, expected: true},
+		{name: "Owner in a server", interaction: func(i *discordgo.InteractionCreate) {
+			i.GuildID, i.Member = "guild", &discordgo.Member{User: &discordgo.User{ID: "owner"}}
+		}, owner: func(userID string) bool { return userID == "owner" }, expected: true},
+		{name: "Server admin", interaction: func(i *discordgo.InteractionCreate) {
+			i.GuildID, i.Member = "guild", &discordgo.Member{User: &discordgo.User{ID: "admin"}, Permissions: discordgo.PermissionAdministrator}
+		}, owner: func(userID string) bool { return userID == "owner" }, expected: false},
+		{name: "No owner", interaction: func(i *discordgo.InteractionCreate) { i.User = &discordgo.User{ID: "owner"} },
+			expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := &stubTransport{}
+			s, _ := discordgo.New("Bot test")
+			s.Client = &http.Client{Transport: transport}
+			r := NewRegistry()
+			r.Owner = tc.owner
+			called := 0
+			r.Add(Command{
+				Definition: &discordgo.ApplicationCommand{Name: "maintenance"},
+				Handler:    func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called++ },
+				OwnerOnly:  true,
+			})
+			if perms := r.Definitions()[0].DefaultMemberPermissions; perms == nil || *perms != 0 {
+				t.Errorf("registered default member permissions %v; want admins only", perms)
+			}
+
+			i := newTestInteraction(discordgo.InteractionApplicationCommand, "maintenance")
+			tc.interaction(i)
+			r.InteractionCreate(s, i)
+
+			if tc.expected && (called != 1 || transport.requests != 0) {
+				t.Errorf("handler called %d times with %d refusals; want it called", called, transport.requests)
+			}
+			if !tc.expected && (called != 0 || transport.requests != 1) {
+				t.Errorf("handler called %d times with %d refusals; want it refused", called, transport.requests)
+			}
+		})
+	}
+}
+
+func TestParseTriggerEmoji(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{input: "🔧", expected: "🔧", ok: true},
+		{input: " 👍🏽 ", expected: "👍🏽", ok: true},
+		{input: "<:fix:123456789012345678>", expected: "fix:123456789012345678", ok: true},
+		{input: "<a:spin:123456789012345678>", expected: "spin:123456789012345678", ok: true},
+		{input: "fix", ok: false},
+		{input: "🔧 🔨", ok: false},
+		{input: "<:fix:123456789012345678> extra", ok: false},
+		{input: "", ok: false},
+	}
+
+	for _, tc := range testCases {
+		got, ok := parseTriggerEmoji(tc.input)
+		if got != tc.expected || ok != tc.ok {
+			t.Errorf("parseTriggerEmoji(%q) = %q, %v; want %q, %v", tc.input, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestScanLinks(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(list, []byte("evil.example\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	checker, err := phishing.New(list)
+	if err != nil {
+		t.Fatalf("phishing.New: %v", err)
+	}
+	messages := []*discordgo.Message{
+		{Content: "see https://news.example/a and <https://news.example/b>"},
+		{Content: "again https://news.example/a"},
+		{Content: "free nitro https://login.evil.example/steam"},
+		{Content: "https://bit.ly/abc"},
+		{Content: "https://discord.com/channels/1/2/3 https://cdn.discordapp.com/attachments/1/2/a.png"},
+		{Content: "no links here"},
+	}
+
+	domains := scanLinks(context.Background(), checker, unshorten.New(nil), messages)
+	var lines []string
+	for _, d := range domains {
+		lines = append(lines, domainLine(d))
+	}
+	expected := []string{
+		"⚠️ `login.evil.example`: 1 link, known phishing or malware",
+		"🔗 `bit.ly`: 1 link, link shortener",
+		"`news.example`: 2 links",
+	}
+	if !slices.Equal(lines, expected) {
+		t.Errorf("scanLinks = %q; want %q", lines, expected)
+	}
+	if report := scanReport(domains); !strings.Contains(report, "news.example: 2 links\n  https://news.example/a\n  https://news.example/b\n") {
+		t.Errorf("scanReport = %q; want every link under its domain", report)
+	}
+}
+
+func TestDisabledFixers(t *testing.T) {
+	fixerNames := []string{"twitter", "twitch", "custom", "cleaner"}
+	testCases := []struct {
+		name     string
+		disabled []string
+		picked   []string
+		expected []string
+	}{
+		{name: "All picked", picked: fixerNames, expected: nil},
+		{name: "Some picked", picked: []string{"twitter", "custom"}, expected: []string{"twitch", "cleaner"}},
+		{name: "Turned back on", disabled: []string{"twitch"}, picked: fixerNames, expected: nil},
+		{name: "Unknown fixer kept", disabled: []string{"retired"}, picked: []string{"twitter", "twitch", "custom"}, expected: []string{"retired", "cleaner"}},
+		{name: "None picked", expected: fixerNames},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := disabledFixers(tc.disabled, fixerNames, tc.picked)
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("disabledFixers = %q; want %q", got, tc.expected)
+			}
+			menu := platformsMessage(config.Guild{DisabledFixers: got}, fixerNames).Components[0].(discordgo.ActionsRow).Components[0].(discordgo.SelectMenu)
+			for _, option := range menu.Options {
+				if option.Default == slices.Contains(got, option.Value) {
+					t.Errorf("menu shows %s as on = %v", option.Value, option.Default)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryDisabled(t *testing.T) {
+	transport := &stubTransport{}
+	s, _ := discordgo.New("Bot test")
+	s.Client = &http.Client{Transport: transport}
+	r := NewRegistry()
+	r.Disabled = func(guildID, name string) bool { return guildID == "quiet" && name == "media" }
+	used := map[string]int{}
+	r.Used = func(guildID, name string) { used[guildID+"/"+name]++ }
+	called := map[string]int{}
+	for _, name := range []string{"media", "fixlink"} {
+		r.Add(Command{
+			Definition: &discordgo.ApplicationCommand{Name: name},
+			Handler:    func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called[name]++ },
+		})
+	}
+
+	for _, guildID := range []string{"quiet", "loud"} {
+		for _, name := range []string{"media", "fixlink"} {
+			i := newTestInteraction(discordgo.InteractionApplicationCommand, name)
+			i.GuildID = guildID
+			r.InteractionCreate(s, i)
+		}
+	}
+
+	if called["media"] != 1 || called["fixlink"] != 2 || transport.requests != 1 {
+		t.Errorf("handlers called %v with %d refusals; want media refused in the quiet guild only", called, transport.requests)
+	}
+	if expected := map[string]int{"quiet/fixlink": 1, "loud/media": 1, "loud/fixlink": 1}; !reflect.DeepEqual(used, expected) {
+		t.Errorf("usage counted %v; want %v", used, expected)
+	}
+	if cmds := r.Commands("quiet"); len(cmds) != 1 || cmds[0].Definition.Name != "fixlink" {
+		t.Errorf("Commands(quiet) = %v; want only fixlink", cmds)
+	}
+	if defs := r.GuildDefinitions("quiet"); len(defs) != 1 || defs[0].Name != "fixlink" {
+		t.Errorf("GuildDefinitions(quiet) = %v; want only fixlink", defs)
+	}
+	if defs := r.Definitions(); len(defs) != 2 {
+		t.Errorf("Definitions returned %d commands; want 2", len(defs))
+	}
+}
+
+func TestRegistryCooldown(t *testing.T) {
+	// use is one invocation: who ran the command where, after how long
+	type use struct {
+		user, channel string
+		after         time.Duration
+		allowed       bool
+	}
+	testCases := []struct {
+		name     string
+		cooldown Cooldown
+		uses     []use
+	}{
+		{name: "No cooldown", uses: []use{{"a", "c1", 0, true}, {"a", "c1", 0, true}}},
+		{name: "Per user", cooldown: Cooldown{User: 5 * time.Second}, uses: []use{
+			{"a", "c1", 0, true},
+			{"a", "c2", time.Second, false},
+			{"b", "c1", 0, true},
+			{"a", "c1", 4 * time.Second, true},
+		}},
+		{name: "Per channel", cooldown: Cooldown{Channel: 2 * time.Second}, uses: []use{
+			{"a", "c1", 0, true},
+			{"b", "c1", time.Second, false},
+			{"b", "c2", 0, true},
+			{"b", "c1", time.Second, true},
+		}},
+		{name: "Refusals don't extend it", cooldown: Cooldown{User: 5 * time.Second}, uses: []use{
+			{"a", "c1", 0, true},
+			{"a", "c1", 4 * time.Second, false},
+			{"a", "c1", time.Second, true},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := &stubTransport{}
+			s, _ := discordgo.New("Bot test")
+			s.Client = &http.Client{Transport: transport}
+			r := NewRegistry()
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			r.now = func() time.Time { return now }
+			called := 0
+			r.Add(Command{
+				Definition: &discordgo.ApplicationCommand{Name: "fixlink"},
+				Handler:    func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called++ },
+				Cooldown:   tc.cooldown,
+			})
+
+			for n, u := range tc.uses {
+				now = now.Add(u.after)
+				i := newTestInteraction(discordgo.InteractionApplicationCommand, "fixlink")
+				i.ChannelID = u.channel
+				i.Member = &discordgo.Member{User: &discordgo.User{ID: u.user}}
+				before := called
+				r.InteractionCreate(s, i)
+				if allowed := called > before; allowed != u.allowed {
+					t.Errorf("use %d by %s in %s: allowed = %v; want %v", n, u.user, u.channel, allowed, u.allowed)
+				}
+			}
+
+			if pruned := r.Prune(now.Add(time.Minute)); tc.cooldown != (Cooldown{}) && pruned == 0 {
+				t.Error("Prune removed nothing after every cooldown ended")
+			}
+		})
+	}
+}
+
+// replicaStore is a Shared store in memory, standing in for the Redis server
+// replicas share. It never expires keys.
+type replicaStore struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func (c *replicaStore) Get(_ context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.keys[key]
+	return v, ok, nil
+}
+
+func (c *replicaStore) Set(_ context.Context, key, value string, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[key] = value
+	return nil
+}
+
+func (c *replicaStore) SetNX(_ context.Context, key, value string, _ time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[key]; ok {
+		return false, nil
+	}
+	c.keys[key] = value
+	return true, nil
+}
+
+func TestRegistryReplicas(t *testing.T) {
+	store := &replicaStore{keys: make(map[string]string)}
+	s, _ := discordgo.New("Bot test")
+	s.Client = &http.Client{Transport: &stubTransport{}}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	called := 0
+	var replicas []*Registry
+	for range 2 {
+		r := NewRegistry()
+		r.now = func() time.Time { return now }
+		r.Claims, r.Shared = dedupe.NewClaims(store), store
+		r.Add(Command{
+			Definition: &discordgo.ApplicationCommand{Name: "fixlink"},
+			Handler:    func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { called++ },
+			Cooldown:   Cooldown{User: 5 * time.Second},
+		})
+		replicas = append(replicas, r)
+	}
+	use := func(id string) *discordgo.InteractionCreate {
+		i := newTestInteraction(discordgo.InteractionApplicationCommand, "fixlink")
+		i.ID, i.ChannelID = id, "chan"
+		i.Member = &discordgo.Member{User: &discordgo.User{ID: "user"}}
+		return i
+	}
+
+	// Every replica gets the interaction, only one answers it
+	for _, r := range replicas {
+		r.InteractionCreate(s, use("first"))
+	}
+	if called != 1 {
+		t.Errorf("command ran %d times for one interaction; want once", called)
+	}
+	// The cooldown started through one replica holds in the other
+	now = now.Add(time.Second)
+	replicas[1].InteractionCreate(s, use("second"))
+	if called != 1 {
+		t.Error("other replica ran the command within its cooldown")
+	}
+	now = now.Add(5 * time.Second)
+	replicas[1].InteractionCreate(s, use("third"))
+	if called != 2 {
+		t.Error("other replica held the command back after its cooldown")
+	}
+}
+
+func TestRegistryDefers(t *testing.T) {
+	reply := func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+		RespondEphemeral(ctx, s, i, "done")
+	}
+	slow := func(handler InteractionHandler) InteractionHandler {
+		return func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			time.Sleep(50 * time.Millisecond)
+			if handler != nil {
+				handler(ctx, s, i)
+			}
+		}
+	}
+	testCases := []struct {
+		name     string
+		handler  InteractionHandler
+		expected []string
+	}{
+		{name: "Fast reply", handler: reply, expected: []string{http.MethodPost}},
+		{name: "Slow reply edits the deferral", handler: slow(reply), expected: []string{http.MethodPost, http.MethodPatch}},
+		{name: "Second reply is a follow-up", handler: slow(func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			reply(ctx, s, i)
+			reply(ctx, s, i)
+		}), expected: []string{http.MethodPost, http.MethodPatch, http.MethodPost}},
+		{name: "Slow handler without a reply", handler: slow(nil), expected: []string{http.MethodPost, http.MethodPatch}},
+		{name: "Fast handler without a reply", handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {}, expected: nil},
+		{name: "Panic", handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) { panic("oops") }, expected: []string{http.MethodPost}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := &stubTransport{}
+			s, _ := discordgo.New("Bot test")
+			s.Client = &http.Client{Transport: transport}
+			r := NewRegistry()
+			r.DeferAfter = 10 * time.Millisecond
+			r.Add(Command{Definition: &discordgo.ApplicationCommand{Name: "slow"}, Handler: tc.handler})
+
+			r.InteractionCreate(s, newTestInteraction(discordgo.InteractionApplicationCommand, "slow"))
+			if !reflect.DeepEqual(transport.methods, tc.expected) {
+				t.Errorf("requests %v; want %v", transport.methods, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCooldownMessage(t *testing.T) {
+	if got, expected := cooldownMessage(1500*time.Millisecond), "Slow down! Try again in 2s."; got != expected {
+		t.Errorf("cooldownMessage = %q; want %q", got, expected)
+	}
+}
+
+func TestBackfillResult(t *testing.T) {
+	testCases := []struct {
+		name     string
+		queued   int
+		err      error
+		expected string
+	}{
+		{name: "Nothing", expected: "Found nothing to fix."},
+		{name: "One", queued: 1, expected: "Fixing links in 1 message."},
+		{name: "Several", queued: 4, expected: "Fixing links in 4 messages."},
+		{name: "Error", err: errors.New("missing access"), expected: "Couldn't read this channel's messages. Check the bot can see its history."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := backfillResult(tc.queued, tc.err); got != tc.expected {
+				t.Errorf("backfillResult = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPager(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewPager(time.Minute)
+	p.now = func() time.Time { return clock }
+	first := p.store("reply", []*discordgo.MessageEmbed{
+		{Title: "1", Footer: &discordgo.MessageEmbedFooter{Text: "note"}},
+		{Title: "2"},
+		{Title: "3"},
+	})
+	if first.Embeds[0].Title != "1" || first.Embeds[0].Footer.Text != "Page 1 of 3 · note" {
+		t.Fatalf("first page %+v; want page 1 with its number and note", first.Embeds[0])
+	}
+
+	testCases := []struct {
+		name     string
+		advance  time.Duration
+		customID string
+		title    string
+		prevOff  bool
+		nextOff  bool
+		expired  bool
+	}{
+		{name: "First", customID: "page:reply:0", title: "1", prevOff: true},
+		{name: "Middle", customID: "page:reply:1", title: "2"},
+		{name: "Last", customID: "page:reply:2", title: "3", nextOff: true},
+		{name: "Past the end", customID: "page:reply:9", title: "3", nextOff: true},
+		{name: "Unknown reply", customID: "page:other:1", expired: true},
+		{name: "Malformed", customID: "page:reply", expired: true},
+		{name: "Expired", advance: time.Minute, customID: "page:reply:1", expired: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock = clock.Add(tc.advance)
+			data, ok := p.turn(tc.customID)
+			if ok == tc.expired {
+				t.Fatalf("turn(%q) found = %v; want %v", tc.customID, ok, !tc.expired)
+			}
+			if !ok {
+				return
+			}
+			if data.Embeds[0].Title != tc.title {
+				t.Errorf("showed page %q; want %q", data.Embeds[0].Title, tc.title)
+			}
+			buttons := data.Components[0].(discordgo.ActionsRow).Components
+			if prev := buttons[0].(discordgo.Button); prev.Disabled != tc.prevOff {
+				t.Errorf("Previous disabled = %v; want %v", prev.Disabled, tc.prevOff)
+			}
+			if next := buttons[1].(discordgo.Button); next.Disabled != tc.nextOff {
+				t.Errorf("Next disabled = %v; want %v", next.Disabled, tc.nextOff)
+			}
+		})
+	}
+
+	if n := p.Prune(clock); n != 1 {
+		t.Errorf("Prune forgot %d replies; want 1", n)
+	}
+	var none *Pager
+	if data := none.store("reply", []*discordgo.MessageEmbed{{Title: "1"}, {Title: "2"}}); len(data.Components) != 0 {
+		t.Errorf("nil Pager added buttons %+v", data.Components)
+	}
+}
+
+func TestLeaderboardPages(t *testing.T) {
+	var board stats.Leaderboard
+	for n := range 25 {
+		board.Users = append(board.Users, stats.Count{Name: fmt.Sprint(n + 1), Links: 30 - n})
+	}
+	board.Platforms = []stats.Count{{Name: "Twitter", Links: 4000}, {Name: "Twitch", Links: 1}}
+
+	pages := leaderboardPages("de", board)
+	if len(pages) != 3 {
+		t.Fatalf("got %d pages; want 3", len(pages))
+	}
+	if first, _, _ := strings.Cut(pages[1].Description, "\n"); first != "11. <@11> · 20 links" {
+		t.Errorf("second page starts %q", first)
+	}
+	if pages[2].Fields[0].Value != "Twitter · 4.000 links\nTwitch · 1 link\n" {
+		t.Errorf("platforms %q", pages[2].Fields[0].Value)
+	}
+}
+
+func TestStatsPages(t *testing.T) {
+	g := stats.Guild{Reposts: 1200, LinksFixed: 1500, Duplicates: 1}
+	board := stats.Leaderboard{Platforms: []stats.Count{{Name: "Twitter", Links: 1125}, {Name: "Twitch", Links: 375}}}
+
+	testCases := []struct {
+		locale    string
+		summary   string
+		platforms string
+	}{
+		{locale: "", summary: "Reposted 1,200 messages fixing 1,500 links", platforms: "Twitter · 1,125 links (75%)\nTwitch · 375 links (25%)"},
+		{locale: "fr", summary: "Reposted 1\u202f200 messages fixing 1\u202f500 links", platforms: "Twitter · 1\u202f125 links (75\u202f%)\nTwitch · 375 links (25\u202f%)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.locale, func(t *testing.T) {
+			pages := statsPages(tc.locale, g, board, nil)
+			if summary, _, _ := strings.Cut(pages[0].Description, "\n"); summary != tc.summary {
+				t.Errorf("summary = %q; want %q", summary, tc.summary)
+			}
+			if pages[1].Description != tc.platforms {
+				t.Errorf("platforms = %q; want %q", pages[1].Description, tc.platforms)
+			}
+		})
+	}
+}
+
+func TestConfigPages(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rules int
+		pages int
+	}{
+		{name: "No rules", rules: 0, pages: 3},
+		{name: "One page of rules", rules: rewriteRulesPerPage, pages: 4},
+		{name: "Two pages of rules", rules: rewriteRulesPerPage + 1, pages: 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Guild{RewriteRules: make([]config.RewriteRule, tc.rules)}
+			if pages := configPages(cfg); len(pages) != tc.pages {
+				t.Errorf("got %d pages; want %d", len(pages), tc.pages)
+			}
+		})
+	}
+}
+
+func TestUpdateIDList(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ids      []string
+		id       string
+		remove   bool
+		expected []string
+	}{
+		{name: "Add", ids: []string{"1"}, id: "2", expected: []string{"1", "2"}},
+		{name: "Add existing", ids: []string{"1"}, id: "1", expected: []string{"1"}},
+		{name: "Remove", ids: []string{"1", "2"}, id: "1", remove: true, expected: []string{"2"}},
+		{name: "Remove missing", ids: []string{"1"}, id: "3", remove: true, expected: []string{"1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := updateIDList(tc.ids, tc.id, tc.remove); !slices.Equal(got, tc.expected) {
+				t.Errorf("updateIDList = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTweetID(t *testing.T) {
+	testCases := []struct {
+		link     string
+		expected string
+		ok       bool
+	}{
+		{link: "https://x.com/user/status/123?s=20", expected: "123", ok: true},
+		{link: " https://twitter.com/i/web/status/45 ", expected: "45", ok: true},
+		{link: "https://fixupx.com/user/status/67/photo/1", expected: "67", ok: true},
+		{link: "https://example.com/user/status/89"},
+		{link: "not a link"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.link, func(t *testing.T) {
+			if got, ok := tweetID(tc.link); got != tc.expected || ok != tc.ok {
+				t.Errorf("tweetID(%q) = %q, %v; want %q, %v", tc.link, got, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+}
+
+func TestMediaContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status/1":
+			w.Write([]byte(`{"tweet":{"media":{"all":[{"url":"https://pbs.twimg.com/a.jpg"},{"url":"https://video.twimg.com/b.mp4"}]}}}`))
+		case "/status/2":
+			w.Write([]byte(`{"tweet":{"text":"just words"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := fxtwitter.New(server.URL, server.Client())
+
+	testCases := []struct {
+		id       string
+		expected string
+	}{
+		{id: "1", expected: "https://pbs.twimg.com/a.jpg\nhttps://video.twimg.com/b.mp4"},
+		{id: "2", expected: "That tweet has no photos or videos."},
+		{id: "3", expected: "Couldn't find that tweet. It may be deleted or from a private account."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			if got := mediaContent(context.Background(), client, tc.id); got != tc.expected {
+				t.Errorf("mediaContent(%s) = %q; want %q", tc.id, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseImportedConfig(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "Empty settings", input: `{}`},
+		{name: "Full settings", input: `{"privacy":true,"channels":["1"],"repost_mode":"reply","twitter_site":"nitter","context_depth":2,
+			"rewrite_rules":[{"pattern":"example\\.com","replacement":"example.org"}]}`},
+		{name: "Not JSON", input: `hello`, wantErr: true},
+		{name: "Unknown field", input: `{"colour":"red"}`, wantErr: true},
+		{name: "Bad repost mode", input: `{"repost_mode":"shout"}`, wantErr: true},
+		{name: "Bad Twitter site", input: `{"twitter_site":"bird.example"}`, wantErr: true},
+		{name: "Custom Twitter site", input: `{"twitter_site":"custom","twitter_domain":"fixvx.com"}`},
+		{name: "Custom Twitter site without domain", input: `{"twitter_site":"custom"}`, wantErr: true},
+		{name: "Twitter domain with path", input: `{"twitter_site":"custom","twitter_domain":"fixvx.com/status"}`, wantErr: true},
+		{name: "Context too deep", input: `{"context_depth":50}`, wantErr: true},
+		{name: "Bad rewrite rule", input: `{"rewrite_rules":[{"pattern":"(","replacement":"x"}]}`, wantErr: true},
+		{name: "Bad repost template", input: `{"repost_template":"Fixed by {bot}"}`, wantErr: true},
+		{name: "Time zone", input: `{"timezone":"Europe/Berlin"}`},
+		{name: "Bad time zone", input: `{"timezone":"Mars/Olympus_Mons"}`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseImportedConfig([]byte(tc.input)); (err != nil) != tc.wantErr {
+				t.Errorf("parseImportedConfig error = %v; wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestDropForeignIDs(t *testing.T) {
+	cfg := config.Guild{Channels: []string{"c1", "other"}, IgnoredRoles: []string{"r1", "gone"}, IgnoredUsers: []string{"u1"}, DigestChannel: "other", StarboardChannel: "c1"}
+	dropped := dropForeignIDs(&cfg, map[string]bool{"c1": true}, map[string]bool{"r1": true})
+
+	if dropped != 3 || cfg.DigestChannel != "" || !slices.Equal(cfg.Channels, []string{"c1"}) || !slices.Equal(cfg.IgnoredRoles, []string{"r1"}) || !slices.Equal(cfg.IgnoredUsers, []string{"u1"}) {
+		t.Errorf("dropForeignIDs dropped %d, left %+v", dropped, cfg)
+	}
+}
+
+func TestFormatFeeds(t *testing.T) {
+	testCases := []struct {
+		name     string
+		subs     []feeds.Subscription
+		expected string
+	}{
+		{name: "None", expected: "This server has no feeds. Add one with `/feed add`."},
+		{
+			name:     "Some",
+			subs:     []feeds.Subscription{{ID: "ab12", Title: "News", ChannelID: "1", Interval: 30 * time.Minute}},
+			expected: "**Feeds**\n`ab12` **News** in <#1>, every 30 minutes",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatFeeds(tc.subs); got != tc.expected {
+				t.Errorf("formatFeeds() = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFindStealable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		message  *discordgo.Message
+		expected []stealable
+	}{
+		{
+			name:    "Custom emoji",
+			message: &discordgo.Message{Content: "hi <:wave:123456789012345678> <a:party:223456789012345678> <:wave:123456789012345678> 😀"},
+			expected: []stealable{
+				{ID: "123456789012345678", Name: "wave"},
+				{ID: "223456789012345678", Name: "party", Animated: true},
+			},
+		},
+		{
+			name:     "Sticker",
+			message:  &discordgo.Message{StickerItems: []*discordgo.StickerItem{{ID: "1", Name: "cat", FormatType: discordgo.StickerFormatTypePNG}}},
+			expected: []stealable{{ID: "1", Name: "cat", Sticker: discordgo.StickerFormatTypePNG}},
+		},
+		{name: "Nothing custom", message: &discordgo.Message{Content: "😀 :wave:"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := findStealable(tc.message); !slices.Equal(got, tc.expected) {
+				t.Errorf("findStealable() = %+v; want %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDownloadEmoji(t *testing.T) {
+	var sizes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := r.URL.Query().Get("size")
+		sizes = append(sizes, size)
+		// Only the smallest size fits under the limit
+		if size != "48" {
+			w.Write(make([]byte, maxEmojiSize+1))
+			return
+		}
+		w.Write([]byte("small"))
+	}))
+	defer server.Close()
+
+	st := newStealer(server.Client())
+	st.cdn = server.URL + "/"
+	data, contentType, err := st.downloadEmoji(context.Background(), stealable{ID: "1", Name: "wave", Animated: true})
+	if err != nil {
+		t.Fatalf("downloadEmoji: %v", err)
+	}
+	if string(data) != "small" || contentType != "image/gif" {
+		t.Errorf("downloadEmoji() = %q, %q; want the 48px gif", data, contentType)
+	}
+	if expected := []string{"128", "96", "64", "48"}; !slices.Equal(sizes, expected) {
+		t.Errorf("requested sizes %q; want %q", sizes, expected)
+	}
+
+	// Vector stickers are refused without downloading them
+	if _, _, err := st.downloadSticker(context.Background(), stealable{ID: "2", Sticker: discordgo.StickerFormatTypeLottie}); !errors.Is(err, errLottie) {
+		t.Errorf("downloadSticker(Lottie) error = %v; want errLottie", err)
+	}
+}
+
+func TestRestoredContent(t *testing.T) {
+	m := trash.Message{
+		AuthorID:    "user",
+		Content:     "look at this",
+		Attachments: []trash.Attachment{{Filename: "a.png", URL: "https://cdn.example/a.png"}},
+		DeletedAt:   time.Unix(1700000000, 0),
+	}
+	expected := "Restored a message from <@user>, deleted <t:1700000000:R>:\nlook at this\nhttps://cdn.example/a.png"
+	if content := restoredContent(m); content != expected {
+		t.Errorf("restoredContent = %q; want %q", content, expected)
+	}
+}
+
+func TestExplainMessage(t *testing.T) {
+	trace := explain.Trace{
+		Source:   "message create",
+		Received: time.Unix(1700000000, 0),
+		Steps:    []explain.Step{{Stage: "ignore list", Result: "not ignored"}, {Stage: "fixer twitter", Result: "no match"}},
+	}
+	expected := "Seen <t:1700000000:R>, from message create:\n- ignore list: not ignored\n- fixer twitter: no match\nStill being handled."
+	if content := explainMessage(trace); content != expected {
+		t.Errorf("explainMessage = %q; want %q", content, expected)
+	}
+	trace.Decision = "unchanged"
+	if content := explainMessage(trace); !strings.HasSuffix(content, "\nDecision: **unchanged**") {
+		t.Errorf("explainMessage of a decided trace = %q", content)
+	}
+	trace.CorrelationID = "abc123"
+	if content := explainMessage(trace); !strings.HasSuffix(content, "\nDecision: **unchanged**\n-# Reference: `abc123`") {
+		t.Errorf("explainMessage of a correlated trace = %q", content)
+	}
+}
+
+func TestDiagnosisMessage(t *testing.T) {
+	trace := explain.Trace{
+		Steps:    []explain.Step{{Stage: "ignore list", Result: "not ignored"}, {Stage: "channel", Result: "fixing is only on in other channels"}},
+		Decision: "channel disabled",
+	}
+	expected := "Fixing is off in this channel.\n\nChecked:\n- ignore list: not ignored\n- channel: fixing is only on in other channels"
+	if content := diagnosisMessage(trace); content != expected {
+		t.Errorf("diagnosisMessage = %q; want %q", content, expected)
+	}
+	trace.Decision = "something new"
+	if content := diagnosisMessage(trace); !strings.HasPrefix(content, "The bot would decide: **something new**.") {
+		t.Errorf("diagnosisMessage of an unknown decision = %q", content)
+	}
+}
+
+func TestLinksHelp(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      config.Guild
+		expected string
+	}{
+		{name: "Default marker", expected: "starting with `!raw`"},
+		{name: "Own marker", cfg: config.Guild{SkipMarker: "nofix"}, expected: "starting with `nofix`"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if help := linksHelp(tc.cfg).Description; !strings.Contains(help, tc.expected) {
+				t.Errorf("linksHelp = %q; want it to contain %q", help, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCommandsHelp(t *testing.T) {
+	cmds := []Command{
+		{Definition: &discordgo.ApplicationCommand{Name: "about", Description: "About the bot"}, Module: ModuleBot},
+		{Definition: &discordgo.ApplicationCommand{Name: "fixlink", Description: "Fix a link"}, Module: ModuleLinks, Cooldown: Cooldown{User: 5 * time.Second, Channel: time.Minute}},
+		{Definition: &discordgo.ApplicationCommand{Name: "Explain Fix", Type: discordgo.MessageApplicationCommand}, Module: ModuleModeration, Permissions: discordgo.PermissionManageMessages},
+		{Definition: &discordgo.ApplicationCommand{Name: "maintenance", Description: "Pause every server"}, Module: ModuleBot, OwnerOnly: true},
+		{Definition: &discordgo.ApplicationCommand{Name: "odd", Description: "Ungrouped"}},
+	}
+
+	testCases := []struct {
+		name     string
+		owner    bool
+		expected []string
+	}{
+		{
+			name: "Member",
+			expected: []string{
+				"Fixing links: `/fixlink` · Fix a link\n-# once every 5s per user, once every 1m per channel",
+				"Moderation: **Explain Fix** · on a message, under Apps\n-# needs Manage Messages",
+				"About the bot: `/about` · About the bot",
+				"Other: `/odd` · Ungrouped",
+			},
+		},
+		{
+			name:  "Owner",
+			owner: true,
+			expected: []string{
+				"Fixing links: `/fixlink` · Fix a link\n-# once every 5s per user, once every 1m per channel",
+				"Moderation: **Explain Fix** · on a message, under Apps\n-# needs Manage Messages",
+				"About the bot: `/about` · About the bot\n`/maintenance` · Pause every server\n-# bot owner only",
+				"Other: `/odd` · Ungrouped",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, page := range commandsHelp(cmds, tc.owner) {
+				got = append(got, page.Title+": "+page.Description)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("commandsHelp = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParsePollAnswers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		list     string
+		expected []string
+		err      bool
+	}{
+		{name: "Answers", list: "cats | dogs|  birds ", expected: []string{"cats", "dogs", "birds"}},
+		{name: "Empty answers skipped", list: "cats||dogs|", expected: []string{"cats", "dogs"}},
+		{name: "One answer", list: "cats", err: true},
+		{name: "Too many answers", list: strings.Repeat("a|", maxPollAnswers+1), err: true},
+		{name: "Answer too long", list: "cats|" + strings.Repeat("a", maxPollAnswer+1), err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			answers, err := parsePollAnswers(tc.list)
+			if (err != nil) != tc.err || !slices.Equal(answers, tc.expected) {
+				t.Errorf("parsePollAnswers(%q) = %q, %v; want %q, error %v", tc.list, answers, err, tc.expected, tc.err)
+			}
+		})
+	}
+}
+
+func TestTweetPoll(t *testing.T) {
+	content, poll := tweetPoll([]trends.Count{{Name: "1", Shares: 3}, {Name: "2", Shares: 1}}, 48)
+
+	if expected := "1. <https://x.com/i/status/1>\n2. <https://x.com/i/status/2>"; content != expected {
+		t.Errorf("content = %q; want %q", content, expected)
+	}
+	if len(poll.Answers) != 2 || poll.Answers[1].Media.Text != "Tweet 2" || poll.Duration != 48 || poll.AllowMultiselect {
+		t.Errorf("poll = %+v; want a single choice between 2 tweets for 48 hours", poll)
+	}
+}
+
+func TestOfferableRole(t *testing.T) {
+	roles := []*discordgo.Role{
+		{ID: "guild", Name: "@everyone", Position: 0},
+		{ID: "member", Name: "Member", Position: 1},
+		{ID: "mod", Name: "Mod", Position: 3},
+		{ID: "helper", Name: "Helper", Position: 2},
+		{ID: "bot", Name: "Bot", Position: 1, Managed: true},
+	}
+	testCases := []struct {
+		name        string
+		roleID      string
+		permissions int64
+		ok          bool
+	}{
+		{name: "Below", roleID: "member", ok: true},
+		{name: "Own highest", roleID: "helper"},
+		{name: "Above", roleID: "mod"},
+		{name: "Above as admin", roleID: "mod", permissions: discordgo.PermissionAdministrator, ok: true},
+		{name: "Everyone", roleID: "guild", permissions: discordgo.PermissionAdministrator},
+		{name: "Managed", roleID: "bot", permissions: discordgo.PermissionAdministrator},
+		{name: "Unknown", roleID: "gone", permissions: discordgo.PermissionAdministrator},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				GuildID: "guild",
+				Member:  &discordgo.Member{Roles: []string{"helper"}, Permissions: tc.permissions},
+			}}
+			role, problem := offerableRole(i, roles, tc.roleID)
+			if (problem == "") != tc.ok {
+				t.Errorf("offerableRole(%s) = %+v, %q; want ok %v", tc.roleID, role, problem, tc.ok)
+			}
+		})
+	}
+}
+
+func TestRoleBelow(t *testing.T) {
+	roles := []*discordgo.Role{
+		{ID: "guild", Position: 0},
+		{ID: "member", Position: 1},
+		{ID: "bot", Position: 2, Managed: true},
+		{ID: "mod", Position: 3},
+	}
+	testCases := []struct {
+		name        string
+		memberRoles []string
+		roleID      string
+		expected    bool
+	}{
+		{name: "Below", memberRoles: []string{"bot"}, roleID: "member", expected: true},
+		{name: "Own role", memberRoles: []string{"bot"}, roleID: "bot"},
+		{name: "Above", memberRoles: []string{"bot"}, roleID: "mod"},
+		{name: "Highest of several", memberRoles: []string{"member", "mod"}, roleID: "bot", expected: true},
+		{name: "No roles", roleID: "member"},
+		{name: "Unknown", memberRoles: []string{"mod"}, roleID: "gone"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := roleBelow(roles, tc.memberRoles, tc.roleID); result != tc.expected {
+				t.Errorf("roleBelow(%v, %s) = %v; want %v", tc.memberRoles, tc.roleID, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRoleMenuMessage(t *testing.T) {
+	menu := rolemenus.Menu{Title: "Colours"}
+	for n := range 7 {
+		menu.Roles = append(menu.Roles, rolemenus.Role{ID: fmt.Sprint(n), Name: fmt.Sprint("Role ", n)})
+	}
+
+	msg := roleMenuMessage(menu)
+	if len(msg.Components) != 2 || len(msg.Components[0].(discordgo.ActionsRow).Components) != 5 {
+		t.Fatalf("buttons laid out as %+v; want rows of 5 and 2", msg.Components)
+	}
+	if button := msg.Components[1].(discordgo.ActionsRow).Components[1].(discordgo.Button); button.CustomID != RoleMenuPrefix+":6" || button.Label != "Role 6" {
+		t.Errorf("last button = %+v", button)
+	}
+
+	menu.Select = true
+	msg = roleMenuMessage(menu)
+	selectMenu := msg.Components[0].(discordgo.ActionsRow).Components[0].(discordgo.SelectMenu)
+	if len(selectMenu.Options) != 7 || selectMenu.MaxValues != 7 || *selectMenu.MinValues != 0 {
+		t.Errorf("select menu = %+v", selectMenu)
+	}
+}
+
+func TestParseMessageID(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "123456789012345678", expected: "123456789012345678"},
+		{input: " https://discord.com/channels/1/2/123456789012345678 ", expected: "123456789012345678"},
+		{input: "nonsense", expected: "nonsense"},
+	}
+
+	for _, tc := range testCases {
+		if got := parseMessageID(tc.input); got != tc.expected {
+			t.Errorf("parseMessageID(%q) = %q; want %q", tc.input, got, tc.expected)
+		}
+	}
+}
+
+func TestPurgeable(t *testing.T) {
+	now := time.Now()
+	messages := []*discordgo.Message{
+		{ID: "1", Author: &discordgo.User{ID: "a"}, Timestamp: now.Add(-time.Minute)},
+		{ID: "2", Author: &discordgo.User{ID: "b"}, Timestamp: now.Add(-time.Hour)},
+		{ID: "3", Author: &discordgo.User{ID: "a"}, Timestamp: now.Add(-time.Hour), Pinned: true},
+		{ID: "4", Author: &discordgo.User{ID: "a"}, Timestamp: now.Add(-15 * 24 * time.Hour)},
+	}
+	testCases := []struct {
+		userID   string
+		expected []string
+	}{
+		{userID: "", expected: []string{"1", "2"}},
+		{userID: "a", expected: []string{"1"}},
+		{userID: "c"},
+	}
+
+	for _, tc := range testCases {
+		if got := purgeable(messages, tc.userID, now); !slices.Equal(got, tc.expected) {
+			t.Errorf("purgeable(%q) = %v; want %v", tc.userID, got, tc.expected)
+		}
+	}
+}
+
+func TestFormatSeconds(t *testing.T) {
+	testCases := []struct {
+		seconds  int
+		expected string
+	}{
+		{seconds: 1, expected: "1 second"},
+		{seconds: 90, expected: "90 seconds"},
+		{seconds: 120, expected: "2 minutes"},
+		{seconds: 3600, expected: "1 hour"},
+	}
+
+	for _, tc := range testCases {
+		if got := formatSeconds(tc.seconds); got != tc.expected {
+			t.Errorf("formatSeconds(%d) = %q; want %q", tc.seconds, got, tc.expected)
+		}
+	}
+}
+
+func TestActivityRange(t *testing.T) {
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		from, to string
+		expected string
+		wantErr  bool
+	}{
+		{name: "Defaults", expected: "2024-05-02 to 2024-05-31"},
+		{name: "Start only", from: "2024-05-20", expected: "2024-05-20 to 2024-05-31"},
+		{name: "End only", to: "2024-04-30", expected: "2024-04-01 to 2024-04-30"},
+		{name: "One day", from: "2024-05-01", to: "2024-05-01", expected: "2024-05-01 to 2024-05-01"},
+		{name: "Not a date", from: "last week", wantErr: true},
+		{name: "Backwards", from: "2024-05-02", to: "2024-05-01", wantErr: true},
+		{name: "Oldest kept day", from: "2024-03-03", expected: "2024-03-03 to 2024-05-31"},
+		{name: "Before the kept days", from: "2024-03-02", wantErr: true},
+		{name: "Future", to: "2024-06-01", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			from, to, err := activityRange(tc.from, tc.to, now)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("activityRange(%q, %q) error = %v; want error %v", tc.from, tc.to, err, tc.wantErr)
+			}
+			if got := from.Format(activityDate) + " to " + to.Format(activityDate); err == nil && got != tc.expected {
+				t.Errorf("activityRange(%q, %q) = %s; want %s", tc.from, tc.to, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestActivityReport(t *testing.T) {
+	days := []stats.Day{{Date: "2024-05-01", Reposts: 2, LinksFixed: 3, Users: map[string]int{"2": 1, "1": 2}, Commands: map[string]int{"stats": 1}}}
+	from, to := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	got, err := activityReport(days, activityCSV, "guild", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "date,action,subject,count\n2024-05-01,repost,,2\n2024-05-01,links_fixed,,3\n2024-05-01,duplicate,,0\n" +
+		"2024-05-01,user_links,1,2\n2024-05-01,user_links,2,1\n2024-05-01,command,stats,1\n"
+	if string(got) != expected {
+		t.Errorf("CSV report = %q; want %q", got, expected)
+	}
+
+	got, err = activityReport(nil, activityJSON, "guild", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), `"days": []`) || !strings.Contains(string(got), `"from": "2024-05-01"`) {
+		t.Errorf("JSON report of no days = %s; want an empty list of days from 2024-05-01", got)
+	}
+}
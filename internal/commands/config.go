@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"context"
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/domains"
+	"go-discord-bot/internal/storage"
+)
+
+// manageGuild is the permission admin commands need.
+const manageGuild int64 = discordgo.PermissionManageServer
+
+// Where commands can be installed and used. Server settings only make sense in
+// servers the bot was added to; link fixing also works when a user installs the
+// app for themselves, in any server or DM.
+var (
+	guildInstall  = &[]discordgo.ApplicationIntegrationType{discordgo.ApplicationIntegrationGuildInstall}
+	anyInstall    = &[]discordgo.ApplicationIntegrationType{discordgo.ApplicationIntegrationGuildInstall, discordgo.ApplicationIntegrationUserInstall}
+	guildContexts = &[]discordgo.InteractionContextType{discordgo.InteractionContextGuild}
+	anyContexts   = &[]discordgo.InteractionContextType{discordgo.InteractionContextGuild, discordgo.InteractionContextBotDM, discordgo.InteractionContextPrivateChannel}
+)
+
+// NewConfig builds the /config command used by admins to change guild
+// settings. pager pages through /config list, and fixerNames are the link
+// fixers /config platforms turns on and off and /config output styles. sites
+// checks the custom sites /config twitter site points links to.
+func NewConfig(st storage.Store, pager *Pager, fixerNames []string, sites *domains.Checker) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "config",
+			Description:      "Change how the bot behaves in this server",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				rewriteConfigGroup(),
+				privacyConfigGroup(),
+				ignoreConfigGroup(),
+				adminsConfigGroup(),
+				rolesConfigGroup(),
+				commandsConfigGroup(),
+				twitterConfigGroup(),
+				previewsConfigGroup(),
+				phishingConfigGroup(),
+				blockAuthorConfigGroup(),
+				unshortenConfigGroup(),
+				digestConfigGroup(),
+				timezoneConfigGroup(),
+				starboardConfigGroup(),
+				crosspostConfigGroup(),
+				voiceConfigGroup(),
+				auditConfigGroup(),
+				templateConfigGroup(),
+				triggerConfigGroup(),
+				markerConfigGroup(),
+				expireConfigGroup(),
+				outputConfigGroup(fixerNames),
+				platformsConfigCommand(),
+				listConfigCommand(),
+				exportConfigCommand(),
+				importConfigCommand(),
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+
+			// Most options are subcommand groups; platforms, list, export and import are plain subcommands
+			group := i.ApplicationCommandData().Options[0]
+			switch group.Name {
+			case "rewrite":
+				handleRewriteConfig(ctx, s, i, st, group.Options[0])
+			case "privacy":
+				handlePrivacyConfig(ctx, s, i, st, group.Options[0])
+			case "ignore":
+				handleIgnoreConfig(ctx, s, i, st, group.Options[0])
+			case "twitter":
+				handleTwitterConfig(ctx, s, i, st, sites, group.Options[0])
+			case "previews":
+				handlePreviewsConfig(ctx, s, i, st, group.Options[0])
+			case "phishing":
+				handlePhishingConfig(ctx, s, i, st, group.Options[0])
+			case "blockauthor":
+				handleBlockAuthorConfig(ctx, s, i, st, group.Options[0])
+			case "unshorten":
+				handleUnshortenConfig(ctx, s, i, st, group.Options[0])
+			case "digest":
+				handleDigestConfig(ctx, s, i, st, group.Options[0])
+			case "timezone":
+				handleTimezoneConfig(ctx, s, i, st, group.Options[0])
+			case "starboard":
+				handleStarboardConfig(ctx, s, i, st, group.Options[0])
+			case "crosspost":
+				handleCrosspostConfig(ctx, s, i, st, group.Options[0])
+			case "voice":
+				handleVoiceConfig(ctx, s, i, st, group.Options[0])
+			case "audit":
+				handleAuditConfig(ctx, s, i, st, group.Options[0])
+			case "template":
+				handleTemplateConfig(ctx, s, i, st, group.Options[0])
+			case "trigger":
+				handleTriggerConfig(ctx, s, i, st, group.Options[0])
+			case "marker":
+				handleMarkerConfig(ctx, s, i, st, group.Options[0])
+			case "expire":
+				handleExpireConfig(ctx, s, i, st, group.Options[0])
+			case "output":
+				handleOutputConfig(ctx, s, i, st, group.Options[0], fixerNames)
+			case "admins":
+				handleAdminsConfig(ctx, s, i, st, group.Options[0])
+			case "roles":
+				handleRolesConfig(ctx, s, i, st, group.Options[0])
+			case "commands":
+				handleCommandsConfig(ctx, s, i, st, group.Options[0])
+			case "platforms":
+				handlePlatformsConfig(ctx, s, i, st, fixerNames)
+			case "list":
+				handleListConfig(ctx, s, i, st, pager)
+			case "export":
+				handleExportConfig(ctx, s, i, st)
+			case "import":
+				handleImportConfig(ctx, s, i, st, group)
+			}
+		},
+		Component: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.MessageComponentData().CustomID == platformsMenuID {
+				handlePlatformsMenu(ctx, s, i, st, fixerNames)
+			}
+		},
+	}
+}
@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// rewriteRulesPerPage is how many rewrite rules one /config list page shows.
+const rewriteRulesPerPage = 10
+
+// listConfigCommand defines /config list.
+func listConfigCommand() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "list",
+		Description: "Show all of this server's settings",
+	}
+}
+
+// handleListConfig runs /config list, showing the guild's settings a few at a time.
+func handleListConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, pager *Pager) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+	pager.Respond(ctx, s, i, configPages(cfg), discordgo.MessageFlagsEphemeral)
+}
+
+// configPages lays a guild's settings out as embeds, one area per page.
+func configPages(cfg config.Guild) []*discordgo.MessageEmbed {
+	repostText := "Just the fixed message"
+	if cfg.RepostTemplate != "" {
+		repostText = "`" + cfg.RepostTemplate + "`"
+	}
+	twitter := "fxtwitter"
+	if cfg.TwitterSite != "" {
+		twitter = cfg.TwitterSite
+	}
+	if cfg.TwitterSite == config.TwitterCustom {
+		twitter = "`" + cfg.TwitterDomain + "`"
+	}
+	if cfg.TranslateTo != "" {
+		twitter += ", translated to " + cfg.TranslateTo
+	}
+	if cfg.ContextDepth > 0 {
+		twitter += fmt.Sprintf(", showing up to %d quoted or parent tweets", cfg.ContextDepth)
+	}
+	if cfg.MirrorMedia {
+		twitter += ", with copies of their media"
+	}
+	switch cfg.Galleries {
+	case config.GalleryRepost:
+		twitter += ", fixing tweets whose embed hides photos"
+	case config.GalleryAttach:
+		twitter += ", attaching photos embeds hide"
+	}
+	if cfg.Profiles == config.ProfileEmbed {
+		twitter += ", embedding profiles"
+	}
+	if cfg.SwapProxies {
+		twitter += ", swapping fixing sites that are down"
+	}
+
+	starboard := "Off"
+	if cfg.StarboardChannel != "" {
+		starboard = fmt.Sprintf("<#%s> at %d ⭐", cfg.StarboardChannel, cfg.Stars())
+	}
+	voice := "Off"
+	if cfg.VoiceChannel != "" {
+		voice = "<#" + cfg.VoiceChannel + ">"
+	}
+	audit := "Off"
+	if cfg.AuditChannel != "" {
+		audit = "<#" + cfg.AuditChannel + ">"
+	}
+	crosspost := "Off"
+	if cfg.Crosspost != "" {
+		crosspost = cfg.Crosspost
+	}
+	phishing := config.PhishingWarn
+	if cfg.PhishingAction != "" {
+		phishing = cfg.PhishingAction
+	}
+
+	output := "plain links"
+	if len(cfg.Outputs) > 0 {
+		platforms := slices.Sorted(maps.Keys(cfg.Outputs))
+		styles := make([]string, len(platforms))
+		for n, name := range platforms {
+			styles[n] = fixerLabel(name) + " " + cfg.Outputs[name]
+		}
+		output = strings.Join(styles, ", ")
+	}
+	if cfg.TextSummaries {
+		output += ", with text summaries of tweets"
+	}
+
+	paused := ""
+	if cfg.Paused {
+		paused = "**Paused**, use /resume to let the bot act again\n"
+	}
+
+	pages := []*discordgo.MessageEmbed{
+		{
+			Title:       "Link fixing",
+			Description: paused + formatSetup(cfg) + "\nRepost text: " + repostText + "\nTwitter: " + twitter + "\nSkip marker: `" + cfg.Marker() + "`\nOutput: " + output + "\n" + formatExpiry(cfg) + "\n" + formatFixReaction(cfg),
+		},
+		{
+			Title: "Features",
+			Description: strings.Join([]string{
+				formatPreviews(cfg.Previews),
+				formatUnshorten(cfg.Unshorten),
+				formatQuotes(cfg.QuoteLinks),
+				formatGames(cfg.GameEmbeds),
+				formatMusic(cfg.MusicSite),
+				formatDigest(cfg),
+				"Time zone: " + cfg.Location().String(),
+				"Counts and dates: " + cmp.Or(cfg.Locale, "the server's language"),
+				"Starboard: " + starboard,
+				"Voice notices: " + voice,
+				"Audit channel: " + audit,
+				formatWelcome(cfg),
+				"Crossposting: " + crosspost,
+				"Phishing links: " + phishing,
+				"Content filters: " + formatFilterCounts(cfg),
+				formatPrivacy(cfg.Privacy),
+			}, "\n"),
+		},
+		{
+			Title:       "Access",
+			Description: strings.Join([]string{formatAdminRoles(cfg), formatFeatureRoles(cfg), formatIgnored(cfg), formatDisabled(cfg)}, "\n"),
+		},
+	}
+
+	var rules []string
+	for n, rule := range cfg.RewriteRules {
+		rules = append(rules, fmt.Sprintf("%d. `%s` → `%s`", n+1, rule.Pattern, rule.Replacement))
+	}
+	for _, page := range paginate(rules, rewriteRulesPerPage) {
+		pages = append(pages, &discordgo.MessageEmbed{Title: "Rewrite rules", Description: strings.Join(page, "\n")})
+	}
+
+	var responses []string
+	for n, r := range cfg.AutoResponses {
+		responses = append(responses, fmt.Sprintf("%d. %s", n+1, formatAutoResponse(r)))
+	}
+	for _, page := range paginate(responses, rewriteRulesPerPage) {
+		pages = append(pages, &discordgo.MessageEmbed{Title: "Auto-responses", Description: strings.Join(page, "\n")})
+	}
+	return pages
+}
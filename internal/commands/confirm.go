@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ConfirmPrefix is the custom ID prefix of the buttons sent to authors in
+// guilds that confirm fixes before posting them.
+const ConfirmPrefix = "repost-confirm"
+
+// ConfirmButtons returns the row holding the Post and Cancel buttons for the
+// fix of the message with messageID.
+func ConfirmButtons(messageID string) discordgo.MessageComponent {
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Post", Style: discordgo.PrimaryButton, CustomID: ConfirmPrefix + ":post:" + messageID},
+		discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: ConfirmPrefix + ":cancel:" + messageID},
+	}}
+}
+
+// ConfirmFunc posts the fix held for the message with messageID, or drops it
+// unless post is set, and reports whether it was still held.
+type ConfirmFunc func(s *discordgo.Session, messageID string, post bool) bool
+
+// NewConfirmRepost returns the handler for the Post and Cancel buttons, which
+// hands the author's pick to confirm and updates the prompt to match.
+func NewConfirmRepost(confirm ConfirmFunc) InteractionHandler {
+	return func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+		parts := strings.Split(i.MessageComponentData().CustomID, ":")
+		if len(parts) != 3 {
+			return
+		}
+		post := parts[1] == "post"
+		content := "Cancelled, the fix won't be posted."
+		switch {
+		case !confirm(s, parts[2], post):
+			content = "This fix isn't waiting to be posted anymore."
+		case post:
+			content = "Posted."
+		}
+		respond(ctx, s, i, discordgo.InteractionResponseUpdateMessage, &discordgo.InteractionResponseData{
+			Content:    content,
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		})
+	}
+}
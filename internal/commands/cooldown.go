@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Cooldown limits how often a command can be used. Zero durations don't limit.
+type Cooldown struct {
+	// User is how long a user waits between uses of the command.
+	User time.Duration
+	// Channel is how long anyone waits between uses of the command in one channel.
+	Channel time.Duration
+}
+
+// sharedTimeout is how long a call to the Shared store may take.
+const sharedTimeout = 2 * time.Second
+
+// Shared is a store several replicas of the bot share, such as Redis, so a
+// command used through one of them is cooling down in all of them.
+type Shared interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+}
+
+// cooldowns remembers when commands were last used, keyed by command name and
+// the user or channel their cooldown applies to.
+type cooldowns struct {
+	mu sync.Mutex
+	// until maps a key to when its cooldown ends.
+	until map[string]time.Time
+}
+
+// take reports how long an interaction must wait before using cmd. If it
+// needn't wait, the use is recorded and starts cmd's cooldowns. They're kept
+// in shared instead if it isn't nil, or here when it fails.
+func (c *cooldowns) take(cmd Command, i *discordgo.InteractionCreate, now time.Time, shared Shared) time.Duration {
+	if cmd.Cooldown == (Cooldown{}) {
+		return 0
+	}
+	name := cmd.Definition.Name
+	var keys []string
+	var periods []time.Duration
+	if userID := interactionUser(i); cmd.Cooldown.User > 0 && userID != "" {
+		keys = append(keys, name+"/user/"+userID)
+		periods = append(periods, cmd.Cooldown.User)
+	}
+	if cmd.Cooldown.Channel > 0 && i.ChannelID != "" {
+		keys = append(keys, name+"/channel/"+i.ChannelID)
+		periods = append(periods, cmd.Cooldown.Channel)
+	}
+	if shared != nil {
+		wait, err := takeShared(shared, keys, periods, now)
+		if err == nil {
+			return wait
+		}
+		log.Println("Error taking shared command cooldown:", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var wait time.Duration
+	for _, key := range keys {
+		wait = max(wait, c.until[key].Sub(now))
+	}
+	if wait > 0 {
+		return wait
+	}
+	for n, key := range keys {
+		c.until[key] = now.Add(periods[n])
+	}
+	return 0
+}
+
+// takeShared does the work of take with the shared store, where each key
+// holds when its cooldown ends, in Unix milliseconds.
+func takeShared(shared Shared, keys []string, periods []time.Duration, now time.Time) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sharedTimeout)
+	defer cancel()
+	var wait time.Duration
+	for _, key := range keys {
+		w, err := sharedWait(ctx, shared, key, now)
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, w)
+	}
+	if wait > 0 {
+		return wait, nil
+	}
+	for n, key := range keys {
+		until := now.Add(periods[n])
+		taken, err := shared.SetNX(ctx, "cooldown:"+key, strconv.FormatInt(until.UnixMilli(), 10), periods[n])
+		if err != nil {
+			return 0, err
+		}
+		if taken {
+			continue
+		}
+		// Another replica took it in the meantime
+		if wait, err = sharedWait(ctx, shared, key, now); err != nil || wait > 0 {
+			return wait, err
+		}
+	}
+	return 0, nil
+}
+
+// sharedWait returns how long key's cooldown in the shared store has left.
+func sharedWait(ctx context.Context, shared Shared, key string, now time.Time) (time.Duration, error) {
+	value, ok, err := shared.Get(ctx, "cooldown:"+key)
+	if err != nil || !ok {
+		return 0, err
+	}
+	until, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return time.UnixMilli(until).Sub(now), nil
+}
+
+// Prune forgets cooldowns that ended by now and returns how many it removed.
+func (c *cooldowns) Prune(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pruned := 0
+	for key, until := range c.until {
+		if !now.Before(until) {
+			delete(c.until, key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many cooldowns are remembered.
+func (c *cooldowns) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.until)
+}
+
+// interactionUser returns the ID of the user behind an interaction, in a guild or not.
+func interactionUser(i *discordgo.InteractionCreate) string {
+	switch {
+	case i.Member != nil && i.Member.User != nil:
+		return i.Member.User.ID
+	case i.User != nil:
+		return i.User.ID
+	}
+	return ""
+}
+
+// cooldownMessage tells a user how long to wait, rounding up to whole seconds.
+func cooldownMessage(wait time.Duration) string {
+	return fmt.Sprintf("Slow down! Try again in %ds.", int(math.Ceil(wait.Seconds())))
+}
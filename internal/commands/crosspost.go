@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// crosspostOff is the /config crosspost choice for not publishing anything.
+const crosspostOff = "off"
+
+// crosspostConfigGroup defines the /config crosspost subcommands.
+func crosspostConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "crosspost",
+		Description: "Publish messages in announcement channels to the servers following them",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "mode",
+				Description: "Choose which messages in announcement channels get published",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "What to publish",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "The bot's reposts", Value: config.CrosspostReposts},
+							{Name: "Everything (needs Manage Messages)", Value: config.CrosspostAll},
+							{Name: "Nothing", Value: crosspostOff},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// handleCrosspostConfig runs a /config crosspost subcommand.
+func handleCrosspostConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	cfg.Crosspost = OptionMap(sub.Options)["mode"].StringValue()
+	if cfg.Crosspost == crosspostOff {
+		cfg.Crosspost = ""
+	}
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+
+	switch cfg.Crosspost {
+	case config.CrosspostReposts:
+		RespondEphemeral(ctx, s, i, "Saved. The bot will publish its reposts in announcement channels, up to Discord's limit of 10 an hour per channel.")
+	case config.CrosspostAll:
+		RespondEphemeral(ctx, s, i, "Saved. The bot will publish every message in announcement channels, up to Discord's limit of 10 an hour per channel.")
+	default:
+		RespondEphemeral(ctx, s, i, "Saved. The bot won't publish messages.")
+	}
+}
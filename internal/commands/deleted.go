@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/timestamp"
+	"go-discord-bot/internal/trash"
+)
+
+// deletedPerPage is how many deleted messages one /deleted list page shows.
+const deletedPerPage = 5
+
+// deletedPreviewLength is how much of a deleted message /deleted list shows.
+const deletedPreviewLength = 200
+
+// NewDeleted builds the /deleted command, which lets moderators see the
+// messages the bot deleted and restore them. It needs Manage Messages.
+func NewDeleted(bin *trash.Bin, pager *Pager) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "deleted",
+			Description:      "See and restore messages the bot deleted",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show the messages the bot deleted recently",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "restore",
+					Description: "Post a deleted message again in its channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "The deleted message's ID, from /deleted list", Required: true},
+					},
+				},
+			},
+		},
+		Module:      ModuleModeration,
+		Permissions: discordgo.PermissionManageMessages,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if bin == nil {
+				RespondEphemeral(ctx, s, i, "The bot doesn't keep the messages it deletes.")
+				return
+			}
+			sub := i.ApplicationCommandData().Options[0]
+			switch sub.Name {
+			case "list":
+				kept, err := bin.List(i.GuildID)
+				if err != nil {
+					log.Println("Error listing deleted messages:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't load the deleted messages, try again later.")
+					return
+				}
+				if len(kept) == 0 {
+					RespondEphemeral(ctx, s, i, fmt.Sprintf("The bot hasn't deleted anything here in the last %s.", bin.Retention()))
+					return
+				}
+				pager.Respond(ctx, s, i, deletedPages(kept), discordgo.MessageFlagsEphemeral)
+			case "restore":
+				restoreDeleted(ctx, s, i, bin, strings.TrimSpace(OptionMap(sub.Options)["id"].StringValue()))
+			}
+		},
+	}
+}
+
+// restoreDeleted posts the deleted message messageID back in its channel.
+func restoreDeleted(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, bin *trash.Bin, messageID string) {
+	m, ok, err := bin.Take(i.GuildID, messageID)
+	if err != nil {
+		log.Println("Error restoring deleted message:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load the deleted messages, try again later.")
+		return
+	}
+	if !ok {
+		RespondEphemeral(ctx, s, i, "There's no deleted message with that ID. `/deleted list` shows the ones that can be restored.")
+		return
+	}
+
+	for _, piece := range chunk.Split(restoredContent(m), chunk.MaxMessageLength) {
+		_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content:         piece,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			log.Println("Error restoring deleted message:", err)
+			if err := bin.Return(i.GuildID, m); err != nil {
+				log.Println("Error keeping deleted message:", err)
+			}
+			RespondEphemeral(ctx, s, i, "Couldn't post the message back, check the bot can still send messages in <#"+m.ChannelID+">.")
+			return
+		}
+	}
+	RespondEphemeral(ctx, s, i, "Restored the message in <#"+m.ChannelID+">.")
+}
+
+// restoredContent is the message posting m back: who wrote it, then what it said.
+func restoredContent(m trash.Message) string {
+	lines := []string{fmt.Sprintf("Restored a message from <@%s>, deleted %s:", m.AuthorID, timestamp.Format(m.DeletedAt, timestamp.Relative))}
+	if m.Content != "" {
+		lines = append(lines, m.Content)
+	}
+	for _, a := range m.Attachments {
+		lines = append(lines, a.URL)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// deletedPages lays deleted messages out as embeds, newest first.
+func deletedPages(kept []trash.Message) []*discordgo.MessageEmbed {
+	entries := make([]string, len(kept))
+	for n, m := range kept {
+		preview := m.Content
+		if len(preview) > deletedPreviewLength {
+			preview = strings.ToValidUTF8(preview[:deletedPreviewLength], "") + "…"
+		}
+		entry := fmt.Sprintf("`%s` from <@%s> in <#%s>, %s as %s", m.ID, m.AuthorID, m.ChannelID, timestamp.Format(m.DeletedAt, timestamp.Relative), m.Reason)
+		if preview != "" {
+			entry += "\n> " + strings.ReplaceAll(preview, "\n", "\n> ")
+		}
+		if len(m.Attachments) > 0 {
+			entry += "\n" + plural(len(m.Attachments), "attachment")
+		}
+		entries[n] = entry
+	}
+
+	var pages []*discordgo.MessageEmbed
+	for _, page := range paginate(entries, deletedPerPage) {
+		pages = append(pages, &discordgo.MessageEmbed{
+			Title:       "Deleted messages",
+			Description: strings.Join(page, "\n\n"),
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Restore one with /deleted restore <id>"},
+		})
+	}
+	return pages
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/explain"
+)
+
+// DiagnoseFunc works out what the bot would do with a message if it were
+// posted now, without doing it, and returns the checks it made.
+type DiagnoseFunc func(ctx context.Context, s *discordgo.Session, m *discordgo.Message) explain.Trace
+
+// reasons explain the decisions of a diagnosis in plain words.
+var reasons = map[string]string{
+	"outside":             "The bot is limited to other servers.",
+	"paused":              "The bot is paused in this server. `/resume` turns it back on.",
+	"ignored":             "The author, or one of their roles, is on this server's ignore list.",
+	"not allowed":         "Fixing is limited to roles the author doesn't have.",
+	"phishing":            "It links to a known phishing or malware site, so the bot warns about it instead.",
+	"removed":             "It links to a tweet by an account this server blocks, so the bot removes it. `/config blockauthor list` shows them.",
+	"skipped":             "It starts with the skip marker, which asks the bot to leave it alone.",
+	"channel disabled":    "Fixing is off in this channel.",
+	"waiting for trigger": "Links here are only fixed when someone reacts with the trigger emoji.",
+	"yielded":             "Another link-fixing bot is in this server, so the bot leaves links to it. `/fixerbots mode` changes that.",
+	"no links":            "It has no links.",
+	"too long":            "It's too long or has too many links for the bot to go through.",
+	"no fixable links":    "None of its links are ones the bot fixes, or the fixers for them are turned off here.",
+	"preview working":     "Discord's preview of it already works, so there was nothing to fix.",
+	"timed out":           "Checking its links took too long. Try again in a moment.",
+	"already fixed":       "The bot already fixed it.",
+	"filtered":            "It links to a tweet this server's content filters block. `/filters list` shows them.",
+	"would fix":           "Nothing stops it being fixed now. It may have been held back at the time, such as by flood protection, or posted while the bot was offline. Reprocess it to fix it.",
+}
+
+// NewWhyNotFixed builds the "Why Wasn't This Fixed?" message context-menu
+// command, which checks a message the way the bot would if it were posted
+// now, and tells moderators in plain words what stops it being fixed. It needs
+// Manage Messages.
+func NewWhyNotFixed(diagnose DiagnoseFunc) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Type:             discordgo.MessageApplicationCommand,
+			Name:             "Why Wasn't This Fixed?",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+		},
+		Module:      ModuleModeration,
+		Permissions: discordgo.PermissionManageMessages,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			data := i.ApplicationCommandData()
+			var target *discordgo.Message
+			if data.Resolved != nil {
+				target = data.Resolved.Messages[data.TargetID]
+			}
+			if diagnose == nil || target == nil {
+				RespondEphemeral(ctx, s, i, "Couldn't check that message.")
+				return
+			}
+			// Resolved messages don't say where they are
+			m := *target
+			m.GuildID, m.ChannelID = i.GuildID, i.ChannelID
+			RespondEphemeral(ctx, s, i, diagnosisMessage(diagnose(ctx, s, &m)))
+		},
+	}
+}
+
+// diagnosisMessage explains a diagnosis, followed by the checks behind it.
+func diagnosisMessage(trace explain.Trace) string {
+	reason, ok := reasons[trace.Decision]
+	if !ok {
+		reason = fmt.Sprintf("The bot would decide: **%s**.", trace.Decision)
+	}
+	lines := []string{reason, "", "Checked:"}
+	for _, step := range trace.Steps {
+		lines = append(lines, fmt.Sprintf("- %s: %s", step.Stage, step.Result))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// digestConfigGroup defines the /config digest subcommands.
+func digestConfigGroup() *discordgo.ApplicationCommandOption {
+	midnight := 0.0
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "digest",
+		Description: "Copy fixed tweet links into an archive channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Choose the archive channel and how links are posted there",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Where fixed links are copied",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "Post each link as it's fixed, or one digest a day (default: each link)",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Each link", Value: config.DigestLive},
+							{Name: "Daily digest", Value: config.DigestDaily},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "hour",
+						Description: "Hour of the day, 0-23 in the server's time zone, the daily digest is posted (default: 0)",
+						MinValue:    &midnight,
+						MaxValue:    23,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Stop copying fixed links",
+			},
+		},
+	}
+}
+
+// handleDigestConfig runs a /config digest subcommand.
+func handleDigestConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "channel":
+		opts := OptionMap(sub.Options)
+		cfg.DigestChannel = opts["channel"].ChannelValue(nil).ID
+		cfg.DigestMode = config.DigestLive
+		if mode, ok := opts["mode"]; ok {
+			cfg.DigestMode = mode.StringValue()
+		}
+		cfg.DigestHour = 0
+		if hour, ok := opts["hour"]; ok {
+			cfg.DigestHour = int(hour.IntValue())
+		}
+	case "off":
+		cfg.DigestChannel = ""
+		cfg.DigestMode = ""
+		cfg.DigestHour = 0
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatDigest(cfg))
+}
+
+// formatDigest describes the digest settings.
+func formatDigest(cfg config.Guild) string {
+	switch {
+	case cfg.DigestChannel == "":
+		return "Fixed links aren't copied anywhere."
+	case cfg.DigestMode == config.DigestDaily:
+		return fmt.Sprintf("Fixed tweet links will be collected into a daily digest in <#%s>, posted at %02d:00 %s time.", cfg.DigestChannel, cfg.DigestHour, cfg.Location())
+	default:
+		return "Fixed tweet links will be copied to <#" + cfg.DigestChannel + "> as they're fixed."
+	}
+}
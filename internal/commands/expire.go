@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// expireConfigGroup defines the /config expire subcommands.
+func expireConfigGroup() *discordgo.ApplicationCommandOption {
+	minHours := 1.0
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "expire",
+		Description: "Have the bot delete its reposts after a while",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Delete reposts once they've been up this long",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "hours",
+						Description: "How many hours reposts stay up, such as 24",
+						Required:    true,
+						MinValue:    &minHours,
+						MaxValue:    config.MaxRepostTTLHours,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Keep reposts up",
+			},
+		},
+	}
+}
+
+// handleExpireConfig runs a /config expire subcommand. Reposts already posted
+// keep the lifetime they were posted with.
+func handleExpireConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "set":
+		cfg.RepostTTLHours = int(OptionMap(sub.Options)["hours"].IntValue())
+	case "off":
+		cfg.RepostTTLHours = 0
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatExpiry(cfg)+".")
+}
+
+// formatExpiry describes how long a guild's reposts stay up.
+func formatExpiry(cfg config.Guild) string {
+	if cfg.RepostTTLHours == 0 {
+		return "Reposts stay up"
+	}
+	return fmt.Sprintf("Reposts are deleted after %s", plural(cfg.RepostTTLHours, "hour"))
+}
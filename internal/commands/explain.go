@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/timestamp"
+)
+
+// NewExplain builds the "Explain Fix" message context-menu command, which
+// shows moderators the checks the bot made on a message and what it decided,
+// to answer why a link was or wasn't fixed. It needs Manage Messages.
+func NewExplain(decisions *explain.Log) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Type:             discordgo.MessageApplicationCommand,
+			Name:             "Explain Fix",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+		},
+		Module:      ModuleModeration,
+		Permissions: discordgo.PermissionManageMessages,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if decisions == nil {
+				RespondEphemeral(ctx, s, i, "The bot doesn't keep a log of its decisions.")
+				return
+			}
+			trace, ok := decisions.Get(i.ApplicationCommandData().TargetID)
+			if !ok || trace.GuildID != i.GuildID {
+				RespondEphemeral(ctx, s, i, "The bot has no record of that message. It only remembers the messages it saw recently, since it last started.")
+				return
+			}
+			RespondEphemeral(ctx, s, i, explainMessage(trace))
+		},
+	}
+}
+
+// explainMessage describes how the bot handled a message, one step a line.
+func explainMessage(trace explain.Trace) string {
+	lines := []string{fmt.Sprintf("Seen %s, from %s:", timestamp.Format(trace.Received, timestamp.Relative), trace.Source)}
+	for _, step := range trace.Steps {
+		lines = append(lines, fmt.Sprintf("- %s: %s", step.Stage, step.Result))
+	}
+	if trace.Decision == "" {
+		lines = append(lines, "Still being handled.")
+	} else {
+		lines = append(lines, "Decision: **"+trace.Decision+"**")
+	}
+	// Matches the message to the bot's logs, should it be reported
+	return strings.Join(lines, "\n") + correlation.Footer(trace.CorrelationID)
+}
@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/feeds"
+	"go-discord-bot/internal/storage"
+)
+
+// NewFeed builds the /feed command, which subscribes channels to RSS and Atom
+// feeds. fetcher checks a feed is readable before it's added.
+func NewFeed(st storage.Store, fetcher *feeds.Fetcher) Command {
+	minInterval := feeds.MinInterval.Minutes()
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "feed",
+			Description:      "Post new items from RSS and Atom feeds",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Post a feed's new items in a channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "url", Description: "The feed's address", Required: true},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Where new items are posted",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "interval",
+							Description: fmt.Sprintf("Minutes between checks (default %d)", int(feeds.DefaultInterval.Minutes())),
+							MinValue:    &minInterval,
+							MaxValue:    24 * 60,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "format",
+							Description: `How items are posted, using {feed}, {title}, {link} and \n`,
+							MaxLength:   500,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Stop posting a feed",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "The feed's ID from /feed list", Required: true},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show the feeds posted in this server",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+
+			sub := i.ApplicationCommandData().Options[0]
+			switch sub.Name {
+			case "add":
+				handleFeedAdd(ctx, s, i, st, fetcher, sub)
+			case "remove":
+				id := strings.TrimSpace(OptionMap(sub.Options)["id"].StringValue())
+				removed, err := feeds.Remove(st, i.GuildID, id)
+				if err != nil {
+					log.Println("Error removing feed:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't remove that feed, try again later.")
+					return
+				}
+				if !removed {
+					RespondEphemeral(ctx, s, i, "There's no feed `"+id+"` in this server. Use `/feed list` to see the IDs.")
+					return
+				}
+				RespondEphemeral(ctx, s, i, "Removed feed `"+id+"`.")
+			case "list":
+				subs, err := feeds.List(st, i.GuildID)
+				if err != nil {
+					log.Println("Error listing feeds:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't load this server's feeds, try again later.")
+					return
+				}
+				RespondEphemeral(ctx, s, i, formatFeeds(subs))
+			}
+		},
+	}
+}
+
+// handleFeedAdd runs /feed add: it checks the feed can be read, then saves the
+// subscription with every current item marked as seen.
+func handleFeedAdd(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, fetcher *feeds.Fetcher, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	opts := OptionMap(opt.Options)
+	sub := feeds.Subscription{
+		GuildID:   i.GuildID,
+		ChannelID: opts["channel"].ChannelValue(nil).ID,
+		URL:       strings.TrimSpace(opts["url"].StringValue()),
+		Interval:  feeds.DefaultInterval,
+	}
+	if interval, ok := opts["interval"]; ok {
+		sub.Interval = time.Duration(interval.IntValue()) * time.Minute
+	}
+	if format, ok := opts["format"]; ok {
+		sub.Format = format.StringValue()
+	}
+	sub.ID = feeds.SubscriptionID(sub.ChannelID, sub.URL)
+
+	if !feeds.ValidURL(sub.URL) {
+		RespondEphemeral(ctx, s, i, "That isn't a web address.")
+		return
+	}
+	existing, err := feeds.List(st, i.GuildID)
+	if err != nil {
+		log.Println("Error listing feeds:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's feeds, try again later.")
+		return
+	}
+	if len(existing) >= feeds.MaxPerGuild {
+		RespondEphemeral(ctx, s, i, fmt.Sprintf("This server already has %d feeds, the most it can have. Remove one first.", feeds.MaxPerGuild))
+		return
+	}
+
+	RespondEphemeral(ctx, s, i, addFeed(ctx, st, fetcher, sub))
+}
+
+// addFeed fetches a new subscription's feed and saves it, returning the reply.
+func addFeed(ctx context.Context, st storage.Store, fetcher *feeds.Fetcher, sub feeds.Subscription) string {
+	feed, err := fetcher.Fetch(ctx, sub.URL)
+	if err != nil {
+		log.Println("Error fetching feed:", err)
+		return "Couldn't read a feed at that address. Check it's an RSS or Atom feed anyone can open."
+	}
+	sub.Title = feed.Title
+	if sub.Title == "" {
+		sub.Title = sub.URL
+	}
+	sub.MarkSeen(feed)
+	sub.LastPoll = time.Now()
+	if err := feeds.Save(st, sub); err != nil {
+		log.Println("Error saving feed:", err)
+		return "Couldn't save the feed, try again later."
+	}
+	return fmt.Sprintf("New items from **%s** will be posted in <#%s>. Its ID is `%s`.", sub.Title, sub.ChannelID, sub.ID)
+}
+
+// formatFeeds lists a guild's subscriptions.
+func formatFeeds(subs []feeds.Subscription) string {
+	if len(subs) == 0 {
+		return "This server has no feeds. Add one with `/feed add`."
+	}
+	var b strings.Builder
+	b.WriteString("**Feeds**\n")
+	for _, sub := range subs {
+		fmt.Fprintf(&b, "`%s` **%s** in <#%s>, every %d minutes\n", sub.ID, sub.Title, sub.ChannelID, int(sub.Interval.Minutes()))
+	}
+	return chunk.Split(strings.TrimSuffix(b.String(), "\n"), chunk.MaxMessageLength)[0]
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// maxFeedBots is how many bots and webhooks a guild can have links in the
+// embeds of fixed.
+const maxFeedBots = 10
+
+// messageLinkPattern matches a link to a message in a guild, capturing the
+// channel and message IDs.
+var messageLinkPattern = regexp.MustCompile(`/channels/\d+/(\d+)/(\d+)`)
+
+// NewFeedBots builds the /feedbots command, which lists the bots and webhooks,
+// such as feed bots posting tweets, whose embeds have their Twitter/X links
+// fixed. Sources are picked by a message they posted, since webhooks can't be
+// picked as users.
+func NewFeedBots(st storage.Store) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "feedbots",
+			Description:      "Fix Twitter/X links in the embeds other bots and webhooks post",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Fix the links in a bot's or webhook's embeds",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "A link to a message it posted", Required: true},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Stop fixing the links in a bot's or webhook's embeds",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "source", Description: "Its ID, as /feedbots list shows it", Required: true},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List the bots and webhooks whose embeds have their links fixed",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleFeedBots(ctx, s, i, st, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleFeedBots runs a /feedbots subcommand.
+func handleFeedBots(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	switch sub.Name {
+	case "add":
+		match := messageLinkPattern.FindStringSubmatch(opts["message"].StringValue())
+		if match == nil {
+			RespondEphemeral(ctx, s, i, "That isn't a message link. Use **Copy Message Link** on a message the bot or webhook posted.")
+			return
+		}
+		m, err := s.ChannelMessage(match[1], match[2], discordgo.WithContext(ctx))
+		if err != nil {
+			log.Println("Error fetching feed bot message:", err)
+			RespondEphemeral(ctx, s, i, "Couldn't find that message. Check the link, and that the bot can see its channel.")
+			return
+		}
+		if m.GuildID != "" && m.GuildID != i.GuildID {
+			RespondEphemeral(ctx, s, i, "That message is in another server.")
+			return
+		}
+		if m.Author == nil || !m.Author.Bot {
+			RespondEphemeral(ctx, s, i, "That message wasn't posted by a bot or webhook.")
+			return
+		}
+		if _, ok := cfg.FeedSources[m.Author.ID]; ok {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("**%s** is listed already.", m.Author.Username))
+			return
+		}
+		if len(cfg.FeedSources) >= maxFeedBots {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("This server already lists the maximum of %d bots and webhooks.", maxFeedBots))
+			return
+		}
+		if cfg.FeedSources == nil {
+			cfg.FeedSources = make(map[string]string)
+		}
+		cfg.FeedSources[m.Author.ID] = m.Author.Username
+
+	case "remove":
+		id := strings.TrimSpace(opts["source"].StringValue())
+		if _, ok := cfg.FeedSources[id]; !ok {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("`%s` isn't listed.", id))
+			return
+		}
+		delete(cfg.FeedSources, id)
+
+	case "list":
+		RespondEphemeral(ctx, s, i, formatFeedBots(cfg))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatFeedBots(cfg))
+}
+
+// formatFeedBots lists the bots and webhooks whose embeds have their links
+// fixed, by name and ID.
+func formatFeedBots(cfg config.Guild) string {
+	if len(cfg.FeedSources) == 0 {
+		return "Links in other bots' embeds aren't fixed. `/feedbots add` picks a bot or webhook whose are."
+	}
+	lines := []string{"Twitter/X links in the embeds of these bots and webhooks are fixed:"}
+	for _, id := range slices.Sorted(maps.Keys(cfg.FeedSources)) {
+		lines = append(lines, fmt.Sprintf("- **%s** (`%s`)", cfg.FeedSources[id], id))
+	}
+	return strings.Join(lines, "\n")
+}
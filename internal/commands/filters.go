@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// NewFilters builds the /filters command, which keeps the bot from reposting
+// tweets by blocked accounts or mentioning blocked keywords.
+func NewFilters(st storage.Store) Command {
+	accountOption := []*discordgo.ApplicationCommandOption{
+		{Type: discordgo.ApplicationCommandOptionString, Name: "account", Description: "The Twitter/X account, such as @someone", Required: true, MaxLength: 16},
+	}
+	keywordOption := []*discordgo.ApplicationCommandOption{
+		{Type: discordgo.ApplicationCommandOptionString, Name: "keyword", Description: "A word or phrase, matched in any case", Required: true, MaxLength: config.MaxKeywordLength},
+	}
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "filters",
+			Description:      "Keep the bot from reposting tweets this server doesn't allow",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "account",
+					Description: "Accounts whose tweets aren't reposted",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "add", Description: "Stop reposting an account's tweets", Options: accountOption},
+						{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "remove", Description: "Repost an account's tweets again", Options: accountOption},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "keyword",
+					Description: "Words tweets containing them aren't reposted for",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "add", Description: "Stop reposting tweets containing a keyword", Options: keywordOption},
+						{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "remove", Description: "Repost tweets containing a keyword again", Options: keywordOption},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "action",
+					Description: "Choose what happens to messages with filtered tweets",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "action",
+							Description: "What the bot does",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Don't fix their links", Value: config.FilterSkip},
+								{Name: "Don't fix their links, tell moderators", Value: config.FilterFlag},
+								{Name: "Remove the messages (needs Manage Messages)", Value: config.FilterRemove},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List this server's content filters",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleFilters(ctx, s, i, st, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleFilters runs a /filters subcommand or subcommand group.
+func handleFilters(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	name := sub.Name
+	if sub.Type == discordgo.ApplicationCommandOptionSubCommandGroup {
+		sub = sub.Options[0]
+		name += " " + sub.Name
+	}
+	var value string
+	if len(sub.Options) > 0 {
+		value = sub.Options[0].StringValue()
+	}
+	changeFilters(ctx, s, i, st, name, value)
+}
+
+// changeFilters makes a change to the guild's content filters, named like
+// the /filters subcommand making it, such as "account add", with the value of
+// its option, and shows the filters.
+func changeFilters(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, name, value string) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch name {
+	case "account add", "account remove":
+		account := strings.TrimPrefix(strings.TrimSpace(value), "@")
+		if !config.ValidAccount(account) {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("`%s` isn't a Twitter/X account name.", account))
+			return
+		}
+		n := slices.IndexFunc(cfg.BlockedAccounts, func(blocked string) bool { return strings.EqualFold(blocked, account) })
+		if name == "account remove" {
+			if n < 0 {
+				RespondEphemeral(ctx, s, i, fmt.Sprintf("@%s isn't blocked.", account))
+				return
+			}
+			cfg.BlockedAccounts = slices.Delete(cfg.BlockedAccounts, n, n+1)
+			break
+		}
+		if n >= 0 {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("@%s is blocked already.", account))
+			return
+		}
+		if len(cfg.BlockedAccounts) >= config.MaxBlockedAccounts {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("This server already blocks the maximum of %d accounts.", config.MaxBlockedAccounts))
+			return
+		}
+		cfg.BlockedAccounts = append(cfg.BlockedAccounts, account)
+
+	case "keyword add", "keyword remove":
+		keyword := strings.Join(strings.Fields(value), " ")
+		if keyword == "" {
+			RespondEphemeral(ctx, s, i, "Keywords can't be blank.")
+			return
+		}
+		n := slices.IndexFunc(cfg.BlockedKeywords, func(blocked string) bool { return strings.EqualFold(blocked, keyword) })
+		if name == "keyword remove" {
+			if n < 0 {
+				RespondEphemeral(ctx, s, i, fmt.Sprintf("%q isn't blocked.", keyword))
+				return
+			}
+			cfg.BlockedKeywords = slices.Delete(cfg.BlockedKeywords, n, n+1)
+			break
+		}
+		if n >= 0 {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("%q is blocked already.", keyword))
+			return
+		}
+		if len(cfg.BlockedKeywords) >= config.MaxBlockedKeywords {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("This server already blocks the maximum of %d keywords.", config.MaxBlockedKeywords))
+			return
+		}
+		cfg.BlockedKeywords = append(cfg.BlockedKeywords, keyword)
+
+	case "action":
+		cfg.FilterAction = value
+		if cfg.FilterAction == config.FilterSkip {
+			cfg.FilterAction = ""
+		}
+
+	case "list":
+		RespondEphemeral(ctx, s, i, formatFilters(cfg))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatFilters(cfg))
+}
+
+// formatFilters describes a guild's content filters and what happens to the
+// messages they catch.
+func formatFilters(cfg config.Guild) string {
+	if !cfg.Filters() {
+		return "No content filters: every tweet is reposted."
+	}
+	var lines []string
+	if len(cfg.BlockedAccounts) > 0 {
+		lines = append(lines, "Blocked accounts: @"+strings.Join(cfg.BlockedAccounts, ", @"))
+	}
+	if len(cfg.BlockedKeywords) > 0 {
+		quoted := make([]string, len(cfg.BlockedKeywords))
+		for n, keyword := range cfg.BlockedKeywords {
+			quoted[n] = fmt.Sprintf("%q", keyword)
+		}
+		lines = append(lines, "Blocked keywords: "+strings.Join(quoted, ", "))
+	}
+	switch cfg.FilterAction {
+	case config.FilterFlag:
+		lines = append(lines, "Links to filtered tweets aren't fixed, and moderators are told in the audit channel.")
+	case config.FilterRemove:
+		lines = append(lines, "Messages with links to filtered tweets are removed, or their links aren't fixed if the bot can't remove them.")
+	default:
+		lines = append(lines, "Links to filtered tweets aren't fixed.")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatFilterCounts sums up a guild's content filters for /config list.
+func formatFilterCounts(cfg config.Guild) string {
+	if !cfg.Filters() {
+		return "Off"
+	}
+	summary := fmt.Sprintf("%d accounts and %d keywords blocked", len(cfg.BlockedAccounts), len(cfg.BlockedKeywords))
+	switch cfg.FilterAction {
+	case config.FilterFlag:
+		summary += ", flagged to moderators"
+	case config.FilterRemove:
+		summary += ", removed"
+	}
+	return summary
+}
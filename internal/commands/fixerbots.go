@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/overlap"
+	"go-discord-bot/internal/storage"
+)
+
+// maxFixerBots is how many bots admins can list as fixing links.
+const maxFixerBots = 10
+
+// NewFixerBots builds the /fixerbots command, which chooses what the bot does
+// in servers with other link-fixing bots and lists those bots. others holds the
+// bots the bot noticed itself.
+func NewFixerBots(st storage.Store, others *overlap.Detector) Command {
+	botOption := []*discordgo.ApplicationCommandOption{
+		{Type: discordgo.ApplicationCommandOptionUser, Name: "bot", Description: "The other bot", Required: true},
+	}
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "fixerbots",
+			Description:      "Keep links from being reposted by this bot and another link-fixing bot",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "mode",
+					Description: "Choose what happens when another bot fixes links here",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "mode",
+							Description: "What this bot does",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Leave links to the other bot", Value: config.OthersYield},
+								{Name: "Keep fixing links, tell moderators", Value: config.OthersAlert},
+								{Name: "Keep fixing links, don't look for other bots", Value: config.OthersIgnore},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Tell the bot another bot fixes links here",
+					Options:     botOption,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Tell the bot another bot doesn't fix links here",
+					Options:     botOption,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List the other bots fixing links here",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleFixerBots(ctx, s, i, st, others, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleFixerBots runs a /fixerbots subcommand.
+func handleFixerBots(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, others *overlap.Detector, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	switch sub.Name {
+	case "mode":
+		cfg.OtherFixers = opts["mode"].StringValue()
+		if cfg.OtherFixers == config.OthersYield {
+			cfg.OtherFixers = ""
+		}
+
+	case "add":
+		user := opts["bot"].UserValue(nil)
+		if !user.Bot {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("<@%s> isn't a bot.", user.ID))
+			return
+		}
+		if slices.Contains(cfg.FixerBots, user.ID) {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("<@%s> is listed already.", user.ID))
+			return
+		}
+		if len(cfg.FixerBots) >= maxFixerBots {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("This server already lists the maximum of %d bots.", maxFixerBots))
+			return
+		}
+		cfg.FixerBots = append(cfg.FixerBots, user.ID)
+
+	case "remove":
+		id := opts["bot"].UserValue(nil).ID
+		n := slices.Index(cfg.FixerBots, id)
+		noticed := others.Forget(i.GuildID, id)
+		if n < 0 {
+			if noticed {
+				RespondEphemeral(ctx, s, i, "Removed.\n"+formatFixerBots(cfg, others.Detected(i.GuildID)))
+			} else {
+				RespondEphemeral(ctx, s, i, fmt.Sprintf("<@%s> isn't listed.", id))
+			}
+			return
+		}
+		cfg.FixerBots = slices.Delete(cfg.FixerBots, n, n+1)
+
+	case "list":
+		RespondEphemeral(ctx, s, i, formatFixerBots(cfg, others.Detected(i.GuildID)))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatFixerBots(cfg, others.Detected(i.GuildID)))
+}
+
+// formatFixerBots describes what the bot does about other link-fixing bots,
+// and which bots those are: listed by admins or noticed by the bot.
+func formatFixerBots(cfg config.Guild, noticed []string) string {
+	var lines []string
+	switch cfg.OthersMode() {
+	case config.OthersYield:
+		lines = append(lines, "Links are left to other link-fixing bots here.")
+	case config.OthersAlert:
+		lines = append(lines, "Links are fixed even with other link-fixing bots here, and moderators are told about them.")
+	case config.OthersIgnore:
+		lines = append(lines, "Links are fixed without looking for other link-fixing bots.")
+	}
+	var bots []string
+	for _, id := range cfg.FixerBots {
+		bots = append(bots, fmt.Sprintf("<@%s>", id))
+	}
+	for _, id := range noticed {
+		if !slices.Contains(cfg.FixerBots, id) {
+			bots = append(bots, fmt.Sprintf("<@%s> (noticed)", id))
+		}
+	}
+	if len(bots) == 0 {
+		lines = append(lines, "No other link-fixing bots are known.")
+	} else {
+		lines = append(lines, "Other link-fixing bots: "+strings.Join(bots, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
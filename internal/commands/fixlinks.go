@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/fixers"
+)
+
+// NewFixLinks builds the "Fix Links" message context-menu command. It runs a
+// message through the fixers on request and replies privately with the fixed
+// links, so it works even in channels where automatic fixing is turned off.
+// It can be used wherever the app is installed, including by users who
+// installed it for themselves in servers the bot isn't in.
+func NewFixLinks(pipeline fixers.Pipeline) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Type:             discordgo.MessageApplicationCommand,
+			Name:             "Fix Links",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+		},
+		Module:   ModuleLinks,
+		Cooldown: Cooldown{User: 3 * time.Second},
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			data := i.ApplicationCommandData()
+			var target *discordgo.Message
+			if data.Resolved != nil {
+				target = data.Resolved.Messages[data.TargetID]
+			}
+			if target == nil {
+				RespondEphemeral(ctx, s, i, "Couldn't read that message.")
+				return
+			}
+
+			links := fixedLinks(ctx, pipeline, target)
+			if len(links) == 0 {
+				RespondEphemeral(ctx, s, i, "There are no links to fix in that message.")
+				return
+			}
+			RespondEphemeral(ctx, s, i, formatLinks(links))
+		},
+	}
+}
+
+// NewFixLink builds the /fixlink command that posts the fixed version of a link.
+// Like Fix Links it works wherever the app is installed.
+func NewFixLink(pipeline fixers.Pipeline) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "fixlink",
+			Description:      "Post a link with a working preview",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "link", Description: "The link to fix", Required: true},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "private", Description: "Only show the fixed link to you"},
+			},
+		},
+		Module: ModuleLinks,
+		// Fixed links are posted publicly, so channels are limited too
+		Cooldown: Cooldown{User: 5 * time.Second, Channel: 2 * time.Second},
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			opts := OptionMap(i.ApplicationCommandData().Options)
+			message := &discordgo.Message{
+				Content:   strings.TrimSpace(opts["link"].StringValue()),
+				GuildID:   i.GuildID,
+				ChannelID: i.ChannelID,
+			}
+
+			links := fixedLinks(ctx, pipeline, message)
+			if len(links) == 0 {
+				RespondEphemeral(ctx, s, i, "That link doesn't need fixing.")
+				return
+			}
+			if private, ok := opts["private"]; ok && private.BoolValue() {
+				RespondEphemeral(ctx, s, i, formatLinks(links))
+				return
+			}
+			// The response is posted by the interaction rather than the bot user,
+			// so this works even in servers and DMs the bot isn't part of
+			respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+				Content:         formatLinks(links),
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			})
+		},
+	}
+}
+
+// fixedLinks runs message through the pipeline and returns the links it rewrote.
+func fixedLinks(ctx context.Context, pipeline fixers.Pipeline, message *discordgo.Message) []string {
+	// The user asked explicitly, so fix links even if their previews look fine
+	copied := *message
+	copied.Embeds = nil
+	copied.Attachments = nil
+
+	fixed := pipeline.Apply(ctx, &discordgo.MessageCreate{Message: &copied})
+	return fixers.ChangedLinks(message.Content, fixed)
+}
+
+// formatLinks lists links one per line, cut to fit in a single message.
+func formatLinks(links []string) string {
+	return chunk.Split(strings.Join(links, "\n"), chunk.MaxMessageLength)[0]
+}
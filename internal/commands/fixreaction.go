@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// NewFixReaction builds the /fixreaction command, which has the bot react to
+// messages once it has posted their fix, so authors know it acted.
+func NewFixReaction(st storage.Store) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "fixreaction",
+			Description:      "React to messages once their links are fixed",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "on",
+					Description: "React to fixed messages, with " + config.DefaultSuccessReaction + " unless you pick another emoji",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "emoji",
+							Description: "The emoji, such as 👍 or one of the server's own",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "off",
+					Description: "Stop reacting to fixed messages",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleFixReaction(ctx, s, i, st, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleFixReaction runs a /fixreaction subcommand.
+func handleFixReaction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "on":
+		cfg.SuccessReaction = config.DefaultSuccessReaction
+		if opt, ok := OptionMap(sub.Options)["emoji"]; ok {
+			emoji, ok := parseTriggerEmoji(opt.StringValue())
+			if !ok {
+				RespondEphemeral(ctx, s, i, "That isn't an emoji. Pick one from the emoji picker, such as 👍.")
+				return
+			}
+			cfg.SuccessReaction = emoji
+		}
+	case "off":
+		cfg.SuccessReaction = ""
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatFixReaction(cfg))
+}
+
+// formatFixReaction describes how the bot marks the messages it fixed.
+func formatFixReaction(cfg config.Guild) string {
+	if cfg.SuccessReaction == "" {
+		return "Fixed messages aren't reacted to."
+	}
+	return "Fixed messages are reacted to with " + formatEmoji(cfg.SuccessReaction) + "."
+}
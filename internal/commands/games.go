@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// NewGames builds the /games command, which has the bot reply to links to
+// Steam and itch.io games with their price, discount and genres.
+func NewGames(st storage.Store) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "games",
+			Description:      "Show the price, discount and genres of Steam and itch.io games linked here",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "on",
+					Description: "Reply to links to Steam and itch.io games with their price and genres",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "off",
+					Description: "Don't show linked games",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show whether linked games are shown",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleGames(ctx, s, i, st, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleGames runs a /games subcommand.
+func handleGames(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "on":
+		cfg.GameEmbeds = true
+	case "off":
+		cfg.GameEmbeds = false
+	case "status":
+		RespondEphemeral(ctx, s, i, formatGames(cfg.GameEmbeds))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatGames(cfg.GameEmbeds))
+}
+
+// formatGames describes the game embeds setting.
+func formatGames(enabled bool) string {
+	if enabled {
+		return "Game embeds are on: links to Steam and itch.io games get a reply with their price, discount and genres."
+	}
+	return "Game embeds are off."
+}
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/fleet"
+	"go-discord-bot/internal/storage"
+)
+
+// guildsPerPage is how many guilds one /guilds list page shows.
+const guildsPerPage = 10
+
+// NewGuilds builds the /guilds command, which shows the bot's owner every
+// server the bot is in with its key settings and missing permissions, and
+// changes a setting in many servers at once. src lists the servers; a nil src
+// lists none.
+func NewGuilds(st storage.Store, src fleet.Source, pager *Pager) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "guilds",
+			Description:      "Manage the settings of every server the bot is in",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List the servers with their key settings and missing permissions",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "problems", Description: "Only list servers where the bot is missing permissions"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Change a setting in many servers at once",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "setting", Description: "The setting as in /config export, such as repost_mode", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "value", Description: "Its new value, such as reply, or JSON, such as [\"twitch\"], or null for the default", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "guilds", Description: "Comma-separated server IDs, or all", Required: true},
+					},
+				},
+			},
+		},
+		Module:    ModuleBot,
+		OwnerOnly: true,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			sub := i.ApplicationCommandData().Options[0]
+			opts := OptionMap(sub.Options)
+			switch sub.Name {
+			case "list":
+				guilds := fleet.Survey(st, src)
+				if opt, ok := opts["problems"]; ok && opt.BoolValue() {
+					guilds = withProblems(guilds)
+				}
+				pager.Respond(ctx, s, i, guildPages(guilds), discordgo.MessageFlagsEphemeral)
+
+			case "set":
+				setting := opts["setting"].StringValue()
+				list, ok := guildList(opts["guilds"].StringValue())
+				if !ok {
+					RespondEphemeral(ctx, s, i, "No servers to change.")
+					return
+				}
+				patch, _ := json.Marshal(map[string]json.RawMessage{setting: settingValue(opts["value"].StringValue())})
+				op, err := fleet.NewSettingChange(st, patch)
+				if err != nil {
+					RespondEphemeral(ctx, s, i, "Nothing changed: "+err.Error())
+					return
+				}
+				// Checking every server takes a request or two each
+				respond(ctx, s, i, discordgo.InteractionResponseDeferredChannelMessageWithSource, &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral})
+				results, err := fleet.Run(ctx, s, op, list, false)
+				if err != nil {
+					RespondEphemeral(ctx, s, i, chunk.Split("Nothing changed: "+err.Error()+formatFailures(results), chunk.MaxMessageLength)[0])
+					return
+				}
+				RespondEphemeral(ctx, s, i, fmt.Sprintf("Set `%s` in %d servers.", setting, len(results)))
+			}
+		},
+	}
+}
+
+// withProblems returns the guilds where the bot is missing permissions.
+func withProblems(guilds []fleet.Guild) []fleet.Guild {
+	var failing []fleet.Guild
+	for _, g := range guilds {
+		if len(g.Problems) > 0 {
+			failing = append(failing, g)
+		}
+	}
+	return failing
+}
+
+// guildList returns the comma-separated guild IDs in list for fleet.Run, or
+// the empty list, which means every guild, for "all". It reports false if
+// list names no guilds.
+func guildList(list string) (string, bool) {
+	list = strings.TrimSpace(list)
+	if strings.EqualFold(list, "all") {
+		return "", true
+	}
+	return list, strings.Trim(list, ", ") != ""
+}
+
+// formatFailures lists the guilds in results that failed or were left alone,
+// one per line after a leading newline, or nothing if none did.
+func formatFailures(results []fleet.Result) string {
+	var lines []string
+	for _, r := range results {
+		if r.Err != nil {
+			lines = append(lines, fmt.Sprintf("\n%s (%s): %v", r.GuildName, r.GuildID, r.Err))
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// settingValue reads value as JSON, or as a string if it isn't JSON, so
+// plain words like reply don't need quotes.
+func settingValue(value string) json.RawMessage {
+	if json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	quoted, _ := json.Marshal(value)
+	return quoted
+}
+
+// guildPages lays guilds out as embeds, guildsPerPage at a time.
+func guildPages(guilds []fleet.Guild) []*discordgo.MessageEmbed {
+	if len(guilds) == 0 {
+		return []*discordgo.MessageEmbed{{Title: "Servers", Description: "No servers to list."}}
+	}
+	var pages []*discordgo.MessageEmbed
+	for start := 0; start < len(guilds); start += guildsPerPage {
+		page := &discordgo.MessageEmbed{Title: fmt.Sprintf("Servers (%d)", len(guilds))}
+		for _, g := range guilds[start:min(start+guildsPerPage, len(guilds))] {
+			page.Fields = append(page.Fields, &discordgo.MessageEmbedField{Name: fmt.Sprintf("%s (%s)", g.Name, g.ID), Value: formatGuildSummary(g)})
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// formatGuildSummary describes a guild's key settings and its problems.
+func formatGuildSummary(g fleet.Guild) string {
+	settings := g.Settings
+	parts := []string{fmt.Sprintf("%d members", g.Members), settings.RepostMode, settings.TwitterSite, "phishing " + settings.PhishingAction}
+	if settings.Paused {
+		parts = append([]string{"**paused**"}, parts...)
+	}
+	if settings.Privacy {
+		parts = append(parts, "privacy mode")
+	}
+	if settings.Channels > 0 {
+		parts = append(parts, fmt.Sprintf("fixing in %d channels", settings.Channels))
+	}
+	if len(settings.DisabledFixers) > 0 {
+		parts = append(parts, "without "+strings.Join(settings.DisabledFixers, ", "))
+	}
+	if !settings.AuditChannel {
+		parts = append(parts, "no audit channel")
+	}
+	lines := []string{strings.Join(parts, " · ")}
+	for _, problem := range g.Problems {
+		lines = append(lines, "⚠️ "+problem)
+	}
+	return strings.Join(lines, "\n")
+}
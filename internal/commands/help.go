@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// NewHelp builds the /help command, which explains how the bot treats the
+// messages it sees and lists the commands in registry, so the list never
+// drifts from what's registered. Guild settings, such as the skip marker, are
+// read from st.
+func NewHelp(st storage.Store, registry *Registry) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "help",
+			Description:      "Learn how the bot works",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "links",
+					Description: "Which links the bot fixes, and how to have it leave one alone",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "commands",
+					Description: "Every command you can use here, and who can use them",
+				},
+			},
+		},
+		Module: ModuleBot,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if opts := i.ApplicationCommandData().Options; len(opts) > 0 && opts[0].Name == "commands" {
+				registry.Pager.Respond(ctx, s, i, commandsHelp(registry.Commands(i.GuildID), registry.owns(i)), discordgo.MessageFlagsEphemeral)
+				return
+			}
+			// DMs and guilds whose settings can't be read get the defaults
+			cfg, _ := config.LoadGuild(st, i.GuildID)
+			RespondEmbed(ctx, s, i, linksHelp(cfg))
+		},
+	}
+}
+
+// linksHelp explains the rules for which links are fixed in a guild with the
+// settings cfg.
+func linksHelp(cfg config.Guild) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: "Fixing links",
+		Description: "The bot reposts messages with links whose previews are broken, such as Twitter/X links, fixed. " +
+			"It leaves a link alone when it's:\n" +
+			"• in angle brackets, like `<https://x.com/user/status/1>`, which also keeps Discord from embedding it\n" +
+			"• in inline code or a code block, like ``` `https://x.com/user/status/1` ```\n" +
+			fmt.Sprintf("• in a message starting with `%s`, which leaves the whole message alone\n", cfg.Marker()) +
+			fmt.Sprintf("• in a forum post tagged `%s`\n", strings.TrimPrefix(cfg.Marker(), "!")) +
+			"• in the text chat of a voice or stage channel",
+	}
+}
+
+// permissionNames names the permissions commands need, as Discord shows them.
+var permissionNames = []struct {
+	perm int64
+	name string
+}{
+	{discordgo.PermissionManageServer, "Manage Server"},
+	{discordgo.PermissionManageMessages, "Manage Messages"},
+	{discordgo.PermissionManageGuildExpressions, "Manage Expressions"},
+}
+
+// commandsHelp lists cmds by module, one page each. Owner-only commands are
+// left out unless owner is set.
+func commandsHelp(cmds []Command, owner bool) []*discordgo.MessageEmbed {
+	byModule := make(map[string][]string)
+	var others []string
+	for _, cmd := range cmds {
+		if cmd.OwnerOnly && !owner {
+			continue
+		}
+		module := cmd.Module
+		if !slices.Contains(modules, module) {
+			module = "Other"
+			if !slices.Contains(others, module) {
+				others = append(others, module)
+			}
+		}
+		byModule[module] = append(byModule[module], commandHelp(cmd))
+	}
+
+	var pages []*discordgo.MessageEmbed
+	for _, module := range append(slices.Clone(modules), others...) {
+		if lines := byModule[module]; len(lines) > 0 {
+			pages = append(pages, &discordgo.MessageEmbed{Title: module, Description: strings.Join(lines, "\n")})
+		}
+	}
+	return pages
+}
+
+// commandHelp describes one command in a line: how to use it, what it does,
+// and what limits who can use it and how often.
+func commandHelp(cmd Command) string {
+	def := cmd.Definition
+	line := fmt.Sprintf("`/%s` · %s", def.Name, def.Description)
+	if def.Type == discordgo.MessageApplicationCommand {
+		line = fmt.Sprintf("**%s** · on a message, under Apps", def.Name)
+	}
+
+	var limits []string
+	if cmd.OwnerOnly {
+		limits = append(limits, "bot owner only")
+	}
+	for _, p := range permissionNames {
+		if cmd.Permissions&p.perm != 0 {
+			limits = append(limits, "needs "+p.name)
+		}
+	}
+	if cmd.Cooldown.User > 0 {
+		limits = append(limits, "once every "+formatCooldown(cmd.Cooldown.User)+" per user")
+	}
+	if cmd.Cooldown.Channel > 0 {
+		limits = append(limits, "once every "+formatCooldown(cmd.Cooldown.Channel)+" per channel")
+	}
+	if len(limits) > 0 {
+		line += "\n-# " + strings.Join(limits, ", ")
+	}
+	return line
+}
+
+// formatCooldown writes a cooldown in whole minutes or seconds.
+func formatCooldown(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%ds", int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// ignoreConfigGroup defines the /config ignore subcommands.
+func ignoreConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "ignore",
+		Description: "Users and roles the bot ignores, such as other bots or relays",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "user",
+				Description: "Ignore a user, or stop ignoring them",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "The user to ignore", Required: true},
+					{Type: discordgo.ApplicationCommandOptionBoolean, Name: "remove", Description: "Stop ignoring the user instead"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "role",
+				Description: "Ignore everyone with a role, or stop ignoring them",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "The role to ignore", Required: true},
+					{Type: discordgo.ApplicationCommandOptionBoolean, Name: "remove", Description: "Stop ignoring the role instead"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the ignored users and roles",
+			},
+		},
+	}
+}
+
+// handleIgnoreConfig runs a /config ignore subcommand.
+func handleIgnoreConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	remove := false
+	if opt, ok := opts["remove"]; ok {
+		remove = opt.BoolValue()
+	}
+
+	switch sub.Name {
+	case "user":
+		cfg.IgnoredUsers = updateIDList(cfg.IgnoredUsers, opts["user"].Value.(string), remove)
+	case "role":
+		cfg.IgnoredRoles = updateIDList(cfg.IgnoredRoles, opts["role"].Value.(string), remove)
+	case "list":
+		RespondEphemeral(ctx, s, i, formatIgnored(cfg))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatIgnored(cfg))
+}
+
+// updateIDList adds id to ids, or removes it when remove is set.
+func updateIDList(ids []string, id string, remove bool) []string {
+	if remove {
+		return slices.DeleteFunc(ids, func(existing string) bool { return existing == id })
+	}
+	if slices.Contains(ids, id) {
+		return ids
+	}
+	return append(ids, id)
+}
+
+// formatIgnored lists the ignored users and roles as mentions.
+func formatIgnored(cfg config.Guild) string {
+	if len(cfg.IgnoredUsers) == 0 && len(cfg.IgnoredRoles) == 0 {
+		return "Nobody is ignored."
+	}
+	var mentions []string
+	for _, id := range cfg.IgnoredUsers {
+		mentions = append(mentions, "<@"+id+">")
+	}
+	for _, id := range cfg.IgnoredRoles {
+		mentions = append(mentions, "<@&"+id+">")
+	}
+	return fmt.Sprintf("Ignoring %s.", strings.Join(mentions, ", "))
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/invite"
+)
+
+// NewInvite builds the /invite command that links to adding the bot to another
+// server. features returns the features turned on, so the link asks for just
+// the permissions they need.
+func NewInvite(features func() []invite.Feature) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "invite",
+			Description: "Get a link to add the bot to a server",
+		},
+		Module: ModuleBot,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			RespondEphemeral(ctx, s, i, inviteMessage(i.AppID, features()))
+		},
+	}
+}
+
+// inviteMessage renders the /invite reply for the application appID.
+func inviteMessage(appID string, features []invite.Feature) string {
+	names := make([]string, len(features))
+	for n, f := range features {
+		names[n] = f.Name
+	}
+	return fmt.Sprintf("[Add me to a server](%s)\nThe link asks for the permissions needed for %s.",
+		invite.URL(appID, invite.Permissions(features)), strings.Join(names, ", "))
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/locale"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/stats"
+	"go-discord-bot/internal/storage"
+)
+
+const (
+	// leaderboardPageSize is how many users one leaderboard page lists.
+	leaderboardPageSize = 10
+	// leaderboardPlatforms is how many platforms every page lists.
+	leaderboardPlatforms = 5
+)
+
+// NewLeaderboard builds the /leaderboard command, which ranks who posted the
+// most fixed links in the guild and the platforms they came from, a page of
+// users at a time. st holds the locale counts are written in.
+func NewLeaderboard(st storage.Store, collector *stats.Collector, pager *Pager) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "leaderboard",
+			Description:      "See who posted the most fixed links in this server",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+		},
+		Module: ModuleStats,
+		Public: true,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			if logging.Private(i.GuildID) {
+				RespondEphemeral(ctx, s, i, "This server is in privacy mode, so the bot doesn't keep a leaderboard.")
+				return
+			}
+			board := collector.Leaderboard(i.GuildID)
+			if len(board.Users) == 0 {
+				RespondEphemeral(ctx, s, i, "No links have been fixed here since the bot last started.")
+				return
+			}
+			pager.Respond(ctx, s, i, leaderboardPages(interactionLocale(st, i), board), 0)
+		},
+	}
+}
+
+// leaderboardPages lays a leaderboard out as embeds, each listing a page of
+// users above the top platforms, with counts written the way locale l does.
+func leaderboardPages(l string, board stats.Leaderboard) []*discordgo.MessageEmbed {
+	users := make([]string, len(board.Users))
+	for n, u := range board.Users {
+		users[n] = fmt.Sprintf("%s. <@%s> · %s", locale.Count(l, n+1), u.Name, pluralIn(l, u.Links, "link"))
+	}
+	var platforms strings.Builder
+	for _, p := range board.Platforms[:min(leaderboardPlatforms, len(board.Platforms))] {
+		fmt.Fprintf(&platforms, "%s · %s\n", p.Name, pluralIn(l, p.Links, "link"))
+	}
+
+	var pages []*discordgo.MessageEmbed
+	for _, page := range paginate(users, leaderboardPageSize) {
+		embed := &discordgo.MessageEmbed{
+			Title:       "Most fixed links",
+			Description: strings.Join(page, "\n"),
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Since the bot last started"},
+		}
+		if platforms.Len() > 0 {
+			embed.Fields = []*discordgo.MessageEmbedField{{Name: "Platforms", Value: platforms.String()}}
+		}
+		pages = append(pages, embed)
+	}
+	return pages
+}
+
+// plural formats n of a noun, like "1 link" or "3 links".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/locale"
+	"go-discord-bot/internal/storage"
+)
+
+// guildLocale returns the language of the server an interaction came from,
+// as Discord has it, or "" outside servers.
+func guildLocale(i *discordgo.InteractionCreate) string {
+	if i.GuildLocale == nil {
+		return ""
+	}
+	return string(*i.GuildLocale)
+}
+
+// interactionLocale returns the locale counts and dates are written in for
+// the server an interaction came from: the one it picked, or its language.
+func interactionLocale(st storage.Store, i *discordgo.InteractionCreate) string {
+	if st == nil || i.GuildID == "" {
+		return guildLocale(i)
+	}
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+	}
+	return cfg.LocaleOr(guildLocale(i))
+}
+
+// pluralIn formats n of a noun like plural, with n written the way l does.
+func pluralIn(l string, n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return locale.Count(l, n) + " " + noun + "s"
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/logging"
+)
+
+// NewLogLevel builds the /loglevel command, which lets the bot's owner turn up
+// one module's logging while the bot is running, to debug it in production.
+func NewLogLevel() Command {
+	modules := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(logging.Modules))
+	for _, m := range logging.Modules {
+		modules = append(modules, &discordgo.ApplicationCommandOptionChoice{Name: string(m), Value: string(m)})
+	}
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "loglevel",
+			Description:      "Show or change how much each part of the bot logs",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "module",
+					Description: "Part of the bot to change, leave out to show every level",
+					Choices:     modules,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "level",
+					Description: "How much it logs",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Errors and notable events", Value: string(logging.Info)},
+						{Name: "Every step", Value: string(logging.Debug)},
+					},
+				},
+			},
+		},
+		Module:    ModuleBot,
+		OwnerOnly: true,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			opts := OptionMap(i.ApplicationCommandData().Options)
+			module, ok := opts["module"]
+			if !ok {
+				RespondEphemeral(ctx, s, i, formatLogLevels(logging.Levels()))
+				return
+			}
+			level := logging.Debug
+			if opt, ok := opts["level"]; ok {
+				level = logging.Level(opt.StringValue())
+			}
+			if err := logging.SetLevel(logging.Module(module.StringValue()), level); err != nil {
+				RespondEphemeral(ctx, s, i, fmt.Sprintf("Couldn't change the level: %v.", err))
+				return
+			}
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("`%s` now logs at the `%s` level, until the bot restarts.", module.StringValue(), level))
+		},
+	}
+}
+
+// formatLogLevels lists the level of each module.
+func formatLogLevels(levels map[logging.Module]logging.Level) string {
+	var b strings.Builder
+	b.WriteString("Log levels:")
+	for _, m := range logging.Modules {
+		fmt.Fprintf(&b, "\n`%s`: %s", m, levels[m])
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/maintenance"
+	"go-discord-bot/internal/timestamp"
+)
+
+// NewMaintenance builds the /maintenance command, which lets the bot's owner
+// stop automatic fixing in every server while the bot is being worked on.
+// changed is called after the switch flips, with the messages held meanwhile
+// when maintenance ends. A nil mode means maintenance isn't available.
+func NewMaintenance(mode *maintenance.Mode, changed func(on bool, held []maintenance.Held)) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "maintenance",
+			Description:      "Stop fixing links in every server while the bot is being worked on",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "on",
+					Description: "Hold new links until maintenance ends",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "off",
+					Description: "End maintenance and fix the links held meanwhile",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show whether the bot is in maintenance",
+				},
+			},
+		},
+		Module:    ModuleBot,
+		OwnerOnly: true,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if mode == nil {
+				RespondEphemeral(ctx, s, i, "Maintenance mode isn't available.")
+				return
+			}
+			options := i.ApplicationCommandData().Options
+			if len(options) == 0 {
+				return
+			}
+			switch options[0].Name {
+			case "on":
+				if err := mode.Start(); err != nil {
+					log.Println("Error starting maintenance:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't start maintenance, try again later.")
+					return
+				}
+				if changed != nil {
+					changed(true, nil)
+				}
+				RespondEphemeral(ctx, s, i, "Maintenance started. Links posted from now on are held and fixed once you use `/maintenance off`.")
+			case "off":
+				held, err := mode.End()
+				if err != nil {
+					log.Println("Error ending maintenance:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't end maintenance, try again later.")
+					return
+				}
+				if changed != nil {
+					changed(false, held)
+				}
+				RespondEphemeral(ctx, s, i, fmt.Sprintf("Maintenance ended. Fixing %d held messages.", len(held)))
+			case "status":
+				RespondEphemeral(ctx, s, i, formatMaintenance(mode))
+			}
+		},
+	}
+}
+
+// formatMaintenance describes the state of the maintenance switch.
+func formatMaintenance(mode *maintenance.Mode) string {
+	on, since, held := mode.Status()
+	if !on {
+		return "The bot isn't in maintenance."
+	}
+	return fmt.Sprintf("The bot has been in maintenance since %s, holding %d messages.", timestamp.Format(since, timestamp.Relative), held)
+}
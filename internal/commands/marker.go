@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// markerConfigGroup defines the /config marker subcommands.
+func markerConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "marker",
+		Description: "Pick the word that, starting a message, has the bot leave it alone",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Leave messages starting with this word alone",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "word",
+						Description: "The word, such as " + config.DefaultSkipMarker,
+						Required:    true,
+						MaxLength:   config.MaxSkipMarkerLength,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Go back to " + config.DefaultSkipMarker,
+			},
+		},
+	}
+}
+
+// handleMarkerConfig runs a /config marker subcommand.
+func handleMarkerConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "set":
+		marker := strings.TrimSpace(OptionMap(sub.Options)["word"].StringValue())
+		if !config.ValidSkipMarker(marker) {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("The marker has to be a single word of at most %d characters.", config.MaxSkipMarkerLength))
+			return
+		}
+		cfg.SkipMarker = marker
+		if marker == config.DefaultSkipMarker {
+			cfg.SkipMarker = ""
+		}
+	case "reset":
+		cfg.SkipMarker = ""
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, fmt.Sprintf("Saved. Messages starting with `%s` are left alone.", cfg.Marker()))
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/patterns"
+)
+
+// NewMedia builds the /media command, which replies with direct links to the
+// original photos and videos of a tweet so they can be saved without the embed
+// around them. Like /fixlink it works wherever the app is installed.
+func NewMedia(client *fxtwitter.Client) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "media",
+			Description:      "Get the original photos and videos of a tweet",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "link", Description: "A link to the tweet", Required: true},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "private", Description: "Only show the media to you"},
+			},
+		},
+		Module:   ModuleLinks,
+		Cooldown: Cooldown{User: 5 * time.Second, Channel: 2 * time.Second},
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			opts := OptionMap(i.ApplicationCommandData().Options)
+			id, ok := tweetID(opts["link"].StringValue())
+			if !ok {
+				RespondEphemeral(ctx, s, i, "That isn't a link to a tweet.")
+				return
+			}
+
+			var flags discordgo.MessageFlags
+			if private, ok := opts["private"]; ok && private.BoolValue() {
+				flags = discordgo.MessageFlagsEphemeral
+			}
+			// Defer straight away, so the lookup can't be deferred with the wrong visibility
+			respond(ctx, s, i, discordgo.InteractionResponseDeferredChannelMessageWithSource, &discordgo.InteractionResponseData{Flags: flags})
+			respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+				Content:         mediaContent(ctx, client, id),
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			})
+		},
+	}
+}
+
+// tweetID returns the status ID of a Twitter/X link or of a fixed mirror of one.
+func tweetID(link string) (string, bool) {
+	link = strings.TrimSpace(link)
+	if !patterns.TwitterStatusLink.MatchString(link) && !patterns.TwitterProxyStatus.MatchString(link) {
+		return "", false
+	}
+	match := patterns.TweetID.FindStringSubmatch(link)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// mediaContent fetches a tweet and lists its media links, or explains why it can't.
+func mediaContent(ctx context.Context, client *fxtwitter.Client, id string) string {
+	tweet, err := client.Status(ctx, id)
+	if errors.Is(err, fxtwitter.ErrNotFound) {
+		return "Couldn't find that tweet. It may be deleted or from a private account."
+	}
+	if err != nil {
+		log.Println("Error fetching tweet:", err)
+		return "Couldn't look up that tweet, try again later."
+	}
+
+	urls := tweet.MediaURLs()
+	if len(urls) == 0 {
+		return "That tweet has no photos or videos."
+	}
+	return formatLinks(urls)
+}
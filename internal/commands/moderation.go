@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// Limits on /purge: how many messages it deletes at once, how many it looks
+// through for a user's, and how old they can be, Discord's limit on bulk
+// deletes. purgeRequest is how many messages it fetches at a time.
+const (
+	maxPurge     = 100
+	purgeScan    = 500
+	purgeMaxAge  = 14 * 24 * time.Hour
+	purgeRequest = 100
+)
+
+// maxSlowmode is Discord's longest slowmode, 6 hours, in seconds.
+const maxSlowmode = 6 * 60 * 60
+
+// NewPurge builds the /purge command, which deletes recent messages in a
+// channel, optionally only a user's. st holds the audit channel told about it.
+func NewPurge(st storage.Store) Command {
+	minCount := 1.0
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "purge",
+			Description:      "Delete recent messages in this channel",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: fmt.Sprintf("How many messages to delete, up to %d", maxPurge),
+					Required:    true,
+					MinValue:    &minCount,
+					MaxValue:    maxPurge,
+				},
+				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Only delete this user's messages"},
+			},
+		},
+		Module:      ModuleModeration,
+		Permissions: discordgo.PermissionManageMessages,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			opts := OptionMap(i.ApplicationCommandData().Options)
+			count := int(opts["count"].IntValue())
+			userID := ""
+			if opt, ok := opts["user"]; ok {
+				userID = opt.Value.(string)
+			}
+
+			ids, err := findPurgeable(ctx, s, i.ChannelID, userID, count, time.Now())
+			if err != nil {
+				log.Println("Error listing messages to purge:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't read this channel's messages. The bot needs the Read Message History permission here.")
+				return
+			}
+			if len(ids) == 0 {
+				RespondEphemeral(ctx, s, i, "There are no messages to delete. Messages older than two weeks and pinned ones are left alone.")
+				return
+			}
+			reason := discordgo.WithAuditLogReason("/purge by " + interactionUser(i))
+			if err := s.ChannelMessagesBulkDelete(i.ChannelID, ids, discordgo.WithContext(ctx), reason); err != nil {
+				log.Println("Error purging messages:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't delete the messages. The bot needs the Manage Messages permission here.")
+				return
+			}
+
+			from := ""
+			if userID != "" {
+				from = fmt.Sprintf(" from <@%s>", userID)
+			}
+			auditCommand(ctx, s, st, i.GuildID, fmt.Sprintf("<@%s> deleted %d messages%s in <#%s> with /purge.", interactionUser(i), len(ids), from, i.ChannelID))
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("Deleted %d messages%s.", len(ids), from))
+		},
+	}
+}
+
+// findPurgeable returns the IDs of up to count of the newest messages in
+// channelID, only userID's unless it's empty, that /purge may delete. It
+// looks through at most purgeScan messages.
+func findPurgeable(ctx context.Context, s *discordgo.Session, channelID, userID string, count int, now time.Time) ([]string, error) {
+	var ids []string
+	before := ""
+	for scanned := 0; len(ids) < count && scanned < purgeScan; {
+		batch, err := s.ChannelMessages(channelID, purgeRequest, before, "", "", discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, purgeable(batch, userID, now)...)
+		scanned += len(batch)
+		// Past two weeks old, none of the older messages can be deleted either
+		if len(batch) < purgeRequest || now.Sub(batch[len(batch)-1].Timestamp) >= purgeMaxAge {
+			break
+		}
+		before = batch[len(batch)-1].ID
+	}
+	if len(ids) > count {
+		ids = ids[:count]
+	}
+	return ids, nil
+}
+
+// purgeable returns the IDs of the messages /purge may delete: those by
+// userID, or anyone if it's empty, that aren't pinned and are young enough
+// for Discord to bulk delete.
+func purgeable(messages []*discordgo.Message, userID string, now time.Time) []string {
+	var ids []string
+	for _, m := range messages {
+		if m.Pinned || now.Sub(m.Timestamp) >= purgeMaxAge {
+			continue
+		}
+		if userID != "" && (m.Author == nil || m.Author.ID != userID) {
+			continue
+		}
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// NewSlowmode builds the /slowmode command, which sets how long members wait
+// between messages in a channel. st holds the audit channel told about it.
+func NewSlowmode(st storage.Store) Command {
+	noSlowmode := 0.0
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "slowmode",
+			Description:      "Make members wait between messages in a channel",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "seconds",
+					Description: "How long members wait between messages, 0 to turn slowmode off",
+					Required:    true,
+					MinValue:    &noSlowmode,
+					MaxValue:    maxSlowmode,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The channel, this one unless you pick another",
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildForum},
+				},
+			},
+		},
+		Module:      ModuleModeration,
+		Permissions: discordgo.PermissionManageChannels,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			opts := OptionMap(i.ApplicationCommandData().Options)
+			seconds := int(opts["seconds"].IntValue())
+			channelID := i.ChannelID
+			if opt, ok := opts["channel"]; ok {
+				channelID = opt.ChannelValue(nil).ID
+			}
+
+			reason := discordgo.WithAuditLogReason("/slowmode by " + interactionUser(i))
+			if _, err := s.ChannelEdit(channelID, &discordgo.ChannelEdit{RateLimitPerUser: &seconds}, discordgo.WithContext(ctx), reason); err != nil {
+				log.Println("Error setting slowmode:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't change the slowmode. The bot needs the Manage Channels permission there.")
+				return
+			}
+
+			notice := fmt.Sprintf("Slowmode in <#%s> is off.", channelID)
+			if seconds > 0 {
+				notice = fmt.Sprintf("Members in <#%s> now wait %s between messages.", channelID, formatSeconds(seconds))
+			}
+			auditCommand(ctx, s, st, i.GuildID, fmt.Sprintf("<@%s> used /slowmode: %s", interactionUser(i), notice))
+			RespondEphemeral(ctx, s, i, notice)
+		},
+	}
+}
+
+// formatSeconds describes a wait of seconds, in the largest whole unit.
+func formatSeconds(seconds int) string {
+	unit, size := "second", 1
+	switch {
+	case seconds%3600 == 0:
+		unit, size = "hour", 3600
+	case seconds%60 == 0:
+		unit, size = "minute", 60
+	}
+	n := seconds / size
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// auditCommand tells moderators in the guild's audit channel, if it has one,
+// about a moderation command someone used.
+func auditCommand(ctx context.Context, s *discordgo.Session, st storage.Store, guildID, notice string) {
+	cfg, err := config.LoadGuild(st, guildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		return
+	}
+	auditTo(ctx, s, cfg.AuditChannel, notice)
+}
+
+// auditTo posts notice to an audit channel, doing nothing if channelID is
+// empty.
+func auditTo(ctx context.Context, s *discordgo.Session, channelID, notice string) {
+	if channelID == "" {
+		return
+	}
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:         notice,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error posting to the audit channel:", err)
+	}
+}
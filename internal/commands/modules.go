@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/lifecycle"
+	"go-discord-bot/internal/timestamp"
+)
+
+// NewModules builds the /modules command, which lets the bot's owner disable
+// a misbehaving part of the bot, such as feeds, in every server without
+// restarting it, and enable it again. A nil modules means modules can't be
+// switched.
+func NewModules(modules *lifecycle.Manager) Command {
+	moduleOption := []*discordgo.ApplicationCommandOption{{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "module",
+		Description: "The part of the bot",
+		Required:    true,
+		Choices: []*discordgo.ApplicationCommandOptionChoice{
+			{Name: "Fixing links", Value: lifecycle.Fixers},
+			{Name: "Welcoming new members", Value: lifecycle.Greeter},
+			{Name: "Starboards", Value: lifecycle.Starboard},
+			{Name: "Feeds", Value: lifecycle.Feeds},
+		},
+	}}
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "modules",
+			Description:      "Disable or enable parts of the bot in every server while it runs",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "disable",
+					Description: "Stop a part of the bot until it's enabled again, even across restarts",
+					Options:     moduleOption,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "enable",
+					Description: "Start a disabled part of the bot again",
+					Options:     moduleOption,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show which parts of the bot are enabled",
+				},
+			},
+		},
+		Module:    ModuleBot,
+		OwnerOnly: true,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if modules == nil {
+				RespondEphemeral(ctx, s, i, "Modules can't be switched here.")
+				return
+			}
+			options := i.ApplicationCommandData().Options
+			if len(options) == 0 {
+				return
+			}
+			sub := options[0]
+			if sub.Name == "status" {
+				RespondEphemeral(ctx, s, i, formatModules(modules.Statuses()))
+				return
+			}
+			name := OptionMap(sub.Options)["module"].StringValue()
+			enable := sub.Name == "enable"
+			if modules.Enabled(name) == enable {
+				RespondEphemeral(ctx, s, i, fmt.Sprintf("`%s` is %s already.", name, sub.Name+"d"))
+				return
+			}
+			set := modules.Disable
+			if enable {
+				set = modules.Enable
+			}
+			if err := set(name); err != nil {
+				log.Println("Error switching module:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't switch it, try again later.")
+				return
+			}
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("`%s` is %s in every server.", name, sub.Name+"d"))
+		},
+	}
+}
+
+// formatModules lists the modules and whether each is enabled.
+func formatModules(statuses []lifecycle.Status) string {
+	var b strings.Builder
+	b.WriteString("Modules:")
+	for _, st := range statuses {
+		state := "✅ enabled"
+		if !st.Enabled {
+			state = "⛔ disabled"
+		}
+		fmt.Fprintf(&b, "\n`%s` %s: %s", st.Name, state, st.Description)
+		if !st.Since.IsZero() {
+			b.WriteString(", since " + timestamp.Format(st.Since, timestamp.Relative))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// musicSiteLabels name the music sites.
+var musicSiteLabels = map[string]string{
+	config.MusicSongLink:     "song.link",
+	config.MusicSpotify:      "Spotify",
+	config.MusicAppleMusic:   "Apple Music",
+	config.MusicYouTubeMusic: "YouTube Music",
+	config.MusicTidal:        "Tidal",
+	config.MusicDeezer:       "Deezer",
+}
+
+// NewMusic builds the /music command, which has the bot point links to songs
+// and albums on streaming services at the service the guild picks.
+func NewMusic(st storage.Store) Command {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, site := range config.MusicSites {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: musicSiteLabels[site], Value: site})
+	}
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "music",
+			Description:      "Repost links to songs on Spotify, Apple Music and others as links to one service",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "site",
+					Description: "Pick where music links point",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "site",
+							Description: "song.link lists every service; the others fall back to it",
+							Required:    true,
+							Choices:     choices,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "off",
+					Description: "Leave music links alone",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show where music links point",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleMusic(ctx, s, i, st, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleMusic runs a /music subcommand.
+func handleMusic(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "site":
+		cfg.MusicSite = sub.Options[0].StringValue()
+	case "off":
+		cfg.MusicSite = ""
+	case "status":
+		RespondEphemeral(ctx, s, i, formatMusic(cfg.MusicSite))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatMusic(cfg.MusicSite))
+}
+
+// formatMusic describes the music site setting.
+func formatMusic(site string) string {
+	switch site {
+	case "":
+		return "Music links are left alone."
+	case config.MusicSongLink:
+		return "Music links point to song.link, which links to every service."
+	}
+	return "Music links point to " + musicSiteLabels[site] + ", or to song.link for songs it doesn't have."
+}
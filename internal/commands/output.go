@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// outputStyleLabels describe the output styles.
+var outputStyleLabels = map[string]string{
+	config.OutputPlain:  "Plain link",
+	config.OutputMasked: "Masked link",
+	config.OutputEmbed:  "Embed built by the bot (tweets only)",
+}
+
+// outputConfigGroup defines the /config output subcommands, with a choice for
+// each of fixerNames.
+func outputConfigGroup(fixerNames []string) *discordgo.ApplicationCommandOption {
+	platforms := make([]*discordgo.ApplicationCommandOptionChoice, len(fixerNames))
+	for n, name := range fixerNames {
+		platforms[n] = &discordgo.ApplicationCommandOptionChoice{Name: fixerLabel(name), Value: name}
+	}
+	styles := make([]*discordgo.ApplicationCommandOptionChoice, len(config.OutputStyles))
+	for n, style := range config.OutputStyles {
+		styles[n] = &discordgo.ApplicationCommandOptionChoice{Name: outputStyleLabels[style], Value: style}
+	}
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "output",
+		Description: "Pick how each platform's fixed links are shown",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Show a platform's fixed links as plain links, masked links or the bot's own embed",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "platform", Description: "The links", Required: true, Choices: platforms},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "style", Description: "How to show them", Required: true, Choices: styles},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "summaries",
+				Description: "Add a text summary of each fixed tweet, for members with embeds off or using screen readers",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Whether reposts get the summaries", Required: true},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List how each platform's fixed links are shown",
+			},
+		},
+	}
+}
+
+// handleOutputConfig runs a /config output subcommand.
+func handleOutputConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption, fixerNames []string) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+	if sub.Name == "list" {
+		RespondEphemeral(ctx, s, i, formatOutputs(cfg, fixerNames))
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	if sub.Name == "summaries" {
+		cfg.TextSummaries = opts["enabled"].BoolValue()
+		if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+			log.Println("Error saving guild config:", err)
+			RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+			return
+		}
+		RespondEphemeral(ctx, s, i, "Saved.\n"+formatOutputs(cfg, fixerNames))
+		return
+	}
+	platform, style := opts["platform"].StringValue(), opts["style"].StringValue()
+	if !slices.Contains(fixerNames, platform) || !config.ValidOutput(platform, style) {
+		RespondEphemeral(ctx, s, i, fmt.Sprintf("%s links can't be shown that way. Only tweets have an embed built by the bot.", fixerLabel(platform)))
+		return
+	}
+	if style == config.OutputPlain {
+		delete(cfg.Outputs, platform)
+	} else {
+		if cfg.Outputs == nil {
+			cfg.Outputs = make(map[string]string)
+		}
+		cfg.Outputs[platform] = style
+	}
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatOutputs(cfg, fixerNames))
+}
+
+// formatOutputs lists how the links each of fixerNames fixes are shown.
+func formatOutputs(cfg config.Guild, fixerNames []string) string {
+	lines := make([]string, len(fixerNames))
+	for n, name := range fixerNames {
+		lines[n] = fmt.Sprintf("%s: %s", fixerLabel(name), strings.ToLower(outputStyleLabels[cfg.Output(name)]))
+	}
+	summaries := "off"
+	if cfg.TextSummaries {
+		summaries = "on"
+	}
+	return "Output styles:\n" + strings.Join(lines, "\n") + "\nText summaries of tweets: " + summaries
+}
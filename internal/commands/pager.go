@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PagePrefix is the custom ID prefix of the Previous and Next buttons under
+// paginated replies.
+const PagePrefix = "page"
+
+// DefaultPageTTL is how long a paginated reply's buttons keep working.
+const DefaultPageTTL = 15 * time.Minute
+
+// Pager sends replies too long for one embed as pages, with Previous and Next
+// buttons to flip through them. Pages are kept in memory until they expire,
+// after which the buttons ask to run the command again. A nil Pager only
+// sends the first page.
+type Pager struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu    sync.Mutex
+	pages map[string]pagedReply
+}
+
+// pagedReply is the pages of one reply.
+type pagedReply struct {
+	pages   []*discordgo.MessageEmbed
+	expires time.Time
+}
+
+// NewPager returns a Pager whose buttons work for ttl after a reply is sent.
+// Its Component handler must be registered under PagePrefix, as NewRegistry
+// does for the registry's own Pager.
+func NewPager(ttl time.Duration) *Pager {
+	return &Pager{ttl: ttl, now: time.Now, pages: make(map[string]pagedReply)}
+}
+
+// Respond replies to an interaction with the first of pages. Each page's
+// footer gets its page number. flags are set on the reply, such as
+// discordgo.MessageFlagsEphemeral.
+func (p *Pager) Respond(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, pages []*discordgo.MessageEmbed, flags discordgo.MessageFlags) {
+	data := p.store(i.ID, pages)
+	data.Flags = flags
+	respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, data)
+}
+
+// store remembers pages under key and returns the first page's message.
+func (p *Pager) store(key string, pages []*discordgo.MessageEmbed) *discordgo.InteractionResponseData {
+	numberPages(pages)
+	if p == nil || len(pages) <= 1 {
+		return pageMessage(key, pages, 0, false)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pages[key] = pagedReply{pages: pages, expires: p.now().Add(p.ttl)}
+	return pageMessage(key, pages, 0, true)
+}
+
+// Component handles clicks on Previous and Next buttons.
+func (p *Pager) Component(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data, ok := p.turn(i.MessageComponentData().CustomID)
+	if !ok {
+		RespondEphemeral(ctx, s, i, "These pages have expired, run the command again.")
+		return
+	}
+	respond(ctx, s, i, discordgo.InteractionResponseUpdateMessage, data)
+}
+
+// turn returns the message for the page a button's custom ID points to.
+func (p *Pager) turn(customID string) (*discordgo.InteractionResponseData, bool) {
+	if p == nil {
+		return nil, false
+	}
+	parts := strings.Split(customID, ":")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	page, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	reply, ok := p.pages[parts[1]]
+	if !ok || !p.now().Before(reply.expires) {
+		return nil, false
+	}
+	return pageMessage(parts[1], reply.pages, page, true), true
+}
+
+// Prune forgets replies whose buttons have expired at now and returns how
+// many it forgot, for the janitor.
+func (p *Pager) Prune(now time.Time) int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pruned := 0
+	for key, reply := range p.pages {
+		if !now.Before(reply.expires) {
+			delete(p.pages, key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many replies' pages are kept.
+func (p *Pager) Len() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pages)
+}
+
+// pageMessage shows one of pages, with buttons to the pages around it when
+// buttons is set. Pages out of range show the nearest page.
+func pageMessage(key string, pages []*discordgo.MessageEmbed, page int, buttons bool) *discordgo.InteractionResponseData {
+	data := &discordgo.InteractionResponseData{AllowedMentions: &discordgo.MessageAllowedMentions{}}
+	if len(pages) == 0 {
+		return data
+	}
+	page = min(max(page, 0), len(pages)-1)
+	data.Embeds = []*discordgo.MessageEmbed{pages[page]}
+	if buttons {
+		data.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Previous", Style: discordgo.SecondaryButton, CustomID: fmt.Sprintf("%s:%s:%d", PagePrefix, key, page-1), Disabled: page == 0},
+			discordgo.Button{Label: "Next", Style: discordgo.SecondaryButton, CustomID: fmt.Sprintf("%s:%s:%d", PagePrefix, key, page+1), Disabled: page == len(pages)-1},
+		}}}
+	}
+	return data
+}
+
+// numberPages adds "Page n of m" to the footers of pages, keeping any footer
+// text they already have.
+func numberPages(pages []*discordgo.MessageEmbed) {
+	if len(pages) <= 1 {
+		return
+	}
+	for n, page := range pages {
+		text := fmt.Sprintf("Page %d of %d", n+1, len(pages))
+		if page.Footer != nil && page.Footer.Text != "" {
+			text += " · " + page.Footer.Text
+		}
+		page.Footer = &discordgo.MessageEmbedFooter{Text: text}
+	}
+}
+
+// paginate splits lines into pages of at most perPage lines.
+func paginate(lines []string, perPage int) [][]string {
+	var pages [][]string
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	if len(lines) > 0 {
+		pages = append(pages, lines)
+	}
+	return pages
+}
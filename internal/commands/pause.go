@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// NewPause builds the /pause command, which stops everything the bot does on
+// its own in the server, such as fixing links, warning about phishing,
+// reacting to reactions and posting feeds and digests, until /resume.
+// Commands keep working.
+func NewPause(st storage.Store) Command {
+	return pauseCommand(st, "pause", "Stop everything the bot does on its own in this server", true)
+}
+
+// NewResume builds the /resume command, which undoes /pause.
+func NewResume(st storage.Store) Command {
+	return pauseCommand(st, "resume", "Let the bot act on its own in this server again", false)
+}
+
+// pauseCommand builds a command that pauses or resumes the bot in a guild.
+func pauseCommand(st storage.Store, name, description string, paused bool) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             name,
+			Description:      description,
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			cfg, err := config.LoadGuild(st, i.GuildID)
+			if err != nil {
+				log.Println("Error loading guild config:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+				return
+			}
+			if cfg.Paused == paused {
+				RespondEphemeral(ctx, s, i, formatPaused(paused))
+				return
+			}
+			cfg.Paused = paused
+			if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+				log.Println("Error saving guild config:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+				return
+			}
+			RespondEphemeral(ctx, s, i, "Saved. "+formatPaused(paused))
+		},
+	}
+}
+
+// formatPaused describes whether the bot is paused.
+func formatPaused(paused bool) string {
+	if paused {
+		return "The bot is paused: it won't fix links, warn about phishing, act on reactions or post feeds and digests here until someone uses /resume. Commands still work."
+	}
+	return "The bot isn't paused."
+}
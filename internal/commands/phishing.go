@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// phishingConfigGroup defines the /config phishing subcommands.
+func phishingConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "phishing",
+		Description: "What happens to links to known phishing and malware sites",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "action",
+				Description: "Choose what the bot does about messages with dangerous links",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "action",
+						Description: "What to do",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Warn", Value: config.PhishingWarn},
+							{Name: "Delete (needs Manage Messages)", Value: config.PhishingDelete},
+							{Name: "Off", Value: config.PhishingOff},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// handlePhishingConfig runs a /config phishing subcommand.
+func handlePhishingConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	cfg.PhishingAction = OptionMap(sub.Options)["action"].StringValue()
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+
+	switch cfg.PhishingAction {
+	case config.PhishingDelete:
+		RespondEphemeral(ctx, s, i, "Saved. Messages linking to known phishing or malware sites will be deleted, or warned about if the bot can't delete them.")
+	case config.PhishingOff:
+		RespondEphemeral(ctx, s, i, "Saved. Links won't be checked for phishing or malware.")
+	default:
+		RespondEphemeral(ctx, s, i, "Saved. Messages linking to known phishing or malware sites will get a warning reply.")
+	}
+}
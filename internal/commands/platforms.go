@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// platformsMenuID is the custom ID of the /config platforms select menu.
+const platformsMenuID = "config:platforms"
+
+// fixerLabels name the fixers the wizard doesn't offer.
+var fixerLabels = map[string]string{
+	"custom": "Your rewrite rules",
+	"music":  "Music links (see /music)",
+}
+
+// platformsConfigCommand defines /config platforms.
+func platformsConfigCommand() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "platforms",
+		Description: "Pick which kinds of links the bot fixes",
+	}
+}
+
+// handlePlatformsConfig runs /config platforms, replying privately with a
+// menu of every fixer.
+func handlePlatformsConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, fixerNames []string) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+	respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, platformsMessage(cfg, fixerNames))
+}
+
+// handlePlatformsMenu saves the fixers picked in the /config platforms menu
+// and updates it to match.
+func handlePlatformsMenu(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, fixerNames []string) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+	cfg.DisabledFixers = disabledFixers(cfg.DisabledFixers, fixerNames, i.MessageComponentData().Values)
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	respond(ctx, s, i, discordgo.InteractionResponseUpdateMessage, platformsMessage(cfg, fixerNames))
+}
+
+// disabledFixers returns the fixers to turn off when only picked among
+// fixerNames are on. Names the bot no longer has stay as they were.
+func disabledFixers(disabled, fixerNames, picked []string) []string {
+	var kept []string
+	for _, name := range disabled {
+		if !slices.Contains(fixerNames, name) {
+			kept = append(kept, name)
+		}
+	}
+	for _, name := range fixerNames {
+		if !slices.Contains(picked, name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// fixerLabel describes a fixer by name.
+func fixerLabel(name string) string {
+	if i := slices.IndexFunc(setupFixers, func(f setupChoice) bool { return f.Value == name }); i >= 0 {
+		return setupFixers[i].Label
+	}
+	if label, ok := fixerLabels[name]; ok {
+		return label
+	}
+	return name
+}
+
+// platformsMessage renders the /config platforms menu for the current settings.
+func platformsMessage(cfg config.Guild, fixerNames []string) *discordgo.InteractionResponseData {
+	if len(fixerNames) == 0 {
+		return &discordgo.InteractionResponseData{Content: "The bot has no link fixers to pick from.", Flags: discordgo.MessageFlagsEphemeral}
+	}
+	zero := 0
+	options := make([]discordgo.SelectMenuOption, len(fixerNames))
+	var on []string
+	for n, name := range fixerNames {
+		options[n] = discordgo.SelectMenuOption{Label: fixerLabel(name), Value: name, Default: cfg.FixerEnabled(name)}
+		if cfg.FixerEnabled(name) {
+			on = append(on, fixerLabel(name))
+		}
+	}
+	fixing := "nothing"
+	if len(on) > 0 {
+		fixing = strings.Join(on, ", ")
+	}
+	return &discordgo.InteractionResponseData{
+		Content: "**Platforms** — changes are saved as soon as you pick them.\nFixing: " + fixing,
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.SelectMenu{
+				CustomID:    platformsMenuID,
+				Placeholder: "Links to fix",
+				MinValues:   &zero,
+				MaxValues:   len(options),
+				Options:     options,
+			}}},
+		},
+	}
+}
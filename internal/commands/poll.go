@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/trends"
+)
+
+// Discord's limits on polls.
+const (
+	maxPollQuestion = 300
+	maxPollAnswers  = 10
+	maxPollAnswer   = 55
+	maxPollHours    = 32 * 24
+)
+
+// defaultPollHours is how long polls run unless the moderator says otherwise.
+const defaultPollHours = 24
+
+// NewPoll builds the /poll command, which lets moderators run a vote with
+// Discord's native polls, such as picking the best tweet shared this week,
+// seeded from the tweets counted by counter.
+func NewPoll(st storage.Store, counter *trends.Counter) Command {
+	minHours := 1.0
+	// Both subcommands take how long the vote runs
+	hours := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "hours",
+		Description: fmt.Sprintf("How long the vote runs, %d hours unless you say otherwise", defaultPollHours),
+		MinValue:    &minHours,
+		MaxValue:    maxPollHours,
+	}
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "poll",
+			Description:      "Run a vote in this channel",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "create",
+					Description: "Ask a question with your own answers",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "question",
+							Description: "What to ask",
+							Required:    true,
+							MaxLength:   maxPollQuestion,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "answers",
+							Description: fmt.Sprintf("Up to %d answers, separated by |", maxPollAnswers),
+							Required:    true,
+						},
+						hours,
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "multiple",
+							Description: "Let people pick more than one answer",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "tweets",
+					Description: "Vote for the best of the tweets shared most this week",
+					Options:     []*discordgo.ApplicationCommandOption{hours},
+				},
+			},
+		},
+		Module:      ModuleTools,
+		Permissions: discordgo.PermissionManageMessages,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			sub := i.ApplicationCommandData().Options[0]
+			opts := OptionMap(sub.Options)
+			duration := defaultPollHours
+			if opt, ok := opts["hours"]; ok {
+				duration = int(opt.IntValue())
+			}
+
+			msg := &discordgo.MessageSend{AllowedMentions: &discordgo.MessageAllowedMentions{}}
+			switch sub.Name {
+			case "create":
+				answers, err := parsePollAnswers(opts["answers"].StringValue())
+				if err != nil {
+					RespondEphemeral(ctx, s, i, "Poll not posted: "+err.Error()+".")
+					return
+				}
+				multiple := false
+				if opt, ok := opts["multiple"]; ok {
+					multiple = opt.BoolValue()
+				}
+				msg.Poll = newPoll(opts["question"].StringValue(), answers, duration, multiple)
+			case "tweets":
+				if logging.Private(i.GuildID) {
+					RespondEphemeral(ctx, s, i, "This server is in privacy mode, so the bot doesn't keep track of what's shared.")
+					return
+				}
+				cfg, err := config.LoadGuild(st, i.GuildID)
+				if err != nil {
+					log.Println("Error loading guild config:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+					return
+				}
+				top, err := counter.Top(i.GuildID, maxPollAnswers, time.Now(), cfg.Location())
+				if err != nil {
+					log.Println("Error loading trends:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't load what's been shared, try again later.")
+					return
+				}
+				if len(top.Tweets) < 2 {
+					RespondEphemeral(ctx, s, i, "Not enough tweets have been shared this week for a vote.")
+					return
+				}
+				msg.Content, msg.Poll = tweetPoll(top.Tweets, duration)
+			}
+
+			if _, err := s.ChannelMessageSendComplex(i.ChannelID, msg, discordgo.WithContext(ctx)); err != nil {
+				log.Println("Error posting poll:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't post the poll. The bot needs the Send Messages and Send Polls permissions here.")
+				return
+			}
+			RespondEphemeral(ctx, s, i, "Poll posted.")
+		},
+	}
+}
+
+// parsePollAnswers splits answers separated by | and checks they fit in a poll.
+func parsePollAnswers(list string) ([]string, error) {
+	var answers []string
+	for _, answer := range strings.Split(list, "|") {
+		if answer = strings.TrimSpace(answer); answer != "" {
+			answers = append(answers, answer)
+		}
+	}
+	switch {
+	case len(answers) < 2:
+		return nil, errors.New("a poll needs at least two answers, separated by |")
+	case len(answers) > maxPollAnswers:
+		return nil, fmt.Errorf("a poll can have at most %d answers", maxPollAnswers)
+	}
+	for _, answer := range answers {
+		if len([]rune(answer)) > maxPollAnswer {
+			return nil, fmt.Errorf("answers can be at most %d characters long, and %q is longer", maxPollAnswer, answer)
+		}
+	}
+	return answers, nil
+}
+
+// newPoll builds a poll running for hours.
+func newPoll(question string, answers []string, hours int, multiple bool) *discordgo.Poll {
+	poll := &discordgo.Poll{
+		Question:         discordgo.PollMedia{Text: question},
+		AllowMultiselect: multiple,
+		LayoutType:       discordgo.PollLayoutTypeDefault,
+		Duration:         hours,
+	}
+	for _, answer := range answers {
+		poll.Answers = append(poll.Answers, discordgo.PollAnswer{Media: &discordgo.PollMedia{Text: answer}})
+	}
+	return poll
+}
+
+// tweetPoll builds a vote for the best of tweets, which are listed in content
+// since poll answers can't hold links.
+func tweetPoll(tweets []trends.Count, hours int) (content string, poll *discordgo.Poll) {
+	lines := make([]string, len(tweets))
+	answers := make([]string, len(tweets))
+	for n, t := range tweets {
+		// Angle brackets keep Discord from embedding every tweet
+		lines[n] = fmt.Sprintf("%d. <https://x.com/i/status/%s>", n+1, t.Name)
+		answers[n] = fmt.Sprintf("Tweet %d", n+1)
+	}
+	return strings.Join(lines, "\n"), newPoll("Which was the best tweet this week?", answers, hours, false)
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// previewsConfigGroup defines the /config previews subcommands.
+func previewsConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "previews",
+		Description: "Preview links Discord doesn't embed",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "on",
+				Description: "Post a preview built from the page for links without an embed",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Don't preview links Discord doesn't embed",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show whether link previews are on",
+			},
+		},
+	}
+}
+
+// handlePreviewsConfig runs a /config previews subcommand.
+func handlePreviewsConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "on":
+		cfg.Previews = true
+	case "off":
+		cfg.Previews = false
+	case "status":
+		RespondEphemeral(ctx, s, i, formatPreviews(cfg.Previews))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatPreviews(cfg.Previews))
+}
+
+// formatPreviews describes the link previews setting.
+func formatPreviews(enabled bool) string {
+	if enabled {
+		return "Link previews are on: links Discord doesn't embed get a preview from the page's title, description and image."
+	}
+	return "Link previews are off."
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/purge"
+	"go-discord-bot/internal/storage"
+)
+
+// privacyConfigGroup defines the /config privacy subcommands.
+func privacyConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "privacy",
+		Description: "Stop the bot logging message content in this server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "on",
+				Description: "Don't log message content or collect stats",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Allow logging message content for debugging",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show whether privacy mode is on",
+			},
+		},
+	}
+}
+
+// handlePrivacyConfig runs a /config privacy subcommand.
+func handlePrivacyConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "on":
+		cfg.Privacy = true
+	case "off":
+		cfg.Privacy = false
+	case "status":
+		RespondEphemeral(ctx, s, i, formatPrivacy(cfg.Privacy))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatPrivacy(cfg.Privacy))
+}
+
+// formatPrivacy describes the privacy mode setting.
+func formatPrivacy(enabled bool) string {
+	if enabled {
+		return "Privacy mode is on: message content from this server is never logged."
+	}
+	return "Privacy mode is off."
+}
+
+// Custom IDs of the /privacy purge buttons. The target follows the prefix,
+// "server" or a user ID.
+const (
+	privacyPurgePrefix  = "privacy:purge:"
+	privacyCancelID     = "privacy:cancel"
+	privacyServerTarget = "server"
+)
+
+// NewPrivacy returns the /privacy command, which deletes everything the bot
+// has stored about the server or one of its members through purger, once
+// whoever asked confirms.
+func NewPrivacy(st storage.Store, purger *purge.Purger) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "privacy",
+			Description:      "Delete what the bot has stored about this server or a member",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "purge",
+					Description: "Delete stored data, after you confirm",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "server",
+							Description: "Delete the settings, stats, feeds and every other record of this server",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "user",
+							Description: "Delete the stats, digest entries and deleted messages of a member",
+							Options: []*discordgo.ApplicationCommandOption{
+								{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "The member", Required: true},
+							},
+						},
+					},
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: discordgo.PermissionAdministrator,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			sub := i.ApplicationCommandData().Options[0].Options[0]
+			target, what := privacyServerTarget, "everything the bot has stored about this server: its settings, stats, feeds, role menus, announcements, digests and deleted messages. The bot will act as if it just joined."
+			if sub.Name == "user" {
+				user := OptionMap(sub.Options)["user"].UserValue(nil)
+				target, what = user.ID, fmt.Sprintf("what the bot has stored about <@%s> in this server: their stats, digest entries and deleted messages.", user.ID)
+			}
+			respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+				Content: "This deletes " + what + " It can't be undone.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+				Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Delete", Style: discordgo.DangerButton, CustomID: privacyPurgePrefix + target},
+					discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: privacyCancelID},
+				}}},
+			})
+		},
+		Component: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			update := func(content string) {
+				respond(ctx, s, i, discordgo.InteractionResponseUpdateMessage, &discordgo.InteractionResponseData{
+					Content:    content,
+					Components: []discordgo.MessageComponent{},
+				})
+			}
+			target, ok := strings.CutPrefix(i.MessageComponentData().CustomID, privacyPurgePrefix)
+			if !ok || i.GuildID == "" {
+				update("Cancelled, nothing was deleted.")
+				return
+			}
+			// The audit channel is a setting, so it's gone once the server's are
+			cfg, err := config.LoadGuild(st, i.GuildID)
+			if err != nil {
+				log.Println("Error loading guild config:", err)
+			}
+			report, notice, err := runPurge(purger, i.GuildID, target)
+			if err != nil {
+				log.Println("Error purging data:", err)
+				update("Couldn't delete everything, try again later.")
+				return
+			}
+			log.Printf("Purged %s data in guild %s at the request of %s: %s", target, i.GuildID, interactionUser(i), report)
+			auditTo(ctx, s, cfg.AuditChannel, fmt.Sprintf("<@%s> deleted %s with /privacy purge (%s).", interactionUser(i), notice, plural(report.Total(), "record")))
+			update(fmt.Sprintf("Deleted %s. Short-lived caches, such as recent reposts, clear themselves within the hour.", plural(report.Total(), "record")))
+		},
+	}
+}
+
+// runPurge deletes the data of target, "server" or a user ID, in guildID,
+// and describes what it deleted for the audit channel.
+func runPurge(purger *purge.Purger, guildID, target string) (purge.Report, string, error) {
+	if purger == nil {
+		return nil, "", errors.New("no purger")
+	}
+	if target == privacyServerTarget {
+		report, err := purger.Guild(guildID)
+		return report, "all of this server's data", err
+	}
+	report, err := purger.User(guildID, target)
+	return report, fmt.Sprintf("the data of <@%s>", target), err
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// NewQuotes builds the /quotes command, which has the bot reply to links to
+// messages in the server with a quote of the message. It isn't part of
+// /config, which has as many options as Discord allows.
+func NewQuotes(st storage.Store) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "quotes",
+			Description:      "Quote the messages that message links in this server point to",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "on",
+					Description: "Reply to links to messages with a quote of the message",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "off",
+					Description: "Don't quote linked messages",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show whether linked messages are quoted",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleQuotes(ctx, s, i, st, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleQuotes runs a /quotes subcommand.
+func handleQuotes(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "on":
+		cfg.QuoteLinks = true
+	case "off":
+		cfg.QuoteLinks = false
+	case "status":
+		RespondEphemeral(ctx, s, i, formatQuotes(cfg.QuoteLinks))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatQuotes(cfg.QuoteLinks))
+}
+
+// formatQuotes describes the message link quoting setting.
+func formatQuotes(enabled bool) string {
+	if enabled {
+		return "Message quotes are on: links to messages in this server get a reply quoting them, if whoever posted the link can read them."
+	}
+	return "Message quotes are off."
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/events"
+)
+
+// RemovePrefix is the custom ID prefix of the Remove button on reposts.
+const RemovePrefix = "repost-remove"
+
+// RemoveButton returns the row holding the Remove button attached to a repost
+// of a message by authorID.
+func RemoveButton(authorID string) discordgo.MessageComponent {
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.Button{
+		Label:    "Remove",
+		Style:    discordgo.SecondaryButton,
+		CustomID: RemovePrefix + ":" + authorID,
+	}}}
+}
+
+// NewRemoveRepost returns the handler that deletes the repost whose Remove button
+// was clicked, publishing each removal on bus. Only the author of the original
+// message or members who can manage messages may remove it.
+func NewRemoveRepost(bus *events.Bus) InteractionHandler {
+	return func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+		if !canRemove(i) {
+			RespondEphemeral(ctx, s, i, "Only the person who posted the link or a moderator can remove this.")
+			return
+		}
+
+		// Acknowledge first, the message the response would update is about to go away
+		respond(ctx, s, i, discordgo.InteractionResponseDeferredMessageUpdate, nil)
+		if err := s.ChannelMessageDelete(i.ChannelID, i.Message.ID, discordgo.WithContext(ctx)); err != nil {
+			log.Println("Error removing repost:", err)
+			return
+		}
+		bus.Publish(events.Event{Type: events.Delete, GuildID: i.GuildID, ChannelID: i.ChannelID, MessageID: i.Message.ID})
+	}
+}
+
+// canRemove reports whether the member who clicked a Remove button may delete the repost.
+func canRemove(i *discordgo.InteractionCreate) bool {
+	_, authorID, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	if i.Member == nil {
+		// Direct messages only involve the author and the bot
+		return i.User != nil && i.User.ID == authorID
+	}
+	if i.Member.Permissions&discordgo.PermissionManageMessages != 0 {
+		return true
+	}
+	return i.Member.User != nil && i.Member.User.ID == authorID
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/correlation"
+)
+
+// DefaultDeferAfter is how long the dispatcher waits for a handler to reply
+// before deferring the interaction, leaving time to spare within the three
+// seconds Discord waits.
+const DefaultDeferAfter = 2 * time.Second
+
+// failedMessage is the reply to interactions whose handler failed to reply.
+const failedMessage = "Something went wrong, try again later."
+
+// Reply states of an interaction.
+const (
+	// replyPending means nothing has been sent yet.
+	replyPending = iota
+	// replyDeferred means Discord was told a reply is coming, which still
+	// has to be sent by editing the original response.
+	replyDeferred
+	// replySent means the interaction has its reply; more messages are
+	// follow-ups and updates edit the reply.
+	replySent
+)
+
+// reply tracks how an interaction has been answered, so the dispatcher can
+// defer slow handlers and the handlers don't need to know it did.
+type reply struct {
+	mu    sync.Mutex
+	state int
+}
+
+type replyKey struct{}
+
+// withReply returns a context that tracks the reply to an interaction.
+func withReply(ctx context.Context) (context.Context, *reply) {
+	r := &reply{}
+	return context.WithValue(ctx, replyKey{}, r), r
+}
+
+// respond replies to an interaction. Replies to interactions the dispatcher
+// deferred edit the original response instead, and replies to interactions
+// already answered are sent as follow-up messages.
+func respond(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, typ discordgo.InteractionResponseType, data *discordgo.InteractionResponseData) {
+	r, _ := ctx.Value(replyKey{}).(*reply)
+	if r == nil {
+		sendResponse(ctx, s, i, typ, data)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.send(ctx, s, i, typ, data)
+}
+
+// Ways to send a reply, depending on how the interaction was answered so far.
+const (
+	sendNothing = iota
+	sendInitial
+	sendEdit
+	sendFollowup
+)
+
+// route returns how to send a response of type typ and moves the reply to
+// its next state. The caller must hold r.mu.
+func (r *reply) route(typ discordgo.InteractionResponseType, data *discordgo.InteractionResponseData) int {
+	deferral := typ == discordgo.InteractionResponseDeferredChannelMessageWithSource || typ == discordgo.InteractionResponseDeferredMessageUpdate
+	switch r.state {
+	case replyPending:
+		r.state = replySent
+		// A deferred message still has to be sent, unlike an acknowledged click
+		if typ == discordgo.InteractionResponseDeferredChannelMessageWithSource {
+			r.state = replyDeferred
+		}
+		return sendInitial
+	case replyDeferred:
+		if deferral {
+			return sendNothing
+		}
+		r.state = replySent
+		return sendEdit
+	default:
+		if deferral || data == nil {
+			return sendNothing
+		}
+		// Updates to an acknowledged click edit the message it was on
+		if typ == discordgo.InteractionResponseUpdateMessage {
+			return sendEdit
+		}
+		return sendFollowup
+	}
+}
+
+// send sends a response the way route says to. The caller must hold r.mu,
+// so a deferral can't race the handler's reply.
+func (r *reply) send(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, typ discordgo.InteractionResponseType, data *discordgo.InteractionResponseData) {
+	switch r.route(typ, data) {
+	case sendInitial:
+		sendResponse(ctx, s, i, typ, data)
+	case sendEdit:
+		editResponse(ctx, s, i, data)
+	case sendFollowup:
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content:         data.Content,
+			Embeds:          data.Embeds,
+			Components:      data.Components,
+			Files:           data.Files,
+			AllowedMentions: data.AllowedMentions,
+			Flags:           data.Flags,
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			log.Println("Error sending interaction follow-up:", err)
+		}
+	}
+}
+
+// deferReply tells Discord a reply is coming, unless the handler already
+// replied. Public commands show everyone they're thinking; the rest only
+// show the user.
+func (r *reply) deferReply(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, public bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state != replyPending {
+		return
+	}
+	typ, data := deferral(i, public)
+	r.send(ctx, s, i, typ, data)
+}
+
+// deferral returns the response that defers an interaction. Clicks are
+// acknowledged without changing their message.
+func deferral(i *discordgo.InteractionCreate, public bool) (discordgo.InteractionResponseType, *discordgo.InteractionResponseData) {
+	if i.Type == discordgo.InteractionMessageComponent {
+		return discordgo.InteractionResponseDeferredMessageUpdate, nil
+	}
+	data := &discordgo.InteractionResponseData{}
+	if !public {
+		data.Flags = discordgo.MessageFlagsEphemeral
+	}
+	return discordgo.InteractionResponseDeferredChannelMessageWithSource, data
+}
+
+// finish replies with failedMessage, ending with the correlation ID ctx
+// carries, if the handler left a deferred reply unsent, or panicked before
+// replying.
+func (r *reply) finish(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, panicked bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state == replySent || (r.state == replyPending && !panicked) {
+		return
+	}
+	r.send(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+		Content: failedMessage + correlation.Footer(correlation.From(ctx)),
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// sendResponse sends the initial response to an interaction.
+func sendResponse(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, typ discordgo.InteractionResponseType, data *discordgo.InteractionResponseData) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: typ, Data: data}, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error responding to interaction:", err)
+	}
+}
+
+// editResponse replaces a deferred response with data.
+func editResponse(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) {
+	if data == nil {
+		data = &discordgo.InteractionResponseData{}
+	}
+	edit := &discordgo.WebhookEdit{Content: &data.Content, Files: data.Files, AllowedMentions: data.AllowedMentions}
+	if data.Embeds != nil {
+		edit.Embeds = &data.Embeds
+	}
+	if data.Components != nil {
+		edit.Components = &data.Components
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit, discordgo.WithContext(ctx)); err != nil {
+		log.Println("Error editing interaction response:", err)
+	}
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/storage"
+)
+
+// rewriteConfigGroup defines the /config rewrite subcommands.
+func rewriteConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "rewrite",
+		Description: "Custom find/replace rules for links",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Add a rewrite rule",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "pattern", Description: "Regular expression matched against each link", Required: true},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "replacement", Description: "Replacement, may reference groups like $1", Required: true},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove a rewrite rule",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionInteger, Name: "number", Description: "Rule number from /config rewrite list", Required: true},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List this server's rewrite rules",
+			},
+		},
+	}
+}
+
+// handleRewriteConfig runs a /config rewrite subcommand.
+func handleRewriteConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	switch sub.Name {
+	case "add":
+		rule := config.RewriteRule{Pattern: opts["pattern"].StringValue(), Replacement: opts["replacement"].StringValue()}
+		if len(cfg.RewriteRules) >= fixers.MaxRewriteRules {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("This server already has the maximum of %d rules.", fixers.MaxRewriteRules))
+			return
+		}
+		if _, err := fixers.ValidateRewriteRule(rule); err != nil {
+			RespondEphemeral(ctx, s, i, "Rule not saved: "+err.Error())
+			return
+		}
+		cfg.RewriteRules = append(cfg.RewriteRules, rule)
+
+	case "remove":
+		n := int(opts["number"].IntValue())
+		if n < 1 || n > len(cfg.RewriteRules) {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("There is no rule number %d.", n))
+			return
+		}
+		cfg.RewriteRules = append(cfg.RewriteRules[:n-1], cfg.RewriteRules[n:]...)
+
+	case "list":
+		RespondEphemeral(ctx, s, i, formatRewriteRules(cfg.RewriteRules))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatRewriteRules(cfg.RewriteRules))
+}
+
+// formatRewriteRules renders a numbered list of rules.
+func formatRewriteRules(rules []config.RewriteRule) string {
+	if len(rules) == 0 {
+		return "No rewrite rules configured."
+	}
+	var b strings.Builder
+	for n, rule := range rules {
+		fmt.Fprintf(&b, "%d. `%s` → `%s`\n", n+1, rule.Pattern, rule.Replacement)
+	}
+	return b.String()
+}
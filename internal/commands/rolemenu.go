@@ -0,0 +1,453 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/rolemenus"
+	"go-discord-bot/internal/storage"
+)
+
+// RoleMenuPrefix is the custom ID prefix of the buttons and select menus on
+// role menus. It differs from the command's name so members clicking them
+// don't need the command's permissions.
+const RoleMenuPrefix = "role-menu"
+
+// roleMenuSelect is the custom ID, after the prefix, of a role menu's select menu.
+const roleMenuSelect = "select"
+
+// roleMenuExtraRoles is how many roles /rolemenu create takes after the first.
+const roleMenuExtraRoles = 4
+
+// messageIDPattern matches a message ID, alone or at the end of a message link.
+var messageIDPattern = regexp.MustCompile(`(?:^|/)(\d{17,20})/?$`)
+
+// NewRoleMenu builds the /rolemenu command, which posts and edits messages
+// members give themselves roles with.
+func NewRoleMenu(st storage.Store) Command {
+	message := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "message",
+		Description: "The menu's message ID or link, from /rolemenu list",
+		Required:    true,
+	}
+	createOptions := []*discordgo.ApplicationCommandOption{
+		{Type: discordgo.ApplicationCommandOptionString, Name: "title", Description: "What the menu is for, like: Pick your pronouns", Required: true, MaxLength: 200},
+		{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "A role members can pick", Required: true},
+	}
+	for n := 2; n <= roleMenuExtraRoles+1; n++ {
+		createOptions = append(createOptions, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionRole,
+			Name:        fmt.Sprintf("role%d", n),
+			Description: "Another role members can pick",
+		})
+	}
+	createOptions = append(createOptions, &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionBoolean,
+		Name:        "dropdown",
+		Description: "Offer the roles in a dropdown instead of as buttons",
+	})
+
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "rolemenu",
+			Description:      "Post menus members give themselves roles with",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "create",
+					Description: "Post a role menu in this channel",
+					Options:     createOptions,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Offer another role in a role menu",
+					Options:     []*discordgo.ApplicationCommandOption{message, {Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "The role", Required: true}},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Stop offering a role in a role menu",
+					Options:     []*discordgo.ApplicationCommandOption{message, {Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "The role", Required: true}},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "delete",
+					Description: "Delete a role menu",
+					Options:     []*discordgo.ApplicationCommandOption{message},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show the role menus in this server",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: discordgo.PermissionManageRoles,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			sub := i.ApplicationCommandData().Options[0]
+			switch sub.Name {
+			case "create":
+				handleRoleMenuCreate(ctx, s, i, st, sub)
+			case "add", "remove":
+				handleRoleMenuEdit(ctx, s, i, st, sub)
+			case "delete":
+				handleRoleMenuDelete(ctx, s, i, st, sub)
+			case "list":
+				menus, err := rolemenus.List(st, i.GuildID)
+				if err != nil {
+					log.Println("Error listing role menus:", err)
+					RespondEphemeral(ctx, s, i, "Couldn't load this server's role menus, try again later.")
+					return
+				}
+				RespondEphemeral(ctx, s, i, formatRoleMenus(menus))
+			}
+		},
+	}
+}
+
+// handleRoleMenuCreate runs /rolemenu create: it posts the menu, then saves it.
+func handleRoleMenuCreate(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	opts := OptionMap(sub.Options)
+	menu := rolemenus.Menu{GuildID: i.GuildID, ChannelID: i.ChannelID, Title: opts["title"].StringValue()}
+	if opt, ok := opts["dropdown"]; ok {
+		menu.Select = opt.BoolValue()
+	}
+	guildRoles, ok := loadGuildRoles(ctx, s, i)
+	if !ok {
+		return
+	}
+	names := []string{"role"}
+	for n := 2; n <= roleMenuExtraRoles+1; n++ {
+		names = append(names, fmt.Sprintf("role%d", n))
+	}
+	for _, name := range names {
+		opt, ok := opts[name]
+		if !ok {
+			continue
+		}
+		role, problem := offerableRole(i, guildRoles, opt.Value.(string))
+		if problem == "" {
+			if err := menu.Add(role); err != nil {
+				problem = "That menu doesn't work: " + err.Error() + "."
+			}
+		}
+		if problem != "" {
+			RespondEphemeral(ctx, s, i, problem)
+			return
+		}
+	}
+
+	sent, err := s.ChannelMessageSendComplex(i.ChannelID, roleMenuMessage(menu), discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error posting role menu:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't post the role menu. The bot needs the Send Messages permission here.")
+		return
+	}
+	menu.MessageID = sent.ID
+	if err := rolemenus.Save(st, menu); err != nil {
+		log.Println("Error saving role menu:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save the role menu, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Role menu posted. The bot needs Manage Roles, and its highest role has to be above the menu's roles, to give them out.")
+}
+
+// handleRoleMenuEdit runs /rolemenu add and remove: it changes the saved menu,
+// then its message.
+func handleRoleMenuEdit(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	opts := OptionMap(sub.Options)
+	messageID := parseMessageID(opts["message"].StringValue())
+	roleID := opts["role"].Value.(string)
+
+	var role rolemenus.Role
+	if sub.Name == "add" {
+		guildRoles, ok := loadGuildRoles(ctx, s, i)
+		if !ok {
+			return
+		}
+		var problem string
+		if role, problem = offerableRole(i, guildRoles, roleID); problem != "" {
+			RespondEphemeral(ctx, s, i, problem)
+			return
+		}
+	}
+	var invalid error
+	menu, found, err := rolemenus.Update(st, i.GuildID, messageID, func(m *rolemenus.Menu) error {
+		if sub.Name == "add" {
+			invalid = m.Add(role)
+		} else {
+			invalid = m.Drop(roleID)
+		}
+		return invalid
+	})
+	switch {
+	case invalid != nil:
+		RespondEphemeral(ctx, s, i, "Couldn't change that menu: "+invalid.Error()+".")
+		return
+	case err != nil:
+		log.Println("Error saving role menu:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save the role menu, try again later.")
+		return
+	case !found:
+		RespondEphemeral(ctx, s, i, "There's no role menu with that message in this server. Use `/rolemenu list` to see them.")
+		return
+	}
+
+	msg := roleMenuMessage(menu)
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         menu.MessageID,
+		Channel:    menu.ChannelID,
+		Content:    &msg.Content,
+		Components: &msg.Components,
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error editing role menu:", err)
+		RespondEphemeral(ctx, s, i, "Saved, but couldn't update the menu's message. Was it deleted? Then use `/rolemenu delete`.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Role menu updated.")
+}
+
+// handleRoleMenuDelete runs /rolemenu delete.
+func handleRoleMenuDelete(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	messageID := parseMessageID(OptionMap(sub.Options)["message"].StringValue())
+	menu, found, err := rolemenus.Get(st, i.GuildID, messageID)
+	if err == nil && found {
+		_, err = rolemenus.Remove(st, i.GuildID, messageID)
+	}
+	if err != nil {
+		log.Println("Error removing role menu:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't delete the role menu, try again later.")
+		return
+	}
+	if !found {
+		RespondEphemeral(ctx, s, i, "There's no role menu with that message in this server. Use `/rolemenu list` to see them.")
+		return
+	}
+	// The message may already be gone, which is fine
+	if err := s.ChannelMessageDelete(menu.ChannelID, menu.MessageID, discordgo.WithContext(ctx)); err != nil {
+		log.Println("Error deleting role menu message:", err)
+	}
+	RespondEphemeral(ctx, s, i, "Role menu deleted.")
+}
+
+// loadGuildRoles fetches the guild's roles, telling the member if it can't.
+func loadGuildRoles(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) ([]*discordgo.Role, bool) {
+	roles, err := s.GuildRoles(i.GuildID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error listing guild roles:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't check this server's roles, try again later.")
+		return nil, false
+	}
+	return roles, true
+}
+
+// offerableRole returns the role roleID of a guild with roles for a menu, or
+// why the member using the command can't offer it: it's @everyone, belongs
+// to an integration, or is at or above the member's highest role. Admins can
+// offer any other role.
+func offerableRole(i *discordgo.InteractionCreate, roles []*discordgo.Role, roleID string) (rolemenus.Role, string) {
+	var role *discordgo.Role
+	highest := -1
+	for _, r := range roles {
+		if r.ID == roleID {
+			role = r
+		}
+		if i.Member != nil && slices.Contains(i.Member.Roles, r.ID) && r.Position > highest {
+			highest = r.Position
+		}
+	}
+	switch {
+	case role == nil:
+		return rolemenus.Role{}, "That role isn't in this server."
+	case role.ID == i.GuildID:
+		return rolemenus.Role{}, "Everyone already has @everyone."
+	case role.Managed:
+		return rolemenus.Role{}, fmt.Sprintf("%s belongs to a bot or integration, so it can't be handed out.", role.Name)
+	case i.Member != nil && i.Member.Permissions&discordgo.PermissionAdministrator == 0 && role.Position >= highest:
+		return rolemenus.Role{}, fmt.Sprintf("%s is at or above your highest role, so you can't hand it out.", role.Name)
+	}
+	return rolemenus.Role{ID: role.ID, Name: role.Name}, ""
+}
+
+// belowBot reports whether the role roleID of a guild with roles is below the
+// bot's highest role there, so the bot can give it out.
+func belowBot(s *discordgo.Session, guildID string, roles []*discordgo.Role, roleID string) bool {
+	if s.State == nil || s.State.User == nil {
+		return false
+	}
+	bot, err := s.State.Member(guildID, s.State.User.ID)
+	if err != nil {
+		if bot, err = s.GuildMember(guildID, s.State.User.ID); err != nil {
+			log.Println("Error looking up the bot's roles:", err)
+			return false
+		}
+	}
+	return roleBelow(roles, bot.Roles, roleID)
+}
+
+// roleBelow reports whether the role roleID is below the highest of
+// memberRoles among a guild's roles.
+func roleBelow(roles []*discordgo.Role, memberRoles []string, roleID string) bool {
+	highest, position := -1, -1
+	for _, r := range roles {
+		if slices.Contains(memberRoles, r.ID) {
+			highest = max(highest, r.Position)
+		}
+		if r.ID == roleID {
+			position = r.Position
+		}
+	}
+	return position >= 0 && position < highest
+}
+
+// roleMenuMessage lays a menu out as a message: its title, and a button per
+// role, five to a row, or a select menu of them.
+func roleMenuMessage(m rolemenus.Menu) *discordgo.MessageSend {
+	msg := &discordgo.MessageSend{AllowedMentions: &discordgo.MessageAllowedMentions{}}
+	if m.Select {
+		msg.Content = fmt.Sprintf("**%s**\nPick your roles below. Leave one out to have it taken away.", m.Title)
+		noMinimum := 0
+		menu := discordgo.SelectMenu{
+			CustomID:    RoleMenuPrefix + ":" + roleMenuSelect,
+			Placeholder: "Pick your roles",
+			MinValues:   &noMinimum,
+			MaxValues:   len(m.Roles),
+		}
+		for _, r := range m.Roles {
+			menu.Options = append(menu.Options, discordgo.SelectMenuOption{Label: r.Name, Value: r.ID})
+		}
+		msg.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{menu}}}
+		return msg
+	}
+
+	msg.Content = fmt.Sprintf("**%s**\nClick a role to get it, and again to have it taken away.", m.Title)
+	var row []discordgo.MessageComponent
+	for _, r := range m.Roles {
+		row = append(row, discordgo.Button{Label: r.Name, Style: discordgo.SecondaryButton, CustomID: RoleMenuPrefix + ":" + r.ID})
+		if len(row) == 5 {
+			msg.Components = append(msg.Components, discordgo.ActionsRow{Components: row})
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		msg.Components = append(msg.Components, discordgo.ActionsRow{Components: row})
+	}
+	return msg
+}
+
+// NewRoleMenuClick returns the handler for clicks on role menus: a button
+// gives the member its role, or takes it away if they have it, and the
+// select menu gives them the roles picked and takes away the rest.
+func NewRoleMenuClick(st storage.Store) InteractionHandler {
+	return func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+		if i.Member == nil || i.Member.User == nil || i.Message == nil {
+			return
+		}
+		menu, found, err := rolemenus.Get(st, i.GuildID, i.Message.ID)
+		if err != nil {
+			log.Println("Error loading role menu:", err)
+			RespondEphemeral(ctx, s, i, "Couldn't load this role menu, try again later.")
+			return
+		}
+		if !found {
+			RespondEphemeral(ctx, s, i, "This role menu was deleted.")
+			return
+		}
+
+		data := i.MessageComponentData()
+		_, choice, _ := strings.Cut(data.CustomID, ":")
+		var give, take []string
+		switch {
+		case choice == roleMenuSelect:
+			give, take = menu.Changes(i.Member.Roles, data.Values)
+		case !menu.Has(choice):
+			RespondEphemeral(ctx, s, i, "This role isn't offered anymore.")
+			return
+		case slices.Contains(i.Member.Roles, choice):
+			take = []string{choice}
+		default:
+			give = []string{choice}
+		}
+
+		for _, roleID := range give {
+			err = s.GuildMemberRoleAdd(i.GuildID, i.Member.User.ID, roleID, discordgo.WithContext(ctx))
+			if err != nil {
+				break
+			}
+		}
+		for _, roleID := range take {
+			if err != nil {
+				break
+			}
+			err = s.GuildMemberRoleRemove(i.GuildID, i.Member.User.ID, roleID, discordgo.WithContext(ctx))
+		}
+		if err != nil {
+			log.Println("Error changing roles from role menu:", err)
+			RespondEphemeral(ctx, s, i, "Couldn't change your roles. The bot needs Manage Roles, and its highest role has to be above the menu's roles.")
+			return
+		}
+		RespondEphemeral(ctx, s, i, formatRoleChanges(give, take))
+	}
+}
+
+// formatRoleChanges describes the roles a member was given and had taken away.
+func formatRoleChanges(give, take []string) string {
+	mention := func(ids []string) string {
+		mentions := make([]string, len(ids))
+		for n, id := range ids {
+			mentions[n] = "<@&" + id + ">"
+		}
+		return strings.Join(mentions, ", ")
+	}
+	var parts []string
+	if len(give) > 0 {
+		parts = append(parts, "Gave you "+mention(give)+".")
+	}
+	if len(take) > 0 {
+		parts = append(parts, "Took away "+mention(take)+".")
+	}
+	if len(parts) == 0 {
+		return "Your roles are already like that."
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatRoleMenus lists a guild's role menus.
+func formatRoleMenus(menus []rolemenus.Menu) string {
+	if len(menus) == 0 {
+		return "This server has no role menus. Post one with `/rolemenu create`."
+	}
+	var b strings.Builder
+	b.WriteString("**Role menus**\n")
+	for _, m := range menus {
+		fmt.Fprintf(&b, "`%s` **%s** in <#%s>, %d roles: https://discord.com/channels/%s/%s/%s\n", m.MessageID, m.Title, m.ChannelID, len(m.Roles), m.GuildID, m.ChannelID, m.MessageID)
+	}
+	return chunk.Split(strings.TrimSuffix(b.String(), "\n"), chunk.MaxMessageLength)[0]
+}
+
+// parseMessageID returns the message ID in s, a message ID or link, or s
+// itself if it has none.
+func parseMessageID(s string) string {
+	s = strings.TrimSpace(s)
+	if m := messageIDPattern.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/access"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// rolesConfigGroup defines the /config roles subcommands.
+func rolesConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "roles",
+		Description: "Limit features, such as having links fixed, to some roles",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "limit",
+				Description: "Let a role use a feature once it's limited, or stop letting it",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "feature",
+						Description: "fixing, mirror, or a command such as /trends",
+						Required:    true,
+					},
+					{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "The role", Required: true},
+					{Type: discordgo.ApplicationCommandOptionBoolean, Name: "remove", Description: "Take the role's access away instead"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the features limited to some roles",
+			},
+		},
+	}
+}
+
+// handleRolesConfig runs a /config roles subcommand.
+func handleRolesConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+	if sub.Name == "list" {
+		RespondEphemeral(ctx, s, i, formatFeatureRoles(cfg))
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	feature, ok := access.Parse(opts["feature"].StringValue())
+	if !ok {
+		RespondEphemeral(ctx, s, i, fmt.Sprintf("I don't know that feature. Use %s, or a command such as /trends.", strings.Join(access.Features, ", ")))
+		return
+	}
+	remove := false
+	if opt, ok := opts["remove"]; ok {
+		remove = opt.BoolValue()
+	}
+	roles := updateIDList(cfg.FeatureRoles[feature], opts["role"].Value.(string), remove)
+	if len(roles) == 0 {
+		delete(cfg.FeatureRoles, feature)
+	} else {
+		if cfg.FeatureRoles == nil {
+			cfg.FeatureRoles = make(map[string][]string)
+		}
+		cfg.FeatureRoles[feature] = roles
+	}
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatFeatureRoles(cfg))
+}
+
+// formatFeatureRoles lists the features limited to some roles, with the roles
+// as mentions.
+func formatFeatureRoles(cfg config.Guild) string {
+	if len(cfg.FeatureRoles) == 0 {
+		return "Everyone can use every feature."
+	}
+	features := make([]string, 0, len(cfg.FeatureRoles))
+	for feature := range cfg.FeatureRoles {
+		features = append(features, feature)
+	}
+	slices.Sort(features)
+	lines := make([]string, len(features))
+	for n, feature := range features {
+		mentions := make([]string, len(cfg.FeatureRoles[feature]))
+		for m, id := range cfg.FeatureRoles[feature] {
+			mentions[m] = "<@&" + id + ">"
+		}
+		lines[n] = fmt.Sprintf("%s: only %s", feature, strings.Join(mentions, ", "))
+	}
+	return "Limited features:\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/phishing"
+	"go-discord-bot/internal/unshorten"
+)
+
+// MaxScan is the most messages /scanlinks looks through.
+const MaxScan = 1000
+
+// maxScanDomains is how many domains the /scanlinks embed lists. The
+// attached report lists them all.
+const maxScanDomains = 20
+
+// discordHosts are Discord's own hosts, whose links aren't external.
+var discordHosts = []string{"discord.com", "discordapp.com", "discordapp.net"}
+
+// domainLinks are the links to one domain found by /scanlinks.
+type domainLinks struct {
+	Host  string
+	Links []string
+	// Bad is set when one of the links is on a phishing or malware blocklist.
+	Bad bool
+	// Shortener is set when the domain is a link shortener, hiding where its
+	// links lead.
+	Shortener bool
+}
+
+// NewScanLinks builds the /scanlinks command, which reports the external links
+// posted in a channel's recent messages by domain, flagging link shorteners
+// and domains checker knows to be bad. A nil checker flags none.
+func NewScanLinks(checker *phishing.Checker) Command {
+	minCount := 1.0
+	// Only the list of shortener hosts is used, nothing is expanded
+	shorteners := unshorten.New(nil)
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "scanlinks",
+			Description:      "Report the links posted in this channel's recent messages",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: fmt.Sprintf("How many recent messages to look through (at most %d)", MaxScan),
+					Required:    true,
+					MinValue:    &minCount,
+					MaxValue:    MaxScan,
+				},
+			},
+		},
+		Module:      ModuleModeration,
+		Permissions: manageGuild,
+		Cooldown:    Cooldown{Channel: time.Minute},
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			count := int(OptionMap(i.ApplicationCommandData().Options)["count"].IntValue())
+			history, err := channelHistory(ctx, s, i.ChannelID, count)
+			if err != nil {
+				log.Println("Error reading channel history:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't read this channel's messages. Check the bot can see its history.")
+				return
+			}
+
+			domains := scanLinks(ctx, checker, shorteners, history)
+			if len(domains) == 0 {
+				RespondEphemeral(ctx, s, i, fmt.Sprintf("Found no links in the last %s.", plural(len(history), "message")))
+				return
+			}
+			data := &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{scanEmbed(domains, len(history))},
+				Flags:  discordgo.MessageFlagsEphemeral,
+			}
+			if len(domains) > maxScanDomains {
+				data.Files = []*discordgo.File{{
+					Name:        "links-" + i.ChannelID + ".txt",
+					ContentType: "text/plain",
+					Reader:      strings.NewReader(scanReport(domains)),
+				}}
+			}
+			respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, data)
+		},
+	}
+}
+
+// channelHistory returns up to count of a channel's last messages, newest first.
+func channelHistory(ctx context.Context, s *discordgo.Session, channelID string, count int) ([]*discordgo.Message, error) {
+	var history []*discordgo.Message
+	before := ""
+	for len(history) < count {
+		// Discord returns at most 100 messages at once
+		page, err := s.ChannelMessages(channelID, min(count-len(history), 100), before, "", "", discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, page...)
+		if len(page) < 100 {
+			break
+		}
+		before = page[len(page)-1].ID
+	}
+	return history, nil
+}
+
+// scanLinks groups the external links in messages by domain, flagged ones
+// first and then the most linked.
+func scanLinks(ctx context.Context, checker *phishing.Checker, shorteners *unshorten.Expander, messages []*discordgo.Message) []*domainLinks {
+	byHost := make(map[string]*domainLinks)
+	var links []string
+	for _, m := range messages {
+		if !patterns.HasLink(m.Content) {
+			continue
+		}
+		for _, link := range patterns.URL.FindAllString(m.Content, -1) {
+			link = strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">")
+			host := phishing.Host(link)
+			if host == "" || discordHost(host) {
+				continue
+			}
+			d, ok := byHost[host]
+			if !ok {
+				d = &domainLinks{Host: host, Shortener: shorteners.Shortened(link)}
+				byHost[host] = d
+			}
+			if !slices.Contains(d.Links, link) {
+				d.Links = append(d.Links, link)
+				links = append(links, link)
+			}
+		}
+	}
+
+	bad, err := checker.Check(ctx, links)
+	if err != nil {
+		// What the local list flagged is still worth reporting
+		log.Println("Error checking links against Safe Browsing:", err)
+	}
+	for _, link := range bad {
+		byHost[phishing.Host(link)].Bad = true
+	}
+
+	domains := make([]*domainLinks, 0, len(byHost))
+	for _, d := range byHost {
+		domains = append(domains, d)
+	}
+	slices.SortFunc(domains, func(a, b *domainLinks) int {
+		if a.Bad != b.Bad {
+			return boolOrder(a.Bad)
+		}
+		if a.Shortener != b.Shortener {
+			return boolOrder(a.Shortener)
+		}
+		return cmp.Or(cmp.Compare(len(b.Links), len(a.Links)), strings.Compare(a.Host, b.Host))
+	})
+	return domains
+}
+
+// boolOrder sorts true before false.
+func boolOrder(first bool) int {
+	if first {
+		return -1
+	}
+	return 1
+}
+
+// discordHost reports whether host is one of Discord's own.
+func discordHost(host string) bool {
+	return slices.ContainsFunc(discordHosts, func(d string) bool {
+		return host == d || strings.HasSuffix(host, "."+d)
+	})
+}
+
+// domainLine describes a domain in the report.
+func domainLine(d *domainLinks) string {
+	line := fmt.Sprintf("`%s`: %s", d.Host, plural(len(d.Links), "link"))
+	if d.Bad {
+		line = "⚠️ " + line + ", known phishing or malware"
+	}
+	if d.Shortener {
+		line = "🔗 " + line + ", link shortener"
+	}
+	return line
+}
+
+// scanEmbed summarizes the links found in scanned messages.
+func scanEmbed(domains []*domainLinks, scanned int) *discordgo.MessageEmbed {
+	var lines []string
+	for _, d := range domains[:min(len(domains), maxScanDomains)] {
+		lines = append(lines, domainLine(d))
+	}
+	if len(domains) > maxScanDomains {
+		lines = append(lines, fmt.Sprintf("…and %s more, see the attached report.", plural(len(domains)-maxScanDomains, "domain")))
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Links in the last %s", plural(scanned, "message")),
+		Description: strings.Join(lines, "\n"),
+	}
+}
+
+// scanReport lists every link found, under its domain.
+func scanReport(domains []*domainLinks) string {
+	var b bytes.Buffer
+	for _, d := range domains {
+		fmt.Fprintln(&b, strings.ReplaceAll(domainLine(d), "`", ""))
+		for _, link := range d.Links {
+			fmt.Fprintln(&b, "  "+link)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,221 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// Custom IDs of the /setup wizard components.
+const (
+	setupChannelsID = "setup:channels"
+	setupFixersID   = "setup:fixers"
+	setupModeID     = "setup:mode"
+	setupDoneID     = "setup:done"
+)
+
+// setupChoice is an option offered by the wizard.
+type setupChoice struct{ Value, Label string }
+
+// setupFixers are the fixers the wizard can toggle, in display order.
+var setupFixers = []setupChoice{
+	{"twitter", "Twitter / X links"},
+	{"twitch", "Twitch clips"},
+	{"cleaner", "Remove tracking parameters"},
+}
+
+// setupModes are the repost modes the wizard offers.
+var setupModes = []setupChoice{
+	{config.RepostMessage, "Post a new message"},
+	{config.RepostReply, "Reply to the original message"},
+	{config.RepostReaction, "React, and post when someone reacts back"},
+	{config.RepostConfirm, "Ask the author privately before posting"},
+	{config.RepostMinimal, "Reply with only the fixed links, hiding the broken embed"},
+	{config.RepostThread, "Post in a \"Fixed links\" thread on the original message"},
+}
+
+// NewSetup builds the /setup command, a wizard that walks admins through the
+// main guild settings with select menus and saves each choice as it's made.
+func NewSetup(st storage.Store) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "setup",
+			Description:      "Set up which channels and links the bot fixes",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			cfg, err := config.LoadGuild(st, i.GuildID)
+			if err != nil {
+				log.Println("Error loading guild config:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+				return
+			}
+			respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, setupMessage(cfg))
+		},
+		Component: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			cfg, err := config.LoadGuild(st, i.GuildID)
+			if err != nil {
+				log.Println("Error loading guild config:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+				return
+			}
+
+			data := i.MessageComponentData()
+			if data.CustomID == setupDoneID {
+				respond(ctx, s, i, discordgo.InteractionResponseUpdateMessage, &discordgo.InteractionResponseData{
+					Content:    "Setup complete.\n" + formatSetup(cfg),
+					Components: []discordgo.MessageComponent{},
+				})
+				return
+			}
+
+			applySetupChoice(&cfg, data.CustomID, data.Values)
+			if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+				log.Println("Error saving guild config:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+				return
+			}
+			respond(ctx, s, i, discordgo.InteractionResponseUpdateMessage, setupMessage(cfg))
+		},
+	}
+}
+
+// applySetupChoice updates cfg with the values picked in a wizard component.
+func applySetupChoice(cfg *config.Guild, customID string, values []string) {
+	switch customID {
+	case setupChannelsID:
+		cfg.Channels = values
+		if len(values) == 0 {
+			cfg.Channels = nil
+		}
+
+	case setupFixersID:
+		// Keep fixers the wizard doesn't manage as they were
+		var disabled []string
+		for _, name := range cfg.DisabledFixers {
+			if !slices.ContainsFunc(setupFixers, func(f setupChoice) bool { return f.Value == name }) {
+				disabled = append(disabled, name)
+			}
+		}
+		for _, f := range setupFixers {
+			if !slices.Contains(values, f.Value) {
+				disabled = append(disabled, f.Value)
+			}
+		}
+		cfg.DisabledFixers = disabled
+
+	case setupModeID:
+		if len(values) == 1 {
+			cfg.RepostMode = values[0]
+		}
+	}
+}
+
+// setupMessage renders the wizard for the current settings.
+func setupMessage(cfg config.Guild) *discordgo.InteractionResponseData {
+	zero := 0
+
+	channels := make([]discordgo.SelectMenuDefaultValue, 0, len(cfg.Channels))
+	for _, id := range cfg.Channels {
+		channels = append(channels, discordgo.SelectMenuDefaultValue{ID: id, Type: discordgo.SelectMenuDefaultValueChannel})
+	}
+
+	fixerOptions := make([]discordgo.SelectMenuOption, 0, len(setupFixers))
+	for _, f := range setupFixers {
+		fixerOptions = append(fixerOptions, discordgo.SelectMenuOption{Label: f.Label, Value: f.Value, Default: cfg.FixerEnabled(f.Value)})
+	}
+
+	modeOptions := make([]discordgo.SelectMenuOption, 0, len(setupModes))
+	for _, m := range setupModes {
+		modeOptions = append(modeOptions, discordgo.SelectMenuOption{Label: m.Label, Value: m.Value, Default: repostMode(cfg) == m.Value})
+	}
+
+	return &discordgo.InteractionResponseData{
+		Content: "**Bot setup** — changes are saved as soon as you pick them.\n" + formatSetup(cfg),
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.SelectMenu{
+				MenuType:      discordgo.ChannelSelectMenu,
+				CustomID:      setupChannelsID,
+				Placeholder:   "Channels to fix links in (none for all)",
+				MinValues:     &zero,
+				MaxValues:     25,
+				DefaultValues: channels,
+				ChannelTypes:  []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildForum, discordgo.ChannelTypeGuildMedia},
+			}}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.SelectMenu{
+				CustomID:    setupFixersID,
+				Placeholder: "Links to fix",
+				MinValues:   &zero,
+				MaxValues:   len(fixerOptions),
+				Options:     fixerOptions,
+			}}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.SelectMenu{
+				CustomID:    setupModeID,
+				Placeholder: "How to post fixed links",
+				Options:     modeOptions,
+			}}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.Button{
+				Label:    "Done",
+				Style:    discordgo.SuccessButton,
+				CustomID: setupDoneID,
+			}}},
+		},
+	}
+}
+
+// formatSetup summarises the settings the wizard manages.
+func formatSetup(cfg config.Guild) string {
+	channels := "all channels"
+	if len(cfg.Channels) > 0 {
+		mentions := make([]string, len(cfg.Channels))
+		for n, id := range cfg.Channels {
+			mentions[n] = "<#" + id + ">"
+		}
+		channels = strings.Join(mentions, ", ")
+	}
+
+	var enabled []string
+	for _, f := range setupFixers {
+		if cfg.FixerEnabled(f.Value) {
+			enabled = append(enabled, f.Label)
+		}
+	}
+	fixing := "nothing"
+	if len(enabled) > 0 {
+		fixing = strings.Join(enabled, ", ")
+	}
+
+	mode := repostMode(cfg)
+	for _, m := range setupModes {
+		if m.Value == mode {
+			mode = m.Label
+		}
+	}
+	if cfg.TriggerEmoji != "" {
+		mode = "Only when someone reacts with " + formatEmoji(cfg.TriggerEmoji)
+	}
+	return fmt.Sprintf("Channels: %s\nFixing: %s\nReposts: %s", channels, fixing, mode)
+}
+
+// repostMode returns the guild's repost mode with the default filled in.
+func repostMode(cfg config.Guild) string {
+	if cfg.RepostMode == "" {
+		return config.RepostMessage
+	}
+	return cfg.RepostMode
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// starboardConfigGroup defines the /config starboard subcommands.
+func starboardConfigGroup() *discordgo.ApplicationCommandOption {
+	minStars := 1.0
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "starboard",
+		Description: "Repost messages with enough ⭐ reactions to a starboard channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Choose the starboard channel and how many stars it takes",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Where starred messages are reposted",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "stars",
+						Description: fmt.Sprintf("How many ⭐ reactions it takes (default %d)", config.DefaultStarThreshold),
+						MinValue:    &minStars,
+						MaxValue:    config.MaxStarThreshold,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Turn the starboard off",
+			},
+		},
+	}
+}
+
+// handleStarboardConfig runs a /config starboard subcommand.
+func handleStarboardConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "channel":
+		opts := OptionMap(sub.Options)
+		cfg.StarboardChannel = opts["channel"].ChannelValue(nil).ID
+		cfg.StarThreshold = 0
+		if stars, ok := opts["stars"]; ok {
+			cfg.StarThreshold = int(stars.IntValue())
+		}
+	case "off":
+		cfg.StarboardChannel = ""
+		cfg.StarThreshold = 0
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	if cfg.StarboardChannel == "" {
+		RespondEphemeral(ctx, s, i, "Saved. The starboard is off.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, fmt.Sprintf("Saved. Messages with %d ⭐ reactions will be reposted in <#%s>.", cfg.Stars(), cfg.StarboardChannel))
+}
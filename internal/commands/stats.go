@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/locale"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/stats"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/timestamp"
+)
+
+// statsPlatformsPerPage is how many platforms one /stats page lists.
+const statsPlatformsPerPage = 15
+
+// NewStats builds the /stats command, which shows what the bot has done in
+// the guild since it started: a summary, then every platform it fixed links
+// from and every command used. st holds the locale counts are written in.
+func NewStats(st storage.Store, collector *stats.Collector, pager *Pager) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "stats",
+			Description:      "See how many links the bot fixed in this server",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+		},
+		Module: ModuleStats,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			if logging.Private(i.GuildID) {
+				RespondEphemeral(ctx, s, i, "This server is in privacy mode, so the bot doesn't keep stats.")
+				return
+			}
+			pager.Respond(ctx, s, i, statsPages(interactionLocale(st, i), collector.Guild(i.GuildID), collector.Leaderboard(i.GuildID), collector.Commands(i.GuildID)), discordgo.MessageFlagsEphemeral)
+		},
+	}
+}
+
+// statsPages lays a guild's stats out as embeds, with counts written the way
+// locale l does: a summary, then its platforms and their share of the links
+// fixed, then the commands used.
+func statsPages(l string, g stats.Guild, board stats.Leaderboard, commands []stats.Use) []*discordgo.MessageEmbed {
+	lastFix := "never"
+	if !g.LastFix.IsZero() {
+		lastFix = timestamp.Format(g.LastFix, timestamp.Relative)
+	}
+	pages := []*discordgo.MessageEmbed{{
+		Title: "Since the bot last started",
+		Description: strings.Join([]string{
+			fmt.Sprintf("Reposted %s fixing %s", pluralIn(l, g.Reposts, "message"), pluralIn(l, g.LinksFixed, "link")),
+			fmt.Sprintf("Pointed at earlier fixes %s", pluralIn(l, g.Duplicates, "time")),
+			"Last fix: " + lastFix,
+		}, "\n"),
+	}}
+
+	total := 0
+	for _, p := range board.Platforms {
+		total += p.Links
+	}
+	platforms := make([]string, len(board.Platforms))
+	for n, p := range board.Platforms {
+		platforms[n] = fmt.Sprintf("%s · %s (%s)", p.Name, pluralIn(l, p.Links, "link"), locale.Percent(l, float64(p.Links)/float64(total)))
+	}
+	for _, page := range paginate(platforms, statsPlatformsPerPage) {
+		pages = append(pages, &discordgo.MessageEmbed{Title: "Fixed links by platform", Description: strings.Join(page, "\n")})
+	}
+
+	used := make([]string, len(commands))
+	for n, c := range commands {
+		used[n] = fmt.Sprintf("`/%s` · %s", c.Name, pluralIn(l, c.Uses, "use"))
+	}
+	for _, page := range paginate(used, statsPlatformsPerPage) {
+		pages = append(pages, &discordgo.MessageEmbed{Title: "Commands used", Description: strings.Join(page, "\n")})
+	}
+	return pages
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/health"
+)
+
+// statusColors are the colors of the /status embed by the bot's worst state.
+var statusColors = map[health.State]int{
+	health.OK:       0x2ecc71,
+	health.Degraded: 0xf1c40f,
+	health.Down:     0xe74c3c,
+}
+
+// statusIcons mark each part of the bot in the /status embed by its state.
+var statusIcons = map[health.State]string{
+	health.OK:       "🟢",
+	health.Degraded: "🟡",
+	health.Down:     "🔴",
+}
+
+// NewStatus builds the /status command, which shows the bot's owner how each
+// part of the bot is doing right now, from the same checks as GET /healthz.
+// A nil monitor has nothing to check.
+func NewStatus(monitor *health.Monitor) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "status",
+			Description:      "Show how each part of the bot is doing right now",
+			Contexts:         anyContexts,
+			IntegrationTypes: anyInstall,
+		},
+		Module:    ModuleBot,
+		OwnerOnly: true,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			RespondEmbed(ctx, s, i, statusEmbed(monitor.Check(ctx), time.Now()))
+		},
+	}
+}
+
+// statusEmbed renders a health report, colored by its worst state, as of now.
+func statusEmbed(report health.Report, now time.Time) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     statusIcons[report.State] + " The bot is " + report.State.String(),
+		Color:     statusColors[report.State],
+		Timestamp: now.Format(time.RFC3339),
+	}
+	if len(report.Checks) == 0 {
+		embed.Description = "Nothing is checked here."
+	}
+	for _, c := range report.Checks {
+		detail := c.Detail
+		if detail == "" {
+			detail = c.State.String()
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: statusIcons[c.State] + " " + c.Name, Value: detail, Inline: true})
+	}
+	return embed
+}
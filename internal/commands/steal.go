@@ -0,0 +1,304 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Upload limits Discord puts on custom emoji and stickers.
+const (
+	maxEmojiSize   = 256 * 1024
+	maxStickerSize = 512 * 1024
+	// stickerSize is the width and height, in pixels, stickers are uploaded at.
+	stickerSize = 320
+)
+
+// maxSteal is how many emoji and stickers one use of the steal commands copies.
+const maxSteal = 5
+
+// emojiSizes are the sizes, in pixels, emoji are downloaded at, largest first,
+// until one fits in maxEmojiSize.
+var emojiSizes = []int{128, 96, 64, 48}
+
+// stealPermissions are the permissions that let the bot add emoji and stickers to a server.
+const stealPermissions = discordgo.PermissionManageGuildExpressions | discordgo.PermissionCreateGuildExpressions
+
+// customEmoji matches custom emoji in message content, like <:name:id> or <a:name:id>.
+var customEmoji = regexp.MustCompile(`<(a?):(\w{2,32}):(\d{17,20})>`)
+
+var (
+	// errTooLarge is returned when an emoji or sticker is over Discord's upload limit.
+	errTooLarge = errors.New("too large to upload")
+	// errLottie is returned for animated vector stickers, which only partnered servers may upload.
+	errLottie = errors.New("vector stickers can't be uploaded")
+)
+
+// stealable is a custom emoji or sticker found in a message.
+type stealable struct {
+	ID       string
+	Name     string
+	Animated bool
+	// Sticker is set for stickers, with the sticker's format.
+	Sticker discordgo.StickerFormat
+}
+
+// stealer downloads emoji and stickers from Discord's CDN and adds them to servers.
+type stealer struct {
+	client *http.Client
+	// cdn is the base URL of Discord's CDN, discordgo.EndpointCDN outside tests.
+	cdn string
+}
+
+// newStealer returns a stealer downloading with client, or a default client if nil.
+func newStealer(client *http.Client) *stealer {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &stealer{client: client, cdn: discordgo.EndpointCDN}
+}
+
+// NewSteal builds the /steal command, which adds custom emoji pasted into it
+// to the current server. It needs the Manage Expressions permission, both for
+// the member using it and the bot.
+func NewSteal(client *http.Client) Command {
+	st := newStealer(client)
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "steal",
+			Description:      "Add custom emoji from other servers to this one",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "emoji", Description: "The emoji to add", Required: true},
+			},
+		},
+		Module:      ModuleTools,
+		Permissions: discordgo.PermissionManageGuildExpressions,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			content := OptionMap(i.ApplicationCommandData().Options)["emoji"].StringValue()
+			st.handle(ctx, s, i, findStealable(&discordgo.Message{Content: content}))
+		},
+	}
+}
+
+// NewStealFromMessage builds the "Steal Emoji and Stickers" message
+// context-menu command, which adds the custom emoji and stickers in a message
+// to the current server.
+func NewStealFromMessage(client *http.Client) Command {
+	st := newStealer(client)
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Type:             discordgo.MessageApplicationCommand,
+			Name:             "Steal Emoji and Stickers",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+		},
+		Module:      ModuleTools,
+		Permissions: discordgo.PermissionManageGuildExpressions,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			data := i.ApplicationCommandData()
+			var target *discordgo.Message
+			if data.Resolved != nil {
+				target = data.Resolved.Messages[data.TargetID]
+			}
+			if target == nil {
+				RespondEphemeral(ctx, s, i, "Couldn't read that message.")
+				return
+			}
+			st.handle(ctx, s, i, findStealable(target))
+		},
+	}
+}
+
+// handle checks the bot may add emoji and stickers, then adds items to the
+// interaction's server and reports how each went.
+func (st *stealer) handle(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, items []stealable) {
+	if i.AppPermissions&stealPermissions == 0 {
+		RespondEphemeral(ctx, s, i, "I need the Manage Expressions permission to add emoji and stickers.")
+		return
+	}
+	if len(items) == 0 {
+		RespondEphemeral(ctx, s, i, "There are no custom emoji or stickers there.")
+		return
+	}
+
+	lines := make([]string, len(items))
+	for n, item := range items {
+		lines[n] = st.steal(ctx, s, i.GuildID, item)
+	}
+	respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+		Content:         strings.Join(lines, "\n"),
+		Flags:           discordgo.MessageFlagsEphemeral,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+// steal copies one emoji or sticker into a guild and describes the outcome.
+func (st *stealer) steal(ctx context.Context, s *discordgo.Session, guildID string, item stealable) string {
+	if item.Sticker != 0 {
+		data, contentType, err := st.downloadSticker(ctx, item)
+		if err == nil {
+			err = createSticker(ctx, s, guildID, item.Name, contentType, data)
+		}
+		if err != nil {
+			return fmt.Sprintf("Couldn't add the sticker **%s**: %s", item.Name, stealError(err))
+		}
+		return fmt.Sprintf("Added the sticker **%s**.", item.Name)
+	}
+
+	data, contentType, err := st.downloadEmoji(ctx, item)
+	var emoji *discordgo.Emoji
+	if err == nil {
+		emoji, err = s.GuildEmojiCreate(guildID, &discordgo.EmojiParams{
+			Name:  item.Name,
+			Image: "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data),
+		}, discordgo.WithContext(ctx))
+	}
+	if err != nil {
+		return fmt.Sprintf("Couldn't add :%s:: %s", item.Name, stealError(err))
+	}
+	return fmt.Sprintf("Added %s.", emoji.MessageFormat())
+}
+
+// findStealable returns the custom emoji and stickers in a message, up to maxSteal.
+func findStealable(m *discordgo.Message) []stealable {
+	var items []stealable
+	seen := make(map[string]bool)
+	for _, match := range customEmoji.FindAllStringSubmatch(m.Content, -1) {
+		if seen[match[3]] {
+			continue
+		}
+		seen[match[3]] = true
+		items = append(items, stealable{ID: match[3], Name: match[2], Animated: match[1] == "a"})
+	}
+	for _, sticker := range m.StickerItems {
+		items = append(items, stealable{ID: sticker.ID, Name: sticker.Name, Sticker: sticker.FormatType})
+	}
+	if len(items) > maxSteal {
+		items = items[:maxSteal]
+	}
+	return items
+}
+
+// downloadEmoji fetches an emoji at the largest of emojiSizes that fits in
+// maxEmojiSize, returning its image and content type.
+func (st *stealer) downloadEmoji(ctx context.Context, item stealable) ([]byte, string, error) {
+	ext, contentType := ".png", "image/png"
+	if item.Animated {
+		ext, contentType = ".gif", "image/gif"
+	}
+	for _, size := range emojiSizes {
+		data, err := st.download(ctx, fmt.Sprintf("%semojis/%s%s?size=%d&quality=lossless", st.cdn, item.ID, ext, size), maxEmojiSize)
+		if errors.Is(err, errTooLarge) {
+			continue
+		}
+		return data, contentType, err
+	}
+	return nil, "", errTooLarge
+}
+
+// downloadSticker fetches a sticker, returning its image and content type.
+func (st *stealer) downloadSticker(ctx context.Context, item stealable) ([]byte, string, error) {
+	ext, contentType := ".png", "image/png"
+	switch item.Sticker {
+	case discordgo.StickerFormatTypeLottie:
+		return nil, "", errLottie
+	case discordgo.StickerFormatTypeGIF:
+		ext, contentType = ".gif", "image/gif"
+	}
+	data, err := st.download(ctx, fmt.Sprintf("%sstickers/%s%s?size=%d", st.cdn, item.ID, ext, stickerSize), maxStickerSize)
+	return data, contentType, err
+}
+
+// download fetches link, failing with errTooLarge if it's over limit bytes.
+func (st *stealer) download(ctx context.Context, link string, limit int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := st.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", link, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		return nil, errTooLarge
+	}
+	return data, nil
+}
+
+// createSticker uploads a sticker to a guild. discordgo has no call for this,
+// since stickers are sent as a multipart form rather than JSON.
+func createSticker(ctx context.Context, s *discordgo.Session, guildID, name, contentType string, data []byte) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	// Stickers need a tag, the name of an emoji people type to be suggested it
+	if err := w.WriteField("name", name); err != nil {
+		return err
+	}
+	if err := w.WriteField("tags", name); err != nil {
+		return err
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="sticker.%s"`, strings.TrimPrefix(contentType, "image/")))
+	header.Set("Content-Type", contentType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	endpoint := discordgo.EndpointGuildStickers(guildID)
+	_, err = s.RequestRaw(http.MethodPost, endpoint, w.FormDataContentType(), body.Bytes(), endpoint, 0, discordgo.WithContext(ctx))
+	return err
+}
+
+// stealError explains why an emoji or sticker couldn't be added.
+func stealError(err error) string {
+	var restErr *discordgo.RESTError
+	switch {
+	case errors.Is(err, errTooLarge):
+		return "it's too large for Discord."
+	case errors.Is(err, errLottie):
+		return "only partnered servers can upload animated vector stickers."
+	case errors.As(err, &restErr) && restErr.Message != nil:
+		switch restErr.Message.Code {
+		case discordgo.ErrCodeMaximumNumberOfEmojisReached:
+			return "this server has no emoji slots left."
+		case discordgo.ErrCodeMaximumNumberOfAnimatedEmojisReached:
+			return "this server has no animated emoji slots left."
+		case discordgo.ErrCodeMaximumNumberOfStickersReached:
+			return "this server has no sticker slots left."
+		}
+		if restErr.Message.Message != "" {
+			return "Discord said " + restErr.Message.Message + "."
+		}
+	}
+	log.Println("Error stealing emoji or sticker:", err)
+	return "something went wrong, try again later."
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/templates"
+)
+
+// templateExample is what /config template set renders a new template with,
+// so admins can see how reposts will look.
+var templateExample = templates.Vars{
+	Links:   []string{"https://fixupx.com/user/status/1"},
+	Message: "look at this https://fixupx.com/user/status/1",
+}
+
+// templateConfigGroup defines the /config template subcommands.
+func templateConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "template",
+		Description: "Change the text reposts are posted with",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Set the repost text, using {user}, {name}, {links}, {message} and {channel}",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "template",
+						Description: "Like: 🔧 Fixed link from {user}: {links}",
+						Required:    true,
+						MaxLength:   templates.MaxLength,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Post reposts as just the fixed message again",
+			},
+		},
+	}
+}
+
+// handleTemplateConfig runs a /config template subcommand.
+func handleTemplateConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	cfg.RepostTemplate = ""
+	if sub.Name == "set" {
+		tmpl := OptionMap(sub.Options)["template"].StringValue()
+		if err := templates.Validate(tmpl); err != nil {
+			RespondEphemeral(ctx, s, i, "That template doesn't work: "+err.Error()+".")
+			return
+		}
+		cfg.RepostTemplate = tmpl
+	}
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	if cfg.RepostTemplate == "" {
+		RespondEphemeral(ctx, s, i, "Saved. Reposts are just the fixed message again.")
+		return
+	}
+
+	example := templateExample
+	example.UserID = interactionUser(i)
+	example.ChannelID = i.ChannelID
+	RespondEphemeral(ctx, s, i, "Saved. Reposts will look like:\n"+templates.Render(cfg.RepostTemplate, example))
+}
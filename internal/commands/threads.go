@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// NewThreads builds the /threads command, which has the bot archive the
+// "Fixed links" threads it starts in thread repost mode once they go quiet.
+func NewThreads(st storage.Store) Command {
+	minHours := 1.0
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "threads",
+			Description:      "Archive the bot's \"Fixed links\" threads once they go quiet",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "archive",
+					Description: "Archive threads after this long without messages",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "hours",
+							Description: "How many hours without messages, such as 24",
+							Required:    true,
+							MinValue:    &minHours,
+							MaxValue:    config.MaxThreadArchiveHours,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "summary",
+							Description: "Sum up what was fixed in a thread before archiving it",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "off",
+					Description: "Leave archiving to Discord, after a week without messages",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show when threads are archived",
+				},
+			},
+		},
+		Module:      ModuleSettings,
+		Permissions: manageGuild,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleThreads(ctx, s, i, st, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleThreads runs a /threads subcommand. Threads started before keep
+// being looked after under the new settings.
+func handleThreads(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "archive":
+		opts := OptionMap(sub.Options)
+		cfg.ThreadArchiveHours = int(opts["hours"].IntValue())
+		cfg.ThreadSummary = false
+		if opt, ok := opts["summary"]; ok {
+			cfg.ThreadSummary = opt.BoolValue()
+		}
+	case "off":
+		cfg.ThreadArchiveHours, cfg.ThreadSummary = 0, false
+	case "status":
+		RespondEphemeral(ctx, s, i, formatThreads(cfg))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatThreads(cfg))
+}
+
+// formatThreads describes when a guild's "Fixed links" threads are archived.
+func formatThreads(cfg config.Guild) string {
+	var text string
+	if cfg.ThreadArchiveHours == 0 {
+		text = "Threads are archived by Discord after a week without messages."
+	} else {
+		text = fmt.Sprintf("Threads are archived after %s without messages", plural(cfg.ThreadArchiveHours, "hour"))
+		if cfg.ThreadSummary {
+			text += ", with a summary of what was fixed in them"
+		}
+		text += "."
+	}
+	if cfg.RepostMode != config.RepostThread {
+		text += " Reposts only go in threads in thread mode, which `/setup` picks."
+	}
+	return text
+}
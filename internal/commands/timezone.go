@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/locale"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/timestamp"
+)
+
+// timezoneConfigGroup defines the /config timezone subcommands.
+func timezoneConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "timezone",
+		Description: "Pick the time zone the server's days are counted in and how counts and dates are written",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Count days in a time zone",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "zone",
+						Description: "A time zone name such as Europe/Berlin or America/New_York",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Count days in UTC again",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "locale",
+				Description: "Write counts and dates, such as in /stats, like a language does",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "locale",
+						Description: "A Discord locale such as de or en-GB, leave out for the server's language",
+					},
+				},
+			},
+		},
+	}
+}
+
+// handleTimezoneConfig runs a /config timezone subcommand.
+func handleTimezoneConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "set":
+		zone := OptionMap(sub.Options)["zone"].StringValue()
+		if _, err := config.LoadLocation(zone); err != nil || zone == "" {
+			RespondEphemeral(ctx, s, i, "I don't know that time zone. Use a name from the tz database, such as Europe/Berlin or America/New_York.")
+			return
+		}
+		cfg.Timezone = zone
+	case "reset":
+		cfg.Timezone = ""
+	case "locale":
+		cfg.Locale = ""
+		if opt, ok := OptionMap(sub.Options)["locale"]; ok {
+			cfg.Locale = strings.TrimSpace(opt.StringValue())
+			if !locale.Known(cfg.Locale) {
+				RespondEphemeral(ctx, s, i, "I don't know that locale. Use one of: "+strings.Join(locale.Locales(), ", ")+".")
+				return
+			}
+		}
+		if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+			log.Println("Error saving guild config:", err)
+			RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+			return
+		}
+		RespondEphemeral(ctx, s, i, "Saved. "+formatLocale(cfg, guildLocale(i), time.Now()))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatTimezone(cfg, time.Now()))
+}
+
+// formatLocale describes how the guild's counts and dates are written, with
+// preferred the server's language.
+func formatLocale(cfg config.Guild, preferred string, now time.Time) string {
+	l := cfg.LocaleOr(preferred)
+	from := "the server's language"
+	if cfg.Locale != "" {
+		from = "`" + cfg.Locale + "`"
+	}
+	return fmt.Sprintf("Counts and dates are written like %s: %s links on %s.", from, locale.Count(l, 12345), locale.Date(l, now))
+}
+
+// formatTimezone describes the guild's time zone and when its day starts.
+func formatTimezone(cfg config.Guild, now time.Time) string {
+	tomorrow := timestamp.StartOfDay(now, cfg.Location()).AddDate(0, 0, 1)
+	return "Days are counted in " + cfg.Location().String() + ", so the next one starts " + timestamp.Format(tomorrow, timestamp.Relative) + "."
+}
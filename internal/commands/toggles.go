@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// commandsConfigGroup defines the /config commands subcommands.
+func commandsConfigGroup() *discordgo.ApplicationCommandOption {
+	moduleChoices := make([]*discordgo.ApplicationCommandOptionChoice, len(config.Modules))
+	for n, name := range config.Modules {
+		moduleChoices[n] = &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name}
+	}
+	toggleOptions := []*discordgo.ApplicationCommandOption{
+		{Type: discordgo.ApplicationCommandOptionString, Name: "command", Description: "A command's name, like media or Fix Links"},
+		{Type: discordgo.ApplicationCommandOptionString, Name: "module", Description: "A feature, like the hello greeting", Choices: moduleChoices},
+	}
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "commands",
+		Description: "Turn commands and features off in this server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Turn a command or module off",
+				Options:     toggleOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "enable",
+				Description: "Turn a command or module back on",
+				Options:     toggleOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List what's turned off",
+			},
+		},
+	}
+}
+
+// handleCommandsConfig runs a /config commands subcommand.
+func handleCommandsConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+	if sub.Name == "list" {
+		RespondEphemeral(ctx, s, i, formatDisabled(cfg))
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	command, module := "", ""
+	if opt, ok := opts["command"]; ok {
+		command = strings.TrimPrefix(strings.TrimSpace(opt.StringValue()), "/")
+	}
+	if opt, ok := opts["module"]; ok {
+		module = opt.StringValue()
+	}
+	if command == "" && module == "" {
+		RespondEphemeral(ctx, s, i, "Pick a command or a module.")
+		return
+	}
+	// Turning these off would leave no way to turn things back on
+	if command == "config" || command == "resume" {
+		RespondEphemeral(ctx, s, i, "/"+command+" can't be turned off.")
+		return
+	}
+
+	remove := sub.Name == "enable"
+	if command != "" {
+		cfg.DisabledCommands = updateIDList(cfg.DisabledCommands, command, remove)
+	}
+	if module != "" {
+		cfg.DisabledModules = updateIDList(cfg.DisabledModules, module, remove)
+	}
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved.\n"+formatDisabled(cfg))
+}
+
+// formatDisabled lists the commands and modules a guild turned off.
+func formatDisabled(cfg config.Guild) string {
+	if len(cfg.DisabledCommands) == 0 && len(cfg.DisabledModules) == 0 {
+		return "Every command and module is on."
+	}
+	var lines []string
+	if len(cfg.DisabledCommands) > 0 {
+		lines = append(lines, fmt.Sprintf("Commands turned off: %s", strings.Join(cfg.DisabledCommands, ", ")))
+	}
+	if len(cfg.DisabledModules) > 0 {
+		lines = append(lines, fmt.Sprintf("Modules turned off: %s", strings.Join(cfg.DisabledModules, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,227 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/storage"
+)
+
+// maxImportSize caps the size of a settings file passed to /config import.
+const maxImportSize = 64 << 10
+
+// exportConfigCommand defines /config export.
+func exportConfigCommand() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "export",
+		Description: "Download this server's settings as a JSON file",
+	}
+}
+
+// importConfigCommand defines /config import.
+func importConfigCommand() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "import",
+		Description: "Replace this server's settings with a file from /config export",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionAttachment, Name: "file", Description: "The exported settings", Required: true},
+		},
+	}
+}
+
+// handleExportConfig runs /config export, replying privately with the settings as a file.
+func handleExportConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		log.Println("Error encoding guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't export this server's settings.")
+		return
+	}
+
+	respond(ctx, s, i, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+		Content: "Here are this server's settings. Use `/config import` to load them here or in another server.",
+		Files: []*discordgo.File{{
+			Name:        "config-" + i.GuildID + ".json",
+			ContentType: "application/json",
+			Reader:      bytes.NewReader(data),
+		}},
+		Flags: discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// handleImportConfig runs /config import. Channels and roles from another
+// server are dropped, since their IDs mean nothing here.
+func handleImportConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	data := i.ApplicationCommandData()
+	var file *discordgo.MessageAttachment
+	if id, ok := OptionMap(sub.Options)["file"].Value.(string); ok && data.Resolved != nil {
+		file = data.Resolved.Attachments[id]
+	}
+	if file == nil {
+		RespondEphemeral(ctx, s, i, "Couldn't read that file.")
+		return
+	}
+	if file.Size > maxImportSize {
+		RespondEphemeral(ctx, s, i, "That file is too big to be exported settings.")
+		return
+	}
+
+	body, err := download(ctx, file.URL)
+	if err != nil {
+		log.Println("Error downloading imported config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't download that file, try again later.")
+		return
+	}
+	cfg, err := parseImportedConfig(body)
+	if err != nil {
+		RespondEphemeral(ctx, s, i, "Settings not imported: "+err.Error())
+		return
+	}
+
+	channels, err := s.GuildChannels(i.GuildID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error listing guild channels:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't check this server's channels, try again later.")
+		return
+	}
+	roles, err := s.GuildRoles(i.GuildID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error listing guild roles:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't check this server's roles, try again later.")
+		return
+	}
+	dropped := dropForeignIDs(&cfg, channelIDs(channels), roleIDs(roles))
+	// The welcome role is given to anyone who joins, so it takes the same
+	// checks as /welcome role
+	var note string
+	if cfg.WelcomeRole != "" {
+		_, problem := offerableRole(i, roles, cfg.WelcomeRole)
+		if problem != "" || i.Member == nil || i.Member.Permissions&discordgo.PermissionManageRoles == 0 || !belowBot(s, i.GuildID, roles, cfg.WelcomeRole) {
+			cfg.WelcomeRole = ""
+			note = " Left out the welcome role, which you or the bot can't hand out."
+		}
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	if dropped > 0 {
+		RespondEphemeral(ctx, s, i, fmt.Sprintf("Imported. Left out %d channels and roles that aren't in this server.", dropped)+note)
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Imported."+note)
+}
+
+// download fetches an attachment, reading at most maxImportSize bytes.
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading attachment: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxImportSize+1))
+}
+
+// parseImportedConfig decodes and validates settings from /config export.
+func parseImportedConfig(data []byte) (config.Guild, error) {
+	var cfg config.Guild
+	if len(data) > maxImportSize {
+		return cfg, errors.New("the file is too big")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, errors.New("the file isn't exported settings")
+	}
+	return cfg, fixers.ValidateGuild(cfg)
+}
+
+// dropForeignIDs removes channels and roles that aren't in the given sets from
+// cfg and returns how many it removed.
+func dropForeignIDs(cfg *config.Guild, channels, roles map[string]bool) int {
+	before := len(cfg.Channels) + len(cfg.IgnoredRoles) + len(cfg.AdminRoles)
+	cfg.Channels = slices.DeleteFunc(cfg.Channels, func(id string) bool { return !channels[id] })
+	cfg.IgnoredRoles = slices.DeleteFunc(cfg.IgnoredRoles, func(id string) bool { return !roles[id] })
+	cfg.AdminRoles = slices.DeleteFunc(cfg.AdminRoles, func(id string) bool { return !roles[id] })
+	dropped := before - len(cfg.Channels) - len(cfg.IgnoredRoles) - len(cfg.AdminRoles)
+	for feature, ids := range cfg.FeatureRoles {
+		kept := slices.DeleteFunc(ids, func(id string) bool { return !roles[id] })
+		dropped += len(ids) - len(kept)
+		if len(kept) == 0 {
+			delete(cfg.FeatureRoles, feature)
+		} else {
+			cfg.FeatureRoles[feature] = kept
+		}
+	}
+	if cfg.DigestChannel != "" && !channels[cfg.DigestChannel] {
+		cfg.DigestChannel = ""
+		dropped++
+	}
+	if cfg.StarboardChannel != "" && !channels[cfg.StarboardChannel] {
+		cfg.StarboardChannel = ""
+		dropped++
+	}
+	if cfg.VoiceChannel != "" && !channels[cfg.VoiceChannel] {
+		cfg.VoiceChannel = ""
+		dropped++
+	}
+	if cfg.AuditChannel != "" && !channels[cfg.AuditChannel] {
+		cfg.AuditChannel = ""
+		dropped++
+	}
+	if cfg.WelcomeChannel != "" && !channels[cfg.WelcomeChannel] {
+		cfg.WelcomeChannel = ""
+		dropped++
+	}
+	if cfg.WelcomeRole != "" && !roles[cfg.WelcomeRole] {
+		cfg.WelcomeRole = ""
+		dropped++
+	}
+	return dropped
+}
+
+// channelIDs returns the set of IDs of channels.
+func channelIDs(channels []*discordgo.Channel) map[string]bool {
+	ids := make(map[string]bool, len(channels))
+	for _, c := range channels {
+		ids[c.ID] = true
+	}
+	return ids
+}
+
+// roleIDs returns the set of IDs of roles.
+func roleIDs(roles []*discordgo.Role) map[string]bool {
+	ids := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		ids[r.ID] = true
+	}
+	return ids
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/trends"
+)
+
+// trendsShown is how many domains and tweets /trends lists.
+const trendsShown = 10
+
+// NewTrends builds the /trends command, which shows the domains and tweets
+// shared most in the guild over the last week, as counted by counter.
+func NewTrends(st storage.Store, counter *trends.Counter) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "trends",
+			Description:      "See the sites and tweets shared most in this server this week",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+		},
+		Module: ModuleStats,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			if logging.Private(i.GuildID) {
+				RespondEphemeral(ctx, s, i, "This server is in privacy mode, so the bot doesn't keep track of what's shared.")
+				return
+			}
+			cfg, err := config.LoadGuild(st, i.GuildID)
+			if err != nil {
+				log.Println("Error loading guild config:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+				return
+			}
+			top, err := counter.Top(i.GuildID, trendsShown, time.Now(), cfg.Location())
+			if err != nil {
+				log.Println("Error loading trends:", err)
+				RespondEphemeral(ctx, s, i, "Couldn't load what's been shared, try again later.")
+				return
+			}
+			RespondEmbed(ctx, s, i, trendsEmbed(top))
+		},
+	}
+}
+
+// trendsEmbed lays out a guild's most shared domains and tweets.
+func trendsEmbed(top trends.Trends) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{Title: fmt.Sprintf("Shared most in the last %d days", trends.Days)}
+	if len(top.Domains) == 0 {
+		embed.Description = "Nothing has been shared yet."
+		return embed
+	}
+
+	domains := make([]string, len(top.Domains))
+	for n, d := range top.Domains {
+		domains[n] = fmt.Sprintf("%d. `%s` · %s", n+1, d.Name, plural(d.Shares, "link"))
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Sites", Value: strings.Join(domains, "\n")})
+	if len(top.Tweets) > 0 {
+		tweets := make([]string, len(top.Tweets))
+		for n, t := range top.Tweets {
+			// Angle brackets keep Discord from embedding every tweet
+			tweets[n] = fmt.Sprintf("%d. <https://x.com/i/status/%s> · shared %s", n+1, t.Name, plural(t.Shares, "time"))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Tweets", Value: strings.Join(tweets, "\n")})
+	}
+	return embed
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// maxEmojiLength is how long, in bytes, a standard emoji can be. The longest,
+// such as family emoji joined from several people, are around 30.
+const maxEmojiLength = 64
+
+// triggerConfigGroup defines the /config trigger subcommands.
+func triggerConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "trigger",
+		Description: "Only fix links when someone reacts to a message with an emoji",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Fix links only when someone reacts with this emoji",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "emoji",
+						Description: "The emoji, such as 🔧 or one of the server's own",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Fix links on their own again",
+			},
+		},
+	}
+}
+
+// handleTriggerConfig runs a /config trigger subcommand.
+func handleTriggerConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "set":
+		emoji, ok := parseTriggerEmoji(OptionMap(sub.Options)["emoji"].StringValue())
+		if !ok {
+			RespondEphemeral(ctx, s, i, "That isn't an emoji. Pick one from the emoji picker, such as 🔧.")
+			return
+		}
+		cfg.TriggerEmoji = emoji
+	case "off":
+		cfg.TriggerEmoji = ""
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatTrigger(cfg))
+}
+
+// parseTriggerEmoji returns the emoji in s the way Discord names it in
+// reactions: "name:id" for custom emoji, or the emoji itself. It reports false
+// if s isn't a single emoji.
+func parseTriggerEmoji(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if match := customEmoji.FindStringSubmatch(s); match != nil && match[0] == s {
+		return match[2] + ":" + match[3], true
+	}
+	if s == "" || len(s) > maxEmojiLength {
+		return "", false
+	}
+	// Standard emoji are symbols, joined by modifiers and selectors
+	for _, r := range s {
+		if r < utf8.RuneSelf || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsSpace(r) {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+// formatEmoji returns how an emoji named like parseTriggerEmoji's results is
+// written in a message.
+func formatEmoji(name string) string {
+	if strings.Contains(name, ":") {
+		return "<:" + name + ">"
+	}
+	return name
+}
+
+// formatTrigger describes when the guild's links are fixed.
+func formatTrigger(cfg config.Guild) string {
+	if cfg.TriggerEmoji == "" {
+		return "Links are fixed as soon as they're posted."
+	}
+	return "Links are only fixed when someone reacts to a message with " + formatEmoji(cfg.TriggerEmoji) + "."
+}
@@ -0,0 +1,280 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/domains"
+	"go-discord-bot/internal/storage"
+)
+
+// twitterConfigGroup defines the /config twitter subcommands.
+func twitterConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "twitter",
+		Description: "How Twitter/X links are fixed",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "site",
+				Description: "Choose the site fixed Twitter/X links point to",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "site",
+						Description: "Where fixed links point",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "fxtwitter", Value: config.TwitterFxTwitter},
+							{Name: "Nitter", Value: config.TwitterNitter},
+							{Name: "Custom domain", Value: config.TwitterCustom},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "domain",
+						Description: "For a custom domain, the site, like fixvx.com",
+						MaxLength:   100,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "translate",
+				Description: "Have fxtwitter translate tweets in fixed links",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "language",
+						Description: "The language to translate to",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Off", Value: translateOff},
+							{Name: "English", Value: "en"},
+							{Name: "Japanese", Value: "ja"},
+							{Name: "Korean", Value: "ko"},
+							{Name: "Chinese", Value: "zh"},
+							{Name: "Spanish", Value: "es"},
+							{Name: "Portuguese", Value: "pt"},
+							{Name: "French", Value: "fr"},
+							{Name: "German", Value: "de"},
+							{Name: "Italian", Value: "it"},
+							{Name: "Russian", Value: "ru"},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "context",
+				Description: "Show quoted and parent tweets under fixed tweets",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "depth",
+						Description: "How many quoted or parent tweets to show, 0 for none",
+						Required:    true,
+						MinValue:    new(float64),
+						MaxValue:    config.MaxContextDepth,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "mirror",
+				Description: "Upload copies of fixed tweets' photos and videos, so they outlive the tweet",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Whether to upload copies",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "proxies",
+				Description: "Swap fxtwitter and vxtwitter links members post while one of them is down",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "swap",
+						Description: "Whether to swap them",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "gallery",
+				Description: "What to do when a tweet's embed shows only some of its photos",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "What the bot does",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Nothing", Value: galleryOff},
+							{Name: "Fix the link anyway", Value: config.GalleryRepost},
+							{Name: "Attach the other photos", Value: config.GalleryAttach},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "profiles",
+				Description: "What to do with links to Twitter/X profiles",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "What the bot does",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Leave them alone", Value: profilesIgnore},
+							{Name: "Reply with an embed of the profile", Value: config.ProfileEmbed},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// profilesIgnore is the /config twitter profiles choice that leaves profile links alone.
+const profilesIgnore = "ignore"
+
+// galleryOff is the /config twitter gallery choice that leaves galleries alone.
+const galleryOff = "off"
+
+// translateOff is the /config twitter translate choice that turns translation off.
+const translateOff = "off"
+
+// handleTwitterConfig runs a /config twitter subcommand.
+// A custom site is checked to serve tweet embeds before it's saved.
+func handleTwitterConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sites *domains.Checker, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "site":
+		opts := OptionMap(sub.Options)
+		cfg.TwitterSite, cfg.TwitterDomain = opts["site"].StringValue(), ""
+		if cfg.TwitterSite != config.TwitterCustom {
+			break
+		}
+		if _, ok := opts["domain"]; !ok {
+			RespondEphemeral(ctx, s, i, "Pick the site with the `domain` option, like `fixvx.com`.")
+			return
+		}
+		domain, err := domains.Normalize(opts["domain"].StringValue())
+		if err != nil {
+			RespondEphemeral(ctx, s, i, "Can't use that site: "+err.Error()+".")
+			return
+		}
+		if err := sites.Verify(ctx, domain); err != nil {
+			RespondEphemeral(ctx, s, i, fmt.Sprintf("Not saved, `%s` doesn't work as a fixing site: %v. Check the domain, or try again later if the site is down.", domain, err))
+			return
+		}
+		cfg.TwitterDomain = domain
+	case "translate":
+		cfg.TranslateTo = OptionMap(sub.Options)["language"].StringValue()
+		if cfg.TranslateTo == translateOff {
+			cfg.TranslateTo = ""
+		}
+	case "context":
+		cfg.ContextDepth = int(OptionMap(sub.Options)["depth"].IntValue())
+	case "mirror":
+		cfg.MirrorMedia = OptionMap(sub.Options)["enabled"].BoolValue()
+	case "proxies":
+		cfg.SwapProxies = OptionMap(sub.Options)["swap"].BoolValue()
+	case "profiles":
+		cfg.Profiles = OptionMap(sub.Options)["mode"].StringValue()
+		if cfg.Profiles == profilesIgnore {
+			cfg.Profiles = ""
+		}
+	case "gallery":
+		cfg.Galleries = OptionMap(sub.Options)["mode"].StringValue()
+		if cfg.Galleries == galleryOff {
+			cfg.Galleries = ""
+		}
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	if sub.Name == "context" {
+		if cfg.ContextDepth == 0 {
+			RespondEphemeral(ctx, s, i, "Saved. Fixed tweets won't show quoted or parent tweets.")
+			return
+		}
+		RespondEphemeral(ctx, s, i, fmt.Sprintf("Saved. Fixed tweets will show up to %d quoted or parent tweets.", cfg.ContextDepth))
+		return
+	}
+	if sub.Name == "mirror" {
+		if cfg.MirrorMedia {
+			RespondEphemeral(ctx, s, i, "Saved. Reposts will carry copies of the tweet's photos and videos, up to 10 MB a message, so they stay if the tweet is deleted.")
+			return
+		}
+		RespondEphemeral(ctx, s, i, "Saved. Reposts won't carry copies of the tweet's media.")
+		return
+	}
+	if sub.Name == "proxies" {
+		if cfg.SwapProxies {
+			RespondEphemeral(ctx, s, i, "Saved. While fxtwitter or fixupx is down, links members post to it will be fixed to vxtwitter or fixvx, and the other way around.")
+			return
+		}
+		RespondEphemeral(ctx, s, i, "Saved. Links members post to fixing sites are left alone, even while the site is down.")
+		return
+	}
+	if sub.Name == "profiles" {
+		if cfg.Profiles == config.ProfileEmbed {
+			RespondEphemeral(ctx, s, i, "Saved. Links to Twitter/X profiles will get a reply with the profile's name, bio and follower counts.")
+			return
+		}
+		RespondEphemeral(ctx, s, i, "Saved. Links to Twitter/X profiles are left alone.")
+		return
+	}
+	if sub.Name == "gallery" {
+		switch cfg.Galleries {
+		case config.GalleryRepost:
+			RespondEphemeral(ctx, s, i, "Saved. Tweets whose embed shows only some of their photos will be fixed anyway.")
+		case config.GalleryAttach:
+			RespondEphemeral(ctx, s, i, "Saved. When a tweet's embed shows only some of its photos, the bot will reply with the others, up to 10 MB a message.")
+		default:
+			RespondEphemeral(ctx, s, i, "Saved. Tweets whose embed works are left alone, however many photos it shows.")
+		}
+		return
+	}
+	if sub.Name == "translate" {
+		if cfg.TranslateTo == "" {
+			RespondEphemeral(ctx, s, i, "Saved. Fixed tweets won't be translated; react to a repost with a flag to translate it.")
+			return
+		}
+		RespondEphemeral(ctx, s, i, "Saved. Fixed tweets will be translated to `"+cfg.TranslateTo+"`.")
+		return
+	}
+	if cfg.TwitterSite == config.TwitterNitter {
+		RespondEphemeral(ctx, s, i, "Saved. Twitter/X links will point to Nitter, or to fxtwitter while no Nitter instance is available.")
+		return
+	}
+	if cfg.TwitterSite == config.TwitterCustom {
+		RespondEphemeral(ctx, s, i, "Saved. Twitter/X links will point to `"+cfg.TwitterDomain+"`, which served the test tweet's embed. If it stops working they'll point to fxtwitter until it's back, and the audit channel hears about it.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. Twitter/X links will point to fxtwitter.")
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// unshortenConfigGroup defines the /config unshorten subcommands.
+func unshortenConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "unshorten",
+		Description: "Show where shortened links such as bit.ly go",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "on",
+				Description: "Reply to shortened links with where they go",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Don't expand shortened links",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show whether shortened links are expanded",
+			},
+		},
+	}
+}
+
+// handleUnshortenConfig runs a /config unshorten subcommand.
+func handleUnshortenConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	switch sub.Name {
+	case "on":
+		cfg.Unshorten = true
+	case "off":
+		cfg.Unshorten = false
+	case "status":
+		RespondEphemeral(ctx, s, i, formatUnshorten(cfg.Unshorten))
+		return
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, "Saved. "+formatUnshorten(cfg.Unshorten))
+}
+
+// formatUnshorten describes the unshorten setting.
+func formatUnshorten(enabled bool) string {
+	if enabled {
+		return "Unshortening is on: shortened links get a reply showing where they go."
+	}
+	return "Unshortening is off."
+}
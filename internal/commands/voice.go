@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// voiceConfigGroup defines the /config voice subcommands.
+func voiceConfigGroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "voice",
+		Description: "Play a notice in a voice channel when a link is fixed (experimental)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Choose the voice channel notices are played in",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "The voice channel to join",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Stop playing notices",
+			},
+		},
+	}
+}
+
+// handleVoiceConfig runs a /config voice subcommand.
+func handleVoiceConfig(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	cfg.VoiceChannel = ""
+	if sub.Name == "channel" {
+		cfg.VoiceChannel = OptionMap(sub.Options)["channel"].ChannelValue(nil).ID
+	}
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	if cfg.VoiceChannel == "" {
+		RespondEphemeral(ctx, s, i, "Saved. No more voice notices.")
+		return
+	}
+	RespondEphemeral(ctx, s, i, fmt.Sprintf("Saved. The bot will join <#%s> to play a notice when it fixes a link, if the bot's host has set up a sound. It needs the Connect and Speak permissions there.", cfg.VoiceChannel))
+}
@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/templates"
+)
+
+// NewWelcome builds the /welcome command, which greets members who join and
+// can give them a role.
+func NewWelcome(st storage.Store) Command {
+	return Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:             "welcome",
+			Description:      "Greet members who join this server",
+			Contexts:         guildContexts,
+			IntegrationTypes: guildInstall,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "channel",
+					Description: "Greet members who join in a channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Where members are greeted",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "message",
+							Description: "Like: " + config.DefaultWelcomeMessage + " Use {user}, {name}, {server} and {count}",
+							MaxLength:   templates.MaxWelcomeLength,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "role",
+					Description: "Give members who join a role, or stop giving one",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "The role, leave out to stop giving one"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "off",
+					Description: "Stop greeting members who join",
+				},
+			},
+		},
+		Module: ModuleSettings,
+		// Giving members a role needs the same permission as handing roles out with /rolemenu
+		Permissions: manageGuild | discordgo.PermissionManageRoles,
+		Handler: func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if i.GuildID == "" {
+				RespondEphemeral(ctx, s, i, "This command can only be used in a server.")
+				return
+			}
+			handleWelcome(ctx, s, i, st, i.ApplicationCommandData().Options[0])
+		},
+	}
+}
+
+// handleWelcome runs a /welcome subcommand.
+func handleWelcome(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, st storage.Store, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	cfg, err := config.LoadGuild(st, i.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't load this server's settings, try again later.")
+		return
+	}
+
+	opts := OptionMap(sub.Options)
+	switch sub.Name {
+	case "channel":
+		message := ""
+		if opt, ok := opts["message"]; ok {
+			message = strings.ReplaceAll(opt.StringValue(), `\n`, "\n")
+		}
+		if err := templates.ValidateWelcome(message); err != nil {
+			RespondEphemeral(ctx, s, i, "That message doesn't work: "+err.Error()+".")
+			return
+		}
+		cfg.WelcomeChannel = opts["channel"].ChannelValue(nil).ID
+		cfg.WelcomeMessage = message
+	case "role":
+		cfg.WelcomeRole = ""
+		if opt, ok := opts["role"]; ok {
+			// Roles trusted with the bot's settings don't get to hand roles out
+			if i.Member.Permissions&discordgo.PermissionManageRoles == 0 {
+				RespondEphemeral(ctx, s, i, "Giving members a role needs the Manage Roles permission.")
+				return
+			}
+			guildRoles, ok := loadGuildRoles(ctx, s, i)
+			if !ok {
+				return
+			}
+			role, problem := offerableRole(i, guildRoles, opt.Value.(string))
+			if problem == "" && !belowBot(s, i.GuildID, guildRoles, role.ID) {
+				problem = fmt.Sprintf("%s is at or above my highest role, so I can't give it out.", role.Name)
+			}
+			if problem != "" {
+				RespondEphemeral(ctx, s, i, problem)
+				return
+			}
+			cfg.WelcomeRole = role.ID
+		}
+	case "off":
+		cfg.WelcomeChannel = ""
+		cfg.WelcomeMessage = ""
+	}
+
+	if err := config.SaveGuild(st, i.GuildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		RespondEphemeral(ctx, s, i, "Couldn't save this server's settings, try again later.")
+		return
+	}
+	switch {
+	case sub.Name == "role" && cfg.WelcomeRole == "":
+		RespondEphemeral(ctx, s, i, "Saved. Members who join won't be given a role.")
+	case sub.Name == "role":
+		RespondEphemeral(ctx, s, i, fmt.Sprintf("Saved. Members who join will be given <@&%s>. I need Manage Roles to give it.", cfg.WelcomeRole))
+	case cfg.WelcomeChannel == "":
+		RespondEphemeral(ctx, s, i, "Saved. Members who join won't be greeted.")
+	default:
+		example := templates.Vars{UserID: interactionUser(i), Server: "this server"}
+		if i.Member != nil {
+			example.Name = i.Member.DisplayName()
+		}
+		if g, err := s.State.Guild(i.GuildID); err == nil {
+			example.Server = g.Name
+			example.Count = g.MemberCount
+		}
+		RespondEphemeral(ctx, s, i, fmt.Sprintf("Saved. Members who join will be greeted in <#%s> like:\n%s", cfg.WelcomeChannel, templates.Render(cfg.Welcome(), example)))
+	}
+}
+
+// formatWelcome describes the welcome settings.
+func formatWelcome(cfg config.Guild) string {
+	var parts []string
+	if cfg.WelcomeChannel != "" {
+		parts = append(parts, fmt.Sprintf("greeting in <#%s> with `%s`", cfg.WelcomeChannel, cfg.Welcome()))
+	}
+	if cfg.WelcomeRole != "" {
+		parts = append(parts, fmt.Sprintf("giving <@&%s>", cfg.WelcomeRole))
+	}
+	if len(parts) == 0 {
+		return "Welcome: Off"
+	}
+	return "Welcome: " + strings.Join(parts, ", ")
+}
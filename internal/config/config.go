@@ -0,0 +1,544 @@
+// Package config loads the bot's process settings and per-guild settings.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"go-discord-bot/internal/chaos"
+	"go-discord-bot/internal/eventqueue"
+	"go-discord-bot/internal/intents"
+	"go-discord-bot/internal/notify"
+	"go-discord-bot/internal/proxy"
+	"go-discord-bot/internal/syndication"
+)
+
+// Config holds the process-wide settings read from the environment.
+type Config struct {
+	// Env names the profile the bot runs as, such as "dev" or "staging",
+	// whose settings in .env.<Env> are read over those in .env, empty for none.
+	Env string
+	// Chaos is the chaos.Profile that makes Discord's API slow and unreliable
+	// on purpose, for testing how the bot copes. It needs a profile other than
+	// prod in Env, and is empty to talk to Discord as it is.
+	Chaos string
+	// Guilds limits the bot to these guilds, such as a test server, so a
+	// profile can't act on real ones. Empty allows every guild. Unlisted is
+	// what the bot does in other guilds: "ignore" them, or "leave" them after
+	// saying why, sparing a small self-hosted bot load it wasn't set up for.
+	Guilds   []string
+	Unlisted string
+	// Token is the Discord bot token.
+	Token string
+	// TokenFile is a file holding Token, such as a mounted secret, read in
+	// place of DISCORD_BOT_TOKEN so the token can be rotated by replacing it.
+	TokenFile string
+	// ExtraBots are more bot identities run by the same process, such as a
+	// staging bot, each with its own sessions and handlers.
+	ExtraBots []Bot
+	// DataFile is where the storage layer keeps its state.
+	DataFile string
+	// WorkerCount and WorkerQueueSize size the message processing pool.
+	WorkerCount     int
+	WorkerQueueSize int
+	// EventQueueSize is how many gateway events wait to be handled before
+	// EventQueuePolicy, "oldest" or "newest", picks which ones are dropped.
+	EventQueueSize   int
+	EventQueuePolicy string
+	// TwitchClipProxy is the host Twitch clip links are rewritten to.
+	TwitchClipProxy string
+	// OperationTimeout bounds a single message fix or command invocation.
+	OperationTimeout time.Duration
+	// ShutdownTimeout is how long queued work may keep running after a shutdown signal.
+	ShutdownTimeout time.Duration
+	// ResumeWindow is how long after a shutdown the shards' saved gateway
+	// sessions are resumed instead of identifying again, 0 to always identify.
+	ResumeWindow time.Duration
+	// FlagsFile is the JSON file holding hot-reloadable feature flags.
+	FlagsFile string
+	// FlagsPollInterval is how often FlagsFile is checked for changes.
+	FlagsPollInterval time.Duration
+	// ShardCount is the total number of shards, 0 to use Discord's recommendation.
+	ShardCount int
+	// ShardIDs lists the shards this process runs, empty for all of them.
+	ShardIDs []int
+	// Intents names the gateway intents the bots connect with, such as
+	// "guild_messages", empty for the ones the enabled features need.
+	Intents []string
+	// EmbedThreshold is the score Discord's embed of a tweet needs for the link
+	// to be left alone, 0 for the fixer's default. Raise it to fix more links.
+	EmbedThreshold int
+	// DuplicateWindow is how long a fixed tweet is remembered so reposting it
+	// elsewhere in the guild links to the earlier fix, 0 to always repost.
+	DuplicateWindow time.Duration
+	// FloodLimit is how many messages a channel may receive in a second before
+	// the bot stops replying there for FloodCooldown. 0 disables flood protection.
+	FloodLimit    int
+	FloodCooldown time.Duration
+	// AbuseLimit is how many times a user may trigger the bot in a guild
+	// within a minute, with commands or links to fix, before the bot ignores
+	// them for AbuseMute. 0 disables abuse detection.
+	AbuseLimit int
+	AbuseMute  time.Duration
+	// RedisURL, like redis://:password@localhost:6379/0, is a Redis server
+	// replicas of the bot share, so only one of them fixes each message and
+	// they count abuse together, with keys prefixed with RedisPrefix. Replicas
+	// coordinate only while DuplicateWindow isn't 0. Empty runs alone.
+	RedisURL    string
+	RedisPrefix string
+	// DailyLookups and DailyMirrors are how many outside lookups, such as
+	// tweets and previews, and how many mirrored messages each guild gets a
+	// day; past them its links are fixed without the extras. 0 is unlimited.
+	DailyLookups int
+	DailyMirrors int
+	// NitterInstances are the Nitter base URLs guilds preferring Nitter link to,
+	// in order of preference. NitterCheckInterval is how often they're health checked.
+	NitterInstances     []string
+	NitterCheckInterval time.Duration
+	// CleanupInterval is how often stale tracking data is pruned, 0 to leave it
+	// to the trackers' own occasional sweeps.
+	CleanupInterval time.Duration
+	// WatchdogInterval is how often resource counts are checked for leaks, 0 to
+	// turn the watchdog off. It warns when there are more than MaxGoroutines
+	// goroutines, MaxTimers timers waiting in one bot, or MaxCacheSize entries
+	// in one cache.
+	WatchdogInterval time.Duration
+	MaxGoroutines    int
+	MaxTimers        int
+	MaxCacheSize     int
+	// OperatorChannel is a Discord channel the main bot posts warnings meant
+	// for whoever runs it to, such as the watchdog's, empty for logs only.
+	// OperatorSeverity is the least severe alert posted there: "info",
+	// "warning" or "error".
+	OperatorChannel  string
+	OperatorSeverity string
+	// AlertWebhook is a URL sent alerts of WebhookSeverity or above as
+	// JSON, empty for none.
+	AlertWebhook    string
+	WebhookSeverity string
+	// AlertEmailTo are emailed alerts of EmailSeverity or above from
+	// AlertEmailFrom, through the SMTP server SMTPAddr logged in to with
+	// SMTPUsername and SMTPPassword. Empty for no email.
+	AlertEmailTo   []string
+	AlertEmailFrom string
+	EmailSeverity  string
+	SMTPAddr       string
+	SMTPUsername   string
+	SMTPPassword   string
+	// DecisionLogSize is how many recent messages each bot remembers the
+	// handling of, for "Explain Fix" and the admin API, 0 to remember none.
+	DecisionLogSize int
+	// TweetFallbackURL is the API the text of fixed tweets Discord didn't embed
+	// is fetched from, to post under the repost while the fixing proxies are
+	// down, or "none" to post nothing.
+	TweetFallbackURL string
+	// SteamCountry is the two-letter country whose prices Steam games are
+	// shown with.
+	SteamCountry string
+	// SongLinkKey is a song.link (Odesli) API key, empty to look songs up
+	// within the API's rate limit for callers without one.
+	SongLinkKey string
+	// DeletedRetention is how long copies of the messages the bot deletes are
+	// kept for moderators to restore, 0 to delete them for good.
+	DeletedRetention time.Duration
+	// UpdateCheckInterval is how often UpdateURL is asked for the latest
+	// release, 0 to never check. The bot's owner is sent a DM about each newer one.
+	UpdateCheckInterval time.Duration
+	UpdateURL           string
+	// TelemetryURL is where anonymous usage counts, such as links fixed per
+	// platform and how many guilds the bot is in, are sent every
+	// TelemetryInterval, empty to send nothing. It's empty unless set.
+	TelemetryURL      string
+	TelemetryInterval time.Duration
+	// Proxy is the proxy outbound HTTP other than Discord's goes through, such
+	// as "http://proxy:3128", empty to connect directly. TwitterProxy and
+	// LinksProxy override it for Twitter's APIs and media and for links posted
+	// in chat, "none" to connect directly; DiscordProxy is used for Discord.
+	Proxy        string
+	DiscordProxy string
+	TwitterProxy string
+	LinksProxy   string
+	// HTTPRequestsPerHost caps how many outbound requests to any one host run
+	// at once; the rest wait their turn.
+	HTTPRequestsPerHost int
+	// DashboardAddr is the address the web dashboard listens on, such as ":8080",
+	// empty to turn it off. DashboardURL is its public address, and ClientID and
+	// ClientSecret are the Discord application's OAuth2 credentials.
+	DashboardAddr string
+	DashboardURL  string
+	ClientID      string
+	ClientSecret  string
+	// APIAddr is the address the admin API listens on, empty to turn it off.
+	// Requests must carry APIToken as a bearer token.
+	APIAddr  string
+	APIToken string
+	// PhishingListFile is a local blocklist of phishing and malware domains,
+	// empty for none. SafeBrowsingKey is a Google Safe Browsing API key, empty
+	// to check links against the local list only.
+	PhishingListFile string
+	SafeBrowsingKey  string
+	// VoiceSoundFile is a DCA file played in voice channels when a link is
+	// fixed. VoiceTTSCommand speaks a notice instead: it's run with the text as
+	// its last argument and must write DCA to standard output. Voice notices
+	// are off when both are empty.
+	VoiceSoundFile  string
+	VoiceTTSCommand string
+	// TraceEndpoint is the OTLP/HTTP collector traces of message handling are
+	// exported to, such as "http://localhost:4318", empty to turn tracing off.
+	// TraceService names the bot in them.
+	TraceEndpoint string
+	TraceService  string
+	// LogFile is a file logs are written to as well as the console, empty for console only.
+	LogFile string
+	// LogMaxSize, LogMaxAge and LogMaxBackups control when LogFile is rotated
+	// and how many old files are kept. 0 disables each limit.
+	LogMaxSize    int64
+	LogMaxAge     time.Duration
+	LogMaxBackups int
+}
+
+// Load reads the configuration from environment variables.
+// It fails if no bot token is set.
+func Load() (Config, error) {
+	cfg := Defaults()
+	if strings.Trim(cfg.Env, "abcdefghijklmnopqrstuvwxyz0123456789-_") != "" {
+		return cfg, fmt.Errorf("invalid BOT_ENV %q: use lowercase letters, digits, - and _", cfg.Env)
+	}
+	if cfg.Env != "" {
+		// Falling back to .env alone could run a test profile with real tokens
+		if _, err := os.Stat(EnvFiles(cfg.Env)[0]); err != nil {
+			return cfg, fmt.Errorf("BOT_ENV is %q, but %w", cfg.Env, err)
+		}
+	}
+	if cfg.Chaos != "" {
+		if cfg.Env == "" || cfg.Env == "prod" {
+			return cfg, fmt.Errorf("CHAOS_PROFILE only works in a test profile, set BOT_ENV to one other than prod")
+		}
+		if _, err := chaos.Parse(cfg.Chaos); err != nil {
+			return cfg, fmt.Errorf("invalid CHAOS_PROFILE: %w", err)
+		}
+	}
+	if _, err := eventqueue.ParsePolicy(cfg.EventQueuePolicy); err != nil {
+		return cfg, fmt.Errorf("invalid EVENT_QUEUE_POLICY: %w", err)
+	}
+	if cfg.Unlisted != "ignore" && cfg.Unlisted != "leave" {
+		return cfg, fmt.Errorf("invalid UNLISTED_GUILDS %q: use ignore or leave", cfg.Unlisted)
+	}
+
+	cfg.TokenFile = os.Getenv("DISCORD_BOT_TOKEN_FILE")
+	token, err := mainToken(cfg.TokenFile, os.Getenv)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Token = token
+
+	ids, err := parseIntList(os.Getenv("SHARD_IDS"))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid SHARD_IDS: %w", err)
+	}
+	cfg.ShardIDs = ids
+
+	if _, err := intents.Parse(cfg.Intents); err != nil {
+		return cfg, fmt.Errorf("invalid GATEWAY_INTENTS: %w", err)
+	}
+
+	if _, err := cfg.Proxies(); err != nil {
+		return cfg, err
+	}
+
+	bots, err := parseBots(os.Getenv("EXTRA_BOT_TOKENS"))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid EXTRA_BOT_TOKENS: %w", err)
+	}
+	cfg.ExtraBots = bots
+
+	if cfg.DashboardAddr != "" && (cfg.DashboardURL == "" || cfg.ClientID == "" || cfg.ClientSecret == "") {
+		return cfg, errors.New("the dashboard needs DASHBOARD_URL, DISCORD_CLIENT_ID and DISCORD_CLIENT_SECRET")
+	}
+	for name, severity := range map[string]string{
+		"OPERATOR_CHANNEL_SEVERITY": cfg.OperatorSeverity,
+		"ALERT_WEBHOOK_SEVERITY":    cfg.WebhookSeverity,
+		"ALERT_EMAIL_SEVERITY":      cfg.EmailSeverity,
+	} {
+		if _, err := notify.ParseSeverity(severity); err != nil {
+			return cfg, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	if len(cfg.AlertEmailTo) > 0 && (cfg.SMTPAddr == "" || cfg.AlertEmailFrom == "") {
+		return cfg, errors.New("emailing alerts needs SMTP_ADDR and ALERT_EMAIL_FROM")
+	}
+	if cfg.APIAddr != "" && cfg.APIToken == "" {
+		return cfg, errors.New("the admin API needs API_TOKEN")
+	}
+	return cfg, nil
+}
+
+// MainBot is the name of the identity using Token.
+const MainBot = "main"
+
+// Bot is a bot identity: a name used in logs and the token it connects with.
+type Bot struct {
+	Name  string
+	Token string
+}
+
+// Bots returns every identity the process runs, the main Token first.
+func (c Config) Bots() []Bot {
+	return append([]Bot{{Name: MainBot, Token: c.Token}}, c.ExtraBots...)
+}
+
+// ReloadBots reads every identity's token again, so leaked tokens can be
+// rotated without a restart: the main one from TokenFile or
+// DISCORD_BOT_TOKEN, and the others from EXTRA_BOT_TOKENS. Variables are
+// looked up in secrets, freshly fetched from a secrets store, then in the
+// profile's .env files, then the environment, as the environment of a running process
+// can't be changed from outside.
+func (c Config) ReloadBots(secrets map[string]string) ([]Bot, error) {
+	var files []map[string]string
+	for _, file := range EnvFiles(c.Env) {
+		// Missing files leave the secrets and environment
+		if vars, err := godotenv.Read(file); err == nil {
+			files = append(files, vars)
+		}
+	}
+	getenv := func(key string) string {
+		if value, ok := secrets[key]; ok {
+			return value
+		}
+		for _, vars := range files {
+			if value, ok := vars[key]; ok {
+				return value
+			}
+		}
+		return os.Getenv(key)
+	}
+	token, err := mainToken(c.TokenFile, getenv)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := parseBots(getenv("EXTRA_BOT_TOKENS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EXTRA_BOT_TOKENS: %w", err)
+	}
+	return append([]Bot{{Name: MainBot, Token: token}}, extra...), nil
+}
+
+// EnvFiles returns the .env files holding the settings of profile, most
+// specific first: .env.<profile>, then .env. Settings in a file take
+// precedence over those in the files after it.
+func EnvFiles(profile string) []string {
+	if profile == "" {
+		return []string{".env"}
+	}
+	return []string{".env." + profile, ".env"}
+}
+
+// GuildAllowed reports whether the bot may act in a guild, which it may in
+// every guild unless Guilds limits it. Direct messages are always allowed.
+func (c Config) GuildAllowed(guildID string) bool {
+	return guildID == "" || len(c.Guilds) == 0 || slices.Contains(c.Guilds, guildID)
+}
+
+// mainToken reads the main bot's token from file, or from DISCORD_BOT_TOKEN
+// if there's no file.
+func mainToken(file string, getenv func(string) string) (string, error) {
+	if file == "" {
+		token := getenv("DISCORD_BOT_TOKEN")
+		if token == "" {
+			return "", errors.New("no token provided. Set DISCORD_BOT_TOKEN in your .env file")
+		}
+		return token, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("reading DISCORD_BOT_TOKEN_FILE: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.New("DISCORD_BOT_TOKEN_FILE is empty")
+	}
+	return token, nil
+}
+
+// Proxies returns the proxy each kind of destination is reached through.
+func (c Config) Proxies() (proxy.Routes, error) {
+	return proxy.NewRoutes(c.Proxy, c.DiscordProxy, c.TwitterProxy, c.LinksProxy)
+}
+
+// Defaults reads every setting except the token from the environment,
+// for offline tools that never connect to Discord.
+func Defaults() Config {
+	env := os.Getenv("BOT_ENV")
+	flagsFile := "flags.json"
+	if env != "" {
+		flagsFile = "flags." + env + ".json"
+	}
+	return Config{
+		Env:                 env,
+		Chaos:               os.Getenv("CHAOS_PROFILE"),
+		Guilds:              envList("ALLOWED_GUILDS"),
+		Unlisted:            envString("UNLISTED_GUILDS", "ignore"),
+		DataFile:            envString("DATA_FILE", "bot-data.json"),
+		WorkerCount:         envInt("WORKER_COUNT", 4),
+		WorkerQueueSize:     envInt("WORKER_QUEUE_SIZE", 100),
+		EventQueueSize:      envInt("EVENT_QUEUE_SIZE", 1000),
+		EventQueuePolicy:    envString("EVENT_QUEUE_POLICY", string(eventqueue.DropOldest)),
+		TwitchClipProxy:     envString("TWITCH_CLIP_PROXY", "clips.fxtwitch.tv"),
+		ShardCount:          envInt("SHARD_COUNT", 0),
+		Intents:             envList("GATEWAY_INTENTS"),
+		OperationTimeout:    time.Duration(envInt("OPERATION_TIMEOUT_SECONDS", 10)) * time.Second,
+		ShutdownTimeout:     time.Duration(envInt("SHUTDOWN_TIMEOUT_SECONDS", 15)) * time.Second,
+		ResumeWindow:        time.Duration(envInt("GATEWAY_RESUME_SECONDS", 120)) * time.Second,
+		FlagsFile:           envString("FLAGS_FILE", flagsFile),
+		FlagsPollInterval:   time.Duration(envInt("FLAGS_POLL_SECONDS", 30)) * time.Second,
+		EmbedThreshold:      envInt("EMBED_SCORE_THRESHOLD", 0),
+		DuplicateWindow:     time.Duration(envInt("DUPLICATE_WINDOW_MINUTES", 60)) * time.Minute,
+		FloodLimit:          envInt("FLOOD_MESSAGES_PER_SECOND", 10),
+		FloodCooldown:       time.Duration(envInt("FLOOD_COOLDOWN_SECONDS", 60)) * time.Second,
+		AbuseLimit:          envInt("ABUSE_TRIGGERS_PER_MINUTE", 20),
+		AbuseMute:           time.Duration(envInt("ABUSE_MUTE_MINUTES", 10)) * time.Minute,
+		RedisURL:            envString("REDIS_URL", ""),
+		RedisPrefix:         envString("REDIS_KEY_PREFIX", "go-discord-bot:"),
+		DailyLookups:        envInt("GUILD_LOOKUPS_PER_DAY", 5000),
+		DailyMirrors:        envInt("GUILD_MIRRORS_PER_DAY", 500),
+		NitterInstances:     envList("NITTER_INSTANCES"),
+		NitterCheckInterval: time.Duration(envInt("NITTER_CHECK_SECONDS", 300)) * time.Second,
+		CleanupInterval:     time.Duration(envInt("CLEANUP_INTERVAL_MINUTES", 15)) * time.Minute,
+		WatchdogInterval:    time.Duration(envInt("WATCHDOG_INTERVAL_SECONDS", 60)) * time.Second,
+		MaxGoroutines:       envInt("WATCHDOG_MAX_GOROUTINES", 2000),
+		MaxTimers:           envInt("WATCHDOG_MAX_TIMERS", 1000),
+		MaxCacheSize:        envInt("WATCHDOG_MAX_CACHE_SIZE", 100000),
+		OperatorChannel:     envString("OPERATOR_CHANNEL_ID", ""),
+		OperatorSeverity:    envString("OPERATOR_CHANNEL_SEVERITY", "warning"),
+		AlertWebhook:        envString("ALERT_WEBHOOK_URL", ""),
+		WebhookSeverity:     envString("ALERT_WEBHOOK_SEVERITY", "warning"),
+		AlertEmailTo:        envList("ALERT_EMAIL_TO"),
+		AlertEmailFrom:      envString("ALERT_EMAIL_FROM", ""),
+		EmailSeverity:       envString("ALERT_EMAIL_SEVERITY", "error"),
+		SMTPAddr:            envString("SMTP_ADDR", ""),
+		SMTPUsername:        envString("SMTP_USERNAME", ""),
+		SMTPPassword:        envString("SMTP_PASSWORD", ""),
+		DecisionLogSize:     envInt("DECISION_LOG_SIZE", 1000),
+		TweetFallbackURL:    envString("TWEET_FALLBACK_URL", syndication.DefaultBaseURL),
+		SteamCountry:        envString("STEAM_COUNTRY", "us"),
+		SongLinkKey:         envString("SONGLINK_API_KEY", ""),
+		DeletedRetention:    time.Duration(envInt("DELETED_RETENTION_HOURS", 168)) * time.Hour,
+		UpdateCheckInterval: time.Duration(envInt("UPDATE_CHECK_HOURS", 0)) * time.Hour,
+		UpdateURL:           envString("UPDATE_CHECK_URL", "https://api.github.com/repos/foxbento/my_first_discord_go_bot/releases/latest"),
+		TelemetryURL:        envString("TELEMETRY_URL", ""),
+		TelemetryInterval:   time.Duration(envInt("TELEMETRY_INTERVAL_HOURS", 24)) * time.Hour,
+		Proxy:               envString("PROXY_URL", ""),
+		DiscordProxy:        envString("DISCORD_PROXY_URL", ""),
+		TwitterProxy:        envString("TWITTER_PROXY_URL", ""),
+		LinksProxy:          envString("LINKS_PROXY_URL", ""),
+		HTTPRequestsPerHost: envInt("HTTP_REQUESTS_PER_HOST", 8),
+		DashboardAddr:       envString("DASHBOARD_ADDR", ""),
+		DashboardURL:        envString("DASHBOARD_URL", ""),
+		ClientID:            envString("DISCORD_CLIENT_ID", ""),
+		ClientSecret:        envString("DISCORD_CLIENT_SECRET", ""),
+		APIAddr:             envString("API_ADDR", ""),
+		APIToken:            envString("API_TOKEN", ""),
+		PhishingListFile:    envString("PHISHING_LIST_FILE", ""),
+		SafeBrowsingKey:     envString("SAFE_BROWSING_KEY", ""),
+		VoiceSoundFile:      envString("VOICE_SOUND_FILE", ""),
+		VoiceTTSCommand:     envString("VOICE_TTS_COMMAND", ""),
+		TraceEndpoint:       envString("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TraceService:        envString("OTEL_SERVICE_NAME", "go-discord-bot"),
+		LogFile:             envString("LOG_FILE", ""),
+		LogMaxSize:          int64(envInt("LOG_MAX_SIZE_MB", 100)) << 20,
+		LogMaxAge:           time.Duration(envInt("LOG_MAX_AGE_HOURS", 24)) * time.Hour,
+		LogMaxBackups:       envInt("LOG_MAX_BACKUPS", 7),
+	}
+}
+
+// envString reads an environment variable, falling back to def when unset.
+func envString(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// envList reads a comma-separated environment variable, skipping empty entries.
+func envList(name string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// envInt reads an integer environment variable, falling back to def when unset or invalid.
+func envInt(name string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// parseBots parses a comma-separated list of name=token pairs such as
+// "staging=abc,eu=def". Names must be unique and can't be MainBot.
+func parseBots(list string) ([]Bot, error) {
+	var bots []Bot
+	seen := map[string]bool{MainBot: true}
+	for n, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// Don't echo the entry, it may be a bare token
+		name, token, ok := strings.Cut(part, "=")
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("entry %d is not name=token", n+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("bot name %q is used twice", name)
+		}
+		seen[name] = true
+		bots = append(bots, Bot{Name: name, Token: token})
+	}
+	return bots, nil
+}
+
+// parseIntList parses a comma-separated list of integers and inclusive ranges
+// such as "0,2,4-7".
+func parseIntList(list string) ([]int, error) {
+	var ints []int
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+				return nil, err
+			}
+			if end < start {
+				return nil, fmt.Errorf("range %q is backwards", part)
+			}
+		}
+		for n := start; n <= end; n++ {
+			ints = append(ints, n)
+		}
+	}
+	return ints, nil
+}
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-discord-bot/internal/storage"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("DISCORD_BOT_TOKEN", "")
+	if _, err := Load(); err == nil {
+		t.Error("Load without a token succeeded")
+	}
+
+	t.Setenv("DISCORD_BOT_TOKEN", "token")
+	t.Setenv("WORKER_COUNT", "not a number")
+	t.Setenv("TWITCH_CLIP_PROXY", "clips.example.com")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.WorkerCount != 4 {
+		t.Errorf("WorkerCount = %d; want default 4", cfg.WorkerCount)
+	}
+	if cfg.TwitchClipProxy != "clips.example.com" {
+		t.Errorf("TwitchClipProxy = %q", cfg.TwitchClipProxy)
+	}
+
+	t.Setenv("TWITTER_PROXY_URL", "ftp://proxy")
+	if _, err := Load(); err == nil {
+		t.Error("Load with an unsupported proxy succeeded")
+	}
+	t.Setenv("TWITTER_PROXY_URL", "")
+
+	t.Setenv("GATEWAY_INTENTS", "guild_messages,typing")
+	if _, err := Load(); err == nil {
+		t.Error("Load with an unknown intent succeeded")
+	}
+	t.Setenv("GATEWAY_INTENTS", "")
+
+	t.Setenv("UNLISTED_GUILDS", "kick")
+	if _, err := Load(); err == nil {
+		t.Error("Load with an unknown UNLISTED_GUILDS succeeded")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(dir) })
+
+	t.Setenv("DISCORD_BOT_TOKEN", "token")
+	t.Setenv("BOT_ENV", "dev")
+	if _, err := Load(); err == nil {
+		t.Error("Load without .env.dev succeeded")
+	}
+	t.Setenv("BOT_ENV", "../dev")
+	if _, err := Load(); err == nil {
+		t.Error("Load with a path for BOT_ENV succeeded")
+	}
+
+	if err := os.WriteFile(".env.dev", []byte("DISCORD_BOT_TOKEN=dev\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BOT_ENV", "dev")
+	t.Setenv("ALLOWED_GUILDS", "test")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.FlagsFile != "flags.dev.json" {
+		t.Errorf("FlagsFile = %q; want the profile's", cfg.FlagsFile)
+	}
+	if !cfg.GuildAllowed("test") || !cfg.GuildAllowed("") || cfg.GuildAllowed("prod") {
+		t.Errorf("GuildAllowed with Guilds %v is wrong", cfg.Guilds)
+	}
+	bots, err := cfg.ReloadBots(nil)
+	if err != nil {
+		t.Fatalf("ReloadBots: %v", err)
+	}
+	if bots[0].Token != "dev" {
+		t.Errorf("ReloadBots() = %v; want the token in .env.dev", bots)
+	}
+
+	t.Setenv("CHAOS_PROFILE", "429=2")
+	if _, err := Load(); err == nil {
+		t.Error("Load with an invalid CHAOS_PROFILE succeeded")
+	}
+	t.Setenv("CHAOS_PROFILE", "seed=1,429=0.1")
+	if cfg, err := Load(); err != nil || cfg.Chaos != "seed=1,429=0.1" {
+		t.Errorf("Load with a CHAOS_PROFILE = %q, %v", cfg.Chaos, err)
+	}
+	t.Setenv("BOT_ENV", "")
+	if _, err := Load(); err == nil {
+		t.Error("Load with a CHAOS_PROFILE outside a test profile succeeded")
+	}
+}
+
+func TestReloadBots(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DISCORD_BOT_TOKEN", "ignored")
+	t.Setenv("DISCORD_BOT_TOKEN_FILE", file)
+	t.Setenv("EXTRA_BOT_TOKENS", "staging=abc")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Token != "old" {
+		t.Errorf("Token = %q; want the file's", cfg.Token)
+	}
+
+	if err := os.WriteFile(file, []byte("new"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EXTRA_BOT_TOKENS", "staging=def")
+	bots, err := cfg.ReloadBots(nil)
+	if err != nil {
+		t.Fatalf("ReloadBots: %v", err)
+	}
+	expected := []Bot{{Name: MainBot, Token: "new"}, {Name: "staging", Token: "def"}}
+	if fmt.Sprint(bots) != fmt.Sprint(expected) {
+		t.Errorf("ReloadBots() = %v; want %v", bots, expected)
+	}
+
+	bots, err = cfg.ReloadBots(map[string]string{"EXTRA_BOT_TOKENS": "staging=ghi"})
+	if err != nil {
+		t.Fatalf("ReloadBots: %v", err)
+	}
+	if bots[1].Token != "ghi" {
+		t.Errorf("ReloadBots() = %v; want the secret's staging token", bots)
+	}
+
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.ReloadBots(nil); err == nil {
+		t.Error("ReloadBots with an empty token file succeeded")
+	}
+}
+
+func TestParseIntList(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []int
+		wantErr  bool
+	}{
+		{input: "", expected: nil},
+		{input: "3", expected: []int{3}},
+		{input: "0, 2,4-6", expected: []int{0, 2, 4, 5, 6}},
+		{input: "5-3", wantErr: true},
+		{input: "a", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		result, err := parseIntList(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseIntList(%q) error = %v; wantErr %v", tc.input, err, tc.wantErr)
+			continue
+		}
+		if fmt.Sprint(result) != fmt.Sprint(tc.expected) {
+			t.Errorf("parseIntList(%q) = %v; want %v", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestParseBots(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []Bot
+		wantErr  bool
+	}{
+		{input: "", expected: nil},
+		{input: "staging=abc", expected: []Bot{{Name: "staging", Token: "abc"}}},
+		{input: " staging = abc, eu=def ,", expected: []Bot{{Name: "staging", Token: "abc"}, {Name: "eu", Token: "def"}}},
+		{input: "abc", wantErr: true},
+		{input: "staging=", wantErr: true},
+		{input: "a=1,a=2", wantErr: true},
+		{input: "main=abc", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		result, err := parseBots(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseBots(%q) error = %v; wantErr %v", tc.input, err, tc.wantErr)
+			continue
+		}
+		if fmt.Sprint(result) != fmt.Sprint(tc.expected) {
+			t.Errorf("parseBots(%q) = %v; want %v", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestGuildRoundTrip(t *testing.T) {
+	st := storage.NewMemory()
+
+	cfg := Guild{RewriteRules: []RewriteRule{{Pattern: "a", Replacement: "b"}}}
+	if err := SaveGuild(st, "guild", cfg); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	loaded, err := LoadGuild(st, "guild")
+	if err != nil {
+		t.Fatalf("LoadGuild: %v", err)
+	}
+	if len(loaded.RewriteRules) != 1 || loaded.RewriteRules[0] != cfg.RewriteRules[0] {
+		t.Errorf("LoadGuild = %+v; want %+v", loaded, cfg)
+	}
+
+	empty, err := LoadGuild(st, "")
+	if err != nil || len(empty.RewriteRules) != 0 {
+		t.Errorf("LoadGuild for DMs = %+v, %v; want defaults", empty, err)
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	st := storage.NewMemory()
+	if err := SaveGuild(st, "guild", Guild{IgnoredUsers: []string{"relay"}, IgnoredRoles: []string{"muted"}}); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		guildID  string
+		userID   string
+		roles    []string
+		expected bool
+	}{
+		{name: "Ignored user", guildID: "guild", userID: "relay", expected: true},
+		{name: "Ignored role", guildID: "guild", userID: "user", roles: []string{"member", "muted"}, expected: true},
+		{name: "Regular member", guildID: "guild", userID: "user", roles: []string{"member"}, expected: false},
+		{name: "Other guild", guildID: "other", userID: "relay", expected: false},
+		{name: "Direct message", guildID: "", userID: "relay", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Ignored(st, tc.guildID, tc.userID, tc.roles); got != tc.expected {
+				t.Errorf("Ignored = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSkips(t *testing.T) {
+	testCases := []struct {
+		name     string
+		marker   string
+		content  string
+		expected bool
+	}{
+		{name: "Default marker", content: "!raw https://x.com/user/status/1", expected: true},
+		{name: "Other case", content: "  !RAW\nhttps://x.com/user/status/1", expected: true},
+		{name: "Marker alone", content: "!raw", expected: true},
+		{name: "Longer word", content: "!rawr https://x.com/user/status/1", expected: false},
+		{name: "Marker later on", content: "https://x.com/user/status/1 !raw", expected: false},
+		{name: "Own marker", marker: "nofix", content: "nofix https://x.com/user/status/1", expected: true},
+		{name: "Default with own marker", marker: "nofix", content: "!raw https://x.com/user/status/1", expected: false},
+		{name: "Empty message", content: "", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := (Guild{SkipMarker: tc.marker}).Skips(tc.content); got != tc.expected {
+				t.Errorf("Skips(%q) = %v; want %v", tc.content, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestBlockedKeyword(t *testing.T) {
+	g := Guild{BlockedKeywords: []string{"spoiler", "Big Game", "#nsfw"}}
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "Word", text: "Huge spoiler ahead", expected: "spoiler"},
+		{name: "Other case", text: "SPOILER: he wins", expected: "spoiler"},
+		{name: "Phrase", text: "watching the big game tonight", expected: "Big Game"},
+		{name: "Inside a word", text: "spoilers ahead", expected: ""},
+		{name: "Later match", text: "nospoiler, real spoiler", expected: "spoiler"},
+		{name: "Symbol", text: "art #nsfw", expected: "#nsfw"},
+		{name: "None", text: "sunset over the bay", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := g.BlockedKeyword(tc.text); got != tc.expected {
+				t.Errorf("BlockedKeyword(%q) = %q; want %q", tc.text, got, tc.expected)
+			}
+		})
+	}
+
+	blocked := Guild{BlockedAccounts: []string{"Spammer"}}
+	if !blocked.BlocksAccount("spammer") || blocked.BlocksAccount("spammer2") {
+		t.Errorf("BlocksAccount didn't match only the blocked account, in any case")
+	}
+}
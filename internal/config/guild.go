@@ -0,0 +1,657 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	// Guild time zones load even where the system has no tz database
+	_ "time/tzdata"
+
+	"go-discord-bot/internal/storage"
+)
+
+// GuildBucket is the store bucket holding per-guild settings keyed by guild ID.
+const GuildBucket = "guild_config"
+
+// Twitter sites fixed Twitter/X links can point to.
+const (
+	// TwitterFxTwitter rewrites links to fxtwitter.com and fixupx.com.
+	TwitterFxTwitter = "fxtwitter"
+	// TwitterNitter rewrites links to a Nitter instance, falling back to
+	// fxtwitter when none is available.
+	TwitterNitter = "nitter"
+	// TwitterCustom rewrites links to the guild's TwitterDomain, falling back
+	// to fxtwitter while it fails its health checks.
+	TwitterCustom = "custom"
+)
+
+// Music sites links to songs and albums on streaming services can point to.
+const (
+	// MusicSongLink rewrites links to their song.link page, which links to
+	// every service.
+	MusicSongLink = "songlink"
+	// The others rewrite links to the same song or album on that service,
+	// or to its song.link page if the service doesn't have it.
+	MusicSpotify      = "spotify"
+	MusicAppleMusic   = "apple_music"
+	MusicYouTubeMusic = "youtube_music"
+	MusicTidal        = "tidal"
+	MusicDeezer       = "deezer"
+)
+
+// MusicSites are the music sites guilds can pick, in display order.
+var MusicSites = []string{MusicSongLink, MusicSpotify, MusicAppleMusic, MusicYouTubeMusic, MusicTidal, MusicDeezer}
+
+// Repost modes control how fixed links are posted.
+const (
+	// RepostMessage posts fixed links as a new message.
+	RepostMessage = "message"
+	// RepostReply posts fixed links as a reply to the original message.
+	RepostReply = "reply"
+	// RepostReaction reacts to the original message and only posts fixed
+	// links, as a reply, once someone reacts back.
+	RepostReaction = "reaction"
+	// RepostConfirm sends the author the fixed links privately, with buttons
+	// to post them, as a reply, or not.
+	RepostConfirm = "confirm"
+	// RepostMinimal replies with just the fixed links on one line and hides
+	// the original message's embeds, which needs Manage Messages.
+	RepostMinimal = "minimal"
+	// RepostThread starts a "Fixed links" thread on the original message and
+	// posts fixed links there, replying instead where that can't be done, as
+	// in threads.
+	RepostThread = "thread"
+)
+
+// Phishing actions control what happens to messages linking to known phishing
+// or malware sites.
+const (
+	// PhishingWarn replies to the message with a warning. It is the default.
+	PhishingWarn = "warn"
+	// PhishingDelete deletes the message, warning instead if the bot can't.
+	PhishingDelete = "delete"
+	// PhishingOff doesn't check links.
+	PhishingOff = "off"
+)
+
+// Other fixer modes control what happens in guilds with another link-fixing
+// bot in them.
+const (
+	// OthersYield leaves links to the other bot, so they aren't reposted twice.
+	// It is the default.
+	OthersYield = "yield"
+	// OthersAlert keeps fixing links, only telling moderators about the overlap.
+	OthersAlert = "alert"
+	// OthersIgnore keeps fixing links and doesn't look for other bots.
+	OthersIgnore = "ignore"
+)
+
+// Filter actions control what happens to messages whose fixed tweets a guild's
+// content filters block.
+const (
+	// FilterSkip leaves the message's links unfixed. It is the default.
+	FilterSkip = "skip"
+	// FilterFlag leaves the message's links unfixed and tells moderators in
+	// the audit channel.
+	FilterFlag = "flag"
+	// FilterRemove deletes the message, which needs Manage Messages. Messages
+	// linking to tweets by blocked accounts are removed even if their links
+	// wouldn't be fixed.
+	FilterRemove = "remove"
+)
+
+// MaxBlockedAccounts and MaxBlockedKeywords cap a guild's content filters, and
+// MaxKeywordLength each blocked keyword.
+const (
+	MaxBlockedAccounts = 100
+	MaxBlockedKeywords = 50
+	MaxKeywordLength   = 100
+)
+
+// Digest modes control how fixed links are copied to the digest channel.
+const (
+	// DigestLive posts each fixed link as it's fixed. It is the default.
+	DigestLive = "live"
+	// DigestDaily posts the day's fixed links in one digest at the guild's
+	// digest hour in its time zone.
+	DigestDaily = "daily"
+)
+
+// Crosspost modes control which messages in announcement channels are published
+// to the servers following them.
+const (
+	// CrosspostReposts publishes the bot's reposts of fixed links.
+	CrosspostReposts = "reposts"
+	// CrosspostAll publishes every message, as well as the bot's reposts.
+	CrosspostAll = "all"
+)
+
+// Gallery modes control what happens to tweets with several photos whose
+// embed shows only some of them.
+const (
+	// GalleryRepost fixes the link anyway, since the fixed embed shows every photo.
+	GalleryRepost = "repost"
+	// GalleryAttach replies with copies of the photos the embed doesn't show.
+	GalleryAttach = "attach"
+)
+
+// ProfileEmbed has the bot reply to links to Twitter/X profiles with an embed
+// of the profile. Without it they are left alone, like every link to Twitter/X
+// that isn't a tweet.
+const ProfileEmbed = "embed"
+
+// Modules are features without settings of their own that a guild can turn off.
+const (
+	// ModuleGreeting answers "hello" with "world!", after the guild's own
+	// auto-responses.
+	ModuleGreeting = "greeting"
+	// ModuleTranslate translates reposts when someone reacts with a country flag.
+	ModuleTranslate = "translate"
+)
+
+// Modules lists every module.
+var Modules = []string{ModuleGreeting, ModuleTranslate}
+
+// DefaultStarThreshold and MaxStarThreshold bound Guild.StarThreshold.
+const (
+	DefaultStarThreshold = 3
+	MaxStarThreshold     = 100
+)
+
+// DefaultWelcomeMessage greets members who join guilds that haven't written
+// their own welcome message.
+const DefaultWelcomeMessage = "Welcome to {server}, {user}!"
+
+// MaxContextDepth caps Guild.ContextDepth, since each tweet shown is another lookup.
+const MaxContextDepth = 5
+
+// MaxRepostTTLHours caps Guild.RepostTTLHours at 30 days.
+const MaxRepostTTLHours = 30 * 24
+
+// MaxThreadArchiveHours caps Guild.ThreadArchiveHours at a week, after which
+// Discord archives inactive threads itself.
+const MaxThreadArchiveHours = 7 * 24
+
+// DefaultSkipMarker is the word that, starting a message, has the bot leave
+// it alone, for guilds that haven't picked their own. MaxSkipMarkerLength caps
+// the ones they pick.
+const (
+	DefaultSkipMarker   = "!raw"
+	MaxSkipMarkerLength = 20
+)
+
+// DefaultSuccessReaction is what the bot reacts to the messages it fixed with,
+// for guilds that turn success reactions on without picking an emoji.
+const DefaultSuccessReaction = "✅"
+
+// Match kinds control how an auto-response's trigger is matched against messages.
+const (
+	// MatchExact matches messages that are the trigger, in any case.
+	MatchExact = "exact"
+	// MatchContains matches messages containing the trigger, in any case.
+	MatchContains = "contains"
+	// MatchRegex matches messages the trigger, a regular expression, matches.
+	MatchRegex = "regex"
+)
+
+// Output styles control how a platform's fixed links are shown in reposts.
+const (
+	// OutputPlain shows the link as it is, with the embed Discord builds for it.
+	OutputPlain = "plain"
+	// OutputMasked shows the link as a markdown link with a short label.
+	OutputMasked = "masked"
+	// OutputEmbed hides Discord's embed and posts one the bot builds from the
+	// API instead. Only tweets have one.
+	OutputEmbed = "embed"
+)
+
+// OutputStyles lists every output style.
+var OutputStyles = []string{OutputPlain, OutputMasked, OutputEmbed}
+
+// Guild holds the settings an admin can change for a single guild.
+type Guild struct {
+	RewriteRules []RewriteRule `json:"rewrite_rules,omitempty"`
+	// Paused stops everything the bot does on its own in the guild, such as
+	// fixing links and posting feeds, while leaving its commands working.
+	Paused bool `json:"paused,omitempty"`
+	// Privacy stops the bot from logging message content or collecting stats in the guild.
+	Privacy bool `json:"privacy,omitempty"`
+	// Channels limits link fixing to these channel IDs, empty for every channel.
+	Channels []string `json:"channels,omitempty"`
+	// DisabledFixers names the fixers that don't run in the guild, such as "twitch".
+	DisabledFixers []string `json:"disabled_fixers,omitempty"`
+	// DisabledCommands names the commands that can't be used in the guild.
+	DisabledCommands []string `json:"disabled_commands,omitempty"`
+	// DisabledModules names the Modules turned off in the guild.
+	DisabledModules []string `json:"disabled_modules,omitempty"`
+	// RepostMode is how fixed links are posted, RepostMessage when empty.
+	RepostMode string `json:"repost_mode,omitempty"`
+	// TriggerEmoji is the emoji, such as "🔧" or "name:id" for a custom one,
+	// anyone reacts to a message with to have its links fixed. While it's set
+	// the bot doesn't fix links on its own in the guild.
+	TriggerEmoji string `json:"trigger_emoji,omitempty"`
+	// SuccessReaction is the emoji, named like TriggerEmoji, the bot reacts to
+	// messages with once it has posted their fix, so authors know it acted.
+	// Empty turns the reaction off.
+	SuccessReaction string `json:"success_reaction,omitempty"`
+	// SkipMarker is the word that, starting a message, has the bot leave its
+	// links alone, DefaultSkipMarker when empty.
+	SkipMarker string `json:"skip_marker,omitempty"`
+	// RepostTemplate is the text reposts are posted with, such as
+	// "Fixed link from {user}: {links}", empty for just the fixed message.
+	// See package templates for the placeholders.
+	RepostTemplate string `json:"repost_template,omitempty"`
+	// RepostTTLHours is how many hours the bot's reposts stay up before it
+	// deletes them, 0 to keep them.
+	RepostTTLHours int `json:"repost_ttl_hours,omitempty"`
+	// ThreadArchiveHours is how many hours the "Fixed links" threads the bot
+	// starts in RepostThread mode may go without messages before it archives
+	// them, 0 to leave them to Discord.
+	ThreadArchiveHours int `json:"thread_archive_hours,omitempty"`
+	// ThreadSummary has the bot sum up a thread, saying what it fixed there,
+	// before archiving it.
+	ThreadSummary bool `json:"thread_summary,omitempty"`
+	// Outputs maps fixer names, such as "twitch", to the output style of the
+	// links they fix. Fixers not in it use OutputPlain.
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// TextSummaries adds a line to reposts for each fixed tweet saying who
+	// posted it, what it says and what media it has, for members with embeds
+	// turned off or using screen readers.
+	TextSummaries bool `json:"text_summaries,omitempty"`
+	// TwitterSite is where fixed Twitter/X links point, TwitterFxTwitter when empty.
+	TwitterSite string `json:"twitter_site,omitempty"`
+	// TwitterDomain is the site links point to with TwitterCustom, such as
+	// "fixvx.com".
+	TwitterDomain string `json:"twitter_domain,omitempty"`
+	// SwapProxies points links members post to a fixing site, such as
+	// fxtwitter.com, at its counterpart, such as vxtwitter.com, while the
+	// site is down.
+	SwapProxies bool `json:"swap_proxies,omitempty"`
+	// TranslateTo is the language code fxtwitter links are translated to, empty for none.
+	TranslateTo string `json:"translate_to,omitempty"`
+	// ContextDepth is how many quoted and parent tweets are shown under a fixed
+	// tweet, 0 for none.
+	ContextDepth int `json:"context_depth,omitempty"`
+	// MirrorMedia has the bot upload copies of the photos and videos of fixed
+	// tweets with its reposts, so they survive the tweet being deleted.
+	MirrorMedia bool `json:"mirror_media,omitempty"`
+	// Galleries is what happens to tweets with several photos whose embed shows
+	// only some of them, nothing when empty.
+	Galleries string `json:"galleries,omitempty"`
+	// Profiles is what happens to links to Twitter/X profiles, nothing when empty.
+	Profiles string `json:"profiles,omitempty"`
+	// Previews has the bot post a preview, built from the page's metadata, for
+	// links Discord didn't embed.
+	Previews bool `json:"previews,omitempty"`
+	// Unshorten has the bot reply with where shortened links, such as bit.ly
+	// links, actually go.
+	Unshorten bool `json:"unshorten,omitempty"`
+	// QuoteLinks has the bot reply to links to messages elsewhere in the
+	// guild with a quote of the message.
+	QuoteLinks bool `json:"quote_links,omitempty"`
+	// GameEmbeds has the bot reply to links to Steam and itch.io games with
+	// their price, discount and genres.
+	GameEmbeds bool `json:"game_embeds,omitempty"`
+	// MusicSite is where links to songs and albums on streaming services,
+	// such as Spotify, point, one of MusicSites. They're left alone when empty.
+	MusicSite string `json:"music_site,omitempty"`
+	// DigestChannel is the channel fixed tweet links are copied to, empty for none.
+	DigestChannel string `json:"digest_channel,omitempty"`
+	// DigestMode is how links are copied to DigestChannel, DigestLive when empty.
+	DigestMode string `json:"digest_mode,omitempty"`
+	// DigestHour is the hour, in the guild's time zone, the daily digest is
+	// posted at, 0 for midnight.
+	DigestHour int `json:"digest_hour,omitempty"`
+	// Timezone is the IANA time zone, such as "Europe/Berlin", the guild's days
+	// are counted in, UTC when empty.
+	Timezone string `json:"timezone,omitempty"`
+	// Locale is the Discord locale, such as "de" or "en-GB", counts and dates
+	// are written in, the server's own language when empty.
+	Locale string `json:"locale,omitempty"`
+	// StarboardChannel is where messages with enough ⭐ reactions are reposted,
+	// empty for no starboard.
+	StarboardChannel string `json:"starboard_channel,omitempty"`
+	// StarThreshold is how many ⭐ reactions put a message on the starboard,
+	// DefaultStarThreshold when 0.
+	StarThreshold int `json:"star_threshold,omitempty"`
+	// AuditChannel is where moderators are told about what the bot did on
+	// its own, such as muting a user who spammed it, and about moderation
+	// commands used, such as /purge, empty for nowhere.
+	AuditChannel string `json:"audit_channel,omitempty"`
+	// WelcomeChannel is where members who join are greeted with
+	// WelcomeMessage, DefaultWelcomeMessage when empty, empty for nowhere. See
+	// package templates for the placeholders.
+	WelcomeChannel string `json:"welcome_channel,omitempty"`
+	WelcomeMessage string `json:"welcome_message,omitempty"`
+	// WelcomeRole is given to every member who joins, empty for none.
+	WelcomeRole string `json:"welcome_role,omitempty"`
+	// AutoResponses are the replies the bot posts to messages matching their
+	// triggers. The first that matches is used, ahead of the greeting module.
+	AutoResponses []AutoResponse `json:"auto_responses,omitempty"`
+	// VoiceChannel is the voice channel a notice is played in when a link is
+	// fixed, empty for none.
+	VoiceChannel string `json:"voice_channel,omitempty"`
+	// Crosspost is which messages in announcement channels are published,
+	// none when empty.
+	Crosspost string `json:"crosspost,omitempty"`
+	// PhishingAction is what happens to messages linking to known phishing or
+	// malware sites, PhishingWarn when empty.
+	PhishingAction string `json:"phishing_action,omitempty"`
+	// OtherFixers is what happens when another link-fixing bot is in the
+	// guild, OthersYield when empty.
+	OtherFixers string `json:"other_fixers,omitempty"`
+	// FixerBots lists the user IDs of bots admins said fix links too, on top
+	// of those the bot notices itself.
+	FixerBots []string `json:"fixer_bots,omitempty"`
+	// BlockedAccounts lists the Twitter/X screen names, without the @, whose
+	// tweets the bot doesn't repost.
+	BlockedAccounts []string `json:"blocked_accounts,omitempty"`
+	// BlockedKeywords lists the words and phrases tweets containing them
+	// aren't reposted for, in any case.
+	BlockedKeywords []string `json:"blocked_keywords,omitempty"`
+	// FilterAction is what happens to messages BlockedAccounts or
+	// BlockedKeywords block, FilterSkip when empty.
+	FilterAction string `json:"filter_action,omitempty"`
+	// FeedSources maps the user IDs of bots and webhooks whose embeds have
+	// their Twitter/X links fixed, such as feed bots posting tweets, to their
+	// names.
+	FeedSources map[string]string `json:"feed_sources,omitempty"`
+	// IgnoredUsers and IgnoredRoles list the users and roles the bot ignores.
+	IgnoredUsers []string `json:"ignored_users,omitempty"`
+	IgnoredRoles []string `json:"ignored_roles,omitempty"`
+	// AdminRoles lists roles whose members may use admin commands without
+	// the permissions those need.
+	AdminRoles []string `json:"admin_roles,omitempty"`
+	// FeatureRoles limits features to members with one of the listed roles,
+	// keyed by feature name. See package access for the features.
+	FeatureRoles map[string][]string `json:"feature_roles,omitempty"`
+}
+
+// Ignores reports whether the bot should ignore a member with the given user ID and roles.
+func (g Guild) Ignores(userID string, roles []string) bool {
+	if slices.Contains(g.IgnoredUsers, userID) {
+		return true
+	}
+	for _, role := range roles {
+		if slices.Contains(g.IgnoredRoles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Location returns the guild's time zone, UTC if it has none or it's unknown.
+func (g Guild) Location() *time.Location {
+	loc, err := LoadLocation(g.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LocaleOr returns the locale the guild's counts and dates are written in:
+// the one it picked, or preferred, the server's language as Discord has it.
+func (g Guild) LocaleOr(preferred string) string {
+	if g.Locale != "" {
+		return g.Locale
+	}
+	return preferred
+}
+
+// LoadLocation returns the IANA time zone called name, such as
+// "America/New_York", or UTC for an empty name. The server's own "Local" zone
+// isn't one guilds can pick.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// Stars returns how many ⭐ reactions put a message on the starboard.
+func (g Guild) Stars() int {
+	if g.StarThreshold <= 0 {
+		return DefaultStarThreshold
+	}
+	return g.StarThreshold
+}
+
+// Welcome returns the message members who join are greeted with.
+func (g Guild) Welcome() string {
+	if g.WelcomeMessage == "" {
+		return DefaultWelcomeMessage
+	}
+	return g.WelcomeMessage
+}
+
+// Marker returns the word that, starting a message, has the bot leave it alone.
+func (g Guild) Marker() string {
+	if g.SkipMarker == "" {
+		return DefaultSkipMarker
+	}
+	return g.SkipMarker
+}
+
+// OthersMode returns what happens when another link-fixing bot is in the guild.
+func (g Guild) OthersMode() string {
+	if g.OtherFixers == "" {
+		return OthersYield
+	}
+	return g.OtherFixers
+}
+
+// Filters reports whether the guild has content filters.
+func (g Guild) Filters() bool {
+	return len(g.BlockedAccounts) > 0 || len(g.BlockedKeywords) > 0
+}
+
+// BlocksAccount reports whether tweets by the Twitter/X account screenName
+// aren't reposted, in any case.
+func (g Guild) BlocksAccount(screenName string) bool {
+	return slices.ContainsFunc(g.BlockedAccounts, func(blocked string) bool {
+		return strings.EqualFold(blocked, screenName)
+	})
+}
+
+// BlockedKeyword returns the first of the guild's blocked keywords text holds
+// as whole words, in any case, or "" if it holds none.
+func (g Guild) BlockedKeyword(text string) string {
+	text = strings.ToLower(text)
+	for _, keyword := range g.BlockedKeywords {
+		if containsWords(text, strings.ToLower(keyword)) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// containsWords reports whether text holds words where they neither start
+// nor end in the middle of a word, so "cat" doesn't match "concatenate".
+func containsWords(text, words string) bool {
+	if words == "" {
+		return false
+	}
+	for start := 0; ; {
+		n := strings.Index(text[start:], words)
+		if n < 0 {
+			return false
+		}
+		n += start
+		end := n + len(words)
+		before, _ := utf8.DecodeLastRuneInString(text[:n])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !inWord(before) && !inWord(after) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(text[n:])
+		start = n + size
+	}
+}
+
+// inWord reports whether r is part of a word, like a letter or digit.
+func inWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// ValidAccount reports whether name can be a Twitter/X screen name: 1 to 15
+// letters, digits or underscores.
+func ValidAccount(name string) bool {
+	return len(name) >= 1 && len(name) <= 15 && !strings.ContainsFunc(name, func(r rune) bool {
+		return !(r == '_' || r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+	})
+}
+
+// RepostTTL returns how long the bot's reposts stay up, 0 for as long as
+// nobody deletes them.
+func (g Guild) RepostTTL() time.Duration {
+	return time.Duration(g.RepostTTLHours) * time.Hour
+}
+
+// ThreadArchiveAfter returns how long the bot's "Fixed links" threads may go
+// without messages before it archives them, 0 for as long as Discord allows.
+func (g Guild) ThreadArchiveAfter() time.Duration {
+	return time.Duration(g.ThreadArchiveHours) * time.Hour
+}
+
+// Skips reports whether content starts with the guild's skip marker, in any case.
+func (g Guild) Skips(content string) bool {
+	fields := strings.Fields(content)
+	return len(fields) > 0 && strings.EqualFold(fields[0], g.Marker())
+}
+
+// SkipsTag reports whether forum posts with the tag called name are left
+// alone: tags named like the skip marker, without its "!", such as "raw".
+func (g Guild) SkipsTag(name string) bool {
+	return strings.EqualFold(strings.TrimPrefix(name, "!"), strings.TrimPrefix(g.Marker(), "!"))
+}
+
+// ValidSkipMarker reports whether marker can be a guild's skip marker: a
+// single word of at most MaxSkipMarkerLength bytes.
+func ValidSkipMarker(marker string) bool {
+	return marker != "" && len(marker) <= MaxSkipMarkerLength && !strings.ContainsFunc(marker, unicode.IsSpace)
+}
+
+// ChannelEnabled reports whether links posted in channelID should be fixed.
+func (g Guild) ChannelEnabled(channelID string) bool {
+	return len(g.Channels) == 0 || slices.Contains(g.Channels, channelID)
+}
+
+// FixerEnabled reports whether the named fixer runs in the guild.
+func (g Guild) FixerEnabled(name string) bool {
+	return !slices.Contains(g.DisabledFixers, name)
+}
+
+// Output returns the output style of the links the named fixer fixes.
+func (g Guild) Output(fixer string) string {
+	if style, ok := g.Outputs[fixer]; ok {
+		return style
+	}
+	return OutputPlain
+}
+
+// ValidOutput reports whether links fixed by the named fixer can be shown in
+// style. Only tweets have an embed built from the API.
+func ValidOutput(fixer, style string) bool {
+	if style == OutputEmbed {
+		return fixer == "twitter"
+	}
+	return slices.Contains(OutputStyles, style)
+}
+
+// CommandEnabled reports whether the named command can be used in the guild.
+func (g Guild) CommandEnabled(name string) bool {
+	return !slices.Contains(g.DisabledCommands, name)
+}
+
+// ModuleEnabled reports whether the named module is on in the guild.
+func (g Guild) ModuleEnabled(name string) bool {
+	return !slices.Contains(g.DisabledModules, name)
+}
+
+// RewriteRule is an admin-defined find/replace applied to links posted in a guild.
+type RewriteRule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// AutoResponse is a reply the bot posts to messages matching Trigger the way
+// Match says, MatchExact when empty.
+type AutoResponse struct {
+	Trigger  string `json:"trigger"`
+	Match    string `json:"match,omitempty"`
+	Response string `json:"response"`
+}
+
+// LoadGuild returns the stored config for a guild, or the defaults if none is saved.
+func LoadGuild(st storage.Store, guildID string) (Guild, error) {
+	var cfg Guild
+	if guildID == "" {
+		return cfg, nil
+	}
+	_, err := st.Get(GuildBucket, guildID, &cfg)
+	return cfg, err
+}
+
+// Ignored reports whether the bot should ignore a member of a guild. It is the
+// shared filter applied before messages and commands are handled. Members are
+// not ignored if the guild's settings can't be read.
+func Ignored(st storage.Store, guildID, userID string, roles []string) bool {
+	if st == nil || guildID == "" {
+		return false
+	}
+	cfg, err := LoadGuild(st, guildID)
+	if err != nil {
+		return false
+	}
+	return cfg.Ignores(userID, roles)
+}
+
+// Paused reports whether the bot is paused in a guild. A guild whose config
+// can't be read isn't.
+func Paused(st storage.Store, guildID string) bool {
+	if st == nil || guildID == "" {
+		return false
+	}
+	cfg, err := LoadGuild(st, guildID)
+	if err != nil {
+		return false
+	}
+	return cfg.Paused
+}
+
+// Trusted reports whether a member with roles may use admin commands in a
+// guild because one of them is an admin role.
+func Trusted(st storage.Store, guildID string, roles []string) bool {
+	if st == nil || guildID == "" {
+		return false
+	}
+	cfg, err := LoadGuild(st, guildID)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(roles, func(role string) bool { return slices.Contains(cfg.AdminRoles, role) })
+}
+
+// SaveGuild persists the config for a guild.
+func SaveGuild(st storage.Store, guildID string, cfg Guild) error {
+	return st.Put(GuildBucket, guildID, cfg)
+}
+
+// Forget deletes guildID's settings from st, for purges, when userID is empty,
+// and returns how many it deleted. Settings hold nothing about members.
+func Forget(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" || userID != "" {
+		return 0, nil
+	}
+	if ok, err := st.Get(GuildBucket, guildID, &Guild{}); err != nil || !ok {
+		return 0, err
+	}
+	return 1, st.Delete(GuildBucket, guildID)
+}
@@ -0,0 +1,65 @@
+// Package correlation gives each event the bot handles, such as a message or
+// a command, an ID that follows it through logs, traces, error reports and
+// replies, so a user reporting something that went wrong can be matched to
+// what the bot did.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+// New returns a new correlation ID, short enough for a user to copy into a
+// report.
+func New() string {
+	var id [6]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+type idKey struct{}
+
+// With returns a context carrying id, so the work it's passed to can tell
+// which event it's for. An empty id leaves ctx as it is.
+func With(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// From returns the correlation ID ctx carries, or "".
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// Println logs like log.Println, starting the line with the correlation ID
+// ctx carries as "correlation=<id>" so the event's lines can be searched for.
+func Println(ctx context.Context, v ...any) {
+	if id := From(ctx); id != "" {
+		v = append([]any{"correlation=" + id}, v...)
+	}
+	log.Println(v...)
+}
+
+// Printf logs like log.Printf, starting the line with the correlation ID ctx
+// carries as Println does.
+func Printf(ctx context.Context, format string, v ...any) {
+	if id := From(ctx); id != "" {
+		format = "correlation=" + id + " " + format
+	}
+	log.Printf(format, v...)
+}
+
+// Footer returns a small line naming the correlation ID id, to end a reply
+// with, or "" if id is empty.
+func Footer(id string) string {
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n-# Reference: `%s`", id)
+}
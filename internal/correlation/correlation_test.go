@@ -0,0 +1,55 @@
+package correlation
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCorrelation(t *testing.T) {
+	id := New()
+	if len(id) != 12 || id == New() {
+		t.Errorf("New = %q; want 12 random hex digits", id)
+	}
+
+	var buf bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	}()
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		log      string
+		footer   string
+		expected string
+	}{
+		{name: "With ID", ctx: With(context.Background(), "abc123"), log: "correlation=abc123 Error sending: boom\n", footer: "\n-# Reference: `abc123`", expected: "abc123"},
+		{name: "Without ID", ctx: context.Background(), log: "Error sending: boom\n"},
+		{name: "Empty ID", ctx: With(context.Background(), ""), log: "Error sending: boom\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if id := From(tc.ctx); id != tc.expected {
+				t.Errorf("From = %q; want %q", id, tc.expected)
+			}
+			if footer := Footer(From(tc.ctx)); footer != tc.footer {
+				t.Errorf("Footer = %q; want %q", footer, tc.footer)
+			}
+			buf.Reset()
+			Println(tc.ctx, "Error sending:", "boom")
+			Printf(tc.ctx, "Error sending: %s\n", "boom")
+			if lines := buf.String(); lines != strings.Repeat(tc.log, 2) {
+				t.Errorf("logged %q; want %q twice", lines, tc.log)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Package crosspost publishes messages posted in announcement channels to the
+// servers following them, staying under Discord's limit on how often one
+// channel may publish.
+package crosspost
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Limit is how many messages Discord lets one channel publish per Window.
+	Limit = 10
+	// Window is the period Limit applies to.
+	Window = time.Hour
+	// channelTTL is how long a channel's type is remembered before it's
+	// looked up again.
+	channelTTL = time.Hour
+)
+
+// ErrRateLimited is returned when a channel has published as many messages as
+// Discord allows for now.
+var ErrRateLimited = errors.New("channel publish limit reached")
+
+// Session is the subset of *discordgo.Session a Publisher uses.
+type Session interface {
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ChannelMessageCrosspost(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// Publisher crossposts messages in announcement channels and keeps count of
+// each channel's publishes, so it stops before Discord starts rejecting them
+// instead of waiting out a rate limit. It is safe for concurrent use.
+type Publisher struct {
+	mu       sync.Mutex
+	channels map[string]*channel
+	now      func() time.Time
+}
+
+// channel is what a Publisher knows about one channel.
+type channel struct {
+	news    bool
+	checked time.Time
+	// published holds when each publish in the last Window happened.
+	published []time.Time
+	// blockedUntil is set when Discord rate limits the channel anyway.
+	blockedUntil time.Time
+}
+
+// New returns a Publisher.
+func New() *Publisher {
+	return &Publisher{channels: make(map[string]*channel), now: time.Now}
+}
+
+// Publish crossposts a message if channelID is an announcement channel and
+// reports whether it did. It returns ErrRateLimited instead of publishing when
+// the channel is at its limit. A nil Publisher publishes nothing.
+func (p *Publisher) Publish(ctx context.Context, s Session, channelID, messageID string) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+	news, err := p.isNews(ctx, s, channelID)
+	if err != nil || !news {
+		return false, err
+	}
+
+	at, err := p.reserve(channelID)
+	if err != nil {
+		return false, err
+	}
+	_, err = s.ChannelMessageCrosspost(channelID, messageID, discordgo.WithContext(ctx), discordgo.WithRetryOnRatelimit(false))
+	if err == nil {
+		return true, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := p.channels[channelID]
+	if !ok {
+		return false, err
+	}
+	// Only publishes that went through count against the limit
+	if i := slices.Index(c.published, at); i >= 0 {
+		c.published = slices.Delete(c.published, i, i+1)
+	}
+	var limited *discordgo.RateLimitError
+	if errors.As(err, &limited) {
+		c.blockedUntil = p.now().Add(limited.RetryAfter)
+		return false, ErrRateLimited
+	}
+	return false, err
+}
+
+// isNews reports whether channelID is an announcement channel, looking it up
+// if it isn't known or was last checked over channelTTL ago.
+func (p *Publisher) isNews(ctx context.Context, s Session, channelID string) (bool, error) {
+	p.mu.Lock()
+	c, ok := p.channels[channelID]
+	if ok && p.now().Sub(c.checked) < channelTTL {
+		news := c.news
+		p.mu.Unlock()
+		return news, nil
+	}
+	p.mu.Unlock()
+
+	ch, err := s.Channel(channelID, discordgo.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok = p.channels[channelID]
+	if !ok {
+		c = &channel{}
+		p.channels[channelID] = c
+	}
+	c.news = ch.Type == discordgo.ChannelTypeGuildNews
+	c.checked = p.now()
+	return c.news, nil
+}
+
+// reserve counts a publish in channelID against its limit, returning when it
+// was counted, or ErrRateLimited if the channel has none left.
+func (p *Publisher) reserve(channelID string) (time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	c := p.channels[channelID]
+	if c == nil {
+		// Pruned since it was looked up
+		c = &channel{news: true, checked: now}
+		p.channels[channelID] = c
+	}
+	c.published = slices.DeleteFunc(c.published, func(at time.Time) bool { return now.Sub(at) >= Window })
+	if now.Before(c.blockedUntil) || len(c.published) >= Limit {
+		return time.Time{}, ErrRateLimited
+	}
+	c.published = append(c.published, now)
+	return now, nil
+}
+
+// Prune forgets channels whose type is due to be looked up again and that
+// haven't published within Window, returning how many were removed.
+func (p *Publisher) Prune(now time.Time) int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	removed := 0
+	for id, c := range p.channels {
+		if now.Sub(c.checked) < channelTTL || now.Before(c.blockedUntil) {
+			continue
+		}
+		if len(c.published) > 0 && now.Sub(c.published[len(c.published)-1]) < Window {
+			continue
+		}
+		delete(p.channels, id)
+		removed++
+	}
+	return removed
+}
+
+// Len returns how many channels the publisher remembers.
+func (p *Publisher) Len() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.channels)
+}
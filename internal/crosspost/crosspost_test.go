@@ -0,0 +1,103 @@
+package crosspost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeSession serves channel types and records publishes.
+type fakeSession struct {
+	types     map[string]discordgo.ChannelType
+	lookups   int
+	published []string
+	// publishErr is returned by ChannelMessageCrosspost when set.
+	publishErr error
+}
+
+func (f *fakeSession) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.lookups++
+	t, ok := f.types[channelID]
+	if !ok {
+		return nil, fmt.Errorf("unknown channel %s", channelID)
+	}
+	return &discordgo.Channel{ID: channelID, Type: t}, nil
+}
+
+func (f *fakeSession) ChannelMessageCrosspost(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if f.publishErr != nil {
+		return nil, f.publishErr
+	}
+	f.published = append(f.published, messageID)
+	return &discordgo.Message{ID: messageID, ChannelID: channelID}, nil
+}
+
+func TestPublish(t *testing.T) {
+	testCases := []struct {
+		name      string
+		channelID string
+		expected  bool
+		wantErr   bool
+	}{
+		{name: "Announcement channel", channelID: "news", expected: true},
+		{name: "Text channel", channelID: "text", expected: false},
+		{name: "Unknown channel", channelID: "gone", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeSession{types: map[string]discordgo.ChannelType{"news": discordgo.ChannelTypeGuildNews, "text": discordgo.ChannelTypeGuildText}}
+			published, err := New().Publish(context.Background(), s, tc.channelID, "msg")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Publish() error = %v; wantErr %v", err, tc.wantErr)
+			}
+			if published != tc.expected || published != (len(s.published) == 1) {
+				t.Errorf("Publish() = %v, published %q; want %v", published, s.published, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPublishLimit(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	p := New()
+	p.now = func() time.Time { return now }
+	s := &fakeSession{types: map[string]discordgo.ChannelType{"news": discordgo.ChannelTypeGuildNews}}
+
+	for n := range Limit {
+		if ok, err := p.Publish(context.Background(), s, "news", fmt.Sprint(n)); !ok || err != nil {
+			t.Fatalf("publish %d = %v, %v", n, ok, err)
+		}
+	}
+	if ok, err := p.Publish(context.Background(), s, "news", "over"); ok || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("publish over the limit = %v, %v; want ErrRateLimited", ok, err)
+	}
+	if s.lookups != 1 {
+		t.Errorf("looked the channel up %d times; want 1", s.lookups)
+	}
+
+	// Publishes free up as they leave the window
+	now = now.Add(Window)
+	if ok, err := p.Publish(context.Background(), s, "news", "later"); !ok || err != nil {
+		t.Errorf("publish an hour later = %v, %v", ok, err)
+	}
+
+	// Discord's own rate limit blocks the channel until it says to retry
+	now = now.Add(2 * Window)
+	s.publishErr = &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{TooManyRequests: &discordgo.TooManyRequests{RetryAfter: time.Minute}}}
+	if _, err := p.Publish(context.Background(), s, "news", "limited"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("rate limited publish error = %v; want ErrRateLimited", err)
+	}
+	s.publishErr = nil
+	if _, err := p.Publish(context.Background(), s, "news", "blocked"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("publish while blocked error = %v; want ErrRateLimited", err)
+	}
+	now = now.Add(time.Minute)
+	if ok, err := p.Publish(context.Background(), s, "news", "unblocked"); !ok || err != nil {
+		t.Errorf("publish after the retry time = %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,321 @@
+// Package dashboard serves a small web dashboard where server admins sign in
+// with Discord and change their guild's settings in a browser. It writes to
+// the same store the bot reads, so changes apply to the next message.
+package dashboard
+
+import (
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/stats"
+	"go-discord-bot/internal/storage"
+)
+
+const (
+	// sessionCookie holds the signed-in session token.
+	sessionCookie = "session"
+	// stateCookie holds the OAuth2 state while the browser is away signing in.
+	stateCookie = "oauth_state"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// Channels lists a guild's channels through the bot's own connection.
+// *discordgo.Session implements it.
+type Channels interface {
+	GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error)
+}
+
+// Config holds the Discord application credentials used to sign admins in.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	// BaseURL is the dashboard's public address, such as https://bot.example.com.
+	// Discord must list BaseURL + "/callback" as a redirect of the application.
+	BaseURL string
+	// Client makes the OAuth2 requests to Discord, nil for http.DefaultClient.
+	Client *http.Client
+}
+
+// Server is the dashboard's HTTP handler.
+type Server struct {
+	// Store holds the guild settings the dashboard edits.
+	Store storage.Store
+	// Bot lists channels, which also checks that the bot is in a guild.
+	Bot Channels
+	// Stats is shown on each guild's page. Nil shows no stats.
+	Stats *stats.Collector
+	// Fixers names the fixers admins can turn on and off.
+	Fixers []string
+
+	oauth    oauth
+	sessions *sessions
+	secure   bool
+	mux      *http.ServeMux
+}
+
+// New returns a dashboard that signs admins in with the given application.
+func New(cfg Config) *Server {
+	base := strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	s := &Server{
+		oauth: oauth{
+			clientID:     cfg.ClientID,
+			clientSecret: cfg.ClientSecret,
+			redirectURL:  base + "/callback",
+			apiBase:      discordAPI,
+			client:       cfg.Client,
+		},
+		sessions: newSessions(),
+		secure:   strings.HasPrefix(base, "https://"),
+		mux:      http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /{$}", s.index)
+	s.mux.HandleFunc("GET /login", s.login)
+	s.mux.HandleFunc("GET /callback", s.callback)
+	s.mux.HandleFunc("POST /logout", s.logout)
+	s.mux.HandleFunc("GET /guilds/{id}", s.guild)
+	s.mux.HandleFunc("POST /guilds/{id}", s.saveGuild)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'")
+	s.mux.ServeHTTP(w, r)
+}
+
+// guildLink is a guild in the list on the front page.
+type guildLink struct {
+	ID   string
+	Name string
+}
+
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(r)
+	if sess == nil {
+		render(w, "login.html", nil)
+		return
+	}
+	var guilds []guildLink
+	for id, name := range sess.user.Guilds {
+		guilds = append(guilds, guildLink{ID: id, Name: name})
+	}
+	sort.Slice(guilds, func(i, j int) bool { return guilds[i].Name < guilds[j].Name })
+	render(w, "index.html", map[string]any{"User": sess.user.Username, "CSRF": sess.csrf, "Guilds": guilds})
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	state := randomToken()
+	http.SetCookie(w, s.cookie(stateCookie, state, 600))
+	http.Redirect(w, r, s.oauth.authorizeURL(state), http.StatusFound)
+}
+
+func (s *Server) callback(w http.ResponseWriter, r *http.Request) {
+	state, err := r.Cookie(stateCookie)
+	if err != nil || state.Value == "" || r.FormValue("state") != state.Value {
+		http.Error(w, "Sign-in expired, try again.", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, s.cookie(stateCookie, "", -1))
+
+	u, err := s.oauth.login(r.Context(), r.FormValue("code"))
+	if err != nil {
+		log.Println("Error signing in to the dashboard:", err)
+		http.Error(w, "Couldn't sign in with Discord, try again.", http.StatusBadGateway)
+		return
+	}
+	http.SetCookie(w, s.cookie(sessionCookie, s.sessions.create(u), int(sessionLifetime.Seconds())))
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	if sess := s.session(r); sess != nil && r.FormValue("csrf") == sess.csrf {
+		c, _ := r.Cookie(sessionCookie)
+		s.sessions.delete(c.Value)
+	}
+	http.SetCookie(w, s.cookie(sessionCookie, "", -1))
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// option is a checkbox on the guild page.
+type option struct {
+	Value   string
+	Label   string
+	Checked bool
+}
+
+func (s *Server) guild(w http.ResponseWriter, r *http.Request) {
+	sess, guildID, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+	channels, ok := s.textChannels(w, guildID)
+	if !ok {
+		return
+	}
+	cfg, err := config.LoadGuild(s.Store, guildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		http.Error(w, "Couldn't load this server's settings, try again later.", http.StatusInternalServerError)
+		return
+	}
+
+	var fixerOptions []option
+	for _, name := range s.Fixers {
+		fixerOptions = append(fixerOptions, option{Value: name, Label: name, Checked: cfg.FixerEnabled(name)})
+	}
+	var channelOptions []option
+	for _, c := range channels {
+		channelOptions = append(channelOptions, option{Value: c.ID, Label: "#" + c.Name, Checked: slices.Contains(cfg.Channels, c.ID)})
+	}
+
+	render(w, "guild.html", map[string]any{
+		"ID":        guildID,
+		"Name":      sess.user.Guilds[guildID],
+		"CSRF":      sess.csrf,
+		"Saved":     r.FormValue("saved") != "",
+		"Fixers":    fixerOptions,
+		"Channels":  channelOptions,
+		"Reply":     cfg.RepostMode == config.RepostReply,
+		"Private":   logging.Private(guildID),
+		"ShowStats": s.Stats != nil,
+		"Stats":     s.Stats.Guild(guildID),
+	})
+}
+
+func (s *Server) saveGuild(w http.ResponseWriter, r *http.Request) {
+	sess, guildID, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+	if r.FormValue("csrf") != sess.csrf {
+		http.Error(w, "This form expired, reload the page and try again.", http.StatusForbidden)
+		return
+	}
+	channels, ok := s.textChannels(w, guildID)
+	if !ok {
+		return
+	}
+	cfg, err := config.LoadGuild(s.Store, guildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		http.Error(w, "Couldn't load this server's settings, try again later.", http.StatusInternalServerError)
+		return
+	}
+
+	applyForm(&cfg, r.Form, s.Fixers, channels)
+	if err := config.SaveGuild(s.Store, guildID, cfg); err != nil {
+		log.Println("Error saving guild config:", err)
+		http.Error(w, "Couldn't save this server's settings, try again later.", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/guilds/"+guildID+"?saved=1", http.StatusSeeOther)
+}
+
+// applyForm updates cfg from the guild page's form. Unknown fixers and channels
+// outside the guild are ignored.
+func applyForm(cfg *config.Guild, form map[string][]string, fixerNames []string, channels []*discordgo.Channel) {
+	enabled := form["fixer"]
+	cfg.DisabledFixers = nil
+	for _, name := range fixerNames {
+		if !slices.Contains(enabled, name) {
+			cfg.DisabledFixers = append(cfg.DisabledFixers, name)
+		}
+	}
+
+	selected := form["channel"]
+	cfg.Channels = nil
+	for _, c := range channels {
+		if slices.Contains(selected, c.ID) {
+			cfg.Channels = append(cfg.Channels, c.ID)
+		}
+	}
+
+	// The checkbox only switches between new messages and replies, so leave
+	// other modes set through commands alone
+	if slices.Contains(form["repost_mode"], config.RepostReply) {
+		cfg.RepostMode = config.RepostReply
+	} else if cfg.RepostMode == config.RepostReply {
+		cfg.RepostMode = ""
+	}
+}
+
+// session returns the signed-in session for a request, or nil.
+func (s *Server) session(r *http.Request) *session {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	return s.sessions.get(c.Value)
+}
+
+// authorize checks that the request comes from someone who can manage the guild
+// in its path, writing an error response if not.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) (*session, string, bool) {
+	sess := s.session(r)
+	if sess == nil {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return nil, "", false
+	}
+	guildID := r.PathValue("id")
+	if _, ok := sess.user.Guilds[guildID]; !ok {
+		http.Error(w, "You can't manage that server.", http.StatusForbidden)
+		return nil, "", false
+	}
+	return sess, guildID, true
+}
+
+// textChannels lists the guild's channels links can be fixed in, writing an
+// error response if the bot can't see the guild.
+func (s *Server) textChannels(w http.ResponseWriter, guildID string) ([]*discordgo.Channel, bool) {
+	all, err := s.Bot.GuildChannels(guildID)
+	if err != nil {
+		http.Error(w, "The bot isn't in that server. Invite it first.", http.StatusNotFound)
+		return nil, false
+	}
+	var channels []*discordgo.Channel
+	for _, c := range all {
+		if c.Type == discordgo.ChannelTypeGuildText || c.Type == discordgo.ChannelTypeGuildNews {
+			channels = append(channels, c)
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Position < channels[j].Position })
+	return channels, true
+}
+
+// cookie builds a cookie only the dashboard can read.
+func (s *Server) cookie(name, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   s.secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// render writes a template, logging failures.
+func render(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		log.Println("Error rendering dashboard page:", err)
+	}
+}
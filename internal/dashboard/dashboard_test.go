@@ -0,0 +1,139 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// fakeChannels knows the channels of guild "1" only.
+type fakeChannels struct{}
+
+func (fakeChannels) GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error) {
+	if guildID != "1" {
+		return nil, errors.New("unknown guild")
+	}
+	return []*discordgo.Channel{
+		{ID: "general", Name: "general", Type: discordgo.ChannelTypeGuildText},
+		{ID: "voice", Name: "voice", Type: discordgo.ChannelTypeGuildVoice},
+		{ID: "links", Name: "links", Type: discordgo.ChannelTypeGuildText, Position: 1},
+	}, nil
+}
+
+// newTestServer returns a dashboard and the cookie of a user who manages guilds "1" and "2".
+func newTestServer(t *testing.T) (*Server, *http.Cookie, string) {
+	t.Helper()
+	s := New(Config{ClientID: "app", ClientSecret: "secret", BaseURL: "https://dash.example"})
+	s.Store = storage.NewMemory()
+	s.Bot = fakeChannels{}
+	s.Fixers = []string{"twitter", "twitch"}
+
+	token := s.sessions.create(&user{ID: "u", Username: "admin", Guilds: map[string]string{"1": "One", "2": "Two"}})
+	return s, &http.Cookie{Name: sessionCookie, Value: token}, s.sessions.get(token).csrf
+}
+
+func TestGuildPageAccess(t *testing.T) {
+	s, cookie, _ := newTestServer(t)
+
+	testCases := []struct {
+		name     string
+		path     string
+		cookie   *http.Cookie
+		expected int
+	}{
+		{name: "Signed out", path: "/guilds/1", expected: http.StatusSeeOther},
+		{name: "Managed guild", path: "/guilds/1", cookie: cookie, expected: http.StatusOK},
+		{name: "Guild the bot isn't in", path: "/guilds/2", cookie: cookie, expected: http.StatusNotFound},
+		{name: "Guild the user doesn't manage", path: "/guilds/3", cookie: cookie, expected: http.StatusForbidden},
+		{name: "Unknown session", path: "/guilds/1", cookie: &http.Cookie{Name: sessionCookie, Value: "nope"}, expected: http.StatusSeeOther},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != tc.expected {
+				t.Errorf("GET %s = %d; want %d", tc.path, rec.Code, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSaveGuild(t *testing.T) {
+	s, cookie, csrf := newTestServer(t)
+
+	post := func(form url.Values) int {
+		req := httptest.NewRequest(http.MethodPost, "/guilds/1", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	form := url.Values{"fixer": {"twitter", "bogus"}, "channel": {"links", "voice", "elsewhere"}, "repost_mode": {"reply"}}
+	if code := post(form); code != http.StatusForbidden {
+		t.Errorf("POST without CSRF token = %d; want %d", code, http.StatusForbidden)
+	}
+	if cfg, _ := config.LoadGuild(s.Store, "1"); len(cfg.DisabledFixers) != 0 {
+		t.Errorf("settings changed without a CSRF token: %+v", cfg)
+	}
+
+	form.Set("csrf", csrf)
+	if code := post(form); code != http.StatusSeeOther {
+		t.Fatalf("POST = %d; want %d", code, http.StatusSeeOther)
+	}
+	cfg, err := config.LoadGuild(s.Store, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.DisabledFixers, []string{"twitch"}) || !slices.Equal(cfg.Channels, []string{"links"}) || cfg.RepostMode != config.RepostReply {
+		t.Errorf("saved %+v; want twitch disabled, only #links and reply mode", cfg)
+	}
+}
+
+func TestOAuthLogin(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/token":
+			if id, secret, _ := r.BasicAuth(); id != "app" || secret != "secret" || r.FormValue("code") != "code" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"access_token":"token"}`))
+		case "/users/@me":
+			w.Write([]byte(`{"id":"u","username":"admin"}`))
+		case "/users/@me/guilds":
+			w.Write([]byte(`[{"id":"1","name":"Owned","owner":true,"permissions":"0"},
+				{"id":"2","name":"Managed","permissions":"32"},
+				{"id":"3","name":"Member","permissions":"1024"}]`))
+		}
+	}))
+	defer api.Close()
+
+	o := oauth{clientID: "app", clientSecret: "secret", apiBase: api.URL, client: api.Client()}
+	u, err := o.login(context.Background(), "code")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if u.Username != "admin" || len(u.Guilds) != 2 || u.Guilds["1"] != "Owned" || u.Guilds["2"] != "Managed" {
+		t.Errorf("login = %+v; want admin managing guilds 1 and 2", u)
+	}
+	if _, err := o.login(context.Background(), "wrong"); err == nil {
+		t.Errorf("login with a bad code succeeded")
+	}
+}
@@ -0,0 +1,118 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// discordAPI is the Discord REST API the OAuth2 flow talks to.
+const discordAPI = "https://discord.com/api/v10"
+
+// oauth signs admins in with Discord's OAuth2 authorization code flow.
+type oauth struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	apiBase      string
+	client       *http.Client
+}
+
+// authorizeURL returns where to send a browser to sign in, carrying state back to the callback.
+func (o oauth) authorizeURL(state string) string {
+	q := url.Values{
+		"client_id":     {o.clientID},
+		"redirect_uri":  {o.redirectURL},
+		"response_type": {"code"},
+		"scope":         {"identify guilds"},
+		"state":         {state},
+		"prompt":        {"none"},
+	}
+	return "https://discord.com/oauth2/authorize?" + q.Encode()
+}
+
+// user is the signed-in Discord account and the guilds it can manage.
+type user struct {
+	ID       string
+	Username string
+	// Guilds maps the ID of each guild the user can manage to its name.
+	Guilds map[string]string
+}
+
+// login exchanges an authorization code for a token and looks up who it belongs to.
+func (o oauth) login(ctx context.Context, code string) (*user, error) {
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {o.redirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.apiBase+"/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(o.clientID, o.clientSecret)
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := o.do(req, &token); err != nil {
+		return nil, fmt.Errorf("exchanging code: %w", err)
+	}
+
+	var me struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}
+	if err := o.get(ctx, token.AccessToken, "/users/@me", &me); err != nil {
+		return nil, fmt.Errorf("fetching user: %w", err)
+	}
+
+	var guilds []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Owner       bool   `json:"owner"`
+		Permissions string `json:"permissions"`
+	}
+	if err := o.get(ctx, token.AccessToken, "/users/@me/guilds", &guilds); err != nil {
+		return nil, fmt.Errorf("fetching guilds: %w", err)
+	}
+
+	u := &user{ID: me.ID, Username: me.Username, Guilds: make(map[string]string)}
+	for _, g := range guilds {
+		perms, _ := strconv.ParseInt(g.Permissions, 10, 64)
+		if g.Owner || perms&(discordgo.PermissionManageServer|discordgo.PermissionAdministrator) != 0 {
+			u.Guilds[g.ID] = g.Name
+		}
+	}
+	return u, nil
+}
+
+// get fetches an API path with the user's access token and decodes the JSON response into v.
+func (o oauth) get(ctx context.Context, token, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.apiBase+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return o.do(req, v)
+}
+
+// do sends req and decodes the JSON response into v.
+func (o oauth) do(req *http.Request, v any) error {
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("discord returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,76 @@
+package dashboard
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// sessionLifetime is how long a sign-in lasts. The guilds a user can manage are
+// read when they sign in, so this also bounds how stale they can get.
+const sessionLifetime = 12 * time.Hour
+
+// session is a signed-in browser.
+type session struct {
+	user *user
+	// csrf must be sent with every form so other sites can't post on the user's behalf.
+	csrf    string
+	expires time.Time
+}
+
+// sessions holds the signed-in browsers, keyed by the token in their cookie.
+type sessions struct {
+	now func() time.Time
+
+	mu   sync.Mutex
+	byID map[string]*session
+}
+
+func newSessions() *sessions {
+	return &sessions{now: time.Now, byID: make(map[string]*session)}
+}
+
+// create signs a user in and returns the token for their cookie.
+func (ss *sessions) create(u *user) string {
+	id := randomToken()
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	now := ss.now()
+	for id, sess := range ss.byID {
+		if now.After(sess.expires) {
+			delete(ss.byID, id)
+		}
+	}
+	ss.byID[id] = &session{user: u, csrf: randomToken(), expires: now.Add(sessionLifetime)}
+	return id
+}
+
+// get returns the session for a cookie token, or nil if it's unknown or expired.
+func (ss *sessions) get(id string) *session {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	sess := ss.byID[id]
+	if sess == nil || ss.now().After(sess.expires) {
+		delete(ss.byID, id)
+		return nil
+	}
+	return sess
+}
+
+// delete signs a session out.
+func (ss *sessions) delete(id string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.byID, id)
+}
+
+// randomToken returns an unguessable URL-safe token.
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,197 @@
+// Package dedupe remembers recent reposts so the same link isn't fixed twice in a guild.
+package dedupe
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// sharedTimeout is how long a call to the Shared store may take.
+const sharedTimeout = 2 * time.Second
+
+// Shared is a store several replicas of the bot share, such as Redis, so a
+// repost one of them made is known to the others.
+type Shared interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+}
+
+// Repost is where a link was last fixed.
+type Repost struct {
+	ChannelID string
+	MessageID string
+	At        time.Time
+}
+
+type key struct {
+	guildID string
+	id      string
+}
+
+// Tracker remembers, per guild, the repost each ID was last fixed in for Window.
+// A nil Tracker remembers nothing.
+type Tracker struct {
+	// Shared, if not nil, also keeps the reposts for other replicas. When it
+	// fails the tracker goes on with what it remembers itself.
+	Shared Shared
+
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	reposts   map[key]Repost
+	lastSweep time.Time
+}
+
+// New returns a Tracker that remembers reposts for window.
+func New(window time.Duration) *Tracker {
+	return &Tracker{window: window, now: time.Now, reposts: make(map[key]Repost)}
+}
+
+// Lookup returns the repost id was fixed in within the window, if any.
+func (t *Tracker) Lookup(guildID, id string) (Repost, bool) {
+	if t == nil {
+		return Repost{}, false
+	}
+	t.mu.Lock()
+	r, ok := t.reposts[key{guildID, id}]
+	now := t.now()
+	t.mu.Unlock()
+	if ok && now.Sub(r.At) < t.window {
+		return r, true
+	}
+	if t.Shared == nil {
+		return Repost{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sharedTimeout)
+	defer cancel()
+	value, ok, err := t.Shared.Get(ctx, sharedKey("repost", guildID, id))
+	if err != nil {
+		log.Println("Error looking up shared repost:", err)
+		return Repost{}, false
+	}
+	if !ok || json.Unmarshal([]byte(value), &r) != nil || now.Sub(r.At) >= t.window {
+		return Repost{}, false
+	}
+	return r, true
+}
+
+// Record remembers that id was fixed in the given message.
+func (t *Tracker) Record(guildID, id, channelID, messageID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	now := t.now()
+	r := Repost{ChannelID: channelID, MessageID: messageID, At: now}
+	t.reposts[key{guildID, id}] = r
+
+	// Forget expired reposts once per window so the map doesn't grow forever
+	if now.Sub(t.lastSweep) >= t.window {
+		t.prune(now)
+	}
+	t.mu.Unlock()
+
+	if t.Shared == nil {
+		return
+	}
+	value, err := json.Marshal(r)
+	if err != nil {
+		log.Println("Error encoding shared repost:", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedTimeout)
+	defer cancel()
+	if err := t.Shared.Set(ctx, sharedKey("repost", guildID, id), string(value), t.window); err != nil {
+		log.Println("Error sharing repost:", err)
+	}
+}
+
+// sharedKey is the key of an ID in a guild in the Shared store.
+func sharedKey(kind, guildID, id string) string {
+	return "dedupe:" + kind + ":" + guildID + "/" + id
+}
+
+// Prune forgets reposts that are out of the window at now and returns how many it forgot.
+func (t *Tracker) Prune(now time.Time) int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.prune(now)
+}
+
+// Len returns how many reposts the tracker remembers.
+func (t *Tracker) Len() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.reposts)
+}
+
+// prune does the work of Prune. Callers must hold t.mu.
+func (t *Tracker) prune(now time.Time) int {
+	pruned := 0
+	for k, r := range t.reposts {
+		if now.Sub(r.At) >= t.window {
+			delete(t.reposts, k)
+			pruned++
+		}
+	}
+	t.lastSweep = now
+	return pruned
+}
+
+// claimWindow is how long a replica keeps a message it claimed, so edits to
+// it are handled by the same replica.
+const claimWindow = time.Hour
+
+// Claims decides which of several replicas of the bot sharing a store
+// handles each message, so only one of them acts on it. A nil Claims leaves
+// every message to this replica.
+type Claims struct {
+	shared  Shared
+	replica string
+}
+
+// NewClaims returns Claims made through shared.
+func NewClaims(shared Shared) *Claims {
+	var replica [8]byte
+	rand.Read(replica[:])
+	return &Claims{shared: shared, replica: hex.EncodeToString(replica[:])}
+}
+
+// Claim reports whether this replica should handle the message with the
+// given ID, rather than another one sharing the store. The first to claim a
+// message keeps it for an hour. When the store fails, every message is this
+// replica's.
+func (c *Claims) Claim(guildID, messageID string) bool {
+	if c == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedTimeout)
+	defer cancel()
+	k := sharedKey("claim", guildID, messageID)
+	claimed, err := c.shared.SetNX(ctx, k, c.replica, claimWindow)
+	if err == nil && !claimed {
+		// It may be this replica's from before the message was edited
+		var owner string
+		owner, claimed, err = c.shared.Get(ctx, k)
+		claimed = claimed && owner == c.replica
+	}
+	if err != nil {
+		log.Println("Error claiming message:", err)
+		return true
+	}
+	return claimed
+}
@@ -0,0 +1,136 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryShared is a Shared store in memory that never expires keys.
+type memoryShared struct {
+	mu   sync.Mutex
+	keys map[string]string
+	err  error
+}
+
+func (m *memoryShared) Get(_ context.Context, key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.keys[key]
+	return v, ok, m.err
+}
+
+func (m *memoryShared) Set(_ context.Context, key, value string, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key] = value
+	return m.err
+}
+
+func (m *memoryShared) SetNX(_ context.Context, key, value string, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[key]; ok || m.err != nil {
+		return false, m.err
+	}
+	m.keys[key] = value
+	return true, nil
+}
+
+func TestTracker(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := New(time.Hour)
+	tr.now = func() time.Time { return clock }
+
+	tr.Record("guild", "1", "chan", "msg")
+
+	testCases := []struct {
+		name     string
+		advance  time.Duration
+		guildID  string
+		id       string
+		expected bool
+	}{
+		{name: "Same guild within window", advance: 30 * time.Minute, guildID: "guild", id: "1", expected: true},
+		{name: "Other guild", guildID: "other", id: "1", expected: false},
+		{name: "Other ID", guildID: "guild", id: "2", expected: false},
+		{name: "After window", advance: 30 * time.Minute, guildID: "guild", id: "1", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock = clock.Add(tc.advance)
+			r, ok := tr.Lookup(tc.guildID, tc.id)
+			if ok != tc.expected {
+				t.Fatalf("Lookup(%q, %q) found = %v; want %v", tc.guildID, tc.id, ok, tc.expected)
+			}
+			if ok && (r.ChannelID != "chan" || r.MessageID != "msg") {
+				t.Errorf("Lookup returned %+v", r)
+			}
+		})
+	}
+
+	tr.Record("guild", "2", "chan", "msg2")
+	if len(tr.reposts) != 1 {
+		t.Errorf("tracker kept %d reposts after expiry; want 1", len(tr.reposts))
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	var tr *Tracker
+	tr.Record("guild", "1", "chan", "msg")
+	if _, ok := tr.Lookup("guild", "1"); ok {
+		t.Errorf("nil tracker found a repost")
+	}
+}
+
+func TestTrackerShared(t *testing.T) {
+	shared := &memoryShared{keys: make(map[string]string)}
+	first, second := New(time.Hour), New(time.Hour)
+	first.Shared, second.Shared = shared, shared
+
+	first.Record("guild", "1", "chan", "msg")
+	if r, ok := second.Lookup("guild", "1"); !ok || r.ChannelID != "chan" || r.MessageID != "msg" {
+		t.Errorf("other replica's Lookup = %+v, %v; want the repost", r, ok)
+	}
+	if _, ok := second.Lookup("other", "1"); ok {
+		t.Error("other replica found a repost in another guild")
+	}
+
+	// Replicas go on alone while the store fails
+	shared.err = errors.New("down")
+	if _, ok := second.Lookup("guild", "2"); ok {
+		t.Error("Lookup found a repost while the store is down")
+	}
+}
+
+func TestClaims(t *testing.T) {
+	shared := &memoryShared{keys: make(map[string]string)}
+	first, second := NewClaims(shared), NewClaims(shared)
+
+	if !first.Claim("guild", "msg") {
+		t.Error("first claim failed")
+	}
+	if second.Claim("guild", "msg") {
+		t.Error("other replica claimed a message already claimed")
+	}
+	if !first.Claim("guild", "msg") {
+		t.Error("replica lost its own claim")
+	}
+	if !second.Claim("guild", "other") {
+		t.Error("other replica couldn't claim another message")
+	}
+
+	// Replicas go on alone while the store fails
+	shared.err = errors.New("down")
+	if !second.Claim("guild", "msg") {
+		t.Error("Claim failed while the store is down")
+	}
+
+	var nilClaims *Claims
+	if !nilClaims.Claim("guild", "msg") {
+		t.Error("nil Claims didn't leave the message to this replica")
+	}
+}
@@ -0,0 +1,293 @@
+// Package digest copies fixed tweet links into a guild's archive channel,
+// either as they're fixed or as one digest embed a day, so servers get a
+// browsable feed of what was shared.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/locale"
+	"go-discord-bot/internal/outbound"
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding each guild's links waiting for the daily
+// digest, keyed by guild ID.
+const Bucket = "link_digest"
+
+// maxEmbedDescription is how much text fits in an embed's description.
+const maxEmbedDescription = 4096
+
+// maxPending is how many links a guild's daily digest holds; later links are dropped.
+const maxPending = 500
+
+// Entry is one fixed link and where it was shared.
+type Entry struct {
+	Link      string    `json:"link"`
+	ChannelID string    `json:"channel_id"`
+	MessageID string    `json:"message_id"`
+	AuthorID  string    `json:"author_id"`
+	At        time.Time `json:"at"`
+}
+
+// Sender posts messages. *discordgo.Session satisfies it.
+type Sender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// Digest records fixed links for guilds with a digest channel. A nil Digest
+// records nothing.
+type Digest struct {
+	// Locale returns a guild's language as Discord has it, which digests
+	// write counts and dates like unless the guild picked another. Nil
+	// writes them like US English.
+	Locale func(guildID string) string
+
+	store storage.Store
+	// mu serializes updates to the pending lists.
+	mu sync.Mutex
+}
+
+// New returns a Digest keeping pending links in st.
+func New(st storage.Store) *Digest {
+	return &Digest{store: st}
+}
+
+// Record archives links fixed in a guild according to its settings: posted
+// to the digest channel right away, or queued for the daily digest. Links
+// fixed in the digest channel itself are skipped.
+func (d *Digest) Record(ctx context.Context, s Sender, guildID string, cfg config.Guild, entries []Entry) error {
+	if d == nil || guildID == "" || cfg.DigestChannel == "" || len(entries) == 0 {
+		return nil
+	}
+	if entries[0].ChannelID == cfg.DigestChannel {
+		return nil
+	}
+
+	if cfg.DigestMode != config.DigestDaily {
+		return post(ctx, s, cfg.DigestChannel, guildID, "", entries)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var pending []Entry
+	if _, err := d.store.Get(Bucket, guildID, &pending); err != nil {
+		return err
+	}
+	if room := maxPending - len(pending); len(entries) > room {
+		entries = entries[:max(room, 0)]
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return d.store.Put(Bucket, guildID, append(pending, entries...))
+}
+
+// Flush posts the daily digest of every guild with links queued before its
+// digest was last due, at the guild's digest hour in its time zone. Links that
+// fail to post stay queued. The announcements scheduler calls it every minute.
+func (d *Digest) Flush(ctx context.Context, s Sender, now time.Time) {
+	if d == nil {
+		return
+	}
+	// Nobody is waiting on a digest, so it goes after other messages
+	ctx = outbound.WithPriority(ctx, outbound.Low)
+	for _, guildID := range d.store.Keys(Bucket) {
+		if err := d.flushGuild(ctx, s, guildID, now); err != nil {
+			log.Println("Error posting link digest:", err)
+		}
+	}
+}
+
+// flushGuild posts one guild's links from before its digest was last due.
+func (d *Digest) flushGuild(ctx context.Context, s Sender, guildID string, now time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var pending []Entry
+	if _, err := d.store.Get(Bucket, guildID, &pending); err != nil {
+		return err
+	}
+	cfg, err := config.LoadGuild(d.store, guildID)
+	if err != nil {
+		return err
+	}
+	// The guild switched the digest off or to live posting since queueing
+	if cfg.DigestChannel == "" || cfg.DigestMode != config.DigestDaily {
+		return d.store.Delete(Bucket, guildID)
+	}
+	// Links keep until the guild resumes
+	if cfg.Paused {
+		return nil
+	}
+
+	cutoff := Cutoff(now, cfg.Location(), cfg.DigestHour)
+	var due, later []Entry
+	for _, e := range pending {
+		if e.At.Before(cutoff) {
+			due = append(due, e)
+		} else {
+			later = append(later, e)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	_, err = s.ChannelMessageSendComplex(cfg.DigestChannel, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{dailyEmbed(cfg.LocaleOr(d.locale(guildID)), guildID, due, cutoff)},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if len(later) == 0 {
+		return d.store.Delete(Bucket, guildID)
+	}
+	return d.store.Put(Bucket, guildID, later)
+}
+
+// Forget deletes from st, for purges, the links waiting for the digest of guildID, or with
+// userID those of that member, in guildID or in every guild if guildID is
+// empty. It returns how many it deleted.
+func Forget(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" && userID == "" {
+		return 0, nil
+	}
+	keys := []string{guildID}
+	if guildID == "" {
+		keys = st.Keys(Bucket)
+	}
+	deleted := 0
+	for _, k := range keys {
+		var kept []Entry
+		if ok, err := st.Get(Bucket, k, &kept); err != nil || !ok {
+			return deleted, err
+		}
+		n := len(kept)
+		if userID == "" {
+			kept = nil
+		} else {
+			kept = slices.DeleteFunc(kept, func(e Entry) bool { return e.AuthorID == userID })
+		}
+		if len(kept) == n {
+			continue
+		}
+		deleted += n - len(kept)
+		var err error
+		if len(kept) == 0 {
+			err = st.Delete(Bucket, k)
+		} else {
+			err = st.Put(Bucket, k, kept)
+		}
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// locale returns the language of a guild, or "" without d.Locale.
+func (d *Digest) locale(guildID string) string {
+	if d.Locale == nil {
+		return ""
+	}
+	return d.Locale(guildID)
+}
+
+// Cutoff returns when the last daily digest due by now was: the latest hour
+// o'clock in loc that isn't after now. Links shared before it are in it.
+func Cutoff(now time.Time, loc *time.Location, hour int) time.Time {
+	local := now.In(loc)
+	cutoff := time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, loc)
+	if cutoff.After(now) {
+		cutoff = time.Date(local.Year(), local.Month(), local.Day()-1, hour, 0, 0, 0, loc)
+	}
+	return cutoff
+}
+
+// shared is a link in a daily digest with how often it was shared.
+type shared struct {
+	first   Entry
+	count   int
+	authors map[string]bool
+}
+
+// dailyEmbed builds the daily digest of entries, as of cutoff: each link once,
+// most shared first, with counts of links and who shared them written the way
+// locale lang does. Links past what an embed can hold are only counted.
+func dailyEmbed(lang, guildID string, entries []Entry, cutoff time.Time) *discordgo.MessageEmbed {
+	var links []*shared
+	byLink := map[string]*shared{}
+	authors := map[string]bool{}
+	for _, e := range entries {
+		authors[e.AuthorID] = true
+		l := byLink[e.Link]
+		if l == nil {
+			l = &shared{first: e, authors: map[string]bool{}}
+			byLink[e.Link] = l
+			links = append(links, l)
+		}
+		l.count++
+		l.authors[e.AuthorID] = true
+	}
+	// Most shared first, then in the order they were first shared
+	slices.SortStableFunc(links, func(a, b *shared) int { return b.count - a.count })
+
+	var b strings.Builder
+	for n, l := range links {
+		line := fmt.Sprintf("%s shared by <@%s> in https://discord.com/channels/%s/%s/%s", l.first.Link, l.first.AuthorID, guildID, l.first.ChannelID, l.first.MessageID)
+		if l.count > 1 {
+			line = fmt.Sprintf("**%s×** %s, first by <@%s> in https://discord.com/channels/%s/%s/%s", locale.Count(lang, l.count), l.first.Link, l.first.AuthorID, guildID, l.first.ChannelID, l.first.MessageID)
+		}
+		more := "\n…and " + locale.Count(lang, len(links)-n) + " more"
+		if b.Len()+len(line)+1+len(more) > maxEmbedDescription {
+			b.WriteString(more)
+			break
+		}
+		if n > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Daily link digest for " + locale.Date(lang, cutoff.AddDate(0, 0, -1)),
+		Description: b.String(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Links shared", Value: locale.Count(lang, len(entries)), Inline: true},
+			{Name: "Different links", Value: locale.Count(lang, len(links)), Inline: true},
+			{Name: "Members sharing", Value: locale.Count(lang, len(authors)), Inline: true},
+		},
+		Timestamp: cutoff.Format(time.RFC3339),
+	}
+}
+
+// post sends entries to channelID, one line each under header, split across
+// as many messages as needed.
+func post(ctx context.Context, s Sender, channelID, guildID, header string, entries []Entry) error {
+	lines := make([]string, len(entries))
+	for n, e := range entries {
+		lines[n] = fmt.Sprintf("%s shared by <@%s> in https://discord.com/channels/%s/%s/%s", e.Link, e.AuthorID, guildID, e.ChannelID, e.MessageID)
+	}
+	for _, piece := range chunk.Split(header+strings.Join(lines, "\n"), chunk.MaxMessageLength) {
+		_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content:         piece,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// fakeSender records the messages posted to each channel, and the
+// descriptions of their embeds.
+type fakeSender struct {
+	posted []string
+}
+
+func (f *fakeSender) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	content := data.Content
+	for _, embed := range data.Embeds {
+		content += embed.Description
+	}
+	f.posted = append(f.posted, channelID+": "+content)
+	return &discordgo.Message{ChannelID: channelID}, nil
+}
+
+func entry(link string, at time.Time) Entry {
+	return Entry{Link: link, ChannelID: "chan", MessageID: "msg", AuthorID: "user", At: at}
+}
+
+func TestRecord(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name    string
+		cfg     config.Guild
+		posted  []string
+		pending int
+	}{
+		{name: "Off", cfg: config.Guild{}},
+		{
+			name:   "Live",
+			cfg:    config.Guild{DigestChannel: "archive"},
+			posted: []string{"archive: https://fixupx.com/a/status/1 shared by <@user> in https://discord.com/channels/guild/chan/msg"},
+		},
+		{name: "Daily", cfg: config.Guild{DigestChannel: "archive", DigestMode: config.DigestDaily}, pending: 1},
+		{name: "Fixed in the digest channel", cfg: config.Guild{DigestChannel: "chan"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			d := New(st)
+			s := &fakeSender{}
+			if err := d.Record(context.Background(), s, "guild", tc.cfg, []Entry{entry("https://fixupx.com/a/status/1", at)}); err != nil {
+				t.Fatalf("Record: %v", err)
+			}
+
+			if !slices.Equal(s.posted, tc.posted) {
+				t.Errorf("posted %q; want %q", s.posted, tc.posted)
+			}
+			var pending []Entry
+			st.Get(Bucket, "guild", &pending)
+			if len(pending) != tc.pending {
+				t.Errorf("%d links pending; want %d", len(pending), tc.pending)
+			}
+		})
+	}
+}
+
+func TestFlush(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "guild", config.Guild{DigestChannel: "archive", DigestMode: config.DigestDaily}); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	d := New(st)
+	s := &fakeSender{}
+	yesterday := time.Date(2024, 4, 30, 22, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 5, 1, 0, 30, 0, 0, time.UTC)
+	for _, e := range []Entry{entry("https://fixupx.com/a/status/1", yesterday), entry("https://fixupx.com/a/status/2", today)} {
+		if err := d.Record(context.Background(), s, "guild", config.Guild{DigestChannel: "archive", DigestMode: config.DigestDaily}, []Entry{e}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	d.Flush(context.Background(), s, today.Add(time.Hour))
+	expected := []string{"archive: https://fixupx.com/a/status/1 shared by <@user> in https://discord.com/channels/guild/chan/msg"}
+	if !slices.Equal(s.posted, expected) {
+		t.Errorf("posted %q; want %q", s.posted, expected)
+	}
+
+	// Nothing goes out while the guild is paused
+	config.SaveGuild(st, "guild", config.Guild{DigestChannel: "archive", DigestMode: config.DigestDaily, Paused: true})
+	d.Flush(context.Background(), s, today.Add(25*time.Hour))
+	config.SaveGuild(st, "guild", config.Guild{DigestChannel: "archive", DigestMode: config.DigestDaily})
+	if len(s.posted) != 1 {
+		t.Errorf("posted %q while paused", s.posted[1:])
+	}
+
+	// Today's link waits for tomorrow's digest
+	var pending []Entry
+	st.Get(Bucket, "guild", &pending)
+	if len(pending) != 1 || pending[0].Link != "https://fixupx.com/a/status/2" {
+		t.Errorf("pending %+v; want today's link", pending)
+	}
+
+	// Switching the digest off drops what was queued
+	config.SaveGuild(st, "guild", config.Guild{})
+	d.Flush(context.Background(), s, today.Add(48*time.Hour))
+	if keys := st.Keys(Bucket); len(keys) != 0 || len(s.posted) != 1 {
+		t.Errorf("after turning the digest off, pending %q and posted %q", keys, s.posted)
+	}
+}
+
+func TestFlushTimezone(t *testing.T) {
+	st := storage.NewMemory()
+	cfg := config.Guild{DigestChannel: "archive", DigestMode: config.DigestDaily, Timezone: "America/New_York"}
+	if err := config.SaveGuild(st, "guild", cfg); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	d := New(st)
+	s := &fakeSender{}
+	// 22:00 on April 30 in New York
+	shared := time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC)
+	if err := d.Record(context.Background(), s, "guild", cfg, []Entry{entry("https://fixupx.com/a/status/1", shared)}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	// Past midnight UTC but not yet in New York
+	d.Flush(context.Background(), s, time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC))
+	if len(s.posted) != 0 {
+		t.Errorf("posted %q before midnight in the guild's time zone", s.posted)
+	}
+
+	d.Flush(context.Background(), s, time.Date(2024, 5, 1, 4, 30, 0, 0, time.UTC))
+	expected := []string{"archive: https://fixupx.com/a/status/1 shared by <@user> in https://discord.com/channels/guild/chan/msg"}
+	if !slices.Equal(s.posted, expected) {
+		t.Errorf("posted %q; want %q", s.posted, expected)
+	}
+}
+
+func TestFlushHour(t *testing.T) {
+	st := storage.NewMemory()
+	cfg := config.Guild{DigestChannel: "archive", DigestMode: config.DigestDaily, DigestHour: 18, Timezone: "Europe/Berlin"}
+	if err := config.SaveGuild(st, "guild", cfg); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	d := New(st)
+	s := &fakeSender{}
+	// 17:00 and 19:00 on May 1 in Berlin
+	for _, at := range []time.Time{time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 17, 0, 0, 0, time.UTC)} {
+		if err := d.Record(context.Background(), s, "guild", cfg, []Entry{entry("https://fixupx.com/a/status/1", at)}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	d.Flush(context.Background(), s, time.Date(2024, 5, 1, 15, 59, 0, 0, time.UTC))
+	if len(s.posted) != 0 {
+		t.Errorf("posted %q before the digest hour", s.posted)
+	}
+	d.Flush(context.Background(), s, time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC))
+	if len(s.posted) != 1 {
+		t.Fatalf("posted %q at the digest hour; want one digest", s.posted)
+	}
+	var pending []Entry
+	st.Get(Bucket, "guild", &pending)
+	if len(pending) != 1 || !pending[0].At.Equal(time.Date(2024, 5, 1, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("pending %+v; want the link shared after the digest hour", pending)
+	}
+}
+
+func TestDailyEmbed(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	other := entry("https://fixupx.com/b/status/2", at)
+	other.AuthorID = "other"
+	entries := []Entry{entry("https://fixupx.com/a/status/1", at), other, entry("https://fixupx.com/b/status/2", at.Add(time.Hour))}
+
+	embed := dailyEmbed("de", "guild", entries, at.Add(12*time.Hour))
+	if embed.Title != "Daily link digest for 01.05.2024" {
+		t.Errorf("title %q", embed.Title)
+	}
+	lines := strings.Split(embed.Description, "\n")
+	expected := []string{
+		"**2×** https://fixupx.com/b/status/2, first by <@other> in https://discord.com/channels/guild/chan/msg",
+		"https://fixupx.com/a/status/1 shared by <@user> in https://discord.com/channels/guild/chan/msg",
+	}
+	if !slices.Equal(lines, expected) {
+		t.Errorf("description lines %q; want %q", lines, expected)
+	}
+	var counts []string
+	for _, f := range embed.Fields {
+		counts = append(counts, f.Name+" "+f.Value)
+	}
+	if want := []string{"Links shared 3", "Different links 2", "Members sharing 2"}; !slices.Equal(counts, want) {
+		t.Errorf("fields %q; want %q", counts, want)
+	}
+
+	// Links past what fits are counted
+	var many []Entry
+	for n := range maxPending {
+		many = append(many, entry(fmt.Sprintf("https://fixupx.com/a/status/%d", n), at))
+	}
+	embed = dailyEmbed("", "guild", many, at)
+	if len(embed.Description) > maxEmbedDescription || !strings.Contains(embed.Description, "more") {
+		t.Errorf("description of %d links is %d long, ending %q", len(many), len(embed.Description), embed.Description[len(embed.Description)-20:])
+	}
+}
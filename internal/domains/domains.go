@@ -0,0 +1,278 @@
+// Package domains checks the custom sites guilds point fixed Twitter/X links
+// to: that a site serves tweet embeds before it's saved, and that it keeps
+// doing so afterwards.
+package domains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+const (
+	// TestPath is the tweet fetched to check a site, one every site can embed:
+	// the first tweet ever posted.
+	TestPath = "/jack/status/20"
+	// CheckInterval is how often Monitor checks the sites guilds use.
+	CheckInterval = 10 * time.Minute
+	// checkTimeout bounds checking one site.
+	checkTimeout = 10 * time.Second
+	// maxPage is how much of a site's page is read looking for embed tags.
+	maxPage = 1 << 20
+)
+
+// crawlerAgent is the User-Agent sites are checked with. Fixing sites only
+// serve embed tags to crawlers like Discord's, and send people on to the tweet.
+const crawlerAgent = "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)"
+
+// metaTag matches the Open Graph meta tags of a page, capturing the property.
+var metaTag = regexp.MustCompile(`(?i)<meta[^>]+(?:property|name)\s*=\s*["'](og:[a-z:]+)["']`)
+
+// counterparts pairs each fixing site members link to with the one taking
+// the same links, for guilds that swap them while one is down.
+var counterparts = map[string]string{
+	"fxtwitter.com": "vxtwitter.com",
+	"vxtwitter.com": "fxtwitter.com",
+	"fixupx.com":    "fixvx.com",
+	"fixvx.com":     "fixupx.com",
+}
+
+// twitterHosts are the sites a custom domain can't be, since links to them are
+// the ones being fixed.
+var twitterHosts = []string{"twitter.com", "x.com"}
+
+// Normalize turns a site as an admin typed it, such as "https://FixVX.com/",
+// into the bare domain links are rewritten to, such as "fixvx.com".
+func Normalize(domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+	u, err := url.Parse(domain)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return "", errors.New("that isn't a domain, use one like `fixvx.com`")
+	}
+	if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.User != nil {
+		return "", errors.New("use just the domain, like `fixvx.com`, without a path")
+	}
+	if !strings.Contains(u.Hostname(), ".") {
+		return "", fmt.Errorf("`%s` isn't a full domain, like `fixvx.com`", u.Host)
+	}
+	for _, host := range twitterHosts {
+		if u.Hostname() == host || strings.HasSuffix(u.Hostname(), "."+host) {
+			return "", errors.New("that's Twitter/X itself, use a site that fixes its embeds")
+		}
+	}
+	return u.Host, nil
+}
+
+// Checker fetches a tweet through a site to see whether it embeds.
+type Checker struct {
+	client *http.Client
+}
+
+// NewChecker returns a Checker fetching with client, or
+// http.DefaultClient if nil.
+func NewChecker(client *http.Client) *Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Checker{client: client}
+}
+
+// Verify fetches the test tweet through domain the way Discord would, and
+// returns why fixed links can't point there, if they can't.
+func (c *Checker) Verify(ctx context.Context, domain string) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+domain+TestPath, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", crawlerAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("couldn't reach %s", domain)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s answered %s", domain, resp.Status)
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxPage))
+	if err != nil {
+		return fmt.Errorf("couldn't read %s's page", domain)
+	}
+
+	tags := make(map[string]bool)
+	for _, match := range metaTag.FindAllSubmatch(page, -1) {
+		tags[strings.ToLower(string(match[1]))] = true
+	}
+	if !tags["og:title"] || !(tags["og:description"] || tags["og:image"] || tags["og:video"]) {
+		return fmt.Errorf("%s doesn't serve tweet embeds, its page has no Open Graph tags", domain)
+	}
+	return nil
+}
+
+// Sender posts messages. *discordgo.Session satisfies it.
+type Sender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// Monitor checks the custom sites guilds use, and tells each guild's
+// moderators in its audit channel when its site starts failing and when it's
+// back. While a guild swaps fixing sites it checks those too, without warning
+// anyone. A nil Monitor considers every site up.
+type Monitor struct {
+	Checker *Checker
+	Store   storage.Store
+	Session Sender
+
+	mu   sync.RWMutex
+	down map[string]bool
+}
+
+// Down reports whether domain failed its last check.
+func (m *Monitor) Down(domain string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.down[domain]
+}
+
+// Sites returns the sites the last check covered and those of them that
+// failed it, sorted.
+func (m *Monitor) Sites() (checked, failing []string) {
+	if m == nil {
+		return nil, nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, domain := range slices.Sorted(maps.Keys(m.down)) {
+		checked = append(checked, domain)
+		if m.down[domain] {
+			failing = append(failing, domain)
+		}
+	}
+	return checked, failing
+}
+
+// Swap returns the counterpart of domain, a fixing site such as
+// fxtwitter.com, if domain failed its last check and the counterpart didn't.
+func (m *Monitor) Swap(domain string) (string, bool) {
+	counterpart, ok := counterparts[domain]
+	if !ok || !m.Down(domain) || m.Down(counterpart) {
+		return "", false
+	}
+	return counterpart, true
+}
+
+// Check checks every site a guild uses once, and warns the guilds using a
+// site that went down or came back.
+func (m *Monitor) Check(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	guilds := make(map[string][]config.Guild)
+	swapping := false
+	for _, guildID := range m.Store.Keys(config.GuildBucket) {
+		cfg, err := config.LoadGuild(m.Store, guildID)
+		if err != nil {
+			log.Println("Error loading guild config:", err)
+			continue
+		}
+		if cfg.TwitterSite == config.TwitterCustom && cfg.TwitterDomain != "" {
+			guilds[cfg.TwitterDomain] = append(guilds[cfg.TwitterDomain], cfg)
+		}
+		swapping = swapping || cfg.SwapProxies
+	}
+
+	down := make(map[string]bool)
+	for domain, using := range guilds {
+		if ctx.Err() != nil {
+			return
+		}
+		err := m.Checker.Verify(ctx, domain)
+		down[domain] = err != nil
+		switch wasDown := m.Down(domain); {
+		case err != nil && !wasDown:
+			log.Printf("Custom Twitter site %s is failing: %v\n", domain, err)
+			m.warn(ctx, using, fmt.Sprintf("⚠️ Fixed Twitter/X links can't point to `%s` right now: %v. They point to fxtwitter until it's back.", domain, err))
+		case err == nil && wasDown:
+			log.Println("Custom Twitter site is back up:", domain)
+			m.warn(ctx, using, fmt.Sprintf("✅ `%s` serves tweet embeds again, so fixed Twitter/X links point to it once more.", domain))
+		}
+	}
+	if swapping {
+		for _, domain := range slices.Sorted(maps.Keys(counterparts)) {
+			if ctx.Err() != nil {
+				return
+			}
+			if _, checked := down[domain]; checked {
+				continue
+			}
+			err := m.Checker.Verify(ctx, domain)
+			down[domain] = err != nil
+			switch wasDown := m.Down(domain); {
+			case err != nil && !wasDown:
+				log.Printf("Fixing site %s is failing, swapping links to it: %v\n", domain, err)
+			case err == nil && wasDown:
+				log.Println("Fixing site is back up:", domain)
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.down = down
+}
+
+// warn posts text in the audit channel of each of guilds that has one.
+func (m *Monitor) warn(ctx context.Context, guilds []config.Guild, text string) {
+	for _, cfg := range guilds {
+		if cfg.AuditChannel == "" || m.Session == nil {
+			continue
+		}
+		_, err := m.Session.ChannelMessageSendComplex(cfg.AuditChannel, &discordgo.MessageSend{
+			Content:         text,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			log.Println("Error warning about custom Twitter site:", err)
+		}
+	}
+}
+
+// Run checks the sites every interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	if m == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
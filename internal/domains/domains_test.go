@@ -0,0 +1,207 @@
+package domains
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{input: "fixvx.com", expected: "fixvx.com"},
+		{input: " https://FixVX.com/ ", expected: "fixvx.com"},
+		{input: "embed.example.org:8443", expected: "embed.example.org:8443"},
+		{input: "fixvx.com/user/status/1", wantErr: true},
+		{input: "fixvx.com?a=b", wantErr: true},
+		{input: "localhost", wantErr: true},
+		{input: "ftp://fixvx.com", wantErr: true},
+		{input: "x.com", wantErr: true},
+		{input: "mobile.twitter.com", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			domain, err := Normalize(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Normalize(%q) error = %v; want error %v", tc.input, err, tc.wantErr)
+			}
+			if domain != tc.expected {
+				t.Errorf("Normalize(%q) = %q; want %q", tc.input, domain, tc.expected)
+			}
+		})
+	}
+}
+
+// embedPage is a page with the tags of a tweet's embed.
+const embedPage = `<html><head><meta property="og:title" content="jack (@jack)"><meta property="og:description" content="just setting up my twttr"></head></html>`
+
+func TestVerify(t *testing.T) {
+	var page atomic.Value
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != TestPath || !strings.Contains(r.UserAgent(), "Discordbot") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(page.Load().(string)))
+	}))
+	defer server.Close()
+	c := NewChecker(server.Client())
+	domain := strings.TrimPrefix(server.URL, "https://")
+
+	testCases := []struct {
+		name    string
+		page    string
+		domain  string
+		wantErr bool
+	}{
+		{name: "Embeds", page: embedPage, domain: domain},
+		{name: "Name attributes", page: `<meta name='og:title' content='a'><meta name="og:image" content="b">`, domain: domain},
+		{name: "No tags", page: "<html><title>Parked</title></html>", domain: domain, wantErr: true},
+		{name: "Title only", page: `<meta property="og:title" content="a">`, domain: domain, wantErr: true},
+		{name: "Unreachable", page: embedPage, domain: "127.0.0.1:1", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page.Store(tc.page)
+			if err := c.Verify(context.Background(), tc.domain); (err != nil) != tc.wantErr {
+				t.Errorf("Verify(%q) = %v; want error %v", tc.domain, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+type fakeSender struct {
+	posted []string
+}
+
+func (f *fakeSender) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.posted = append(f.posted, channelID)
+	return &discordgo.Message{}, nil
+}
+
+func TestMonitor(t *testing.T) {
+	var down atomic.Bool
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(embedPage))
+	}))
+	defer server.Close()
+	domain := strings.TrimPrefix(server.URL, "https://")
+
+	st := storage.NewMemory()
+	for guildID, cfg := range map[string]config.Guild{
+		"audited": {TwitterSite: config.TwitterCustom, TwitterDomain: domain, AuditChannel: "audit"},
+		"quiet":   {TwitterSite: config.TwitterCustom, TwitterDomain: domain},
+		"default": {TwitterDomain: "unused.example", AuditChannel: "other"},
+	} {
+		if err := config.SaveGuild(st, guildID, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &fakeSender{}
+	m := &Monitor{Checker: NewChecker(server.Client()), Store: st, Session: s}
+
+	testCases := []struct {
+		name     string
+		down     bool
+		expected []string
+	}{
+		{name: "Up"},
+		{name: "Starts failing", down: true, expected: []string{"audit"}},
+		{name: "Still failing", down: true},
+		{name: "Back up", expected: []string{"audit"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			down.Store(tc.down)
+			s.posted = nil
+			m.Check(context.Background())
+			if m.Down(domain) != tc.down {
+				t.Errorf("Down = %v; want %v", m.Down(domain), tc.down)
+			}
+			if !slices.Equal(s.posted, tc.expected) {
+				t.Errorf("warned %q; want %q", s.posted, tc.expected)
+			}
+		})
+	}
+
+	var none *Monitor
+	none.Check(context.Background())
+	if none.Down(domain) {
+		t.Error("nil Monitor considers a site down")
+	}
+}
+
+// fakeSites answers for every site, failing those in down.
+type fakeSites struct {
+	down map[string]bool
+}
+
+func (f *fakeSites) RoundTrip(r *http.Request) (*http.Response, error) {
+	status, body := http.StatusOK, embedPage
+	if f.down[r.URL.Host] {
+		status, body = http.StatusBadGateway, ""
+	}
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+}
+
+func TestSwap(t *testing.T) {
+	testCases := []struct {
+		name     string
+		swapping bool
+		down     []string
+		domain   string
+		expected string
+	}{
+		{name: "Up", swapping: true, domain: "fxtwitter.com"},
+		{name: "Down", swapping: true, down: []string{"fxtwitter.com"}, domain: "fxtwitter.com", expected: "vxtwitter.com"},
+		{name: "Other way around", swapping: true, down: []string{"fixvx.com"}, domain: "fixvx.com", expected: "fixupx.com"},
+		{name: "Both down", swapping: true, down: []string{"fixupx.com", "fixvx.com"}, domain: "fixupx.com"},
+		{name: "No counterpart", swapping: true, down: []string{"twittpr.com"}, domain: "twittpr.com"},
+		{name: "No guild swaps", down: []string{"fxtwitter.com"}, domain: "fxtwitter.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{SwapProxies: tc.swapping}); err != nil {
+				t.Fatal(err)
+			}
+			sites := &fakeSites{down: map[string]bool{}}
+			for _, domain := range tc.down {
+				sites.down[domain] = true
+			}
+			m := &Monitor{Checker: NewChecker(&http.Client{Transport: sites}), Store: st}
+			m.Check(context.Background())
+			got, ok := m.Swap(tc.domain)
+			if got != tc.expected || ok != (tc.expected != "") {
+				t.Errorf("Swap(%q) = %q, %v; want %q", tc.domain, got, ok, tc.expected)
+			}
+		})
+	}
+
+	var none *Monitor
+	if _, ok := none.Swap("fxtwitter.com"); ok {
+		t.Error("nil Monitor swaps a site")
+	}
+}
@@ -0,0 +1,219 @@
+// Package eventqueue takes gateway events off discordgo's reader goroutine:
+// handlers only queue their events, a few workers handle them, and when a
+// flood fills the queue events are dropped instead of holding up the gateway.
+package eventqueue
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Policy picks which event is dropped when the queue is full.
+type Policy string
+
+const (
+	// DropNewest turns new events away until there's room.
+	DropNewest Policy = "newest"
+	// DropOldest makes room by dropping the event that's waited longest.
+	DropOldest Policy = "oldest"
+)
+
+// ParsePolicy reads a policy setting, "newest" or "oldest".
+func ParsePolicy(setting string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(setting))); p {
+	case DropNewest, DropOldest:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown policy %q, expected newest or oldest", setting)
+}
+
+// Stats describes the queue since it started.
+type Stats struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+	// Peak is the longest the queue has been.
+	Peak    int `json:"peak"`
+	Handled int `json:"handled"`
+	// Dropped counts the events dropped by type, such as "MessageCreate".
+	Dropped map[string]int `json:"dropped"`
+	// Saturated is whether the queue is dropping events now.
+	Saturated bool `json:"saturated"`
+}
+
+// job is a queued event: the call to its handler and the event's type.
+type job struct {
+	kind string
+	run  func()
+}
+
+// Queue holds events for its workers to handle.
+type Queue struct {
+	// Label prefixes the queue's log lines, such as "[staging] ".
+	Label string
+
+	policy Policy
+	size   int
+
+	mu        sync.Mutex
+	ready     *sync.Cond
+	jobs      []job
+	closed    bool
+	peak      int
+	handled   int
+	dropped   map[string]int
+	saturated bool
+	// droppedSince counts the events dropped since the queue filled up.
+	droppedSince int
+	wg           sync.WaitGroup
+}
+
+// New starts workers goroutines handling events from a queue holding up to
+// size of them, dropping events by policy when it's full.
+func New(size, workers int, policy Policy) *Queue {
+	size = max(size, 1)
+	q := &Queue{policy: policy, size: size, dropped: map[string]int{}}
+	q.ready = sync.NewCond(&q.mu)
+	for range max(workers, 1) {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Push queues run, the handling of an event of type kind. It never blocks,
+// and reports false if the event was turned away because the queue is full
+// or stopped.
+func (q *Queue) Push(kind string, run func()) bool {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return false
+	}
+	accepted := true
+	if len(q.jobs) >= q.size {
+		dropped := kind
+		if q.policy == DropOldest {
+			dropped = q.jobs[0].kind
+			q.jobs = q.jobs[1:]
+		} else {
+			accepted = false
+		}
+		q.dropped[dropped]++
+		q.droppedSince++
+		if !q.saturated {
+			q.saturated = true
+			defer log.Printf("%sEvent queue is full, dropping the %s events until it catches up", q.Label, q.policy)
+		}
+	}
+	if accepted {
+		q.jobs = append(q.jobs, job{kind: kind, run: run})
+		q.peak = max(q.peak, len(q.jobs))
+		q.ready.Signal()
+	}
+	q.mu.Unlock()
+	return accepted
+}
+
+// work handles queued events until the queue is stopped and empty.
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		for len(q.jobs) == 0 && !q.closed {
+			q.ready.Wait()
+		}
+		if len(q.jobs) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		next := q.jobs[0]
+		q.jobs = q.jobs[1:]
+		q.handled++
+		// Caught up once it's half empty, so a queue hovering at full
+		// doesn't log every event
+		if q.saturated && len(q.jobs) <= q.size/2 {
+			q.saturated = false
+			log.Printf("%sEvent queue caught up after dropping %d events", q.Label, q.droppedSince)
+			q.droppedSince = 0
+		}
+		q.mu.Unlock()
+		next.run()
+	}
+}
+
+// Len returns how many events are waiting.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+// Stats returns the queue's counts.
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dropped := make(map[string]int, len(q.dropped))
+	for kind, n := range q.dropped {
+		dropped[kind] = n
+	}
+	return Stats{Length: len(q.jobs), Capacity: q.size, Peak: q.peak, Handled: q.handled, Dropped: dropped, Saturated: q.saturated}
+}
+
+// Stop stops accepting events and waits for the queued ones to be handled.
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	q.closed = true
+	q.ready.Broadcast()
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// Handler returns handler, a discordgo event handler such as
+// func(*discordgo.Session, *discordgo.MessageCreate), changed to queue its
+// events on q instead of handling them straight away. Handlers for
+// *discordgo.Event count their events under the event's gateway type.
+func (q *Queue) Handler(handler any) any {
+	v, ok := eventHandler(handler)
+	if !ok {
+		return handler
+	}
+	kind := strings.TrimPrefix(v.Type().In(1).String(), "*discordgo.")
+	return reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+		name := kind
+		if e, ok := args[1].Interface().(*discordgo.Event); ok {
+			name = e.Type
+		}
+		q.Push(name, func() { v.Call(args) })
+		return nil
+	}).Interface()
+}
+
+// Async returns handler, a discordgo event handler, changed to handle each
+// event on a goroutine of its own, as discordgo does unless it's told to
+// handle events in order.
+func Async(handler any) any {
+	v, ok := eventHandler(handler)
+	if !ok {
+		return handler
+	}
+	return reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+		go v.Call(args)
+		return nil
+	}).Interface()
+}
+
+// eventHandler reports whether handler looks like a discordgo event handler:
+// a function taking a session and an event and returning nothing.
+func eventHandler(handler any) (reflect.Value, bool) {
+	v := reflect.ValueOf(handler)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.NumOut() != 0 || t.In(0) != reflect.TypeOf(&discordgo.Session{}) {
+		return v, false
+	}
+	return v, true
+}
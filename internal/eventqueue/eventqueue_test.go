@@ -0,0 +1,105 @@
+package eventqueue
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPush(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   Policy
+		expected []string
+		dropped  map[string]int
+	}{
+		{name: "Drop newest", policy: DropNewest, expected: []string{"a", "b"}, dropped: map[string]int{"c": 1}},
+		{name: "Drop oldest", policy: DropOldest, expected: []string{"b", "c"}, dropped: map[string]int{"a": 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := New(2, 1, tc.policy)
+			// Hold the worker so the queue fills up
+			started, release := make(chan struct{}), make(chan struct{})
+			q.Push("hold", func() {
+				close(started)
+				<-release
+			})
+			<-started
+
+			var mu sync.Mutex
+			var handled []string
+			for _, kind := range []string{"a", "b", "c"} {
+				q.Push(kind, func() {
+					mu.Lock()
+					handled = append(handled, kind)
+					mu.Unlock()
+				})
+			}
+			stats := q.Stats()
+			if !stats.Saturated || stats.Length != 2 || len(stats.Dropped) != 1 {
+				t.Errorf("Stats() = %+v; want a full, saturated queue that's dropped one event", stats)
+			}
+			close(release)
+			q.Stop()
+
+			if !slices.Equal(handled, tc.expected) {
+				t.Errorf("handled %v; want %v", handled, tc.expected)
+			}
+			if stats := q.Stats(); stats.Saturated || stats.Handled != 3 || stats.Peak != 2 {
+				t.Errorf("Stats() after catching up = %+v; want 3 handled, a peak of 2 and no longer saturated", stats)
+			}
+			for kind, n := range tc.dropped {
+				if q.Stats().Dropped[kind] != n {
+					t.Errorf("dropped %v; want %v", q.Stats().Dropped, tc.dropped)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	q := New(10, 1, DropOldest)
+	got := make(chan string, 2)
+	typed := q.Handler(func(_ *discordgo.Session, m *discordgo.MessageCreate) {
+		got <- m.ID
+	}).(func(*discordgo.Session, *discordgo.MessageCreate))
+	raw := q.Handler(func(_ *discordgo.Session, e *discordgo.Event) {
+		got <- e.Type
+	}).(func(*discordgo.Session, *discordgo.Event))
+
+	typed(nil, &discordgo.MessageCreate{Message: &discordgo.Message{ID: "msg"}})
+	raw(nil, &discordgo.Event{Type: "MESSAGE_CREATE"})
+	q.Stop()
+
+	if first, second := <-got, <-got; first != "msg" || second != "MESSAGE_CREATE" {
+		t.Errorf("handled %q and %q; want msg and MESSAGE_CREATE", first, second)
+	}
+	if stats := q.Stats(); stats.Handled != 2 {
+		t.Errorf("Stats() = %+v; want 2 handled", stats)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	testCases := []struct {
+		setting  string
+		expected Policy
+		wantErr  bool
+	}{
+		{setting: "oldest", expected: DropOldest},
+		{setting: " Newest ", expected: DropNewest},
+		{setting: "random", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.setting, func(t *testing.T) {
+			got, err := ParsePolicy(tc.setting)
+			if (err != nil) != tc.wantErr || got != tc.expected {
+				t.Errorf("ParsePolicy(%q) = %q, %v; want %q, error %v", tc.setting, got, err, tc.expected, tc.wantErr)
+			}
+		})
+	}
+}
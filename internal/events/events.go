@@ -0,0 +1,109 @@
+// Package events broadcasts what the bot does as it happens, so operators can
+// watch fixes, removals and errors live instead of scraping logs.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/report"
+)
+
+// Event types.
+const (
+	// Fix is a message reposted with fixed links.
+	Fix = "fix"
+	// Duplicate is a reply pointing at an earlier fix instead of reposting.
+	Duplicate = "duplicate"
+	// Delete is a repost removed with its Remove button.
+	Delete = "delete"
+	// Phishing is a message linking to a known phishing or malware site.
+	// Detail is what was done about it.
+	Phishing = "phishing"
+	// Filtered is a message whose links the guild's content filters kept
+	// from being reposted. Detail is why.
+	Filtered = "filtered"
+	// Error is a failure that couldn't be handled automatically.
+	Error = "error"
+)
+
+// Event is one thing the bot did. It carries IDs, never message content.
+type Event struct {
+	Type      string    `json:"type"`
+	GuildID   string    `json:"guild_id,omitempty"`
+	ChannelID string    `json:"channel_id,omitempty"`
+	MessageID string    `json:"message_id,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// Bus fans events out to every subscriber. Subscribers that fall behind miss
+// events rather than slowing the bot down. A nil Bus drops every event.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// New returns a Bus with no subscribers.
+func New() *Bus {
+	return &Bus{subs: make(map[chan Event]struct{})}
+}
+
+// Publish sends e to every subscriber, stamping it with the current time.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	e.At = time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving events, buffering up to buffer of them,
+// and a function that unsubscribes and closes the channel.
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Reporter passes failures on to Next and publishes them on Bus as Error events.
+type Reporter struct {
+	Bus *Bus
+	// Next also receives every failure. Nil means report.Log.
+	Next report.Reporter
+}
+
+// Report implements report.Reporter.
+func (r Reporter) Report(ctx context.Context, op string, err error) {
+	next := r.Next
+	if next == nil {
+		next = report.Log{}
+	}
+	next.Report(ctx, op, err)
+	detail := op + ": " + err.Error()
+	if id := correlation.From(ctx); id != "" {
+		// Alerts carry it, so an operator can find the event in the logs
+		detail += " (correlation " + id + ")"
+	}
+	r.Bus.Publish(Event{Type: Error, Detail: detail})
+}
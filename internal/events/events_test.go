@@ -0,0 +1,37 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	b := New()
+	fast, stopFast := b.Subscribe(10)
+	slow, stopSlow := b.Subscribe(1)
+	defer stopSlow()
+
+	b.Publish(Event{Type: Fix, GuildID: "guild"})
+	Reporter{Bus: b}.Report(context.Background(), "send message", errors.New("boom"))
+
+	if e := <-fast; e.Type != Fix || e.GuildID != "guild" || e.At.IsZero() {
+		t.Errorf("first event = %+v; want a stamped fix", e)
+	}
+	if e := <-fast; e.Type != Error || e.Detail != "send message: boom" {
+		t.Errorf("second event = %+v; want the reported error", e)
+	}
+	if e := <-slow; e.Type != Fix || len(slow) != 0 {
+		t.Errorf("slow subscriber got %+v with %d queued; want only the first event", e, len(slow))
+	}
+
+	stopFast()
+	stopFast()
+	if _, ok := <-fast; ok {
+		t.Errorf("channel still open after unsubscribing")
+	}
+	b.Publish(Event{Type: Delete})
+
+	var none *Bus
+	none.Publish(Event{Type: Fix})
+}
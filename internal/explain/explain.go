@@ -0,0 +1,188 @@
+// Package explain keeps a short record of how the bot handled each recent
+// message: the checks it made, which fixers matched and what it decided, so
+// "why didn't the bot fix my link?" can be answered from data instead of
+// guesses. Records hold no message content.
+package explain
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/report"
+)
+
+// Step is one check made on a message and what it found, such as the stage
+// "fixer twitter" with the result "no match".
+type Step struct {
+	Stage  string `json:"stage"`
+	Result string `json:"result"`
+}
+
+// Trace is how one message was handled.
+type Trace struct {
+	MessageID string `json:"message_id"`
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	AuthorID  string `json:"author_id"`
+	// CorrelationID is the ID the message's log lines, traces and error
+	// reports carry.
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// Source is what brought the message to the bot, such as "message create",
+	// "reprocess" or "backfill".
+	Source   string    `json:"source"`
+	Received time.Time `json:"received"`
+	Steps    []Step    `json:"steps"`
+	// Decision is what the bot did with it, empty while it's still being handled.
+	Decision string `json:"decision"`
+}
+
+// Log keeps the traces of the most recent messages. A nil Log keeps nothing.
+type Log struct {
+	size int
+	now  func() time.Time
+
+	mu     sync.Mutex
+	traces map[string]*Trace
+	// order holds the traces oldest first, to know which to forget
+	order []*Trace
+}
+
+// New returns a Log keeping the last size traces, or nil if size isn't positive.
+func New(size int) *Log {
+	if size <= 0 {
+		return nil
+	}
+	return &Log{size: size, now: time.Now, traces: make(map[string]*Trace)}
+}
+
+// Start begins the trace of handling m under correlationID, replacing any
+// earlier trace of it, such as when a message is reprocessed.
+func (l *Log) Start(m *discordgo.Message, source, correlationID string) *Record {
+	if l == nil {
+		return nil
+	}
+	t := &Trace{
+		MessageID:     m.ID,
+		GuildID:       m.GuildID,
+		ChannelID:     m.ChannelID,
+		CorrelationID: correlationID,
+		Source:        source,
+		Received:      l.now(),
+	}
+	if m.Author != nil {
+		t.AuthorID = m.Author.ID
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.traces[m.ID] = t
+	l.order = append(l.order, t)
+	if len(l.order) > l.size {
+		oldest := l.order[0]
+		if l.traces[oldest.MessageID] == oldest {
+			delete(l.traces, oldest.MessageID)
+		}
+		l.order = slices.Delete(l.order, 0, 1)
+	}
+	return &Record{log: l, trace: t}
+}
+
+// Get returns the latest trace of a message, if it's still kept.
+func (l *Log) Get(messageID string) (Trace, bool) {
+	if l == nil {
+		return Trace{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t, ok := l.traces[messageID]
+	if !ok {
+		return Trace{}, false
+	}
+	copied := *t
+	copied.Steps = slices.Clone(t.Steps)
+	return copied, true
+}
+
+// Find returns the kept trace with the given correlation ID, such as one a
+// user quoted from a reply, if any.
+func (l *Log) Find(correlationID string) (Trace, bool) {
+	if l == nil || correlationID == "" {
+		return Trace{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, t := range slices.Backward(l.order) {
+		if t.CorrelationID == correlationID {
+			copied := *t
+			copied.Steps = slices.Clone(t.Steps)
+			return copied, true
+		}
+	}
+	return Trace{}, false
+}
+
+// Len returns how many traces are kept.
+func (l *Log) Len() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.traces)
+}
+
+// Record adds to the trace of one message. Its methods may be called on a nil
+// Record, which records nothing.
+type Record struct {
+	log   *Log
+	trace *Trace
+}
+
+// Note adds a step to the trace.
+func (r *Record) Note(stage, result string) {
+	if r == nil {
+		return
+	}
+	r.log.mu.Lock()
+	defer r.log.mu.Unlock()
+	r.trace.Steps = append(r.trace.Steps, Step{Stage: stage, Result: result})
+}
+
+// Decide sets what the bot did with the message. Steps noted afterwards, such
+// as a later preview check, still count.
+func (r *Record) Decide(decision string) {
+	if r == nil {
+		return
+	}
+	r.log.mu.Lock()
+	defer r.log.mu.Unlock()
+	r.trace.Decision = decision
+}
+
+type recordKey struct{}
+
+// WithRecord returns a context carrying r, so code handling the message can
+// note its steps. A nil r leaves ctx as it is.
+func WithRecord(ctx context.Context, r *Record) context.Context {
+	if r == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, recordKey{}, r)
+}
+
+// FromContext returns the record ctx carries, or nil.
+func FromContext(ctx context.Context) *Record {
+	r, _ := ctx.Value(recordKey{}).(*Record)
+	return r
+}
+
+// Note adds a step to the record ctx carries, if any, and leaves it as a
+// breadcrumb for errors reported later.
+func Note(ctx context.Context, stage, result string) {
+	FromContext(ctx).Note(stage, result)
+	report.Leave(ctx, stage, result)
+}
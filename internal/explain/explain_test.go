@@ -0,0 +1,54 @@
+package explain
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLog(t *testing.T) {
+	l := New(2)
+	first := l.Start(&discordgo.Message{ID: "1", Author: &discordgo.User{ID: "user"}}, "message create", "")
+	ctx := WithRecord(context.Background(), first)
+	Note(ctx, "fixer twitter", "rewrote links")
+	first.Decide("reposted")
+	first.Note("previews", "off")
+
+	got, ok := l.Get("1")
+	if !ok {
+		t.Fatal("Get found no trace of message 1")
+	}
+	expected := []Step{{Stage: "fixer twitter", Result: "rewrote links"}, {Stage: "previews", Result: "off"}}
+	if got.Decision != "reposted" || got.AuthorID != "user" || !slices.Equal(got.Steps, expected) {
+		t.Errorf("trace = %+v; want reposted with %+v", got, expected)
+	}
+
+	// Reprocessing replaces the trace, and the oldest one goes once there are too many
+	l.Start(&discordgo.Message{ID: "2"}, "message create", "")
+	l.Start(&discordgo.Message{ID: "1"}, "reprocess", "abc123").Decide("unchanged")
+	l.Start(&discordgo.Message{ID: "3"}, "message create", "")
+	if _, ok := l.Get("2"); ok {
+		t.Error("message 2 is still kept after two newer ones")
+	}
+	if got, _ := l.Get("1"); got.Source != "reprocess" || got.Decision != "unchanged" || len(got.Steps) != 0 {
+		t.Errorf("reprocessed trace = %+v", got)
+	}
+	if got, ok := l.Find("abc123"); !ok || got.MessageID != "1" {
+		t.Errorf("Find = %+v, %v; want the reprocessed trace", got, ok)
+	}
+	if _, ok := l.Find("missing"); ok {
+		t.Error("Find found an unknown correlation ID")
+	}
+	if l.Len() != 2 {
+		t.Errorf("Len = %d; want 2", l.Len())
+	}
+
+	var off *Log
+	off.Start(&discordgo.Message{ID: "1"}, "message create", "").Note("stage", "result")
+	Note(context.Background(), "stage", "result")
+	if _, ok := off.Get("1"); ok || New(0) != nil {
+		t.Error("a nil Log kept a trace")
+	}
+}
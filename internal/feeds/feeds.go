@@ -0,0 +1,169 @@
+// Package feeds posts new items from RSS and Atom feeds to the channels
+// subscribed to them.
+package feeds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding subscriptions, keyed by guild ID and
+// subscription ID.
+const Bucket = "feeds"
+
+const (
+	// MaxPerGuild is how many feeds one guild may subscribe to.
+	MaxPerGuild = 25
+	// MinInterval and DefaultInterval bound how often a feed is polled.
+	MinInterval     = 5 * time.Minute
+	DefaultInterval = 30 * time.Minute
+	// DefaultFormat is how items are posted unless a subscription says otherwise.
+	DefaultFormat = "**{feed}**: {title}\n{link}"
+	// maxSeen is how many item IDs are remembered per subscription.
+	maxSeen = 200
+)
+
+// storeMu serializes read-modify-write updates to subscriptions, so a poll
+// finishing can't bring back a subscription removed while it ran.
+var storeMu sync.Mutex
+
+// Subscription is a feed posted to a channel.
+type Subscription struct {
+	ID        string        `json:"id"`
+	GuildID   string        `json:"guild_id"`
+	ChannelID string        `json:"channel_id"`
+	URL       string        `json:"url"`
+	Title     string        `json:"title,omitempty"`
+	Interval  time.Duration `json:"interval"`
+	// Format is the message posted per item, with {feed}, {title} and {link}
+	// replaced. Empty means DefaultFormat.
+	Format string `json:"format,omitempty"`
+	// Seen lists the IDs of the most recent items already posted or skipped.
+	Seen []string `json:"seen,omitempty"`
+	// ETag and LastModified let polls skip feeds that haven't changed.
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	LastPoll     time.Time `json:"last_poll"`
+}
+
+// SubscriptionID derives the ID of a channel's subscription to a feed.
+func SubscriptionID(channelID, feedURL string) string {
+	sum := sha256.Sum256([]byte(channelID + " " + feedURL))
+	return hex.EncodeToString(sum[:4])
+}
+
+// ValidURL reports whether feedURL is an absolute http(s) URL.
+func ValidURL(feedURL string) bool {
+	u, err := url.Parse(feedURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil
+}
+
+// key is where a subscription is stored.
+func key(guildID, id string) string {
+	return guildID + "/" + id
+}
+
+// List returns a guild's subscriptions, or every subscription if guildID is empty.
+func List(st storage.Store, guildID string) ([]Subscription, error) {
+	var subs []Subscription
+	for _, k := range st.Keys(Bucket) {
+		if guildID != "" && !strings.HasPrefix(k, guildID+"/") {
+			continue
+		}
+		var sub Subscription
+		if _, err := st.Get(Bucket, k, &sub); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Title < subs[j].Title })
+	return subs, nil
+}
+
+// Forget deletes guildID's subscriptions from st, for purges, when userID is
+// empty, and returns how many it deleted. They hold nothing about members.
+func Forget(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" || userID != "" {
+		return 0, nil
+	}
+	deleted := 0
+	for _, k := range st.Keys(Bucket) {
+		if !strings.HasPrefix(k, guildID+"/") {
+			continue
+		}
+		if err := st.Delete(Bucket, k); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Save adds or replaces a subscription.
+func Save(st storage.Store, sub Subscription) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	return st.Put(Bucket, key(sub.GuildID, sub.ID), sub)
+}
+
+// Remove deletes a guild's subscription and reports whether it existed.
+func Remove(st storage.Store, guildID, id string) (bool, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	ok, err := st.Get(Bucket, key(guildID, id), &Subscription{})
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, st.Delete(Bucket, key(guildID, id))
+}
+
+// update saves sub if it still exists.
+func update(st storage.Store, sub Subscription) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if ok, err := st.Get(Bucket, key(sub.GuildID, sub.ID), &Subscription{}); err != nil || !ok {
+		return err
+	}
+	return st.Put(Bucket, key(sub.GuildID, sub.ID), sub)
+}
+
+// MarkSeen records every item in feed as seen, so only later items are posted.
+func (sub *Subscription) MarkSeen(feed Feed) {
+	for n := len(feed.Items) - 1; n >= 0; n-- {
+		sub.remember(feed.Items[n].ID)
+	}
+}
+
+// remember adds id to the seen list, forgetting the oldest past maxSeen.
+func (sub *Subscription) remember(id string) {
+	sub.Seen = append(sub.Seen, id)
+	if len(sub.Seen) > maxSeen {
+		sub.Seen = sub.Seen[len(sub.Seen)-maxSeen:]
+	}
+}
+
+// Message renders an item with the subscription's format.
+func (sub Subscription) Message(item Item) string {
+	format := sub.Format
+	if format == "" {
+		format = DefaultFormat
+	}
+	title := item.Title
+	if title == "" {
+		title = "New post"
+	}
+	link := item.Link
+	if !ValidURL(link) {
+		link = ""
+	}
+	msg := strings.NewReplacer("{feed}", sub.Title, "{title}", title, "{link}", link, `\n`, "\n").Replace(format)
+	return chunk.Split(strings.TrimSpace(msg), chunk.MaxMessageLength)[0]
+}
@@ -0,0 +1,189 @@
+package feeds
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel>
+  <title>Art
+    Weekly</title>
+  <item><title>Second</title><link>https://example.com/2</link><guid>2</guid><pubDate>Tue, 30 Apr 2024 10:00:00 +0000</pubDate></item>
+  <item><title>First</title><link>https://example.com/1</link></item>
+</channel></rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>News</title>
+  <entry>
+    <title>Hello</title><id>tag:example.com,2024:1</id>
+    <link rel="edit" href="https://example.com/edit/1"/><link href="https://example.com/posts/1"/>
+    <updated>2024-04-30T10:00:00Z</updated>
+  </entry>
+</feed>`
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected Feed
+		wantErr  bool
+	}{
+		{
+			name:  "RSS",
+			input: rssFeed,
+			expected: Feed{Title: "Art Weekly", Items: []Item{
+				{ID: "2", Title: "Second", Link: "https://example.com/2", Published: time.Date(2024, 4, 30, 10, 0, 0, 0, time.FixedZone("", 0))},
+				{ID: "https://example.com/1", Title: "First", Link: "https://example.com/1"},
+			}},
+		},
+		{
+			name:  "Atom",
+			input: atomFeed,
+			expected: Feed{Title: "News", Items: []Item{
+				{ID: "tag:example.com,2024:1", Title: "Hello", Link: "https://example.com/posts/1", Published: time.Date(2024, 4, 30, 10, 0, 0, 0, time.UTC)},
+			}},
+		},
+		{
+			name:     "Latin-1",
+			input:    "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><rss><channel><title>Caf\xe9</title></channel></rss>",
+			expected: Feed{Title: "Café"},
+		},
+		{name: "HTML page", input: "<html><body>hi</body></html>", wantErr: true},
+		{name: "Empty", input: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			feed, err := Parse(strings.NewReader(tc.input))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Parse() = %+v; want an error", feed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if feed.Title != tc.expected.Title || !slices.EqualFunc(feed.Items, tc.expected.Items, func(a, b Item) bool {
+				return a.ID == b.ID && a.Title == b.Title && a.Link == b.Link && a.Published.Equal(b.Published)
+			}) {
+				t.Errorf("Parse() = %+v; want %+v", feed, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	item := Item{Title: "Hello", Link: "https://example.com/1"}
+	testCases := []struct {
+		name     string
+		format   string
+		item     Item
+		expected string
+	}{
+		{name: "Default", item: item, expected: "**News**: Hello\nhttps://example.com/1"},
+		{name: "Custom", format: `New: {title}\n<{link}>`, item: item, expected: "New: Hello\n<https://example.com/1>"},
+		{name: "Untitled", format: "{title} {link}", item: Item{Link: "javascript:alert(1)"}, expected: "New post"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sub := Subscription{Title: "News", Format: tc.format}
+			if got := sub.Message(tc.item); got != tc.expected {
+				t.Errorf("Message() = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+// fakeSender records the content of every message posted.
+type fakeSender struct {
+	posted []string
+}
+
+func (f *fakeSender) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.posted = append(f.posted, channelID+": "+data.Content)
+	return &discordgo.Message{}, nil
+}
+
+func TestPoll(t *testing.T) {
+	body := rssFeed
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag := fmt.Sprintf(`"%d"`, len(body))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	st := storage.NewMemory()
+	fetcher := NewFetcher(server.Client())
+	feed, err := fetcher.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	sub := Subscription{ID: "abc", GuildID: "guild", ChannelID: "chan", URL: server.URL, Title: feed.Title, Interval: MinInterval, Format: "{title}"}
+	sub.MarkSeen(feed)
+	if err := Save(st, sub); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	s := &fakeSender{}
+	w := &Watcher{Store: st, Fetcher: fetcher, Session: s}
+	start := time.Now()
+
+	// Items present when subscribing aren't posted
+	w.Poll(context.Background(), start.Add(MinInterval))
+	if len(s.posted) != 0 {
+		t.Errorf("posted %q on the first poll; want nothing", s.posted)
+	}
+
+	// New items are posted oldest first, once the interval has passed
+	body = strings.Replace(rssFeed, "<item>", "<item><title>Fourth</title><guid>4</guid></item><item><title>Third</title><guid>3</guid></item><item>", 1)
+	w.Poll(context.Background(), start.Add(MinInterval+time.Minute))
+	if len(s.posted) != 0 {
+		t.Errorf("posted %q before the interval passed", s.posted)
+	}
+	config.SaveGuild(st, "guild", config.Guild{Paused: true})
+	w.Poll(context.Background(), start.Add(2*MinInterval))
+	if len(s.posted) != 0 {
+		t.Errorf("posted %q while the guild was paused", s.posted)
+	}
+	config.SaveGuild(st, "guild", config.Guild{})
+	w.Poll(context.Background(), start.Add(3*MinInterval))
+	if expected := []string{"chan: Third", "chan: Fourth"}; !slices.Equal(s.posted, expected) {
+		t.Errorf("posted %q; want %q", s.posted, expected)
+	}
+
+	// An unchanged feed isn't downloaded again
+	w.Poll(context.Background(), start.Add(5*MinInterval))
+	if len(s.posted) != 2 || requests != 4 {
+		t.Errorf("after an unchanged poll, posted %q with %d requests", s.posted, requests)
+	}
+
+	// Removed subscriptions stay removed
+	if ok, err := Remove(st, "guild", "abc"); !ok || err != nil {
+		t.Fatalf("Remove() = %v, %v", ok, err)
+	}
+	if err := update(st, sub); err != nil || len(st.Keys(Bucket)) != 0 {
+		t.Errorf("update brought back a removed subscription: %v", err)
+	}
+}
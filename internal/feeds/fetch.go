@@ -0,0 +1,72 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go-discord-bot/internal/preview"
+	"go-discord-bot/internal/safehttp"
+)
+
+// maxFeedSize is how much of a feed is read.
+const maxFeedSize = 2 << 20
+
+// errNotModified is returned when a feed hasn't changed since the last poll.
+var errNotModified = errors.New("feed not modified")
+
+// Fetcher downloads feeds.
+type Fetcher struct {
+	client *http.Client
+}
+
+// NewFetcher returns a fetcher using client. A nil client uses
+// safehttp.Client, so feeds can't point the bot at private addresses.
+func NewFetcher(client *http.Client) *Fetcher {
+	if client == nil {
+		client = safehttp.Client(20 * time.Second)
+	}
+	return &Fetcher{client: client}
+}
+
+// Fetch downloads and parses the feed at feedURL.
+func (f *Fetcher) Fetch(ctx context.Context, feedURL string) (Feed, error) {
+	feed, _, _, err := f.fetch(ctx, feedURL, "", "")
+	return feed, err
+}
+
+// fetch downloads a feed, sending the validators from the last poll. It
+// returns errNotModified if the server says nothing changed, and the
+// validators to send next time.
+func (f *Fetcher) fetch(ctx context.Context, feedURL, etag, lastModified string) (Feed, string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return Feed{}, "", "", err
+	}
+	req.Header.Set("User-Agent", preview.UserAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Feed{}, "", "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return Feed{}, etag, lastModified, errNotModified
+	case resp.StatusCode != http.StatusOK:
+		return Feed{}, "", "", fmt.Errorf("fetching feed: %s", resp.Status)
+	}
+	feed, err := Parse(io.LimitReader(resp.Body, maxFeedSize))
+	return feed, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), err
+}
@@ -0,0 +1,183 @@
+package feeds
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Feed is a parsed RSS or Atom feed.
+type Feed struct {
+	Title string
+	// Items are in the order the feed lists them, usually newest first.
+	Items []Item
+}
+
+// Item is one post in a feed.
+type Item struct {
+	// ID identifies the item across polls: its GUID or Atom ID, else its link.
+	ID        string
+	Title     string
+	Link      string
+	Published time.Time
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title   string `xml:"title"`
+			Link    string `xml:"link"`
+			GUID    string `xml:"guid"`
+			PubDate string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomDocument struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// Parse reads an RSS 2.0 or Atom feed.
+func Parse(r io.Reader) (Feed, error) {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charsetReader
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return Feed{}, errors.New("not a feed: the document is empty")
+		}
+		if err != nil {
+			return Feed{}, fmt.Errorf("not a feed: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "rss":
+			var doc rssDocument
+			if err := dec.DecodeElement(&doc, &start); err != nil {
+				return Feed{}, fmt.Errorf("decoding RSS feed: %w", err)
+			}
+			return doc.feed(), nil
+		case "feed":
+			var doc atomDocument
+			if err := dec.DecodeElement(&doc, &start); err != nil {
+				return Feed{}, fmt.Errorf("decoding Atom feed: %w", err)
+			}
+			return doc.feed(), nil
+		default:
+			return Feed{}, fmt.Errorf("not an RSS or Atom feed (root element %q)", start.Name.Local)
+		}
+	}
+}
+
+func (doc rssDocument) feed() Feed {
+	f := Feed{Title: clean(doc.Channel.Title)}
+	for _, it := range doc.Channel.Items {
+		item := Item{ID: strings.TrimSpace(it.GUID), Title: clean(it.Title), Link: strings.TrimSpace(it.Link), Published: parseTime(it.PubDate)}
+		if item.ID == "" {
+			item.ID = item.Link
+		}
+		if item.ID != "" {
+			f.Items = append(f.Items, item)
+		}
+	}
+	return f
+}
+
+func (doc atomDocument) feed() Feed {
+	f := Feed{Title: clean(doc.Title)}
+	for _, e := range doc.Entries {
+		item := Item{ID: strings.TrimSpace(e.ID), Title: clean(e.Title), Published: parseTime(e.Published)}
+		if item.Published.IsZero() {
+			item.Published = parseTime(e.Updated)
+		}
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				item.Link = strings.TrimSpace(l.Href)
+				break
+			}
+		}
+		if item.ID == "" {
+			item.ID = item.Link
+		}
+		if item.ID != "" {
+			f.Items = append(f.Items, item)
+		}
+	}
+	return f
+}
+
+// timeLayouts are the date formats seen in feeds, RSS's RFC 1123 variants first.
+var timeLayouts = []string{time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", time.RFC3339}
+
+// parseTime parses a feed date, returning the zero time if it's unrecognized.
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// clean collapses the whitespace in a title.
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// charsetReader converts the non-UTF-8 encodings feeds commonly declare.
+func charsetReader(label string, r io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return r, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		return &latin1Reader{r: bufio.NewReader(r)}, nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", label)
+}
+
+// latin1Reader decodes ISO-8859-1, where every byte is the code point of the same value.
+type latin1Reader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(l.pending) > 0 {
+			c := copy(p[n:], l.pending)
+			l.pending = l.pending[c:]
+			n += c
+			continue
+		}
+		b, err := l.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		l.pending = []byte(string(rune(b)))
+	}
+	return n, nil
+}
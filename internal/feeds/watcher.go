@@ -0,0 +1,109 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// maxPostsPerPoll caps how many new items one poll posts, so a feed that
+// republishes everything at once doesn't flood the channel.
+const maxPostsPerPoll = 5
+
+// Sender posts messages. *discordgo.Session satisfies it.
+type Sender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// Watcher polls subscribed feeds and posts their new items.
+type Watcher struct {
+	Store   storage.Store
+	Fetcher *Fetcher
+	Session Sender
+}
+
+// Run polls the feeds that are due every tick until ctx is done.
+func (w *Watcher) Run(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.Poll(ctx, now)
+		}
+	}
+}
+
+// Poll checks every subscription whose interval has passed since its last poll.
+func (w *Watcher) Poll(ctx context.Context, now time.Time) {
+	subs, err := List(w.Store, "")
+	if err != nil {
+		log.Println("Error loading feed subscriptions:", err)
+		return
+	}
+	for _, sub := range subs {
+		if ctx.Err() != nil {
+			return
+		}
+		// Feeds of paused guilds catch up once they resume
+		if now.Sub(sub.LastPoll) < max(sub.Interval, MinInterval) || config.Paused(w.Store, sub.GuildID) {
+			continue
+		}
+		if err := w.poll(ctx, sub, now); err != nil {
+			log.Printf("Error polling feed %s for guild %s: %v\n", sub.ID, sub.GuildID, err)
+		}
+	}
+}
+
+// poll fetches one feed and posts the items it hasn't seen, oldest first.
+func (w *Watcher) poll(ctx context.Context, sub Subscription, now time.Time) error {
+	sub.LastPoll = now
+	feed, etag, lastModified, err := w.Fetcher.fetch(ctx, sub.URL, sub.ETag, sub.LastModified)
+	if errors.Is(err, errNotModified) {
+		return update(w.Store, sub)
+	}
+	if err != nil {
+		// Still wait a full interval before trying again
+		return errors.Join(err, update(w.Store, sub))
+	}
+	sub.ETag, sub.LastModified = etag, lastModified
+	if feed.Title != "" {
+		sub.Title = feed.Title
+	}
+
+	var fresh []Item
+	for n := len(feed.Items) - 1; n >= 0; n-- {
+		if !slices.Contains(sub.Seen, feed.Items[n].ID) {
+			fresh = append(fresh, feed.Items[n])
+		}
+	}
+	// Skip all but the newest few rather than flood the channel
+	if len(fresh) > maxPostsPerPoll {
+		for _, item := range fresh[:len(fresh)-maxPostsPerPoll] {
+			sub.remember(item.ID)
+		}
+		fresh = fresh[len(fresh)-maxPostsPerPoll:]
+	}
+
+	for _, item := range fresh {
+		_, err := w.Session.ChannelMessageSendComplex(sub.ChannelID, &discordgo.MessageSend{
+			Content:         sub.Message(item),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			// Items not posted yet are tried again next poll
+			return errors.Join(err, update(w.Store, sub))
+		}
+		sub.remember(item.ID)
+	}
+	return update(w.Store, sub)
+}
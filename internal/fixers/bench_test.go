@@ -0,0 +1,95 @@
+package fixers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/storage"
+)
+
+// benchCorpus is a spread of messages like those the bot sees. Most chat has no
+// links at all, so that case matters as much as the ones that get fixed.
+var benchCorpus = []struct {
+	name    string
+	content string
+	embeds  []*discordgo.MessageEmbed
+}{
+	{name: "Chat", content: "lol did anyone else see the stream last night, that ending was wild"},
+	{name: "Long chat", content: strings.Repeat("this is a pretty long message without a single link in it ", 30)},
+	{name: "Other link", content: "docs are here https://example.com/guide?utm_source=discord#setup"},
+	{name: "Tweet", content: "look https://x.com/someone/status/1827343634091409773?t=abc&s=19 so good"},
+	{name: "Embedded tweet", content: "https://twitter.com/someone/status/1827343634091409773", embeds: []*discordgo.MessageEmbed{
+		{URL: "https://twitter.com/someone/status/1827343634091409773", Image: &discordgo.MessageEmbedImage{URL: "https://pbs.twimg.com/media/abc.jpg"}},
+	}},
+	{name: "Bracketed tweet", content: "<https://x.com/someone/status/1827343634091409773>"},
+	{name: "Twitch clip", content: "clip of the year https://clips.twitch.tv/FunnySlug-abc123"},
+	{name: "Many links", content: "https://x.com/a/status/1 https://twitter.com/b/status/2 https://fixupx.com/c/status/3 https://example.com https://clips.twitch.tv/Slug"},
+}
+
+// benchPipeline is the pipeline the bot runs, with a guild that has one
+// custom rewrite rule.
+func benchPipeline(b *testing.B) Pipeline {
+	st := storage.NewMemory()
+	err := config.SaveGuild(st, "guild", config.Guild{
+		RewriteRules: []config.RewriteRule{{Pattern: `https://example\.com/(.*)`, Replacement: "https://mirror.example.com/$1"}},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return Pipeline{
+		Twitter{Store: st},
+		Twitch{Proxy: "clips.fxtwitch.seria.moe"},
+		Custom{Store: st},
+		Cleaner{},
+	}
+}
+
+func BenchmarkPipeline(b *testing.B) {
+	// Keep the Twitter fixer's message logging out of the measurements
+	logging.SetPrivacy(func(string) bool { return true })
+	defer logging.SetPrivacy(nil)
+	p := benchPipeline(b)
+	ctx := context.Background()
+	for _, tc := range benchCorpus {
+		m := &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: "guild", Content: tc.content, Embeds: tc.embeds}}
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p.Apply(ctx, m)
+			}
+		})
+	}
+}
+
+func BenchmarkChangedLinks(b *testing.B) {
+	original := benchCorpus[len(benchCorpus)-1].content
+	modified := modifyTwitterLinks(original)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ChangedLinks(original, modified)
+	}
+}
+
+func BenchmarkCleanURL(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		CleanURL("https://fixupx.com/someone/status/1827343634091409773?t=abc&s=19&utm_source=discord")
+	}
+}
+
+func BenchmarkHasValidTwitterPreview(b *testing.B) {
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{Embeds: []*discordgo.MessageEmbed{
+		{URL: "https://twitter.com/someone/status/1", Thumbnail: &discordgo.MessageEmbedThumbnail{URL: "https://abs.twimg.com/icons/logo.png"}},
+		{URL: "https://twitter.com/someone/status/2", Image: &discordgo.MessageEmbedImage{URL: "https://pbs.twimg.com/media/abc.jpg"}},
+	}}}
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hasValidTwitterPreview(ctx, m, DefaultEmbedThreshold)
+	}
+}
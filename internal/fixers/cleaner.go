@@ -0,0 +1,97 @@
+package fixers
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/patterns"
+)
+
+// trackingParams lists query parameters that only exist to track clicks.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"igshid":  true,
+	"igsh":    true,
+	"si":      true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"ref_src": true,
+	"ref_url": true,
+	"s":       false, // twitter share source, only stripped on twitter/x hosts
+	"t":       false, // twitter share token, only stripped on twitter/x hosts
+}
+
+// isTrackingParam reports whether a query parameter should be removed from a link on host.
+func isTrackingParam(host, name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "utm_") {
+		return true
+	}
+	if strip, known := trackingParams[lower]; known {
+		if strip {
+			return true
+		}
+		return isTwitterHost(host)
+	}
+	return false
+}
+
+func isTwitterHost(host string) bool {
+	host = strings.TrimPrefix(host, "www.")
+	return host == "twitter.com" || host == "x.com" || host == "fxtwitter.com" || host == "fixupx.com"
+}
+
+// CleanURL removes tracking parameters from a single link.
+// Links that can't be parsed are returned unchanged.
+func CleanURL(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.RawQuery == "" {
+		return link
+	}
+
+	query := u.Query()
+	changed := false
+	for name := range query {
+		if isTrackingParam(u.Hostname(), name) {
+			query.Del(name)
+			changed = true
+		}
+	}
+	if !changed {
+		return link
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// cleanTrackingParams strips tracking parameters from every link in content,
+// leaving links in angle brackets alone.
+func cleanTrackingParams(content string) string {
+	return patterns.URL.ReplaceAllStringFunc(content, func(link string) string {
+		if strings.HasPrefix(link, "<") && strings.HasSuffix(link, ">") {
+			return link
+		}
+		return CleanURL(link)
+	})
+}
+
+// Cleaner strips tracking parameters from links the bot is about to repost.
+// It only touches content an earlier fixer already changed, so a tracking
+// parameter alone never causes a repost.
+type Cleaner struct{}
+
+// Name implements Fixer.
+func (Cleaner) Name() string { return "cleaner" }
+
+// Fix implements Fixer.
+func (Cleaner) Fix(ctx context.Context, m *discordgo.MessageCreate, content string) string {
+	if content == m.Content {
+		return content
+	}
+	return cleanTrackingParams(content)
+}
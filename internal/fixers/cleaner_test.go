@@ -0,0 +1,67 @@
+package fixers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCleanTrackingParams(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "UTM parameters",
+			input:    "https://example.com/article?id=5&utm_source=twitter&utm_medium=social",
+			expected: "https://example.com/article?id=5",
+		},
+		{
+			name:     "Only tracking parameters",
+			input:    "https://example.com/?fbclid=abc",
+			expected: "https://example.com/",
+		},
+		{
+			name:     "Spotify si parameter",
+			input:    "https://open.spotify.com/track/123?si=abcdef",
+			expected: "https://open.spotify.com/track/123",
+		},
+		{
+			name:     "Twitter share parameters",
+			input:    "https://fixupx.com/user/status/1?t=abc&s=19",
+			expected: "https://fixupx.com/user/status/1",
+		},
+		{
+			name:     "Short parameters kept on other hosts",
+			input:    "https://youtube.com/watch?v=abc&t=30",
+			expected: "https://youtube.com/watch?v=abc&t=30",
+		},
+		{
+			name:     "Link in angle brackets",
+			input:    "<https://example.com/?utm_source=x>",
+			expected: "<https://example.com/?utm_source=x>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := cleanTrackingParams(tc.input)
+			if result != tc.expected {
+				t.Errorf("cleanTrackingParams(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCleanerFixerOnlyTouchesReposts(t *testing.T) {
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{Content: "https://example.com/?utm_source=x"}}
+
+	if result := (Cleaner{}).Fix(context.Background(), m, m.Content); result != m.Content {
+		t.Errorf("Cleaner changed unmodified content to %q", result)
+	}
+	if result := (Cleaner{}).Fix(context.Background(), m, "fixed https://example.com/?utm_source=x"); result != "fixed https://example.com/" {
+		t.Errorf("Cleaner.Fix on modified content = %q", result)
+	}
+}
@@ -0,0 +1,141 @@
+package fixers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/storage"
+)
+
+const (
+	// MaxRewriteRules caps how many custom rules a single guild can define.
+	MaxRewriteRules = 25
+	// maxRewritePatternLength caps the length of a rule's pattern and replacement.
+	maxRewritePatternLength = 300
+	// maxCompiledRules bounds the compiled pattern cache; when it's full the
+	// cache is emptied, so rules guilds have since changed or removed go too.
+	maxCompiledRules = 2000
+)
+
+// groupReferencePattern matches $1, ${1} and ${name} references in a replacement.
+var groupReferencePattern = regexp.MustCompile(`\$(\d+|\{[^}]*\})`)
+
+// compiledRules caches validated rule patterns so they aren't recompiled for
+// every message, guarded by compiledMu.
+var (
+	compiledMu    sync.Mutex
+	compiledRules = make(map[config.RewriteRule]*regexp.Regexp)
+)
+
+// Custom applies the guild's own rewrite rules.
+type Custom struct {
+	Store storage.Store
+}
+
+// Name implements Fixer.
+func (Custom) Name() string { return "custom" }
+
+// Fix implements Fixer.
+func (f Custom) Fix(ctx context.Context, m *discordgo.MessageCreate, content string) string {
+	// Rules only rewrite links, so there's no need to load them for plain chat
+	if !patterns.HasLink(content) {
+		return content
+	}
+	cfg, err := config.LoadGuild(f.Store, m.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+		return content
+	}
+	if len(cfg.RewriteRules) == 0 {
+		return content
+	}
+	return applyRewriteRules(cfg.RewriteRules, content)
+}
+
+// ValidateRewriteRule compiles the rule's pattern and checks that the replacement
+// only references capture groups that exist. It returns the compiled pattern.
+func ValidateRewriteRule(rule config.RewriteRule) (*regexp.Regexp, error) {
+	if rule.Pattern == "" {
+		return nil, fmt.Errorf("pattern must not be empty")
+	}
+	if len(rule.Pattern) > maxRewritePatternLength || len(rule.Replacement) > maxRewritePatternLength {
+		return nil, fmt.Errorf("pattern and replacement must be at most %d characters", maxRewritePatternLength)
+	}
+
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if re.MatchString("") {
+		return nil, fmt.Errorf("pattern must not match empty text")
+	}
+
+	names := re.SubexpNames()
+	for _, ref := range groupReferencePattern.FindAllStringSubmatch(rule.Replacement, -1) {
+		name := strings.Trim(ref[1], "{}")
+		if n, err := strconv.Atoi(name); err == nil {
+			if n > re.NumSubexp() {
+				return nil, fmt.Errorf("replacement references group %d but the pattern only has %d", n, re.NumSubexp())
+			}
+			continue
+		}
+		if re.SubexpIndex(name) < 0 {
+			return nil, fmt.Errorf("replacement references unknown group %q (groups: %s)", name, strings.Join(names[1:], ", "))
+		}
+	}
+
+	return re, nil
+}
+
+// applyRewriteRules rewrites every link in content with the first rule that matches it.
+// Links in angle brackets are left alone, like the built-in fixers.
+func applyRewriteRules(rules []config.RewriteRule, content string) string {
+	compiled := make([]*regexp.Regexp, len(rules))
+	for i, rule := range rules {
+		compiled[i] = compileRewriteRule(rule)
+	}
+
+	return patterns.URL.ReplaceAllStringFunc(content, func(link string) string {
+		if strings.HasPrefix(link, "<") && strings.HasSuffix(link, ">") {
+			return link
+		}
+		for i, re := range compiled {
+			if re != nil && re.MatchString(link) {
+				return re.ReplaceAllString(link, rules[i].Replacement)
+			}
+		}
+		return link
+	})
+}
+
+// compileRewriteRule returns the cached compiled pattern for a rule,
+// or nil if the rule is invalid.
+func compileRewriteRule(rule config.RewriteRule) *regexp.Regexp {
+	compiledMu.Lock()
+	re, ok := compiledRules[rule]
+	compiledMu.Unlock()
+	if ok {
+		return re
+	}
+	re, err := ValidateRewriteRule(rule)
+	if err != nil {
+		log.Printf("Skipping invalid rewrite rule %q: %v\n", rule.Pattern, err)
+		return nil
+	}
+	compiledMu.Lock()
+	if len(compiledRules) >= maxCompiledRules {
+		clear(compiledRules)
+	}
+	compiledRules[rule] = re
+	compiledMu.Unlock()
+	return re
+}
@@ -0,0 +1,156 @@
+package fixers
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+func TestValidateRewriteRule(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rule    config.RewriteRule
+		wantErr bool
+	}{
+		{name: "Valid rule", rule: config.RewriteRule{Pattern: `https://example\.com/(\w+)`, Replacement: "https://fixed.example.com/$1"}},
+		{name: "Named group", rule: config.RewriteRule{Pattern: `https://example\.com/(?P<id>\w+)`, Replacement: "https://fixed.example.com/${id}"}},
+		{name: "Empty pattern", rule: config.RewriteRule{Pattern: "", Replacement: "x"}, wantErr: true},
+		{name: "Invalid regex", rule: config.RewriteRule{Pattern: `(unclosed`, Replacement: "x"}, wantErr: true},
+		{name: "Matches empty text", rule: config.RewriteRule{Pattern: `.*`, Replacement: "x"}, wantErr: true},
+		{name: "Missing numbered group", rule: config.RewriteRule{Pattern: `example\.com`, Replacement: "$1"}, wantErr: true},
+		{name: "Unknown named group", rule: config.RewriteRule{Pattern: `example\.com/(\w+)`, Replacement: "${id}"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ValidateRewriteRule(tc.rule)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ValidateRewriteRule(%+v) error = %v; wantErr %v", tc.rule, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyRewriteRules(t *testing.T) {
+	rules := []config.RewriteRule{
+		{Pattern: `^https://(www\.)?instagram\.com/`, Replacement: "https://ddinstagram.com/"},
+		{Pattern: `^https://example\.com/(\w+)$`, Replacement: "https://fixed.example.com/$1"},
+		{Pattern: `(broken`, Replacement: "ignored"},
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "First matching rule wins",
+			input:    "look https://www.instagram.com/p/abc and https://example.com/thing",
+			expected: "look https://ddinstagram.com/p/abc and https://fixed.example.com/thing",
+		},
+		{
+			name:     "Plain text is untouched",
+			input:    "example.com/thing is not a link",
+			expected: "example.com/thing is not a link",
+		},
+		{
+			name:     "Link in angle brackets",
+			input:    "<https://example.com/thing>",
+			expected: "<https://example.com/thing>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := applyRewriteRules(rules, tc.input)
+			if result != tc.expected {
+				t.Errorf("applyRewriteRules(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCustomFixerInPipeline(t *testing.T) {
+	st := storage.NewMemory()
+	rules := []config.RewriteRule{{Pattern: `^https://example\.com/`, Replacement: "https://fixed.example.com/"}}
+	if err := config.SaveGuild(st, "guild", config.Guild{RewriteRules: rules}); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+
+	p := Pipeline{Twitter{}, Custom{Store: st}, Cleaner{}}
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{
+		GuildID: "guild",
+		Content: "https://example.com/a?utm_source=x and https://x.com/user/status/1",
+	}}
+
+	expected := "https://fixed.example.com/a and https://fixupx.com/user/status/1"
+	if result := p.Apply(context.Background(), m); result != expected {
+		t.Errorf("Pipeline.Apply = %q; want %q", result, expected)
+	}
+
+	m.GuildID = "other"
+	if result := p.Apply(context.Background(), m); result != "https://example.com/a and https://fixupx.com/user/status/1" {
+		t.Errorf("Pipeline.Apply in a guild without rules = %q", result)
+	}
+}
+
+func TestPipelineLeavesCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Inline code",
+			input:    "`https://x.com/user/status/1` vs https://x.com/user/status/2",
+			expected: "`https://x.com/user/status/1` vs https://fixupx.com/user/status/2",
+		},
+		{
+			name:     "Code block",
+			input:    "```\nhttps://x.com/user/status/1?s=20\n```\nhttps://x.com/user/status/2?s=20",
+			expected: "```\nhttps://x.com/user/status/1?s=20\n```\nhttps://fixupx.com/user/status/2",
+		},
+		{
+			name:     "Only code",
+			input:    "`https://x.com/user/status/1`",
+			expected: "`https://x.com/user/status/1`",
+		},
+		{
+			name:     "Lone backtick",
+			input:    "it`s https://x.com/user/status/1",
+			expected: "it`s https://fixupx.com/user/status/1",
+		},
+	}
+
+	p := Pipeline{Twitter{}, Cleaner{}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{Content: tc.input}}
+			if result := p.Apply(context.Background(), m); result != tc.expected {
+				t.Errorf("Pipeline.Apply(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+			if m.Content != tc.input {
+				t.Errorf("Pipeline.Apply changed the message to %q", m.Content)
+			}
+		})
+	}
+}
+
+func TestCompileRewriteRuleCacheBounded(t *testing.T) {
+	for n := range maxCompiledRules + 10 {
+		rule := config.RewriteRule{Pattern: `https://example\.com/` + strconv.Itoa(n), Replacement: "https://example.org/"}
+		if compileRewriteRule(rule) == nil {
+			t.Fatalf("compileRewriteRule(%q) = nil; want a pattern", rule.Pattern)
+		}
+	}
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+	if len(compiledRules) > maxCompiledRules {
+		t.Errorf("cache holds %d patterns; want at most %d", len(compiledRules), maxCompiledRules)
+	}
+}
@@ -0,0 +1,117 @@
+package fixers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultEmbedThreshold is the score an embed of a tweet needs to count as
+// showing the tweet's media.
+const DefaultEmbedThreshold = 3
+
+// Weights of the signs that Discord's embed of a tweet shows its media. They
+// add up to an embed's score; none decides on its own.
+const (
+	// scoreMediaType is for embeds Discord typed as an image, video or GIF.
+	scoreMediaType = 2
+	// scoreVideo is for a video served from Twitter's CDN, scoreImage for any
+	// image, and scoreThumbnail for a thumbnail alone.
+	scoreVideo     = 3
+	scoreImage     = 2
+	scoreThumbnail = 1
+	// scoreNoMedia is for embeds with nothing but text.
+	scoreNoMedia = -2
+	// scoreCDN is for media on Twitter's CDN, counted once per embed.
+	scoreCDN = 3
+	// scoreGenericImage is for Twitter's logo or default card image, shown
+	// when Discord couldn't get at the tweet.
+	scoreGenericImage = -5
+	// scoreStill is for a still of a GIF or video shown without the video.
+	scoreStill = -3
+)
+
+// twitterCDNs are the hosts Twitter serves tweet media from.
+var twitterCDNs = []string{
+	"pbs.twimg.com",
+	"video.twimg.com",
+	"ton.twimg.com",
+}
+
+// scoreTwitterEmbed weighs how likely embed is to show a tweet's media.
+func scoreTwitterEmbed(embed *discordgo.MessageEmbed) int {
+	score := 0
+	switch embed.Type {
+	case discordgo.EmbedTypeImage, discordgo.EmbedTypeVideo, discordgo.EmbedTypeGifv:
+		score += scoreMediaType
+	}
+
+	hasVideo := embed.Video != nil && embed.Video.URL != ""
+	var pictures []string
+	if embed.Image != nil && embed.Image.URL != "" {
+		pictures = append(pictures, embed.Image.URL)
+	}
+	if embed.Thumbnail != nil && embed.Thumbnail.URL != "" {
+		pictures = append(pictures, embed.Thumbnail.URL)
+	}
+	switch {
+	case hasVideo && onTwitterCDN(embed.Video.URL):
+		score += scoreVideo
+	case embed.Image != nil && embed.Image.URL != "":
+		score += scoreImage
+	case len(pictures) > 0:
+		score += scoreThumbnail
+	default:
+		score += scoreNoMedia
+	}
+
+	// Discord links image embeds to the media itself
+	cdn := onTwitterCDN(embed.URL) || hasVideo && onTwitterCDN(embed.Video.URL)
+	for _, picture := range pictures {
+		switch {
+		case genericTwitterImage(picture):
+			score += scoreGenericImage
+		case stillOfVideo(picture) && !hasVideo:
+			score += scoreStill
+		case onTwitterCDN(picture):
+			cdn = true
+		}
+	}
+	if cdn {
+		score += scoreCDN
+	}
+	return score
+}
+
+// onTwitterCDN reports whether link is served from one of twitterCDNs.
+func onTwitterCDN(link string) bool {
+	host := hostname(link)
+	for _, cdn := range twitterCDNs {
+		if host == cdn || strings.HasSuffix(host, "."+cdn) {
+			return true
+		}
+	}
+	return false
+}
+
+// genericTwitterImage reports whether link is one of Twitter's own images,
+// such as its logo, rather than a tweet's media.
+func genericTwitterImage(link string) bool {
+	host := hostname(link)
+	return host == "abs.twimg.com" || strings.HasSuffix(host, ".abs.twimg.com")
+}
+
+// stillOfVideo reports whether link is the still Twitter shows for a GIF or video.
+func stillOfVideo(link string) bool {
+	return strings.Contains(link, "tweet_video_thumb") || strings.Contains(link, "ext_tw_video_thumb") || strings.Contains(link, "amplify_video_thumb")
+}
+
+// hostname returns the host link points at, or "" if it isn't a URL.
+func hostname(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,49 @@
+package fixers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestScoreTwitterEmbed checks scores against embeds the way Discord sends
+// them, for tweets it did and didn't manage to show.
+func TestScoreTwitterEmbed(t *testing.T) {
+	data, err := os.ReadFile("testdata/embeds.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testCases []struct {
+		Name    string                  `json:"name"`
+		Working bool                    `json:"working"`
+		Embed   *discordgo.MessageEmbed `json:"embed"`
+	}
+	if err := json.Unmarshal(data, &testCases); err != nil {
+		t.Fatalf("decoding embeds: %v", err)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			score := scoreTwitterEmbed(tc.Embed)
+			if working := score >= DefaultEmbedThreshold; working != tc.Working {
+				t.Errorf("scoreTwitterEmbed = %d, working %v; want working %v", score, working, tc.Working)
+			}
+		})
+	}
+}
+
+func TestTwitterFixerEmbedThreshold(t *testing.T) {
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{
+		Content: "https://x.com/user/status/1",
+		Embeds:  []*discordgo.MessageEmbed{{Thumbnail: &discordgo.MessageEmbedThumbnail{URL: "https://pbs.twimg.com/media/a.jpg"}}},
+	}}
+	if result := (Twitter{}).Fix(context.Background(), m, m.Content); result != m.Content {
+		t.Errorf("Twitter.Fix with a thumbnail from the CDN = %q; want unchanged", result)
+	}
+	if result := (Twitter{EmbedThreshold: 5}).Fix(context.Background(), m, m.Content); result != "https://fixupx.com/user/status/1" {
+		t.Errorf("Twitter.Fix with a stricter threshold = %q", result)
+	}
+}
@@ -0,0 +1,140 @@
+// Package fixers rewrites links whose Discord previews are broken.
+package fixers
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/tracing"
+)
+
+// Fixer rewrites one family of links in a message.
+// Fix receives the content produced by the previous fixers and returns it
+// unchanged when there is nothing to do. Fixers that make network calls must
+// give up when ctx is done and return the content they were given.
+type Fixer interface {
+	Name() string
+	Fix(ctx context.Context, m *discordgo.MessageCreate, content string) string
+}
+
+// Pipeline is an ordered list of fixers applied to every incoming message.
+type Pipeline []Fixer
+
+// Apply runs every fixer over the message content and returns the result.
+// Links in code blocks and inline code are left alone, since whoever posted
+// them wanted them shown as they are. Once ctx is done the remaining fixers
+// are skipped.
+func (p Pipeline) Apply(ctx context.Context, m *discordgo.MessageCreate) string {
+	content, _ := p.ApplyOrigins(ctx, m)
+	return content
+}
+
+// ApplyOrigins is Apply that also returns the name of the fixer that produced
+// each link it changed, for Render.
+func (p Pipeline) ApplyOrigins(ctx context.Context, m *discordgo.MessageCreate) (string, map[string]string) {
+	origins := make(map[string]string)
+	content, code := maskCode(m.Content)
+	if len(code) > 0 {
+		// Fixers also look at the message itself, so it gets the masked content too
+		masked := *m.Message
+		masked.Content = content
+		m = &discordgo.MessageCreate{Message: &masked}
+	}
+	for _, f := range p {
+		if ctx.Err() != nil {
+			break
+		}
+		fixCtx, span := tracing.Start(ctx, "fixer "+f.Name())
+		fixed := f.Fix(fixCtx, m, content)
+		span.Set(tracing.Bool("changed", fixed != content))
+		span.End()
+		if fixed != content {
+			for _, link := range ChangedLinks(content, fixed) {
+				origins[link] = f.Name()
+			}
+			explain.Note(ctx, "fixer "+f.Name(), "rewrote links")
+			logging.Debugf(logging.Fixers, "%s rewrote %d links in message %s", f.Name(), len(ChangedLinks(content, fixed)), m.ID)
+		} else {
+			explain.Note(ctx, "fixer "+f.Name(), "no match")
+		}
+		content = fixed
+	}
+	return unmaskCode(content, code), origins
+}
+
+// maskCode replaces every code block and inline code span in content with a
+// placeholder no fixer matches, returning the masked content and the spans in
+// order.
+func maskCode(content string) (string, []string) {
+	if !strings.Contains(content, "`") {
+		return content, nil
+	}
+	var code []string
+	masked := patterns.Code.ReplaceAllStringFunc(content, func(span string) string {
+		code = append(code, span)
+		return codePlaceholder(len(code) - 1)
+	})
+	return masked, code
+}
+
+// unmaskCode puts the spans maskCode took out of content back.
+func unmaskCode(content string, code []string) string {
+	for n, span := range code {
+		content = strings.Replace(content, codePlaceholder(n), span, 1)
+	}
+	return content
+}
+
+// codePlaceholder stands in for the nth code span. Control characters keep it
+// from being read as part of a link.
+func codePlaceholder(n int) string {
+	return "\x00code" + strconv.Itoa(n) + "\x00"
+}
+
+// Names returns the names of the pipeline's fixers, in order.
+func (p Pipeline) Names() []string {
+	names := make([]string, 0, len(p))
+	for _, f := range p {
+		names = append(names, f.Name())
+	}
+	return names
+}
+
+// Without returns the pipeline minus the fixers with the given names.
+func (p Pipeline) Without(names []string) Pipeline {
+	if len(names) == 0 {
+		return p
+	}
+	var kept Pipeline
+	for _, f := range p {
+		if !slices.Contains(names, f.Name()) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// ChangedLinks returns the links in modified that don't appear in original, in order.
+// These are the links the pipeline rewrote.
+func ChangedLinks(original, modified string) []string {
+	seen := make(map[string]bool)
+	for _, link := range patterns.URL.FindAllString(original, -1) {
+		seen[link] = true
+	}
+
+	var links []string
+	for _, link := range patterns.URL.FindAllString(modified, -1) {
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
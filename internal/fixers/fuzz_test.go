@@ -0,0 +1,94 @@
+package fixers
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"go-discord-bot/internal/patterns"
+)
+
+// bracketedTwitterLink matches a status link fully wrapped in angle brackets,
+// which modifyTwitterLinks must leave untouched.
+var bracketedTwitterLink = regexp.MustCompile(`<https?://(www\.|mobile\.)?(twitter\.com|x\.com)/(?:i/web|[A-Za-z0-9_]+)/status/\d+(\?[^\s<>]*)?>`)
+
+var fuzzSeeds = []string{
+	"Check out https://twitter.com/user/status/123456",
+	"Don't modify this: <https://twitter.com/user/status/123456>",
+	"https://x.com/Nefarious_Foxx/status/1827343634091409773?t=vz1CxWwkTUyboeZhODW_yw&s=19",
+	"Check https://www.twitter.com/user1/status/123 and https://x.com/user2/status/456",
+	"This <link> https://twitter.com/user/status/123456 and this <one> https://x.com/user/status/789012",
+	"https://x.com/a/status/1/photo/1",
+	"https://mobile.twitter.com/i/web/status/1?s=20 https://x.com/i/status/2",
+	"<https://x.com/a/status/1>trailing",
+	"https://x.com/ü/status/1?q=\xff",
+	"",
+}
+
+func FuzzModifyTwitterLinks(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		output := modifyTwitterLinks(input)
+
+		if utf8.ValidString(input) && !utf8.ValidString(output) {
+			t.Fatalf("modifyTwitterLinks(%q) produced invalid UTF-8 %q", input, output)
+		}
+
+		for _, link := range bracketedTwitterLink.FindAllString(input, -1) {
+			if !strings.Contains(output, link) {
+				t.Fatalf("modifyTwitterLinks(%q) = %q; bracketed link %q was modified", input, output, link)
+			}
+		}
+
+		for _, match := range patterns.TwitterRewritable.FindAllString(input, -1) {
+			if strings.HasPrefix(match, "<") {
+				continue
+			}
+			if _, err := url.Parse(match); err != nil {
+				continue
+			}
+
+			rewritten := modifySingleLink(match)
+			u, err := url.Parse(rewritten)
+			if err != nil {
+				t.Fatalf("modifySingleLink(%q) = %q, which doesn't parse: %v", match, rewritten, err)
+			}
+			if host := u.Hostname(); host != "fxtwitter.com" && host != "fixupx.com" {
+				t.Fatalf("modifySingleLink(%q) = %q; unexpected host %q", match, rewritten, host)
+			}
+			if u.RawQuery != "" {
+				t.Fatalf("modifySingleLink(%q) = %q; query was not stripped", match, rewritten)
+			}
+		}
+	})
+}
+
+func FuzzExtractTwitterLinks(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, link := range extractTwitterLinks(input) {
+			if !strings.Contains(input, link) {
+				t.Fatalf("extractTwitterLinks(%q) returned %q, which isn't in the input", input, link)
+			}
+			if utf8.ValidString(input) && !utf8.ValidString(link) {
+				t.Fatalf("extractTwitterLinks(%q) returned invalid UTF-8 %q", input, link)
+			}
+			u, err := url.Parse(link)
+			if err != nil {
+				t.Fatalf("extractTwitterLinks(%q) returned %q, which doesn't parse: %v", input, link, err)
+			}
+			host := strings.TrimPrefix(strings.TrimPrefix(u.Hostname(), "www."), "mobile.")
+			if host != "twitter.com" && host != "x.com" {
+				t.Fatalf("extractTwitterLinks(%q) returned %q with host %q", input, link, host)
+			}
+		}
+	})
+}
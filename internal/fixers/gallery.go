@@ -0,0 +1,81 @@
+package fixers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/patterns"
+)
+
+// Gallery is a tweet with several photos whose embed doesn't show them all.
+type Gallery struct {
+	TweetID string
+	// Missing are the URLs of the photos the embed doesn't show.
+	Missing []string
+}
+
+// Galleries looks up the tweets linked in m and returns those with photos its
+// embeds don't show, such as a four-photo tweet Discord embedded with one.
+// Tweets linked in angle brackets, which aren't embedded on purpose, are left out.
+func Galleries(ctx context.Context, tweets *fxtwitter.Client, m *discordgo.MessageCreate) []Gallery {
+	if tweets == nil {
+		return nil
+	}
+	var galleries []Gallery
+	for _, id := range embeddedTweetIDs(m.Content) {
+		tweet, err := tweets.Status(ctx, id)
+		if err != nil {
+			log.Println("Error fetching tweet media:", err)
+			continue
+		}
+		photos := tweet.Photos()
+		if len(photos) < 2 {
+			continue
+		}
+		shown := shownPhotos(m.Embeds, id)
+		explain.Note(ctx, "gallery", fmt.Sprintf("tweet %s shows %d of %d photos", id, min(shown, len(photos)), len(photos)))
+		if shown < len(photos) {
+			galleries = append(galleries, Gallery{TweetID: id, Missing: photos[shown:]})
+		}
+	}
+	return galleries
+}
+
+// embeddedTweetIDs returns the IDs of the tweets linked in content outside
+// angle brackets, each once.
+func embeddedTweetIDs(content string) []string {
+	var ids []string
+	for _, match := range patterns.TwitterRewritable.FindAllString(content, -1) {
+		if strings.HasPrefix(match, "<") && strings.HasSuffix(match, ">") {
+			continue
+		}
+		if id := patterns.TweetID.FindStringSubmatch(match); id != nil && !slices.Contains(ids, id[1]) {
+			ids = append(ids, id[1])
+		}
+	}
+	return ids
+}
+
+// shownPhotos counts the embeds of the tweet with the given ID that show a
+// picture. Discord embeds each photo of a tweet it shows as a separate embed
+// linking to the tweet.
+func shownPhotos(embeds []*discordgo.MessageEmbed, tweetID string) int {
+	shown := 0
+	for _, embed := range embeds {
+		id := patterns.TweetID.FindStringSubmatch(embed.URL)
+		if id == nil || id[1] != tweetID {
+			continue
+		}
+		if embed.Image != nil && embed.Image.URL != "" || embed.Thumbnail != nil && embed.Thumbnail.URL != "" {
+			shown++
+		}
+	}
+	return shown
+}
@@ -0,0 +1,67 @@
+package fixers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/storage"
+)
+
+func TestTwitterFixerGalleries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status/1":
+			w.Write([]byte(`{"tweet":{"id":"1","media":{"all":[{"type":"photo","url":"https://pbs.twimg.com/media/a.jpg"},{"type":"photo","url":"https://pbs.twimg.com/media/b.jpg"}]}}}`))
+		case "/status/2":
+			w.Write([]byte(`{"tweet":{"id":"2","media":{"all":[{"type":"photo","url":"https://pbs.twimg.com/media/c.jpg"}]}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	photo := func(tweet, name string) *discordgo.MessageEmbed {
+		return &discordgo.MessageEmbed{URL: "https://x.com/user/status/" + tweet, Image: &discordgo.MessageEmbedImage{URL: "https://pbs.twimg.com/media/" + name + ".jpg"}}
+	}
+	testCases := []struct {
+		name     string
+		mode     string
+		content  string
+		embeds   []*discordgo.MessageEmbed
+		expected string
+	}{
+		{name: "Off", content: "https://x.com/user/status/1", embeds: []*discordgo.MessageEmbed{photo("1", "a")},
+			expected: "https://x.com/user/status/1"},
+		{name: "One of two photos shown", mode: config.GalleryRepost, content: "https://x.com/user/status/1", embeds: []*discordgo.MessageEmbed{photo("1", "a")},
+			expected: "https://fixupx.com/user/status/1"},
+		{name: "Every photo shown", mode: config.GalleryRepost, content: "https://x.com/user/status/1", embeds: []*discordgo.MessageEmbed{photo("1", "a"), photo("1", "b")},
+			expected: "https://x.com/user/status/1"},
+		{name: "Only the gallery is fixed", mode: config.GalleryRepost, content: "https://x.com/user/status/2 https://x.com/user/status/1",
+			embeds:   []*discordgo.MessageEmbed{photo("2", "c"), photo("1", "a")},
+			expected: "https://x.com/user/status/2 https://fixupx.com/user/status/1"},
+		{name: "Attach mode leaves the link", mode: config.GalleryAttach, content: "https://x.com/user/status/1", embeds: []*discordgo.MessageEmbed{photo("1", "a")},
+			expected: "https://x.com/user/status/1"},
+		{name: "Unknown tweet", mode: config.GalleryRepost, content: "https://x.com/user/status/3", embeds: []*discordgo.MessageEmbed{photo("3", "d")},
+			expected: "https://x.com/user/status/3"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{Galleries: tc.mode}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: "guild", Content: tc.content, Embeds: tc.embeds}}
+			f := Twitter{Store: st, Tweets: fxtwitter.New(server.URL, server.Client())}
+			if result := f.Fix(context.Background(), m, m.Content); result != tc.expected {
+				t.Errorf("Twitter.Fix(%q) = %q; want %q", tc.content, result, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package fixers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/odesli"
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/storage"
+)
+
+// musicPlatforms maps the music sites guilds pick to the platforms Odesli
+// names them by.
+var musicPlatforms = map[string]string{
+	config.MusicSpotify:      odesli.Spotify,
+	config.MusicAppleMusic:   odesli.AppleMusic,
+	config.MusicYouTubeMusic: odesli.YouTubeMusic,
+	config.MusicTidal:        odesli.Tidal,
+	config.MusicDeezer:       odesli.Deezer,
+}
+
+// musicHosts maps the hosts music links are on to their platforms.
+var musicHosts = map[string]string{
+	"open.spotify.com":  odesli.Spotify,
+	"music.apple.com":   odesli.AppleMusic,
+	"music.youtube.com": odesli.YouTubeMusic,
+	"tidal.com":         odesli.Tidal,
+	"listen.tidal.com":  odesli.Tidal,
+	"deezer.com":        odesli.Deezer,
+	"www.deezer.com":    odesli.Deezer,
+}
+
+// Music rewrites links to songs and albums on streaming services, such as
+// Spotify or Apple Music, to the same release on the service the guild picked,
+// or to its song.link page. Guilds that picked none are left alone.
+type Music struct {
+	// Store holds the per-guild settings; nil leaves every link alone.
+	Store storage.Store
+	// Songs finds releases on the other services. Nil leaves every link alone.
+	Songs *odesli.Client
+	// Budget limits the songs each guild may have looked up; past it, links
+	// are left alone. Nil allows every lookup.
+	Budget *budget.Budget
+}
+
+// Name implements Fixer.
+func (Music) Name() string { return "music" }
+
+// Fix implements Fixer.
+func (f Music) Fix(ctx context.Context, m *discordgo.MessageCreate, content string) string {
+	if f.Songs == nil || f.Store == nil || !patterns.MusicLink.MatchString(m.Content) {
+		return content
+	}
+	cfg, err := config.LoadGuild(f.Store, m.GuildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+	}
+	if cfg.MusicSite == "" {
+		return content
+	}
+	target := musicPlatforms[cfg.MusicSite]
+
+	var b strings.Builder
+	last, rewritten := 0, 0
+	for _, loc := range patterns.MusicLink.FindAllStringIndex(content, -1) {
+		// Links in angle brackets are left alone, like other links
+		if loc[0] > 0 && content[loc[0]-1] == '<' {
+			continue
+		}
+		link := content[loc[0]:loc[1]]
+		if target != "" && musicPlatform(link) == target {
+			continue
+		}
+		if !f.Budget.Spend(m.GuildID, budget.Lookups, 1) {
+			break
+		}
+		song, err := f.Songs.Lookup(ctx, link)
+		if err != nil {
+			if !errors.Is(err, odesli.ErrNotFound) {
+				log.Println("Error looking up song:", err)
+			}
+			continue
+		}
+		fixed, ok := song.Links[target]
+		if !ok {
+			fixed = song.PageURL
+		}
+		b.WriteString(content[last:loc[0]])
+		b.WriteString(fixed)
+		last = loc[1]
+		rewritten++
+	}
+	explain.Note(ctx, "music", fmt.Sprintf("pointed %d links at %s", rewritten, cfg.MusicSite))
+	if last == 0 {
+		return content
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// musicPlatform returns the platform a music link is on.
+func musicPlatform(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return musicHosts[strings.ToLower(u.Hostname())]
+}
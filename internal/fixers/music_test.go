@@ -0,0 +1,67 @@
+package fixers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/odesli"
+	"go-discord-bot/internal/storage"
+)
+
+func TestMusicFixer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("url") {
+		case "https://open.spotify.com/track/abc?si=x", "https://music.apple.com/us/album/song/1?i=2":
+			w.Write([]byte(`{"pageUrl":"https://song.link/s/abc","linksByPlatform":{"spotify":{"url":"https://open.spotify.com/track/abc"},"appleMusic":{"url":"https://music.apple.com/us/album/song/1?i=2"}}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	st := storage.NewMemory()
+	for guildID, site := range map[string]string{"page": config.MusicSongLink, "apple": config.MusicAppleMusic, "spotify": config.MusicSpotify, "tidal": config.MusicTidal} {
+		if err := config.SaveGuild(st, guildID, config.Guild{MusicSite: site}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		guildID  string
+		input    string
+		expected string
+	}{
+		{name: "song.link page", guildID: "page",
+			input: "listen https://open.spotify.com/track/abc?si=x", expected: "listen https://song.link/s/abc"},
+		{name: "Other service", guildID: "apple",
+			input: "https://open.spotify.com/track/abc?si=x !", expected: "https://music.apple.com/us/album/song/1?i=2 !"},
+		{name: "Already on the service", guildID: "apple",
+			input: "https://music.apple.com/us/album/song/1?i=2", expected: "https://music.apple.com/us/album/song/1?i=2"},
+		{name: "Apple Music to Spotify", guildID: "spotify",
+			input: "https://music.apple.com/us/album/song/1?i=2", expected: "https://open.spotify.com/track/abc"},
+		{name: "Not on the service", guildID: "tidal",
+			input: "https://open.spotify.com/track/abc?si=x", expected: "https://song.link/s/abc"},
+		{name: "Not found", guildID: "page",
+			input: "https://open.spotify.com/track/gone", expected: "https://open.spotify.com/track/gone"},
+		{name: "Angle brackets", guildID: "page",
+			input: "<https://open.spotify.com/track/abc?si=x>", expected: "<https://open.spotify.com/track/abc?si=x>"},
+		{name: "Guild picked nothing", guildID: "other",
+			input: "https://open.spotify.com/track/abc?si=x", expected: "https://open.spotify.com/track/abc?si=x"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: tc.guildID, Content: tc.input}}
+			f := Music{Store: st, Songs: odesli.New(server.URL, "", server.Client())}
+			if result := f.Fix(context.Background(), m, m.Content); result != tc.expected {
+				t.Errorf("Music.Fix(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
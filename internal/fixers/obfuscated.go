@@ -0,0 +1,198 @@
+package fixers
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go-discord-bot/internal/patterns"
+)
+
+// lookalikes maps letters that look like Latin ones, from Cyrillic and Greek,
+// to the Latin letter. Fullwidth forms are mapped by foldLookalikes itself.
+var lookalikes = map[rune]rune{
+	'а': 'a', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j', 'к': 'k', 'о': 'o', 'р': 'p',
+	'с': 'c', 'ѕ': 's', 'у': 'y', 'х': 'x', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O', 'Р': 'P', 'С': 'C', 'Т': 'T',
+	'Х': 'X', 'Ѕ': 'S', 'І': 'I', 'Ј': 'J', 'Ԝ': 'W',
+	'ο': 'o', 'ν': 'v', 'τ': 't', 'χ': 'x', 'ι': 'i', 'κ': 'k', 'ρ': 'p',
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M', 'Ν': 'N', 'Ο': 'O',
+	'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X',
+	// Browsers read these as dots in domains
+	'。': '.', '｡': '.',
+}
+
+// invisible are characters that show as nothing, which can split a link's
+// host or path without anyone seeing it.
+var invisible = []rune{'\u00ad', '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff'}
+
+// unobfuscateTwitterLinks spells the Twitter/X links in content that hide
+// behind percent-encoding, punycode, lookalike letters or invisible
+// characters, such as https://x%2Ecom/user/status/1 or
+// https://ｘ.com/user/status/1, the plain way, so they're fixed like any
+// other. Other links are left as they are.
+func unobfuscateTwitterLinks(content string) string {
+	if !patterns.HasLink(content) {
+		return content
+	}
+	return patterns.URL.ReplaceAllStringFunc(content, func(match string) string {
+		link := strings.TrimSuffix(strings.TrimPrefix(match, "<"), ">")
+		plain := unobfuscateLink(link)
+		// The link itself has to be the tweet, not one it spells out further on
+		if loc := patterns.TwitterStatus.FindStringIndex(plain); plain == link || loc == nil || loc[0] != 0 {
+			return match
+		}
+		return strings.Replace(match, link, plain, 1)
+	})
+}
+
+// unobfuscateLink decodes the host and path of link and folds lookalike
+// characters in them to plain ASCII. The query string and fragment keep
+// their encoding, since they may need it.
+func unobfuscateLink(link string) string {
+	i := strings.Index(link, "://")
+	if i < 0 {
+		return link
+	}
+	prefix, rest := link[:i+3], link[i+3:]
+	end := strings.IndexAny(rest, "?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	path, suffix := rest[:end], rest[end:]
+	// Decoding that would split the link or end it early is left undone
+	if decoded, err := url.PathUnescape(path); err == nil && !strings.ContainsFunc(decoded, unicode.IsSpace) && !strings.ContainsAny(decoded, "<>") {
+		path = decoded
+	}
+	path = foldLookalikes(path)
+
+	host, path, hasPath := strings.Cut(path, "/")
+	labels := strings.Split(strings.ToLower(host), ".")
+	for n, label := range labels {
+		if encoded, ok := strings.CutPrefix(label, "xn--"); ok {
+			if decoded, ok := decodePunycode(encoded); ok {
+				labels[n] = strings.ToLower(foldLookalikes(decoded))
+			}
+		}
+	}
+	host = strings.Join(labels, ".")
+	if hasPath {
+		host += "/"
+	}
+	return prefix + host + path + suffix
+}
+
+// foldLookalikes maps fullwidth characters and lookalikes to their ASCII
+// counterparts and drops invisible characters.
+func foldLookalikes(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '！' && r <= '～':
+			// Fullwidth forms sit at a fixed offset from ASCII
+			return r - '！' + '!'
+		case slices.Contains(invisible, r):
+			return -1
+		}
+		if plain, ok := lookalikes[r]; ok {
+			return plain
+		}
+		return r
+	}, s)
+}
+
+// Punycode parameters, from RFC 3492.
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+	// punyMaxLabel is the longest a domain label may be.
+	punyMaxLabel = 63
+)
+
+// decodePunycode decodes the punycode of an internationalized domain label,
+// without its "xn--" prefix, reporting false if it isn't valid punycode.
+func decodePunycode(s string) (string, bool) {
+	if len(s) > punyMaxLabel {
+		return "", false
+	}
+	var output []rune
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		for _, r := range s[:i] {
+			if r >= utf8.RuneSelf {
+				return "", false
+			}
+			output = append(output, r)
+		}
+		s = s[i+1:]
+	}
+
+	n, bias, i := punyInitialN, punyInitialBias, 0
+	for len(s) > 0 {
+		oldI, w := i, 1
+		for k := punyBase; ; k += punyBase {
+			if len(s) == 0 {
+				return "", false
+			}
+			digit, ok := punyDigit(s[0])
+			s = s[1:]
+			if !ok {
+				return "", false
+			}
+			i += digit * w
+			t := min(max(k-bias, punyTMin), punyTMax)
+			if digit < t {
+				break
+			}
+			w *= punyBase - t
+			// Labels are short, so anything this large is garbage
+			if i > utf8.MaxRune*punyMaxLabel || w > utf8.MaxRune*punyMaxLabel {
+				return "", false
+			}
+		}
+		length := len(output) + 1
+		bias = punyAdapt(i-oldI, length, oldI == 0)
+		n += i / length
+		i %= length
+		if n > utf8.MaxRune {
+			return "", false
+		}
+		output = slices.Insert(output, i, rune(n))
+		i++
+	}
+	return string(output), true
+}
+
+// punyDigit returns the value of a punycode digit.
+func punyDigit(c byte) (int, bool) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A'), true
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 26, true
+	}
+	return 0, false
+}
+
+// punyAdapt is the bias adaptation function of RFC 3492.
+func punyAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	k := 0
+	for delta > (punyBase-punyTMin)*punyTMax/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
@@ -0,0 +1,85 @@
+package fixers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestUnobfuscateTwitterLinks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Plain link", input: "https://x.com/user/status/1", expected: "https://x.com/user/status/1"},
+		{name: "Encoded dot", input: "see https://x%2Ecom/user/status/1", expected: "see https://x.com/user/status/1"},
+		{name: "Encoded slashes", input: "https://twitter.com%2Fuser%2Fstatus%2F1", expected: "https://twitter.com/user/status/1"},
+		{name: "Query keeps its encoding", input: "https://x%2ecom/user/status/1?t=a%20b", expected: "https://x.com/user/status/1?t=a%20b"},
+		{name: "Upper case host", input: "https://X.COM/user/status/1", expected: "https://x.com/user/status/1"},
+		{name: "Cyrillic letter", input: "https://х.com/user/status/1", expected: "https://x.com/user/status/1"},
+		{name: "Fullwidth letters", input: "https://ｔｗｉｔｔｅｒ.com/user/status/1", expected: "https://twitter.com/user/status/1"},
+		{name: "Ideographic dot", input: "https://x。com/user/status/1", expected: "https://x.com/user/status/1"},
+		{name: "Invisible character", input: "https://x.\u200bcom/user/status/1", expected: "https://x.com/user/status/1"},
+		{name: "Punycode", input: "https://xn--u1a.com/user/status/1", expected: "https://x.com/user/status/1"},
+		{name: "Punycode with lookalike", input: "https://www.xn--twtter-qvf.com/user/status/1", expected: "https://www.twitter.com/user/status/1"},
+		{name: "Angle brackets", input: "<https://x%2Ecom/user/status/1>", expected: "<https://x.com/user/status/1>"},
+		{name: "Encoded space left alone", input: "https://x.com/user/status/1%20x", expected: "https://x.com/user/status/1%20x"},
+		{name: "Other sites left alone", input: "https://ex%61mple.com/х", expected: "https://ex%61mple.com/х"},
+		{name: "Tweet further on left alone", input: "https://example.com/go/https:%2F%2Fx.com/user/status/1", expected: "https://example.com/go/https:%2F%2Fx.com/user/status/1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := unobfuscateTwitterLinks(tc.input); result != tc.expected {
+				t.Errorf("unobfuscateTwitterLinks(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDecodePunycode(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{input: "u1a", expected: "х", ok: true},
+		{input: "mnchen-3ya", expected: "münchen", ok: true},
+		{input: "com-kfd", expected: "хcom", ok: true},
+		{input: "9i7c", expected: "ｘ", ok: true},
+		{input: "a!b"},
+		{input: "mnchen-3y"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result, ok := decodePunycode(tc.input)
+			if result != tc.expected || ok != tc.ok {
+				t.Errorf("decodePunycode(%q) = %q, %v; want %q, %v", tc.input, result, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+}
+
+func TestTwitterFixerObfuscated(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Percent-encoded", input: "look https://x%2Ecom/user/status/1", expected: "look https://fixupx.com/user/status/1"},
+		{name: "Lookalike", input: "https://tхitter.com/user/status/1 https://х.com/user/status/2", expected: "https://tхitter.com/user/status/1 https://fixupx.com/user/status/2"},
+		{name: "Angle brackets stay as posted", input: "<https://x%2Ecom/user/status/1>", expected: "<https://x%2Ecom/user/status/1>"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{Content: tc.input}}
+			if result := (Twitter{}).Fix(context.Background(), m, m.Content); result != tc.expected {
+				t.Errorf("Twitter.Fix(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package fixers
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/patterns"
+)
+
+// maxMaskLabel is how long, in characters, the text of a masked link may be.
+const maxMaskLabel = 60
+
+// Render rewrites the links in content that styles has an output style for:
+// config.OutputMasked links become markdown links, and config.OutputEmbed
+// links are wrapped in angle brackets so the embed the bot posts with them
+// shows instead of Discord's. Links already in angle brackets keep them.
+// Other links are left as they are.
+func Render(content string, styles map[string]string) string {
+	if len(styles) == 0 {
+		return content
+	}
+	return patterns.URL.ReplaceAllStringFunc(content, func(link string) string {
+		switch styles[link] {
+		case config.OutputMasked:
+			return "[" + maskLabel(link) + "](" + link + ")"
+		case config.OutputEmbed:
+			if !strings.HasPrefix(link, "<") {
+				return "<" + link + ">"
+			}
+		}
+		return link
+	})
+}
+
+// maskLabel returns the text of a masked link: the link without its scheme
+// and query, shortened if need be. Markdown in it is escaped so the label
+// can't end the link early.
+func maskLabel(link string) string {
+	label := strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">")
+	label = strings.TrimPrefix(strings.TrimPrefix(label, "https://"), "http://")
+	label = strings.TrimPrefix(label, "www.")
+	label, _, _ = strings.Cut(label, "?")
+	label = strings.TrimSuffix(label, "/")
+	if utf8.RuneCountInString(label) > maxMaskLabel {
+		label = string([]rune(label)[:maxMaskLabel-1]) + "…"
+	}
+	return strings.NewReplacer("[", "\\[", "]", "\\]", "_", "\\_", "*", "\\*", "~", "\\~", "`", "\\`").Replace(label)
+}
@@ -0,0 +1,29 @@
+package fixers
+
+import (
+	"testing"
+
+	"go-discord-bot/internal/config"
+)
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		styles   map[string]string
+		expected string
+	}{
+		{name: "No styles", content: "look https://fixupx.com/a/status/1", expected: "look https://fixupx.com/a/status/1"},
+		{name: "Masked", content: "look https://www.fixupx.com/a_b/status/1?s=20", styles: map[string]string{"https://www.fixupx.com/a_b/status/1?s=20": config.OutputMasked}, expected: "look [fixupx.com/a\\_b/status/1](https://www.fixupx.com/a_b/status/1?s=20)"},
+		{name: "Embed", content: "https://fixupx.com/a/status/1 and https://clips.twitch.tv/x", styles: map[string]string{"https://fixupx.com/a/status/1": config.OutputEmbed}, expected: "<https://fixupx.com/a/status/1> and https://clips.twitch.tv/x"},
+		{name: "Already hidden", content: "<https://fixupx.com/a/status/1>", styles: map[string]string{"<https://fixupx.com/a/status/1>": config.OutputEmbed}, expected: "<https://fixupx.com/a/status/1>"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := Render(tc.content, tc.styles); result != tc.expected {
+				t.Errorf("Render(%q) = %q; want %q", tc.content, result, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package fixers
+
+import (
+	"regexp"
+)
+
+// fxTwitterLink matches an fxtwitter or fixupx status link and anything after it
+// up to the next whitespace. The link up to the status ID is group 1.
+var fxTwitterLink = regexp.MustCompile(`(https://(?:fxtwitter|fixupx)\.com/[A-Za-z0-9_]+/status/\d+)[^\s<>]*`)
+
+// flagLanguages maps country flag emoji to the language fxtwitter should translate to.
+var flagLanguages = map[string]string{
+	"🇺🇸": "en", "🇬🇧": "en", "🇦🇺": "en", "🇨🇦": "en",
+	"🇯🇵": "ja",
+	"🇰🇷": "ko",
+	"🇨🇳": "zh", "🇹🇼": "zh",
+	"🇫🇷": "fr",
+	"🇩🇪": "de",
+	"🇪🇸": "es", "🇲🇽": "es",
+	"🇧🇷": "pt", "🇵🇹": "pt",
+	"🇮🇹": "it",
+	"🇷🇺": "ru",
+	"🇺🇦": "uk",
+	"🇵🇱": "pl",
+	"🇳🇱": "nl",
+	"🇹🇷": "tr",
+	"🇸🇦": "ar",
+	"🇮🇳": "hi",
+	"🇮🇩": "id",
+	"🇹🇭": "th",
+	"🇻🇳": "vi",
+}
+
+// FlagLanguage returns the language a flag emoji asks tweets to be translated to.
+func FlagLanguage(emoji string) (string, bool) {
+	lang, ok := flagLanguages[emoji]
+	return lang, ok
+}
+
+// TranslateLinks points every fxtwitter and fixupx link in content at the
+// version translated to lang, replacing any earlier translation.
+func TranslateLinks(content, lang string) string {
+	return fxTwitterLink.ReplaceAllString(content, "${1}/"+lang)
+}
+
+// translateLink is TranslateLinks for a single fixed link, doing nothing when lang is empty.
+func translateLink(link, lang string) string {
+	if lang == "" {
+		return link
+	}
+	return TranslateLinks(link, lang)
+}
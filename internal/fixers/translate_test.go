@@ -0,0 +1,47 @@
+package fixers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+func TestTranslateLinks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "fxtwitter link", input: "look https://fxtwitter.com/user/status/1 wow", expected: "look https://fxtwitter.com/user/status/1/en wow"},
+		{name: "fixupx link", input: "https://fixupx.com/user/status/1", expected: "https://fixupx.com/user/status/1/en"},
+		{name: "Earlier translation is replaced", input: "https://fixupx.com/user/status/1/ja", expected: "https://fixupx.com/user/status/1/en"},
+		{name: "Photo suffix is dropped", input: "https://fixupx.com/user/status/1/photo/1", expected: "https://fixupx.com/user/status/1/en"},
+		{name: "Raw link is left alone", input: "https://x.com/user/status/1", expected: "https://x.com/user/status/1"},
+		{name: "Bracketed link keeps its brackets", input: "<https://fixupx.com/user/status/1>", expected: "<https://fixupx.com/user/status/1/en>"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := TranslateLinks(tc.input, "en"); result != tc.expected {
+				t.Errorf("TranslateLinks(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTwitterFixerTranslate(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "guild", config.Guild{TranslateTo: "en"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: "guild", Content: "https://x.com/user/status/1?s=20 <https://x.com/user/status/2>"}}
+	expected := "https://fixupx.com/user/status/1/en <https://x.com/user/status/2>"
+	if result := (Twitter{Store: st}).Fix(context.Background(), m, m.Content); result != expected {
+		t.Errorf("Twitter.Fix = %q; want %q", result, expected)
+	}
+}
@@ -0,0 +1,93 @@
+package fixers
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/flags"
+	"go-discord-bot/internal/patterns"
+)
+
+// Twitch rewrites Twitch clip links whose preview failed to render.
+type Twitch struct {
+	// Proxy is the host clip links are rewritten to.
+	Proxy string
+	// Flags controls experimental behavior; nil uses the defaults.
+	Flags *flags.Flags
+}
+
+// Name implements Fixer.
+func (Twitch) Name() string { return "twitch" }
+
+// Fix implements Fixer.
+func (f Twitch) Fix(ctx context.Context, m *discordgo.MessageCreate, content string) string {
+	if !containsTwitchClipLink(m.Content) {
+		return content
+	}
+	if f.Flags.EnabledFor(flags.EmbedVerification, m.GuildID) && hasValidTwitchPreview(m) {
+		return content
+	}
+	return modifyTwitchLinks(content, f.Proxy)
+}
+
+func containsTwitchClipLink(content string) bool {
+	return strings.Contains(content, "twitch.tv") && patterns.TwitchClip.MatchString(content)
+}
+
+// hasValidTwitchPreview reports whether Discord already rendered a playable clip embed.
+func hasValidTwitchPreview(m *discordgo.MessageCreate) bool {
+	for _, embed := range m.Embeds {
+		if isWorkingTwitchEmbed(embed) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWorkingTwitchEmbed(embed *discordgo.MessageEmbed) bool {
+	// Only Twitch's own player counts; a video from anywhere else isn't the clip
+	if embed.Video != nil && embed.Video.URL != "" {
+		if u, err := url.Parse(embed.Video.URL); err == nil && isTwitchHost(u.Hostname()) {
+			return true
+		}
+	}
+
+	// Clip thumbnails are served from Twitch's CDNs; a generic Twitch logo means the embed failed
+	if embed.Thumbnail != nil && embed.Thumbnail.URL != "" {
+		u, err := url.Parse(embed.Thumbnail.URL)
+		if err == nil {
+			host := strings.ToLower(u.Hostname())
+			if host == "static-cdn.jtvnw.net" && strings.Contains(u.Path, "twitch-clips") {
+				return true
+			}
+			// Such as clips-media-assets2.twitch.tv
+			if strings.HasPrefix(host, "clips-media-assets") && isTwitchHost(host) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isTwitchHost reports whether host is twitch.tv or one of its subdomains,
+// such as clips.twitch.tv.
+func isTwitchHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "twitch.tv" || strings.HasSuffix(host, ".twitch.tv")
+}
+
+// modifyTwitchLinks rewrites Twitch clip links to the given embed proxy host.
+// Links in angle brackets are left alone, matching the Twitter behavior.
+func modifyTwitchLinks(content, proxy string) string {
+	return patterns.TwitchClip.ReplaceAllStringFunc(content, func(match string) string {
+		if strings.HasPrefix(match, "<") && strings.HasSuffix(match, ">") {
+			return match
+		}
+		groups := patterns.TwitchClip.FindStringSubmatch(match)
+		return "https://" + proxy + "/" + groups[2]
+	})
+}
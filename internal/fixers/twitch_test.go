@@ -0,0 +1,132 @@
+package fixers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestModifyTwitchLinks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Clips subdomain link",
+			input:    "lol https://clips.twitch.tv/FunnySlug-abc123",
+			expected: "lol https://clips.fxtwitch.tv/FunnySlug-abc123",
+		},
+		{
+			name:     "Channel clip link",
+			input:    "https://www.twitch.tv/streamer/clip/FunnySlug-abc123?filter=clips",
+			expected: "https://clips.fxtwitch.tv/FunnySlug-abc123",
+		},
+		{
+			name:     "Mobile channel clip link",
+			input:    "https://m.twitch.tv/streamer/clip/Slug",
+			expected: "https://clips.fxtwitch.tv/Slug",
+		},
+		{
+			name:     "Link in angle brackets",
+			input:    "<https://clips.twitch.tv/Slug>",
+			expected: "<https://clips.twitch.tv/Slug>",
+		},
+		{
+			name:     "Channel link is not a clip",
+			input:    "https://twitch.tv/streamer",
+			expected: "https://twitch.tv/streamer",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := modifyTwitchLinks(tc.input, "clips.fxtwitch.tv")
+			if result != tc.expected {
+				t.Errorf("modifyTwitchLinks(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTwitchFixer(t *testing.T) {
+	f := Twitch{Proxy: "clips.example.com"}
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{Content: "https://clips.twitch.tv/Slug"}}
+
+	if result := f.Fix(context.Background(), m, m.Content); result != "https://clips.example.com/Slug" {
+		t.Errorf("Twitch.Fix with custom proxy = %q", result)
+	}
+
+	m.Embeds = []*discordgo.MessageEmbed{{Video: &discordgo.MessageEmbedVideo{URL: "https://clips.twitch.tv/embed?clip=Slug"}}}
+	if result := f.Fix(context.Background(), m, m.Content); result != m.Content {
+		t.Errorf("Twitch.Fix with working embed = %q; want unchanged", result)
+	}
+}
+
+func TestIsWorkingTwitchEmbed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		embed    *discordgo.MessageEmbed
+		expected bool
+	}{
+		{
+			name:     "Embed with video",
+			embed:    &discordgo.MessageEmbed{Video: &discordgo.MessageEmbedVideo{URL: "https://clips.twitch.tv/embed?clip=Slug"}},
+			expected: true,
+		},
+		{
+			name:     "Video on the Twitch player",
+			embed:    &discordgo.MessageEmbed{Video: &discordgo.MessageEmbedVideo{URL: "https://player.twitch.tv/?clip=Slug"}},
+			expected: true,
+		},
+		{
+			name:     "Video on another host",
+			embed:    &discordgo.MessageEmbed{Video: &discordgo.MessageEmbedVideo{URL: "https://example.com/clips.twitch.tv/video.mp4"}},
+			expected: false,
+		},
+		{
+			name:     "Video on a lookalike host",
+			embed:    &discordgo.MessageEmbed{Video: &discordgo.MessageEmbedVideo{URL: "https://clips.twitch.tv.example.com/embed?clip=Slug"}},
+			expected: false,
+		},
+		{
+			name:     "Clip thumbnail",
+			embed:    &discordgo.MessageEmbed{Thumbnail: &discordgo.MessageEmbedThumbnail{URL: "https://static-cdn.jtvnw.net/twitch-clips/abc/preview.jpg"}},
+			expected: true,
+		},
+		{
+			name:     "Clip thumbnail on a lookalike CDN",
+			embed:    &discordgo.MessageEmbed{Thumbnail: &discordgo.MessageEmbedThumbnail{URL: "https://evilstatic-cdn.jtvnw.net/twitch-clips/abc/preview.jpg"}},
+			expected: false,
+		},
+		{
+			name:     "Clip media assets",
+			embed:    &discordgo.MessageEmbed{Thumbnail: &discordgo.MessageEmbedThumbnail{URL: "https://clips-media-assets2.twitch.tv/abc-preview.jpg"}},
+			expected: true,
+		},
+		{
+			name:     "Clip media assets on another host",
+			embed:    &discordgo.MessageEmbed{Thumbnail: &discordgo.MessageEmbedThumbnail{URL: "https://clips-media-assets.example.com/abc-preview.jpg"}},
+			expected: false,
+		},
+		{
+			name:     "Generic Twitch logo",
+			embed:    &discordgo.MessageEmbed{Thumbnail: &discordgo.MessageEmbedThumbnail{URL: "https://static-cdn.jtvnw.net/ttv-static-metadata/twitch_logo3.jpg"}},
+			expected: false,
+		},
+		{
+			name:     "Empty embed",
+			embed:    &discordgo.MessageEmbed{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := isWorkingTwitchEmbed(tc.embed); result != tc.expected {
+				t.Errorf("isWorkingTwitchEmbed() = %v; want %v", result, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package fixers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/domains"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/flags"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/nitter"
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/storage"
+)
+
+// Twitter rewrites Twitter/X status links whose preview failed to render.
+type Twitter struct {
+	// Flags controls experimental behavior; nil uses the defaults.
+	Flags *flags.Flags
+	// Store holds the per-guild settings, such as which site links point to.
+	// Nil means every guild uses the defaults.
+	Store storage.Store
+	// Nitter is where links go for guilds that prefer Nitter. Nil or with no
+	// instance up, those guilds get fxtwitter links instead.
+	Nitter *nitter.Instances
+	// Domains tracks the custom sites guilds point links to. Guilds whose
+	// site is down get fxtwitter links meanwhile; nil considers every site up.
+	// It also tracks the fixing sites guilds swap while one is down.
+	Domains *domains.Monitor
+	// Budget limits the tweets each guild may have looked up; past it,
+	// galleries are left alone. Nil allows every lookup.
+	Budget *budget.Budget
+	// EmbedThreshold is the score an embed needs for its tweet to be left
+	// alone, DefaultEmbedThreshold when 0.
+	EmbedThreshold int
+	// Tweets looks up how many photos tweets have, for guilds that fix tweets
+	// whose embed shows only some of them. Nil disables this.
+	Tweets *fxtwitter.Client
+}
+
+// Name implements Fixer.
+func (Twitter) Name() string { return "twitter" }
+
+// Fix implements Fixer. Links spelled to slip past it, such as
+// percent-encoded or lookalike ones, are fixed like plain ones.
+func (f Twitter) Fix(ctx context.Context, m *discordgo.MessageCreate, content string) string {
+	plain := unobfuscateTwitterLinks(content)
+	if plain == content {
+		return f.fix(ctx, m, content)
+	}
+	// Fixers also look at the message itself, so it gets the plain links too
+	unobfuscated := *m.Message
+	unobfuscated.Content = unobfuscateTwitterLinks(m.Content)
+	fixed := f.fix(ctx, &discordgo.MessageCreate{Message: &unobfuscated}, plain)
+	if fixed == plain {
+		// Nothing needed fixing, so the links stay as they were posted
+		return content
+	}
+	return fixed
+}
+
+// fix is Fix for content whose links are spelled plainly.
+func (f Twitter) fix(ctx context.Context, m *discordgo.MessageCreate, content string) string {
+	proxied := patterns.TwitterProxyStatus.MatchString(m.Content)
+	if !containsTwitterLink(m.Content) && !proxied {
+		return content
+	}
+	cfg := f.guildConfig(m.GuildID)
+	if proxied && cfg.SwapProxies {
+		content = f.swapProxies(content)
+	}
+	if !containsTwitterLink(m.Content) {
+		return content
+	}
+
+	// Log detailed information about the message and its embeds
+	logTwitterMessage(m)
+
+	// Check if the message has any valid Twitter embeds or attachments
+	threshold := f.EmbedThreshold
+	if threshold == 0 {
+		threshold = DefaultEmbedThreshold
+	}
+	// Leave tweets the author already fixed by hand alongside the raw link
+	skip := proxiedTweetIDs(m.Content)
+	if f.Flags.EnabledFor(flags.EmbedVerification, m.GuildID) && hasValidTwitterPreview(ctx, m, threshold) {
+		if cfg.Galleries != config.GalleryRepost {
+			return content
+		}
+		// The embed works but may hide photos, which the fixed embed shows
+		if !f.Budget.Spend(m.GuildID, budget.Lookups, 1) {
+			return content
+		}
+		galleries := Galleries(ctx, f.Tweets, m)
+		if len(galleries) == 0 {
+			return content
+		}
+		skip = withoutGalleries(m.Content, skip, galleries)
+	}
+	if base, ok := f.nitterBase(cfg); ok {
+		return rewriteTwitterLinks(content, skip, func(link string) string { return nitterLink(link, base) })
+	}
+	if domain, ok := f.customDomain(cfg); ok {
+		// Custom sites take the same paths as Nitter
+		return rewriteTwitterLinks(content, skip, func(link string) string { return nitterLink(link, "https://"+domain) })
+	}
+	return rewriteTwitterLinks(content, skip, func(link string) string {
+		return translateLink(modifySingleLink(link), cfg.TranslateTo)
+	})
+}
+
+// guildConfig returns the settings for a guild, or the defaults without a store.
+func (f Twitter) guildConfig(guildID string) config.Guild {
+	if f.Store == nil {
+		return config.Guild{}
+	}
+	cfg, err := config.LoadGuild(f.Store, guildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+	}
+	return cfg
+}
+
+// nitterBase returns the Nitter instance to link to if the guild prefers Nitter and one is up.
+func (f Twitter) nitterBase(cfg config.Guild) (string, bool) {
+	if cfg.TwitterSite != config.TwitterNitter {
+		return "", false
+	}
+	return f.Nitter.Current()
+}
+
+// customDomain returns the guild's own site to link to if it set one and it's up.
+func (f Twitter) customDomain(cfg config.Guild) (string, bool) {
+	if cfg.TwitterSite != config.TwitterCustom || cfg.TwitterDomain == "" || f.Domains.Down(cfg.TwitterDomain) {
+		return "", false
+	}
+	return cfg.TwitterDomain, true
+}
+
+// swapProxies points the links in content to a fixing site that's down, such
+// as fxtwitter.com, at its counterpart if that one's up. Links in angle
+// brackets are left alone, like other links.
+func (f Twitter) swapProxies(content string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range patterns.TwitterProxyStatus.FindAllStringIndex(content, -1) {
+		if loc[0] > 0 && content[loc[0]-1] == '<' {
+			continue
+		}
+		link := content[loc[0]:loc[1]]
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		// Keep subdomains such as d.fxtwitter.com, which the counterparts share
+		labels := strings.Split(u.Hostname(), ".")
+		domain := strings.Join(labels[max(len(labels)-2, 0):], ".")
+		counterpart, ok := f.Domains.Swap(domain)
+		if !ok {
+			continue
+		}
+		b.WriteString(content[last:loc[0]])
+		b.WriteString(strings.Replace(link, domain+"/", counterpart+"/", 1))
+		last = loc[1]
+	}
+	if last == 0 {
+		return content
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// logTwitterMessage logs detailed information about a message containing a Twitter link.
+// Nothing is logged for guilds in privacy mode.
+func logTwitterMessage(m *discordgo.MessageCreate) {
+	twitterLinks := extractTwitterLinks(m.Content)
+
+	for _, link := range twitterLinks {
+		logging.Contentf(m.GuildID, "Original Twitter Link: %s\n", link)
+		logging.Contentf(m.GuildID, "Total Embeds in Message: %d\n", len(m.Embeds))
+
+		for i, embed := range m.Embeds {
+			logging.Contentf(m.GuildID, "Embed %d:\n", i+1)
+			logging.Contentf(m.GuildID, "  Type: %s\n", embed.Type)
+			logging.Contentf(m.GuildID, "  Title: %s\n", embed.Title)
+			logging.Contentf(m.GuildID, "  Description: %s\n", embed.Description)
+
+			if embed.Image != nil {
+				logging.Contentf(m.GuildID, "  Image URL: %s\n", embed.Image.URL)
+			}
+
+			if embed.Thumbnail != nil {
+				logging.Contentf(m.GuildID, "  Thumbnail URL: %s\n", embed.Thumbnail.URL)
+			}
+
+			logging.Contentf(m.GuildID, "  Fields: %d\n", len(embed.Fields))
+		}
+
+		logging.Contentf(m.GuildID, "Total Attachments in Message: %d\n", len(m.Attachments))
+
+		for i, attachment := range m.Attachments {
+			logging.Contentf(m.GuildID, "Attachment %d:\n", i+1)
+			logging.Contentf(m.GuildID, "  Filename: %s\n", attachment.Filename)
+			logging.Contentf(m.GuildID, "  URL: %s\n", attachment.URL)
+			logging.Contentf(m.GuildID, "  Size: %d bytes\n", attachment.Size)
+		}
+	}
+}
+
+func containsTwitterLink(content string) bool {
+	return patterns.TwitterStatus.MatchString(content)
+}
+
+func extractTwitterLinks(content string) []string {
+	return patterns.TwitterStatusLink.FindAllString(content, -1)
+}
+
+// hasValidTwitterPreview reports whether an embed of the message scores at
+// least threshold, or the message has an attachment from Twitter's CDN.
+func hasValidTwitterPreview(ctx context.Context, m *discordgo.MessageCreate, threshold int) bool {
+	if len(m.Embeds) > 0 {
+		best := scoreTwitterEmbed(m.Embeds[0])
+		for _, embed := range m.Embeds[1:] {
+			best = max(best, scoreTwitterEmbed(embed))
+		}
+		explain.Note(ctx, "twitter embed", fmt.Sprintf("scored %d, %d needed", best, threshold))
+		if best >= threshold {
+			return true
+		}
+	}
+
+	for _, attachment := range m.Attachments {
+		if onTwitterCDN(attachment.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// modifyTwitterLinks takes a string and replaces Twitter/X links with modified versions.
+// It changes "twitter.com" to "fxtwitter.com" and "x.com" to "fixupx.com".
+func modifyTwitterLinks(content string) string {
+	return rewriteTwitterLinks(content, nil, modifySingleLink)
+}
+
+// rewriteTwitterLinks replaces every Twitter/X link outside angle brackets with
+// rewrite(link), leaving links to the tweet IDs in skip alone.
+func rewriteTwitterLinks(content string, skip map[string]bool, rewrite func(link string) string) string {
+	// Match Twitter and X links, including those in angle brackets
+	return patterns.TwitterRewritable.ReplaceAllStringFunc(content, func(match string) string {
+		if strings.HasPrefix(match, "<") && strings.HasSuffix(match, ">") {
+			return match // Preserve links in angle brackets
+		}
+		if len(skip) > 0 {
+			if id := patterns.TweetID.FindStringSubmatch(match); id != nil && skip[id[1]] {
+				return match
+			}
+		}
+		return rewrite(match)
+	})
+}
+
+// proxiedTweetIDs returns the IDs of tweets already linked through a fixing proxy in content.
+func proxiedTweetIDs(content string) map[string]bool {
+	var ids map[string]bool
+	for _, match := range patterns.TwitterProxyStatus.FindAllStringSubmatch(content, -1) {
+		if ids == nil {
+			ids = make(map[string]bool)
+		}
+		ids[match[1]] = true
+	}
+	return ids
+}
+
+// withoutGalleries adds the tweets linked in content that aren't among
+// galleries to skip, so only the galleries are fixed.
+func withoutGalleries(content string, skip map[string]bool, galleries []Gallery) map[string]bool {
+	if skip == nil {
+		skip = make(map[string]bool)
+	}
+	for _, id := range embeddedTweetIDs(content) {
+		if !slices.ContainsFunc(galleries, func(g Gallery) bool { return g.TweetID == id }) {
+			skip[id] = true
+		}
+	}
+	return skip
+}
+
+func modifySingleLink(link string) string {
+	// Remove query parameters
+	if idx := strings.Index(link, "?"); idx != -1 {
+		link = link[:idx]
+	}
+
+	// Strip protocol and www or mobile subdomain
+	link = strings.TrimPrefix(link, "http://")
+	link = strings.TrimPrefix(link, "https://")
+	link = strings.TrimPrefix(link, "www.")
+	link = strings.TrimPrefix(link, "mobile.")
+
+	// The proxies only understand /i/status/<id> for links without a username
+	link = strings.Replace(link, "/i/web/status/", "/i/status/", 1)
+
+	// Replace domain
+	if strings.HasPrefix(link, "twitter.com") {
+		link = "https://fxtwitter.com" + strings.TrimPrefix(link, "twitter.com")
+	} else if strings.HasPrefix(link, "x.com") {
+		link = "https://fixupx.com" + strings.TrimPrefix(link, "x.com")
+	}
+
+	return link
+}
+
+// nitterLink rewrites a Twitter/X link to the same tweet on the Nitter instance at base.
+func nitterLink(link, base string) string {
+	if idx := strings.Index(link, "?"); idx != -1 {
+		link = link[:idx]
+	}
+	link = strings.TrimPrefix(link, "http://")
+	link = strings.TrimPrefix(link, "https://")
+	link = strings.TrimPrefix(link, "www.")
+	link = strings.TrimPrefix(link, "mobile.")
+	link = strings.TrimPrefix(link, "twitter.com")
+	link = strings.TrimPrefix(link, "x.com")
+	return base + link
+}
@@ -0,0 +1,318 @@
+package fixers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/domains"
+	"go-discord-bot/internal/flags"
+	"go-discord-bot/internal/nitter"
+	"go-discord-bot/internal/storage"
+)
+
+func TestModifyTwitterLinks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Twitter link",
+			input:    "Check out https://twitter.com/user/status/123456",
+			expected: "Check out https://fxtwitter.com/user/status/123456",
+		},
+		{
+			name:     "X link",
+			input:    "Look at https://x.com/user/status/789012",
+			expected: "Look at https://fixupx.com/user/status/789012",
+		},
+		{
+			name:     "Multiple links",
+			input:    "Twitter: https://twitter.com/user1/status/123 and X: https://x.com/user2/status/456",
+			expected: "Twitter: https://fxtwitter.com/user1/status/123 and X: https://fixupx.com/user2/status/456",
+		},
+		{
+			name:     "No links",
+			input:    "Just a regular message",
+			expected: "Just a regular message",
+		},
+		{
+			name:     "Link in angle brackets",
+			input:    "Don't modify this: <https://twitter.com/user/status/123456>",
+			expected: "Don't modify this: <https://twitter.com/user/status/123456>",
+		},
+		{
+			name:     "Mixed links",
+			input:    "Modify this: https://twitter.com/user1/status/123 but not this: <https://x.com/user2/status/456>",
+			expected: "Modify this: https://fxtwitter.com/user1/status/123 but not this: <https://x.com/user2/status/456>",
+		},
+		{
+			name:     "Twitter link with www subdomain",
+			input:    "Check out https://www.twitter.com/user/status/123456",
+			expected: "Check out https://fxtwitter.com/user/status/123456",
+		},
+		{
+			name:     "X link with www subdomain",
+			input:    "Look at https://www.x.com/user/status/789012",
+			expected: "Look at https://fixupx.com/user/status/789012",
+		},
+		{
+			name:     "Links at start and end of string",
+			input:    "https://twitter.com/user1/status/123 is interesting and so is https://x.com/user2/status/456",
+			expected: "https://fxtwitter.com/user1/status/123 is interesting and so is https://fixupx.com/user2/status/456",
+		},
+		{
+			name:     "HTTP links",
+			input:    "Old link http://twitter.com/user/status/123456 and http://x.com/user/status/789012",
+			expected: "Old link https://fxtwitter.com/user/status/123456 and https://fixupx.com/user/status/789012",
+		},
+		{
+			name:     "Links with angle brackets in text",
+			input:    "This <link> https://twitter.com/user/status/123456 and this <one> https://x.com/user/status/789012",
+			expected: "This <link> https://fxtwitter.com/user/status/123456 and this <one> https://fixupx.com/user/status/789012",
+		},
+		{
+			name:     "Mixed www and non-www links",
+			input:    "Check https://www.twitter.com/user1/status/123 and https://x.com/user2/status/456",
+			expected: "Check https://fxtwitter.com/user1/status/123 and https://fixupx.com/user2/status/456",
+		},
+		{
+			name:     "X link with query parameters",
+			input:    "https://x.com/Nefarious_Foxx/status/1827343634091409773?t=vz1CxWwkTUyboeZhODW_yw&s=19",
+			expected: "https://fixupx.com/Nefarious_Foxx/status/1827343634091409773",
+		},
+		{
+			name:     "Twitter link with query parameters",
+			input:    "https://twitter.com/SinSquaredArt/status/1825669070588354674?t=NzSvTTYTI773iZgSnwTHpQ&s=19",
+			expected: "https://fxtwitter.com/SinSquaredArt/status/1825669070588354674",
+		},
+		{
+			name:     "X link with www and query parameters",
+			input:    "https://www.x.com/CandySharkie/status/1826132464814682482",
+			expected: "https://fixupx.com/CandySharkie/status/1826132464814682482",
+		},
+		{
+			name:     "Twitter link with www and query parameters",
+			input:    "https://www.twitter.com/CandySharkie/status/1826132464814682482",
+			expected: "https://fxtwitter.com/CandySharkie/status/1826132464814682482",
+		},
+		{
+			name:     "Twitter web status link",
+			input:    "https://twitter.com/i/web/status/1826132464814682482",
+			expected: "https://fxtwitter.com/i/status/1826132464814682482",
+		},
+		{
+			name:     "X status link without username",
+			input:    "https://x.com/i/status/1826132464814682482?s=20",
+			expected: "https://fixupx.com/i/status/1826132464814682482",
+		},
+		{
+			name:     "Mobile Twitter link",
+			input:    "https://mobile.twitter.com/user/status/123456",
+			expected: "https://fxtwitter.com/user/status/123456",
+		},
+		{
+			name:     "Mobile Twitter web status link in angle brackets",
+			input:    "<https://mobile.twitter.com/i/web/status/123456>",
+			expected: "<https://mobile.twitter.com/i/web/status/123456>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := modifyTwitterLinks(tc.input)
+			if result != tc.expected {
+				t.Errorf("modifyTwitterLinks(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTwitterFixerEmbedVerificationFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	if err := os.WriteFile(path, []byte(`{"embed_verification": false}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	featureFlags, err := flags.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{
+		Content: "https://x.com/user/status/1",
+		Embeds:  []*discordgo.MessageEmbed{{Image: &discordgo.MessageEmbedImage{URL: "https://pbs.twimg.com/media/a.jpg"}}},
+	}}
+
+	if result := (Twitter{}).Fix(context.Background(), m, m.Content); result != m.Content {
+		t.Errorf("Twitter.Fix with a working embed = %q; want unchanged", result)
+	}
+	if result := (Twitter{Flags: featureFlags}).Fix(context.Background(), m, m.Content); result != "https://fixupx.com/user/status/1" {
+		t.Errorf("Twitter.Fix with embed verification off = %q", result)
+	}
+}
+
+func TestTwitterFixerSkipsAlreadyFixedTweets(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Raw link next to its fxtwitter link", input: "https://x.com/user/status/1 https://fxtwitter.com/user/status/1",
+			expected: "https://x.com/user/status/1 https://fxtwitter.com/user/status/1"},
+		{name: "Proxy with different username casing", input: "https://twitter.com/User/status/1?s=20 https://vxtwitter.com/user/status/1",
+			expected: "https://twitter.com/User/status/1?s=20 https://vxtwitter.com/user/status/1"},
+		{name: "Direct media proxy", input: "https://x.com/user/status/1 https://d.fixupx.com/user/status/1",
+			expected: "https://x.com/user/status/1 https://d.fixupx.com/user/status/1"},
+		{name: "Proxy for a different tweet", input: "https://x.com/user/status/1 https://fixupx.com/user/status/2",
+			expected: "https://fixupx.com/user/status/1 https://fixupx.com/user/status/2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{Content: tc.input}}
+			if result := (Twitter{}).Fix(context.Background(), m, m.Content); result != tc.expected {
+				t.Errorf("Twitter.Fix(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTwitterFixerNitter(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "nitter-guild", config.Guild{TwitterSite: config.TwitterNitter}); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		instance *nitter.Instances
+		guildID  string
+		input    string
+		expected string
+	}{
+		{name: "Guild prefers Nitter", instance: nitter.New([]string{"nitter.example"}, nil), guildID: "nitter-guild",
+			input: "see https://x.com/user/status/1?s=20", expected: "see https://nitter.example/user/status/1"},
+		{name: "Mobile web status link", instance: nitter.New([]string{"nitter.example"}, nil), guildID: "nitter-guild",
+			input: "https://mobile.twitter.com/i/web/status/1", expected: "https://nitter.example/i/web/status/1"},
+		{name: "Guild uses the default", instance: nitter.New([]string{"nitter.example"}, nil), guildID: "other",
+			input: "https://x.com/user/status/1", expected: "https://fixupx.com/user/status/1"},
+		{name: "No instance available", instance: nitter.New(nil, nil), guildID: "nitter-guild",
+			input: "https://x.com/user/status/1", expected: "https://fixupx.com/user/status/1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: tc.guildID, Content: tc.input}}
+			f := Twitter{Store: st, Nitter: tc.instance}
+			if result := f.Fix(context.Background(), m, m.Content); result != tc.expected {
+				t.Errorf("Twitter.Fix(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTwitterFixerCustomDomain(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "custom-guild", config.Guild{TwitterSite: config.TwitterCustom, TwitterDomain: "fixvx.com"}); err != nil {
+		t.Fatal(err)
+	}
+	// A monitor whose last check found the site down
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	downStore := storage.NewMemory()
+	if err := config.SaveGuild(downStore, "custom-guild", config.Guild{TwitterSite: config.TwitterCustom, TwitterDomain: strings.TrimPrefix(server.URL, "https://")}); err != nil {
+		t.Fatal(err)
+	}
+	down := &domains.Monitor{Checker: domains.NewChecker(server.Client()), Store: downStore}
+	down.Check(context.Background())
+
+	testCases := []struct {
+		name     string
+		store    storage.Store
+		monitor  *domains.Monitor
+		guildID  string
+		input    string
+		expected string
+	}{
+		{name: "Guild's own site", store: st, guildID: "custom-guild",
+			input: "see https://x.com/user/status/1?s=20", expected: "see https://fixvx.com/user/status/1"},
+		{name: "Guild uses the default", store: st, guildID: "other",
+			input: "https://x.com/user/status/1", expected: "https://fixupx.com/user/status/1"},
+		{name: "Site down", store: downStore, monitor: down, guildID: "custom-guild",
+			input: "https://x.com/user/status/1", expected: "https://fixupx.com/user/status/1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: tc.guildID, Content: tc.input}}
+			f := Twitter{Store: tc.store, Domains: tc.monitor}
+			if result := f.Fix(context.Background(), m, m.Content); result != tc.expected {
+				t.Errorf("Twitter.Fix(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+// downSites answers every fixing site with a tweet embed except the ones in
+// it, which fail.
+type downSites map[string]bool
+
+func (d downSites) RoundTrip(r *http.Request) (*http.Response, error) {
+	if d[r.URL.Host] {
+		return &http.Response{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway", Body: http.NoBody, Request: r}, nil
+	}
+	page := `<meta property="og:title" content="jack"><meta property="og:description" content="just setting up my twttr">`
+	return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(page)), Request: r}, nil
+}
+
+func TestTwitterFixerSwapProxies(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "swapping", config.Guild{SwapProxies: true}); err != nil {
+		t.Fatal(err)
+	}
+	monitor := &domains.Monitor{Checker: domains.NewChecker(&http.Client{Transport: downSites{"fxtwitter.com": true, "fixupx.com": true, "fixvx.com": true}}), Store: st}
+	monitor.Check(context.Background())
+
+	testCases := []struct {
+		name     string
+		guildID  string
+		input    string
+		expected string
+	}{
+		{name: "Proxy down", guildID: "swapping",
+			input: "look https://fxtwitter.com/user/status/1?s=20", expected: "look https://vxtwitter.com/user/status/1?s=20"},
+		{name: "Subdomain kept", guildID: "swapping",
+			input: "https://d.fxtwitter.com/user/status/1", expected: "https://d.vxtwitter.com/user/status/1"},
+		{name: "Proxy up", guildID: "swapping",
+			input: "https://vxtwitter.com/user/status/1", expected: "https://vxtwitter.com/user/status/1"},
+		{name: "Counterpart down too", guildID: "swapping",
+			input: "https://fixupx.com/user/status/1", expected: "https://fixupx.com/user/status/1"},
+		{name: "Angle brackets", guildID: "swapping",
+			input: "<https://fxtwitter.com/user/status/1>", expected: "<https://fxtwitter.com/user/status/1>"},
+		{name: "Alongside a Twitter link", guildID: "swapping",
+			input: "https://fxtwitter.com/user/status/1 https://x.com/user/status/2", expected: "https://vxtwitter.com/user/status/1 https://fixupx.com/user/status/2"},
+		{name: "Guild doesn't swap", guildID: "other",
+			input: "https://fxtwitter.com/user/status/1", expected: "https://fxtwitter.com/user/status/1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: tc.guildID, Content: tc.input}}
+			f := Twitter{Store: st, Domains: monitor}
+			if result := f.Fix(context.Background(), m, m.Content); result != tc.expected {
+				t.Errorf("Twitter.Fix(%q) = %q; want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package fixers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go-discord-bot/internal/access"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/domains"
+	"go-discord-bot/internal/locale"
+	"go-discord-bot/internal/responders"
+	"go-discord-bot/internal/templates"
+)
+
+// ValidateGuild checks settings that come from outside the bot's own commands,
+// such as an imported file, for values the commands would have refused.
+func ValidateGuild(cfg config.Guild) error {
+	if len(cfg.RewriteRules) > MaxRewriteRules {
+		return fmt.Errorf("it has more than %d rewrite rules", MaxRewriteRules)
+	}
+	for n, rule := range cfg.RewriteRules {
+		if _, err := ValidateRewriteRule(rule); err != nil {
+			return fmt.Errorf("rewrite rule %d: %w", n+1, err)
+		}
+	}
+	if len(cfg.AutoResponses) > responders.MaxResponses {
+		return fmt.Errorf("it has more than %d auto-responses", responders.MaxResponses)
+	}
+	for n, r := range cfg.AutoResponses {
+		if err := responders.Validate(r); err != nil {
+			return fmt.Errorf("auto-response %d: %w", n+1, err)
+		}
+	}
+	if !slices.Contains([]string{"", config.RepostMessage, config.RepostReply, config.RepostReaction, config.RepostConfirm, config.RepostMinimal, config.RepostThread}, cfg.RepostMode) {
+		return fmt.Errorf("unknown repost mode %q", cfg.RepostMode)
+	}
+	if cfg.RepostTemplate != "" {
+		if err := templates.Validate(cfg.RepostTemplate); err != nil {
+			return fmt.Errorf("repost template: %w", err)
+		}
+	}
+	if cfg.WelcomeMessage != "" {
+		if err := templates.ValidateWelcome(cfg.WelcomeMessage); err != nil {
+			return fmt.Errorf("welcome message: %w", err)
+		}
+	}
+	if cfg.SkipMarker != "" && !config.ValidSkipMarker(cfg.SkipMarker) {
+		return fmt.Errorf("skip marker %q isn't a single word of at most %d characters", cfg.SkipMarker, config.MaxSkipMarkerLength)
+	}
+	for fixer, style := range cfg.Outputs {
+		if !config.ValidOutput(fixer, style) {
+			return fmt.Errorf("output style %q can't be used for %s links", style, fixer)
+		}
+	}
+	if !slices.Contains([]string{"", config.TwitterFxTwitter, config.TwitterNitter, config.TwitterCustom}, cfg.TwitterSite) {
+		return fmt.Errorf("unknown Twitter site %q", cfg.TwitterSite)
+	}
+	if cfg.MusicSite != "" && !slices.Contains(config.MusicSites, cfg.MusicSite) {
+		return fmt.Errorf("unknown music site %q", cfg.MusicSite)
+	}
+	if cfg.TwitterDomain != "" {
+		if domain, err := domains.Normalize(cfg.TwitterDomain); err != nil || domain != cfg.TwitterDomain {
+			return fmt.Errorf("the Twitter domain %q isn't a bare domain like fixvx.com", cfg.TwitterDomain)
+		}
+	}
+	if cfg.TwitterSite == config.TwitterCustom && cfg.TwitterDomain == "" {
+		return fmt.Errorf("the custom Twitter site needs a domain")
+	}
+	if !slices.Contains([]string{"", config.GalleryRepost, config.GalleryAttach}, cfg.Galleries) {
+		return fmt.Errorf("unknown gallery mode %q", cfg.Galleries)
+	}
+	if !slices.Contains([]string{"", config.ProfileEmbed}, cfg.Profiles) {
+		return fmt.Errorf("unknown profile mode %q", cfg.Profiles)
+	}
+	if !slices.Contains([]string{"", config.PhishingWarn, config.PhishingDelete, config.PhishingOff}, cfg.PhishingAction) {
+		return fmt.Errorf("unknown phishing action %q", cfg.PhishingAction)
+	}
+	if len(cfg.BlockedAccounts) > config.MaxBlockedAccounts {
+		return fmt.Errorf("it blocks more than %d accounts", config.MaxBlockedAccounts)
+	}
+	for _, name := range cfg.BlockedAccounts {
+		if !config.ValidAccount(name) {
+			return fmt.Errorf("blocked account %q isn't a Twitter/X screen name", name)
+		}
+	}
+	if len(cfg.BlockedKeywords) > config.MaxBlockedKeywords {
+		return fmt.Errorf("it blocks more than %d keywords", config.MaxBlockedKeywords)
+	}
+	for _, keyword := range cfg.BlockedKeywords {
+		if strings.TrimSpace(keyword) == "" || utf8.RuneCountInString(keyword) > config.MaxKeywordLength {
+			return fmt.Errorf("blocked keyword %q isn't 1 to %d characters", keyword, config.MaxKeywordLength)
+		}
+	}
+	if !slices.Contains([]string{"", config.FilterSkip, config.FilterFlag, config.FilterRemove}, cfg.FilterAction) {
+		return fmt.Errorf("unknown filter action %q", cfg.FilterAction)
+	}
+	if !slices.Contains([]string{"", config.OthersYield, config.OthersAlert, config.OthersIgnore}, cfg.OtherFixers) {
+		return fmt.Errorf("unknown other fixers mode %q", cfg.OtherFixers)
+	}
+	if !slices.Contains([]string{"", config.DigestLive, config.DigestDaily}, cfg.DigestMode) {
+		return fmt.Errorf("unknown digest mode %q", cfg.DigestMode)
+	}
+	if !slices.Contains([]string{"", config.CrosspostReposts, config.CrosspostAll}, cfg.Crosspost) {
+		return fmt.Errorf("unknown crosspost mode %q", cfg.Crosspost)
+	}
+	for feature := range cfg.FeatureRoles {
+		if parsed, ok := access.Parse(feature); !ok || parsed != feature {
+			return fmt.Errorf("unknown feature %q", feature)
+		}
+	}
+	for _, name := range cfg.DisabledModules {
+		if !slices.Contains(config.Modules, name) {
+			return fmt.Errorf("unknown module %q", name)
+		}
+	}
+	if _, err := config.LoadLocation(cfg.Timezone); err != nil {
+		return err
+	}
+	if cfg.Locale != "" && !locale.Known(cfg.Locale) {
+		return fmt.Errorf("unknown locale %q", cfg.Locale)
+	}
+	if cfg.StarThreshold < 0 || cfg.StarThreshold > config.MaxStarThreshold {
+		return fmt.Errorf("star threshold must be between 1 and %d", config.MaxStarThreshold)
+	}
+	if cfg.DigestHour < 0 || cfg.DigestHour > 23 {
+		return fmt.Errorf("digest hour must be between 0 and 23")
+	}
+	if cfg.ContextDepth < 0 || cfg.ContextDepth > config.MaxContextDepth {
+		return fmt.Errorf("context depth must be between 0 and %d", config.MaxContextDepth)
+	}
+	if cfg.RepostTTLHours < 0 || cfg.RepostTTLHours > config.MaxRepostTTLHours {
+		return fmt.Errorf("repost lifetime must be between 0 and %d hours", config.MaxRepostTTLHours)
+	}
+	if cfg.ThreadArchiveHours < 0 || cfg.ThreadArchiveHours > config.MaxThreadArchiveHours {
+		return fmt.Errorf("thread inactivity before archiving must be between 0 and %d hours", config.MaxThreadArchiveHours)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Package flags provides feature flags that can be changed without restarting the bot.
+//
+// Flags live in a JSON file mapping flag names to booleans, for example
+// {"embed_verification": false}. Flags missing from the file use their default.
+// A flag can also be set to a percentage, such as {"embed_verification": 10},
+// to roll it out gradually: it's then on in that share of guilds, picked by
+// hashing their IDs, so a guild keeps its value across restarts and reloads
+// and raising the percentage only adds guilds.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// EmbedVerification makes fixers skip links whose Discord preview already works.
+// Turning it off reposts every matching link regardless of its embed.
+const EmbedVerification = "embed_verification"
+
+// Defaults holds the value of every known flag when the file doesn't set it.
+var Defaults = map[string]bool{
+	EmbedVerification: true,
+}
+
+// Flags is a set of feature flags loaded from a file.
+// A nil *Flags reports every flag at its default.
+type Flags struct {
+	path string
+
+	mu sync.RWMutex
+	// values maps the flags the file sets to the percentage of guilds they're
+	// on in, 100 for true and 0 for false.
+	values  map[string]int
+	modTime time.Time
+}
+
+// Load reads flags from path. A missing file leaves every flag at its default.
+func Load(path string) (*Flags, error) {
+	f := &Flags{path: path}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Enabled reports whether the named flag is on everywhere. Flags rolled out
+// to only some guilds are off.
+func (f *Flags) Enabled(name string) bool {
+	return f.EnabledFor(name, "")
+}
+
+// EnabledFor reports whether the named flag is on in the guild with guildID.
+// Flags rolled out to only some guilds are off outside guilds.
+func (f *Flags) EnabledFor(name, guildID string) bool {
+	if f == nil {
+		return Defaults[name]
+	}
+	f.mu.RLock()
+	percent, ok := f.values[name]
+	f.mu.RUnlock()
+	switch {
+	case !ok:
+		return Defaults[name]
+	case percent >= 100:
+		return true
+	case percent <= 0 || guildID == "":
+		return false
+	}
+	return bucket(name, guildID) < percent
+}
+
+// bucket places a guild in one of 100 buckets for the named flag. The name
+// is hashed in so each flag is rolled out to different guilds first.
+func bucket(name, guildID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + "/" + guildID))
+	return int(h.Sum32() % 100)
+}
+
+// Reload re-reads the flag file. On error the previous values are kept.
+func (f *Flags) Reload() error {
+	values := map[string]int{}
+	var modTime time.Time
+
+	if f.path != "" {
+		info, err := os.Stat(f.path)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return err
+		default:
+			data, err := os.ReadFile(f.path)
+			if err != nil {
+				return err
+			}
+			var raw map[string]json.RawMessage
+			if err := json.Unmarshal(data, &raw); err != nil {
+				return fmt.Errorf("decoding %s: %w", f.path, err)
+			}
+			for name, value := range raw {
+				percent, err := parseValue(value)
+				if err != nil {
+					return fmt.Errorf("decoding %s: flag %q: %w", f.path, name, err)
+				}
+				values[name] = percent
+			}
+			modTime = info.ModTime()
+		}
+	}
+
+	for name := range values {
+		if _, known := Defaults[name]; !known {
+			log.Printf("Unknown feature flag %q in %s\n", name, f.path)
+		}
+	}
+
+	f.mu.Lock()
+	f.values = values
+	f.modTime = modTime
+	f.mu.Unlock()
+	return nil
+}
+
+// parseValue returns the percentage of guilds a flag's value in the file
+// turns it on in: 100 for true, 0 for false or the percentage itself.
+func parseValue(value json.RawMessage) (int, error) {
+	var on bool
+	if err := json.Unmarshal(value, &on); err == nil {
+		if on {
+			return 100, nil
+		}
+		return 0, nil
+	}
+	var percent int
+	if err := json.Unmarshal(value, &percent); err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("%s is neither true, false nor a percentage from 0 to 100", value)
+	}
+	return percent, nil
+}
+
+// Watch polls the flag file every interval and reloads it when it changes,
+// until ctx is done.
+func (f *Flags) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !f.changed() {
+				continue
+			}
+			if err := f.Reload(); err != nil {
+				log.Println("Error reloading feature flags:", err)
+				continue
+			}
+			log.Println("Reloaded feature flags from", f.path)
+		}
+	}
+}
+
+// changed reports whether the flag file's modification time differs from the loaded one.
+func (f *Flags) changed() bool {
+	var modTime time.Time
+	if info, err := os.Stat(f.path); err == nil {
+		modTime = info.ModTime()
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return !modTime.Equal(f.modTime)
+}
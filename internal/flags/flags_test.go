@@ -0,0 +1,148 @@
+package flags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNilFlagsUseDefaults(t *testing.T) {
+	var f *Flags
+	if !f.Enabled(EmbedVerification) {
+		t.Error("nil Flags reported embed_verification off")
+	}
+	if f.Enabled("unknown") {
+		t.Error("nil Flags reported an unknown flag on")
+	}
+}
+
+func TestLoadAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load of missing file: %v", err)
+	}
+	if !f.Enabled(EmbedVerification) {
+		t.Error("missing file didn't use defaults")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"embed_verification": false}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if f.Enabled(EmbedVerification) {
+		t.Error("Reload didn't pick up the flag change")
+	}
+
+	if err := os.WriteFile(path, []byte(`not json`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reload(); err == nil {
+		t.Error("Reload of invalid JSON succeeded")
+	}
+	if f.Enabled(EmbedVerification) {
+		t.Error("failed Reload discarded the previous values")
+	}
+}
+
+func TestWatchPicksUpChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Watch(ctx, 5*time.Millisecond)
+
+	if err := os.WriteFile(path, []byte(`{"embed_verification": false}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for f.Enabled(EmbedVerification) {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch didn't reload the changed file")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRollout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	if err := os.WriteFile(path, []byte(`{"embed_verification": 30}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Enabled(EmbedVerification) || f.EnabledFor(EmbedVerification, "") {
+		t.Error("flag rolled out to some guilds reported on outside guilds")
+	}
+
+	var on []string
+	for n := range 1000 {
+		guildID := strconv.Itoa(100000000000000000 + n*7919)
+		if f.EnabledFor(EmbedVerification, guildID) {
+			on = append(on, guildID)
+		}
+		if f.EnabledFor(EmbedVerification, guildID) != f.EnabledFor(EmbedVerification, guildID) {
+			t.Fatalf("guild %s flipped between calls", guildID)
+		}
+	}
+	if len(on) < 250 || len(on) > 350 {
+		t.Errorf("flag at 30%% on in %d of 1000 guilds", len(on))
+	}
+
+	if err := os.WriteFile(path, []byte(`{"embed_verification": 60}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	for _, guildID := range on {
+		if !f.EnabledFor(EmbedVerification, guildID) {
+			t.Fatalf("raising the rollout turned the flag off in guild %s", guildID)
+		}
+	}
+}
+
+func TestReloadValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		file     string
+		expected bool
+		fails    bool
+	}{
+		{name: "True", file: `{"embed_verification": true}`, expected: true},
+		{name: "False", file: `{"embed_verification": false}`},
+		{name: "Everywhere", file: `{"embed_verification": 100}`, expected: true},
+		{name: "Nowhere", file: `{"embed_verification": 0}`},
+		{name: "Over 100", file: `{"embed_verification": 101}`, fails: true},
+		{name: "Fraction", file: `{"embed_verification": 12.5}`, fails: true},
+		{name: "String", file: `{"embed_verification": "yes"}`, fails: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "flags.json")
+			if err := os.WriteFile(path, []byte(tc.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			f, err := Load(path)
+			if tc.fails != (err != nil) {
+				t.Fatalf("Load(%s) error = %v; want failure %v", tc.file, err, tc.fails)
+			}
+			if !tc.fails && f.EnabledFor(EmbedVerification, "1") != tc.expected {
+				t.Errorf("Load(%s) reported the flag %v; want %v", tc.file, !tc.expected, tc.expected)
+			}
+		})
+	}
+}
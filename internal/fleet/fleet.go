@@ -0,0 +1,358 @@
+// Package fleet implements owner operations that apply to many guilds at once.
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/storage"
+)
+
+// Session is the part of a Discord session that fleet operations use.
+type Session interface {
+	User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error)
+	UserGuilds(limit int, beforeID, afterID string, withCounts bool, options ...discordgo.RequestOption) ([]*discordgo.UserGuild, error)
+	Guild(guildID string, options ...discordgo.RequestOption) (*discordgo.Guild, error)
+	GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error)
+	UserChannelPermissions(userID, channelID string, fetchOptions ...discordgo.RequestOption) (int64, error)
+	ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error
+}
+
+// Operation is a change made to many guilds at once by Run.
+type Operation interface {
+	// Check makes sure the change can be made in guild, without making it,
+	// and describes what it will do there.
+	Check(ctx context.Context, s Session, botID string, guild *discordgo.Guild) (string, error)
+	// Make makes the change in a guild that passed Check.
+	Make(ctx context.Context, s Session, guildID string) error
+	// Undo takes back a change Make made.
+	Undo(ctx context.Context, s Session, guildID string) error
+}
+
+// Result is what an operation did, or would do, in one guild.
+type Result struct {
+	GuildID   string
+	GuildName string
+	// Plan describes what the operation does in the guild.
+	Plan string
+	// Err is why the guild failed its check or its change, or why it was
+	// left as it was.
+	Err error
+	// Done reports whether the change was made in the guild, and kept.
+	Done bool
+}
+
+var (
+	// ErrSkipped is the Result error of guilds left alone as another failed.
+	ErrSkipped = errors.New("not changed, as another guild failed")
+	// ErrUndone is the Result error of guilds whose change was taken back as
+	// another failed.
+	ErrUndone = errors.New("changed, then undone as another guild failed")
+	// ErrUnknownGuild is returned, wrapped, by Run when the guild list names
+	// a guild the bot isn't in.
+	ErrUnknownGuild = errors.New("unknown guild")
+)
+
+// parseGuildFilter turns a comma-separated list of guild IDs into a set.
+// An empty list means "every guild".
+func parseGuildFilter(list string) map[string]bool {
+	filter := make(map[string]bool)
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			filter[id] = true
+		}
+	}
+	return filter
+}
+
+// selectGuilds returns the guilds matching the filter, preserving order, and
+// the IDs in the filter that match none of them, sorted.
+func selectGuilds(guilds []*discordgo.UserGuild, filter map[string]bool) ([]*discordgo.UserGuild, []string) {
+	if len(filter) == 0 {
+		return guilds, nil
+	}
+	var selected []*discordgo.UserGuild
+	found := make(map[string]bool, len(filter))
+	for _, g := range guilds {
+		if filter[g.ID] {
+			selected = append(selected, g)
+			found[g.ID] = true
+		}
+	}
+	var unknown []string
+	for id := range filter {
+		if !found[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	slices.Sort(unknown)
+	return selected, unknown
+}
+
+// fetchAllGuilds pages through every guild the bot is a member of.
+func fetchAllGuilds(ctx context.Context, s Session) ([]*discordgo.UserGuild, error) {
+	var all []*discordgo.UserGuild
+	after := ""
+	for {
+		page, err := s.UserGuilds(200, "", after, false, discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < 200 {
+			return all, nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+// Run applies op to every guild in guildList, a comma-separated list of
+// guild IDs, or to every guild the bot is in if it's empty. A guild listed
+// twice counts once, and listing one the bot isn't in is an error. Every
+// guild is checked first, and if any fails, none is changed. If a change then
+// fails, the ones already made are undone. With dryRun set, guilds are only
+// checked. The results, one per guild, say what was or would be done in each.
+func Run(ctx context.Context, s Session, op Operation, guildList string, dryRun bool) ([]Result, error) {
+	user, err := s.User("@me", discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("fetching bot user: %w", err)
+	}
+	guilds, err := fetchAllGuilds(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("listing guilds: %w", err)
+	}
+
+	selected, unknown := selectGuilds(guilds, parseGuildFilter(guildList))
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGuild, strings.Join(unknown, ", "))
+	}
+	results := make([]Result, len(selected))
+	failed := 0
+	var first error
+	for n, g := range selected {
+		results[n] = Result{GuildID: g.ID, GuildName: g.Name}
+		guild, err := s.Guild(g.ID, discordgo.WithContext(ctx))
+		if err == nil {
+			results[n].Plan, err = op.Check(ctx, s, user.ID, guild)
+		}
+		if err != nil {
+			results[n].Err = err
+			if failed == 0 {
+				first = err
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d guilds failed checks; nothing was changed: %w", failed, len(selected), first)
+	}
+	if dryRun {
+		return results, nil
+	}
+
+	for n := range results {
+		err := ctx.Err()
+		if err == nil {
+			err = op.Make(ctx, s, results[n].GuildID)
+		}
+		if err != nil {
+			results[n].Err = err
+			for m := n + 1; m < len(results); m++ {
+				results[m].Err = ErrSkipped
+			}
+			undo(context.WithoutCancel(ctx), s, op, results[:n])
+			return results, fmt.Errorf("changing %s (%s): %w", results[n].GuildName, results[n].GuildID, err)
+		}
+		results[n].Done = true
+	}
+	return results, nil
+}
+
+// undo takes back the changes op made in the guilds of results, latest first.
+func undo(ctx context.Context, s Session, op Operation, results []Result) {
+	for n := len(results) - 1; n >= 0; n-- {
+		if err := op.Undo(ctx, s, results[n].GuildID); err != nil {
+			results[n].Err = fmt.Errorf("undoing the change failed: %w", err)
+			continue
+		}
+		results[n].Done = false
+		results[n].Err = ErrUndone
+	}
+}
+
+// guildPermissions returns the permissions member has in guild, before
+// channel overwrites.
+func guildPermissions(guild *discordgo.Guild, member *discordgo.Member) int64 {
+	if guild.OwnerID == member.User.ID {
+		return discordgo.PermissionAll
+	}
+	var perms int64
+	for _, role := range guild.Roles {
+		// The @everyone role shares the guild's ID
+		if role.ID == guild.ID || slices.Contains(member.Roles, role.ID) {
+			perms |= role.Permissions
+		}
+	}
+	if perms&discordgo.PermissionAdministrator != 0 {
+		return discordgo.PermissionAll
+	}
+	return perms
+}
+
+// Announcement posts a message to the system channel of each guild.
+type Announcement struct {
+	Message string
+	// channels and sent are the system channel of each checked guild and
+	// the message posted there.
+	channels map[string]string
+	sent     map[string]string
+}
+
+// NewAnnouncement returns an Operation posting message.
+func NewAnnouncement(message string) *Announcement {
+	return &Announcement{Message: message, channels: make(map[string]string), sent: make(map[string]string)}
+}
+
+// Check implements Operation.
+func (a *Announcement) Check(ctx context.Context, s Session, botID string, guild *discordgo.Guild) (string, error) {
+	if guild.SystemChannelID == "" {
+		return "", errors.New("no system channel")
+	}
+	perms, err := s.UserChannelPermissions(botID, guild.SystemChannelID, discordgo.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("checking permissions in the system channel: %w", err)
+	}
+	if post := int64(discordgo.PermissionViewChannel | discordgo.PermissionSendMessages); perms&post != post {
+		return "", errors.New("missing " + permissionList(post&^perms) + ", in the system channel")
+	}
+	a.channels[guild.ID] = guild.SystemChannelID
+	return "post in channel " + guild.SystemChannelID, nil
+}
+
+// Make implements Operation.
+func (a *Announcement) Make(ctx context.Context, s Session, guildID string) error {
+	msg, err := s.ChannelMessageSend(a.channels[guildID], a.Message, discordgo.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	a.sent[guildID] = msg.ID
+	return nil
+}
+
+// Undo implements Operation, deleting the announcement.
+func (a *Announcement) Undo(ctx context.Context, s Session, guildID string) error {
+	return s.ChannelMessageDelete(a.channels[guildID], a.sent[guildID], discordgo.WithContext(ctx))
+}
+
+// GuildChange changes the settings of each guild. Guilds where the bot would
+// lack a permission the new settings need fail the check.
+type GuildChange struct {
+	store  storage.Store
+	what   string
+	change func(cfg config.Guild) (config.Guild, error)
+	// before and after are the settings of each checked guild, as they are
+	// and as they'll be.
+	before map[string]config.Guild
+	after  map[string]config.Guild
+}
+
+// newGuildChange returns a GuildChange that makes change, described by what,
+// to the settings in st.
+func newGuildChange(st storage.Store, what string, change func(cfg config.Guild) (config.Guild, error)) *GuildChange {
+	return &GuildChange{store: st, what: what, change: change, before: make(map[string]config.Guild), after: make(map[string]config.Guild)}
+}
+
+// NewSettingChange returns an Operation setting the settings in patch, a
+// JSON object of guild settings such as {"repost_mode": "reply"}. A null
+// setting goes back to its default.
+func NewSettingChange(st storage.Store, patch json.RawMessage) (*GuildChange, error) {
+	var changes map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &changes); err != nil || changes == nil {
+		return nil, fmt.Errorf("%w: not a JSON object", ErrInvalid)
+	}
+	var names []string
+	for name := range changes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return newGuildChange(st, "set "+strings.Join(names, ", "), func(cfg config.Guild) (config.Guild, error) {
+		return patchGuild(cfg, changes)
+	}), nil
+}
+
+// NewModuleToggle returns an Operation turning module, one of
+// config.Modules, on or off.
+func NewModuleToggle(st storage.Store, module string, on bool) (*GuildChange, error) {
+	if !slices.Contains(config.Modules, module) {
+		return nil, fmt.Errorf("%w: unknown module %q, want one of %s", ErrInvalid, module, strings.Join(config.Modules, ", "))
+	}
+	what := "turn " + module + " off"
+	if on {
+		what = "turn " + module + " on"
+	}
+	return newGuildChange(st, what, func(cfg config.Guild) (config.Guild, error) {
+		cfg.DisabledModules = slices.DeleteFunc(slices.Clone(cfg.DisabledModules), func(name string) bool { return name == module })
+		if !on {
+			cfg.DisabledModules = append(cfg.DisabledModules, module)
+		}
+		return cfg, nil
+	}), nil
+}
+
+// Check implements Operation.
+func (g *GuildChange) Check(ctx context.Context, s Session, botID string, guild *discordgo.Guild) (string, error) {
+	cfg, err := config.LoadGuild(g.store, guild.ID)
+	if err != nil {
+		return "", fmt.Errorf("loading settings: %w", err)
+	}
+	changed, err := g.change(cfg)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	member, err := s.GuildMember(guild.ID, botID, discordgo.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("fetching the bot's member: %w", err)
+	}
+	inGuild := guildPermissions(guild, member)
+	perms := func(channelID string) (int64, bool) {
+		if channelID == "" {
+			return inGuild, true
+		}
+		channel, err := s.UserChannelPermissions(botID, channelID, discordgo.WithContext(ctx))
+		return channel, err == nil
+	}
+	// Only what the change brings counts; the guild's other problems stay
+	already := problems(cfg, perms)
+	var added []string
+	for _, p := range problems(changed, perms) {
+		if !slices.Contains(already, p) {
+			added = append(added, p)
+		}
+	}
+	if len(added) > 0 {
+		return "", errors.New(strings.Join(added, "; "))
+	}
+
+	g.before[guild.ID], g.after[guild.ID] = cfg, changed
+	return g.what, nil
+}
+
+// Make implements Operation.
+func (g *GuildChange) Make(ctx context.Context, s Session, guildID string) error {
+	return config.SaveGuild(g.store, guildID, g.after[guildID])
+}
+
+// Undo implements Operation, putting the settings back as they were.
+func (g *GuildChange) Undo(ctx context.Context, s Session, guildID string) error {
+	return config.SaveGuild(g.store, guildID, g.before[guildID])
+}
@@ -0,0 +1,282 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/invite"
+	"go-discord-bot/internal/storage"
+)
+
+func TestSelectGuilds(t *testing.T) {
+	guilds := []*discordgo.UserGuild{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+	testCases := []struct {
+		name     string
+		filter   string
+		expected []string
+		unknown  []string
+	}{
+		{name: "Empty filter selects all", filter: "", expected: []string{"1", "2", "3"}},
+		{name: "Single guild", filter: "2", expected: []string{"2"}},
+		{name: "Whitespace and duplicates", filter: " 3 , 1,3", expected: []string{"1", "3"}},
+		{name: "Unknown IDs", filter: "9,2,10", expected: []string{"2"}, unknown: []string{"10", "9"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, unknown := selectGuilds(guilds, parseGuildFilter(tc.filter))
+			if !slices.Equal(unknown, tc.unknown) {
+				t.Errorf("selectGuilds(%q) unknown = %q; want %q", tc.filter, unknown, tc.unknown)
+			}
+			if len(result) != len(tc.expected) {
+				t.Fatalf("selectGuilds(%q) returned %d guilds; want %d", tc.filter, len(result), len(tc.expected))
+			}
+			for i, g := range result {
+				if g.ID != tc.expected[i] {
+					t.Errorf("selectGuilds(%q)[%d] = %q; want %q", tc.filter, i, g.ID, tc.expected[i])
+				}
+			}
+		})
+	}
+}
+
+// fakeSource is a Source with fixed guilds and permissions, keyed by guild or
+// channel ID.
+type fakeSource struct {
+	guilds []*discordgo.Guild
+	perms  map[string]int64
+}
+
+func (f fakeSource) Guilds() []*discordgo.Guild {
+	return f.guilds
+}
+
+func (f fakeSource) Permissions(guildID, channelID string) (int64, bool) {
+	if channelID != "" {
+		guildID = channelID
+	}
+	perms, ok := f.perms[guildID]
+	return perms, ok
+}
+
+func TestSurvey(t *testing.T) {
+	st := storage.NewMemory()
+	config.SaveGuild(st, "2", config.Guild{Paused: true, PhishingAction: config.PhishingDelete, AuditChannel: "audit"})
+	src := fakeSource{
+		guilds: []*discordgo.Guild{{ID: "2", Name: "beta", MemberCount: 5}, {ID: "1", Name: "Alpha"}, {ID: "3", Name: "gamma"}},
+		perms: map[string]int64{
+			"1":     ^int64(0),
+			"2":     discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionReadMessageHistory,
+			"audit": discordgo.PermissionViewChannel,
+		},
+	}
+
+	guilds := Survey(st, src)
+	var names []string
+	for _, g := range guilds {
+		names = append(names, g.Name)
+	}
+	if !slices.Equal(names, []string{"Alpha", "beta", "gamma"}) {
+		t.Fatalf("Survey listed %q; want them by name", names)
+	}
+	if guilds[0].Problems != nil || guilds[0].Settings.RepostMode != config.RepostMessage {
+		t.Errorf("Alpha = %+v; want default settings without problems", guilds[0])
+	}
+	expected := []string{
+		"missing Send Messages in Threads and Embed Links, for fixing links",
+		"missing Manage Messages, for deleting messages",
+		"missing Send Messages, in the audit channel <#audit>",
+	}
+	if g := guilds[1]; !g.Settings.Paused || g.Members != 5 || !slices.Equal(g.Problems, expected) {
+		t.Errorf("beta = %+v; want paused with problems %q", g, expected)
+	}
+	if g := guilds[2]; !slices.Equal(g.Problems, []string{"the bot's permissions here are unknown"}) {
+		t.Errorf("gamma problems = %q; want unknown permissions", g.Problems)
+	}
+	if Survey(st, nil) != nil {
+		t.Errorf("Survey listed guilds without a source")
+	}
+}
+
+func TestGuildPermissions(t *testing.T) {
+	guild := &discordgo.Guild{ID: "g", OwnerID: "owner", Roles: []*discordgo.Role{
+		{ID: "g", Permissions: discordgo.PermissionViewChannel},
+		{ID: "mod", Permissions: discordgo.PermissionManageMessages},
+		{ID: "admin", Permissions: discordgo.PermissionAdministrator},
+	}}
+
+	testCases := []struct {
+		name     string
+		member   *discordgo.Member
+		expected int64
+	}{
+		{name: "Everyone", member: &discordgo.Member{User: &discordgo.User{ID: "bot"}}, expected: discordgo.PermissionViewChannel},
+		{name: "Roles add up", member: &discordgo.Member{User: &discordgo.User{ID: "bot"}, Roles: []string{"mod"}}, expected: discordgo.PermissionViewChannel | discordgo.PermissionManageMessages},
+		{name: "Administrator", member: &discordgo.Member{User: &discordgo.User{ID: "bot"}, Roles: []string{"admin"}}, expected: discordgo.PermissionAll},
+		{name: "Owner", member: &discordgo.Member{User: &discordgo.User{ID: "owner"}}, expected: discordgo.PermissionAll},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := guildPermissions(guild, tc.member); result != tc.expected {
+				t.Errorf("guildPermissions = %d; want %d", result, tc.expected)
+			}
+		})
+	}
+}
+
+// fakeSession is a Session for guilds where the bot has one role, named by
+// guild ID in roles, and the same permissions in every channel.
+type fakeSession struct {
+	roles    map[string]int64
+	channels int64
+	// failSend makes posting in this channel fail.
+	failSend string
+	sent     []string
+	deleted  []string
+}
+
+func (f *fakeSession) User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error) {
+	return &discordgo.User{ID: "bot"}, nil
+}
+
+func (f *fakeSession) UserGuilds(limit int, beforeID, afterID string, withCounts bool, options ...discordgo.RequestOption) ([]*discordgo.UserGuild, error) {
+	var guilds []*discordgo.UserGuild
+	for _, id := range slices.Sorted(maps.Keys(f.roles)) {
+		guilds = append(guilds, &discordgo.UserGuild{ID: id, Name: "guild " + id})
+	}
+	return guilds, nil
+}
+
+func (f *fakeSession) Guild(guildID string, options ...discordgo.RequestOption) (*discordgo.Guild, error) {
+	return &discordgo.Guild{ID: guildID, SystemChannelID: "system" + guildID, Roles: []*discordgo.Role{{ID: "role", Permissions: f.roles[guildID]}}}, nil
+}
+
+func (f *fakeSession) GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error) {
+	return &discordgo.Member{User: &discordgo.User{ID: userID}, Roles: []string{"role"}}, nil
+}
+
+func (f *fakeSession) UserChannelPermissions(userID, channelID string, fetchOptions ...discordgo.RequestOption) (int64, error) {
+	return f.channels, nil
+}
+
+func (f *fakeSession) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if channelID == f.failSend {
+		return nil, errors.New("send failed")
+	}
+	f.sent = append(f.sent, channelID)
+	return &discordgo.Message{ID: "message" + channelID}, nil
+}
+
+func (f *fakeSession) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	f.deleted = append(f.deleted, messageID)
+	return nil
+}
+
+func TestRunGuildChange(t *testing.T) {
+	fixing := invite.Fixing.Permissions
+	testCases := []struct {
+		name     string
+		roles    map[string]int64
+		patch    string
+		module   string
+		dryRun   bool
+		expected string
+		err      bool
+	}{
+		{name: "Setting", roles: map[string]int64{"1": fixing, "2": fixing}, patch: `{"repost_mode": "reply"}`, expected: config.RepostReply},
+		{name: "Dry run", roles: map[string]int64{"1": fixing, "2": fixing}, patch: `{"repost_mode": "reply"}`, dryRun: true},
+		{name: "Missing permission in one guild", roles: map[string]int64{"1": fixing | discordgo.PermissionAddReactions, "2": fixing}, patch: `{"repost_mode": "reaction"}`, err: true},
+		{name: "Invalid setting", roles: map[string]int64{"1": fixing}, patch: `{"repost_mode": "shout"}`, err: true},
+		{name: "Problems the change doesn't bring", roles: map[string]int64{"1": 0}, module: config.ModuleTranslate},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			var op *GuildChange
+			var err error
+			if tc.module != "" {
+				op, err = NewModuleToggle(st, tc.module, false)
+			} else {
+				op, err = NewSettingChange(st, json.RawMessage(tc.patch))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := Run(context.Background(), &fakeSession{roles: tc.roles, channels: ^int64(0)}, op, "", tc.dryRun)
+			if (err != nil) != tc.err {
+				t.Fatalf("Run = %v; want error %v", err, tc.err)
+			}
+			if len(results) != len(tc.roles) {
+				t.Fatalf("Run returned %d results; want one per guild", len(results))
+			}
+			for _, r := range results {
+				cfg, _ := config.LoadGuild(st, r.GuildID)
+				if r.Done != (!tc.err && !tc.dryRun) || cfg.RepostMode != tc.expected {
+					t.Errorf("guild %s: result %+v, repost mode %q; want %q", r.GuildID, r, cfg.RepostMode, tc.expected)
+				}
+				if tc.module != "" && cfg.ModuleEnabled(tc.module) {
+					t.Errorf("guild %s: module %s still on", r.GuildID, tc.module)
+				}
+			}
+		})
+	}
+
+	if _, err := NewModuleToggle(storage.NewMemory(), "dance", true); !errors.Is(err, ErrInvalid) {
+		t.Errorf("NewModuleToggle(dance) = %v; want ErrInvalid", err)
+	}
+}
+
+func TestRunAnnouncement(t *testing.T) {
+	roles := map[string]int64{"1": 0, "2": 0, "3": 0}
+
+	t.Run("Missing permission", func(t *testing.T) {
+		s := &fakeSession{roles: roles, channels: discordgo.PermissionViewChannel}
+		results, err := Run(context.Background(), s, NewAnnouncement("hi"), "1,2", false)
+		if err == nil || len(s.sent) != 0 || len(results) != 2 || results[0].Err == nil {
+			t.Errorf("Run = %+v, %v, sent %q; want every guild failing without sending", results, err, s.sent)
+		}
+	})
+
+	t.Run("Send fails", func(t *testing.T) {
+		s := &fakeSession{roles: roles, channels: ^int64(0), failSend: "system2"}
+		results, err := Run(context.Background(), s, NewAnnouncement("hi"), "", false)
+		if err == nil {
+			t.Fatal("Run succeeded; want an error")
+		}
+		expected := []error{ErrUndone, results[1].Err, ErrSkipped}
+		for n, r := range results {
+			if r.Done || r.Err != expected[n] {
+				t.Errorf("results[%d] = %+v; want error %v", n, r, expected[n])
+			}
+		}
+		if !slices.Equal(s.deleted, []string{"messagesystem1"}) {
+			t.Errorf("deleted %q; want the first announcement", s.deleted)
+		}
+	})
+
+	t.Run("Unknown guild", func(t *testing.T) {
+		s := &fakeSession{roles: roles, channels: ^int64(0)}
+		results, err := Run(context.Background(), s, NewAnnouncement("hi"), "1,4", false)
+		if !errors.Is(err, ErrUnknownGuild) || results != nil || len(s.sent) != 0 {
+			t.Errorf("Run = %+v, %v, sent %q; want ErrUnknownGuild without sending", results, err, s.sent)
+		}
+	})
+
+	t.Run("Sent", func(t *testing.T) {
+		s := &fakeSession{roles: roles, channels: ^int64(0)}
+		results, err := Run(context.Background(), s, NewAnnouncement("hi"), "", false)
+		if err != nil || !slices.Equal(s.sent, []string{"system1", "system2", "system3"}) || !results[2].Done {
+			t.Errorf("Run = %+v, %v, sent %q; want sent everywhere", results, err, s.sent)
+		}
+	})
+}
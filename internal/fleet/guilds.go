@@ -0,0 +1,218 @@
+package fleet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/invite"
+	"go-discord-bot/internal/storage"
+)
+
+// Source lists the guilds the bot is in and what it may do in them, from the
+// state of its shards.
+type Source interface {
+	// Guilds returns the guilds the bot is in.
+	Guilds() []*discordgo.Guild
+	// Permissions returns the bot's permissions in a guild, or in one of its
+	// channels if channelID isn't empty, reporting false if they're unknown.
+	Permissions(guildID, channelID string) (int64, bool)
+}
+
+// Guild sums up one of the bot's guilds for its owner: its key settings and
+// what the bot can't do there that those settings need.
+type Guild struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Members  int      `json:"members"`
+	Settings Settings `json:"settings"`
+	// Problems are what stops the bot doing what the settings ask, such as
+	// "missing Manage Messages, for deleting messages".
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Settings are the guild settings that most change what the bot does there,
+// with defaults filled in.
+type Settings struct {
+	Paused         bool     `json:"paused"`
+	Privacy        bool     `json:"privacy"`
+	RepostMode     string   `json:"repost_mode"`
+	TwitterSite    string   `json:"twitter_site"`
+	PhishingAction string   `json:"phishing_action"`
+	Channels       int      `json:"channels"`
+	DisabledFixers []string `json:"disabled_fixers"`
+	AuditChannel   bool     `json:"audit_channel"`
+}
+
+// permissionNames name the permissions Survey checks for.
+var permissionNames = []struct {
+	perm int64
+	name string
+}{
+	{discordgo.PermissionViewChannel, "View Channels"},
+	{discordgo.PermissionSendMessages, "Send Messages"},
+	{discordgo.PermissionSendMessagesInThreads, "Send Messages in Threads"},
+	{discordgo.PermissionEmbedLinks, "Embed Links"},
+	{discordgo.PermissionReadMessageHistory, "Read Message History"},
+	{discordgo.PermissionManageMessages, "Manage Messages"},
+	{discordgo.PermissionAddReactions, "Add Reactions"},
+	{discordgo.PermissionManageRoles, "Manage Roles"},
+	{discordgo.PermissionCreatePublicThreads, "Create Public Threads"},
+}
+
+// Survey sums up every guild src lists, with their settings from st, sorted
+// by name. A nil src lists none.
+func Survey(st storage.Store, src Source) []Guild {
+	if src == nil {
+		return nil
+	}
+	var guilds []Guild
+	for _, g := range src.Guilds() {
+		cfg, err := config.LoadGuild(st, g.ID)
+		if err != nil {
+			log.Println("Error loading guild config:", err)
+		}
+		guilds = append(guilds, Guild{
+			ID:       g.ID,
+			Name:     g.Name,
+			Members:  g.MemberCount,
+			Settings: summarize(cfg),
+			Problems: problems(cfg, func(channelID string) (int64, bool) { return src.Permissions(g.ID, channelID) }),
+		})
+	}
+	slices.SortFunc(guilds, func(a, b Guild) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return guilds
+}
+
+// summarize picks the key settings out of cfg.
+func summarize(cfg config.Guild) Settings {
+	settings := Settings{
+		Paused:         cfg.Paused,
+		Privacy:        cfg.Privacy,
+		RepostMode:     cfg.RepostMode,
+		TwitterSite:    cfg.TwitterSite,
+		PhishingAction: cfg.PhishingAction,
+		Channels:       len(cfg.Channels),
+		DisabledFixers: cfg.DisabledFixers,
+		AuditChannel:   cfg.AuditChannel != "",
+	}
+	if settings.RepostMode == "" {
+		settings.RepostMode = config.RepostMessage
+	}
+	if settings.TwitterSite == "" {
+		settings.TwitterSite = config.TwitterFxTwitter
+	}
+	if settings.PhishingAction == "" {
+		settings.PhishingAction = config.PhishingWarn
+	}
+	if settings.DisabledFixers == nil {
+		settings.DisabledFixers = []string{}
+	}
+	return settings
+}
+
+// problems returns what stops the bot doing what a guild's settings cfg ask,
+// such as the permissions it's missing. perms returns the bot's permissions in the guild, or in
+// one of its channels.
+func problems(cfg config.Guild, perms func(channelID string) (int64, bool)) []string {
+	guild, ok := perms("")
+	if !ok {
+		return []string{"the bot's permissions here are unknown"}
+	}
+	needs := []struct {
+		perms int64
+		what  string
+		on    bool
+	}{
+		{invite.Fixing.Permissions, "fixing links", true},
+		{discordgo.PermissionManageMessages, "deleting messages", cfg.PhishingAction == config.PhishingDelete || cfg.FilterAction == config.FilterRemove},
+		{discordgo.PermissionManageMessages, "hiding embeds in minimal mode", cfg.RepostMode == config.RepostMinimal},
+		{discordgo.PermissionManageMessages, "crossposting", cfg.Crosspost != ""},
+		{discordgo.PermissionCreatePublicThreads, "posting in threads", cfg.RepostMode == config.RepostThread},
+		{discordgo.PermissionAddReactions, "reactions", cfg.RepostMode == config.RepostReaction || cfg.TriggerEmoji != "" || cfg.SuccessReaction != ""},
+		{discordgo.PermissionManageRoles, "the welcome role", cfg.WelcomeRole != ""},
+	}
+	var found []string
+	for _, need := range needs {
+		if need.on && guild&need.perms != need.perms {
+			found = append(found, "missing "+permissionList(need.perms&^guild)+", for "+need.what)
+		}
+	}
+
+	post := int64(discordgo.PermissionViewChannel | discordgo.PermissionSendMessages)
+	channels := []struct{ id, what string }{
+		{cfg.AuditChannel, "audit"},
+		{cfg.StarboardChannel, "starboard"},
+		{cfg.DigestChannel, "digest"},
+		{cfg.WelcomeChannel, "welcome"},
+	}
+	for _, c := range channels {
+		if c.id == "" {
+			continue
+		}
+		if channel, ok := perms(c.id); !ok {
+			found = append(found, fmt.Sprintf("the %s channel <#%s> is gone", c.what, c.id))
+		} else if channel&post != post {
+			found = append(found, fmt.Sprintf("missing %s, in the %s channel <#%s>", permissionList(post&^channel), c.what, c.id))
+		}
+	}
+	return found
+}
+
+// permissionList names the permissions in perms, such as "Send Messages and
+// Embed Links".
+func permissionList(perms int64) string {
+	var names []string
+	for _, p := range permissionNames {
+		if perms&p.perm != 0 {
+			names = append(names, p.name)
+		}
+	}
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// ErrInvalid is returned, wrapped, by NewSettingChange, NewModuleToggle and
+// Run when the settings can't be set.
+var ErrInvalid = errors.New("invalid settings")
+
+// patchGuild returns cfg with changes made, checked like imported settings.
+func patchGuild(cfg config.Guild, changes map[string]json.RawMessage) (config.Guild, error) {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return cfg, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return cfg, err
+	}
+	// Settings are decoded into empty ones, where null leaves the default
+	maps.Copy(fields, changes)
+	raw, err = json.Marshal(fields)
+	if err != nil {
+		return cfg, err
+	}
+	var patched config.Guild
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patched); err != nil {
+		return cfg, err
+	}
+	if err := fixers.ValidateGuild(patched); err != nil {
+		return cfg, err
+	}
+	return patched, nil
+}
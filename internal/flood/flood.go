@@ -0,0 +1,165 @@
+// Package flood detects bursts of messages in a channel, such as raids or spam,
+// so the bot can stop fixing links there instead of amplifying them.
+package flood
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often channels that have gone quiet are forgotten.
+const sweepInterval = 10 * time.Minute
+
+// sharedTimeout is how long a call to the Shared store may take.
+const sharedTimeout = 2 * time.Second
+
+// Shared is a store several replicas of the bot share, such as Redis, so
+// they count a channel's messages together.
+type Shared interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// channel tracks the message rate in one channel.
+type channel struct {
+	windowStart    time.Time
+	count          int
+	suspendedUntil time.Time
+	lastSeen       time.Time
+}
+
+// Monitor counts messages per channel and suspends a channel for Cooldown once
+// it sees more than Limit messages within a second. A nil Monitor never suspends.
+type Monitor struct {
+	// Shared, if not nil, counts messages and keeps suspensions for every
+	// replica instead. When it fails the monitor counts by itself.
+	Shared Shared
+
+	limit    int
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	channels  map[string]*channel
+	lastSweep time.Time
+}
+
+// New returns a Monitor that suspends a channel for cooldown after more than
+// limit messages arrive in it within one second.
+func New(limit int, cooldown time.Duration) *Monitor {
+	return &Monitor{limit: limit, cooldown: cooldown, now: time.Now, channels: make(map[string]*channel)}
+}
+
+// Observe records a message in channelID and reports whether links in it should
+// still be fixed. tripped is true only for the message that started a suspension,
+// so callers can log or alert once per burst.
+func (m *Monitor) Observe(channelID string) (allowed, tripped bool) {
+	if m == nil {
+		return true, false
+	}
+	if m.Shared != nil {
+		allowed, tripped, err := m.observeShared(channelID)
+		if err == nil {
+			return allowed, tripped
+		}
+		log.Println("Error counting shared messages:", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	c := m.channels[channelID]
+	if c == nil {
+		c = &channel{windowStart: now}
+		m.channels[channelID] = c
+	}
+	c.lastSeen = now
+
+	if now.Sub(c.windowStart) >= time.Second {
+		c.windowStart, c.count = now, 0
+	}
+	c.count++
+
+	if now.Before(c.suspendedUntil) {
+		// Keep the channel suspended while the burst continues
+		if c.count > m.limit {
+			c.suspendedUntil = now.Add(m.cooldown)
+		}
+		return false, false
+	}
+	if c.count > m.limit {
+		c.suspendedUntil = now.Add(m.cooldown)
+		return false, true
+	}
+	return true, false
+}
+
+// observeShared does the work of Observe with the Shared store.
+func (m *Monitor) observeShared(channelID string) (allowed, tripped bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sharedTimeout)
+	defer cancel()
+	count, err := m.Shared.Incr(ctx, "flood:count:"+channelID, time.Second)
+	if err != nil {
+		return true, false, err
+	}
+	key := "flood:suspended:" + channelID
+	_, suspended, err := m.Shared.Get(ctx, key)
+	if err != nil || count <= int64(m.limit) {
+		return !suspended, false, err
+	}
+	if suspended {
+		// Keep the channel suspended while the burst continues
+		return false, false, m.Shared.Set(ctx, key, "1", m.cooldown)
+	}
+	// Only the replica that suspends the channel trips, so it's logged once
+	tripped, err = m.Shared.SetNX(ctx, key, "1", m.cooldown)
+	return false, tripped, err
+}
+
+// sweep prunes the monitor at most once per sweepInterval. Callers must hold m.mu.
+func (m *Monitor) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.prune(now)
+}
+
+// Prune forgets channels that haven't seen a message in a while and aren't
+// suspended, and returns how many it forgot.
+func (m *Monitor) Prune(now time.Time) int {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.prune(now)
+}
+
+// Len returns how many channels the monitor is tracking.
+func (m *Monitor) Len() int {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.channels)
+}
+
+// prune does the work of Prune. Callers must hold m.mu.
+func (m *Monitor) prune(now time.Time) int {
+	pruned := 0
+	for id, c := range m.channels {
+		if now.Sub(c.lastSeen) >= sweepInterval && !now.Before(c.suspendedUntil) {
+			delete(m.channels, id)
+			pruned++
+		}
+	}
+	m.lastSweep = now
+	return pruned
+}
@@ -0,0 +1,110 @@
+package flood
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryShared is a Shared store in memory that never expires keys.
+type memoryShared struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func (m *memoryShared) Get(_ context.Context, key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.keys[key]
+	return v, ok, nil
+}
+
+func (m *memoryShared) Set(_ context.Context, key, value string, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key] = value
+	return nil
+}
+
+func (m *memoryShared) SetNX(_ context.Context, key, value string, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[key]; ok {
+		return false, nil
+	}
+	m.keys[key] = value
+	return true, nil
+}
+
+func (m *memoryShared) Incr(_ context.Context, key string, _ time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, _ := strconv.ParseInt(m.keys[key], 10, 64)
+	m.keys[key] = strconv.FormatInt(n+1, 10)
+	return n + 1, nil
+}
+
+func TestMonitor(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := New(3, time.Minute)
+	m.now = func() time.Time { return clock }
+
+	testCases := []struct {
+		name     string
+		advance  time.Duration
+		channel  string
+		messages int
+		allowed  bool
+		tripped  bool
+	}{
+		{name: "Under the limit", channel: "chan", messages: 3, allowed: true},
+		{name: "Burst trips suspension", channel: "chan", messages: 1, allowed: false, tripped: true},
+		{name: "Other channels unaffected", channel: "other", messages: 1, allowed: true},
+		{name: "Still suspended", advance: 30 * time.Second, channel: "chan", messages: 1, allowed: false},
+		{name: "Resumes after cooldown", advance: 31 * time.Second, channel: "chan", messages: 1, allowed: true},
+		{name: "New window resets the count", advance: time.Second, channel: "chan", messages: 3, allowed: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock = clock.Add(tc.advance)
+			var allowed, tripped bool
+			for range tc.messages {
+				allowed, tripped = m.Observe(tc.channel)
+			}
+			if allowed != tc.allowed || tripped != tc.tripped {
+				t.Errorf("Observe = %v, %v; want %v, %v", allowed, tripped, tc.allowed, tc.tripped)
+			}
+		})
+	}
+}
+
+func TestNilMonitor(t *testing.T) {
+	var m *Monitor
+	if allowed, _ := m.Observe("chan"); !allowed {
+		t.Errorf("nil monitor suspended a channel")
+	}
+}
+
+func TestMonitorShared(t *testing.T) {
+	shared := &memoryShared{keys: make(map[string]string)}
+	first, second := New(3, time.Minute), New(3, time.Minute)
+	first.Shared, second.Shared = shared, shared
+
+	for _, m := range []*Monitor{first, second, first} {
+		if allowed, _ := m.Observe("chan"); !allowed {
+			t.Fatal("suspended under the limit")
+		}
+	}
+	if allowed, tripped := second.Observe("chan"); allowed || !tripped {
+		t.Errorf("Observe over the limit across replicas = %v, %v; want suspended and tripped", allowed, tripped)
+	}
+	if allowed, tripped := first.Observe("chan"); allowed || tripped {
+		t.Errorf("Observe on the other replica = %v, %v; want suspended without tripping again", allowed, tripped)
+	}
+	if allowed, _ := first.Observe("other"); !allowed {
+		t.Error("other channel suspended")
+	}
+}
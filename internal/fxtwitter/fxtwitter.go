@@ -0,0 +1,305 @@
+// Package fxtwitter is a small client for the fxtwitter status API, which
+// returns the text and media of a tweet without needing Twitter credentials.
+package fxtwitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"go-discord-bot/internal/report"
+)
+
+// DefaultBaseURL is the public fxtwitter API.
+const DefaultBaseURL = "https://api.fxtwitter.com"
+
+// ErrNotFound is returned for tweets and accounts that don't exist or can't be seen.
+var ErrNotFound = errors.New("tweet not found")
+
+// Client fetches tweets from an fxtwitter API instance.
+type Client struct {
+	baseURL string
+	client  *http.Client
+
+	// failures counts the requests in a row that failed, for health checks
+	failures atomic.Int64
+}
+
+// New returns a client for the API at baseURL, or DefaultBaseURL if it's empty.
+// A nil client uses http.DefaultClient.
+func New(baseURL string, client *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// Tweet is the part of an fxtwitter status the bot uses.
+type Tweet struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Text   string `json:"text"`
+	Author Author `json:"author"`
+	Media  *Media `json:"media"`
+	// Quote is the tweet this one quotes, if any.
+	Quote *Tweet `json:"quote"`
+	// ReplyingToStatus is the ID of the tweet this one replies to, if any.
+	ReplyingToStatus string `json:"replying_to_status"`
+}
+
+// Author is the account that posted a tweet.
+type Author struct {
+	Name       string `json:"name"`
+	ScreenName string `json:"screen_name"`
+}
+
+// Media holds the photos, videos and GIFs attached to a tweet.
+type Media struct {
+	All []MediaItem `json:"all"`
+}
+
+// MediaItem is a single photo, video or GIF. URL points at the original file,
+// and Variants, for videos and GIFs, at every encoding of it. AltText is the
+// description the poster wrote for a photo, in whatever language they wrote it.
+type MediaItem struct {
+	Type     string    `json:"type"`
+	URL      string    `json:"url"`
+	AltText  string    `json:"altText"`
+	Variants []Variant `json:"variants"`
+}
+
+// Variant is one encoding of a video or GIF.
+type Variant struct {
+	ContentType string `json:"content_type"`
+	Bitrate     int    `json:"bitrate"`
+	URL         string `json:"url"`
+}
+
+// photoSizes are the sizes Twitter's CDN scales photos down to, largest first.
+var photoSizes = []string{"medium", "small"}
+
+// Smaller returns the URLs of smaller versions of the item, largest first: the
+// lower bitrate MP4s of a video or GIF, or a photo scaled down.
+func (item MediaItem) Smaller() []string {
+	if item.Type == "photo" {
+		return SmallerPhotos(item.URL)
+	}
+	variants := slices.Clone(item.Variants)
+	slices.SortStableFunc(variants, func(a, b Variant) int { return b.Bitrate - a.Bitrate })
+	var urls []string
+	for _, v := range variants {
+		if v.ContentType == "video/mp4" && v.URL != "" && v.URL != item.URL {
+			urls = append(urls, v.URL)
+		}
+	}
+	return urls
+}
+
+// SmallerPhotos returns the URLs of a photo on Twitter's CDN scaled down,
+// largest first, or nil for photos elsewhere.
+func SmallerPhotos(photo string) []string {
+	u, err := url.Parse(photo)
+	if err != nil || u.Host != "pbs.twimg.com" {
+		return nil
+	}
+	urls := make([]string, len(photoSizes))
+	for n, size := range photoSizes {
+		q := u.Query()
+		q.Set("name", size)
+		scaled := *u
+		scaled.RawQuery = q.Encode()
+		urls[n] = scaled.String()
+	}
+	return urls
+}
+
+// MediaURLs returns the direct URL of every media item in the tweet, in order.
+func (t *Tweet) MediaURLs() []string {
+	if t.Media == nil {
+		return nil
+	}
+	urls := make([]string, 0, len(t.Media.All))
+	for _, item := range t.Media.All {
+		if item.URL != "" {
+			urls = append(urls, item.URL)
+		}
+	}
+	return urls
+}
+
+// Photos returns the URLs of the tweet's photos, in order.
+func (t *Tweet) Photos() []string {
+	if t.Media == nil {
+		return nil
+	}
+	var urls []string
+	for _, item := range t.Media.All {
+		if item.Type == "photo" && item.URL != "" {
+			urls = append(urls, item.URL)
+		}
+	}
+	return urls
+}
+
+// AltTexts returns the alt text of each of the tweet's photos, in order, empty
+// for photos without any.
+func (t *Tweet) AltTexts() []string {
+	if t.Media == nil {
+		return nil
+	}
+	var texts []string
+	for _, item := range t.Media.All {
+		if item.Type == "photo" && item.URL != "" {
+			texts = append(texts, strings.TrimSpace(item.AltText))
+		}
+	}
+	return texts
+}
+
+// Photo returns the URL of the tweet's first photo, if it has one.
+func (t *Tweet) Photo() (string, bool) {
+	if t.Media == nil {
+		return "", false
+	}
+	for _, item := range t.Media.All {
+		if item.Type == "photo" && item.URL != "" {
+			return item.URL, true
+		}
+	}
+	return "", false
+}
+
+// Relation is how a tweet shown for context relates to the tweet it explains.
+type Relation int
+
+const (
+	// Quoted tweets are quoted by the tweet before them.
+	Quoted Relation = iota
+	// Parent tweets are replied to by the tweet before them.
+	Parent
+)
+
+// Related is a tweet shown for context.
+type Related struct {
+	Relation Relation
+	Tweet    *Tweet
+}
+
+// Thread returns up to depth tweets that give context to the tweet with the
+// given ID: the tweet it quotes, then the tweet it replies to and that tweet's
+// quote, and so on up the thread. A parent that can't be fetched, such as a
+// deleted tweet, ends the thread early.
+func (c *Client) Thread(ctx context.Context, id string, depth int) ([]Related, error) {
+	tweet, err := c.Status(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var related []Related
+	for len(related) < depth {
+		if tweet.Quote != nil {
+			related = append(related, Related{Relation: Quoted, Tweet: tweet.Quote})
+			if len(related) == depth {
+				break
+			}
+		}
+		if tweet.ReplyingToStatus == "" {
+			break
+		}
+		tweet, err = c.Status(ctx, tweet.ReplyingToStatus)
+		if err != nil {
+			break
+		}
+		related = append(related, Related{Relation: Parent, Tweet: tweet})
+	}
+	return related, nil
+}
+
+// Status fetches the tweet with the given ID.
+func (c *Client) Status(ctx context.Context, id string) (*Tweet, error) {
+	var body struct {
+		Tweet *Tweet `json:"tweet"`
+	}
+	if err := c.get(ctx, "/status/"+id, &body); err != nil {
+		return nil, err
+	}
+	if body.Tweet == nil {
+		return nil, ErrNotFound
+	}
+	return body.Tweet, nil
+}
+
+// User is the part of an fxtwitter profile the bot uses.
+type User struct {
+	ScreenName  string `json:"screen_name"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	AvatarURL   string `json:"avatar_url"`
+	Followers   int    `json:"followers"`
+	Following   int    `json:"following"`
+	Tweets      int    `json:"tweets"`
+}
+
+// User fetches the profile of the account with the given screen name.
+func (c *Client) User(ctx context.Context, screenName string) (*User, error) {
+	var body struct {
+		User *User `json:"user"`
+	}
+	if err := c.get(ctx, "/"+url.PathEscape(screenName), &body); err != nil {
+		return nil, err
+	}
+	if body.User == nil {
+		return nil, ErrNotFound
+	}
+	return body.User, nil
+}
+
+// Failures returns how many requests to the API failed in a row, without a
+// response or with a server error, 0 if the last one succeeded.
+func (c *Client) Failures() int {
+	return int(c.failures.Load())
+}
+
+// get decodes the API's response for path into body.
+func (c *Client) get(ctx context.Context, path string, body any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		report.Leave(ctx, "fxtwitter", path+": "+err.Error())
+		if ctx.Err() == nil {
+			c.failures.Add(1)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	report.Leave(ctx, "fxtwitter", path+": "+resp.Status)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		c.failures.Add(1)
+	} else {
+		c.failures.Store(0)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("fxtwitter returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(body); err != nil {
+		return fmt.Errorf("decoding fxtwitter response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package fxtwitter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status/1":
+			w.Write([]byte(`{"code":200,"message":"OK","tweet":{"id":"1","text":"hi","author":{"screen_name":"user"},
+				"media":{"all":[{"type":"photo","url":"https://pbs.twimg.com/media/a.jpg"},{"type":"video","url":"https://video.twimg.com/b.mp4"}]}}}`))
+		case "/status/2":
+			w.Write([]byte(`{"code":200,"message":"OK","tweet":{"id":"2","text":"no media"}}`))
+		case "/status/3":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"NOT_FOUND","tweet":null}`))
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, server.Client())
+
+	testCases := []struct {
+		name     string
+		id       string
+		expected []string
+		err      bool
+		notFound bool
+	}{
+		{name: "Photo and video", id: "1", expected: []string{"https://pbs.twimg.com/media/a.jpg", "https://video.twimg.com/b.mp4"}},
+		{name: "No media", id: "2"},
+		{name: "Server error", id: "3", err: true},
+		{name: "Missing tweet", id: "4", err: true, notFound: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tweet, err := c.Status(context.Background(), tc.id)
+			if (err != nil) != tc.err {
+				t.Fatalf("Status(%s) error = %v; want error %v", tc.id, err, tc.err)
+			}
+			if tc.notFound && !errors.Is(err, ErrNotFound) {
+				t.Errorf("Status(%s) error = %v; want ErrNotFound", tc.id, err)
+			}
+			if err != nil {
+				return
+			}
+			if urls := tweet.MediaURLs(); !slices.Equal(urls, tc.expected) {
+				t.Errorf("MediaURLs = %q; want %q", urls, tc.expected)
+			}
+		})
+	}
+}
+
+func TestThread(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status/3":
+			w.Write([]byte(`{"tweet":{"id":"3","replying_to_status":"2","quote":{"id":"q3"}}}`))
+		case "/status/2":
+			w.Write([]byte(`{"tweet":{"id":"2","replying_to_status":"1"}}`))
+		case "/status/1":
+			w.Write([]byte(`{"tweet":{"id":"1","replying_to_status":"0"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, server.Client())
+
+	testCases := []struct {
+		name     string
+		id       string
+		depth    int
+		expected []string
+	}{
+		{name: "Whole thread", id: "3", depth: 5, expected: []string{"q3", "2", "1"}},
+		{name: "Depth limits the thread", id: "3", depth: 2, expected: []string{"q3", "2"}},
+		{name: "Just the quote", id: "3", depth: 1, expected: []string{"q3"}},
+		{name: "Off", id: "3", depth: 0},
+		{name: "No context", id: "1", depth: 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			related, err := c.Thread(context.Background(), tc.id, tc.depth)
+			if err != nil {
+				t.Fatalf("Thread: %v", err)
+			}
+			var ids []string
+			for _, r := range related {
+				ids = append(ids, r.Tweet.ID)
+			}
+			if !slices.Equal(ids, tc.expected) {
+				t.Errorf("Thread(%s, %d) = %v; want %v", tc.id, tc.depth, ids, tc.expected)
+			}
+		})
+	}
+}
+
+func TestUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/someone":
+			w.Write([]byte(`{"code":200,"message":"OK","user":{"screen_name":"someone","name":"Some One","followers":12,"avatar_url":"https://pbs.twimg.com/profile_images/1/a.jpg"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"User not found"}`))
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, server.Client())
+
+	user, err := c.User(context.Background(), "someone")
+	if err != nil {
+		t.Fatalf("User: %v", err)
+	}
+	if user.Name != "Some One" || user.Followers != 12 || user.AvatarURL == "" {
+		t.Errorf("User = %+v", user)
+	}
+	if _, err := c.User(context.Background(), "nobody"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("User(nobody) error = %v; want ErrNotFound", err)
+	}
+}
+
+func TestSmaller(t *testing.T) {
+	testCases := []struct {
+		name     string
+		item     MediaItem
+		expected []string
+	}{
+		{
+			name: "Video",
+			item: MediaItem{Type: "video", URL: "https://video.twimg.com/hd.mp4", Variants: []Variant{
+				{ContentType: "video/mp4", Bitrate: 832000, URL: "https://video.twimg.com/sd.mp4"},
+				{ContentType: "application/x-mpegURL", URL: "https://video.twimg.com/pl.m3u8"},
+				{ContentType: "video/mp4", Bitrate: 2176000, URL: "https://video.twimg.com/hd.mp4"},
+				{ContentType: "video/mp4", Bitrate: 256000, URL: "https://video.twimg.com/ld.mp4"},
+			}},
+			expected: []string{"https://video.twimg.com/sd.mp4", "https://video.twimg.com/ld.mp4"},
+		},
+		{
+			name:     "Photo",
+			item:     MediaItem{Type: "photo", URL: "https://pbs.twimg.com/media/a.jpg?name=orig"},
+			expected: []string{"https://pbs.twimg.com/media/a.jpg?name=medium", "https://pbs.twimg.com/media/a.jpg?name=small"},
+		},
+		{name: "Photo elsewhere", item: MediaItem{Type: "photo", URL: "https://example.com/a.jpg"}},
+		{name: "GIF without variants", item: MediaItem{Type: "gif", URL: "https://video.twimg.com/a.mp4"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if urls := tc.item.Smaller(); !slices.Equal(urls, tc.expected) {
+				t.Errorf("Smaller = %q; want %q", urls, tc.expected)
+			}
+		})
+	}
+}
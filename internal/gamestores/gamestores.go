@@ -0,0 +1,191 @@
+// Package gamestores looks up games linked from Steam and itch.io through
+// their public store APIs, so links to them can be shown with their price,
+// discount and genres.
+package gamestores
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultSteamURL is Steam's store, whose appdetails API needs no key.
+const DefaultSteamURL = "https://store.steampowered.com"
+
+// DefaultItchURL is where itch.io serves a creator's games, with %s standing
+// for the creator.
+const DefaultItchURL = "https://%s.itch.io"
+
+// Stores, as shown under their games.
+const (
+	Steam = "Steam"
+	Itch  = "itch.io"
+)
+
+// ErrNotFound is returned for games that don't exist or aren't for sale.
+var ErrNotFound = errors.New("game not found")
+
+// Game is what a store says about a game.
+type Game struct {
+	// Store is Steam or Itch.
+	Store       string
+	Title       string
+	URL         string
+	Description string
+	// Image is the game's header or cover image, if it has one.
+	Image string
+	// Price is what the game costs now, in the store's format such as
+	// "$4.99", "Free" for free games and empty if the store didn't say.
+	Price string
+	// OriginalPrice is what the game costs without its discount, empty
+	// without one.
+	OriginalPrice string
+	// Discount is the discount in percent, 0 without one.
+	Discount int
+	// Genres are the game's genres, on stores that list them.
+	Genres []string
+}
+
+// Client looks up games on Steam and itch.io.
+type Client struct {
+	steamURL string
+	itchURL  string
+	country  string
+	client   *http.Client
+}
+
+// New returns a client for the stores at steamURL and itchURL, or
+// DefaultSteamURL and DefaultItchURL if they're empty. Steam prices are
+// those of country, a two-letter code such as "us". A nil client uses
+// http.DefaultClient.
+func New(steamURL, itchURL, country string, client *http.Client) *Client {
+	if steamURL == "" {
+		steamURL = DefaultSteamURL
+	}
+	if itchURL == "" {
+		itchURL = DefaultItchURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{steamURL: strings.TrimSuffix(steamURL, "/"), itchURL: strings.TrimSuffix(itchURL, "/"), country: country, client: client}
+}
+
+// Steam looks up the Steam game with the given app ID.
+func (c *Client) Steam(ctx context.Context, appID string) (*Game, error) {
+	query := url.Values{"appids": {appID}, "l": {"english"}}
+	if c.country != "" {
+		query.Set("cc", c.country)
+	}
+	var body map[string]struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Name             string `json:"name"`
+			ShortDescription string `json:"short_description"`
+			HeaderImage      string `json:"header_image"`
+			IsFree           bool   `json:"is_free"`
+			PriceOverview    *struct {
+				DiscountPercent  int    `json:"discount_percent"`
+				InitialFormatted string `json:"initial_formatted"`
+				FinalFormatted   string `json:"final_formatted"`
+			} `json:"price_overview"`
+			Genres []struct {
+				Description string `json:"description"`
+			} `json:"genres"`
+		} `json:"data"`
+	}
+	if err := c.get(ctx, c.steamURL+"/api/appdetails?"+query.Encode(), &body); err != nil {
+		return nil, err
+	}
+	app, ok := body[appID]
+	if !ok || !app.Success {
+		return nil, ErrNotFound
+	}
+
+	game := &Game{
+		Store:       Steam,
+		Title:       app.Data.Name,
+		URL:         "https://store.steampowered.com/app/" + appID,
+		Description: html.UnescapeString(app.Data.ShortDescription),
+		Image:       app.Data.HeaderImage,
+	}
+	switch price := app.Data.PriceOverview; {
+	case app.Data.IsFree:
+		game.Price = "Free"
+	case price != nil:
+		game.Price = price.FinalFormatted
+		if price.DiscountPercent > 0 {
+			game.OriginalPrice, game.Discount = price.InitialFormatted, price.DiscountPercent
+		}
+	}
+	for _, genre := range app.Data.Genres {
+		game.Genres = append(game.Genres, genre.Description)
+	}
+	return game, nil
+}
+
+// Itch looks up the itch.io game slug of creator.
+func (c *Client) Itch(ctx context.Context, creator, slug string) (*Game, error) {
+	var body struct {
+		Title         string `json:"title"`
+		CoverImage    string `json:"cover_image"`
+		Price         string `json:"price"`
+		OriginalPrice string `json:"original_price"`
+		Sale          *struct {
+			Rate int `json:"rate"`
+		} `json:"sale"`
+	}
+	base := fmt.Sprintf(c.itchURL, url.PathEscape(creator))
+	if err := c.get(ctx, base+"/"+url.PathEscape(slug)+"/data.json", &body); err != nil {
+		return nil, err
+	}
+	if body.Title == "" {
+		return nil, ErrNotFound
+	}
+
+	game := &Game{
+		Store: Itch,
+		Title: body.Title,
+		URL:   fmt.Sprintf(DefaultItchURL, creator) + "/" + slug,
+		Image: body.CoverImage,
+		Price: body.Price,
+	}
+	if free(body.Price) {
+		game.Price = "Free"
+	}
+	if body.Sale != nil && body.Sale.Rate > 0 {
+		game.OriginalPrice, game.Discount = body.OriginalPrice, body.Sale.Rate
+	}
+	return game, nil
+}
+
+// free reports whether price, such as "$0.00", is nothing.
+func free(price string) bool {
+	return price != "" && strings.Trim(price, "$€£¥ 0.,") == ""
+}
+
+// get fetches rawURL and decodes its JSON body into v.
+func (c *Client) get(ctx context.Context, rawURL string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("store returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,111 @@
+package gamestores
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/appdetails":
+			if r.URL.Query().Get("cc") != "de" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			switch id := r.URL.Query().Get("appids"); id {
+			case "620":
+				w.Write([]byte(`{"620":{"success":true,"data":{"name":"Portal 2","short_description":"Sequel to &quot;Portal&quot;","header_image":"https://cdn.example/620.jpg","is_free":false,"price_overview":{"discount_percent":80,"initial_formatted":"9,99€","final_formatted":"1,99€"},"genres":[{"description":"Action"},{"description":"Adventure"}]}}}`))
+			case "570":
+				w.Write([]byte(`{"570":{"success":true,"data":{"name":"Dota 2","is_free":true}}}`))
+			case "500":
+				w.WriteHeader(http.StatusInternalServerError)
+			default:
+				w.Write([]byte(`{"` + id + `":{"success":false}}`))
+			}
+		case "/maker/sale-game/data.json":
+			w.Write([]byte(`{"title":"Sale Game","cover_image":"https://img.example/cover.png","price":"$2.50","original_price":"$5.00","sale":{"rate":50}}`))
+		case "/maker/free-game/data.json":
+			w.Write([]byte(`{"title":"Free Game","price":"$0.00"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestSteam(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	c := New(server.URL, "", "de", server.Client())
+
+	testCases := []struct {
+		name     string
+		id       string
+		expected *Game
+		notFound bool
+	}{
+		{
+			name: "Discounted",
+			id:   "620",
+			expected: &Game{
+				Store: Steam, Title: "Portal 2", URL: "https://store.steampowered.com/app/620", Description: `Sequel to "Portal"`,
+				Image: "https://cdn.example/620.jpg", Price: "1,99€", OriginalPrice: "9,99€", Discount: 80, Genres: []string{"Action", "Adventure"},
+			},
+		},
+		{name: "Free", id: "570", expected: &Game{Store: Steam, Title: "Dota 2", URL: "https://store.steampowered.com/app/570", Price: "Free"}},
+		{name: "Missing", id: "1", notFound: true},
+		{name: "Server error", id: "500"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			game, err := c.Steam(context.Background(), tc.id)
+			if tc.notFound != errors.Is(err, ErrNotFound) {
+				t.Errorf("Steam(%s) error = %v; want ErrNotFound %v", tc.id, err, tc.notFound)
+			}
+			if !reflect.DeepEqual(game, tc.expected) {
+				t.Errorf("Steam(%s) = %+v; want %+v", tc.id, game, tc.expected)
+			}
+		})
+	}
+}
+
+func TestItch(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	c := New("", server.URL+"/%s", "", server.Client())
+
+	testCases := []struct {
+		name     string
+		slug     string
+		expected *Game
+		notFound bool
+	}{
+		{
+			name: "On sale",
+			slug: "sale-game",
+			expected: &Game{
+				Store: Itch, Title: "Sale Game", URL: "https://maker.itch.io/sale-game", Image: "https://img.example/cover.png",
+				Price: "$2.50", OriginalPrice: "$5.00", Discount: 50,
+			},
+		},
+		{name: "Free", slug: "free-game", expected: &Game{Store: Itch, Title: "Free Game", URL: "https://maker.itch.io/free-game", Price: "Free"}},
+		{name: "Missing", slug: "nothing", notFound: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			game, err := c.Itch(context.Background(), "maker", tc.slug)
+			if tc.notFound != errors.Is(err, ErrNotFound) {
+				t.Errorf("Itch(%s) error = %v; want ErrNotFound %v", tc.slug, err, tc.notFound)
+			}
+			if !reflect.DeepEqual(game, tc.expected) {
+				t.Errorf("Itch(%s) = %+v; want %+v", tc.slug, game, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Muted counts a user triggering the bot in a guild, with a command or a link
+// to fix, and reports whether the bot should ignore them for triggering it
+// far too often. Moderators are told in the guild's audit channel when a user
+// is muted.
+func (h *Handler) Muted(s Session, guildID, userID string) bool {
+	allowed, tripped := h.Abuse.Observe(guildID, userID)
+	if tripped {
+		log.Printf("User %s is spamming the bot in guild %s, ignoring them for %s\n", userID, guildID, h.Abuse.Mute())
+		h.audit(s, guildID, fmt.Sprintf("<@%s> used the bot far more than anyone normally would, so it's ignoring them for %d minutes.", userID, int(h.Abuse.Mute().Minutes())))
+	}
+	return !allowed
+}
+
+// audit tells moderators about something the bot did on its own, in guilds
+// with an audit channel.
+func (h *Handler) audit(s Session, guildID, notice string) {
+	cfg := h.guildConfig(guildID)
+	if cfg.AuditChannel == "" {
+		return
+	}
+	ctx, cancel := h.operation()
+	defer cancel()
+	h.send(ctx, s, cfg.AuditChannel, &discordgo.MessageSend{
+		Content:         notice,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
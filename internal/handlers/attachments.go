@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/patterns"
+)
+
+const (
+	// maxScanLength is the longest message, counting forwarded text and the
+	// links in attachments' alt text, that the fixers scan. Discord caps what
+	// people type at 4000 characters, so only odd messages come near it.
+	maxScanLength = 16000
+	// maxScanLinks is the most links a message may hold for the fixers to
+	// scan it, so one message can't set off hundreds of lookups.
+	maxScanLinks = 50
+)
+
+// MessageCreateEvent is the callback function for every gateway event. It
+// hands MESSAGE_CREATE events to HandleMessageCreate with the links in their
+// attachments' alt text, which discordgo doesn't decode, and ignores the rest.
+func (h *Handler) MessageCreateEvent(s *discordgo.Session, e *discordgo.Event) {
+	m, ok := e.Struct.(*discordgo.MessageCreate)
+	if e.Type != "MESSAGE_CREATE" || !ok || m.Message == nil {
+		return
+	}
+	h.HandleMessageCreate(s, s.State.User.ID, &discordgo.MessageCreate{Message: withAltText(m.Message, altTextLinks(e.RawData))})
+}
+
+// altTextLinks returns the links in the alt text of the attachments of a raw
+// message.
+func altTextLinks(raw json.RawMessage) []string {
+	if !strings.Contains(string(raw), `"description"`) {
+		return nil
+	}
+	var m struct {
+		Attachments []struct {
+			Description string `json:"description"`
+		} `json:"attachments"`
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		log.Println("Error decoding attachment alt text:", err)
+		return nil
+	}
+	var links []string
+	for _, a := range m.Attachments {
+		if patterns.HasLink(a.Description) {
+			links = append(links, patterns.URL.FindAllString(a.Description, maxScanLinks)...)
+		}
+	}
+	return links
+}
+
+// withAltText returns m with the links from its attachments' alt text that
+// aren't in its content added on their own lines, so a post that is only an
+// image described with a tweet link is fixed like the link itself.
+func withAltText(m *discordgo.Message, links []string) *discordgo.Message {
+	var added []string
+	for _, link := range links {
+		if !strings.Contains(m.Content, link) && !slices.Contains(added, link) {
+			added = append(added, link)
+		}
+	}
+	if len(added) == 0 {
+		return m
+	}
+	described := *m
+	described.Content = strings.TrimSpace(m.Content + "\n" + strings.Join(added, "\n"))
+	return &described
+}
+
+// beyondScanLimits returns why content is too much for the fixers to scan, or
+// "" if it isn't.
+func beyondScanLimits(content string) string {
+	if len(content) > maxScanLength {
+		return fmt.Sprintf("it's %d characters long, over the %d scanned", len(content), maxScanLength)
+	}
+	if patterns.HasLink(content) && len(patterns.URL.FindAllStringIndex(content, maxScanLinks+1)) > maxScanLinks {
+		return fmt.Sprintf("it has more than %d links", maxScanLinks)
+	}
+	return ""
+}
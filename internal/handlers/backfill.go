@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/outbound"
+)
+
+// Backfill fixes links in a channel's last count messages that the bot never
+// got to, such as those posted while it was down. Messages the bot already
+// replied to or reposted, and tweets fixed recently elsewhere in the guild,
+// are skipped. The rest are queued like new messages, oldest first, so they
+// share the pool and Discord's rate limits with everything else. It returns
+// how many messages were queued.
+func (h *Handler) Backfill(ctx context.Context, s Session, botUserID, guildID, channelID string, count int) (int, error) {
+	history, err := s.ChannelMessages(channelID, min(count, commands.MaxBackfill), "", "", "", discordgo.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	cfg := h.guildConfig(guildID)
+	pipeline := h.Fixers.Without(cfg.DisabledFixers)
+	var replied []string
+	var reposts []string
+	for _, m := range history {
+		if m.Author == nil || m.Author.ID != botUserID {
+			continue
+		}
+		if m.MessageReference != nil {
+			replied = append(replied, m.MessageReference.MessageID)
+		}
+		reposts = append(reposts, m.Content)
+	}
+
+	queued := 0
+	// History comes newest first
+	for _, m := range slices.Backward(history) {
+		if m.Author == nil || m.Author.Bot || slices.Contains(replied, m.ID) {
+			continue
+		}
+		var roles []string
+		if m.Member != nil {
+			roles = m.Member.Roles
+		}
+		if config.Ignored(h.Store, guildID, m.Author.ID, roles) {
+			continue
+		}
+
+		m.GuildID = guildID
+		m = withSnapshot(m)
+		modified := pipeline.Apply(ctx, &discordgo.MessageCreate{Message: m})
+		if modified == m.Content {
+			continue
+		}
+		changed := fixers.ChangedLinks(m.Content, modified)
+		if _, _, seen := h.earlierRepost(guildID, changed); seen || reposted(reposts, changed) {
+			continue
+		}
+
+		trace := h.trace("backfill", m)
+		// Backfilled reposts wait behind those of new messages
+		trace.priority = outbound.Low
+		if !h.queue(trace, s, &discordgo.MessageCreate{Message: m}) {
+			correlation.Println(ctx, "Worker queue full, stopping backfill of channel", channelID)
+			break
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// reposted reports whether one of the bot's messages already has every link in links.
+func reposted(reposts []string, links []string) bool {
+	if len(links) == 0 {
+		return false
+	}
+	for _, content := range reposts {
+		found := true
+		for _, link := range links {
+			if !strings.Contains(content, link) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
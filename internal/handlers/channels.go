@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+)
+
+// channelSkip returns why links posted in channelID aren't fixed in a guild
+// with the settings cfg, or "" if they are. Threads and forum posts follow
+// the channel they're in unless they were picked themselves, forum posts
+// tagged like the skip marker are left alone, and so are voice channel chats.
+func (h *Handler) channelSkip(ctx context.Context, s Session, cfg config.Guild, guildID, channelID string) string {
+	if guildID == "" {
+		return ""
+	}
+	info, err := h.Channels.Get(ctx, s, channelID)
+	if err != nil {
+		// Go by the channel's own settings then
+		correlation.Println(ctx, "Error looking up channel:", err)
+	}
+	if info.VoiceChat() {
+		return "links in voice channel chats are left alone"
+	}
+	if !cfg.ChannelEnabled(channelID) && !(info.Thread() && cfg.ChannelEnabled(info.ParentID)) {
+		return "fixing is only on in other channels"
+	}
+	if info.Thread() {
+		tags, err := h.Channels.TagNames(ctx, s, info)
+		if err != nil {
+			correlation.Println(ctx, "Error looking up forum tags:", err)
+		}
+		for _, tag := range tags {
+			if cfg.SkipsTag(tag) {
+				return "the forum post is tagged " + tag
+			}
+		}
+	}
+	return ""
+}
+
+// ChannelUpdate is the discordgo handler for changed channels.
+func (h *Handler) ChannelUpdate(_ *discordgo.Session, c *discordgo.ChannelUpdate) {
+	h.Channels.Remember(c.Channel)
+}
+
+// ChannelDelete is the discordgo handler for deleted channels.
+func (h *Handler) ChannelDelete(_ *discordgo.Session, c *discordgo.ChannelDelete) {
+	if c.Channel != nil {
+		h.Channels.Forget(c.ID)
+	}
+}
+
+// ThreadUpdate is the discordgo handler for changed threads, such as forum
+// posts whose tags changed or threads someone reopened.
+func (h *Handler) ThreadUpdate(_ *discordgo.Session, t *discordgo.ThreadUpdate) {
+	h.Channels.Remember(t.Channel)
+	h.reopenThread(t.Channel)
+}
+
+// ThreadDelete is the discordgo handler for deleted threads.
+func (h *Handler) ThreadDelete(_ *discordgo.Session, t *discordgo.ThreadDelete) {
+	if t.Channel != nil {
+		h.Channels.Forget(t.ID)
+	}
+}
+
+// ThreadCreate is the discordgo handler for new threads and forum posts.
+func (h *Handler) ThreadCreate(s *discordgo.Session, t *discordgo.ThreadCreate) {
+	h.HandleThreadCreate(s, t)
+}
+
+// HandleThreadCreate does the work of ThreadCreate against any Session. The
+// bot joins new threads in channels where it fixes links, so it's there to
+// post fixes in them.
+func (h *Handler) HandleThreadCreate(s Session, t *discordgo.ThreadCreate) {
+	h.Channels.Remember(t.Channel)
+	if !t.NewlyCreated || t.GuildID == "" || h.outside(t.GuildID) {
+		return
+	}
+	job := func() {
+		ctx, cancel := h.operation()
+		defer cancel()
+		cfg := h.guildConfig(t.GuildID)
+		if cfg.Paused || h.channelSkip(ctx, s, cfg, t.GuildID, t.ID) != "" {
+			return
+		}
+		err := h.Retry.Do(ctx, "join thread", func() error {
+			return s.ThreadJoin(t.ID, discordgo.WithContext(ctx))
+		})
+		if err != nil {
+			log.Println("Error joining thread:", err)
+		}
+	}
+	if !h.Pool.Submit(t.ID, job) {
+		log.Println("Worker queue full, not joining thread", t.ID)
+	}
+}
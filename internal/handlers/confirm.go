@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/correlation"
+)
+
+// askToConfirm sends the author of m the fix of its links in a DM, with
+// buttons to post it or not, and holds the fix until they pick one. It
+// reports whether the author got the DM.
+func (h *Handler) askToConfirm(ctx context.Context, s Session, m *discordgo.MessageCreate, modifiedContent string, origins map[string]string) bool {
+	var dm *discordgo.Channel
+	err := h.Retry.Do(ctx, "open DM", func() error {
+		var err error
+		dm, err = s.UserChannelCreate(m.Author.ID, discordgo.WithContext(ctx))
+		return err
+	})
+	if err != nil {
+		correlation.Println(ctx, "Error opening DM to confirm fix:", err)
+		return false
+	}
+	_, err = h.send(ctx, s, dm.ID, &discordgo.MessageSend{
+		Content:    fmt.Sprintf("Post this fix of your message in <#%s>? https://discord.com/channels/%s/%s/%s", m.ChannelID, m.GuildID, m.ChannelID, m.ID),
+		Embeds:     []*discordgo.MessageEmbed{{Description: modifiedContent}},
+		Components: []discordgo.MessageComponent{commands.ConfirmButtons(m.ID)},
+	})
+	if err != nil {
+		// Most often the author doesn't take DMs from server members
+		return false
+	}
+	h.Confirmations.Add(m.Message, modifiedContent, origins)
+	return true
+}
+
+// ConfirmFix posts the fix held for the message with messageID until its
+// author confirmed it, or drops it unless post is set. It reports whether the
+// fix was still held.
+func (h *Handler) ConfirmFix(s Session, messageID string, post bool) bool {
+	p, ok := h.Confirmations.Take(messageID)
+	if !ok || !post {
+		return ok
+	}
+	if !h.Pool.Submit(p.Message.ChannelID, func() { h.postHeld(s, p) }) {
+		log.Println("Worker queue full, dropping confirmed fix of", messageID)
+		return false
+	}
+	return true
+}
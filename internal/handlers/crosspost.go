@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/crosspost"
+)
+
+// publish crossposts a message if it was posted in an announcement channel.
+func (h *Handler) publish(ctx context.Context, s Session, m *discordgo.Message) {
+	// Messages already published, or followed in from another server, can't be published again
+	if m.Flags&(discordgo.MessageFlagsCrossPosted|discordgo.MessageFlagsIsCrossPosted) != 0 {
+		return
+	}
+	_, err := h.Crossposts.Publish(ctx, s, m.ChannelID, m.ID)
+	if errors.Is(err, crosspost.ErrRateLimited) {
+		correlation.Printf(ctx, "Publish limit reached in channel %s, not publishing message %s\n", m.ChannelID, m.ID)
+		return
+	}
+	if err != nil {
+		correlation.Println(ctx, "Error publishing message:", err)
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/access"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/patterns"
+)
+
+// Diagnose works out what the bot would do with m if it were posted now. It
+// makes the checks HandleMessageCreate and fixMessage make, without sending,
+// reacting or counting anything, and returns the trace of them, so moderators
+// can find out why a message wasn't fixed even once the decision log forgot
+// it. Rate limits, such as flood protection, aren't checked, since they only
+// held back messages at the time.
+func (h *Handler) Diagnose(ctx context.Context, s Session, m *discordgo.Message) explain.Trace {
+	m = withSnapshot(m)
+	// The trace is kept apart from the decision log, to not replace what
+	// really happened to the message
+	diagnosis := explain.New(1)
+	record := diagnosis.Start(m, "diagnosis", correlation.From(ctx))
+	decision := h.diagnose(explain.WithRecord(ctx, record), s, record, m)
+	if decision == "unchanged" {
+		decision = "no fixable links"
+		// The Twitter fixer only scores a message's embed to leave working
+		// previews alone
+		if trace, _ := diagnosis.Get(m.ID); previewWorking(trace.Steps) {
+			decision = "preview working"
+		}
+	}
+	record.Decide(decision)
+	trace, _ := diagnosis.Get(m.ID)
+	return trace
+}
+
+// diagnose makes the checks for Diagnose, noting them in record, and returns
+// the decision.
+func (h *Handler) diagnose(ctx context.Context, s Session, record *explain.Record, m *discordgo.Message) string {
+	if h.outside(m.GuildID) {
+		record.Note("guilds", "the bot is limited to other servers")
+		return "outside"
+	}
+	if config.Paused(h.Store, m.GuildID) {
+		record.Note("pause", "the bot is paused in this server")
+		return "paused"
+	}
+	var authorID string
+	if m.Author != nil {
+		authorID = m.Author.ID
+	}
+	member := m.Member
+	if member == nil {
+		member, _ = h.Members.Get(m.GuildID, authorID)
+	}
+	var roles []string
+	if member != nil {
+		roles = member.Roles
+	}
+	if config.Ignored(h.Store, m.GuildID, authorID, roles) {
+		record.Note("ignore list", "the author or one of their roles is ignored")
+		return "ignored"
+	}
+	record.Note("ignore list", "not ignored")
+	if !access.Check(h.Store, m.GuildID, access.Fixing, roles) {
+		record.Note("roles", "fixing is limited to roles the author doesn't have")
+		return "not allowed"
+	}
+
+	cfg := h.guildConfig(m.GuildID)
+	if len(h.phishingLinks(ctx, m, cfg)) > 0 {
+		record.Note("phishing", "links to a known phishing or malware site")
+		return "phishing"
+	}
+	if cfg.FilterAction == config.FilterRemove {
+		if account := blockedAuthor(cfg, m.Content); account != "" {
+			record.Note("content filters", "links to a tweet by @"+account+", a blocked account")
+			return "removed"
+		}
+	}
+	if cfg.Skips(m.Content) {
+		record.Note("skip marker", "the message starts with "+cfg.Marker())
+		return "skipped"
+	}
+	if why := h.channelSkip(ctx, s, cfg, m.GuildID, m.ChannelID); why != "" {
+		record.Note("channel", why)
+		return "channel disabled"
+	}
+	record.Note("channel", "fixing is on")
+	if cfg.TriggerEmoji != "" {
+		record.Note("trigger emoji", "links are only fixed when someone reacts with "+cfg.TriggerEmoji)
+		return "waiting for trigger"
+	}
+	if other := h.otherFixer(cfg, m.GuildID); other != "" {
+		record.Note("other fixers", fmt.Sprintf("links here are left to <@%s>", other))
+		return "yielded"
+	}
+	if !patterns.HasLink(m.Content) {
+		record.Note("links", "the message has none")
+		return "no links"
+	}
+	if why := beyondScanLimits(m.Content); why != "" {
+		record.Note("limits", why)
+		return "too long"
+	}
+
+	if len(cfg.DisabledFixers) > 0 {
+		record.Note("disabled fixers", strings.Join(cfg.DisabledFixers, ", "))
+	}
+	modified, _ := h.Fixers.Without(cfg.DisabledFixers).ApplyOrigins(ctx, &discordgo.MessageCreate{Message: m})
+	if ctx.Err() != nil {
+		return "timed out"
+	}
+	if modified == m.Content {
+		return "unchanged"
+	}
+	if earlier, ok := h.Duplicates.Lookup(m.GuildID, fixedMessageKey(m.ID)); ok {
+		record.Note("duplicate", fmt.Sprintf("this message was already fixed in <#%s>", earlier.ChannelID))
+		return "already fixed"
+	}
+	changed := fixers.ChangedLinks(m.Content, modified)
+	tweetIDs, _, _ := h.earlierRepost(m.GuildID, changed)
+	if why := h.filtered(ctx, m.GuildID, cfg, changed, tweetIDs); why != "" {
+		record.Note("content filters", why)
+		return "filtered"
+	}
+	return "would fix"
+}
+
+// previewWorking reports whether the steps of a message left unchanged show
+// the Twitter fixer found its embed good enough.
+func previewWorking(steps []explain.Step) bool {
+	return slices.ContainsFunc(steps, func(step explain.Step) bool { return step.Stage == "twitter embed" })
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/mockdiscord"
+	"go-discord-bot/internal/preview"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/workerpool"
+)
+
+// pageTransport sends every request to a test server, whatever its host.
+type pageTransport struct {
+	target *url.URL
+}
+
+func (t pageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// TestEndToEnd replays recorded gateway traffic against a handler connected to
+// a mock Discord, including Discord embedding links after the message arrives.
+func TestEndToEnd(t *testing.T) {
+	pages := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/article" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><meta property="og:title" content="An article"></head></html>`))
+	}))
+	defer pages.Close()
+	target, _ := url.Parse(pages.URL)
+
+	const (
+		guildID   = "200000000000000001"
+		channelID = "300000000000000001"
+	)
+	testCases := []struct {
+		name      string
+		recording string
+		messageID string
+		expected  []string
+	}{
+		{name: "Embedded before the preview delay", recording: "embedded_late.json", messageID: "400000000000000001"},
+		{name: "Never embedded", recording: "never_embedded.json", messageID: "400000000000000002", expected: []string{"embed:An article"}},
+		{name: "Tweet", recording: "tweet.json", messageID: "400000000000000003", expected: []string{"https://fixupx.com/user/status/123"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			events, err := mockdiscord.LoadRecording(filepath.Join("testdata", tc.recording))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			srv := mockdiscord.New()
+			defer srv.Close()
+			srv.AddChannel(&discordgo.Channel{ID: channelID, GuildID: guildID, Type: discordgo.ChannelTypeGuildText})
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, guildID, config.Guild{Previews: true}); err != nil {
+				t.Fatal(err)
+			}
+			h := &Handler{
+				Fixers:       fixers.Pipeline{fixers.Twitter{}},
+				Pool:         workerpool.New(1, 10),
+				Store:        st,
+				Previews:     preview.New(&http.Client{Transport: pageTransport{target: target}}),
+				PreviewDelay: 500 * time.Millisecond,
+			}
+
+			s, err := srv.Session()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.AddHandler(h.MessageCreateEvent)
+			if err := s.Open(); err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer s.Close()
+
+			if err := srv.Replay(ctx, events); err != nil {
+				t.Fatalf("Replay: %v", err)
+			}
+			// The bot is done once it has replied or, for links Discord may
+			// still embed, checked back on the message after the delay
+			checked := "GET /channels/" + channelID + "/messages/" + tc.messageID
+			err = srv.Wait(ctx, func() bool {
+				return len(srv.Sent()) >= len(tc.expected) && (len(tc.expected) > 0 || slices.Contains(srv.Requests(), checked))
+			})
+			if err != nil {
+				t.Fatalf("waiting for the bot: %v; requests %q", err, srv.Requests())
+			}
+			h.Pool.Stop()
+
+			var got []string
+			for _, m := range srv.Sent() {
+				if m.Content == "" && len(m.Embeds) > 0 {
+					got = append(got, "embed:"+m.Embeds[0].Title)
+					continue
+				}
+				got = append(got, m.Content)
+			}
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("sent %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/fxtwitter"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/embeds")
+
+// TestTweetEmbedSnapshots renders the embeds reposts carry, for the tweet and
+// the tweets it quotes, from fxtwitter API responses in testdata/tweets, and
+// compares them with testdata/embeds. Run with -update after changing how
+// embeds look, and check the diff.
+func TestTweetEmbedSnapshots(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "tweets", "*.json"))
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("no tweet fixtures: %v", err)
+	}
+	responses := make(map[string][]byte)
+	links := make(map[string]string)
+	for _, path := range fixtures {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Tweet fxtwitter.Tweet `json:"tweet"`
+		}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Fatalf("decoding %s: %v", path, err)
+		}
+		responses["/status/"+body.Tweet.ID] = data
+		links[strings.TrimSuffix(filepath.Base(path), ".json")] = body.Tweet.URL
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+	h := &Handler{Tweets: fxtwitter.New(server.URL, server.Client())}
+
+	for name, link := range links {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			embed, ok := h.outputEmbed(ctx, "", link)
+			if !ok {
+				t.Fatalf("outputEmbed(%q) found no tweet", link)
+			}
+			id := link[strings.LastIndex(link, "/")+1:]
+			embeds := append([]*discordgo.MessageEmbed{embed}, h.contextEmbeds(ctx, "", []string{id}, 1)...)
+			got, err := json.MarshalIndent(embeds, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", "embeds", name+".json")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, expected) {
+				t.Errorf("embeds differ from %s (run with -update if that's intended):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestAltTextFields(t *testing.T) {
+	testCases := []struct {
+		name     string
+		texts    []string
+		expected []string
+	}{
+		{name: "No alt text", texts: []string{"", ""}},
+		{name: "One photo", texts: []string{"A cat"}, expected: []string{"Image description: A cat"}},
+		{name: "Keeps numbering", texts: []string{"", "Un chat"}, expected: []string{"Image 2 description: Un chat"}},
+		{name: "Too long", texts: []string{strings.Repeat("a", 1100)}, expected: []string{"Image description: " + strings.Repeat("a", 1024)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, field := range altTextFields(tc.texts) {
+				got = append(got, field.Name+": "+field.Value)
+			}
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("altTextFields(%q) = %q; want %q", tc.texts, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTweetSummary(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tweet    fxtwitter.Tweet
+		expected string
+	}{
+		{name: "Bare", expected: "Tweet"},
+		{name: "GIF only", tweet: fxtwitter.Tweet{Media: &fxtwitter.Media{All: []fxtwitter.MediaItem{{Type: "gif"}}}}, expected: "Tweet (1 GIF)"},
+		{name: "Long text", tweet: fxtwitter.Tweet{Text: strings.Repeat("é", 300)}, expected: `Tweet: "` + strings.Repeat("é", 279) + `…"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tweetSummary(&tc.tweet); got != tc.expected {
+				t.Errorf("tweetSummary() = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
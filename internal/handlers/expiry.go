@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+)
+
+// ExpiryBucket is the store bucket holding the reposts waiting to be deleted
+// in guilds whose reposts expire, keyed by bot, channel and message ID, so
+// deletions survive restarts.
+const ExpiryBucket = "repost_expiry"
+
+// expiry is a repost waiting to be deleted.
+type expiry struct {
+	ChannelID string    `json:"channel_id"`
+	MessageID string    `json:"message_id"`
+	GuildID   string    `json:"guild_id"`
+	Due       time.Time `json:"due"`
+}
+
+// scheduleExpiry deletes a repost once it's been up as long as the guild
+// keeps them.
+func (h *Handler) scheduleExpiry(s Session, guildID string, sent *discordgo.Message, cfg config.Guild) {
+	ttl := cfg.RepostTTL()
+	if ttl <= 0 || guildID == "" {
+		return
+	}
+	e := expiry{ChannelID: sent.ChannelID, MessageID: sent.ID, GuildID: guildID, Due: time.Now().Add(ttl)}
+	key := h.expiryKey(sent.ChannelID, sent.ID)
+	if h.Store != nil {
+		if err := h.Store.Put(ExpiryBucket, key, e); err != nil {
+			log.Println("Error saving repost expiry:", err)
+		}
+	}
+	h.runExpiry(s, key, e, ttl)
+}
+
+// runExpiry deletes a repost after delay and forgets its saved expiry.
+func (h *Handler) runExpiry(s Session, key string, e expiry, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		h.whenAvailable(e.GuildID, func() {
+			job := func() {
+				h.expire(s, e)
+				h.forgetExpiry(key)
+			}
+			// Leave the expiry saved, to try again when the bot restarts
+			if !h.Pool.Submit(e.ChannelID, job) {
+				log.Println("Worker queue full, not deleting expired repost", e.MessageID)
+			}
+		})
+	})
+}
+
+// expire deletes an expired repost. Failures are reported by h.Retry, and
+// not tried again, as the repost is most likely gone already.
+func (h *Handler) expire(s Session, e expiry) {
+	ctx, cancel := h.operation()
+	defer cancel()
+	h.Retry.Do(ctx, "delete expired repost", func() error {
+		return s.ChannelMessageDelete(e.ChannelID, e.MessageID, discordgo.WithContext(ctx))
+	})
+}
+
+// ResumeExpiries schedules the repost deletions the handler saved before the
+// bot last stopped. Overdue ones run right away.
+func (h *Handler) ResumeExpiries(s Session) {
+	if h.Store == nil {
+		return
+	}
+	now := time.Now()
+	for _, key := range h.Store.Keys(ExpiryBucket) {
+		if !strings.HasPrefix(key, h.Name+"/") {
+			continue
+		}
+		var e expiry
+		if _, err := h.Store.Get(ExpiryBucket, key, &e); err != nil {
+			log.Println("Error loading repost expiry:", err)
+			h.forgetExpiry(key)
+			continue
+		}
+		h.runExpiry(s, key, e, max(e.Due.Sub(now), 0))
+	}
+}
+
+// forgetExpiry deletes a saved repost expiry.
+func (h *Handler) forgetExpiry(key string) {
+	if h.Store == nil {
+		return
+	}
+	if err := h.Store.Delete(ExpiryBucket, key); err != nil {
+		log.Println("Error deleting repost expiry:", err)
+	}
+}
+
+// expiryKey is where the handler saves the expiry of a repost.
+func (h *Handler) expiryKey(channelID, messageID string) string {
+	return h.Name + "/" + channelID + "/" + messageID
+}
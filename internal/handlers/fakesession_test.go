@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// sentMessage is a message send or edit recorded by fakeSession.
+type sentMessage struct {
+	ChannelID string
+	Content   string
+	// ReplyTo is the ID of the message a send replied to, if any.
+	ReplyTo string
+	// Removable reports whether the send carried the Remove button.
+	Removable bool
+	// Embeds counts the embeds a send carried.
+	Embeds int
+	// Files counts the attachments a send carried.
+	Files int
+}
+
+// fakeSession is a Session that records calls instead of talking to Discord.
+type fakeSession struct {
+	mu      sync.Mutex
+	sent    []sentMessage
+	edited  []sentMessage
+	deleted []string
+	// messages are returned by ChannelMessage, keyed by message ID.
+	messages map[string]*discordgo.Message
+	// history is returned by ChannelMessages, newest first.
+	history []*discordgo.Message
+	// channels are returned by Channel, keyed by channel ID.
+	channels map[string]*discordgo.Channel
+	// reactions holds "messageID emoji" for every reaction the bot added.
+	reactions []string
+	// reactErrs are returned by successive MessageReactionAdd calls before they start succeeding.
+	reactErrs []error
+	// crossposted holds the IDs of published messages.
+	crossposted []string
+	// requestedMembers holds the IDs of members asked for over the gateway.
+	requestedMembers []string
+	// suppressed holds the IDs of messages whose embeds the bot hid.
+	suppressed []string
+	// joined holds the IDs of threads the bot joined.
+	joined []string
+	// threads holds the IDs of messages the bot started a thread on, whose
+	// thread has the ID "thread-" and the message ID.
+	threads []string
+	// archived holds the IDs of threads the bot archived.
+	archived []string
+	// left holds the IDs of guilds the bot left.
+	left []string
+	// roles holds the "guild/user/role" of roles given to members.
+	roles []string
+	// sendErrs are returned by successive ChannelMessageSendComplex calls before they start succeeding.
+	sendErrs []error
+}
+
+func (f *fakeSession) ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if m, ok := f.messages[messageID]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("unknown message %s", messageID)
+}
+
+func (f *fakeSession) ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.history[:min(limit, len(f.history))], nil
+}
+
+func (f *fakeSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.sendErrs) > 0 {
+		err := f.sendErrs[0]
+		f.sendErrs = f.sendErrs[1:]
+		return nil, err
+	}
+	sent := sentMessage{ChannelID: channelID, Content: data.Content}
+	if data.Reference != nil {
+		sent.ReplyTo = data.Reference.MessageID
+	}
+	sent.Removable = len(data.Components) > 0
+	sent.Embeds = len(data.Embeds)
+	sent.Files = len(data.Files)
+	f.sent = append(f.sent, sent)
+	return &discordgo.Message{ID: fmt.Sprint("sent", len(f.sent)), ChannelID: channelID, Content: data.Content}, nil
+}
+
+func (f *fakeSession) ChannelMessageEdit(channelID, messageID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.edited = append(f.edited, sentMessage{ChannelID: channelID, Content: content})
+	return &discordgo.Message{ID: messageID, ChannelID: channelID, Content: content}, nil
+}
+
+func (f *fakeSession) ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if m.Flags&discordgo.MessageFlagsSuppressEmbeds != 0 {
+		f.suppressed = append(f.suppressed, m.ID)
+	}
+	return &discordgo.Message{ID: m.ID, ChannelID: m.Channel, Flags: m.Flags}, nil
+}
+
+func (f *fakeSession) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, messageID)
+	return nil
+}
+
+func (f *fakeSession) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.channels[channelID]; ok {
+		return c, nil
+	}
+	return &discordgo.Channel{ID: channelID, Type: discordgo.ChannelTypeGuildText}, nil
+}
+
+func (f *fakeSession) MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.reactErrs) > 0 {
+		err := f.reactErrs[0]
+		f.reactErrs = f.reactErrs[1:]
+		return err
+	}
+	f.reactions = append(f.reactions, messageID+" "+emojiID)
+	return nil
+}
+
+func (f *fakeSession) ChannelMessageCrosspost(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.crossposted = append(f.crossposted, messageID)
+	return &discordgo.Message{ID: messageID, ChannelID: channelID}, nil
+}
+
+func (f *fakeSession) ChannelVoiceJoin(gID, cID string, mute, deaf bool) (*discordgo.VoiceConnection, error) {
+	return nil, fmt.Errorf("can't join voice channel %s", cID)
+}
+
+func (f *fakeSession) ThreadJoin(id string, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.joined = append(f.joined, id)
+	return nil
+}
+
+func (f *fakeSession) MessageThreadStartComplex(channelID, messageID string, data *discordgo.ThreadStart, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.threads = append(f.threads, messageID)
+	return &discordgo.Channel{ID: "thread-" + messageID, ParentID: channelID, Type: discordgo.ChannelTypeGuildPublicThread, Name: data.Name}, nil
+}
+
+func (f *fakeSession) ChannelEditComplex(channelID string, data *discordgo.ChannelEdit, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if data.Archived != nil && *data.Archived {
+		f.archived = append(f.archived, channelID)
+	}
+	return &discordgo.Channel{ID: channelID}, nil
+}
+
+func (f *fakeSession) GuildLeave(guildID string, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.left = append(f.left, guildID)
+	return nil
+}
+
+func (f *fakeSession) GuildMemberRoleAdd(guildID, userID, roleID string, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.roles = append(f.roles, guildID+"/"+userID+"/"+roleID)
+	return nil
+}
+
+func (f *fakeSession) RequestGuildMembersList(guildID string, userIDs []string, limit int, nonce string, presences bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requestedMembers = append(f.requestedMembers, userIDs...)
+	return nil
+}
+
+// UserChannelCreate returns the DM channel "dm-<recipientID>".
+func (f *fakeSession) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+// Crossposted returns a copy of the IDs of published messages.
+func (f *fakeSession) Crossposted() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.crossposted...)
+}
+
+// Edited returns a copy of the recorded edits.
+func (f *fakeSession) Edited() []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMessage(nil), f.edited...)
+}
+
+// Reactions returns a copy of the recorded reactions.
+func (f *fakeSession) Reactions() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.reactions...)
+}
+
+// Sent returns a copy of the recorded sends.
+func (f *fakeSession) Sent() []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMessage(nil), f.sent...)
+}
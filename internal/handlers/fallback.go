@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/syndication"
+	"go-discord-bot/internal/timestamp"
+)
+
+// scheduleFallback checks back on a repost once Discord has had time to embed
+// it, and posts the text of the tweets it didn't embed, which happens when the
+// fixing proxies are down. authorID is who posted the original message, in guildID.
+func (h *Handler) scheduleFallback(ctx context.Context, s Session, guildID string, repost *discordgo.Message, authorID string) {
+	if h.Fallback == nil {
+		return
+	}
+	var ids []string
+	for _, match := range patterns.TweetID.FindAllStringSubmatch(repost.Content, -1) {
+		if !slices.Contains(ids, match[1]) {
+			ids = append(ids, match[1])
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	record := explain.FromContext(ctx)
+	delay := h.PreviewDelay
+	if delay <= 0 {
+		delay = DefaultPreviewDelay
+	}
+	h.previewTimers.Add(1)
+	time.AfterFunc(delay, func() {
+		h.previewTimers.Add(-1)
+		h.whenAvailable(guildID, func() {
+			if !h.Pool.Submit(repost.ChannelID, func() { h.postFallback(record, s, repost, authorID, ids) }) {
+				correlation.Println(ctx, "Worker queue full, dropping fallback check for message", repost.ID)
+			}
+		})
+	})
+}
+
+// postFallback replies to a repost with the text of the tweets among tweetIDs
+// it has no embed for, unless it has since been deleted or had its embeds
+// suppressed.
+func (h *Handler) postFallback(record *explain.Record, s Session, repost *discordgo.Message, authorID string, tweetIDs []string) {
+	ctx, cancel := h.operation()
+	defer cancel()
+
+	current, err := s.ChannelMessage(repost.ChannelID, repost.ID, discordgo.WithContext(ctx))
+	if err != nil {
+		record.Note("fallback check", "the repost is gone")
+		return
+	}
+	if current.Flags&discordgo.MessageFlagsSuppressEmbeds != 0 {
+		record.Note("fallback check", "embeds were suppressed")
+		return
+	}
+	missing := slices.DeleteFunc(slices.Clone(tweetIDs), func(id string) bool { return embedsTweet(current.Embeds, id) })
+	if len(missing) == 0 {
+		record.Note("fallback check", "Discord embedded the fixed tweets")
+		return
+	}
+
+	var quotes []string
+	for _, id := range missing {
+		tweet, err := h.Fallback.Tweet(ctx, id)
+		if err != nil {
+			log.Println("Error fetching tweet text:", err)
+			continue
+		}
+		quotes = append(quotes, quoteTweet(tweet))
+	}
+	record.Note("fallback check", fmt.Sprintf("posted the text of %d of %d tweets Discord didn't embed", len(quotes), len(missing)))
+	if len(quotes) == 0 {
+		return
+	}
+
+	h.send(ctx, s, repost.ChannelID, &discordgo.MessageSend{
+		Content:         chunk.Split(strings.Join(quotes, "\n\n"), chunk.MaxMessageLength)[0],
+		Reference:       repost.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Components:      []discordgo.MessageComponent{commands.RemoveButton(authorID)},
+	})
+}
+
+// embedsTweet reports whether one of embeds shows the tweet with the given ID.
+func embedsTweet(embeds []*discordgo.MessageEmbed, tweetID string) bool {
+	return slices.ContainsFunc(embeds, func(embed *discordgo.MessageEmbed) bool {
+		match := patterns.TweetID.FindStringSubmatch(embed.URL)
+		return match != nil && match[1] == tweetID
+	})
+}
+
+// quoteTweet renders a tweet as a quote block followed by who posted it.
+func quoteTweet(tweet *syndication.Tweet) string {
+	lines := strings.Split(tweet.Text, "\n")
+	for n, line := range lines {
+		lines[n] = "> " + line
+	}
+	attribution := fmt.Sprintf("— %s (@%s)", tweet.User.Name, tweet.User.ScreenName)
+	if !tweet.CreatedAt.IsZero() {
+		attribution += ", " + timestamp.Format(tweet.CreatedAt, timestamp.Relative)
+	}
+	// Angle brackets keep Discord from trying to embed the tweet again
+	attribution += fmt.Sprintf(" <https://x.com/%s/status/%s>", tweet.User.ScreenName, tweet.ID)
+	return strings.Join(lines, "\n") + "\n" + attribution
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/patterns"
+)
+
+// withFeedLinks returns a message posted by one of its guild's feed sources
+// with the Twitter/X links in its embeds added to its content, so they're
+// fixed like those of any other message. The embeds are dropped: they're the
+// source's own, not previews of the links. Other messages are returned as
+// they are, along with false.
+func (h *Handler) withFeedLinks(m *discordgo.Message) (*discordgo.Message, bool) {
+	if m.Author == nil || !m.Author.Bot || len(m.Embeds) == 0 || m.GuildID == "" {
+		return m, false
+	}
+	if _, ok := h.guildConfig(m.GuildID).FeedSources[m.Author.ID]; !ok {
+		return m, false
+	}
+	links := embedTwitterLinks(m.Embeds)
+	links = slices.DeleteFunc(links, func(link string) bool { return strings.Contains(m.Content, link) })
+	if len(links) == 0 {
+		return m, false
+	}
+	feed := *m
+	feed.Content = strings.TrimSpace(m.Content + "\n" + strings.Join(links, "\n"))
+	feed.Embeds = nil
+	return &feed, true
+}
+
+// embedTwitterLinks returns the Twitter/X status links in embeds' links,
+// descriptions and fields, without repeats.
+func embedTwitterLinks(embeds []*discordgo.MessageEmbed) []string {
+	var links []string
+	add := func(text string) {
+		for _, link := range patterns.TwitterStatusLink.FindAllString(text, -1) {
+			if !slices.Contains(links, link) {
+				links = append(links, link)
+			}
+		}
+	}
+	for _, embed := range embeds {
+		add(embed.URL)
+		add(embed.Description)
+		for _, field := range embed.Fields {
+			add(field.Value)
+		}
+	}
+	return links
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/events"
+	"go-discord-bot/internal/patterns"
+)
+
+// filtered returns why the guild's content filters keep a message's fixed
+// links from being reposted, empty if they don't. Accounts are read from the
+// links themselves; keywords need each tweet's text, so tweets the guild has no
+// lookups left for aren't checked for them.
+func (h *Handler) filtered(ctx context.Context, guildID string, cfg config.Guild, changed, tweetIDs []string) string {
+	if !cfg.Filters() {
+		return ""
+	}
+	for _, link := range changed {
+		if groups := patterns.TweetAuthor.FindStringSubmatch(link); groups != nil && cfg.BlocksAccount(groups[1]) {
+			return "a tweet by @" + groups[1] + ", a blocked account"
+		}
+	}
+	if len(cfg.BlockedKeywords) == 0 || h.Tweets == nil {
+		return ""
+	}
+	for _, id := range tweetIDs {
+		if !h.Budget.Spend(guildID, budget.Lookups, 1) {
+			break
+		}
+		tweet, err := h.Tweets.Status(ctx, id)
+		if err != nil {
+			correlation.Println(ctx, "Error fetching tweet to filter:", err)
+			continue
+		}
+		if cfg.BlocksAccount(tweet.Author.ScreenName) {
+			return "a tweet by @" + tweet.Author.ScreenName + ", a blocked account"
+		}
+		if keyword := cfg.BlockedKeyword(tweet.Text); keyword != "" {
+			return fmt.Sprintf("a tweet mentioning %q, a blocked keyword", keyword)
+		}
+	}
+	return ""
+}
+
+// reportFiltered records that the guild's content filters kept a message's
+// links from being reposted and tells moderators or removes the message, in
+// guilds that chose to.
+func (h *Handler) reportFiltered(ctx context.Context, s Session, m *discordgo.Message, cfg config.Guild, why string) {
+	h.Events.Publish(events.Event{Type: events.Filtered, GuildID: m.GuildID, ChannelID: m.ChannelID, MessageID: m.ID, Detail: why})
+	switch cfg.FilterAction {
+	case config.FilterFlag:
+		h.audit(s, m.GuildID, fmt.Sprintf("Didn't fix the links <@%s> posted in https://discord.com/channels/%s/%s/%s: they're to %s.", m.Author.ID, m.GuildID, m.ChannelID, m.ID, why))
+	case config.FilterRemove:
+		h.remove(ctx, s, m, cfg, "filtered", why)
+	}
+}
+
+// removeBlocked removes a message linking to a tweet by one of the guild's
+// blocked accounts, in guilds that remove them, whether or not its links would
+// be fixed. It reports whether the message is gone.
+func (h *Handler) removeBlocked(ctx context.Context, s Session, m *discordgo.Message, cfg config.Guild) bool {
+	if cfg.FilterAction != config.FilterRemove || len(cfg.BlockedAccounts) == 0 || m.GuildID == "" {
+		return false
+	}
+	account := blockedAuthor(cfg, m.Content)
+	if account == "" {
+		return false
+	}
+	why := "a tweet by @" + account + ", a blocked account"
+	if !h.remove(ctx, s, m, cfg, "filtered", why) {
+		// Without Manage Messages its links are still left unfixed
+		return false
+	}
+	h.Events.Publish(events.Event{Type: events.Filtered, GuildID: m.GuildID, ChannelID: m.ChannelID, MessageID: m.ID, Detail: why})
+	return true
+}
+
+// blockedAuthor returns the screen name of the first blocked account content
+// links a tweet by, empty if it links none.
+func blockedAuthor(cfg config.Guild, content string) string {
+	for _, link := range patterns.TwitterStatusLink.FindAllString(content, -1) {
+		if groups := patterns.TweetAuthor.FindStringSubmatch(link); groups != nil && cfg.BlocksAccount(groups[1]) {
+			return groups[1]
+		}
+	}
+	return ""
+}
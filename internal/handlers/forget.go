@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"strings"
+
+	"go-discord-bot/internal/storage"
+)
+
+// ForgetStarboard deletes from st, for purges, which of guildID's messages
+// are on its starboard when userID is empty, and returns how many records it
+// deleted. Starboard records hold nothing about members.
+func ForgetStarboard(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" || userID != "" {
+		return 0, nil
+	}
+	return forgetRecords(st, StarboardBucket, func(key string, _ starPost) bool {
+		return strings.HasPrefix(key, guildID+"/")
+	})
+}
+
+// ForgetExpiries deletes from st, for purges, guildID's reposts waiting to be
+// deleted when userID is empty, and returns how many it deleted.
+func ForgetExpiries(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" || userID != "" {
+		return 0, nil
+	}
+	return forgetRecords(st, ExpiryBucket, func(_ string, e expiry) bool {
+		return e.GuildID == guildID
+	})
+}
+
+// ForgetPreviewChecks deletes from st, for purges, guildID's messages waiting
+// to be checked for embeds, or with userID those that member posted, in
+// guildID or in every guild if guildID is empty. It returns how many it
+// deleted.
+func ForgetPreviewChecks(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" && userID == "" {
+		return 0, nil
+	}
+	return forgetRecords(st, PreviewCheckBucket, func(_ string, c previewCheck) bool {
+		return (guildID == "" || c.GuildID == guildID) && (userID == "" || c.AuthorID == userID)
+	})
+}
+
+// ForgetThreads deletes from st, for purges, the threads the bot started in
+// guildID when userID is empty, and returns how many it deleted. The threads
+// are the bot's own, so forgetting a member removes nothing.
+func ForgetThreads(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" || userID != "" {
+		return 0, nil
+	}
+	return forgetRecords(st, ThreadBucket, func(_ string, t fixThread) bool {
+		return t.GuildID == guildID
+	})
+}
+
+// forgetRecords deletes the records in bucket that match, and returns how
+// many it deleted.
+func forgetRecords[T any](st storage.Store, bucket string, match func(key string, record T) bool) (int, error) {
+	deleted := 0
+	for _, key := range st.Keys(bucket) {
+		var record T
+		if _, err := st.Get(bucket, key, &record); err != nil {
+			return deleted, err
+		}
+		if !match(key, record) {
+			continue
+		}
+		if err := st.Delete(bucket, key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// withSnapshot returns a forwarded message with the content and embeds of the
+// message it forwards, so its links are fixed like those of any other
+// message. Other messages are returned as they are.
+func withSnapshot(m *discordgo.Message) *discordgo.Message {
+	ref := m.MessageReference
+	if ref == nil || ref.Type != discordgo.MessageReferenceTypeForward || len(m.MessageSnapshots) == 0 || m.MessageSnapshots[0].Message == nil {
+		return m
+	}
+	snapshot := m.MessageSnapshots[0].Message
+	forward := *m
+	forward.Content = snapshot.Content
+	// Forwards can't carry text of their own yet, but keep any that shows up
+	if m.Content != "" {
+		forward.Content = m.Content + "\n" + snapshot.Content
+	}
+	forward.Embeds = slices.Concat(m.Embeds, snapshot.Embeds)
+	return &forward
+}
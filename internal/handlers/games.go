@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/gamestores"
+	"go-discord-bot/internal/patterns"
+)
+
+// maxGames is how many games linked in one message are embedded.
+const maxGames = 3
+
+// maxGameDescription is how much of a game's description is shown, in runes.
+const maxGameDescription = 300
+
+// storeColors are the colors of game embeds by store.
+var storeColors = map[string]int{
+	gamestores.Steam: 0x1b2838,
+	gamestores.Itch:  0xfa5c5c,
+}
+
+// gameLink is a game linked from a message.
+type gameLink struct {
+	store string
+	// id is the Steam app ID, or the itch.io creator and game.
+	id []string
+}
+
+// embedGames replies to a message with the price, discount and genres of the
+// Steam and itch.io games it links to, for guilds that want it. Links in
+// angle brackets are left alone.
+func (h *Handler) embedGames(ctx context.Context, s Session, m *discordgo.MessageCreate, cfg config.Guild) {
+	if h.Games == nil || !cfg.GameEmbeds || !patterns.HasLink(m.Content) {
+		return
+	}
+	links := gameLinks(m.Content)
+	if len(links) == 0 {
+		return
+	}
+
+	var embeds []*discordgo.MessageEmbed
+	for _, link := range links {
+		if len(embeds) == maxGames || !h.Budget.Spend(m.GuildID, budget.Lookups, 1) {
+			break
+		}
+		var game *gamestores.Game
+		var err error
+		if link.store == gamestores.Steam {
+			game, err = h.Games.Steam(ctx, link.id[0])
+		} else {
+			game, err = h.Games.Itch(ctx, link.id[0], link.id[1])
+		}
+		if err != nil {
+			correlation.Println(ctx, "Error looking up game:", err)
+			continue
+		}
+		embeds = append(embeds, gameEmbed(game))
+	}
+	explain.Note(ctx, "games", fmt.Sprintf("embedded %d of %d games", len(embeds), len(links)))
+	if len(embeds) == 0 {
+		return
+	}
+	h.send(ctx, s, m.ChannelID, &discordgo.MessageSend{
+		Embeds:          embeds,
+		Reference:       m.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Components:      []discordgo.MessageComponent{commands.RemoveButton(m.Author.ID)},
+	})
+}
+
+// gameLinks returns the distinct Steam and itch.io games linked in content
+// outside angle brackets.
+func gameLinks(content string) []gameLink {
+	var links []gameLink
+	for _, link := range patterns.URL.FindAllString(content, -1) {
+		if strings.HasPrefix(link, "<") && strings.HasSuffix(link, ">") {
+			continue
+		}
+		link = strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">")
+		var game gameLink
+		if match := patterns.SteamApp.FindStringSubmatch(link); match != nil {
+			game = gameLink{store: gamestores.Steam, id: match[1:]}
+		} else if match := patterns.ItchGame.FindStringSubmatch(strings.ToLower(link)); match != nil {
+			game = gameLink{store: gamestores.Itch, id: match[1:]}
+		} else {
+			continue
+		}
+		if !slices.ContainsFunc(links, func(l gameLink) bool { return l.store == game.store && slices.Equal(l.id, game.id) }) {
+			links = append(links, game)
+		}
+	}
+	return links
+}
+
+// gameEmbed renders a game: its title, the start of its description, its
+// price with any discount, its genres and its image, colored by its store.
+func gameEmbed(game *gamestores.Game) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  game.Title,
+		URL:    game.URL,
+		Color:  storeColors[game.Store],
+		Footer: &discordgo.MessageEmbedFooter{Text: game.Store},
+	}
+	if game.Description != "" {
+		embed.Description = shorten(game.Description, maxGameDescription)
+	}
+	if price := formatPrice(game); price != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Price", Value: price, Inline: true})
+	}
+	if len(game.Genres) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Genres", Value: strings.Join(game.Genres, ", "), Inline: true})
+	}
+	if game.Image != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: game.Image}
+	}
+	return embed
+}
+
+// formatPrice shows what a game costs, such as "~~$9.99~~ **$1.99** (-80%)"
+// while it's discounted, or "" if its store didn't say.
+func formatPrice(game *gamestores.Game) string {
+	if game.Discount == 0 || game.OriginalPrice == "" {
+		return game.Price
+	}
+	return fmt.Sprintf("~~%s~~ **%s** (-%d%%)", game.OriginalPrice, game.Price, game.Discount)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"log"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// leaveNotice is posted in a guild before the bot leaves it for not being one
+// of the guilds it's limited to.
+const leaveNotice = "Hi! Whoever runs this bot has only set it up for certain servers, so it's leaving this one. Sorry for the trouble, and thanks for trying it!"
+
+// leaveOutside leaves g, after posting leaveNotice in it, if the handler is
+// set to leave guilds outside those it's limited to and g is one. It reports
+// whether it does.
+func (h *Handler) leaveOutside(s Session, g *discordgo.Guild) bool {
+	if !h.Leave || !h.outside(g.ID) {
+		return false
+	}
+	job := func() {
+		ctx, cancel := h.operation()
+		defer cancel()
+		if channelID := noticeChannel(g); channelID != "" {
+			// The bot may not be allowed to post there, which doesn't stop it leaving
+			msg := &discordgo.MessageSend{Content: leaveNotice, AllowedMentions: &discordgo.MessageAllowedMentions{}}
+			if _, err := s.ChannelMessageSendComplex(channelID, msg, discordgo.WithContext(ctx)); err != nil {
+				log.Println("Error posting leave notice:", err)
+			}
+		}
+		err := h.Retry.Do(ctx, "leave guild", func() error {
+			return s.GuildLeave(g.ID, discordgo.WithContext(ctx))
+		})
+		if err != nil {
+			log.Println("Error leaving guild:", err)
+			return
+		}
+		log.Printf("Left guild %s (%s), which isn't in ALLOWED_GUILDS\n", g.ID, g.Name)
+	}
+	if !h.Pool.Submit(g.ID, job) {
+		log.Println("Worker queue full, not leaving guild", g.ID)
+	}
+	return true
+}
+
+// noticeChannel picks where to tell a guild something: its system channel,
+// or else its topmost text channel. It's empty if the guild has neither.
+func noticeChannel(g *discordgo.Guild) string {
+	if g.SystemChannelID != "" {
+		return g.SystemChannelID
+	}
+	var text []*discordgo.Channel
+	for _, ch := range g.Channels {
+		if ch.Type == discordgo.ChannelTypeGuildText {
+			text = append(text, ch)
+		}
+	}
+	if len(text) == 0 {
+		return ""
+	}
+	return slices.MinFunc(text, func(a, b *discordgo.Channel) int { return a.Position - b.Position }).ID
+}
@@ -0,0 +1,761 @@
+// Package handlers contains the Discord gateway event handlers.
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/abuse"
+	"go-discord-bot/internal/access"
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/canonical"
+	"go-discord-bot/internal/channels"
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/crosspost"
+	"go-discord-bot/internal/dedupe"
+	"go-discord-bot/internal/digest"
+	"go-discord-bot/internal/events"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/flood"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/gamestores"
+	"go-discord-bot/internal/lifecycle"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/maintenance"
+	"go-discord-bot/internal/members"
+	"go-discord-bot/internal/mirror"
+	"go-discord-bot/internal/outages"
+	"go-discord-bot/internal/outbound"
+	"go-discord-bot/internal/overlap"
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/pending"
+	"go-discord-bot/internal/phishing"
+	"go-discord-bot/internal/preview"
+	"go-discord-bot/internal/report"
+	"go-discord-bot/internal/responders"
+	"go-discord-bot/internal/retry"
+	"go-discord-bot/internal/stats"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/syndication"
+	"go-discord-bot/internal/templates"
+	"go-discord-bot/internal/tracing"
+	"go-discord-bot/internal/trash"
+	"go-discord-bot/internal/trends"
+	"go-discord-bot/internal/unshorten"
+	"go-discord-bot/internal/voice"
+	"go-discord-bot/internal/workerpool"
+)
+
+// maxRepostMessages is how many messages a single repost may be split into
+// before the handler falls back to posting only the rewritten links.
+const maxRepostMessages = 3
+
+// Handler reacts to messages posted in channels the bot can see.
+type Handler struct {
+	// Name identifies the bot in the state the handler saves, so bots
+	// sharing a store don't pick up each other's work.
+	Name string
+	// Fixers is the link pipeline every message is run through.
+	Fixers fixers.Pipeline
+	// Pool runs message processing off the discordgo event goroutine.
+	Pool *workerpool.Pool
+	// Context is the parent of every operation the handler starts and is
+	// cancelled when the bot shuts down. Nil means context.Background.
+	Context context.Context
+	// Timeout bounds how long fixing a single message may take, 0 for no limit.
+	Timeout time.Duration
+	// Retry controls how failed Discord calls are retried and reported.
+	// The zero value tries once and logs failures.
+	Retry retry.Policy
+	// Store holds the per-guild settings. Nil means every guild uses the defaults.
+	Store storage.Store
+	// Duplicates remembers recently fixed tweets so a tweet fixed anywhere in a
+	// guild links to the earlier fix instead of being reposted, and recently
+	// fixed messages so ones Discord sends again after a reconnect aren't
+	// fixed twice. Nil disables this.
+	Duplicates *dedupe.Tracker
+	// Claims decides which of the bot's replicas handles each message, so
+	// only one of them acts on it. Nil leaves every message to this one.
+	Claims *dedupe.Claims
+	// Flood suspends the handler in channels receiving a burst of messages,
+	// so the bot doesn't amplify raids or spam. Nil disables this.
+	Flood *flood.Monitor
+	// Guilds limits the handler to these guilds, such as a test server for a
+	// dev profile, empty for every guild. Leave makes it leave other guilds,
+	// after saying why, rather than ignore them.
+	Guilds []string
+	Leave  bool
+	// Abuse ignores users who trigger the bot far more often than anyone
+	// normally would. Nil ignores nobody.
+	Abuse *abuse.Detector
+	// Tweets looks up quoted and parent tweets for guilds that show them under
+	// fixed tweets. Nil disables this.
+	Tweets *fxtwitter.Client
+	// Stats counts reposts per guild. Nil disables this.
+	Stats *stats.Collector
+	// Events receives every fix as it happens. Nil disables this.
+	Events *events.Bus
+	// Previews builds previews for links Discord didn't embed, in guilds that
+	// turned them on. Nil disables this.
+	Previews *preview.Scraper
+	// Phishing flags links to known phishing and malware sites, which are
+	// warned about or deleted instead of fixed. Nil disables this.
+	Phishing *phishing.Checker
+	// Unshortener expands shortened links for guilds that want to see where
+	// they go. Nil disables this.
+	Unshortener *unshorten.Expander
+	// AuthorPermissions returns the permissions the author of a message has in
+	// another channel of its guild, or false if they aren't known, so links to
+	// messages are only quoted to those who can read them. Nil disables
+	// quoting message links.
+	AuthorPermissions func(m *discordgo.Message, channelID string) (int64, bool)
+	// Games looks up the Steam and itch.io games linked in guilds that want
+	// their prices shown. Nil disables this.
+	Games *gamestores.Client
+	// Canonical remembers where shortened links went, across restarts, so
+	// they're only expanded once. Nil expands every link.
+	Canonical *canonical.Cache
+	// Budget limits the lookups and mirrored media each guild gets a day;
+	// past it, links are fixed without them. Nil allows everything.
+	Budget *budget.Budget
+	// Digest copies fixed tweet links to the digest channel of guilds that
+	// have one. Nil disables this.
+	Digest *digest.Digest
+	// Crossposts publishes messages in announcement channels for guilds that
+	// turned it on. Nil disables this.
+	Crossposts *crosspost.Publisher
+	// Voice plays a notice in the voice channel of guilds that set one when
+	// a link is fixed. Nil disables this.
+	Voice *voice.Announcer
+	// Pending holds the fixes of guilds in reaction mode until someone reacts
+	// for them. Nil makes those guilds repost right away.
+	Pending *pending.Tracker
+	// Channels knows the type of each channel and the channel each thread
+	// is in, so threads follow the settings of their channels and voice chats
+	// are left alone. Nil treats every channel as a plain text channel.
+	Channels *channels.Cache
+	// Members remembers server nicknames and avatars, so reposts and the
+	// starboard show people as their server does. Nil uses global names.
+	Members *members.Cache
+	// Others notices other link-fixing bots, which the guilds that want it
+	// leave links to. Nil only leaves links to the bots admins listed.
+	Others *overlap.Detector
+	// Outages knows which guilds Discord can't reach right now, and holds
+	// the preview and fallback checks due in them until they're back. Nil
+	// runs them anyway.
+	Outages *outages.Tracker
+	// Confirmations holds the fixes of guilds in confirm mode until their
+	// authors post or drop them. Nil makes those guilds repost right away.
+	Confirmations *pending.Tracker
+	// Mirror downloads the media of fixed tweets, for guilds that keep copies
+	// of it under their reposts. Nil disables this.
+	Mirror *mirror.Downloader
+	// Trash keeps copies of the messages the bot deletes, outside guilds in
+	// privacy mode, so moderators can restore them. Nil deletes them for good.
+	Trash *trash.Bin
+	// Tracer traces how each message is handled, from the event to the API
+	// calls it leads to. Nil disables this.
+	Tracer *tracing.Tracer
+	// Decisions records the checks each message went through and what the
+	// handler decided, to explain why a link was or wasn't fixed. Nil
+	// disables this.
+	Decisions *explain.Log
+	// Fallback fetches the text of fixed tweets Discord didn't embed, as when
+	// the fixing proxies are down, to post it under the repost. Nil disables this.
+	Fallback *syndication.Client
+	// Trends counts the links shared in each guild for /trends. Nil counts nothing.
+	Trends *trends.Counter
+	// Maintenance holds messages instead of fixing them while the bot is in
+	// maintenance, to fix them once it ends. Nil never holds them.
+	Maintenance *maintenance.Mode
+	// Modules says which of the fixers, greeter and starboard modules the
+	// bot's owner disabled. Nil has them all enabled.
+	Modules *lifecycle.Manager
+	// Responses keeps auto-responses from being posted again and again in a
+	// channel. Nil lets them be.
+	Responses *responders.Cooldowns
+	// UploadLimit returns how much one message may upload in a guild, which
+	// grows with its boosts. Nil uses mirror.Limit everywhere.
+	UploadLimit func(guildID string) int64
+	// PreviewDelay is how long to wait for Discord's own embeds before
+	// previewing links, DefaultPreviewDelay when 0.
+	PreviewDelay time.Duration
+
+	// resumed makes sure saved preview checks and repost deletions are
+	// resumed only once, however often the bot reconnects.
+	resumed sync.Once
+	// previewTimers counts the preview and fallback checks waiting for their timers.
+	previewTimers atomic.Int64
+}
+
+// operation returns a context for one unit of work, bounded by h.Timeout.
+func (h *Handler) operation() (context.Context, context.CancelFunc) {
+	ctx := h.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if h.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, h.Timeout)
+}
+
+// outside reports whether a guild is outside those the handler is limited to.
+// Direct messages never are.
+func (h *Handler) outside(guildID string) bool {
+	return guildID != "" && len(h.Guilds) > 0 && !slices.Contains(h.Guilds, guildID)
+}
+
+// HandleMessageCreate does the work of MessageCreate against any Session.
+// botUserID is the bot's own user ID, used to ignore its own messages.
+func (h *Handler) HandleMessageCreate(s Session, botUserID string, m *discordgo.MessageCreate) {
+	// Ignore messages from the bot itself
+	if m.Author.ID == botUserID {
+		return
+	}
+	m = &discordgo.MessageCreate{Message: withSnapshot(m.Message)}
+	trace := h.trace("message create", m.Message)
+	h.Members.Remember(m.GuildID, m.Member, m.Author)
+
+	if h.outside(m.GuildID) {
+		trace.note("guilds", "the bot is limited to other servers")
+		trace.decide("outside")
+		return
+	}
+	h.watchOtherFixers(s, m)
+	if config.Paused(h.Store, m.GuildID) {
+		trace.note("pause", "the bot is paused in this server")
+		trace.decide("paused")
+		return
+	}
+	// Claimed before anything the bot does about it, so replicas don't
+	// each delete, answer or fix the same message
+	if !h.Claims.Claim(m.GuildID, m.ID) {
+		trace.note("replicas", "another replica of the bot is handling this message")
+		trace.decide("claimed by another replica")
+		return
+	}
+	if feed, ok := h.withFeedLinks(m.Message); ok {
+		m = &discordgo.MessageCreate{Message: feed}
+		trace.note("feed source", "fixing the Twitter/X links in its embeds")
+	}
+
+	// Ignore users and roles the guild's admins blocked
+	var roles []string
+	if m.Member != nil {
+		roles = m.Member.Roles
+	}
+	if config.Ignored(h.Store, m.GuildID, m.Author.ID, roles) {
+		trace.note("ignore list", "the author or one of their roles is ignored")
+		h.turnAway(trace, s, m, "ignored")
+		return
+	}
+	trace.note("ignore list", "not ignored")
+
+	// Stay quiet in channels that are being flooded
+	allowed, tripped := h.Flood.Observe(m.ChannelID)
+	if tripped {
+		log.Printf("Message flood in channel %s of guild %s, pausing replies there\n", m.ChannelID, m.GuildID)
+	}
+	if !allowed {
+		trace.note("flood protection", "replies in this channel are paused")
+		h.turnAway(trace, s, m, "flooded")
+		return
+	}
+
+	// Answer messages matching the guild's auto-responses, but not other
+	// bots, which might answer back
+	if !m.Author.Bot {
+		cfg := h.guildConfig(m.GuildID)
+		responses := cfg.AutoResponses
+		if cfg.ModuleEnabled(config.ModuleGreeting) {
+			responses = append(slices.Clip(responses), responders.Greeting)
+		}
+		if r, ok := responders.Match(responses, m.Content); ok {
+			if h.Responses.Take(m.ChannelID, r) {
+				ctx, cancel := h.operation()
+				defer cancel()
+				h.send(trace.context(ctx), s, m.ChannelID, &discordgo.MessageSend{
+					Content:         r.Response,
+					AllowedMentions: &discordgo.MessageAllowedMentions{},
+				})
+				trace.note("auto-responses", "answered "+r.Trigger)
+			} else {
+				trace.note("auto-responses", r.Trigger+" was answered here moments ago")
+			}
+			// Messages with links in them still have those fixed
+			if !patterns.HasLink(m.Content) {
+				trace.decide("auto-responded")
+				return
+			}
+		}
+	}
+
+	if !access.Check(h.Store, m.GuildID, access.Fixing, roles) {
+		trace.note("roles", "fixing is limited to roles the author doesn't have")
+		h.turnAway(trace, s, m, "not allowed")
+		return
+	}
+
+	// Messages with links are fixed after maintenance rather than not at all
+	if h.Maintenance.On() && patterns.HasLink(m.Content) {
+		held := h.Maintenance.Hold(maintenance.Held{Bot: h.Name, GuildID: m.GuildID, ChannelID: m.ChannelID, MessageID: m.ID})
+		if held {
+			trace.note("maintenance", "held until maintenance ends")
+			trace.decide("held for maintenance")
+		} else {
+			trace.note("maintenance", "too many messages are held already")
+			trace.decide("dropped")
+		}
+		return
+	}
+
+	// Fixing may involve slow lookups, so it runs on the worker pool
+	// keyed by channel to keep reposts in the order messages arrived
+	if !h.queue(trace, s, m) {
+		log.Println("Worker queue full, dropping message", m.ID)
+	}
+}
+
+// turnAway decides trace with decision for a message the bot won't fix.
+// Links to phishing sites are still caught, whoever posts them and however
+// busy the channel is, so a message with links is checked for them on the
+// worker pool first.
+func (h *Handler) turnAway(trace *handling, s Session, m *discordgo.MessageCreate, decision string) {
+	if h.Phishing == nil || m.GuildID == "" || !patterns.HasLink(m.Content) {
+		trace.decide(decision)
+		return
+	}
+	queued := h.Pool.Submit(m.ChannelID, func() {
+		ctx, cancel := h.operation()
+		defer cancel()
+		ctx, span := tracing.Start(trace.context(ctx), "check phishing")
+		defer func() {
+			span.End()
+			trace.decide(decision)
+		}()
+		if h.checkPhishing(ctx, s, m, h.guildConfig(m.GuildID)) {
+			trace.note("phishing", "links to a known phishing or malware site")
+			decision = "phishing"
+		}
+	})
+	if !queued {
+		trace.decide(decision)
+	}
+}
+
+// Reprocess runs a message through the fixers again, as if it had just been
+// posted, skipping the ignore and flood checks. It reports false if the work
+// queue is full.
+func (h *Handler) Reprocess(s Session, m *discordgo.Message) bool {
+	return h.queue(h.trace("reprocess", m), s, &discordgo.MessageCreate{Message: m})
+}
+
+// handling follows one message through the handler: a span for the tracer
+// and a record for the decision log, both finished once the handler decides
+// what to do with it, and breadcrumbs for errors reported along the way.
+type handling struct {
+	// id is the correlation ID of the message's log lines, span and reports.
+	id     string
+	span   *tracing.Span
+	record *explain.Record
+	crumbs *report.Breadcrumbs
+	// priority is what the bot's replies are sent at.
+	priority outbound.Priority
+}
+
+// trace starts following how m, brought to the handler by source, is handled.
+func (h *Handler) trace(source string, m *discordgo.Message) *handling {
+	id := correlation.New()
+	_, span := h.Tracer.Start(context.Background(), source, tracing.String("correlation.id", id),
+		tracing.String("guild.id", m.GuildID), tracing.String("channel.id", m.ChannelID), tracing.String("message.id", m.ID))
+	var crumbs *report.Breadcrumbs
+	// Crumbs name tweets and fixers, which guilds in privacy mode keep out of the logs
+	if !logging.Private(m.GuildID) {
+		crumbs = report.NewBreadcrumbs(report.DefaultBreadcrumbs)
+		crumbs.Add(source, "message "+m.ID)
+	}
+	return &handling{id: id, span: span, record: h.Decisions.Start(m, source, id), crumbs: crumbs}
+}
+
+// context returns ctx carrying the correlation ID, span, record, breadcrumbs
+// and priority, so the work it's passed to is traced, can note its steps and
+// waits its turn to reply.
+func (t *handling) context(ctx context.Context) context.Context {
+	ctx = outbound.WithPriority(correlation.With(ctx, t.id), t.priority)
+	return report.WithBreadcrumbs(explain.WithRecord(tracing.WithSpan(ctx, t.span), t.record), t.crumbs)
+}
+
+// note records a check made on the message and what it found.
+func (t *handling) note(stage, result string) {
+	t.record.Note(stage, result)
+	t.crumbs.Add(stage, result)
+}
+
+// decide records what the handler did with the message and ends its span.
+func (t *handling) decide(decision string) {
+	t.span.Set(tracing.String("decision", decision))
+	t.span.End()
+	t.record.Decide(decision)
+}
+
+// queue fixes a message on the worker pool as part of trace, deciding trace
+// once it's done. It reports false if the work queue is full.
+func (h *Handler) queue(trace *handling, s Session, m *discordgo.MessageCreate) bool {
+	if h.Pool.Submit(m.ChannelID, func() { h.fixMessage(trace, s, m) }) {
+		return true
+	}
+	trace.decide("dropped")
+	return false
+}
+
+// fixMessage runs a message through the link fixers and reposts the result if
+// anything changed. Time spent queued shows as the gap between the start of
+// trace and its "fix message" span.
+func (h *Handler) fixMessage(trace *handling, s Session, m *discordgo.MessageCreate) {
+	ctx, cancel := h.operation()
+	defer cancel()
+	ctx, span := tracing.Start(trace.context(ctx), "fix message")
+	decision := "reposted"
+	defer func() {
+		span.End()
+		trace.decide(decision)
+	}()
+
+	cfg := h.guildConfig(m.GuildID)
+	// Dangerous links are caught in every channel, not just those with fixing on
+	if h.checkPhishing(ctx, s, m, cfg) {
+		trace.note("phishing", "links to a known phishing or malware site")
+		decision = "phishing"
+		return
+	}
+	if h.removeBlocked(ctx, s, m.Message, cfg) {
+		trace.note("content filters", "links to a tweet by a blocked account")
+		decision = "removed"
+		return
+	}
+	if cfg.Crosspost == config.CrosspostAll {
+		h.publish(ctx, s, m.Message)
+	}
+	if cfg.Skips(m.Content) {
+		trace.note("skip marker", "the message starts with "+cfg.Marker())
+		decision = "skipped"
+		return
+	}
+	h.countShared(m, cfg)
+	if why := h.channelSkip(ctx, s, cfg, m.GuildID, m.ChannelID); why != "" {
+		trace.note("channel", why)
+		decision = "channel disabled"
+		return
+	}
+	trace.note("channel", "fixing is on")
+	if cfg.TriggerEmoji != "" {
+		trace.note("trigger emoji", "links are only fixed when someone reacts with "+cfg.TriggerEmoji)
+		decision = "waiting for trigger"
+		return
+	}
+	if other := h.otherFixer(cfg, m.GuildID); other != "" {
+		trace.note("other fixers", fmt.Sprintf("links here are left to <@%s>", other))
+		decision = "yielded"
+		return
+	}
+	if why := beyondScanLimits(m.Content); why != "" {
+		trace.note("limits", why)
+		decision = "too long"
+		return
+	}
+	if !h.Modules.Enabled(lifecycle.Fixers) {
+		trace.note("modules", "the bot's owner disabled fixing links for now")
+		decision = "module disabled"
+		return
+	}
+	h.replyUnshortened(ctx, s, m, cfg)
+	h.quoteMessageLinks(ctx, s, m, cfg)
+	h.embedGames(ctx, s, m, cfg)
+	h.handleTwitterPages(ctx, s, m, cfg)
+
+	if len(cfg.DisabledFixers) > 0 {
+		trace.note("disabled fixers", strings.Join(cfg.DisabledFixers, ", "))
+	}
+	modifiedContent, origins := h.Fixers.Without(cfg.DisabledFixers).ApplyOrigins(ctx, m)
+	if ctx.Err() != nil {
+		correlation.Println(ctx, "Gave up fixing message", m.ID+":", ctx.Err())
+		span.Fail(ctx.Err())
+		decision = "timed out"
+		return
+	}
+
+	if modifiedContent == m.Content {
+		decision = "unchanged"
+		if h.attachGalleries(ctx, s, m, cfg) {
+			decision = "attached gallery"
+		}
+		h.schedulePreviews(trace.record, s, m, cfg)
+		return
+	}
+
+	if earlier, ok := h.Duplicates.Lookup(m.GuildID, fixedMessageKey(m.ID)); ok {
+		trace.note("duplicate", fmt.Sprintf("this message was already fixed in <#%s>", earlier.ChannelID))
+		decision = "already fixed"
+		return
+	}
+	if h.Muted(s, m.GuildID, m.Author.ID) {
+		trace.note("abuse", "the author is muted for using the bot far too often")
+		decision = "muted"
+		return
+	}
+	changed := fixers.ChangedLinks(m.Content, modifiedContent)
+	tweetIDs, earlier, ok := h.earlierRepost(m.GuildID, changed)
+	if why := h.filtered(ctx, m.GuildID, cfg, changed, tweetIDs); why != "" {
+		trace.note("content filters", why)
+		h.reportFiltered(ctx, s, m.Message, cfg, why)
+		decision = "filtered"
+		return
+	}
+	if ok {
+		trace.note("duplicate", fmt.Sprintf("every tweet was already fixed in <#%s>", earlier.ChannelID))
+		_, err := h.send(ctx, s, m.ChannelID, &discordgo.MessageSend{
+			Content:         fmt.Sprintf("Already fixed in <#%s>: https://discord.com/channels/%s/%s/%s", earlier.ChannelID, m.GuildID, earlier.ChannelID, earlier.MessageID),
+			Reference:       m.Reference(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+			Components:      []discordgo.MessageComponent{commands.RemoveButton(m.Author.ID)},
+		})
+		if err == nil {
+			h.Stats.RecordDuplicate(m.GuildID)
+			h.Events.Publish(events.Event{Type: events.Duplicate, GuildID: m.GuildID, ChannelID: m.ChannelID, MessageID: m.ID})
+			h.reactFixed(ctx, s, m.Message, cfg)
+		}
+		decision = "duplicate"
+		return
+	}
+	if h.Duplicates != nil {
+		trace.note("duplicate", "not fixed recently")
+	}
+
+	if cfg.RepostMode == config.RepostReaction && h.Pending != nil {
+		err := h.Retry.Do(ctx, "react to message", func() error {
+			return s.MessageReactionAdd(m.ChannelID, m.ID, linkEmoji, discordgo.WithContext(ctx))
+		})
+		if err == nil {
+			h.Pending.Add(m.Message, modifiedContent, origins)
+			trace.note("repost mode", "reaction, waiting for someone to react")
+			decision = "held for reaction"
+			return
+		}
+		// Without the reaction nobody could ask for the fix, so post it now
+	}
+	if cfg.RepostMode == config.RepostConfirm && h.Confirmations != nil {
+		if h.askToConfirm(ctx, s, m, modifiedContent, origins) {
+			trace.note("repost mode", "confirm, waiting for the author")
+			decision = "held for confirmation"
+			return
+		}
+		// Authors who don't take DMs can't confirm, so post it now
+	}
+	h.repost(ctx, s, m, cfg, modifiedContent, origins, changed, tweetIDs)
+}
+
+// repost posts the fixed version of a message, with its links shown in the
+// output style of the fixers in origins that fixed them, and records the fix.
+func (h *Handler) repost(ctx context.Context, s Session, m *discordgo.MessageCreate, cfg config.Guild, modifiedContent string, origins map[string]string, changed, tweetIDs []string) {
+	repost := modifiedContent
+	if cfg.RepostMode == config.RepostMinimal {
+		repost = strings.Join(changed, " ")
+	} else if cfg.RepostTemplate != "" {
+		repost = templates.Render(cfg.RepostTemplate, templates.Vars{
+			UserID:    m.Author.ID,
+			Name:      h.member(s, m.GuildID, m.Author, m.Member).DisplayName(),
+			ChannelID: m.ChannelID,
+			Links:     changed,
+			Message:   modifiedContent,
+		})
+	}
+	styles, embeds := h.outputStyles(ctx, m.GuildID, cfg, origins, changed)
+	pieces := repostMessages(m.Content, fixers.Render(repost, styles))
+	embeds = append(embeds, h.contextEmbeds(ctx, m.GuildID, tweetIDs, cfg.ContextDepth)...)
+	if len(embeds) > maxContextEmbeds {
+		embeds = embeds[:maxContextEmbeds]
+	}
+	summaries := h.textSummaries(ctx, m.GuildID, cfg, tweetIDs)
+	for _, line := range summaries {
+		pieces = withLine(pieces, line)
+	}
+	files, tooBig, media := h.mirrorMedia(ctx, m.GuildID, tweetIDs, cfg, memberRoles(m.Member))
+	if len(tooBig) > 0 {
+		pieces = withLine(pieces, tooBigNote(tooBig, h.uploadLimit(m.GuildID)))
+	}
+	channelID := m.ChannelID
+	if cfg.RepostMode == config.RepostThread {
+		if threadID := h.startThread(ctx, s, m, changed); threadID != "" {
+			channelID = threadID
+		}
+	}
+	for n, piece := range pieces {
+		msg := &discordgo.MessageSend{
+			Content:    piece,
+			Components: []discordgo.MessageComponent{commands.RemoveButton(m.Author.ID)},
+		}
+		// Context goes under the last piece, after the links it explains
+		if n == len(pieces)-1 {
+			msg.Embeds = embeds
+			msg.Files = files
+		}
+		// Templates can mention the author, and summaries whoever a tweet
+		// names, which shouldn't ping them
+		if cfg.RepostTemplate != "" || len(summaries) > 0 {
+			msg.AllowedMentions = &discordgo.MessageAllowedMentions{}
+		}
+		// A thread hangs off the original message already
+		if n == 0 && channelID == m.ChannelID && slices.Contains([]string{config.RepostReply, config.RepostReaction, config.RepostConfirm, config.RepostMinimal, config.RepostThread}, cfg.RepostMode) {
+			msg.Reference = m.Reference()
+			msg.AllowedMentions = &discordgo.MessageAllowedMentions{}
+		}
+		sent, err := h.send(ctx, s, channelID, msg)
+		if err != nil && len(msg.Files) > 0 {
+			// The links are what matters, post them even if the copies won't go,
+			// and link the media instead
+			correlation.Println(ctx, "Error uploading mirrored media:", err)
+			msg.Files = nil
+			if note := "Couldn't upload the media here, linked instead: " + strings.Join(media, " "); len(msg.Content)+1+len(note) <= chunk.MaxMessageLength {
+				msg.Content += "\n" + note
+			}
+			sent, err = h.send(ctx, s, channelID, msg)
+		}
+		if err != nil {
+			// Don't post the rest of a repost out of context
+			return
+		}
+		if cfg.Crosspost != "" {
+			h.publish(ctx, s, sent)
+		}
+		h.scheduleFallback(ctx, s, m.GuildID, sent, m.Author.ID)
+		h.scheduleExpiry(s, m.GuildID, sent, cfg)
+		if n != 0 {
+			continue
+		}
+		if cfg.RepostMode == config.RepostMinimal {
+			h.suppressEmbeds(ctx, s, m.Message)
+		}
+		h.Events.Publish(events.Event{Type: events.Fix, GuildID: m.GuildID, ChannelID: m.ChannelID, MessageID: m.ID, Detail: fmt.Sprintf("%d links", len(changed))})
+		if m.GuildID != "" {
+			h.Duplicates.Record(m.GuildID, fixedMessageKey(m.ID), sent.ChannelID, sent.ID)
+			for _, id := range tweetIDs {
+				h.Duplicates.Record(m.GuildID, id, sent.ChannelID, sent.ID)
+			}
+			h.Stats.RecordRepost(m.GuildID, m.Author.ID, changed)
+			h.reactFixed(ctx, s, m.Message, cfg)
+			h.archive(ctx, s, m, cfg, changed)
+			h.announce(s, m, cfg)
+		}
+	}
+}
+
+// earlierRepost returns the tweet IDs among the fixed links and, when every fixed
+// link is a tweet already fixed in the guild, the most recent repost of one of them.
+func (h *Handler) earlierRepost(guildID string, fixedLinks []string) ([]string, dedupe.Repost, bool) {
+	var ids []string
+	var latest dedupe.Repost
+	allSeen := guildID != "" && len(fixedLinks) > 0
+	for _, link := range fixedLinks {
+		match := patterns.TweetID.FindStringSubmatch(link)
+		if match == nil {
+			allSeen = false
+			continue
+		}
+		ids = append(ids, match[1])
+
+		r, ok := h.Duplicates.Lookup(guildID, match[1])
+		if !ok {
+			allSeen = false
+		} else if r.At.After(latest.At) {
+			latest = r
+		}
+	}
+	return ids, latest, allSeen
+}
+
+// archive copies the tweets among a message's fixed links to the guild's digest.
+func (h *Handler) archive(ctx context.Context, s Session, m *discordgo.MessageCreate, cfg config.Guild, fixedLinks []string) {
+	var entries []digest.Entry
+	for _, link := range fixedLinks {
+		if patterns.TweetID.MatchString(link) {
+			entries = append(entries, digest.Entry{Link: link, ChannelID: m.ChannelID, MessageID: m.ID, AuthorID: m.Author.ID, At: time.Now()})
+		}
+	}
+	if err := h.Digest.Record(ctx, s, m.GuildID, cfg, entries); err != nil {
+		correlation.Println(ctx, "Error archiving fixed links:", err)
+	}
+}
+
+// memberRoles returns the roles of member, none if it's nil as for messages
+// fetched over REST.
+func memberRoles(member *discordgo.Member) []string {
+	if member == nil {
+		return nil
+	}
+	return member.Roles
+}
+
+// guildConfig returns the settings for a guild, falling back to the defaults
+// when there is no store or the settings can't be read.
+func (h *Handler) guildConfig(guildID string) config.Guild {
+	if h.Store == nil {
+		return config.Guild{}
+	}
+	cfg, err := config.LoadGuild(h.Store, guildID)
+	if err != nil {
+		log.Println("Error loading guild config:", err)
+	}
+	return cfg
+}
+
+// repostMessages splits modified into messages that fit Discord's length limit.
+// If that would take more than maxRepostMessages, it posts only the links that
+// the fixers changed instead.
+func repostMessages(original, modified string) []string {
+	pieces := chunk.Split(modified, chunk.MaxMessageLength)
+	if len(pieces) <= maxRepostMessages {
+		return pieces
+	}
+
+	links := fixers.ChangedLinks(original, modified)
+	if len(links) == 0 {
+		return pieces[:maxRepostMessages]
+	}
+	pieces = chunk.Split(strings.Join(links, "\n"), chunk.MaxMessageLength)
+	if len(pieces) > maxRepostMessages {
+		pieces = pieces[:maxRepostMessages]
+	}
+	return pieces
+}
+
+// send posts a message to a channel, retrying transient failures.
+// Failures are reported through h.Retry and returned.
+func (h *Handler) send(ctx context.Context, s Session, channelID string, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	var sent *discordgo.Message
+	err := h.Retry.Do(ctx, "send message", func() error {
+		// Each attempt uploads attachments from the start
+		for _, f := range msg.Files {
+			if r, ok := f.Reader.(io.Seeker); ok {
+				r.Seek(0, io.SeekStart)
+			}
+		}
+		var err error
+		sent, err = s.ChannelMessageSendComplex(channelID, msg, discordgo.WithContext(ctx))
+		return err
+	})
+	return sent, err
+}
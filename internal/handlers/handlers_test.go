@@ -0,0 +1,2569 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/abuse"
+	"go-discord-bot/internal/access"
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/canonical"
+	"go-discord-bot/internal/channels"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/crosspost"
+	"go-discord-bot/internal/dedupe"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/flood"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/gamestores"
+	"go-discord-bot/internal/lifecycle"
+	"go-discord-bot/internal/maintenance"
+	"go-discord-bot/internal/members"
+	"go-discord-bot/internal/mirror"
+	"go-discord-bot/internal/outages"
+	"go-discord-bot/internal/overlap"
+	"go-discord-bot/internal/pending"
+	"go-discord-bot/internal/phishing"
+	"go-discord-bot/internal/preview"
+	"go-discord-bot/internal/retry"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/syndication"
+	"go-discord-bot/internal/tracing"
+	"go-discord-bot/internal/trash"
+	"go-discord-bot/internal/unshorten"
+	"go-discord-bot/internal/workerpool"
+)
+
+const testBotID = "bot"
+
+// newTestMessage builds a MessageCreate event from a user in channel "chan".
+func newTestMessage(authorID, content string, embeds ...*discordgo.MessageEmbed) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "msg",
+		ChannelID: "chan",
+		GuildID:   "guild",
+		Content:   content,
+		Author:    &discordgo.User{ID: authorID},
+		Embeds:    embeds,
+	}}
+}
+
+// runHandler feeds messages through HandleMessageCreate and waits for the worker pool to drain.
+func runHandler(t *testing.T, s Session, messages ...*discordgo.MessageCreate) {
+	t.Helper()
+
+	h := &Handler{
+		Fixers: fixers.Pipeline{
+			fixers.Twitter{},
+			fixers.Twitch{Proxy: "clips.fxtwitch.tv"},
+			fixers.Custom{Store: storage.NewMemory()},
+			fixers.Cleaner{},
+		},
+		Pool: workerpool.New(1, 10),
+	}
+	for _, m := range messages {
+		h.HandleMessageCreate(s, testBotID, m)
+	}
+	h.Pool.Stop()
+}
+
+func TestHandleMessageCreate(t *testing.T) {
+	workingEmbed := &discordgo.MessageEmbed{Image: &discordgo.MessageEmbedImage{URL: "https://pbs.twimg.com/media/abc.jpg"}}
+	brokenEmbed := &discordgo.MessageEmbed{Thumbnail: &discordgo.MessageEmbedThumbnail{URL: "https://abs.twimg.com/rweb/ssr/default/v2/og/image.png"}}
+
+	testCases := []struct {
+		name     string
+		message  *discordgo.MessageCreate
+		expected []sentMessage
+	}{
+		{
+			name:    "Own message is ignored",
+			message: newTestMessage(testBotID, "https://x.com/user/status/1"),
+		},
+		{
+			name:     "Hello gets a reply",
+			message:  newTestMessage("user", "hello"),
+			expected: []sentMessage{{ChannelID: "chan", Content: "world!"}},
+		},
+		{
+			name:    "Plain text is ignored",
+			message: newTestMessage("user", "just chatting"),
+		},
+		{
+			name:     "Tweet without embed is reposted",
+			message:  newTestMessage("user", "look https://x.com/user/status/1?s=20"),
+			expected: []sentMessage{{ChannelID: "chan", Content: "look https://fixupx.com/user/status/1", Removable: true}},
+		},
+		{
+			name:     "Tweet with broken embed is reposted",
+			message:  newTestMessage("user", "https://twitter.com/user/status/1", brokenEmbed),
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://fxtwitter.com/user/status/1", Removable: true}},
+		},
+		{
+			name:    "Tweet with working embed is left alone",
+			message: newTestMessage("user", "https://twitter.com/user/status/1", workingEmbed),
+		},
+		{
+			name:    "Bracketed tweet is left alone",
+			message: newTestMessage("user", "<https://twitter.com/user/status/1>"),
+		},
+		{
+			name:     "Twitch clip is reposted",
+			message:  newTestMessage("user", "https://clips.twitch.tv/Slug"),
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://clips.fxtwitch.tv/Slug", Removable: true}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeSession{}
+			runHandler(t, s, tc.message)
+
+			sent := s.Sent()
+			if len(sent) != len(tc.expected) {
+				t.Fatalf("sent %d messages %+v; want %d", len(sent), sent, len(tc.expected))
+			}
+			for i := range sent {
+				if sent[i] != tc.expected[i] {
+					t.Errorf("message %d = %+v; want %+v", i, sent[i], tc.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := &fakeSession{}
+	h := &Handler{
+		Fixers:  fixers.Pipeline{fixers.Twitter{}},
+		Pool:    workerpool.New(1, 10),
+		Context: ctx,
+	}
+	h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/1"))
+	h.Pool.Stop()
+
+	if len(s.Sent()) != 0 {
+		t.Errorf("sent %+v after shutdown; want nothing", s.Sent())
+	}
+}
+
+func TestHandleMessageCreateSendError(t *testing.T) {
+	s := &fakeSession{sendErrs: []error{errors.New("boom")}}
+	runHandler(t, s, newTestMessage("user", "https://x.com/user/status/1"))
+
+	if len(s.Sent()) != 0 {
+		t.Errorf("expected no recorded sends when sending fails")
+	}
+}
+
+func TestHandleMessageCreateRetriesTransientErrors(t *testing.T) {
+	serverError := &discordgo.RESTError{Response: &http.Response{StatusCode: 503, Header: http.Header{}}}
+	s := &fakeSession{sendErrs: []error{serverError, serverError}}
+
+	h := &Handler{
+		Fixers: fixers.Pipeline{fixers.Twitter{}},
+		Pool:   workerpool.New(1, 10),
+		Retry:  retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}
+	h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/1"))
+	h.Pool.Stop()
+
+	if sent := s.Sent(); len(sent) != 1 || sent[0].Content != "https://fixupx.com/user/status/1" {
+		t.Errorf("sent %+v after retries; want the fixed link once", sent)
+	}
+}
+
+func TestHandleMessageCreateSplitsLongReposts(t *testing.T) {
+	s := &fakeSession{}
+	content := strings.Repeat("a", 1990) + "\nhttps://x.com/user/status/1"
+	runHandler(t, s, newTestMessage("user", content))
+
+	sent := s.Sent()
+	if len(sent) != 2 || sent[0].Content != strings.Repeat("a", 1990) || sent[1].Content != "https://fixupx.com/user/status/1" {
+		t.Errorf("sent %d messages; want the text and the fixed link split in two", len(sent))
+	}
+}
+
+func TestHandleMessageCreatePathologicalMessages(t *testing.T) {
+	links := func(n int) string {
+		links := make([]string, n)
+		for i := range links {
+			links[i] = fmt.Sprintf("https://x.com/user/status/%d", i+1)
+		}
+		return strings.Join(links, " ")
+	}
+
+	testCases := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{name: "10k characters", content: strings.Repeat("word ", 2000) + "https://x.com/user/status/1", expected: []string{"https://fixupx.com/user/status/1"}},
+		{name: "Over the scanned length", content: strings.Repeat("word ", maxScanLength/5) + "https://x.com/user/status/1"},
+		{name: "As many links as scanned", content: links(maxScanLinks), expected: []string{strings.ReplaceAll(links(maxScanLinks), "x.com", "fixupx.com")}},
+		{name: "Hundreds of links", content: links(300)},
+		{name: "Hundreds of non-tweet links", content: strings.Repeat("https://example.com/page ", 300) + "https://x.com/user/status/1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeSession{}
+			runHandler(t, s, newTestMessage("user", tc.content))
+
+			var got []string
+			for _, sent := range s.Sent() {
+				got = append(got, sent.Content)
+			}
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("sent %d messages %.100q; want %.100q", len(got), got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWithAltText(t *testing.T) {
+	raw := json.RawMessage(`{"content": "look", "attachments": [
+		{"filename": "a.png", "description": "screenshot of https://x.com/user/status/1"},
+		{"filename": "b.png"},
+		{"filename": "c.png", "description": "same tweet, https://x.com/user/status/1 and <https://x.com/user/status/2>"}
+	]}`)
+
+	testCases := []struct {
+		name     string
+		content  string
+		raw      json.RawMessage
+		expected string
+	}{
+		{name: "Attachments only", raw: raw, expected: "https://x.com/user/status/1\n<https://x.com/user/status/2>"},
+		{name: "With text", content: "look", raw: raw, expected: "look\nhttps://x.com/user/status/1\n<https://x.com/user/status/2>"},
+		{name: "Link already in the text", content: "https://x.com/user/status/1", raw: raw, expected: "https://x.com/user/status/1\n<https://x.com/user/status/2>"},
+		{name: "No alt text", content: "look", raw: json.RawMessage(`{"attachments": [{"filename": "a.png"}]}`), expected: "look"},
+		{name: "Not a message", content: "look", raw: json.RawMessage(`[]`), expected: "look"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := withAltText(&discordgo.Message{Content: tc.content}, altTextLinks(tc.raw))
+			if m.Content != tc.expected {
+				t.Errorf("content = %q; want %q", m.Content, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRepostMessages(t *testing.T) {
+	filler := strings.Repeat("word ", 2000)
+	original := filler + "https://x.com/a/status/1 <https://x.com/b/status/2>"
+	modified := filler + "https://fixupx.com/a/status/1 <https://x.com/b/status/2>"
+
+	testCases := []struct {
+		name     string
+		original string
+		modified string
+		expected []string
+	}{
+		{name: "Short repost", original: "https://x.com/a/status/1", modified: "https://fixupx.com/a/status/1", expected: []string{"https://fixupx.com/a/status/1"}},
+		{name: "Too long falls back to links", original: original, modified: modified, expected: []string{"https://fixupx.com/a/status/1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := repostMessages(tc.original, tc.modified)
+			if strings.Join(result, "|") != strings.Join(tc.expected, "|") {
+				t.Errorf("repostMessages = %q; want %q", result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateGuildSettings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      config.Guild
+		content  string
+		expected []sentMessage
+	}{
+		{name: "Defaults", content: "https://x.com/user/status/1",
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true}}},
+		{name: "Other channel only", cfg: config.Guild{Channels: []string{"other"}}, content: "https://x.com/user/status/1",
+			expected: nil},
+		{name: "This channel enabled", cfg: config.Guild{Channels: []string{"other", "chan"}}, content: "https://x.com/user/status/1",
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true}}},
+		{name: "Fixer disabled", cfg: config.Guild{DisabledFixers: []string{"twitter"}}, content: "https://x.com/user/status/1",
+			expected: nil},
+		{name: "Ignored user", cfg: config.Guild{IgnoredUsers: []string{"user"}}, content: "hello",
+			expected: nil},
+		{name: "Ignored role", cfg: config.Guild{IgnoredRoles: []string{"relay"}}, content: "https://x.com/user/status/1",
+			expected: nil},
+		{name: "Greeting", content: "hello", expected: []sentMessage{{ChannelID: "chan", Content: "world!"}}},
+		{name: "Paused", cfg: config.Guild{Paused: true}, content: "https://x.com/user/status/1",
+			expected: nil},
+		{name: "Paused greeting", cfg: config.Guild{Paused: true}, content: "hello",
+			expected: nil},
+		{name: "Greeting disabled", cfg: config.Guild{DisabledModules: []string{config.ModuleGreeting}}, content: "hello",
+			expected: nil},
+		{name: "Auto-response", cfg: config.Guild{AutoResponses: []config.AutoResponse{{Trigger: "rules", Match: config.MatchContains, Response: "See <#rules>"}}},
+			content: "where are the Rules", expected: []sentMessage{{ChannelID: "chan", Content: "See <#rules>"}}},
+		{name: "Auto-response ahead of the greeting", cfg: config.Guild{AutoResponses: []config.AutoResponse{{Trigger: "hello", Response: "hi!"}}},
+			content: "Hello", expected: []sentMessage{{ChannelID: "chan", Content: "hi!"}}},
+		{name: "Auto-response with a link", cfg: config.Guild{AutoResponses: []config.AutoResponse{{Trigger: "look", Match: config.MatchContains, Response: "nice"}}},
+			content:  "look https://x.com/user/status/1",
+			expected: []sentMessage{{ChannelID: "chan", Content: "nice"}, {ChannelID: "chan", Content: "look https://fixupx.com/user/status/1", Removable: true}}},
+		{name: "Reply mode", cfg: config.Guild{RepostMode: config.RepostReply}, content: "https://x.com/user/status/1",
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", ReplyTo: "msg", Removable: true}}},
+		{name: "Fixing limited to the author's role", cfg: config.Guild{FeatureRoles: map[string][]string{access.Fixing: {"relay"}}}, content: "https://x.com/user/status/1",
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true}}},
+		{name: "Fixing limited to other roles", cfg: config.Guild{FeatureRoles: map[string][]string{access.Fixing: {"artists"}}}, content: "https://x.com/user/status/1",
+			expected: nil},
+		{name: "Trigger emoji", cfg: config.Guild{TriggerEmoji: "🔧"}, content: "https://x.com/user/status/1",
+			expected: nil},
+		{name: "Skip marker", content: "!raw https://x.com/user/status/1",
+			expected: nil},
+		{name: "Own skip marker", cfg: config.Guild{SkipMarker: "nofix"}, content: "NoFix https://x.com/user/status/1",
+			expected: nil},
+		{name: "Link in code", content: "`https://x.com/user/status/1`",
+			expected: nil},
+		{name: "Template", cfg: config.Guild{RepostTemplate: "🔧 Fixed link from {user}: {links}"}, content: "look https://x.com/user/status/1",
+			expected: []sentMessage{{ChannelID: "chan", Content: "🔧 Fixed link from <@user>: https://fixupx.com/user/status/1", Removable: true}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.cfg); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st}
+			m := newTestMessage("user", tc.content)
+			m.Member = &discordgo.Member{Roles: []string{"relay"}}
+			h.HandleMessageCreate(s, testBotID, m)
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateForwarded(t *testing.T) {
+	testCases := []struct {
+		name     string
+		snapshot string
+		embeds   []*discordgo.MessageEmbed
+		expected []sentMessage
+	}{
+		{name: "Forwarded tweet", snapshot: "look https://x.com/user/status/1",
+			expected: []sentMessage{{ChannelID: "chan", Content: "look https://fixupx.com/user/status/1", Removable: true}}},
+		{name: "Forwarded tweet already embedded", snapshot: "https://x.com/user/status/1",
+			embeds:   []*discordgo.MessageEmbed{{Type: discordgo.EmbedTypeVideo, URL: "https://x.com/user/status/1", Video: &discordgo.MessageEmbedVideo{URL: "https://video.twimg.com/1.mp4"}}},
+			expected: nil},
+		{name: "Forwarded text", snapshot: "just words", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeSession{}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10)}
+			m := newTestMessage("user", "")
+			m.MessageReference = &discordgo.MessageReference{Type: discordgo.MessageReferenceTypeForward, ChannelID: "elsewhere", MessageID: "original"}
+			m.MessageSnapshots = []discordgo.MessageSnapshot{{Message: &discordgo.Message{Content: tc.snapshot, Embeds: tc.embeds}}}
+			h.HandleMessageCreate(s, testBotID, m)
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateSuccessReaction(t *testing.T) {
+	testCases := []struct {
+		name      string
+		cfg       config.Guild
+		reactions []string
+	}{
+		{name: "Off"},
+		{name: "Default", cfg: config.Guild{SuccessReaction: config.DefaultSuccessReaction}, reactions: []string{"msg ✅", "again ✅"}},
+		{name: "Custom emoji", cfg: config.Guild{SuccessReaction: "fixed:123"}, reactions: []string{"msg fixed:123", "again fixed:123"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.cfg); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Duplicates: dedupe.New(time.Hour)}
+
+			// Discord can send a message again after a reconnect
+			resent := newTestMessage("user", "https://x.com/user/status/1")
+			again := newTestMessage("other", "https://x.com/user/status/1")
+			again.ID = "again"
+			for _, m := range []*discordgo.MessageCreate{newTestMessage("user", "https://x.com/user/status/1"), resent, again, newTestMessage("user", "hi")} {
+				h.HandleMessageCreate(s, testBotID, m)
+			}
+			h.Pool.Stop()
+
+			if reactions := s.Reactions(); !slices.Equal(reactions, tc.reactions) {
+				t.Errorf("reacted %q; want %q", reactions, tc.reactions)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateLinksToEarlierFix(t *testing.T) {
+	s := &fakeSession{}
+	h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}, fixers.Twitch{Proxy: "clips.fxtwitch.tv"}}, Pool: workerpool.New(1, 10), Duplicates: dedupe.New(time.Hour)}
+
+	first := newTestMessage("user", "https://x.com/user/status/1")
+	again := newTestMessage("other", "look https://twitter.com/someone/status/1?s=20")
+	again.ID = "again"
+	again.ChannelID = "other-chan"
+	mixed := newTestMessage("user", "https://x.com/user/status/1 https://clips.twitch.tv/Slug")
+	mixed.ID = "mixed"
+	// Discord can send a message again after a reconnect
+	resent := newTestMessage("user", "https://x.com/user/status/1 https://clips.twitch.tv/Slug")
+	resent.ID = "mixed"
+	for _, m := range []*discordgo.MessageCreate{first, again, mixed, resent} {
+		h.HandleMessageCreate(s, testBotID, m)
+	}
+	h.Pool.Stop()
+
+	expected := []sentMessage{
+		{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true},
+		{ChannelID: "other-chan", Content: "Already fixed in <#chan>: https://discord.com/channels/guild/chan/sent1", ReplyTo: "again", Removable: true},
+		{ChannelID: "chan", Content: "https://fixupx.com/user/status/1 https://clips.fxtwitch.tv/Slug", Removable: true},
+	}
+	sent := s.Sent()
+	if !slices.Equal(sent, expected) {
+		t.Errorf("sent %+v; want %+v", sent, expected)
+	}
+}
+
+func TestHandleMessageCreateFlood(t *testing.T) {
+	s := &fakeSession{}
+	h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Flood: flood.New(2, time.Minute)}
+	for _, content := range []string{"hello", "https://x.com/user/status/1", "https://x.com/user/status/2", "hello"} {
+		h.HandleMessageCreate(s, testBotID, newTestMessage("user", content))
+	}
+	h.Pool.Stop()
+
+	if sent := s.Sent(); len(sent) != 2 {
+		t.Errorf("sent %+v during a flood; want only the first two replies", sent)
+	}
+}
+
+func TestHandleReactionAdd(t *testing.T) {
+	repost := &discordgo.Message{ID: "repost", ChannelID: "chan", Author: &discordgo.User{ID: testBotID}, Content: "https://fixupx.com/user/status/1"}
+	other := &discordgo.Message{ID: "other", ChannelID: "chan", Author: &discordgo.User{ID: "user"}, Content: "https://fixupx.com/user/status/1"}
+
+	testCases := []struct {
+		name      string
+		userID    string
+		messageID string
+		emoji     string
+		expected  []sentMessage
+	}{
+		{name: "Flag on a repost", userID: "user", messageID: "repost", emoji: "🇯🇵",
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1/ja"}}},
+		{name: "Other emoji", userID: "user", messageID: "repost", emoji: "👍"},
+		{name: "Flag on someone else's message", userID: "user", messageID: "other", emoji: "🇯🇵"},
+		{name: "Bot's own reaction", userID: testBotID, messageID: "repost", emoji: "🇯🇵"},
+		{name: "Ignored user", userID: "ignored", messageID: "repost", emoji: "🇯🇵"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{IgnoredUsers: []string{"ignored"}}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{messages: map[string]*discordgo.Message{"repost": repost, "other": other}}
+			h := &Handler{Pool: workerpool.New(1, 10), Store: st}
+			h.HandleReactionAdd(s, testBotID, &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+				UserID:    tc.userID,
+				MessageID: tc.messageID,
+				ChannelID: "chan",
+				GuildID:   "guild",
+				Emoji:     discordgo.Emoji{Name: tc.emoji},
+			}})
+			h.Pool.Stop()
+
+			if edited := s.Edited(); !slices.Equal(edited, tc.expected) {
+				t.Errorf("edited %+v; want %+v", edited, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleReactionAddTrigger(t *testing.T) {
+	link := &discordgo.Message{ID: "link", ChannelID: "chan", Author: &discordgo.User{ID: "user"}, Content: "https://x.com/user/status/1"}
+	fixed := &discordgo.Message{ID: "fixed", ChannelID: "chan", Author: &discordgo.User{ID: "user"}, Content: "https://x.com/user/status/2",
+		Reactions: []*discordgo.MessageReactions{{Count: 2, Me: true, Emoji: &discordgo.Emoji{Name: "🔧"}}}}
+	plain := &discordgo.Message{ID: "plain", ChannelID: "chan", Author: &discordgo.User{ID: "user"}, Content: "no links here"}
+
+	testCases := []struct {
+		name      string
+		cfg       config.Guild
+		messageID string
+		emoji     string
+		expected  []sentMessage
+		reactions []string
+	}{
+		{name: "Trigger", cfg: config.Guild{TriggerEmoji: "🔧"}, messageID: "link", emoji: "🔧",
+			expected:  []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", ReplyTo: "link", Removable: true}},
+			reactions: []string{"link 🔧"}},
+		{name: "Custom trigger", cfg: config.Guild{TriggerEmoji: "fix:123"}, messageID: "link", emoji: "fix",
+			expected:  []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", ReplyTo: "link", Removable: true}},
+			reactions: []string{"link fix:123"}},
+		{name: "Fixed already", cfg: config.Guild{TriggerEmoji: "🔧"}, messageID: "fixed", emoji: "🔧"},
+		{name: "Nothing to fix", cfg: config.Guild{TriggerEmoji: "🔧"}, messageID: "plain", emoji: "🔧"},
+		{name: "Other emoji", cfg: config.Guild{TriggerEmoji: "🔧"}, messageID: "link", emoji: "👍"},
+		{name: "No trigger", messageID: "link", emoji: "🔧"},
+		{name: "Other channel only", cfg: config.Guild{TriggerEmoji: "🔧", Channels: []string{"other"}}, messageID: "link", emoji: "🔧"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.cfg); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{messages: map[string]*discordgo.Message{"link": link, "fixed": fixed, "plain": plain}}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st}
+			emoji := discordgo.Emoji{Name: tc.emoji}
+			if tc.emoji == "fix" {
+				emoji.ID = "123"
+			}
+			h.HandleReactionAdd(s, testBotID, &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+				UserID:    "reactor",
+				MessageID: tc.messageID,
+				ChannelID: "chan",
+				GuildID:   "guild",
+				Emoji:     emoji,
+			}})
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+			if reactions := s.Reactions(); !slices.Equal(reactions, tc.reactions) {
+				t.Errorf("reacted %q; want %q", reactions, tc.reactions)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateShowsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status/2":
+			w.Write([]byte(`{"tweet":{"id":"2","replying_to_status":"1","quote":{"id":"q","text":"quoted"}}}`))
+		case "/status/1":
+			w.Write([]byte(`{"tweet":{"id":"1","text":"parent"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		depth    int
+		expected []sentMessage
+	}{
+		{name: "Off", depth: 0, expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true}}},
+		{name: "Quote only", depth: 1, expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true, Embeds: 1}}},
+		{name: "Quote and parent", depth: 5, expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true, Embeds: 2}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{ContextDepth: tc.depth}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{
+				Fixers: fixers.Pipeline{fixers.Twitter{}},
+				Pool:   workerpool.New(1, 10),
+				Store:  st,
+				Tweets: fxtwitter.New(server.URL, server.Client()),
+			}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/2"))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateTextSummaries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status/2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"tweet":{"id":"2","text":"hello\n@everyone","author":{"name":"Some One","screen_name":"someone"},"media":{"all":[{"type":"photo"},{"type":"photo"},{"type":"video"}]}}}`))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		on       bool
+		expected []sentMessage
+	}{
+		{name: "Off", expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true}}},
+		{name: "On", on: true, expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2\nTweet by Some One (@someone): \"hello @everyone\" (2 photos, 1 video)", Removable: true}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{TextSummaries: tc.on}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{
+				Fixers: fixers.Pipeline{fixers.Twitter{}},
+				Pool:   workerpool.New(1, 10),
+				Store:  st,
+				Tweets: fxtwitter.New(server.URL, server.Client()),
+			}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/2"))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateContentFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tweet":{"id":"2","text":"Huge SPOILER ahead","author":{"name":"Some One","screen_name":"someone"}}}`))
+	}))
+	defer server.Close()
+
+	fixed := []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true}}
+	testCases := []struct {
+		name     string
+		cfg      config.Guild
+		expected []sentMessage
+		removed  bool
+	}{
+		{name: "No filters", expected: fixed},
+		{name: "Other account", cfg: config.Guild{BlockedAccounts: []string{"someone_else"}}, expected: fixed},
+		{name: "Blocked account", cfg: config.Guild{BlockedAccounts: []string{"User"}}},
+		{name: "Blocked author", cfg: config.Guild{BlockedAccounts: []string{"someone"}, BlockedKeywords: []string{"unrelated"}}},
+		{name: "Blocked keyword", cfg: config.Guild{BlockedKeywords: []string{"spoiler"}}},
+		{name: "Other keyword", cfg: config.Guild{BlockedKeywords: []string{"spoil"}}, expected: fixed},
+		{
+			name:     "Flagged",
+			cfg:      config.Guild{BlockedKeywords: []string{"spoiler"}, FilterAction: config.FilterFlag, AuditChannel: "audit"},
+			expected: []sentMessage{{ChannelID: "audit", Content: `Didn't fix the links <@user> posted in https://discord.com/channels/guild/chan/msg: they're to a tweet mentioning "spoiler", a blocked keyword.`}},
+		},
+		{
+			name:     "Removed account",
+			cfg:      config.Guild{BlockedAccounts: []string{"user"}, FilterAction: config.FilterRemove},
+			expected: []sentMessage{{ChannelID: "chan", Content: "Removed a message from <@user> linking to a tweet by @user, a blocked account."}},
+			removed:  true,
+		},
+		{
+			name:     "Removed keyword",
+			cfg:      config.Guild{BlockedKeywords: []string{"spoiler"}, FilterAction: config.FilterRemove},
+			expected: []sentMessage{{ChannelID: "chan", Content: `Removed a message from <@user> linking to a tweet mentioning "spoiler", a blocked keyword.`}},
+			removed:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.cfg); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{
+				Fixers: fixers.Pipeline{fixers.Twitter{}},
+				Pool:   workerpool.New(1, 10),
+				Store:  st,
+				Tweets: fxtwitter.New(server.URL, server.Client()),
+			}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/2"))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+			if removed := slices.Contains(s.deleted, "msg"); removed != tc.removed {
+				t.Errorf("removed = %v; want %v", removed, tc.removed)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateFeedSources(t *testing.T) {
+	feedEmbeds := []*discordgo.MessageEmbed{{
+		URL:         "https://x.com/news/status/1",
+		Description: "[Also](https://twitter.com/news/status/2) and https://x.com/news/status/1 again",
+		Fields:      []*discordgo.MessageEmbedField{{Name: "Source", Value: "https://example.com/article"}},
+	}}
+	fixed := []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/news/status/1\nhttps://fxtwitter.com/news/status/2", Removable: true}}
+
+	testCases := []struct {
+		name     string
+		authorID string
+		bot      bool
+		embeds   []*discordgo.MessageEmbed
+		expected []sentMessage
+	}{
+		{name: "Listed webhook", authorID: "webhook", bot: true, embeds: feedEmbeds, expected: fixed},
+		{name: "Unlisted bot", authorID: "other", bot: true, embeds: feedEmbeds},
+		{name: "Listed ID on a person", authorID: "webhook", embeds: feedEmbeds},
+		{name: "No Twitter links", authorID: "webhook", bot: true, embeds: []*discordgo.MessageEmbed{{URL: "https://example.com/article"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{FeedSources: map[string]string{"webhook": "News"}}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st}
+			m := newTestMessage(tc.authorID, "")
+			m.Author.Bot = tc.bot
+			m.Embeds = tc.embeds
+			h.HandleMessageCreate(s, testBotID, m)
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateModules(t *testing.T) {
+	testCases := []struct {
+		name     string
+		disabled string
+		expected []sentMessage
+	}{
+		{name: "Enabled", expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true}}},
+		{name: "Fixers disabled", disabled: lifecycle.Fixers},
+		{name: "Other module disabled", disabled: lifecycle.Feeds, expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			modules, _ := lifecycle.New(nil)
+			modules.Register(lifecycle.Fixers, "Fixes links", nil)
+			modules.Register(lifecycle.Feeds, "Posts feeds", nil)
+			if tc.disabled != "" {
+				if err := modules.Disable(tc.disabled); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := &fakeSession{}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Modules: modules}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/2"))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+// replicaCache is a dedupe.Shared store in memory, standing in for the Redis
+// server replicas share.
+type replicaCache struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func (c *replicaCache) Get(_ context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.keys[key]
+	return v, ok, nil
+}
+
+func (c *replicaCache) Set(_ context.Context, key, value string, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[key] = value
+	return nil
+}
+
+func (c *replicaCache) SetNX(_ context.Context, key, value string, _ time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[key]; ok {
+		return false, nil
+	}
+	c.keys[key] = value
+	return true, nil
+}
+
+func TestHandleMessageCreateReplicas(t *testing.T) {
+	cache := &replicaCache{keys: make(map[string]string)}
+	var sessions []*fakeSession
+	for range 2 {
+		s := &fakeSession{}
+		h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Claims: dedupe.NewClaims(cache)}
+		h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/2"))
+		h.Pool.Stop()
+		sessions = append(sessions, s)
+	}
+
+	expected := []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true}}
+	if sent := sessions[0].Sent(); !slices.Equal(sent, expected) {
+		t.Errorf("first replica sent %+v; want %+v", sent, expected)
+	}
+	if sent := sessions[1].Sent(); len(sent) != 0 {
+		t.Errorf("second replica sent %+v; want nothing", sent)
+	}
+}
+
+func TestHandleMessageCreateMirrorsMedia(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status/2":
+			w.Write([]byte(`{"tweet":{"id":"2","media":{"all":[{"type":"photo","url":"` + server.URL + `/media/a.jpg"},{"type":"photo","url":"` + server.URL + `/media/gone.jpg"}]}}}`))
+		case "/media/a.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("jpeg"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		mirror   bool
+		limit    int64
+		spent    bool
+		sendErrs []error
+		expected []sentMessage
+	}{
+		{name: "Off", expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true}}},
+		{name: "On", mirror: true, expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true, Files: 1}}},
+		{name: "Over budget", mirror: true, spent: true, expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true}}},
+		{
+			name:   "Too big",
+			mirror: true,
+			limit:  3,
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2\n" +
+				"Too big to upload here (over 0 MB), linked instead: " + server.URL + "/media/a.jpg", Removable: true}},
+		},
+		{
+			name:     "Upload refused",
+			mirror:   true,
+			sendErrs: []error{errors.New("request entity too large")},
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2\n" +
+				"Couldn't upload the media here, linked instead: " + server.URL + "/media/a.jpg " + server.URL + "/media/gone.jpg", Removable: true}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{MirrorMedia: tc.mirror}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{sendErrs: tc.sendErrs}
+			h := &Handler{
+				Fixers: fixers.Pipeline{fixers.Twitter{}},
+				Pool:   workerpool.New(1, 10),
+				Store:  st,
+				Tweets: fxtwitter.New(server.URL, server.Client()),
+				Mirror: mirror.New(server.Client()),
+			}
+			if tc.limit != 0 {
+				h.UploadLimit = func(string) int64 { return tc.limit }
+			}
+			if tc.spent {
+				h.Budget = budget.New(budget.Limits{budget.Mirrors: 1})
+				h.Budget.Spend("guild", budget.Mirrors, 1)
+			}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/2"))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateAttachesGalleries(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status/2":
+			w.Write([]byte(`{"tweet":{"id":"2","media":{"all":[{"type":"photo","url":"` + server.URL + `/media/a.jpg"},{"type":"photo","url":"` + server.URL + `/media/b.jpg"},{"type":"photo","url":"` + server.URL + `/media/c.jpg"}]}}}`))
+		case "/media/b.jpg", "/media/c.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("jpeg"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		mode     string
+		expected []sentMessage
+	}{
+		{name: "Off"},
+		{name: "Attach", mode: config.GalleryAttach, expected: []sentMessage{{ChannelID: "chan", ReplyTo: "msg", Removable: true, Files: 2}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{Galleries: tc.mode}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{
+				Fixers: fixers.Pipeline{fixers.Twitter{}},
+				Pool:   workerpool.New(1, 10),
+				Store:  st,
+				Tweets: fxtwitter.New(server.URL, server.Client()),
+				Mirror: mirror.New(server.Client()),
+			}
+			embed := &discordgo.MessageEmbed{URL: "https://x.com/user/status/2", Image: &discordgo.MessageEmbedImage{URL: "https://pbs.twimg.com/media/a.jpg"}}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/2", embed))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateTwitterPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/someone", "/other":
+			w.Write([]byte(`{"user":{"screen_name":"` + r.URL.Path[1:] + `","name":"Someone","followers":3}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		mode     string
+		content  string
+		expected []sentMessage
+	}{
+		{name: "Off", content: "https://x.com/someone"},
+		{name: "Profile", mode: config.ProfileEmbed, content: "https://x.com/someone and https://twitter.com/Someone?s=20",
+			expected: []sentMessage{{ChannelID: "chan", ReplyTo: "msg", Removable: true, Embeds: 1}}},
+		{name: "Two profiles", mode: config.ProfileEmbed, content: "https://x.com/someone https://x.com/other",
+			expected: []sentMessage{{ChannelID: "chan", ReplyTo: "msg", Removable: true, Embeds: 2}}},
+		{name: "Site pages, communities and Spaces", mode: config.ProfileEmbed, content: "https://x.com/home https://x.com/i/communities/1 https://x.com/i/spaces/1abc"},
+		{name: "Bracketed", mode: config.ProfileEmbed, content: "<https://x.com/someone>"},
+		{name: "Unknown account", mode: config.ProfileEmbed, content: "https://x.com/nobody"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{Profiles: tc.mode}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{
+				Fixers: fixers.Pipeline{fixers.Twitter{}},
+				Pool:   workerpool.New(1, 10),
+				Store:  st,
+				Tweets: fxtwitter.New(server.URL, server.Client()),
+			}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", tc.content))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestReprocess(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "guild", config.Guild{IgnoredUsers: []string{"user"}}); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	s := &fakeSession{}
+	h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st}
+	// Operators can reprocess messages from ignored users
+	if !h.Reprocess(s, newTestMessage("user", "https://x.com/user/status/1").Message) {
+		t.Fatal("Reprocess reported a full queue")
+	}
+	h.Pool.Stop()
+
+	if sent := s.Sent(); len(sent) != 1 || sent[0].Content != "https://fixupx.com/user/status/1" {
+		t.Errorf("sent %+v; want the fixed link", sent)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	st := storage.NewMemory()
+	for guildID, cfg := range map[string]config.Guild{
+		"ignoring":  {IgnoredUsers: []string{"user"}},
+		"elsewhere": {Channels: []string{"other"}},
+	} {
+		if err := config.SaveGuild(st, guildID, cfg); err != nil {
+			t.Fatalf("SaveGuild: %v", err)
+		}
+	}
+	working := []*discordgo.MessageEmbed{{Image: &discordgo.MessageEmbedImage{URL: "https://pbs.twimg.com/media/a.jpg"}}}
+
+	testCases := []struct {
+		name     string
+		guildID  string
+		content  string
+		embeds   []*discordgo.MessageEmbed
+		expected string
+	}{
+		{name: "Fixable", guildID: "guild", content: "https://x.com/user/status/1", expected: "would fix"},
+		{name: "Ignored author", guildID: "ignoring", content: "https://x.com/user/status/1", expected: "ignored"},
+		{name: "Channel disabled", guildID: "elsewhere", content: "https://x.com/user/status/1", expected: "channel disabled"},
+		{name: "No links", guildID: "guild", content: "hello", expected: "no links"},
+		{name: "No fixable links", guildID: "guild", content: "https://example.com", expected: "no fixable links"},
+		{name: "Preview working", guildID: "guild", content: "https://x.com/user/status/1", embeds: working, expected: "preview working"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeSession{}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Store: st, Decisions: explain.New(10)}
+			m := newTestMessage("user", tc.content).Message
+			m.GuildID, m.Embeds = tc.guildID, tc.embeds
+			trace := h.Diagnose(context.Background(), s, m)
+			if trace.Decision != tc.expected {
+				t.Errorf("Diagnose decided %q after %+v; want %q", trace.Decision, trace.Steps, tc.expected)
+			}
+			if len(s.Sent()) != 0 || h.Decisions.Len() != 0 {
+				t.Error("Diagnose sent a message or logged a decision")
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateYieldsToOtherFixers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mode     string
+		expected []sentMessage
+	}{
+		{
+			name: "Yield",
+			expected: []sentMessage{
+				{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true},
+				{ChannelID: "audit", Content: "<@rival> seems to fix links too: it reposted a tweet moments after someone shared it in <#chan>. So links aren't reposted twice, this bot leaves them to it now. `/fixerbots mode` changes that."},
+			},
+		},
+		{
+			name: "Alert",
+			mode: config.OthersAlert,
+			expected: []sentMessage{
+				{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true},
+				{ChannelID: "audit", Content: "<@rival> seems to fix links too: it reposted a tweet moments after someone shared it in <#chan>. Both bots repost links here. `/fixerbots mode` can have this one leave them to it."},
+				{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true},
+			},
+		},
+		{
+			name: "Ignore",
+			mode: config.OthersIgnore,
+			expected: []sentMessage{
+				{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true},
+				{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{AuditChannel: "audit", OtherFixers: tc.mode}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Store: st, Others: overlap.New(st)}
+			rival := newTestMessage("rival", "https://fxtwitter.com/user/status/1")
+			rival.Author.Bot = true
+			// Each message is handled before the next arrives
+			for _, m := range []*discordgo.MessageCreate{newTestMessage("user", "https://x.com/user/status/1"), rival, newTestMessage("user", "https://x.com/user/status/2")} {
+				h.Pool = workerpool.New(1, 10)
+				h.HandleMessageCreate(s, testBotID, m)
+				h.Pool.Stop()
+			}
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateMaintenance(t *testing.T) {
+	mode, _ := maintenance.New(nil)
+	mode.Start()
+	m := newTestMessage("user", "https://x.com/user/status/1")
+	s := &fakeSession{messages: map[string]*discordgo.Message{m.ID: m.Message}}
+	h := &Handler{Name: "main", Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Maintenance: mode}
+	h.HandleMessageCreate(s, testBotID, m)
+	h.HandleMessageCreate(s, testBotID, newTestMessage("user", "no links here"))
+
+	held, _ := mode.End()
+	if expected := []maintenance.Held{{Bot: "main", GuildID: "guild", ChannelID: "chan", MessageID: "msg"}}; !slices.Equal(held, expected) {
+		t.Fatalf("held %+v; want %+v", held, expected)
+	}
+	// Other bots fix the messages they held themselves
+	other := append(held, maintenance.Held{Bot: "other", ChannelID: "chan", MessageID: "msg"})
+	if queued := h.FixHeld(s, other); queued != 1 {
+		t.Errorf("FixHeld queued %d messages; want 1", queued)
+	}
+	h.Pool.Stop()
+
+	if sent := s.Sent(); len(sent) != 1 || sent[0].Content != "https://fixupx.com/user/status/1" {
+		t.Errorf("sent %+v; want the held link fixed once", sent)
+	}
+}
+
+func TestPreviewLinks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{name: "No links", content: "hello", expected: nil},
+		{name: "Links", content: "see https://a.example/1 and https://b.example/2", expected: []string{"https://a.example/1", "https://b.example/2"}},
+		{name: "Bracketed link skipped", content: "<https://a.example/1> https://b.example/2", expected: []string{"https://b.example/2"}},
+		{name: "Duplicates", content: "https://a.example/1 https://a.example/1", expected: []string{"https://a.example/1"}},
+		{name: "Capped", content: "https://a.example/1 https://a.example/2 https://a.example/3 https://a.example/4", expected: []string{"https://a.example/1", "https://a.example/2", "https://a.example/3"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := previewLinks(tc.content); !slices.Equal(got, tc.expected) {
+				t.Errorf("previewLinks(%q) = %q; want %q", tc.content, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPostPreviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/page" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<meta property="og:title" content="A page">`))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		current  *discordgo.Message
+		expected []sentMessage
+	}{
+		{
+			name:     "Not embedded",
+			current:  &discordgo.Message{ID: "msg"},
+			expected: []sentMessage{{ChannelID: "chan", ReplyTo: "msg", Removable: true, Embeds: 1}},
+		},
+		{name: "Embedded by Discord", current: &discordgo.Message{ID: "msg", Embeds: []*discordgo.MessageEmbed{{}}}},
+		{name: "Embeds suppressed", current: &discordgo.Message{ID: "msg", Flags: discordgo.MessageFlagsSuppressEmbeds}},
+		{name: "Deleted"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeSession{messages: map[string]*discordgo.Message{}}
+			if tc.current != nil {
+				s.messages[tc.current.ID] = tc.current
+			}
+			h := &Handler{Previews: preview.New(server.Client())}
+			m := newTestMessage("user", server.URL+"/page").Message
+			h.postPreviews(nil, s, m, []string{server.URL + "/page", server.URL + "/missing"})
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPostFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"__typename":"Tweet","id_str":"1","text":"first line\nsecond line","user":{"name":"Some One","screen_name":"someone"}}`))
+	}))
+	defer server.Close()
+
+	quote := "> first line\n> second line\n— Some One (@someone) <https://x.com/someone/status/1>"
+	testCases := []struct {
+		name     string
+		current  *discordgo.Message
+		expected []sentMessage
+	}{
+		{
+			name:     "Not embedded",
+			current:  &discordgo.Message{ID: "repost"},
+			expected: []sentMessage{{ChannelID: "chan", Content: quote, ReplyTo: "repost", Removable: true}},
+		},
+		{
+			name:     "One tweet embedded",
+			current:  &discordgo.Message{ID: "repost", Embeds: []*discordgo.MessageEmbed{{URL: "https://twitter.com/other/status/2"}}},
+			expected: []sentMessage{{ChannelID: "chan", Content: quote, ReplyTo: "repost", Removable: true}},
+		},
+		{name: "Embedded by Discord", current: &discordgo.Message{ID: "repost", Embeds: []*discordgo.MessageEmbed{{URL: "https://twitter.com/someone/status/1"}, {URL: "https://twitter.com/other/status/2"}}}},
+		{name: "Embeds suppressed", current: &discordgo.Message{ID: "repost", Flags: discordgo.MessageFlagsSuppressEmbeds}},
+		{name: "Deleted"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeSession{messages: map[string]*discordgo.Message{}}
+			if tc.current != nil {
+				s.messages[tc.current.ID] = tc.current
+			}
+			h := &Handler{Fallback: syndication.New(server.URL, server.Client())}
+			repost := &discordgo.Message{ID: "repost", ChannelID: "chan", Content: "https://fixupx.com/someone/status/1 https://fixupx.com/other/status/2"}
+			h.postFallback(nil, s, repost, "user", []string{"1", "2"})
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestResumePreviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<meta property="og:title" content="A page">`))
+	}))
+	defer server.Close()
+
+	st := storage.NewMemory()
+	now := time.Now()
+	checks := map[string]previewCheck{
+		"main/chan/msg":   {ChannelID: "chan", MessageID: "msg", GuildID: "guild", AuthorID: "user", Links: []string{server.URL}, Due: now.Add(-time.Second)},
+		"main/chan/old":   {ChannelID: "chan", MessageID: "old", GuildID: "guild", AuthorID: "user", Links: []string{server.URL}, Due: now.Add(-time.Hour)},
+		"other/chan/msg2": {ChannelID: "chan", MessageID: "msg2", GuildID: "guild", AuthorID: "user", Links: []string{server.URL}, Due: now},
+	}
+	for key, check := range checks {
+		if err := st.Put(PreviewCheckBucket, key, check); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &fakeSession{messages: map[string]*discordgo.Message{
+		"msg":  {ID: "msg"},
+		"old":  {ID: "old"},
+		"msg2": {ID: "msg2"},
+	}}
+	h := &Handler{Name: "main", Pool: workerpool.New(1, 10), Store: st, Previews: preview.New(server.Client())}
+	h.ResumePreviews(s)
+
+	// The resumed check runs on a timer, so wait for it to be forgotten
+	deadline := time.Now().Add(5 * time.Second)
+	for len(st.Keys(PreviewCheckBucket)) > 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.Pool.Stop()
+
+	expected := []sentMessage{{ChannelID: "chan", ReplyTo: "msg", Removable: true, Embeds: 1}}
+	if sent := s.Sent(); !slices.Equal(sent, expected) {
+		t.Errorf("sent %+v; want %+v", sent, expected)
+	}
+	if keys := st.Keys(PreviewCheckBucket); !slices.Equal(keys, []string{"other/chan/msg2"}) {
+		t.Errorf("checks left %q; want only the other bot's", keys)
+	}
+}
+
+func TestRepostExpiry(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "guild", config.Guild{RepostTTLHours: 24}); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	s := &fakeSession{}
+	h := &Handler{Name: "main", Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st}
+	h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/1"))
+	h.Pool.Stop()
+
+	var saved expiry
+	if ok, err := st.Get(ExpiryBucket, "main/chan/sent1", &saved); !ok || err != nil {
+		t.Fatalf("repost expiry not saved: %v", err)
+	}
+	if until := time.Until(saved.Due); until < 23*time.Hour || until > 24*time.Hour {
+		t.Errorf("repost expires in %s; want 24h", until)
+	}
+
+	// After a restart, the overdue repost is deleted and the other bot's left alone
+	saved.Due = time.Now().Add(-time.Minute)
+	for _, key := range []string{"main/chan/sent1", "other/chan/sent2"} {
+		if err := st.Put(ExpiryBucket, key, saved); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s = &fakeSession{}
+	h = &Handler{Name: "main", Pool: workerpool.New(1, 10), Store: st}
+	h.ResumeExpiries(s)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(st.Keys(ExpiryBucket)) > 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.Pool.Stop()
+
+	if deleted := s.deleted; !slices.Equal(deleted, []string{"sent1"}) {
+		t.Errorf("deleted %q; want the expired repost", deleted)
+	}
+	if keys := st.Keys(ExpiryBucket); !slices.Equal(keys, []string{"other/chan/sent2"}) {
+		t.Errorf("expiries left %q; want only the other bot's", keys)
+	}
+}
+
+func TestHandleMessageCreateMutesSpammers(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "guild", config.Guild{AuditChannel: "audit"}); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	s := &fakeSession{}
+	h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Abuse: abuse.New(1, 10*time.Minute)}
+	for n, author := range []string{"spammer", "spammer", "spammer", "user"} {
+		m := newTestMessage(author, fmt.Sprintf("https://x.com/user/status/%d", n+1))
+		m.ID = fmt.Sprint("msg", n)
+		h.HandleMessageCreate(s, testBotID, m)
+	}
+	h.Pool.Stop()
+
+	expected := []sentMessage{
+		{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true},
+		{ChannelID: "audit", Content: "<@spammer> used the bot far more than anyone normally would, so it's ignoring them for 10 minutes."},
+		{ChannelID: "chan", Content: "https://fixupx.com/user/status/4", Removable: true},
+	}
+	if sent := s.Sent(); !slices.Equal(sent, expected) {
+		t.Errorf("sent %+v; want %+v", sent, expected)
+	}
+}
+
+func TestHandleMessageCreateOutsideGuilds(t *testing.T) {
+	testCases := []struct {
+		guilds   []string
+		expected int
+	}{
+		{guilds: nil, expected: 1},
+		{guilds: []string{"test", "guild"}, expected: 1},
+		{guilds: []string{"test"}, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		s := &fakeSession{}
+		h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Guilds: tc.guilds}
+		h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/1"))
+		h.Pool.Stop()
+		if sent := s.Sent(); len(sent) != tc.expected {
+			t.Errorf("with guilds %v, sent %+v; want %d messages", tc.guilds, sent, tc.expected)
+		}
+	}
+}
+
+func TestHandleMessageCreatePhishing(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(list, []byte("evil.example\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	checker, err := phishing.New(list)
+	if err != nil {
+		t.Fatalf("phishing.New: %v", err)
+	}
+
+	const content = "https://x.com/user/status/1 https://login.evil.example/steam"
+	testCases := []struct {
+		name    string
+		action  string
+		privacy bool
+		ignored []string
+		content string
+		sent    []sentMessage
+		deleted []string
+		kept    int
+	}{
+		{
+			name:    "Warn by default",
+			content: content,
+			sent:    []sentMessage{{ChannelID: "chan", Content: "Warning: this message links to a known phishing or malware site (`login.evil.example`). Don't open it or enter any details.", ReplyTo: "msg"}},
+		},
+		{
+			name:    "Delete",
+			action:  config.PhishingDelete,
+			content: content,
+			sent:    []sentMessage{{ChannelID: "chan", Content: "Removed a message from <@user> linking to a known phishing or malware site. Moderators can restore it with `/deleted restore`."}},
+			deleted: []string{"msg"},
+			kept:    1,
+		},
+		{
+			name:    "Delete in privacy mode",
+			action:  config.PhishingDelete,
+			privacy: true,
+			content: content,
+			sent:    []sentMessage{{ChannelID: "chan", Content: "Removed a message from <@user> linking to a known phishing or malware site."}},
+			deleted: []string{"msg"},
+		},
+		{
+			name:    "Off",
+			action:  config.PhishingOff,
+			content: content,
+			sent:    []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1 https://login.evil.example/steam", Removable: true}},
+		},
+		{
+			name:    "Safe links",
+			content: "https://x.com/user/status/1",
+			sent:    []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true}},
+		},
+		{
+			name:    "Ignored author",
+			ignored: []string{"user"},
+			content: content,
+			sent:    []sentMessage{{ChannelID: "chan", Content: "Warning: this message links to a known phishing or malware site (`login.evil.example`). Don't open it or enter any details.", ReplyTo: "msg"}},
+		},
+		{
+			name:    "Ignored author with safe links",
+			ignored: []string{"user"},
+			content: "https://x.com/user/status/1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{PhishingAction: tc.action, Privacy: tc.privacy, IgnoredUsers: tc.ignored}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			bin := trash.New(st, time.Hour)
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Phishing: checker, Trash: bin}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", tc.content))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.sent) {
+				t.Errorf("sent %+v; want %+v", sent, tc.sent)
+			}
+			if !slices.Equal(s.deleted, tc.deleted) {
+				t.Errorf("deleted %q; want %q", s.deleted, tc.deleted)
+			}
+			if kept, _ := bin.List("guild"); len(kept) != tc.kept {
+				t.Errorf("kept %+v; want %d messages", kept, tc.kept)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateUnshortens(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Redirect(w, r, "https://example.com/landing?utm_source=bot", http.StatusMovedPermanently)
+	}))
+	defer server.Close()
+	host, _ := url.Parse(server.URL)
+	link := server.URL + "/abc"
+
+	testCases := []struct {
+		name       string
+		cfg        config.Guild
+		remembered string
+		expected   []sentMessage
+		requested  bool
+		stored     bool
+	}{
+		{name: "Off", expected: nil},
+		{
+			name:      "On",
+			cfg:       config.Guild{Unshorten: true},
+			expected:  []sentMessage{{ChannelID: "chan", Content: "<" + link + "> goes to <https://example.com/landing>", ReplyTo: "msg", Removable: true}},
+			requested: true,
+			stored:    true,
+		},
+		{
+			name:       "Remembered",
+			cfg:        config.Guild{Unshorten: true},
+			remembered: "https://example.com/before",
+			expected:   []sentMessage{{ChannelID: "chan", Content: "<" + link + "> goes to <https://example.com/before>", ReplyTo: "msg", Removable: true}},
+			stored:     true,
+		},
+		{
+			name:      "Privacy mode",
+			cfg:       config.Guild{Unshorten: true, Privacy: true},
+			expected:  []sentMessage{{ChannelID: "chan", Content: "<" + link + "> goes to <https://example.com/landing>", ReplyTo: "msg", Removable: true}},
+			requested: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests.Store(0)
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.cfg); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			resolved := canonical.New(st, time.Hour)
+			if tc.remembered != "" {
+				resolved.Put(link, tc.remembered)
+			}
+			s := &fakeSession{}
+			h := &Handler{Pool: workerpool.New(1, 10), Store: st, Unshortener: unshorten.New(server.Client(), host.Hostname()), Canonical: resolved}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "look "+link+" "+link))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+			if requested := requests.Load() > 0; requested != tc.requested {
+				t.Errorf("shortener requested %v; want %v", requested, tc.requested)
+			}
+			if _, stored := resolved.Get(link); stored != tc.stored {
+				t.Errorf("destination stored %v; want %v", stored, tc.stored)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateQuotes(t *testing.T) {
+	link := func(guildID, channelID, messageID string) string {
+		return "https://discord.com/channels/" + guildID + "/" + channelID + "/" + messageID
+	}
+
+	testCases := []struct {
+		name     string
+		cfg      config.Guild
+		content  string
+		expected []sentMessage
+	}{
+		{name: "Off", content: "see " + link("100", "200", "201")},
+		{
+			name:     "On",
+			cfg:      config.Guild{QuoteLinks: true},
+			content:  "see " + link("100", "200", "201"),
+			expected: []sentMessage{{ChannelID: "chan", ReplyTo: "msg", Removable: true, Embeds: 1}},
+		},
+		{
+			name:     "Several",
+			cfg:      config.Guild{QuoteLinks: true},
+			content:  link("100", "200", "201") + " and " + link("100", "200", "201") + " and " + link("100", "200", "202"),
+			expected: []sentMessage{{ChannelID: "chan", ReplyTo: "msg", Removable: true, Embeds: 2}},
+		},
+		{name: "Hidden channel", cfg: config.Guild{QuoteLinks: true}, content: "see " + link("100", "300", "301")},
+		{name: "Age-restricted channel", cfg: config.Guild{QuoteLinks: true}, content: "see " + link("100", "400", "401")},
+		{name: "Other server", cfg: config.Guild{QuoteLinks: true}, content: "see " + link("101", "200", "201")},
+		{name: "Angle brackets", cfg: config.Guild{QuoteLinks: true}, content: "see <" + link("100", "200", "201") + ">"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "100", tc.cfg); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{
+				messages: map[string]*discordgo.Message{
+					"201": {ID: "201", ChannelID: "200", Content: "hello", Author: &discordgo.User{ID: "poster"}},
+					"202": {ID: "202", ChannelID: "200", Author: &discordgo.User{ID: "poster"}, Attachments: []*discordgo.MessageAttachment{{}}},
+					"301": {ID: "301", ChannelID: "300", Content: "psst", Author: &discordgo.User{ID: "poster"}},
+					"401": {ID: "401", ChannelID: "400", Content: "oh my", Author: &discordgo.User{ID: "poster"}},
+				},
+				channels: map[string]*discordgo.Channel{
+					"400": {ID: "400", Type: discordgo.ChannelTypeGuildText, NSFW: true},
+				},
+			}
+			h := &Handler{Pool: workerpool.New(1, 10), Store: st, Channels: channels.New()}
+			h.AuthorPermissions = func(_ *discordgo.Message, channelID string) (int64, bool) {
+				if channelID == "300" {
+					return discordgo.PermissionSendMessages, true
+				}
+				return discordgo.PermissionViewChannel | discordgo.PermissionReadMessageHistory, true
+			}
+			m := newTestMessage("user", tc.content)
+			m.GuildID = "100"
+			h.HandleMessageCreate(s, testBotID, m)
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateGames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/appdetails":
+			w.Write([]byte(`{"620":{"success":true,"data":{"name":"Portal 2","price_overview":{"final_formatted":"$9.99"}}}}`))
+		case "/maker/game/data.json":
+			w.Write([]byte(`{"title":"Game","price":"$0.00"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		cfg      config.Guild
+		content  string
+		expected []sentMessage
+	}{
+		{name: "Off", content: "https://store.steampowered.com/app/620/Portal_2/"},
+		{
+			name:     "Steam",
+			cfg:      config.Guild{GameEmbeds: true},
+			content:  "get https://store.steampowered.com/app/620/Portal_2/",
+			expected: []sentMessage{{ChannelID: "chan", ReplyTo: "msg", Removable: true, Embeds: 1}},
+		},
+		{
+			name:     "Steam and itch.io",
+			cfg:      config.Guild{GameEmbeds: true},
+			content:  "https://store.steampowered.com/app/620 https://store.steampowered.com/app/620/ https://maker.itch.io/game",
+			expected: []sentMessage{{ChannelID: "chan", ReplyTo: "msg", Removable: true, Embeds: 2}},
+		},
+		{name: "Angle brackets", cfg: config.Guild{GameEmbeds: true}, content: "<https://store.steampowered.com/app/620>"},
+		{name: "Missing game", cfg: config.Guild{GameEmbeds: true}, content: "https://maker.itch.io/other"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.cfg); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{Pool: workerpool.New(1, 10), Store: st, Games: gamestores.New(server.URL, server.URL+"/%s", "", server.Client())}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", tc.content))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	testCases := []struct {
+		name     string
+		game     gamestores.Game
+		expected string
+	}{
+		{name: "Unknown"},
+		{name: "Full price", game: gamestores.Game{Price: "$9.99"}, expected: "$9.99"},
+		{name: "Discounted", game: gamestores.Game{Price: "$1.99", OriginalPrice: "$9.99", Discount: 80}, expected: "~~$9.99~~ **$1.99** (-80%)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if price := formatPrice(&tc.game); price != tc.expected {
+				t.Errorf("formatPrice = %q; want %q", price, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleReactionAddStarboard(t *testing.T) {
+	starred := func(id, channelID string, stars int) *discordgo.Message {
+		return &discordgo.Message{
+			ID:        id,
+			ChannelID: channelID,
+			Author:    &discordgo.User{ID: "user", Username: "user"},
+			Content:   "look at this",
+			Reactions: []*discordgo.MessageReactions{{Count: stars, Emoji: &discordgo.Emoji{Name: starEmoji}}},
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		cfg       config.Guild
+		message   *discordgo.Message
+		reactions int
+		sent      []sentMessage
+		edited    []sentMessage
+	}{
+		{
+			name:      "Enough stars",
+			cfg:       config.Guild{StarboardChannel: "stars", StarThreshold: 2},
+			message:   starred("msg", "chan", 2),
+			reactions: 1,
+			sent:      []sentMessage{{ChannelID: "stars", Content: "⭐ **2** in <#chan>", Embeds: 1}},
+		},
+		{
+			name:      "Starred again",
+			cfg:       config.Guild{StarboardChannel: "stars", StarThreshold: 2},
+			message:   starred("msg", "chan", 3),
+			reactions: 2,
+			sent:      []sentMessage{{ChannelID: "stars", Content: "⭐ **3** in <#chan>", Embeds: 1}},
+			edited:    []sentMessage{{ChannelID: "stars", Content: "⭐ **3** in <#chan>"}},
+		},
+		{name: "Too few stars", cfg: config.Guild{StarboardChannel: "stars"}, message: starred("msg", "chan", 2), reactions: 1},
+		{name: "No starboard", cfg: config.Guild{}, message: starred("msg", "chan", 5), reactions: 1},
+		{name: "Starboard post", cfg: config.Guild{StarboardChannel: "stars"}, message: starred("msg", "stars", 5), reactions: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.cfg); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{messages: map[string]*discordgo.Message{"msg": tc.message}}
+			h := &Handler{Pool: workerpool.New(1, 10), Store: st}
+			for range tc.reactions {
+				h.HandleReactionAdd(s, testBotID, &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+					UserID:    "user",
+					MessageID: "msg",
+					ChannelID: tc.message.ChannelID,
+					GuildID:   "guild",
+					Emoji:     discordgo.Emoji{Name: starEmoji},
+				}})
+			}
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.sent) {
+				t.Errorf("sent %+v; want %+v", sent, tc.sent)
+			}
+			if edited := s.Edited(); !slices.Equal(edited, tc.edited) {
+				t.Errorf("edited %+v; want %+v", edited, tc.edited)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateCrossposts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mode     string
+		channel  discordgo.ChannelType
+		expected []string
+	}{
+		{name: "Off", mode: "", channel: discordgo.ChannelTypeGuildNews},
+		{name: "Reposts", mode: config.CrosspostReposts, channel: discordgo.ChannelTypeGuildNews, expected: []string{"sent1"}},
+		{name: "All", mode: config.CrosspostAll, channel: discordgo.ChannelTypeGuildNews, expected: []string{"msg", "sent1"}},
+		{name: "Text channel", mode: config.CrosspostAll, channel: discordgo.ChannelTypeGuildText},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{Crosspost: tc.mode}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{channels: map[string]*discordgo.Channel{"chan": {ID: "chan", Type: tc.channel}}}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Crossposts: crosspost.New()}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/1"))
+			h.Pool.Stop()
+
+			if len(s.Sent()) != 1 {
+				t.Fatalf("sent %+v; want one repost", s.Sent())
+			}
+			if published := s.Crossposted(); !slices.Equal(published, tc.expected) {
+				t.Errorf("published %q; want %q", published, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateReactionMode(t *testing.T) {
+	testCases := []struct {
+		name      string
+		reactErrs []error
+		reactions []string
+		sent      []sentMessage
+		emojis    []string
+	}{
+		{name: "Not asked for", reactions: []string{"msg 🔗"}},
+		{
+			name:      "Asked for",
+			emojis:    []string{linkEmoji},
+			reactions: []string{"msg 🔗"},
+			sent:      []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", ReplyTo: "msg", Removable: true}},
+		},
+		{
+			name:      "Asked for twice",
+			emojis:    []string{linkEmoji, linkEmoji},
+			reactions: []string{"msg 🔗"},
+			sent:      []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", ReplyTo: "msg", Removable: true}},
+		},
+		{name: "Other emoji", emojis: []string{"👍"}, reactions: []string{"msg 🔗"}},
+		{
+			name:      "Can't react",
+			reactErrs: []error{errors.New("missing permissions")},
+			sent:      []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", ReplyTo: "msg", Removable: true}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{RepostMode: config.RepostReaction}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{reactErrs: tc.reactErrs}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Pending: pending.New(time.Hour)}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/1"))
+			for _, emoji := range tc.emojis {
+				h.HandleReactionAdd(s, testBotID, &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+					UserID:    "other",
+					MessageID: "msg",
+					ChannelID: "chan",
+					GuildID:   "guild",
+					Emoji:     discordgo.Emoji{Name: emoji},
+				}})
+			}
+			h.Pool.Stop()
+
+			if reactions := s.Reactions(); !slices.Equal(reactions, tc.reactions) {
+				t.Errorf("reacted %q; want %q", reactions, tc.reactions)
+			}
+			if sent := s.Sent(); !slices.Equal(sent, tc.sent) {
+				t.Errorf("sent %+v; want %+v", sent, tc.sent)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateTraces(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		spans    []string
+		decision string
+	}{
+		{name: "Fixed", content: "https://x.com/user/status/1", spans: []string{"fixer twitter", "send message", "fix message", "message create"}, decision: "reposted"},
+		{name: "Nothing to fix", content: "no links here", spans: []string{"fixer twitter", "fix message", "message create"}, decision: "unchanged"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var spans []map[string]any
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					ResourceSpans []struct {
+						ScopeSpans []struct {
+							Spans []map[string]any `json:"spans"`
+						} `json:"scopeSpans"`
+					} `json:"resourceSpans"`
+				}
+				json.NewDecoder(r.Body).Decode(&req)
+				for _, rs := range req.ResourceSpans {
+					for _, ss := range rs.ScopeSpans {
+						spans = append(spans, ss.Spans...)
+					}
+				}
+			}))
+			defer srv.Close()
+
+			tracer := tracing.New(srv.URL, "test", srv.Client())
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Tracer: tracer}
+			h.HandleMessageCreate(&fakeSession{}, testBotID, newTestMessage("user", tc.content))
+			h.Pool.Stop()
+			if err := tracer.Flush(context.Background()); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+
+			var names []string
+			for _, span := range spans {
+				names = append(names, span["name"].(string))
+			}
+			if !slices.Equal(names, tc.spans) {
+				t.Errorf("spans %q; want %q", names, tc.spans)
+			}
+			root := spans[len(spans)-1]
+			if attrs, _ := json.Marshal(root["attributes"]); !strings.Contains(string(attrs), `"decision","value":{"stringValue":"`+tc.decision+`"}`) {
+				t.Errorf("root span attributes %s; want decision %q", attrs, tc.decision)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateRecordsDecisions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		guild    config.Guild
+		content  string
+		steps    []explain.Step
+		decision string
+	}{
+		{
+			name:    "Fixed",
+			content: "https://x.com/user/status/1",
+			steps: []explain.Step{
+				{Stage: "ignore list", Result: "not ignored"},
+				{Stage: "channel", Result: "fixing is on"},
+				{Stage: "fixer twitter", Result: "rewrote links"},
+				{Stage: "fixer cleaner", Result: "no match"},
+			},
+			decision: "reposted",
+		},
+		{
+			name:    "Fixer turned off",
+			guild:   config.Guild{DisabledFixers: []string{"twitter"}},
+			content: "https://x.com/user/status/1",
+			steps: []explain.Step{
+				{Stage: "ignore list", Result: "not ignored"},
+				{Stage: "channel", Result: "fixing is on"},
+				{Stage: "disabled fixers", Result: "twitter"},
+				{Stage: "fixer cleaner", Result: "no match"},
+				{Stage: "previews", Result: "off"},
+			},
+			decision: "unchanged",
+		},
+		{
+			name:    "Channel turned off",
+			guild:   config.Guild{Channels: []string{"other"}},
+			content: "https://x.com/user/status/1",
+			steps: []explain.Step{
+				{Stage: "ignore list", Result: "not ignored"},
+				{Stage: "channel", Result: "fixing is only on in other channels"},
+			},
+			decision: "channel disabled",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.guild); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			h := &Handler{
+				Fixers:    fixers.Pipeline{fixers.Twitter{}, fixers.Cleaner{}},
+				Pool:      workerpool.New(1, 10),
+				Store:     st,
+				Decisions: explain.New(10),
+			}
+			h.HandleMessageCreate(&fakeSession{}, testBotID, newTestMessage("user", tc.content))
+			h.Pool.Stop()
+
+			trace, ok := h.Decisions.Get("msg")
+			if !ok {
+				t.Fatal("no decision recorded")
+			}
+			if trace.Decision != tc.decision || !slices.Equal(trace.Steps, tc.steps) {
+				t.Errorf("recorded %q after %+v; want %q after %+v", trace.Decision, trace.Steps, tc.decision, tc.steps)
+			}
+			if found, ok := h.Decisions.Find(trace.CorrelationID); !ok || found.MessageID != "msg" {
+				t.Errorf("no decision recorded under correlation ID %q", trace.CorrelationID)
+			}
+		})
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "guild", config.Guild{IgnoredUsers: []string{"blocked"}}); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	duplicates := dedupe.New(time.Hour)
+	duplicates.Record("guild", "4", "elsewhere", "fix")
+
+	message := func(id, authorID, content string) *discordgo.Message {
+		return &discordgo.Message{ID: id, ChannelID: "chan", Author: &discordgo.User{ID: authorID}, Content: content}
+	}
+	reply := message("r2", testBotID, "https://fixupx.com/user/status/2")
+	reply.MessageReference = &discordgo.MessageReference{MessageID: "m2"}
+	otherBot := message("b1", "otherbot", "https://x.com/user/status/6")
+	otherBot.Author.Bot = true
+	s := &fakeSession{history: []*discordgo.Message{
+		message("r3", testBotID, "https://fixupx.com/user/status/3"),
+		message("m3", "user", "https://x.com/user/status/3"),
+		reply,
+		message("m2", "user", "https://x.com/user/status/2"),
+		message("m4", "user", "https://x.com/user/status/4"),
+		message("blocked", "blocked", "https://x.com/user/status/7"),
+		otherBot,
+		message("chat", "user", "hello"),
+		message("m1", "user", "https://x.com/user/status/1"),
+		message("m5", "user", "https://x.com/user/status/5"),
+	}}
+	h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Duplicates: duplicates}
+
+	queued, err := h.Backfill(context.Background(), s, testBotID, "guild", "chan", 50)
+	h.Pool.Stop()
+	if err != nil {
+		t.Fatalf("Backfill: %v", err)
+	}
+	if queued != 2 {
+		t.Errorf("queued %d messages; want 2", queued)
+	}
+	var got []string
+	for _, sent := range s.Sent() {
+		got = append(got, sent.Content)
+	}
+	// Oldest first
+	expected := []string{"https://fixupx.com/user/status/5", "https://fixupx.com/user/status/1"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("sent %q; want %q", got, expected)
+	}
+}
+
+func TestConfirmFix(t *testing.T) {
+	prompt := sentMessage{ChannelID: "dm-user", Content: "Post this fix of your message in <#chan>? https://discord.com/channels/guild/chan/msg", Removable: true, Embeds: 1}
+	testCases := []struct {
+		name     string
+		post     bool
+		expected []sentMessage
+	}{
+		{name: "Post", post: true,
+			expected: []sentMessage{prompt, {ChannelID: "chan", Content: "https://fixupx.com/user/status/1", ReplyTo: "msg", Removable: true}}},
+		{name: "Cancel", post: false, expected: []sentMessage{prompt}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{RepostMode: config.RepostConfirm}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Pending: pending.New(time.Hour), Confirmations: pending.New(time.Hour)}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/1"))
+			// Reacting for the fix is only for guilds in reaction mode
+			h.HandleReactionAdd(s, testBotID, &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+				UserID: "other", MessageID: "msg", ChannelID: "chan", GuildID: "guild", Emoji: discordgo.Emoji{Name: "🔗"},
+			}})
+			h.Pool.Stop()
+
+			h.Pool = workerpool.New(1, 10)
+			if !h.ConfirmFix(s, "msg", tc.post) {
+				t.Error("ConfirmFix = false; want true")
+			}
+			if h.ConfirmFix(s, "msg", tc.post) {
+				t.Error("ConfirmFix a second time = true; want false")
+			}
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestChannelTypes(t *testing.T) {
+	known := map[string]*discordgo.Channel{
+		"forum":  {ID: "forum", GuildID: "guild", Type: discordgo.ChannelTypeGuildForum, AvailableTags: []discordgo.ForumTag{{ID: "1", Name: "art"}, {ID: "2", Name: "Raw"}}},
+		"post":   {ID: "post", GuildID: "guild", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "forum", AppliedTags: []string{"1"}},
+		"tagged": {ID: "tagged", GuildID: "guild", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "forum", AppliedTags: []string{"1", "2"}},
+		"other":  {ID: "other", GuildID: "guild", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "elsewhere"},
+		"voice":  {ID: "voice", GuildID: "guild", Type: discordgo.ChannelTypeGuildVoice},
+	}
+	testCases := []struct {
+		name      string
+		channelID string
+		expected  []sentMessage
+		joined    []string
+	}{
+		{name: "Post in a picked forum", channelID: "post",
+			expected: []sentMessage{{ChannelID: "post", Content: "https://fixupx.com/user/status/1", Removable: true}}, joined: []string{"post"}},
+		{name: "Thread in another channel", channelID: "other"},
+		{name: "Post tagged like the skip marker", channelID: "tagged"},
+		{name: "Voice channel chat", channelID: "voice"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{Channels: []string{"forum", "voice"}}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{channels: known}
+			h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Channels: channels.New()}
+			h.HandleThreadCreate(s, &discordgo.ThreadCreate{Channel: known[tc.channelID], NewlyCreated: true})
+			m := newTestMessage("user", "https://x.com/user/status/1")
+			m.ChannelID = tc.channelID
+			h.HandleMessageCreate(s, testBotID, m)
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+			if !slices.Equal(s.joined, tc.joined) {
+				t.Errorf("joined %q; want %q", s.joined, tc.joined)
+			}
+		})
+	}
+}
+
+func TestMemberNames(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "guild", config.Guild{RepostTemplate: "{links} from {name}"}); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	s := &fakeSession{}
+	h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Members: members.New()}
+	nicked := newTestMessage("user", "https://x.com/user/status/1")
+	nicked.Author.Username = "user"
+	nicked.Member = &discordgo.Member{Nick: "Nick"}
+	h.HandleMessageCreate(s, testBotID, nicked)
+	h.Pool.Stop()
+
+	expected := []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1 from Nick", Removable: true}}
+	if sent := s.Sent(); !slices.Equal(sent, expected) {
+		t.Errorf("sent %+v; want %+v", sent, expected)
+	}
+
+	// Messages fetched later come without their member
+	if name := h.member(s, "guild", &discordgo.User{ID: "user", Username: "user"}, nil).DisplayName(); name != "Nick" {
+		t.Errorf("remembered name = %q; want Nick", name)
+	}
+	if name := h.member(s, "guild", &discordgo.User{ID: "other", Username: "other"}, nil).DisplayName(); name != "other" {
+		t.Errorf("unknown member's name = %q; want other", name)
+	}
+	if !slices.Equal(s.requestedMembers, []string{"other"}) {
+		t.Errorf("requested members %q; want [other]", s.requestedMembers)
+	}
+}
+
+func TestHandleMessageCreateOutputStyles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status/1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"tweet":{"id":"1","text":"tweet"}}`))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		outputs  map[string]string
+		content  string
+		expected []sentMessage
+	}{
+		{name: "Plain", content: "https://x.com/user/status/1", expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", Removable: true}}},
+		{name: "Masked", outputs: map[string]string{"twitter": config.OutputMasked}, content: "https://x.com/user/status/1", expected: []sentMessage{{ChannelID: "chan", Content: "[fixupx.com/user/status/1](https://fixupx.com/user/status/1)", Removable: true}}},
+		{name: "Embed", outputs: map[string]string{"twitter": config.OutputEmbed}, content: "https://x.com/user/status/1", expected: []sentMessage{{ChannelID: "chan", Content: "<https://fixupx.com/user/status/1>", Removable: true, Embeds: 1}}},
+		{name: "Embed unavailable", outputs: map[string]string{"twitter": config.OutputEmbed}, content: "https://x.com/user/status/2", expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/2", Removable: true}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{Outputs: tc.outputs}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{}
+			h := &Handler{
+				Fixers: fixers.Pipeline{fixers.Twitter{}},
+				Pool:   workerpool.New(1, 10),
+				Store:  st,
+				Tweets: fxtwitter.New(server.URL, server.Client()),
+			}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", tc.content))
+			h.Pool.Stop()
+
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHandleMessageCreateMinimalMode(t *testing.T) {
+	st := storage.NewMemory()
+	if err := config.SaveGuild(st, "guild", config.Guild{RepostMode: config.RepostMinimal, RepostTemplate: "From {user}: {message}"}); err != nil {
+		t.Fatalf("SaveGuild: %v", err)
+	}
+	s := &fakeSession{}
+	h := &Handler{Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st}
+	h.HandleMessageCreate(s, testBotID, newTestMessage("user", "look at https://x.com/user/status/1\nand https://x.com/user/status/2"))
+	h.Pool.Stop()
+
+	expected := []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1 https://fixupx.com/user/status/2", ReplyTo: "msg", Removable: true}}
+	if sent := s.Sent(); !slices.Equal(sent, expected) {
+		t.Errorf("sent %+v; want %+v", sent, expected)
+	}
+	if !slices.Equal(s.suppressed, []string{"msg"}) {
+		t.Errorf("suppressed embeds of %q; want [msg]", s.suppressed)
+	}
+}
+
+func TestHandleMessageCreateThreadMode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		channel  *discordgo.Channel
+		threads  []string
+		expected []sentMessage
+	}{
+		{
+			name:     "Thread on the message",
+			threads:  []string{"msg"},
+			expected: []sentMessage{{ChannelID: "thread-msg", Content: "https://fixupx.com/user/status/1", Removable: true}},
+		},
+		{
+			name:     "Reply in threads",
+			channel:  &discordgo.Channel{ID: "chan", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "parent"},
+			expected: []sentMessage{{ChannelID: "chan", Content: "https://fixupx.com/user/status/1", ReplyTo: "msg", Removable: true}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", config.Guild{RepostMode: config.RepostThread}); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			s := &fakeSession{channels: map[string]*discordgo.Channel{}}
+			if tc.channel != nil {
+				s.channels[tc.channel.ID] = tc.channel
+			}
+			h := &Handler{Name: "main", Fixers: fixers.Pipeline{fixers.Twitter{}}, Pool: workerpool.New(1, 10), Store: st, Channels: channels.New()}
+			h.HandleMessageCreate(s, testBotID, newTestMessage("user", "https://x.com/user/status/1"))
+			h.Pool.Stop()
+
+			if !slices.Equal(s.threads, tc.threads) {
+				t.Errorf("started threads on %q; want %q", s.threads, tc.threads)
+			}
+			if sent := s.Sent(); !slices.Equal(sent, tc.expected) {
+				t.Errorf("sent %+v; want %+v", sent, tc.expected)
+			}
+			var expectedKeys []string
+			for _, id := range tc.threads {
+				expectedKeys = append(expectedKeys, "main/thread-"+id)
+			}
+			if keys := st.Keys(ThreadBucket); !slices.Equal(keys, expectedKeys) {
+				t.Errorf("tracked threads %q; want %q", keys, expectedKeys)
+			}
+		})
+	}
+}
+
+// snowflakeAt returns a Discord ID made at t.
+func snowflakeAt(t time.Time) string {
+	return strconv.FormatInt((t.UnixMilli()-1420070400000)<<22, 10)
+}
+
+func TestArchiveThreads(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name     string
+		cfg      config.Guild
+		active   time.Time
+		channel  *discordgo.Channel
+		archived bool
+		sent     []sentMessage
+		// stored marks threads the bot archived before.
+		stored  bool
+		tracked bool
+	}{
+		{
+			name:     "Quiet",
+			cfg:      config.Guild{ThreadArchiveHours: 24},
+			active:   now.Add(-25 * time.Hour),
+			channel:  &discordgo.Channel{LastMessageID: snowflakeAt(now.Add(-25 * time.Hour))},
+			archived: true,
+			tracked:  true,
+		},
+		{
+			name:     "Quiet with a summary",
+			cfg:      config.Guild{ThreadArchiveHours: 24, ThreadSummary: true},
+			active:   now.Add(-25 * time.Hour),
+			channel:  &discordgo.Channel{LastMessageID: snowflakeAt(now.Add(-25 * time.Hour)), MessageCount: 3},
+			archived: true,
+			tracked:  true,
+			sent:     []sentMessage{{ChannelID: "thread", Content: "Archiving this thread, as it's gone quiet. Fixed here: <https://fixupx.com/user/status/1>. Messages: 3. Send a message to reopen it."}},
+		},
+		{
+			name:    "Messages since",
+			cfg:     config.Guild{ThreadArchiveHours: 24, ThreadSummary: true},
+			active:  now.Add(-25 * time.Hour),
+			channel: &discordgo.Channel{LastMessageID: snowflakeAt(now.Add(-time.Hour))},
+			tracked: true,
+		},
+		{
+			name:    "Archived already",
+			cfg:     config.Guild{ThreadArchiveHours: 24},
+			active:  now.Add(-25 * time.Hour),
+			channel: &discordgo.Channel{ThreadMetadata: &discordgo.ThreadMetadata{Archived: true}},
+		},
+		{
+			name:    "Not quiet long enough",
+			cfg:     config.Guild{ThreadArchiveHours: 24},
+			active:  now.Add(-time.Hour),
+			channel: &discordgo.Channel{},
+			tracked: true,
+		},
+		{
+			name:    "Archived long ago",
+			cfg:     config.Guild{ThreadArchiveHours: 24},
+			active:  now.Add(-31 * 24 * time.Hour),
+			stored:  true,
+			channel: &discordgo.Channel{},
+		},
+		{
+			name:    "Left to Discord",
+			active:  now.Add(-25 * time.Hour),
+			channel: &discordgo.Channel{},
+			tracked: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.cfg); err != nil {
+				t.Fatalf("SaveGuild: %v", err)
+			}
+			thread := fixThread{GuildID: "guild", ThreadID: "thread", Links: []string{"https://fixupx.com/user/status/1"}, Active: tc.active, Archived: tc.stored}
+			for _, key := range []string{"main/thread", "other/thread"} {
+				if err := st.Put(ThreadBucket, key, thread); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tc.channel.ID = "thread"
+			s := &fakeSession{channels: map[string]*discordgo.Channel{"thread": tc.channel}}
+			h := &Handler{Name: "main", Store: st}
+			h.ArchiveThreads(context.Background(), s, now)
+
+			if archived := len(s.archived) > 0; archived != tc.archived {
+				t.Errorf("archived %q; want archived %v", s.archived, tc.archived)
+			}
+			if sent := s.Sent(); !slices.Equal(sent, tc.sent) {
+				t.Errorf("sent %+v; want %+v", sent, tc.sent)
+			}
+			expectedKeys := []string{"other/thread"}
+			if tc.tracked {
+				expectedKeys = []string{"main/thread", "other/thread"}
+			}
+			if keys := st.Keys(ThreadBucket); !slices.Equal(keys, expectedKeys) {
+				t.Errorf("tracked threads %q; want %q", keys, expectedKeys)
+			}
+			var saved fixThread
+			if ok, _ := st.Get(ThreadBucket, "main/thread", &saved); ok && saved.Archived != tc.archived {
+				t.Errorf("saved as archived %v; want %v", saved.Archived, tc.archived)
+			}
+		})
+	}
+}
+
+func TestReopenThread(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stored   fixThread
+		archived bool
+		expected bool
+	}{
+		{name: "Reopened", stored: fixThread{Archived: true}, expected: false},
+		{name: "Still archived", stored: fixThread{Archived: true}, archived: true, expected: true},
+		{name: "Never archived", stored: fixThread{}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := st.Put(ThreadBucket, "main/thread", tc.stored); err != nil {
+				t.Fatal(err)
+			}
+			h := &Handler{Name: "main", Store: st}
+			h.ThreadUpdate(nil, &discordgo.ThreadUpdate{Channel: &discordgo.Channel{
+				ID:             "thread",
+				Type:           discordgo.ChannelTypeGuildPublicThread,
+				ThreadMetadata: &discordgo.ThreadMetadata{Archived: tc.archived},
+			}})
+
+			var saved fixThread
+			if _, err := st.Get(ThreadBucket, "main/thread", &saved); err != nil {
+				t.Fatal(err)
+			}
+			if saved.Archived != tc.expected {
+				t.Errorf("archived = %v; want %v", saved.Archived, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLeaveOutside(t *testing.T) {
+	s := &fakeSession{}
+	h := &Handler{Pool: workerpool.New(1, 10), Guilds: []string{"test"}, Leave: true}
+	h.HandleGuildCreate(s, &discordgo.Guild{ID: "test", SystemChannelID: "welcome"})
+	h.HandleGuildCreate(s, &discordgo.Guild{ID: "other", Channels: []*discordgo.Channel{
+		{ID: "voice", Type: discordgo.ChannelTypeGuildVoice},
+		{ID: "rules", Type: discordgo.ChannelTypeGuildText, Position: 2},
+		{ID: "general", Type: discordgo.ChannelTypeGuildText, Position: 1},
+	}})
+	h.Pool.Stop()
+
+	if expected := []sentMessage{{ChannelID: "general", Content: leaveNotice}}; !slices.Equal(s.Sent(), expected) {
+		t.Errorf("sent %+v; want %+v", s.Sent(), expected)
+	}
+	if !slices.Equal(s.left, []string{"other"}) {
+		t.Errorf("left %v; want [other]", s.left)
+	}
+}
+
+func TestHandleGuildMemberAdd(t *testing.T) {
+	testCases := []struct {
+		name          string
+		cfg           config.Guild
+		bot           bool
+		expectedSent  []sentMessage
+		expectedRoles []string
+	}{
+		{name: "Off"},
+		{
+			name:         "Default message",
+			cfg:          config.Guild{WelcomeChannel: "welcome"},
+			expectedSent: []sentMessage{{ChannelID: "welcome", Content: "Welcome to Cats, <@new>!"}},
+		},
+		{
+			name:          "Message and role",
+			cfg:           config.Guild{WelcomeChannel: "welcome", WelcomeMessage: "Hi {name}, member #{count}", WelcomeRole: "newbie"},
+			expectedSent:  []sentMessage{{ChannelID: "welcome", Content: "Hi Newt, member #12"}},
+			expectedRoles: []string{"guild/new/newbie"},
+		},
+		{name: "Paused", cfg: config.Guild{WelcomeChannel: "welcome", WelcomeRole: "newbie", Paused: true}},
+		{name: "Bot", cfg: config.Guild{WelcomeChannel: "welcome", WelcomeRole: "newbie"}, bot: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			if err := config.SaveGuild(st, "guild", tc.cfg); err != nil {
+				t.Fatal(err)
+			}
+			s := &fakeSession{}
+			h := &Handler{Pool: workerpool.New(1, 10), Store: st}
+			member := &discordgo.Member{GuildID: "guild", User: &discordgo.User{ID: "new", Username: "Newt", Bot: tc.bot}}
+			h.HandleGuildMemberAdd(s, &discordgo.GuildMemberAdd{Member: member}, "Cats", 12)
+			h.Pool.Stop()
+
+			if !slices.Equal(s.Sent(), tc.expectedSent) {
+				t.Errorf("sent %+v; want %+v", s.Sent(), tc.expectedSent)
+			}
+			if !slices.Equal(s.roles, tc.expectedRoles) {
+				t.Errorf("gave roles %v; want %v", s.roles, tc.expectedRoles)
+			}
+		})
+	}
+}
+
+func TestGuildOutage(t *testing.T) {
+	h := &Handler{Outages: outages.New(), Channels: channels.New()}
+	h.GuildDelete(nil, &discordgo.GuildDelete{Guild: &discordgo.Guild{ID: "guild", Unavailable: true}})
+
+	ran := 0
+	h.whenAvailable("guild", func() { ran++ })
+	h.whenAvailable("other", func() { ran++ })
+	if ran != 1 {
+		t.Fatalf("ran %d checks during the outage; want only the other guild's", ran)
+	}
+
+	h.HandleGuildCreate(&fakeSession{}, &discordgo.Guild{ID: "guild", Channels: []*discordgo.Channel{{ID: "voice", GuildID: "guild", Type: discordgo.ChannelTypeGuildVoice}}})
+	if ran != 2 {
+		t.Errorf("ran %d checks after the outage; want 2", ran)
+	}
+	// The channels the guild came back with are known without asking Discord
+	if info, err := h.Channels.Get(context.Background(), &fakeSession{}, "voice"); err != nil || !info.VoiceChat() {
+		t.Errorf("channel after the outage = %+v, %v; want a voice chat", info, err)
+	}
+}
+
+func TestForget(t *testing.T) {
+	seed := func() storage.Store {
+		st := storage.NewMemory()
+		st.Put(StarboardBucket, "1/msg", starPost{MessageID: "post"})
+		st.Put(StarboardBucket, "10/msg", starPost{MessageID: "post"})
+		st.Put(ExpiryBucket, "main/chan/msg", expiry{GuildID: "1"})
+		st.Put(PreviewCheckBucket, "main/chan/a", previewCheck{GuildID: "1", AuthorID: "alice"})
+		st.Put(PreviewCheckBucket, "main/chan/b", previewCheck{GuildID: "2", AuthorID: "alice"})
+		st.Put(PreviewCheckBucket, "main/chan/c", previewCheck{GuildID: "1", AuthorID: "bob"})
+		st.Put(ThreadBucket, "main/thread", fixThread{GuildID: "2"})
+		return st
+	}
+
+	testCases := []struct {
+		name            string
+		guildID, userID string
+		expected        map[string]int
+	}{
+		{name: "Guild", guildID: "1", expected: map[string]int{StarboardBucket: 1, ExpiryBucket: 1, PreviewCheckBucket: 2}},
+		{name: "Other guild", guildID: "2", expected: map[string]int{PreviewCheckBucket: 1, ThreadBucket: 1}},
+		{name: "User in a guild", guildID: "1", userID: "alice", expected: map[string]int{PreviewCheckBucket: 1}},
+		{name: "User everywhere", userID: "alice", expected: map[string]int{PreviewCheckBucket: 2}},
+		{name: "Nobody", expected: map[string]int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := seed()
+			deleted := map[string]int{}
+			for bucket, forget := range map[string]func(storage.Store, string, string) (int, error){
+				StarboardBucket:    ForgetStarboard,
+				ExpiryBucket:       ForgetExpiries,
+				PreviewCheckBucket: ForgetPreviewChecks,
+				ThreadBucket:       ForgetThreads,
+			} {
+				before := len(st.Keys(bucket))
+				n, err := forget(st, tc.guildID, tc.userID)
+				if err != nil {
+					t.Fatalf("forgetting %s: %v", bucket, err)
+				}
+				if left := len(st.Keys(bucket)); before-left != n {
+					t.Errorf("%s: reported %d deleted; %d were", bucket, n, before-left)
+				}
+				if n > 0 {
+					deleted[bucket] = n
+				}
+			}
+			if fmt.Sprint(deleted) != fmt.Sprint(tc.expected) {
+				t.Errorf("deleted %v; want %v", deleted, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/maintenance"
+)
+
+// FixHeld fixes the messages this handler's bot held during maintenance, as if
+// they had just been posted. Like Reprocess it skips the ignore and flood
+// checks, which the messages passed when they were held. Messages deleted
+// since are skipped. It returns how many it queued.
+func (h *Handler) FixHeld(s Session, held []maintenance.Held) int {
+	queued := 0
+	for _, msg := range held {
+		if msg.Bot != h.Name {
+			continue
+		}
+		ctx, cancel := h.operation()
+		m, err := s.ChannelMessage(msg.ChannelID, msg.MessageID, discordgo.WithContext(ctx))
+		cancel()
+		if err != nil {
+			log.Println("Error fetching message held for maintenance:", err)
+			continue
+		}
+		// Messages fetched over REST don't say which guild they're in
+		m.GuildID = msg.GuildID
+		m = withSnapshot(m)
+		if !h.queue(h.trace("maintenance", m), s, &discordgo.MessageCreate{Message: m}) {
+			log.Println("Worker queue full, dropping message held for maintenance", m.ID)
+			continue
+		}
+		queued++
+	}
+	return queued
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// member returns the member of guildID that user is: known, such as the
+// member a message came with, or else as remembered. Without either it's just
+// user, and the gateway is asked for them for next time. A nil user gives nil.
+func (h *Handler) member(s Session, guildID string, user *discordgo.User, known *discordgo.Member) *discordgo.Member {
+	if user == nil {
+		return nil
+	}
+	if known != nil {
+		copied := *known
+		copied.GuildID = guildID
+		copied.User = user
+		return &copied
+	}
+	return h.Members.Member(s, guildID, user)
+}
+
+// GuildMembersChunk is the discordgo handler for members the bot asked the
+// gateway for.
+func (h *Handler) GuildMembersChunk(_ *discordgo.Session, c *discordgo.GuildMembersChunk) {
+	h.Members.Chunk(c)
+}
+
+// GuildMemberUpdate is the discordgo handler for members whose nickname or
+// avatar changed. It needs the server members intent.
+func (h *Handler) GuildMemberUpdate(_ *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+	if m.Member != nil {
+		h.Members.Remember(m.GuildID, m.Member, nil)
+	}
+}
+
+// GuildMemberRemove is the discordgo handler for members who left. It needs
+// the server members intent.
+func (h *Handler) GuildMemberRemove(_ *discordgo.Session, m *discordgo.GuildMemberRemove) {
+	if m.Member != nil && m.User != nil {
+		h.Members.Forget(m.GuildID, m.User.ID)
+		if m.User.Bot {
+			h.Others.Forget(m.GuildID, m.User.ID)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/correlation"
+)
+
+// suppressEmbeds hides the embeds of m, whose fixed links the bot just
+// replied with in minimal mode. Without Manage Messages the embeds stay.
+func (h *Handler) suppressEmbeds(ctx context.Context, s Session, m *discordgo.Message) {
+	if m.Flags&discordgo.MessageFlagsSuppressEmbeds != 0 {
+		return
+	}
+	err := h.Retry.Do(ctx, "suppress embeds", func() error {
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:      m.ID,
+			Channel: m.ChannelID,
+			Flags:   m.Flags | discordgo.MessageFlagsSuppressEmbeds,
+		}, discordgo.WithContext(ctx))
+		return err
+	})
+	if err != nil {
+		correlation.Println(ctx, "Error suppressing embeds of fixed message:", err)
+	}
+}
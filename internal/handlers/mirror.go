@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/access"
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/mirror"
+)
+
+// mirrorMedia downloads the photos and videos of fixed tweets, for guilds that
+// keep copies of them, as attachments that fit in one message in the guild.
+// It also returns the URLs of the media too big to upload, and of all of it.
+func (h *Handler) mirrorMedia(ctx context.Context, guildID string, tweetIDs []string, cfg config.Guild, roles []string) (files []*discordgo.File, tooBig, all []string) {
+	if h.Mirror == nil || h.Tweets == nil || !cfg.MirrorMedia || !access.Allowed(cfg, access.Mirror, roles) {
+		return nil, nil, nil
+	}
+	// Nothing is looked up for media the guild has no mirrors left for
+	if h.Budget.Left(guildID, budget.Mirrors) == 0 {
+		return nil, nil, nil
+	}
+	var sources []mirror.Source
+	for _, id := range tweetIDs {
+		if !h.Budget.Spend(guildID, budget.Lookups, 1) {
+			break
+		}
+		tweet, err := h.Tweets.Status(ctx, id)
+		if err != nil {
+			correlation.Println(ctx, "Error fetching tweet media:", err)
+			continue
+		}
+		if tweet.Media == nil {
+			continue
+		}
+		for _, item := range tweet.Media.All {
+			if item.URL != "" {
+				sources = append(sources, mirror.Source{URL: item.URL, Smaller: item.Smaller()})
+				all = append(all, item.URL)
+			}
+		}
+	}
+	if len(sources) == 0 || !h.Budget.Spend(guildID, budget.Mirrors, 1) {
+		return nil, nil, nil
+	}
+	files, tooBig = h.Mirror.Fetch(ctx, sources, h.uploadLimit(guildID))
+	return files, tooBig, all
+}
+
+// uploadLimit returns how much a message may upload in a guild.
+func (h *Handler) uploadLimit(guildID string) int64 {
+	if h.UploadLimit == nil || guildID == "" {
+		return mirror.Limit
+	}
+	return h.UploadLimit(guildID)
+}
+
+// tooBigNote links media too big to upload in a guild allowing limit bytes
+// per message, so it can still be seen.
+func tooBigNote(urls []string, limit int64) string {
+	return fmt.Sprintf("Too big to upload here (over %d MB), linked instead: %s", limit>>20, strings.Join(urls, " "))
+}
+
+// withLine adds line to the end of the last of pieces, or as a piece of its
+// own if it doesn't fit there.
+func withLine(pieces []string, line string) []string {
+	if len(pieces) == 0 {
+		return []string{line}
+	}
+	last := pieces[len(pieces)-1]
+	if len(last)+1+len(line) > chunk.MaxMessageLength {
+		return append(pieces, line)
+	}
+	return append(pieces[:len(pieces)-1], last+"\n"+line)
+}
+
+// attachGalleries replies to a message whose tweets were left alone, for
+// guilds that want it, with copies of the photos their embeds don't show. It
+// reports whether it replied.
+func (h *Handler) attachGalleries(ctx context.Context, s Session, m *discordgo.MessageCreate, cfg config.Guild) bool {
+	if h.Mirror == nil || h.Tweets == nil || cfg.Galleries != config.GalleryAttach || !cfg.FixerEnabled("twitter") {
+		return false
+	}
+	if h.Budget.Left(m.GuildID, budget.Mirrors) == 0 || !h.Budget.Spend(m.GuildID, budget.Lookups, 1) {
+		return false
+	}
+	var sources []mirror.Source
+	for _, gallery := range fixers.Galleries(ctx, h.Tweets, m) {
+		for _, photo := range gallery.Missing {
+			sources = append(sources, mirror.Source{URL: photo, Smaller: fxtwitter.SmallerPhotos(photo)})
+		}
+	}
+	if len(sources) == 0 || !h.Budget.Spend(m.GuildID, budget.Mirrors, 1) {
+		return false
+	}
+	limit := h.uploadLimit(m.GuildID)
+	files, tooBig := h.Mirror.Fetch(ctx, sources, limit)
+	if len(files) == 0 && len(tooBig) == 0 {
+		return false
+	}
+	content := ""
+	if len(tooBig) > 0 {
+		content = tooBigNote(tooBig, limit)
+	}
+	_, err := h.send(ctx, s, m.ChannelID, &discordgo.MessageSend{
+		Content:         content,
+		Files:           files,
+		Reference:       m.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Components:      []discordgo.MessageComponent{commands.RemoveButton(m.Author.ID)},
+	})
+	return err == nil
+}
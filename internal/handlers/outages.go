@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fixedMessageKey is the ID the original message of a repost is remembered
+// by in Duplicates, next to the tweets it fixed.
+func fixedMessageKey(messageID string) string {
+	return "message:" + messageID
+}
+
+// whenAvailable runs job now, or once guildID is back if Discord can't reach
+// it right now.
+func (h *Handler) whenAvailable(guildID string, job func()) {
+	if !h.Outages.Hold(guildID, job) {
+		job()
+	}
+}
+
+// GuildDelete is the discordgo handler for guilds the bot left or that went
+// unavailable in an outage. Checks due in unavailable guilds wait for them.
+func (h *Handler) GuildDelete(_ *discordgo.Session, g *discordgo.GuildDelete) {
+	if !g.Unavailable {
+		h.Outages.End(g.ID)
+		return
+	}
+	if h.Outages.Start(g.ID) {
+		log.Println("Guild", g.ID, "is unavailable, holding its checks until it's back")
+	}
+}
+
+// GuildCreate is the discordgo handler for guilds becoming available, as
+// when the bot connects or a guild comes back from an outage.
+func (h *Handler) GuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	h.HandleGuildCreate(s, g.Guild)
+}
+
+// HandleGuildCreate does the work of GuildCreate against any Session.
+func (h *Handler) HandleGuildCreate(s Session, g *discordgo.Guild) {
+	if h.leaveOutside(s, g) {
+		return
+	}
+	if held, lasted, ok := h.guildBack(g); ok {
+		log.Printf("Guild %s is back after %s, ran %d held checks", g.ID, lasted.Round(time.Second), held)
+	}
+}
+
+// guildBack ends the outage of g, if it was down. The channels and members
+// it came with are remembered again, in case they changed while it was gone,
+// and the checks held for it run. It returns how many there were and how
+// long the guild was gone.
+func (h *Handler) guildBack(g *discordgo.Guild) (int, time.Duration, bool) {
+	held, lasted, ok := h.Outages.End(g.ID)
+	if !ok {
+		return 0, 0, false
+	}
+	for _, ch := range g.Channels {
+		h.Channels.Remember(ch)
+	}
+	for _, t := range g.Threads {
+		h.Channels.Remember(t)
+	}
+	for _, m := range g.Members {
+		h.Members.Remember(g.ID, m, nil)
+	}
+	for _, job := range held {
+		job()
+	}
+	return len(held), lasted, true
+}
+
+// Disconnect is the discordgo handler for a shard losing its connection.
+// Every guild on it is down until the shard resumes or the guild is sent
+// again.
+func (h *Handler) Disconnect(s *discordgo.Session, _ *discordgo.Disconnect) {
+	for _, id := range stateGuilds(s) {
+		h.Outages.Start(id)
+	}
+}
+
+// Resumed is the discordgo handler for a shard resuming its connection.
+// Discord sends the events it missed, so only the held checks need to run.
+func (h *Handler) Resumed(s *discordgo.Session, _ *discordgo.Resumed) {
+	total := 0
+	for _, id := range stateGuilds(s) {
+		held, _, _ := h.guildBack(&discordgo.Guild{ID: id})
+		total += held
+	}
+	if total > 0 {
+		log.Printf("Shard %d resumed, ran %d held checks", s.ShardID, total)
+	}
+}
+
+// stateGuilds returns the IDs of the guilds s knows about. Unavailable guilds
+// are left out of its state.
+func stateGuilds(s *discordgo.Session) []string {
+	if s.State == nil {
+		return nil
+	}
+	s.State.RLock()
+	defer s.State.RUnlock()
+	ids := make([]string, len(s.State.Guilds))
+	for n, g := range s.State.Guilds {
+		ids[n] = g.ID
+	}
+	return ids
+}
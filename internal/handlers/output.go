@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/patterns"
+)
+
+// outputStyles returns the output style of each fixed link whose fixer the
+// guild picked one for, and the embeds the bot builds for links in
+// config.OutputEmbed style. Links whose embed can't be built, or that guildID
+// has no lookups left for, are shown plain.
+func (h *Handler) outputStyles(ctx context.Context, guildID string, cfg config.Guild, origins map[string]string, changed []string) (map[string]string, []*discordgo.MessageEmbed) {
+	styles := make(map[string]string)
+	var embeds []*discordgo.MessageEmbed
+	for _, link := range changed {
+		style := cfg.Output(origins[link])
+		if style == config.OutputEmbed {
+			embed, ok := h.outputEmbed(ctx, guildID, link)
+			if !ok || len(embeds) == maxContextEmbeds {
+				continue
+			}
+			embeds = append(embeds, embed)
+		}
+		if style != config.OutputPlain {
+			styles[link] = style
+		}
+	}
+	return styles, embeds
+}
+
+// outputEmbed looks up the tweet link points to and renders it as an embed.
+func (h *Handler) outputEmbed(ctx context.Context, guildID, link string) (*discordgo.MessageEmbed, bool) {
+	match := patterns.TweetID.FindStringSubmatch(link)
+	if h.Tweets == nil || match == nil || !h.Budget.Spend(guildID, budget.Lookups, 1) {
+		return nil, false
+	}
+	tweet, err := h.Tweets.Status(ctx, match[1])
+	if err != nil {
+		correlation.Println(ctx, "Error fetching tweet to embed:", err)
+		return nil, false
+	}
+	return tweetEmbed(tweet, "Tweet"), true
+}
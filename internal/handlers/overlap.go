@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+)
+
+// watchOtherFixers learns which other bots fix links in the guild: it
+// remembers the tweets people share, and tells moderators the first time
+// another bot reposts one through an embed proxy.
+func (h *Handler) watchOtherFixers(s Session, m *discordgo.MessageCreate) {
+	if h.Others == nil || m.GuildID == "" {
+		return
+	}
+	if !m.Author.Bot {
+		h.Others.Shared(m.GuildID, m.ChannelID, m.Author.ID, m.Content)
+		return
+	}
+	cfg := h.guildConfig(m.GuildID)
+	if cfg.OthersMode() == config.OthersIgnore || !h.Others.Observe(m.GuildID, m.ChannelID, m.Author.ID, m.Content) {
+		return
+	}
+	notice := fmt.Sprintf("<@%s> seems to fix links too: it reposted a tweet moments after someone shared it in <#%s>.", m.Author.ID, m.ChannelID)
+	if cfg.OthersMode() == config.OthersYield {
+		notice += " So links aren't reposted twice, this bot leaves them to it now. `/fixerbots mode` changes that."
+	} else {
+		notice += " Both bots repost links here. `/fixerbots mode` can have this one leave them to it."
+	}
+	h.audit(s, m.GuildID, notice)
+}
+
+// otherFixer returns the other link-fixing bot a guild's links are left to,
+// empty when the bot fixes them itself.
+func (h *Handler) otherFixer(cfg config.Guild, guildID string) string {
+	if guildID == "" || cfg.OthersMode() != config.OthersYield {
+		return ""
+	}
+	if len(cfg.FixerBots) > 0 {
+		return cfg.FixerBots[0]
+	}
+	if bots := h.Others.Detected(guildID); len(bots) > 0 {
+		return bots[0]
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/events"
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/phishing"
+)
+
+// phishingLinks returns the links in m that h.Phishing flags, in guilds that
+// have phishing checks on.
+func (h *Handler) phishingLinks(ctx context.Context, m *discordgo.Message, cfg config.Guild) []string {
+	if h.Phishing == nil || m.GuildID == "" || cfg.PhishingAction == config.PhishingOff || !patterns.HasLink(m.Content) {
+		return nil
+	}
+	var links []string
+	for _, link := range patterns.URL.FindAllString(m.Content, -1) {
+		links = append(links, strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">"))
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	flagged, err := h.Phishing.Check(ctx, links)
+	if err != nil {
+		correlation.Println(ctx, "Error checking links for phishing:", err)
+	}
+	return flagged
+}
+
+// checkPhishing looks up a message's links in h.Phishing and, if any are
+// flagged, warns about or deletes the message as the guild chose. It reports
+// whether the message was flagged, in which case it must not be reposted.
+func (h *Handler) checkPhishing(ctx context.Context, s Session, m *discordgo.MessageCreate, cfg config.Guild) bool {
+	flagged := h.phishingLinks(ctx, m.Message, cfg)
+	if len(flagged) == 0 {
+		return false
+	}
+
+	var hosts []string
+	for _, link := range flagged {
+		if host := phishing.Host(link); host != "" && !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+
+	if cfg.PhishingAction == config.PhishingDelete {
+		if h.remove(ctx, s, m.Message, cfg, "phishing", "a known phishing or malware site") {
+			h.Events.Publish(events.Event{Type: events.Phishing, GuildID: m.GuildID, ChannelID: m.ChannelID, MessageID: m.ID, Detail: "deleted"})
+			return true
+		}
+		// Without Manage Messages the best the bot can do is warn
+	}
+
+	h.Events.Publish(events.Event{Type: events.Phishing, GuildID: m.GuildID, ChannelID: m.ChannelID, MessageID: m.ID, Detail: "warned"})
+	h.send(ctx, s, m.ChannelID, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("Warning: this message links to a known phishing or malware site (`%s`). Don't open it or enter any details.", strings.Join(hosts, "`, `")),
+		Reference:       m.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	return true
+}
+
+// remove deletes a message linking to what, keeping it for moderators to
+// restore where the guild allows, and says so in its channel. reason names why
+// it was kept, such as "phishing". It reports whether the message is gone.
+func (h *Handler) remove(ctx context.Context, s Session, m *discordgo.Message, cfg config.Guild, reason, what string) bool {
+	err := h.Retry.Do(ctx, "delete "+reason+" message", func() error {
+		return s.ChannelMessageDelete(m.ChannelID, m.ID, discordgo.WithContext(ctx))
+	})
+	if err != nil {
+		return false
+	}
+	notice := fmt.Sprintf("Removed a message from <@%s> linking to %s.", m.Author.ID, what)
+	if h.Trash != nil && !cfg.Privacy {
+		if err := h.Trash.Keep(m.GuildID, m, reason); err != nil {
+			correlation.Println(ctx, "Error keeping deleted message:", err)
+		} else {
+			notice += " Moderators can restore it with `/deleted restore`."
+		}
+	}
+	h.send(ctx, s, m.ChannelID, &discordgo.MessageSend{
+		Content:         notice,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	return true
+}
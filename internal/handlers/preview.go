@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/patterns"
+)
+
+// DefaultPreviewDelay is how long the handler waits for Discord's own embeds
+// before previewing links itself.
+const DefaultPreviewDelay = 5 * time.Second
+
+// maxPreviews is how many links in one message get a preview.
+const maxPreviews = 3
+
+// PreviewCheckBucket is the store bucket holding the messages waiting to be
+// checked for embeds, keyed by bot, channel and message ID, so checks
+// survive restarts.
+const PreviewCheckBucket = "preview_checks"
+
+// maxPreviewCheckAge is how overdue a saved check may be when the bot starts
+// and still run. Previews of older messages would only be noise.
+const maxPreviewCheckAge = 10 * time.Minute
+
+// previewCheck is a message waiting to be checked for embeds.
+type previewCheck struct {
+	ChannelID string    `json:"channel_id"`
+	MessageID string    `json:"message_id"`
+	GuildID   string    `json:"guild_id"`
+	AuthorID  string    `json:"author_id"`
+	Links     []string  `json:"links"`
+	Due       time.Time `json:"due"`
+}
+
+// message rebuilds enough of the checked message to reply to it.
+func (c previewCheck) message() *discordgo.Message {
+	return &discordgo.Message{ID: c.MessageID, ChannelID: c.ChannelID, GuildID: c.GuildID, Author: &discordgo.User{ID: c.AuthorID}}
+}
+
+// schedulePreviews checks back on a message once Discord has had time to embed
+// its links, and previews them if it didn't. What it finds is noted in record.
+func (h *Handler) schedulePreviews(record *explain.Record, s Session, m *discordgo.MessageCreate, cfg config.Guild) {
+	if h.Previews == nil || !cfg.Previews || m.GuildID == "" {
+		record.Note("previews", "off")
+		return
+	}
+	links := previewLinks(m.Content)
+	if len(links) == 0 {
+		record.Note("previews", "no links to preview")
+		return
+	}
+
+	delay := h.PreviewDelay
+	if delay <= 0 {
+		delay = DefaultPreviewDelay
+	}
+	check := previewCheck{
+		ChannelID: m.ChannelID,
+		MessageID: m.ID,
+		GuildID:   m.GuildID,
+		AuthorID:  m.Author.ID,
+		Links:     links,
+		Due:       time.Now().Add(delay),
+	}
+	key := h.previewCheckKey(m.ChannelID, m.ID)
+	if h.Store != nil {
+		if err := h.Store.Put(PreviewCheckBucket, key, check); err != nil {
+			log.Println("Error saving preview check:", err)
+		}
+	}
+	record.Note("previews", fmt.Sprintf("checking for Discord's embeds after %s", delay))
+	h.runPreviewCheck(record, s, key, m.Message, links, delay)
+}
+
+// runPreviewCheck previews a message's links after delay and forgets its
+// saved check.
+func (h *Handler) runPreviewCheck(record *explain.Record, s Session, key string, m *discordgo.Message, links []string, delay time.Duration) {
+	h.previewTimers.Add(1)
+	time.AfterFunc(delay, func() {
+		h.previewTimers.Add(-1)
+		job := func() {
+			h.postPreviews(record, s, m, links)
+			h.forgetPreviewCheck(key)
+		}
+		h.whenAvailable(m.GuildID, func() {
+			if !h.Pool.Submit(m.ChannelID, job) {
+				log.Println("Worker queue full, dropping previews for message", m.ID)
+				h.forgetPreviewCheck(key)
+			}
+		})
+	})
+}
+
+// PendingPreviews returns how many preview checks, and fallback checks of
+// reposts, are waiting to run.
+func (h *Handler) PendingPreviews() int {
+	return int(h.previewTimers.Load())
+}
+
+// Ready is the callback function for the Ready event. When the first shard
+// connects, it resumes the preview checks and repost deletions saved before
+// the bot last stopped.
+func (h *Handler) Ready(s *discordgo.Session, _ *discordgo.Ready) {
+	if s.ShardID != 0 {
+		return
+	}
+	h.resumed.Do(func() {
+		h.ResumePreviews(s)
+		h.ResumeExpiries(s)
+	})
+}
+
+// ResumePreviews schedules the preview checks the handler saved before the
+// bot last stopped. Checks overdue by more than maxPreviewCheckAge are dropped.
+func (h *Handler) ResumePreviews(s Session) {
+	if h.Store == nil {
+		return
+	}
+	now := time.Now()
+	for _, key := range h.Store.Keys(PreviewCheckBucket) {
+		if !strings.HasPrefix(key, h.Name+"/") {
+			continue
+		}
+		var check previewCheck
+		if _, err := h.Store.Get(PreviewCheckBucket, key, &check); err != nil {
+			log.Println("Error loading preview check:", err)
+			h.forgetPreviewCheck(key)
+			continue
+		}
+		if now.Sub(check.Due) > maxPreviewCheckAge {
+			h.forgetPreviewCheck(key)
+			continue
+		}
+		h.runPreviewCheck(nil, s, key, check.message(), check.Links, max(check.Due.Sub(now), 0))
+	}
+}
+
+// forgetPreviewCheck deletes a saved preview check.
+func (h *Handler) forgetPreviewCheck(key string) {
+	if h.Store == nil {
+		return
+	}
+	if err := h.Store.Delete(PreviewCheckBucket, key); err != nil {
+		log.Println("Error deleting preview check:", err)
+	}
+}
+
+// previewCheckKey is where the handler saves its check of a message.
+func (h *Handler) previewCheckKey(channelID, messageID string) string {
+	return h.Name + "/" + channelID + "/" + messageID
+}
+
+// postPreviews replies to a message with previews of its links, unless the
+// message has since been embedded, had its embeds suppressed or been deleted.
+func (h *Handler) postPreviews(record *explain.Record, s Session, m *discordgo.Message, links []string) {
+	ctx, cancel := h.operation()
+	defer cancel()
+
+	current, err := s.ChannelMessage(m.ChannelID, m.ID, discordgo.WithContext(ctx))
+	if err != nil {
+		record.Note("preview check", "the message is gone")
+		return
+	}
+	current = withSnapshot(current)
+	if len(current.Embeds) > 0 {
+		record.Note("preview check", "Discord embedded the links itself")
+		return
+	}
+	if current.Flags&discordgo.MessageFlagsSuppressEmbeds != 0 {
+		record.Note("preview check", "embeds were suppressed")
+		return
+	}
+
+	var embeds []*discordgo.MessageEmbed
+	for _, link := range links {
+		if !h.Budget.Spend(m.GuildID, budget.Lookups, 1) {
+			break
+		}
+		card, err := h.Previews.Fetch(ctx, link)
+		if err != nil {
+			logging.Contentf(m.GuildID, "Couldn't preview %s: %v\n", link, err)
+			continue
+		}
+		embeds = append(embeds, card.Embed())
+	}
+	record.Note("preview check", fmt.Sprintf("previewed %d of %d links", len(embeds), len(links)))
+	if len(embeds) == 0 {
+		return
+	}
+
+	h.send(ctx, s, m.ChannelID, &discordgo.MessageSend{
+		Embeds:          embeds,
+		Reference:       m.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Components:      []discordgo.MessageComponent{commands.RemoveButton(m.Author.ID)},
+	})
+}
+
+// previewLinks returns the distinct links in content that Discord would try to
+// embed, up to maxPreviews. Links in angle brackets are left alone, since
+// their author asked for no embed.
+func previewLinks(content string) []string {
+	if !patterns.HasLink(content) {
+		return nil
+	}
+	var links []string
+	for _, link := range patterns.URL.FindAllString(content, -1) {
+		if strings.HasPrefix(link, "<") && strings.HasSuffix(link, ">") {
+			continue
+		}
+		link = strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">")
+		if len(links) == maxPreviews {
+			break
+		}
+		if !slices.Contains(links, link) {
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/explain"
+	"go-discord-bot/internal/fxtwitter"
+	"go-discord-bot/internal/patterns"
+)
+
+// maxProfiles is how many profiles linked in one message are embedded.
+const maxProfiles = 3
+
+// twitterPages are paths on Twitter/X that look like profiles but are the
+// site's own pages.
+var twitterPages = []string{
+	"compose", "download", "explore", "hashtag", "home", "i", "intent", "jobs", "login",
+	"logout", "messages", "notifications", "privacy", "search", "settings", "share", "signup", "tos",
+}
+
+// handleTwitterPages deals with links to Twitter/X pages that aren't tweets,
+// which the fixers never rewrite: profiles are embedded for guilds that want
+// it, and communities and Spaces, which have no API to show them from, are
+// left alone.
+func (h *Handler) handleTwitterPages(ctx context.Context, s Session, m *discordgo.MessageCreate, cfg config.Guild) {
+	if !patterns.HasLink(m.Content) {
+		return
+	}
+	names, others := twitterPageLinks(m.Content)
+	if others > 0 {
+		explain.Note(ctx, "twitter pages", "community and Space links are left alone")
+	}
+	if len(names) == 0 {
+		return
+	}
+	if h.Tweets == nil || cfg.Profiles != config.ProfileEmbed {
+		explain.Note(ctx, "twitter pages", "profile links are left alone")
+		return
+	}
+
+	var embeds []*discordgo.MessageEmbed
+	for _, name := range names {
+		if embeddedProfile(m.Embeds, name) {
+			continue
+		}
+		if len(embeds) == maxProfiles || !h.Budget.Spend(m.GuildID, budget.Lookups, 1) {
+			break
+		}
+		user, err := h.Tweets.User(ctx, name)
+		if err != nil {
+			correlation.Println(ctx, "Error fetching Twitter profile:", err)
+			continue
+		}
+		embeds = append(embeds, profileEmbed(user))
+	}
+	explain.Note(ctx, "twitter pages", fmt.Sprintf("embedded %d of %d profiles", len(embeds), len(names)))
+	if len(embeds) == 0 {
+		return
+	}
+	h.send(ctx, s, m.ChannelID, &discordgo.MessageSend{
+		Embeds:          embeds,
+		Reference:       m.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Components:      []discordgo.MessageComponent{commands.RemoveButton(m.Author.ID)},
+	})
+}
+
+// twitterPageLinks returns the distinct screen names of the profiles linked in
+// content outside angle brackets, and how many links to communities and
+// Spaces it has.
+func twitterPageLinks(content string) (names []string, others int) {
+	for _, link := range patterns.URL.FindAllString(content, -1) {
+		if strings.HasPrefix(link, "<") && strings.HasSuffix(link, ">") {
+			continue
+		}
+		link = strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">")
+		if patterns.TwitterCommunityOrSpace.MatchString(link) {
+			others++
+			continue
+		}
+		match := patterns.TwitterProfile.FindStringSubmatch(link)
+		if match == nil || slices.Contains(twitterPages, strings.ToLower(match[1])) {
+			continue
+		}
+		if !slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, match[1]) }) {
+			names = append(names, match[1])
+		}
+	}
+	return names, others
+}
+
+// embeddedProfile reports whether Discord already embedded the profile of
+// screenName with its picture.
+func embeddedProfile(embeds []*discordgo.MessageEmbed, screenName string) bool {
+	for _, embed := range embeds {
+		match := patterns.TwitterProfile.FindStringSubmatch(embed.URL)
+		if match != nil && strings.EqualFold(match[1], screenName) && embed.Thumbnail != nil && embed.Thumbnail.URL != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// profileEmbed renders a Twitter/X profile.
+func profileEmbed(user *fxtwitter.User) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: user.Name + " (@" + user.ScreenName + ")",
+		URL:   "https://x.com/" + user.ScreenName,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Followers", Value: fmt.Sprint(user.Followers), Inline: true},
+			{Name: "Following", Value: fmt.Sprint(user.Following), Inline: true},
+			{Name: "Posts", Value: fmt.Sprint(user.Tweets), Inline: true},
+		},
+	}
+	if user.Description != "" {
+		embed.Description = chunk.Split(user.Description, maxEmbedDescription)[0]
+	}
+	if user.AvatarURL != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL}
+	}
+	return embed
+}
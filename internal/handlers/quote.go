@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/channels"
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/patterns"
+)
+
+// maxQuoted is how many message links in one message are quoted.
+const maxQuoted = 3
+
+// maxQuoteLength is how much of a quoted message's text is shown, in runes.
+const maxQuoteLength = 300
+
+// quoteReadable are the permissions needed to read a channel's messages.
+const quoteReadable = discordgo.PermissionViewChannel | discordgo.PermissionReadMessageHistory
+
+// errHidden is why a message isn't quoted to someone who couldn't read it
+// where it was posted.
+var errHidden = errors.New("its channel is hidden from the author")
+
+// errRestricted is why a message from an age-restricted channel isn't quoted
+// in a channel that isn't.
+var errRestricted = errors.New("its channel is age-restricted and this one isn't")
+
+// quoteMessageLinks replies to a message with a quote of each message it
+// links to elsewhere in the guild, in guilds that turned this on. Links in
+// angle brackets are left alone, as are messages the author can't read and
+// messages from age-restricted channels linked from others.
+func (h *Handler) quoteMessageLinks(ctx context.Context, s Session, m *discordgo.MessageCreate, cfg config.Guild) {
+	if h.AuthorPermissions == nil || !cfg.QuoteLinks || !patterns.HasLink(m.Content) {
+		return
+	}
+
+	var quoted, links []string
+	var embeds []*discordgo.MessageEmbed
+	for _, groups := range patterns.MessageLink.FindAllStringSubmatch(m.Content, -1) {
+		guildID, channelID, messageID := groups[2], groups[3], groups[4]
+		if groups[1] != "" || guildID != m.GuildID || slices.Contains(quoted, messageID) {
+			continue
+		}
+		if len(quoted) == maxQuoted {
+			break
+		}
+		quoted = append(quoted, messageID)
+
+		embed, err := h.quote(ctx, s, m, channelID, messageID)
+		if err != nil {
+			logging.Contentf(m.GuildID, "Couldn't quote message %s: %v\n", messageID, err)
+			continue
+		}
+		embeds = append(embeds, embed)
+		links = append(links, fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID))
+	}
+	if len(embeds) == 0 {
+		return
+	}
+	var jumps []discordgo.MessageComponent
+	for n, link := range links {
+		label := "Jump to message"
+		if len(links) > 1 {
+			label += fmt.Sprintf(" %d", n+1)
+		}
+		jumps = append(jumps, discordgo.Button{Label: label, Style: discordgo.LinkButton, URL: link})
+	}
+
+	h.send(ctx, s, m.ChannelID, &discordgo.MessageSend{
+		Embeds:          embeds,
+		Reference:       m.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Components:      []discordgo.MessageComponent{discordgo.ActionsRow{Components: jumps}, commands.RemoveButton(m.Author.ID)},
+	})
+}
+
+// quote fetches the message messageID in channelID, linked from m, and
+// renders it, unless m's author couldn't read it there.
+func (h *Handler) quote(ctx context.Context, s Session, m *discordgo.MessageCreate, channelID, messageID string) (*discordgo.MessageEmbed, error) {
+	perms, ok := h.AuthorPermissions(m.Message, channelID)
+	if !ok || perms&quoteReadable != quoteReadable {
+		return nil, errHidden
+	}
+	linked, err := h.Channels.Get(ctx, s, channelID)
+	if err != nil {
+		return nil, err
+	}
+	// Private threads are only for those added to them
+	if linked.Type == discordgo.ChannelTypeGuildPrivateThread {
+		return nil, errHidden
+	}
+	restricted, err := h.ageRestricted(ctx, s, linked)
+	if err != nil {
+		return nil, err
+	}
+	if restricted {
+		here, err := h.Channels.Get(ctx, s, m.ChannelID)
+		if err != nil {
+			return nil, err
+		}
+		if restricted, err = h.ageRestricted(ctx, s, here); err != nil {
+			return nil, err
+		}
+		if !restricted {
+			return nil, errRestricted
+		}
+	}
+
+	msg, err := s.ChannelMessage(channelID, messageID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return quoteEmbed(channelID, msg, h.member(s, m.GuildID, msg.Author, nil)), nil
+}
+
+// ageRestricted reports whether the channel with info is age-restricted,
+// looking up the channel a thread is in.
+func (h *Handler) ageRestricted(ctx context.Context, s Session, info channels.Info) (bool, error) {
+	if !info.Thread() {
+		return info.NSFW, nil
+	}
+	parent, err := h.Channels.Get(ctx, s, info.ParentID)
+	return parent.NSFW, err
+}
+
+// quoteEmbed renders a quoted message compactly: its author, as the member
+// they are in the guild, the start of its text, or what it holds when it has
+// none, and where and when it was posted.
+func quoteEmbed(channelID string, m *discordgo.Message, author *discordgo.Member) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{Description: shortQuote(m) + fmt.Sprintf("\n-# in <#%s>", channelID)}
+	if author != nil && author.User != nil {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: author.DisplayName(), IconURL: author.AvatarURL("")}
+	}
+	if !m.Timestamp.IsZero() {
+		embed.Timestamp = m.Timestamp.Format(time.RFC3339)
+	}
+	return embed
+}
+
+// shortQuote returns the start of m's text, or what it holds when it has
+// none, such as "*2 attachments*".
+func shortQuote(m *discordgo.Message) string {
+	text := shorten(strings.TrimSpace(m.Content), maxQuoteLength)
+	switch {
+	case text != "":
+		return text
+	case len(m.Attachments) == 1:
+		return "*1 attachment*"
+	case len(m.Attachments) > 1:
+		return fmt.Sprintf("*%d attachments*", len(m.Attachments))
+	case len(m.Embeds) > 0:
+		return "*an embed*"
+	case len(m.StickerItems) > 0:
+		return "*a sticker*"
+	}
+	return "*no text*"
+}
+
+// shorten cuts text to at most n runes, marking the cut with an ellipsis.
+func shorten(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	return strings.TrimSpace(string([]rune(text)[:n-1])) + "…"
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/pending"
+)
+
+// linkEmoji is the reaction guilds in reaction mode use to ask for a fix.
+const linkEmoji = "🔗"
+
+// reactedKey is the ID a message the bot reacted to once fixed is remembered
+// by in Duplicates, so it's reacted to only once.
+func reactedKey(messageID string) string {
+	return "reacted:" + messageID
+}
+
+// reactFixed reacts to a message whose fix the bot posted, or found posted
+// already, with the guild's success reaction, if it has one.
+func (h *Handler) reactFixed(ctx context.Context, s Session, m *discordgo.Message, cfg config.Guild) {
+	if cfg.SuccessReaction == "" {
+		return
+	}
+	if _, ok := h.Duplicates.Lookup(m.GuildID, reactedKey(m.ID)); ok {
+		return
+	}
+	err := h.Retry.Do(ctx, "react to message", func() error {
+		return s.MessageReactionAdd(m.ChannelID, m.ID, cfg.SuccessReaction, discordgo.WithContext(ctx))
+	})
+	if err != nil {
+		correlation.Println(ctx, "Error reacting to fixed message:", err)
+		return
+	}
+	h.Duplicates.Record(m.GuildID, reactedKey(m.ID), m.ChannelID, m.ID)
+}
+
+// repostPending posts the fix the bot is holding for a message in a guild in
+// reaction mode, if it still is.
+func (h *Handler) repostPending(s Session, messageID string) {
+	if p, ok := h.Pending.Take(messageID); ok {
+		h.postHeld(s, p)
+	}
+}
+
+// postHeld posts a fix the bot held until someone asked for it.
+func (h *Handler) postHeld(s Session, p pending.Repost) {
+	ctx, cancel := h.operation()
+	defer cancel()
+
+	m := p.Message
+	cfg := h.guildConfig(m.GuildID)
+	// Fixes asked for later reply, so it's clear which message they're for,
+	// even if the guild has since changed repost modes
+	cfg.RepostMode = config.RepostReaction
+	changed := fixers.ChangedLinks(m.Content, p.Content)
+	tweetIDs, _, _ := h.earlierRepost(m.GuildID, changed)
+	h.repost(ctx, s, &discordgo.MessageCreate{Message: m}, cfg, p.Content, p.Origins, changed, tweetIDs)
+}
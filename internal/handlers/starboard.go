@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/lifecycle"
+)
+
+// starEmoji is the reaction that votes a message onto the starboard.
+const starEmoji = "⭐"
+
+// StarboardBucket is the store bucket recording which messages are on a
+// starboard, keyed by guild and message ID.
+const StarboardBucket = "starboard"
+
+// starPost is the starboard post made for a starred message.
+type starPost struct {
+	MessageID string `json:"message_id"`
+}
+
+// updateStarboard posts a message to the guild's starboard once it has enough
+// stars, or updates the star count on the post already made for it.
+func (h *Handler) updateStarboard(s Session, guildID, channelID, messageID string) {
+	if h.Store == nil || guildID == "" || !h.Modules.Enabled(lifecycle.Starboard) {
+		return
+	}
+	cfg := h.guildConfig(guildID)
+	// Starring the starboard's own posts doesn't repost them again
+	if cfg.StarboardChannel == "" || channelID == cfg.StarboardChannel {
+		return
+	}
+
+	ctx, cancel := h.operation()
+	defer cancel()
+
+	msg, err := s.ChannelMessage(channelID, messageID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error fetching starred message:", err)
+		return
+	}
+	stars := starCount(msg)
+	if stars < cfg.Stars() {
+		return
+	}
+
+	key := guildID + "/" + messageID
+	header := fmt.Sprintf("%s **%d** in <#%s>", starEmoji, stars, channelID)
+	var post starPost
+	found, err := h.Store.Get(StarboardBucket, key, &post)
+	if err != nil {
+		log.Println("Error loading starboard post:", err)
+		return
+	}
+	if found {
+		h.Retry.Do(ctx, "update starboard post", func() error {
+			_, err := s.ChannelMessageEdit(cfg.StarboardChannel, post.MessageID, header, discordgo.WithContext(ctx))
+			return err
+		})
+		return
+	}
+
+	sent, err := h.send(ctx, s, cfg.StarboardChannel, &discordgo.MessageSend{
+		Content:         header,
+		Embeds:          []*discordgo.MessageEmbed{starEmbed(guildID, msg, h.member(s, guildID, msg.Author, nil))},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return
+	}
+	if err := h.Store.Put(StarboardBucket, key, starPost{MessageID: sent.ID}); err != nil {
+		log.Println("Error saving starboard post:", err)
+	}
+}
+
+// starCount returns how many ⭐ reactions a message has.
+func starCount(m *discordgo.Message) int {
+	for _, r := range m.Reactions {
+		if r.Emoji != nil && r.Emoji.Name == starEmoji {
+			return r.Count
+		}
+	}
+	return 0
+}
+
+// starEmbed renders a starred message for the starboard: its author, as the
+// member they are in the guild, text, first image and a link back to it. Other attachments are linked.
+func starEmbed(guildID string, m *discordgo.Message, author *discordgo.Member) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Fields: []*discordgo.MessageEmbedField{{
+			Name:  "Source",
+			Value: fmt.Sprintf("[Jump to message](https://discord.com/channels/%s/%s/%s)", guildID, m.ChannelID, m.ID),
+		}},
+	}
+	if m.Content != "" {
+		embed.Description = chunk.Split(m.Content, maxEmbedDescription)[0]
+	}
+	if author != nil && author.User != nil {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: author.DisplayName(), IconURL: author.AvatarURL("")}
+	}
+	if !m.Timestamp.IsZero() {
+		embed.Timestamp = m.Timestamp.Format(time.RFC3339)
+	}
+
+	var others []string
+	for _, a := range m.Attachments {
+		if embed.Image == nil && strings.HasPrefix(a.ContentType, "image/") {
+			embed.Image = &discordgo.MessageEmbedImage{URL: a.URL}
+			continue
+		}
+		others = append(others, fmt.Sprintf("[%s](%s)", a.Filename, a.URL))
+	}
+	// Fall back to the preview image of a link in the message
+	for _, e := range m.Embeds {
+		if embed.Image != nil {
+			break
+		}
+		switch {
+		case e.Image != nil && e.Image.URL != "":
+			embed.Image = &discordgo.MessageEmbedImage{URL: e.Image.URL}
+		case e.Thumbnail != nil && e.Thumbnail.URL != "":
+			embed.Image = &discordgo.MessageEmbedImage{URL: e.Thumbnail.URL}
+		}
+	}
+	if len(others) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Attachments",
+			Value: chunk.Split(strings.Join(others, "\n"), 1024)[0],
+		})
+	}
+	return embed
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/fxtwitter"
+)
+
+// maxSummaryText is how many characters of a tweet's text its summary quotes.
+const maxSummaryText = 280
+
+// textSummaries returns a line for each tweet in tweetIDs saying who posted
+// it, what it says and what media it has, for guilds with text summaries on.
+// Tweets guildID has no lookups left for are left out.
+func (h *Handler) textSummaries(ctx context.Context, guildID string, cfg config.Guild, tweetIDs []string) []string {
+	if !cfg.TextSummaries || h.Tweets == nil {
+		return nil
+	}
+	var lines []string
+	for _, id := range tweetIDs {
+		if !h.Budget.Spend(guildID, budget.Lookups, 1) {
+			break
+		}
+		tweet, err := h.Tweets.Status(ctx, id)
+		if err != nil {
+			correlation.Println(ctx, "Error fetching tweet to summarize:", err)
+			continue
+		}
+		lines = append(lines, tweetSummary(tweet))
+	}
+	return lines
+}
+
+// tweetSummary describes a tweet in one line, such as
+// `Tweet by Some One (@someone): "sunset over the bay" (2 photos)`.
+func tweetSummary(t *fxtwitter.Tweet) string {
+	summary := "Tweet"
+	if author := t.Author; author.ScreenName != "" {
+		summary += fmt.Sprintf(" by %s (@%s)", author.Name, author.ScreenName)
+	}
+	if text := strings.Join(strings.Fields(t.Text), " "); text != "" {
+		if runes := []rune(text); len(runes) > maxSummaryText {
+			text = string(runes[:maxSummaryText-1]) + "…"
+		}
+		summary += `: "` + text + `"`
+	}
+	if media := mediaCounts(t); media != "" {
+		summary += " (" + media + ")"
+	}
+	return summary
+}
+
+// mediaCounts counts a tweet's photos, videos and GIFs, such as
+// "2 photos, 1 video", empty if it has none.
+func mediaCounts(t *fxtwitter.Tweet) string {
+	if t.Media == nil {
+		return ""
+	}
+	counts := map[string]int{}
+	for _, item := range t.Media.All {
+		counts[item.Type]++
+	}
+	var parts []string
+	for _, kind := range []struct{ typ, noun string }{{"photo", "photo"}, {"video", "video"}, {"gif", "GIF"}} {
+		switch n := counts[kind.typ]; n {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+kind.noun)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %ss", n, kind.noun))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
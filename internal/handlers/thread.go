@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/correlation"
+	"go-discord-bot/internal/fxtwitter"
+)
+
+// maxContextEmbeds is how many embeds Discord allows on one message.
+const maxContextEmbeds = 10
+
+// maxEmbedDescription is how long an embed description may be.
+const maxEmbedDescription = 4096
+
+// maxFieldValue is how long an embed field's value may be.
+const maxFieldValue = 1024
+
+// contextEmbeds looks up the quoted and parent tweets of each tweet ID, up to
+// depth per tweet, and renders them as embeds for the repost. Tweets guildID
+// has no lookups left for are skipped.
+func (h *Handler) contextEmbeds(ctx context.Context, guildID string, tweetIDs []string, depth int) []*discordgo.MessageEmbed {
+	if h.Tweets == nil || depth <= 0 {
+		return nil
+	}
+
+	var embeds []*discordgo.MessageEmbed
+	for _, id := range tweetIDs {
+		if !h.Budget.Spend(guildID, budget.Lookups, depth) {
+			break
+		}
+		related, err := h.Tweets.Thread(ctx, id, depth)
+		if err != nil {
+			correlation.Println(ctx, "Error fetching tweet context:", err)
+			continue
+		}
+		for _, r := range related {
+			if len(embeds) == maxContextEmbeds {
+				return embeds
+			}
+			label := "Quoted tweet"
+			if r.Relation == fxtwitter.Parent {
+				label = "Replying to"
+			}
+			embeds = append(embeds, tweetEmbed(r.Tweet, label))
+		}
+	}
+	return embeds
+}
+
+// tweetEmbed renders a tweet with label, such as how it relates to the fixed
+// one, in the footer.
+func tweetEmbed(t *fxtwitter.Tweet, label string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		URL:    t.URL,
+		Footer: &discordgo.MessageEmbedFooter{Text: label},
+	}
+	if t.Text != "" {
+		embed.Description = chunk.Split(t.Text, maxEmbedDescription)[0]
+	}
+	if author := t.Author; author.ScreenName != "" {
+		embed.Author = &discordgo.MessageEmbedAuthor{
+			Name: author.Name + " (@" + author.ScreenName + ")",
+			URL:  "https://x.com/" + author.ScreenName,
+		}
+	}
+	if photo, ok := t.Photo(); ok {
+		embed.Image = &discordgo.MessageEmbedImage{URL: photo}
+	}
+	embed.Fields = altTextFields(t.AltTexts())
+	return embed
+}
+
+// altTextFields renders the alt text of a tweet's photos as embed fields, for
+// members using screen readers. Photos without alt text are skipped, but keep
+// their number.
+func altTextFields(texts []string) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	for i, text := range texts {
+		if text == "" {
+			continue
+		}
+		name := "Image description"
+		if len(texts) > 1 {
+			name = fmt.Sprintf("Image %d description", i+1)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: name, Value: chunk.Split(text, maxFieldValue)[0]})
+	}
+	return fields
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/correlation"
+)
+
+// ThreadBucket is the store bucket holding the "Fixed links" threads the bot
+// started in RepostThread mode, keyed by bot and thread ID, so they're
+// archived once inactive even across restarts.
+const ThreadBucket = "fix_threads"
+
+const (
+	// threadName is the name of the threads reposts go in.
+	threadName = "Fixed links"
+	// threadAutoArchive is how many minutes Discord lets the bot's threads go
+	// without messages, the longest it allows; guilds pick shorter.
+	threadAutoArchive = 7 * 24 * 60
+	// maxThreadLinks caps the fixed links kept for a thread's summary.
+	maxThreadLinks = 5
+	// keepArchived is how long the bot remembers a thread it archived, in
+	// case someone reopens it. Discord archives threads reopened later itself.
+	keepArchived = 30 * 24 * time.Hour
+)
+
+// fixThread is a "Fixed links" thread the bot started.
+type fixThread struct {
+	GuildID  string `json:"guild_id"`
+	ThreadID string `json:"thread_id"`
+	// Links are the first links the bot fixed in the thread.
+	Links []string `json:"links"`
+	// Active is when the thread last had a message, as far as the bot has
+	// checked, or when the bot archived it.
+	Active time.Time `json:"active"`
+	// Archived reports whether the bot archived the thread. It's archived
+	// again once it goes quiet if someone reopens it.
+	Archived bool `json:"archived,omitempty"`
+}
+
+// startThread starts a "Fixed links" thread on m for its repost and keeps
+// track of it, returning its ID. It returns "" where there can't be one,
+// such as for messages in threads, so the repost goes beside m instead.
+func (h *Handler) startThread(ctx context.Context, s Session, m *discordgo.MessageCreate, links []string) string {
+	if m.GuildID == "" {
+		return ""
+	}
+	if info, err := h.Channels.Get(ctx, s, m.ChannelID); err == nil && (info.Thread() || info.VoiceChat()) {
+		return ""
+	}
+	var thread *discordgo.Channel
+	err := h.Retry.Do(ctx, "start thread", func() error {
+		var err error
+		thread, err = s.MessageThreadStartComplex(m.ChannelID, m.ID, &discordgo.ThreadStart{
+			Name:                threadName,
+			AutoArchiveDuration: threadAutoArchive,
+		}, discordgo.WithContext(ctx))
+		return err
+	})
+	if err != nil {
+		correlation.Println(ctx, "Error starting thread:", err)
+		return ""
+	}
+	h.Channels.Remember(thread)
+
+	t := fixThread{GuildID: m.GuildID, ThreadID: thread.ID, Links: links[:min(len(links), maxThreadLinks)], Active: time.Now()}
+	if h.Store != nil {
+		if err := h.Store.Put(ThreadBucket, h.threadKey(thread.ID), t); err != nil {
+			correlation.Println(ctx, "Error saving thread:", err)
+		}
+	}
+	return thread.ID
+}
+
+// ArchiveThreads archives the "Fixed links" threads the bot started that
+// went without messages for as long as their guild lets them, summing them
+// up first in guilds that want it. The scheduler runs it every minute.
+func (h *Handler) ArchiveThreads(ctx context.Context, s Session, now time.Time) {
+	if h.Store == nil {
+		return
+	}
+	for _, key := range h.Store.Keys(ThreadBucket) {
+		if !strings.HasPrefix(key, h.Name+"/") {
+			continue
+		}
+		var t fixThread
+		if _, err := h.Store.Get(ThreadBucket, key, &t); err != nil {
+			log.Println("Error loading thread:", err)
+			h.forgetThread(key)
+			continue
+		}
+		if t.Archived {
+			if now.Sub(t.Active) >= keepArchived {
+				h.forgetThread(key)
+			}
+			continue
+		}
+		cfg := h.guildConfig(t.GuildID)
+		after := cfg.ThreadArchiveAfter()
+		// Most ticks, no thread has been quiet long enough to look at
+		if after <= 0 || cfg.Paused || now.Sub(t.Active) < after {
+			continue
+		}
+		h.archiveThread(ctx, s, key, t, after, cfg.ThreadSummary, now)
+	}
+}
+
+// archiveThread archives a thread unless it had messages in the last after,
+// in which case it's looked at again once it's been quiet that long. The
+// thread is remembered as archived, so it's looked after again if reopened.
+func (h *Handler) archiveThread(ctx context.Context, s Session, key string, t fixThread, after time.Duration, summary bool, now time.Time) {
+	thread, err := s.Channel(t.ThreadID, discordgo.WithContext(ctx))
+	if err != nil {
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Message != nil &&
+			(restErr.Message.Code == discordgo.ErrCodeUnknownChannel || restErr.Message.Code == discordgo.ErrCodeMissingAccess) {
+			h.forgetThread(key)
+			return
+		}
+		log.Println("Error fetching thread:", err)
+		return
+	}
+	// Threads archived by hand, or by Discord, are left as they are
+	if thread.ThreadMetadata != nil && thread.ThreadMetadata.Archived {
+		h.forgetThread(key)
+		return
+	}
+	if last, err := discordgo.SnowflakeTimestamp(thread.LastMessageID); err == nil && last.After(t.Active) {
+		t.Active = last
+		if now.Sub(last) < after {
+			if err := h.Store.Put(ThreadBucket, key, t); err != nil {
+				log.Println("Error saving thread:", err)
+			}
+			return
+		}
+	}
+
+	if summary {
+		if _, err := h.send(ctx, s, t.ThreadID, &discordgo.MessageSend{
+			Content:         threadSummary(t, thread.MessageCount),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}); err != nil {
+			log.Println("Error summing up thread:", err)
+		}
+	}
+	archived := true
+	err = h.Retry.Do(ctx, "archive thread", func() error {
+		_, err := s.ChannelEditComplex(t.ThreadID, &discordgo.ChannelEdit{Archived: &archived}, discordgo.WithContext(ctx))
+		return err
+	})
+	if err != nil {
+		// The summary, if any, counts as a message, so this waits for the
+		// thread to go quiet again before trying once more
+		log.Println("Error archiving thread:", err)
+		return
+	}
+	t.Archived, t.Active = true, now
+	if err := h.Store.Put(ThreadBucket, key, t); err != nil {
+		log.Println("Error saving thread:", err)
+	}
+}
+
+// reopenThread looks after a thread the bot archived again once it's
+// unarchived, as sending a message in it does.
+func (h *Handler) reopenThread(thread *discordgo.Channel) {
+	if h.Store == nil || thread == nil || thread.ThreadMetadata == nil || thread.ThreadMetadata.Archived {
+		return
+	}
+	key := h.threadKey(thread.ID)
+	var t fixThread
+	if ok, err := h.Store.Get(ThreadBucket, key, &t); err != nil || !ok || !t.Archived {
+		return
+	}
+	t.Archived, t.Active = false, time.Now()
+	if err := h.Store.Put(ThreadBucket, key, t); err != nil {
+		log.Println("Error saving thread:", err)
+	}
+}
+
+// threadSummary sums up a thread with count messages that's about to be
+// archived.
+func threadSummary(t fixThread, count int) string {
+	var links []string
+	for _, link := range t.Links {
+		// In angle brackets, so the summary doesn't embed them all again
+		links = append(links, "<"+link+">")
+	}
+	return fmt.Sprintf("Archiving this thread, as it's gone quiet. Fixed here: %s. Messages: %d. Send a message to reopen it.",
+		strings.Join(links, " "), count)
+}
+
+// forgetThread stops keeping track of a thread.
+func (h *Handler) forgetThread(key string) {
+	if err := h.Store.Delete(ThreadBucket, key); err != nil {
+		log.Println("Error deleting thread:", err)
+	}
+}
+
+// threadKey is where the handler saves a thread it started.
+func (h *Handler) threadKey(threadID string) string {
+	return h.Name + "/" + threadID
+}
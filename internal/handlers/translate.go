@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/fixers"
+)
+
+// MessageReactionAdd is the callback function for the MessageReactionAdd event.
+// Reacting to one of the bot's reposts with a country flag translates the tweets in it,
+// starring a message may put it on the guild's starboard, reacting with 🔗
+// posts the fix the bot is holding for a message in reaction mode, and
+// reacting with the guild's trigger emoji fixes the message.
+func (h *Handler) MessageReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	h.HandleReactionAdd(s, s.State.User.ID, r)
+}
+
+// HandleReactionAdd does the work of MessageReactionAdd against any Session.
+func (h *Handler) HandleReactionAdd(s Session, botUserID string, r *discordgo.MessageReactionAdd) {
+	if r.UserID == botUserID {
+		return
+	}
+	lang, isFlag := fixers.FlagLanguage(r.Emoji.Name)
+	isStar := r.Emoji.Name == starEmoji
+	isLink := r.Emoji.Name == linkEmoji
+	// Only guilds that set a trigger emoji need their config looked up for every reaction
+	isTrigger := !isFlag && !isStar && !isLink && r.GuildID != "" && h.guildConfig(r.GuildID).TriggerEmoji == r.Emoji.APIName()
+	if !isFlag && !isStar && !isLink && !isTrigger {
+		return
+	}
+
+	if h.outside(r.GuildID) || config.Paused(h.Store, r.GuildID) {
+		return
+	}
+
+	var roles []string
+	if r.Member != nil {
+		roles = r.Member.Roles
+	}
+	if config.Ignored(h.Store, r.GuildID, r.UserID, roles) {
+		return
+	}
+
+	job := func() {
+		if h.guildConfig(r.GuildID).ModuleEnabled(config.ModuleTranslate) {
+			h.translateRepost(s, botUserID, r.ChannelID, r.MessageID, lang)
+		}
+	}
+	if isStar {
+		job = func() { h.updateStarboard(s, r.GuildID, r.ChannelID, r.MessageID) }
+	}
+	if isLink {
+		job = func() { h.repostPending(s, r.MessageID) }
+	}
+	if isTrigger {
+		job = func() { h.fixTriggered(s, botUserID, r.GuildID, r.ChannelID, r.MessageID, roles) }
+	}
+	if !h.Pool.Submit(r.ChannelID, job) {
+		log.Println("Worker queue full, dropping reaction on", r.MessageID)
+	}
+}
+
+// translateRepost edits one of the bot's reposts so its fxtwitter links show the
+// tweets translated to lang. Messages by anyone else are left alone.
+func (h *Handler) translateRepost(s Session, botUserID, channelID, messageID, lang string) {
+	ctx, cancel := h.operation()
+	defer cancel()
+
+	msg, err := s.ChannelMessage(channelID, messageID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error fetching reacted message:", err)
+		return
+	}
+	if msg.Author == nil || msg.Author.ID != botUserID {
+		return
+	}
+
+	translated := fixers.TranslateLinks(msg.Content, lang)
+	if translated == msg.Content {
+		return
+	}
+	h.Retry.Do(ctx, "translate repost", func() error {
+		_, err := s.ChannelMessageEdit(channelID, messageID, translated, discordgo.WithContext(ctx))
+		return err
+	})
+}
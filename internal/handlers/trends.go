@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/patterns"
+)
+
+// countShared counts the links in a message towards the guild's trends.
+func (h *Handler) countShared(m *discordgo.MessageCreate, cfg config.Guild) {
+	if h.Trends == nil || !patterns.HasLink(m.Content) {
+		return
+	}
+	var links []string
+	for _, link := range patterns.URL.FindAllString(m.Content, -1) {
+		links = append(links, strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">"))
+	}
+	h.Trends.Record(m.GuildID, links, time.Now(), cfg.Location())
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"log"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/access"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/fixers"
+)
+
+// fixTriggered fixes the links in a message someone reacted to with the
+// guild's trigger emoji, if the roles of whoever reacted allow it. The bot
+// reacts with the emoji as well once it has, so more reactions, even after a
+// restart, don't fix the message again.
+func (h *Handler) fixTriggered(s Session, botUserID, guildID, channelID, messageID string, roles []string) {
+	ctx, cancel := h.operation()
+	defer cancel()
+
+	cfg := h.guildConfig(guildID)
+	if cfg.TriggerEmoji == "" || !access.Allowed(cfg, access.Fixing, roles) || h.channelSkip(ctx, s, cfg, guildID, channelID) != "" {
+		return
+	}
+	m, err := s.ChannelMessage(channelID, messageID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Println("Error fetching reacted message:", err)
+		return
+	}
+	m = withSnapshot(m)
+	if m.Author == nil || m.Author.ID == botUserID || cfg.Skips(m.Content) || fixedBefore(m, cfg.TriggerEmoji) {
+		return
+	}
+	// Messages fetched over REST don't say which guild they're in
+	m.GuildID = guildID
+	created := &discordgo.MessageCreate{Message: m}
+
+	modifiedContent, origins := h.Fixers.Without(cfg.DisabledFixers).ApplyOrigins(ctx, created)
+	if modifiedContent == m.Content || ctx.Err() != nil {
+		return
+	}
+	err = h.Retry.Do(ctx, "react to message", func() error {
+		return s.MessageReactionAdd(channelID, messageID, cfg.TriggerEmoji, discordgo.WithContext(ctx))
+	})
+	if err != nil {
+		// Without the mark the message could be fixed again and again
+		log.Println("Error marking triggered message as fixed:", err)
+		return
+	}
+	// Filtered messages are marked too, so moderators aren't told again
+	changed := fixers.ChangedLinks(m.Content, modifiedContent)
+	tweetIDs, _, _ := h.earlierRepost(guildID, changed)
+	if why := h.filtered(ctx, guildID, cfg, changed, tweetIDs); why != "" {
+		h.reportFiltered(ctx, s, m, cfg, why)
+		return
+	}
+
+	// Fixes asked for later reply, so it's clear which message they're for
+	cfg.RepostMode = config.RepostReaction
+	h.repost(ctx, s, created, cfg, modifiedContent, origins, changed, tweetIDs)
+}
+
+// fixedBefore reports whether the bot has reacted to m with emoji, marking it
+// as fixed already.
+func fixedBefore(m *discordgo.Message, emoji string) bool {
+	return slices.ContainsFunc(m.Reactions, func(r *discordgo.MessageReactions) bool {
+		return r.Me && r.Emoji != nil && r.Emoji.APIName() == emoji
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/budget"
+	"go-discord-bot/internal/canonical"
+	"go-discord-bot/internal/chunk"
+	"go-discord-bot/internal/commands"
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/patterns"
+)
+
+// maxUnshortened is how many shortened links in one message are expanded.
+const maxUnshortened = 5
+
+// replyUnshortened replies to a message with where its shortened links go,
+// in guilds that turned this on.
+func (h *Handler) replyUnshortened(ctx context.Context, s Session, m *discordgo.MessageCreate, cfg config.Guild) {
+	if h.Unshortener == nil || !cfg.Unshorten || !patterns.HasLink(m.Content) {
+		return
+	}
+
+	var expanded, lines []string
+	for _, link := range patterns.URL.FindAllString(m.Content, -1) {
+		link = strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">")
+		if !h.Unshortener.Shortened(link) || slices.Contains(expanded, link) {
+			continue
+		}
+		if len(expanded) == maxUnshortened {
+			break
+		}
+		expanded = append(expanded, link)
+
+		destination, err := h.expand(ctx, m.GuildID, link, cfg)
+		if err != nil {
+			logging.Contentf(m.GuildID, "Couldn't expand %s: %v\n", link, err)
+			continue
+		}
+		// Angle brackets keep Discord from embedding either link
+		lines = append(lines, fmt.Sprintf("<%s> goes to <%s>", link, destination))
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	h.send(ctx, s, m.ChannelID, &discordgo.MessageSend{
+		Content:         chunk.Split(strings.Join(lines, "\n"), chunk.MaxMessageLength)[0],
+		Reference:       m.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Components:      []discordgo.MessageComponent{commands.RemoveButton(m.Author.ID)},
+	})
+}
+
+// expand returns where a shortened link goes, in its canonical form, looking
+// it up only if it isn't remembered. Links shared in guilds in privacy mode
+// aren't remembered.
+func (h *Handler) expand(ctx context.Context, guildID, link string, cfg config.Guild) (string, error) {
+	if destination, ok := h.Canonical.Get(link); ok {
+		return destination, nil
+	}
+	if !h.Budget.Spend(guildID, budget.Lookups, 1) {
+		return "", budget.ErrSpent
+	}
+	destination, err := h.Unshortener.Expand(ctx, link)
+	if err != nil {
+		return "", err
+	}
+	destination = canonical.Normalize(destination)
+	if !cfg.Privacy {
+		h.Canonical.Put(link, destination)
+	}
+	return destination, nil
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+)
+
+// announce plays a voice notice for a fixed message in the guild's voice channel.
+func (h *Handler) announce(s Session, m *discordgo.MessageCreate, cfg config.Guild) {
+	if cfg.VoiceChannel == "" {
+		return
+	}
+	// The notice outlives fixing the message, so it isn't bound by h.Timeout
+	ctx := h.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	text := "New link"
+	if name := h.member(s, m.GuildID, m.Author, m.Member).DisplayName(); name != "" && !cfg.Privacy {
+		text = "New link from " + name
+	}
+	h.Voice.Announce(ctx, s, m.GuildID, cfg.VoiceChannel, text)
+}
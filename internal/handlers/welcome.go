@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/lifecycle"
+	"go-discord-bot/internal/templates"
+)
+
+// GuildMemberAdd is the discordgo handler for members who joined. It needs
+// the server members intent.
+func (h *Handler) GuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	server, count := "", 0
+	// The state has already counted the new member
+	if g, err := s.State.Guild(m.GuildID); err == nil {
+		server, count = g.Name, g.MemberCount
+	}
+	h.HandleGuildMemberAdd(s, m, server, count)
+}
+
+// HandleGuildMemberAdd does the work of GuildMemberAdd against any Session:
+// it greets the member in the guild's welcome channel and gives them its
+// welcome role. server is the guild's name and count how many members it has,
+// for the welcome message.
+func (h *Handler) HandleGuildMemberAdd(s Session, m *discordgo.GuildMemberAdd, server string, count int) {
+	if m.Member == nil || m.User == nil {
+		return
+	}
+	h.Members.Remember(m.GuildID, m.Member, nil)
+	// Bots are left to whoever added them
+	if m.User.Bot || h.outside(m.GuildID) || h.Store == nil || !h.Modules.Enabled(lifecycle.Greeter) {
+		return
+	}
+	cfg := h.guildConfig(m.GuildID)
+	if cfg.Paused || (cfg.WelcomeChannel == "" && cfg.WelcomeRole == "") {
+		return
+	}
+
+	job := func() {
+		ctx, cancel := h.operation()
+		defer cancel()
+		if cfg.WelcomeRole != "" {
+			err := h.Retry.Do(ctx, "give welcome role", func() error {
+				return s.GuildMemberRoleAdd(m.GuildID, m.User.ID, cfg.WelcomeRole, discordgo.WithContext(ctx))
+			})
+			if err != nil {
+				log.Println("Error giving welcome role:", err)
+			}
+		}
+		if cfg.WelcomeChannel != "" {
+			text := templates.Render(cfg.Welcome(), templates.Vars{
+				UserID: m.User.ID,
+				Name:   m.Member.DisplayName(),
+				Server: server,
+				Count:  count,
+			})
+			// Only the new member is pinged, whatever the message mentions
+			h.send(ctx, s, cfg.WelcomeChannel, &discordgo.MessageSend{
+				Content:         text,
+				AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{m.User.ID}},
+			})
+		}
+	}
+	if !h.Pool.Submit(m.GuildID, job) {
+		log.Println("Worker queue full, not welcoming member in guild", m.GuildID)
+	}
+}
@@ -0,0 +1,110 @@
+// Package health reports how each part of the bot is doing right now, such
+// as the gateway, storage or the fx API, so operators can see which one is
+// degraded from /status or GET /healthz before users report it.
+package health
+
+import (
+	"context"
+	"sync"
+)
+
+// State is how well a part of the bot works.
+type State int
+
+// States, from best to worst.
+const (
+	OK State = iota
+	// Degraded parts work, but slower or with fewer features.
+	Degraded
+	// Down parts don't work at all.
+	Down
+)
+
+// String returns the state's name, such as "degraded".
+func (s State) String() string {
+	switch s {
+	case OK:
+		return "ok"
+	case Degraded:
+		return "degraded"
+	default:
+		return "down"
+	}
+}
+
+// MarshalText encodes the state as its name.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Probe checks one part of the bot, returning its state and a short detail
+// for operators, such as "2 of 3 shards connected".
+type Probe func(ctx context.Context) (State, string)
+
+// Check is the result of one probe.
+type Check struct {
+	Name   string `json:"name"`
+	State  State  `json:"state"`
+	Detail string `json:"detail"`
+}
+
+// Report is the result of every probe. Its State is the worst of theirs.
+type Report struct {
+	State  State   `json:"state"`
+	Checks []Check `json:"checks"`
+}
+
+// probe is a named Probe.
+type probe struct {
+	name string
+	run  Probe
+}
+
+// Monitor holds the probes of the bot's parts. A nil Monitor reports no
+// checks, in an OK state.
+type Monitor struct {
+	mu     sync.Mutex
+	probes []probe
+}
+
+// New returns a Monitor without probes.
+func New() *Monitor {
+	return &Monitor{}
+}
+
+// Add adds the probe of a part of the bot, checked after those added before.
+func (m *Monitor) Add(name string, run Probe) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.probes = append(m.probes, probe{name: name, run: run})
+}
+
+// Check runs every probe, in the order they were added.
+func (m *Monitor) Check(ctx context.Context) Report {
+	report := Report{Checks: []Check{}}
+	if m == nil {
+		return report
+	}
+	m.mu.Lock()
+	probes := m.probes
+	m.mu.Unlock()
+	for _, p := range probes {
+		state, detail := p.run(ctx)
+		report.Checks = append(report.Checks, Check{Name: p.name, State: state, Detail: detail})
+		report.State = max(report.State, state)
+	}
+	return report
+}
+
+// Level returns Down when value reaches down, Degraded when it reaches
+// degraded and OK below, for probes of counts like queue depths.
+func Level(value, degraded, down int) State {
+	switch {
+	case value >= down:
+		return Down
+	case value >= degraded:
+		return Degraded
+	default:
+		return OK
+	}
+}
@@ -0,0 +1,47 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestMonitor(t *testing.T) {
+	m := New()
+	m.Add("Gateway", func(context.Context) (State, string) { return OK, "1 shard connected" })
+	m.Add("Queues", func(context.Context) (State, string) { return Level(80, 50, 100), "80 events queued" })
+	m.Add("Storage", func(context.Context) (State, string) { return OK, "reads work" })
+
+	report := m.Check(context.Background())
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	expected := `{"state":"degraded","checks":[{"name":"Gateway","state":"ok","detail":"1 shard connected"},{"name":"Queues","state":"degraded","detail":"80 events queued"},{"name":"Storage","state":"ok","detail":"reads work"}]}`
+	if string(data) != expected {
+		t.Errorf("report = %s; want %s", data, expected)
+	}
+
+	var none *Monitor
+	if report := none.Check(context.Background()); report.State != OK || len(report.Checks) != 0 {
+		t.Errorf("nil Monitor reported %+v", report)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	testCases := []struct {
+		value    int
+		expected State
+	}{
+		{value: 0, expected: OK},
+		{value: 49, expected: OK},
+		{value: 50, expected: Degraded},
+		{value: 100, expected: Down},
+	}
+
+	for _, tc := range testCases {
+		if state := Level(tc.value, 50, 100); state != tc.expected {
+			t.Errorf("Level(%d, 50, 100) = %v; want %v", tc.value, state, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package httpclient hands out the bot's outbound HTTP clients. Clients for the
+// same proxy route share one pool of connections, every request names the bot
+// in its User-Agent unless it brings its own, no host gets more than a few
+// requests at once, and what's sent is counted per host.
+package httpclient
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/proxy"
+	"go-discord-bot/internal/safehttp"
+	"go-discord-bot/internal/version"
+)
+
+// DefaultPerHost is how many requests a host gets at once when New isn't given
+// a limit.
+const DefaultPerHost = 8
+
+// maxHosts caps how many hosts are counted separately, since links posted in
+// chat can name any number of them. Later hosts are counted under OtherHosts.
+const maxHosts = 500
+
+// OtherHosts is the host the requests past maxHosts are counted under.
+const OtherHosts = "other"
+
+// UserAgent is sent with requests that don't set a User-Agent of their own.
+var UserAgent = "go-discord-bot/" + version.Version
+
+// HostStats counts the requests sent to one host since the bot started.
+type HostStats struct {
+	Host     string `json:"host"`
+	Requests int    `json:"requests"`
+	// Errors counts requests that got no response at all.
+	Errors int `json:"errors"`
+	// Statuses counts responses by class, such as "2xx" or "5xx".
+	Statuses map[string]int `json:"statuses"`
+	InFlight int            `json:"in_flight"`
+	// Waiting counts requests held back by the per-host limit.
+	Waiting int `json:"waiting"`
+}
+
+// Clients makes the bot's outbound HTTP clients. A nil *Clients returns nil
+// clients, so constructors fall back to their own defaults.
+type Clients struct {
+	routes  proxy.Routes
+	perHost int
+
+	mu         sync.Mutex
+	transports map[transportKey]http.RoundTripper
+	hosts      map[string]*host
+	stats      map[string]*HostStats
+}
+
+// transportKey picks a shared transport: the proxy it goes through and
+// whether it's held to safehttp's rules.
+type transportKey struct {
+	via  string
+	safe bool
+}
+
+// host holds the slots limiting requests to one host at once. It's dropped
+// once nothing is using it.
+type host struct {
+	slots chan struct{}
+	users int
+}
+
+// New returns clients reaching each kind of destination through its route in
+// routes, sending at most perHost requests to a host at once. perHost below 1
+// uses DefaultPerHost.
+func New(routes proxy.Routes, perHost int) *Clients {
+	if perHost < 1 {
+		perHost = DefaultPerHost
+	}
+	return &Clients{
+		routes:     routes,
+		perHost:    perHost,
+		transports: map[transportKey]http.RoundTripper{},
+		hosts:      map[string]*host{},
+		stats:      map[string]*HostStats{},
+	}
+}
+
+// Discord returns a client for Discord's REST API and OAuth2 endpoints.
+func (c *Clients) Discord(timeout time.Duration) *http.Client {
+	return c.client(c.route(func(r proxy.Routes) *url.URL { return r.Discord }), false, timeout)
+}
+
+// Twitter returns a client for the fx APIs, Nitter and Twitter's embed API.
+func (c *Clients) Twitter(timeout time.Duration) *http.Client {
+	return c.client(c.route(func(r proxy.Routes) *url.URL { return r.Twitter }), false, timeout)
+}
+
+// Other returns a client for the services the bot is configured with, such as
+// Safe Browsing, update checks and alert webhooks.
+func (c *Clients) Other(timeout time.Duration) *http.Client {
+	return c.client(c.route(func(r proxy.Routes) *url.URL { return r.Other }), false, timeout)
+}
+
+// Links returns a safehttp client for links posted in chat and feeds.
+func (c *Clients) Links(timeout time.Duration) *http.Client {
+	return c.client(c.route(func(r proxy.Routes) *url.URL { return r.Links }), true, timeout)
+}
+
+// Media returns a safehttp client for tweet media, reached through the
+// Twitter route.
+func (c *Clients) Media(timeout time.Duration) *http.Client {
+	return c.client(c.route(func(r proxy.Routes) *url.URL { return r.Twitter }), true, timeout)
+}
+
+// route picks a route out of c's routes, nil if c is nil.
+func (c *Clients) route(pick func(proxy.Routes) *url.URL) *url.URL {
+	if c == nil {
+		return nil
+	}
+	return pick(c.routes)
+}
+
+// client returns a client with timeout on the shared transport for via and
+// safe, creating the transport the first time it's asked for.
+func (c *Clients) client(via *url.URL, safe bool, timeout time.Duration) *http.Client {
+	if c == nil {
+		return nil
+	}
+	key := transportKey{safe: safe}
+	if via != nil {
+		key.via = via.String()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rt, ok := c.transports[key]
+	if !ok {
+		var base *http.Transport
+		switch {
+		case safe:
+			base = safehttp.Transport(via)
+		case via != nil:
+			base = proxy.Transport(via)
+		default:
+			base = http.DefaultTransport.(*http.Transport).Clone()
+		}
+		base.MaxIdleConnsPerHost = c.perHost
+		rt = limiter{clients: c, next: logging.Transport(base)}
+		c.transports[key] = rt
+	}
+	return &http.Client{Timeout: timeout, Transport: rt}
+}
+
+// Stats returns what's been sent to each host, busiest first.
+func (c *Clients) Stats() []HostStats {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make([]HostStats, 0, len(c.stats))
+	for _, st := range c.stats {
+		copied := *st
+		copied.Statuses = make(map[string]int, len(st.Statuses))
+		for class, n := range st.Statuses {
+			copied.Statuses[class] = n
+		}
+		stats = append(stats, copied)
+	}
+	slices.SortFunc(stats, func(a, b HostStats) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), cmp.Compare(a.Host, b.Host))
+	})
+	return stats
+}
+
+// statsFor returns the counters for name, or OtherHosts' once maxHosts hosts
+// are being counted. c.mu must be held.
+func (c *Clients) statsFor(name string) *HostStats {
+	if st, ok := c.stats[name]; ok {
+		return st
+	}
+	if len(c.stats) >= maxHosts {
+		name = OtherHosts
+		if st, ok := c.stats[name]; ok {
+			return st
+		}
+	}
+	st := &HostStats{Host: name, Statuses: map[string]int{}}
+	c.stats[name] = st
+	return st
+}
+
+// acquire waits for one of name's slots, returning the function that gives it
+// back, or ctx's error if ctx is done first.
+func (c *Clients) acquire(ctx context.Context, name string) (func(), *HostStats, error) {
+	c.mu.Lock()
+	h, ok := c.hosts[name]
+	if !ok {
+		h = &host{slots: make(chan struct{}, c.perHost)}
+		c.hosts[name] = h
+	}
+	h.users++
+	st := c.statsFor(name)
+	st.Waiting++
+	c.mu.Unlock()
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		c.mu.Lock()
+		st.Waiting--
+		c.leave(name, h)
+		c.mu.Unlock()
+		return nil, nil, ctx.Err()
+	}
+	c.mu.Lock()
+	st.Waiting--
+	st.InFlight++
+	c.mu.Unlock()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			<-h.slots
+			c.mu.Lock()
+			st.InFlight--
+			c.leave(name, h)
+			c.mu.Unlock()
+		})
+	}
+	return release, st, nil
+}
+
+// leave drops h once nothing is using it. c.mu must be held.
+func (c *Clients) leave(name string, h *host) {
+	h.users--
+	if h.users == 0 {
+		delete(c.hosts, name)
+	}
+}
+
+// limiter is the round tripper every client shares: it sets the User-Agent,
+// holds each host to its slots and counts the requests.
+type limiter struct {
+	clients *Clients
+	next    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper. The host's slot is held until the
+// response body is read to the end or closed.
+func (l limiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", UserAgent)
+	}
+	release, st, err := l.clients.acquire(req.Context(), req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.next.RoundTrip(req)
+	l.clients.mu.Lock()
+	st.Requests++
+	if err != nil {
+		st.Errors++
+	} else {
+		st.Statuses[fmt.Sprintf("%dxx", resp.StatusCode/100)]++
+	}
+	l.clients.mu.Unlock()
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody gives its host's slot back once it's read to the end or
+// closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
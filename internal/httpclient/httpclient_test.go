@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"go-discord-bot/internal/proxy"
+)
+
+func TestUserAgent(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.UserAgent()
+	}))
+	defer srv.Close()
+	client := New(proxy.Routes{}, 0).Other(time.Second)
+
+	testCases := []struct {
+		name     string
+		agent    string
+		expected string
+	}{
+		{name: "Default", expected: UserAgent},
+		{name: "Own", agent: "feeds/1.0", expected: "feeds/1.0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if tc.agent != "" {
+				req.Header.Set("User-Agent", tc.agent)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if got != tc.expected {
+				t.Errorf("User-Agent = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPerHostLimit(t *testing.T) {
+	var mu sync.Mutex
+	running, most := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		most = max(most, running)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	clients := New(proxy.Routes{}, 2)
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := clients.Twitter(time.Second).Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if most != 2 {
+		t.Errorf("at most %d requests at once; want 2", most)
+	}
+	u, _ := url.Parse(srv.URL)
+	stats := clients.Stats()
+	if len(stats) != 1 || stats[0].Host != u.Hostname() || stats[0].Requests != 6 || stats[0].Statuses["2xx"] != 6 || stats[0].InFlight != 0 {
+		t.Errorf("Stats() = %+v; want 6 finished 2xx requests to %s", stats, u.Hostname())
+	}
+}
+
+func TestSharedTransports(t *testing.T) {
+	clients := New(proxy.Routes{}, 0)
+	if clients.Other(time.Second).Transport != clients.Twitter(5*time.Second).Transport {
+		t.Error("unproxied clients don't share a transport")
+	}
+	if clients.Other(0).Transport == clients.Links(0).Transport {
+		t.Error("links share a transport with clients that may reach private addresses")
+	}
+}
+
+func TestNilClients(t *testing.T) {
+	var clients *Clients
+	if clients.Links(time.Second) != nil || clients.Stats() != nil {
+		t.Error("nil Clients returned a client or stats")
+	}
+}
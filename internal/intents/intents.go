@@ -0,0 +1,137 @@
+// Package intents picks the gateway intents the bot connects with and checks
+// them against the features that need them, so a missing intent turns a
+// feature off with an explanation instead of leaving it silently broken.
+package intents
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+)
+
+// byName maps the intent names accepted in config to their bits.
+var byName = map[string]discordgo.Intent{
+	"guilds":                  discordgo.IntentsGuilds,
+	"guild_members":           discordgo.IntentsGuildMembers,
+	"guild_voice_states":      discordgo.IntentsGuildVoiceStates,
+	"guild_presences":         discordgo.IntentsGuildPresences,
+	"guild_messages":          discordgo.IntentsGuildMessages,
+	"guild_message_reactions": discordgo.IntentsGuildMessageReactions,
+	"direct_messages":         discordgo.IntentsDirectMessages,
+	"message_content":         discordgo.IntentMessageContent,
+}
+
+// Privileged are the intents that must also be turned on for the bot in the
+// Discord developer portal.
+const Privileged = discordgo.IntentsGuildMembers | discordgo.IntentsGuildPresences | discordgo.IntentMessageContent
+
+// Parse turns a list of intent names such as "guild_messages" into intents.
+// Names are case-insensitive; unknown names are an error.
+func Parse(names []string) (discordgo.Intent, error) {
+	var intents discordgo.Intent
+	for _, name := range names {
+		intent, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("unknown intent %q, expected one of %s", name, strings.Join(known(), ", "))
+		}
+		intents |= intent
+	}
+	return intents, nil
+}
+
+// Names lists the names of intents, sorted.
+func Names(intents discordgo.Intent) []string {
+	var names []string
+	for name, intent := range byName {
+		if intents&intent != 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// known lists every intent name Parse accepts, sorted.
+func known() []string {
+	var all discordgo.Intent
+	for _, intent := range byName {
+		all |= intent
+	}
+	return Names(all)
+}
+
+// Feature is something the bot does that only works with some intents.
+type Feature struct {
+	// Name describes the feature in log lines, such as "voice notices".
+	Name string
+	// Needs are the intents the feature can't work without.
+	Needs discordgo.Intent
+	// Required features stop the bot from starting without their intents.
+	Required bool
+	// Optional features are off unless their intents are added to
+	// GATEWAY_INTENTS, so they aren't in the default intents and missing them
+	// needs no explaining.
+	Optional bool
+	// Disable turns the feature off. Nil for features that simply never see
+	// the events they'd need.
+	Disable func()
+}
+
+// Default returns the intents every feature that isn't optional needs.
+func Default(features []Feature) discordgo.Intent {
+	var intents discordgo.Intent
+	for _, f := range features {
+		if !f.Optional {
+			intents |= f.Needs
+		}
+	}
+	return intents
+}
+
+// Advise checks features against the intents the bot connects with. It fails
+// if a required feature is missing intents, and otherwise disables the rest
+// that are, returning a note explaining each one it turned off.
+func Advise(enabled discordgo.Intent, features []Feature) ([]string, error) {
+	var notes []string
+	for _, f := range features {
+		missing := f.Needs &^ enabled
+		if missing == 0 || f.Optional {
+			continue
+		}
+		if f.Required {
+			return nil, fmt.Errorf("%s needs the %s intents; add them to GATEWAY_INTENTS", f.Name, strings.Join(Names(missing), ", "))
+		}
+		if f.Disable != nil {
+			f.Disable()
+		}
+		notes = append(notes, fmt.Sprintf("Turned off %s: it needs the %s intents, which GATEWAY_INTENTS leaves out", f.Name, strings.Join(Names(missing), ", ")))
+	}
+	return notes, nil
+}
+
+// Gateway close codes for intents Discord won't accept.
+const (
+	closeInvalidIntents    = 4013
+	closeDisallowedIntents = 4014
+)
+
+// Explain adds advice to errors connecting with intents Discord refused,
+// and returns other errors unchanged.
+func Explain(err error, intents discordgo.Intent) error {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return err
+	}
+	switch closeErr.Code {
+	case closeDisallowedIntents:
+		return fmt.Errorf("%w: turn on the %s privileged intents for the bot in the Discord developer portal, or remove them from GATEWAY_INTENTS",
+			err, strings.Join(Names(intents&Privileged), ", "))
+	case closeInvalidIntents:
+		return fmt.Errorf("%w: Discord doesn't accept the intents %s", err, strings.Join(Names(intents), ", "))
+	}
+	return err
+}
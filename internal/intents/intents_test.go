@@ -0,0 +1,83 @@
+package intents
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		input    []string
+		expected discordgo.Intent
+		wantErr  bool
+	}{
+		{input: nil, expected: 0},
+		{input: []string{"guild_messages", " Message_Content "}, expected: discordgo.IntentsGuildMessages | discordgo.IntentMessageContent},
+		{input: []string{"guild_messages", "messages"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		result, err := Parse(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Parse(%q) error = %v; wantErr %v", tc.input, err, tc.wantErr)
+			continue
+		}
+		if result != tc.expected {
+			t.Errorf("Parse(%q) = %d; want %d", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestAdvise(t *testing.T) {
+	testCases := []struct {
+		name     string
+		enabled  discordgo.Intent
+		notes    int
+		disabled []string
+		wantErr  bool
+	}{
+		{name: "Everything", enabled: discordgo.IntentsAll},
+		{name: "No reactions", enabled: discordgo.IntentsGuildMessages | discordgo.IntentMessageContent, notes: 1, disabled: []string{"reactions"}},
+		{name: "No message content", enabled: discordgo.IntentsGuildMessages | discordgo.IntentsGuildMessageReactions, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var disabled []string
+			features := []Feature{
+				{Name: "link fixing", Needs: discordgo.IntentsGuildMessages | discordgo.IntentMessageContent, Required: true},
+				{Name: "reactions", Needs: discordgo.IntentsGuildMessageReactions, Disable: func() { disabled = append(disabled, "reactions") }},
+				{Name: "DMs", Needs: discordgo.IntentsDirectMessages, Optional: true},
+			}
+			if Default(features)&discordgo.IntentsDirectMessages != 0 {
+				t.Error("Default includes an optional feature's intents")
+			}
+
+			notes, err := Advise(tc.enabled, features)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Advise error = %v; wantErr %v", err, tc.wantErr)
+			}
+			if len(notes) != tc.notes || fmt.Sprint(disabled) != fmt.Sprint(tc.disabled) {
+				t.Errorf("Advise noted %q and disabled %v; want %d notes and %v disabled", notes, disabled, tc.notes, tc.disabled)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	other := errors.New("network down")
+	if err := Explain(other, discordgo.IntentMessageContent); err != other {
+		t.Errorf("Explain changed an unrelated error to %v", err)
+	}
+
+	refused := &websocket.CloseError{Code: closeDisallowedIntents, Text: "Disallowed intent(s)."}
+	err := Explain(refused, discordgo.IntentsGuildMessages|discordgo.IntentMessageContent)
+	if !errors.Is(err, refused) || !strings.Contains(err.Error(), "turn on the message_content privileged intents") {
+		t.Errorf("Explain = %v; want advice on message_content", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package invite works out which permissions the bot needs in a server, from
+// the features it has turned on, and builds the link that invites it with them.
+package invite
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/intents"
+)
+
+// Feature is something the bot does that needs permissions in a server.
+type Feature struct {
+	// Name describes the feature to whoever invites the bot.
+	Name        string
+	Permissions int64
+}
+
+// Features the bot may need permissions for.
+var (
+	// Fixing is reposting fixed links, as replies and in threads.
+	Fixing = Feature{Name: "fixing links", Permissions: discordgo.PermissionViewChannel |
+		discordgo.PermissionSendMessages |
+		discordgo.PermissionSendMessagesInThreads |
+		discordgo.PermissionEmbedLinks |
+		discordgo.PermissionReadMessageHistory}
+	// Moderation is deleting phishing links, publishing other people's
+	// messages in announcement channels, hiding their broken embeds in
+	// minimal mode and deleting them with /purge.
+	Moderation = Feature{Name: "deleting phishing links, publishing announcements, hiding broken embeds and /purge", Permissions: discordgo.PermissionManageMessages}
+	// Reactions is reaction mode, which reacts to messages it could fix, and
+	// trigger emoji, which mark the messages fixed.
+	Reactions = Feature{Name: "reaction mode and trigger emoji", Permissions: discordgo.PermissionAddReactions}
+	// SuccessReactions is reacting to the messages the bot fixed.
+	SuccessReactions = Feature{Name: "success reactions", Permissions: discordgo.PermissionAddReactions}
+	// Emoji is /steal adding emoji and stickers.
+	Emoji = Feature{Name: "/steal", Permissions: discordgo.PermissionManageGuildExpressions}
+	// Polls is /poll posting votes.
+	Polls = Feature{Name: "/poll", Permissions: discordgo.PermissionSendPolls}
+	// Slowmode is /slowmode changing how long members wait between messages.
+	Slowmode = Feature{Name: "/slowmode", Permissions: discordgo.PermissionManageChannels}
+	// Roles is /welcome and /rolemenu handing out roles.
+	Roles = Feature{Name: "welcome roles and role menus", Permissions: discordgo.PermissionManageRoles}
+	// Threads is thread mode, which posts fixed links in a thread on the
+	// original message.
+	Threads = Feature{Name: "thread mode", Permissions: discordgo.PermissionCreatePublicThreads}
+	// Voice is playing voice notices.
+	Voice = Feature{Name: "voice notices", Permissions: discordgo.PermissionVoiceConnect | discordgo.PermissionVoiceSpeak}
+)
+
+// Enabled returns the features cfg turns on.
+func Enabled(cfg config.Config) []Feature {
+	features := []Feature{Fixing, Moderation, SuccessReactions}
+	// Without reaction events neither can work, and reaction mode is turned off at startup
+	enabled, _ := intents.Parse(cfg.Intents)
+	if len(cfg.Intents) == 0 || enabled&discordgo.IntentsGuildMessageReactions != 0 {
+		features = append(features, Reactions)
+	}
+	features = append(features, Emoji, Polls, Slowmode, Roles, Threads)
+	if cfg.VoiceSoundFile != "" || cfg.VoiceTTSCommand != "" {
+		features = append(features, Voice)
+	}
+	return features
+}
+
+// Permissions returns every permission features need.
+func Permissions(features []Feature) int64 {
+	var perms int64
+	for _, f := range features {
+		perms |= f.Permissions
+	}
+	return perms
+}
+
+// URL returns the link that adds the application appID to a server as a bot
+// with its slash commands and perms.
+func URL(appID string, perms int64) string {
+	q := url.Values{}
+	q.Set("client_id", appID)
+	q.Set("scope", "bot applications.commands")
+	q.Set("permissions", strconv.FormatInt(perms, 10))
+	return "https://discord.com/oauth2/authorize?" + q.Encode()
+}
@@ -0,0 +1,45 @@
+package invite
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+
+	"go-discord-bot/internal/config"
+)
+
+func TestEnabled(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      config.Config
+		expected []Feature
+	}{
+		{name: "Defaults", expected: []Feature{Fixing, Moderation, SuccessReactions, Reactions, Emoji, Polls, Slowmode, Roles, Threads}},
+		{name: "Voice notices", cfg: config.Config{VoiceSoundFile: "ding.ogg"}, expected: []Feature{Fixing, Moderation, SuccessReactions, Reactions, Emoji, Polls, Slowmode, Roles, Threads, Voice}},
+		{name: "No reaction events", cfg: config.Config{Intents: []string{"guild_messages", "message_content"}}, expected: []Feature{Fixing, Moderation, SuccessReactions, Emoji, Polls, Slowmode, Roles, Threads}},
+		{name: "Reaction events", cfg: config.Config{Intents: []string{"guild_messages", "guild_message_reactions"}}, expected: []Feature{Fixing, Moderation, SuccessReactions, Reactions, Emoji, Polls, Slowmode, Roles, Threads}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if features := Enabled(tc.cfg); !slices.Equal(features, tc.expected) {
+				t.Errorf("Enabled = %v; want %v", features, tc.expected)
+			}
+		})
+	}
+}
+
+func TestURL(t *testing.T) {
+	u, err := url.Parse(URL("123", Permissions([]Feature{Reactions, Voice})))
+	if err != nil {
+		t.Fatalf("URL doesn't parse: %v", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != "123" || q.Get("scope") != "bot applications.commands" {
+		t.Errorf("query = %v", q)
+	}
+	// Add Reactions, Connect and Speak
+	if q.Get("permissions") != "3145792" {
+		t.Errorf("permissions = %s; want 3145792", q.Get("permissions"))
+	}
+}
@@ -0,0 +1,87 @@
+// Package janitor periodically prunes the bot's in-memory tracking data, such as
+// remembered reposts, so a long-running bot's memory stays bounded.
+package janitor
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Pruner holds data that goes stale. Prune forgets whatever is stale at now and
+// returns how many items it removed.
+type Pruner interface {
+	Prune(now time.Time) int
+}
+
+// Janitor runs its pruners on a schedule and counts what they remove.
+type Janitor struct {
+	now     func() time.Time
+	names   []string
+	pruners map[string]Pruner
+
+	mu     sync.Mutex
+	totals map[string]int
+}
+
+// New returns a Janitor with no pruners.
+func New() *Janitor {
+	return &Janitor{now: time.Now, pruners: make(map[string]Pruner), totals: make(map[string]int)}
+}
+
+// Add registers a pruner under a name used in logs and totals.
+// Pruners must be added before Run is called.
+func (j *Janitor) Add(name string, p Pruner) {
+	if _, ok := j.pruners[name]; !ok {
+		j.names = append(j.names, name)
+	}
+	j.pruners[name] = p
+}
+
+// Sweep runs every pruner once and returns how many items each removed.
+func (j *Janitor) Sweep() map[string]int {
+	now := j.now()
+	pruned := make(map[string]int, len(j.names))
+	for _, name := range j.names {
+		pruned[name] = j.pruners[name].Prune(now)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, name := range j.names {
+		j.totals[name] += pruned[name]
+		if pruned[name] > 0 {
+			log.Printf("Pruned %d stale %s (%d since start)\n", pruned[name], name, j.totals[name])
+		}
+	}
+	return pruned
+}
+
+// Totals returns how many items each pruner has removed since the janitor started.
+func (j *Janitor) Totals() map[string]int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	totals := make(map[string]int, len(j.totals))
+	for name, n := range j.totals {
+		totals[name] = n
+	}
+	return totals
+}
+
+// Run sweeps every interval until ctx is done.
+func (j *Janitor) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.Sweep()
+		}
+	}
+}
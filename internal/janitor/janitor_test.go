@@ -0,0 +1,53 @@
+package janitor
+
+import (
+	"testing"
+	"time"
+
+	"go-discord-bot/internal/dedupe"
+)
+
+// countPruner prunes a fixed number of items on every sweep.
+type countPruner int
+
+func (p countPruner) Prune(time.Time) int { return int(p) }
+
+func TestJanitorSweep(t *testing.T) {
+	clock := time.Now()
+	j := New()
+	j.now = func() time.Time { return clock }
+
+	reposts := dedupe.New(time.Minute)
+	reposts.Record("guild", "1", "chan", "msg")
+	reposts.Record("guild", "2", "chan", "msg")
+	var disabled *dedupe.Tracker
+
+	j.Add("reposts", reposts)
+	j.Add("disabled", disabled)
+	j.Add("fixed", countPruner(2))
+
+	testCases := []struct {
+		name     string
+		advance  time.Duration
+		expected map[string]int
+	}{
+		{name: "Nothing stale yet", expected: map[string]int{"reposts": 0, "disabled": 0, "fixed": 2}},
+		{name: "Reposts expired", advance: time.Hour, expected: map[string]int{"reposts": 2, "disabled": 0, "fixed": 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock = clock.Add(tc.advance)
+			pruned := j.Sweep()
+			for name, want := range tc.expected {
+				if pruned[name] != want {
+					t.Errorf("pruned %d %s; want %d", pruned[name], name, want)
+				}
+			}
+		})
+	}
+
+	if totals := j.Totals(); totals["reposts"] != 2 || totals["fixed"] != 4 {
+		t.Errorf("Totals = %v; want 2 reposts and 4 fixed", totals)
+	}
+}
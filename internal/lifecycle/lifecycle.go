@@ -0,0 +1,202 @@
+// Package lifecycle holds the parts of the bot its owner can stop and start
+// while it runs, such as link fixing or feeds, to switch off one that
+// misbehaves without restarting the whole bot. Stopped modules stay stopped
+// across restarts.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding the stopped modules.
+const Bucket = "modules"
+
+// stoppedKey is where the stopped modules are kept in Bucket.
+const stoppedKey = "stopped"
+
+// The modules the bot registers.
+const (
+	// Fixers fixes the links in messages.
+	Fixers = "fixers"
+	// Greeter welcomes new members.
+	Greeter = "greeter"
+	// Starboard reposts starred messages to starboards.
+	Starboard = "starboard"
+	// Feeds posts new items from RSS and Atom feeds.
+	Feeds = "feeds"
+)
+
+// ErrUnknown is returned for modules that weren't registered.
+var ErrUnknown = errors.New("unknown module")
+
+// Status describes a module.
+type Status struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	// Since is when the owner last enabled or disabled the module, zero if
+	// they haven't since the bot started.
+	Since time.Time `json:"since,omitempty"`
+}
+
+// module is a registered module and its background work, if it's running.
+type module struct {
+	description string
+	run         func(ctx context.Context)
+	enabled     bool
+	since       time.Time
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// Manager enables and disables modules. A nil Manager has every module
+// enabled.
+type Manager struct {
+	store storage.Store
+	now   func() time.Time
+
+	mu      sync.Mutex
+	ctx     context.Context
+	modules map[string]*module
+	order   []string
+	stopped []string
+}
+
+// New returns a Manager with the modules stopped in st disabled. A nil st
+// forgets them when the bot stops.
+func New(st storage.Store) (*Manager, error) {
+	m := &Manager{store: st, now: time.Now, modules: make(map[string]*module)}
+	if st == nil {
+		return m, nil
+	}
+	if _, err := st.Get(Bucket, stoppedKey, &m.stopped); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Register adds a module, disabled if it was stopped before the bot last
+// restarted. run, if not nil, is the module's background work, run until its
+// context is done; modules without it are only switched off for whoever
+// checks Enabled.
+func (m *Manager) Register(name, description string, run func(ctx context.Context)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.modules[name] = &module{description: description, run: run, enabled: !slices.Contains(m.stopped, name)}
+	m.order = append(m.order, name)
+}
+
+// Start runs the background work of the enabled modules, and of those
+// enabled later, until ctx is done.
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ctx = ctx
+	for _, name := range m.order {
+		if mod := m.modules[name]; mod.enabled {
+			m.launch(mod)
+		}
+	}
+}
+
+// launch starts mod's background work, if it has any and the manager has
+// started. m.mu must be held.
+func (m *Manager) launch(mod *module) {
+	if mod.run == nil || m.ctx == nil || mod.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	done := make(chan struct{})
+	mod.cancel, mod.done = cancel, done
+	go func() {
+		defer close(done)
+		mod.run(ctx)
+	}()
+}
+
+// Enabled reports whether the named module is enabled. Modules that weren't
+// registered always are.
+func (m *Manager) Enabled(name string) bool {
+	if m == nil {
+		return true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mod, ok := m.modules[name]
+	return !ok || mod.enabled
+}
+
+// Enable enables the named module, starting its background work.
+func (m *Manager) Enable(name string) error {
+	return m.set(name, true)
+}
+
+// Disable disables the named module, waiting for its background work to stop.
+func (m *Manager) Disable(name string) error {
+	return m.set(name, false)
+}
+
+// set enables or disables the named module and saves which are stopped.
+func (m *Manager) set(name string, enabled bool) error {
+	m.mu.Lock()
+	mod, ok := m.modules[name]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("%w %q", ErrUnknown, name)
+	}
+	if mod.enabled == enabled {
+		m.mu.Unlock()
+		return nil
+	}
+	mod.enabled, mod.since = enabled, m.now()
+	var done chan struct{}
+	if enabled {
+		m.launch(mod)
+	} else if mod.cancel != nil {
+		mod.cancel()
+		done = mod.done
+		mod.cancel, mod.done = nil, nil
+	}
+	m.stopped = slices.DeleteFunc(m.stopped, func(stopped string) bool { return stopped == name })
+	if !enabled {
+		m.stopped = append(m.stopped, name)
+	}
+	stopped := slices.Clone(m.stopped)
+	m.mu.Unlock()
+
+	if enabled {
+		log.Println("Module enabled:", name)
+	} else {
+		log.Println("Module disabled:", name)
+	}
+	if done != nil {
+		<-done
+	}
+	if m.store == nil {
+		return nil
+	}
+	return m.store.Put(Bucket, stoppedKey, stopped)
+}
+
+// Statuses describes every module, in the order they were registered.
+func (m *Manager) Statuses() []Status {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]Status, 0, len(m.order))
+	for _, name := range m.order {
+		mod := m.modules[name]
+		statuses = append(statuses, Status{Name: name, Description: mod.description, Enabled: mod.enabled, Since: mod.since})
+	}
+	return statuses
+}
@@ -0,0 +1,68 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+
+	"go-discord-bot/internal/storage"
+)
+
+func TestManager(t *testing.T) {
+	st := storage.NewMemory()
+	m, err := New(st)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	runs := make(chan context.Context, 2)
+	m.Register(Feeds, "Posts feeds", func(ctx context.Context) {
+		runs <- ctx
+		<-ctx.Done()
+	})
+	m.Register(Fixers, "Fixes links", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Start(ctx)
+
+	first := <-runs
+	if !m.Enabled(Feeds) || !m.Enabled(Fixers) || !m.Enabled("unregistered") {
+		t.Fatal("a module is disabled before anyone disabled it")
+	}
+
+	if err := m.Disable(Feeds); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	// Disable waits for the background work to stop
+	if first.Err() == nil || m.Enabled(Feeds) {
+		t.Error("Disable left feeds running")
+	}
+	if err := m.Disable(Fixers); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	if err := m.Disable("unknown"); err == nil {
+		t.Error("Disable of an unknown module succeeded")
+	}
+
+	if err := m.Enable(Feeds); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	if second := <-runs; second.Err() != nil || !m.Enabled(Feeds) {
+		t.Error("Enable didn't run feeds again")
+	}
+
+	// A restart keeps fixers disabled
+	restarted, err := New(st)
+	if err != nil {
+		t.Fatalf("New after restart: %v", err)
+	}
+	restarted.Register(Fixers, "Fixes links", nil)
+	restarted.Register(Feeds, "Posts feeds", nil)
+	statuses := restarted.Statuses()
+	if len(statuses) != 2 || statuses[0].Enabled || !statuses[1].Enabled {
+		t.Errorf("Statuses after restart = %+v; want fixers disabled and feeds enabled", statuses)
+	}
+
+	var none *Manager
+	if !none.Enabled(Fixers) || none.Statuses() != nil {
+		t.Error("nil Manager disables modules")
+	}
+}
@@ -0,0 +1,139 @@
+// Package locale writes numbers and dates the way a server's language does,
+// for the counts in /stats, /leaderboard and digests. Locales are Discord's,
+// such as "de" or "en-GB"; unknown ones are written like US English.
+package locale
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default is the locale used when a server has none the bot knows.
+const Default = "en-US"
+
+const (
+	// nbsp and nnbsp keep a number together with its digit groups and
+	// percent sign, the way the languages using spaces there write it.
+	nbsp  = "\u00a0"
+	nnbsp = "\u202f"
+)
+
+// format is how a locale writes numbers and dates.
+type format struct {
+	// group separates thousands, decimal the fraction.
+	group, decimal string
+	// percent writes a percentage around its number, such as "%s%%".
+	percent string
+	// date is a time layout for days.
+	date string
+}
+
+// formats holds every locale Discord has.
+var formats = map[string]format{
+	"bg":     {group: nbsp, decimal: ",", percent: "%s" + nbsp + "%%", date: "2.01.2006"},
+	"cs":     {group: nbsp, decimal: ",", percent: "%s" + nbsp + "%%", date: "2. 1. 2006"},
+	"da":     {group: ".", decimal: ",", percent: "%s" + nbsp + "%%", date: "2.1.2006"},
+	"de":     {group: ".", decimal: ",", percent: "%s" + nbsp + "%%", date: "02.01.2006"},
+	"el":     {group: ".", decimal: ",", percent: "%s%%", date: "2/1/2006"},
+	"en-GB":  {group: ",", decimal: ".", percent: "%s%%", date: "02/01/2006"},
+	"en-US":  {group: ",", decimal: ".", percent: "%s%%", date: "1/2/2006"},
+	"es-419": {group: ",", decimal: ".", percent: "%s" + nbsp + "%%", date: "2/1/2006"},
+	"es-ES":  {group: ".", decimal: ",", percent: "%s" + nbsp + "%%", date: "2/1/2006"},
+	"fi":     {group: nbsp, decimal: ",", percent: "%s" + nbsp + "%%", date: "2.1.2006"},
+	"fr":     {group: nnbsp, decimal: ",", percent: "%s" + nnbsp + "%%", date: "02/01/2006"},
+	"hi":     {group: ",", decimal: ".", percent: "%s%%", date: "2/1/2006"},
+	"hr":     {group: ".", decimal: ",", percent: "%s" + nbsp + "%%", date: "02. 01. 2006."},
+	"hu":     {group: nbsp, decimal: ",", percent: "%s%%", date: "2006. 01. 02."},
+	"id":     {group: ".", decimal: ",", percent: "%s%%", date: "2/1/2006"},
+	"it":     {group: ".", decimal: ",", percent: "%s%%", date: "2/1/2006"},
+	"ja":     {group: ",", decimal: ".", percent: "%s%%", date: "2006/01/02"},
+	"ko":     {group: ",", decimal: ".", percent: "%s%%", date: "2006. 1. 2."},
+	"lt":     {group: nbsp, decimal: ",", percent: "%s" + nbsp + "%%", date: "2006-01-02"},
+	"nl":     {group: ".", decimal: ",", percent: "%s%%", date: "2-1-2006"},
+	"no":     {group: nbsp, decimal: ",", percent: "%s" + nbsp + "%%", date: "2.1.2006"},
+	"pl":     {group: nbsp, decimal: ",", percent: "%s%%", date: "2.01.2006"},
+	"pt-BR":  {group: ".", decimal: ",", percent: "%s%%", date: "02/01/2006"},
+	"ro":     {group: ".", decimal: ",", percent: "%s" + nbsp + "%%", date: "02.01.2006"},
+	"ru":     {group: nbsp, decimal: ",", percent: "%s" + nbsp + "%%", date: "02.01.2006"},
+	"sv-SE":  {group: nbsp, decimal: ",", percent: "%s" + nbsp + "%%", date: "2006-01-02"},
+	"th":     {group: ",", decimal: ".", percent: "%s%%", date: "2/1/2006"},
+	"tr":     {group: ".", decimal: ",", percent: "%%%s", date: "02.01.2006"},
+	"uk":     {group: nbsp, decimal: ",", percent: "%s%%", date: "02.01.2006"},
+	"vi":     {group: ".", decimal: ",", percent: "%s%%", date: "2/1/2006"},
+	"zh-CN":  {group: ",", decimal: ".", percent: "%s%%", date: "2006/1/2"},
+	"zh-TW":  {group: ",", decimal: ".", percent: "%s%%", date: "2006/1/2"},
+}
+
+// Locales lists the locales the bot knows, sorted.
+func Locales() []string {
+	locales := make([]string, 0, len(formats))
+	for l := range formats {
+		locales = append(locales, l)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Known reports whether the bot knows how locale writes numbers and dates.
+func Known(locale string) bool {
+	_, ok := formats[locale]
+	return ok
+}
+
+// lookup returns how locale writes numbers and dates.
+func lookup(locale string) format {
+	if f, ok := formats[locale]; ok {
+		return f
+	}
+	return formats[Default]
+}
+
+// Count writes n with its thousands grouped, such as "12,345" or "12.345".
+func Count(locale string, n int) string {
+	return group(strconv.Itoa(n), lookup(locale).group)
+}
+
+// group separates the thousands of the whole number digits with sep.
+func group(digits, sep string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	first := len(digits) % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(digits[:first])
+	for i := first; i < len(digits); i += 3 {
+		b.WriteString(sep)
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// Percent writes a fraction as a percentage with at most one decimal place,
+// such as "12.5%" or "12,5 %".
+func Percent(locale string, fraction float64) string {
+	f := lookup(locale)
+	number := strconv.FormatFloat(fraction*100, 'f', 1, 64)
+	number = strings.TrimSuffix(number, ".0")
+	whole, decimals, _ := strings.Cut(number, ".")
+	number = group(whole, f.group)
+	if decimals != "" {
+		number += f.decimal + decimals
+	}
+	return fmt.Sprintf(f.percent, number)
+}
+
+// Date writes the day of t, such as "5/1/2024" or "01.05.2024".
+func Date(locale string, t time.Time) string {
+	return t.Format(lookup(locale).date)
+}
@@ -0,0 +1,41 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		locale          string
+		count           int
+		percent         float64
+		expectedCount   string
+		expectedPercent string
+		expectedDate    string
+	}{
+		{locale: "en-US", count: 1234567, percent: 0.125, expectedCount: "1,234,567", expectedPercent: "12.5%", expectedDate: "5/1/2024"},
+		{locale: "en-GB", count: 999, percent: 1, expectedCount: "999", expectedPercent: "100%", expectedDate: "01/05/2024"},
+		{locale: "de", count: 12345, percent: 0.5, expectedCount: "12.345", expectedPercent: "50\u00a0%", expectedDate: "01.05.2024"},
+		{locale: "fr", count: -1234, percent: 0.0333, expectedCount: "-1\u202f234", expectedPercent: "3,3\u202f%", expectedDate: "01/05/2024"},
+		{locale: "tr", count: 1000, percent: 0.25, expectedCount: "1.000", expectedPercent: "%25", expectedDate: "01.05.2024"},
+		{locale: "ja", count: 100000, percent: 0.999, expectedCount: "100,000", expectedPercent: "99.9%", expectedDate: "2024/05/01"},
+		{locale: "xx", count: 1000, percent: 0.1, expectedCount: "1,000", expectedPercent: "10%", expectedDate: "5/1/2024"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.locale, func(t *testing.T) {
+			if got := Count(tc.locale, tc.count); got != tc.expectedCount {
+				t.Errorf("Count(%d) = %q; want %q", tc.count, got, tc.expectedCount)
+			}
+			if got := Percent(tc.locale, tc.percent); got != tc.expectedPercent {
+				t.Errorf("Percent(%v) = %q; want %q", tc.percent, got, tc.expectedPercent)
+			}
+			if got := Date(tc.locale, day); got != tc.expectedDate {
+				t.Errorf("Date = %q; want %q", got, tc.expectedDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Module is a part of the bot whose logging can be turned up on its own, to
+// debug it in production without drowning the logs in everything else.
+type Module string
+
+const (
+	// Fixers logs which fixers matched each message.
+	Fixers Module = "fixers"
+	// Storage logs every write to the store.
+	Storage Module = "storage"
+	// Gateway logs every event Discord's gateway sends.
+	Gateway Module = "gateway"
+	// HTTP logs every outgoing request and how it went.
+	HTTP Module = "http"
+)
+
+// Modules lists every module, in the order they're shown.
+var Modules = []Module{Fixers, Storage, Gateway, HTTP}
+
+// Level is how much a module logs.
+type Level string
+
+const (
+	// Info logs errors and notable events only. It is the default.
+	Info Level = "info"
+	// Debug logs the module's every step as well.
+	Debug Level = "debug"
+)
+
+var (
+	levelsMu sync.RWMutex
+	debug    = make(map[Module]bool)
+)
+
+// SetLevel changes how much a module logs, from now on.
+func SetLevel(m Module, l Level) error {
+	if !slices.Contains(Modules, m) {
+		return fmt.Errorf("unknown module %q", m)
+	}
+	if l != Info && l != Debug {
+		return fmt.Errorf("unknown level %q", l)
+	}
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	debug[m] = l == Debug
+	return nil
+}
+
+// LevelOf returns how much a module logs.
+func LevelOf(m Module) Level {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	if debug[m] {
+		return Debug
+	}
+	return Info
+}
+
+// Levels returns how much each module logs.
+func Levels() map[Module]Level {
+	levels := make(map[Module]Level, len(Modules))
+	for _, m := range Modules {
+		levels[m] = LevelOf(m)
+	}
+	return levels
+}
+
+// Debugf logs a step of a module when it's at the Debug level. Details of
+// messages must go through Contentf instead, which respects privacy mode.
+func Debugf(m Module, format string, args ...any) {
+	if LevelOf(m) != Debug {
+		return
+	}
+	log.Printf("[%s] "+format, append([]any{m}, args...)...)
+}
+
+// Transport returns a transport passing requests to base, or
+// http.DefaultTransport if it's nil, that logs each one for the HTTP module.
+// Query strings are left out, as they may hold what's being looked up.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if LevelOf(HTTP) != Debug {
+		return t.base.RoundTrip(req)
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	took := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		Debugf(HTTP, "%s %s%s failed after %s: %v", req.Method, req.URL.Host, req.URL.Path, took, err)
+		return resp, err
+	}
+	Debugf(HTTP, "%s %s%s: %s in %s", req.Method, req.URL.Host, req.URL.Path, resp.Status, took)
+	return resp, err
+}
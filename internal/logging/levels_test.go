@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDebugf(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	log.SetFlags(0)
+	t.Cleanup(func() { log.SetFlags(log.LstdFlags) })
+	t.Cleanup(func() { SetLevel(Storage, Info) })
+
+	testCases := []struct {
+		name     string
+		module   Module
+		level    Level
+		expected string
+		wantErr  bool
+	}{
+		{name: "Info", module: Storage, level: Info, expected: ""},
+		{name: "Debug", module: Storage, level: Debug, expected: "[storage] put a/b\n"},
+		{name: "Unknown module", module: "cache", level: Debug, wantErr: true},
+		{name: "Unknown level", module: Storage, level: "trace", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			if err := SetLevel(tc.module, tc.level); (err != nil) != tc.wantErr {
+				t.Fatalf("SetLevel(%q, %q) error = %v; want error %v", tc.module, tc.level, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			Debugf(tc.module, "put %s/%s", "a", "b")
+			if buf.String() != tc.expected {
+				t.Errorf("Debugf logged %q; want %q", buf.String(), tc.expected)
+			}
+			if LevelOf(tc.module) != tc.level || Levels()[tc.module] != tc.level {
+				t.Errorf("LevelOf = %q; want %q", LevelOf(tc.module), tc.level)
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	log.SetFlags(0)
+	t.Cleanup(func() { log.SetFlags(log.LstdFlags) })
+	t.Cleanup(func() { SetLevel(HTTP, Info) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	for _, level := range []Level{Info, Debug} {
+		buf.Reset()
+		SetLevel(HTTP, level)
+		resp, err := client.Get(server.URL + "/page?q=secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		logged := buf.String()
+		if level == Info && logged != "" {
+			t.Errorf("logged %q at the info level", logged)
+		}
+		if level == Debug && (!strings.HasPrefix(logged, "[http] GET "+strings.TrimPrefix(server.URL, "http://")+"/page: 418") || strings.Contains(logged, "secret")) {
+			t.Errorf("logged %q at the debug level", logged)
+		}
+	}
+}
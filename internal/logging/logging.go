@@ -0,0 +1,40 @@
+// Package logging is where anything derived from user messages is logged.
+// Keeping it in one place lets a guild's privacy mode be enforced for every caller.
+package logging
+
+import (
+	"log"
+	"sync"
+)
+
+var (
+	mu      sync.RWMutex
+	private func(guildID string) bool
+)
+
+// SetPrivacy installs the lookup that reports whether a guild has privacy mode enabled.
+// Until it is called no guild is treated as private.
+func SetPrivacy(fn func(guildID string) bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	private = fn
+}
+
+// Private reports whether guildID has privacy mode enabled.
+// Anything that collects per-message stats must check it first.
+// Direct messages have no guild and are never private.
+func Private(guildID string) bool {
+	mu.RLock()
+	fn := private
+	mu.RUnlock()
+	return guildID != "" && fn != nil && fn(guildID)
+}
+
+// Contentf logs message content or details seen in guildID,
+// unless that guild has privacy mode enabled.
+func Contentf(guildID, format string, args ...any) {
+	if Private(guildID) {
+		return
+	}
+	log.Printf(format, args...)
+}
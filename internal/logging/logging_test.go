@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestContentf(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	log.SetFlags(0)
+	t.Cleanup(func() { log.SetFlags(log.LstdFlags) })
+
+	SetPrivacy(func(guildID string) bool { return guildID == "private" })
+	t.Cleanup(func() { SetPrivacy(nil) })
+
+	testCases := []struct {
+		name     string
+		guildID  string
+		expected string
+	}{
+		{name: "Public guild", guildID: "public", expected: "link\n"},
+		{name: "Private guild", guildID: "private", expected: ""},
+		{name: "Direct message", guildID: "", expected: "link\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			Contentf(tc.guildID, "%s\n", "link")
+			if buf.String() != tc.expected {
+				t.Errorf("Contentf in %q logged %q; want %q", tc.guildID, buf.String(), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.log")
+	r, err := OpenRotating(path, 10, 0, 2)
+	if err != nil {
+		t.Fatalf("OpenRotating: %v", err)
+	}
+	defer r.Close()
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "fourth\n" {
+		t.Errorf("current log = %q, %v; want only the last line", data, err)
+	}
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Errorf("kept %d backups; want 2", len(backups))
+	}
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.log")
+	r, err := OpenRotating(path, 0, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("OpenRotating: %v", err)
+	}
+	defer r.Close()
+
+	clock := time.Now()
+	r.now = func() time.Time { return clock }
+	r.opened = clock
+
+	testCases := []struct {
+		name    string
+		advance time.Duration
+		backups int
+	}{
+		{name: "Within max age", advance: 30 * time.Minute, backups: 0},
+		{name: "Past max age", advance: 31 * time.Minute, backups: 1},
+		{name: "Fresh file after rotation", advance: time.Minute, backups: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock = clock.Add(tc.advance)
+			if _, err := r.Write([]byte("line\n")); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if backups, _ := filepath.Glob(path + ".*"); len(backups) != tc.backups {
+				t.Errorf("have %d backups; want %d", len(backups), tc.backups)
+			}
+		})
+	}
+}
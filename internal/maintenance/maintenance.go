@@ -0,0 +1,140 @@
+// Package maintenance is the bot-wide switch that stops automatic fixing while
+// the bot is being worked on. Messages posted meanwhile are held, to be fixed
+// once maintenance ends, and both the switch and the held messages survive
+// restarts.
+package maintenance
+
+import (
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding the maintenance state.
+const Bucket = "maintenance"
+
+// stateKey is where the state is kept in Bucket.
+const stateKey = "state"
+
+// MaxHeld is how many messages are held at most. Messages past it aren't
+// fixed; /backfill can catch them after.
+const MaxHeld = 1000
+
+// Held is a message posted during maintenance, to fix once it ends.
+type Held struct {
+	// Bot is the name of the bot that saw the message.
+	Bot       string `json:"bot"`
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+}
+
+// state is what's saved in the store.
+type state struct {
+	On    bool      `json:"on"`
+	Since time.Time `json:"since"`
+	Held  []Held    `json:"held,omitempty"`
+}
+
+// Mode is the maintenance switch. A nil Mode is never on.
+type Mode struct {
+	store storage.Store
+	now   func() time.Time
+
+	mu    sync.Mutex
+	state state
+}
+
+// New returns the switch saved in st, off if there's none. A nil st keeps it
+// in memory.
+func New(st storage.Store) (*Mode, error) {
+	m := &Mode{store: st, now: time.Now}
+	if st == nil {
+		return m, nil
+	}
+	if _, err := st.Get(Bucket, stateKey, &m.state); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// On reports whether the bot is in maintenance.
+func (m *Mode) On() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.On
+}
+
+// Status returns whether the bot is in maintenance, since when, and how many
+// messages are held.
+func (m *Mode) Status() (on bool, since time.Time, held int) {
+	if m == nil {
+		return false, time.Time{}, 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.On, m.state.Since, len(m.state.Held)
+}
+
+// Start puts the bot into maintenance. Starting it again changes nothing.
+func (m *Mode) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state.On {
+		return nil
+	}
+	next := state{On: true, Since: m.now()}
+	if err := m.save(next); err != nil {
+		return err
+	}
+	m.state = next
+	return nil
+}
+
+// End takes the bot out of maintenance and returns the messages held
+// meanwhile, oldest first.
+func (m *Mode) End() ([]Held, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.state.On {
+		return nil, nil
+	}
+	if err := m.save(state{}); err != nil {
+		return nil, err
+	}
+	held := m.state.Held
+	m.state = state{}
+	return held, nil
+}
+
+// Hold keeps a message to fix once maintenance ends. It reports false if the
+// bot isn't in maintenance or MaxHeld messages are already held.
+func (m *Mode) Hold(h Held) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.state.On || len(m.state.Held) >= MaxHeld {
+		return false
+	}
+	next := m.state
+	next.Held = append(next.Held[:len(next.Held):len(next.Held)], h)
+	if err := m.save(next); err != nil {
+		return false
+	}
+	m.state = next
+	return true
+}
+
+// save writes s to the store.
+func (m *Mode) save(s state) error {
+	if m.store == nil {
+		return nil
+	}
+	return m.store.Put(Bucket, stateKey, s)
+}
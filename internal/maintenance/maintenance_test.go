@@ -0,0 +1,67 @@
+package maintenance
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"go-discord-bot/internal/storage"
+)
+
+func TestMode(t *testing.T) {
+	st := storage.NewMemory()
+	m, err := New(st)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	first := Held{Bot: "main", GuildID: "guild", ChannelID: "chan", MessageID: "1"}
+	if m.On() || m.Hold(first) {
+		t.Fatal("a new switch is on or holds messages")
+	}
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	second := Held{Bot: "main", GuildID: "guild", ChannelID: "chan", MessageID: "2"}
+	if !m.Hold(first) || !m.Hold(second) {
+		t.Fatal("Hold refused a message during maintenance")
+	}
+
+	// A restart keeps the switch and what it held
+	restarted, err := New(st)
+	if err != nil {
+		t.Fatalf("New after restart: %v", err)
+	}
+	if on, since, held := restarted.Status(); !on || since.IsZero() || held != 2 {
+		t.Errorf("Status after restart = %v, %v, %d; want on with 2 held", on, since, held)
+	}
+
+	held, err := restarted.End()
+	if err != nil {
+		t.Fatalf("End: %v", err)
+	}
+	if expected := []Held{first, second}; !slices.Equal(held, expected) {
+		t.Errorf("End = %+v; want %+v", held, expected)
+	}
+	if again, _ := restarted.End(); restarted.On() || len(again) != 0 {
+		t.Errorf("after End, on = %v and held %+v", restarted.On(), again)
+	}
+
+	var off *Mode
+	if off.On() || off.Hold(first) {
+		t.Error("a nil Mode is on")
+	}
+}
+
+func TestModeMaxHeld(t *testing.T) {
+	m, _ := New(nil)
+	m.Start()
+	for n := range MaxHeld {
+		if !m.Hold(Held{MessageID: strconv.Itoa(n)}) {
+			t.Fatalf("Hold refused message %d", n)
+		}
+	}
+	if m.Hold(Held{MessageID: "one too many"}) {
+		t.Error("Hold took more than MaxHeld messages")
+	}
+}
@@ -0,0 +1,178 @@
+// Package members remembers the server nicknames and avatars of the members
+// the bot has seen, so what it posts about someone shows them the way their
+// server does rather than by their global username.
+package members
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// ttl is how long a member is remembered after they were last seen.
+	ttl = 24 * time.Hour
+	// requestInterval is how long the bot waits before asking the gateway
+	// for the same member again, for members it didn't get back.
+	requestInterval = 10 * time.Minute
+)
+
+// Requester asks the gateway for guild members, which arrive later in
+// GuildMembersChunk events. *discordgo.Session is one.
+type Requester interface {
+	RequestGuildMembersList(guildID string, userIDs []string, limit int, nonce string, presences bool) error
+}
+
+// key identifies a member.
+type key struct {
+	guildID, userID string
+}
+
+// entry is a remembered member.
+type entry struct {
+	member *discordgo.Member
+	seen   time.Time
+}
+
+// Cache remembers members by guild. A nil Cache remembers nothing, so names
+// and avatars fall back to the users' own. It is safe for concurrent use.
+type Cache struct {
+	mu        sync.Mutex
+	members   map[key]entry
+	requested map[key]time.Time
+	now       func() time.Time
+}
+
+// New returns an empty Cache.
+func New() *Cache {
+	return &Cache{members: make(map[key]entry), requested: make(map[key]time.Time), now: time.Now}
+}
+
+// Remember notes a member of guildID. Members sent with messages don't
+// carry their user, so it's passed separately; nil uses m.User.
+func (c *Cache) Remember(guildID string, m *discordgo.Member, user *discordgo.User) {
+	if c == nil || m == nil {
+		return
+	}
+	if user == nil {
+		user = m.User
+	}
+	if user == nil || guildID == "" {
+		return
+	}
+	copied := *m
+	copied.GuildID = guildID
+	copied.User = user
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := key{guildID, user.ID}
+	c.members[k] = entry{member: &copied, seen: c.now()}
+	delete(c.requested, k)
+}
+
+// Chunk remembers the members the gateway sent back for a request.
+func (c *Cache) Chunk(chunk *discordgo.GuildMembersChunk) {
+	for _, m := range chunk.Members {
+		c.Remember(chunk.GuildID, m, nil)
+	}
+}
+
+// Forget drops a member, such as one who left.
+func (c *Cache) Forget(guildID, userID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.members, key{guildID, userID})
+}
+
+// Get returns the member of guildID with userID, if they're remembered.
+func (c *Cache) Get(guildID, userID string) (*discordgo.Member, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := key{guildID, userID}
+	e, ok := c.members[k]
+	if !ok {
+		return nil, false
+	}
+	e.seen = c.now()
+	c.members[k] = e
+	return e.member, true
+}
+
+// Request asks the gateway for the members of guildID with userIDs that
+// aren't remembered or asked for recently. They're remembered once Chunk
+// gets them, so this is for next time.
+func (c *Cache) Request(r Requester, guildID string, userIDs ...string) error {
+	if c == nil || guildID == "" {
+		return nil
+	}
+	now := c.now()
+	var missing []string
+	c.mu.Lock()
+	for _, id := range userIDs {
+		k := key{guildID, id}
+		if _, ok := c.members[k]; ok {
+			continue
+		}
+		if at, ok := c.requested[k]; ok && now.Sub(at) < requestInterval {
+			continue
+		}
+		c.requested[k] = now
+		missing = append(missing, id)
+	}
+	c.mu.Unlock()
+	if len(missing) == 0 {
+		return nil
+	}
+	return r.RequestGuildMembersList(guildID, missing, 0, "", false)
+}
+
+// Member returns the member of guildID that user is, as remembered or else
+// as just user, asking the gateway for them for next time.
+func (c *Cache) Member(r Requester, guildID string, user *discordgo.User) *discordgo.Member {
+	if m, ok := c.Get(guildID, user.ID); ok {
+		return m
+	}
+	// Errors only mean the global name and avatar are used again next time
+	_ = c.Request(r, guildID, user.ID)
+	return &discordgo.Member{GuildID: guildID, User: user}
+}
+
+// Prune forgets members not seen in the last day and requests that were
+// never answered, returning how many members it forgot.
+func (c *Cache) Prune(now time.Time) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pruned := 0
+	for k, e := range c.members {
+		if now.Sub(e.seen) >= ttl {
+			delete(c.members, k)
+			pruned++
+		}
+	}
+	for k, at := range c.requested {
+		if now.Sub(at) >= requestInterval {
+			delete(c.requested, k)
+		}
+	}
+	return pruned
+}
+
+// Len returns how many members the Cache remembers.
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.members)
+}
@@ -0,0 +1,73 @@
+package members
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeRequester records the members asked for.
+type fakeRequester struct {
+	requested [][]string
+}
+
+func (f *fakeRequester) RequestGuildMembersList(guildID string, userIDs []string, limit int, nonce string, presences bool) error {
+	f.requested = append(f.requested, userIDs)
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	c := New()
+	r := &fakeRequester{}
+	alice := &discordgo.User{ID: "alice", Username: "alice", GlobalName: "Alice"}
+	bob := &discordgo.User{ID: "bob", Username: "bob"}
+
+	// Members sent with messages come without their user
+	c.Remember("guild", &discordgo.Member{Nick: "Al", Avatar: "hash"}, alice)
+
+	testCases := []struct {
+		name     string
+		guildID  string
+		user     *discordgo.User
+		expected string
+	}{
+		{name: "Nickname", guildID: "guild", user: alice, expected: "Al"},
+		{name: "Other guild", guildID: "other", user: alice, expected: "Alice"},
+		{name: "Unknown member", guildID: "guild", user: bob, expected: "bob"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if name := c.Member(r, tc.guildID, tc.user).DisplayName(); name != tc.expected {
+				t.Errorf("DisplayName = %q; want %q", name, tc.expected)
+			}
+		})
+	}
+	if expected := [][]string{{"alice"}, {"bob"}}; !slices.EqualFunc(r.requested, expected, slices.Equal) {
+		t.Errorf("requested %q; want %q", r.requested, expected)
+	}
+
+	// Asked for recently, so not again until the answer comes
+	c.Member(r, "guild", bob)
+	if len(r.requested) != 2 {
+		t.Errorf("requested %q; want no more requests", r.requested)
+	}
+	c.Chunk(&discordgo.GuildMembersChunk{GuildID: "guild", Members: []*discordgo.Member{{User: bob, Nick: "Bobby"}}})
+	if name := c.Member(r, "guild", bob).DisplayName(); name != "Bobby" {
+		t.Errorf("DisplayName after the chunk = %q; want Bobby", name)
+	}
+	if m, _ := c.Get("guild", "alice"); m.AvatarURL("") != "https://cdn.discordapp.com/guilds/guild/users/alice/avatars/hash.png" {
+		t.Errorf("AvatarURL = %q; want the guild avatar", m.AvatarURL(""))
+	}
+
+	if pruned := c.Prune(time.Now().Add(ttl)); pruned != 2 || c.Len() != 0 {
+		t.Errorf("Prune = %d leaving %d; want 2 leaving 0", pruned, c.Len())
+	}
+
+	var none *Cache
+	if name := none.Member(r, "guild", alice).DisplayName(); name != "Alice" {
+		t.Errorf("nil DisplayName = %q; want Alice", name)
+	}
+}
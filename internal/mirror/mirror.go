@@ -0,0 +1,130 @@
+// Package mirror downloads the media of fixed tweets so it can be uploaded to
+// Discord alongside the repost, and outlive the tweet it came from.
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/safehttp"
+)
+
+// Limit is how much a bot may upload in one message to any server. Boosted
+// servers allow more, see LimitFor.
+const Limit = 10 << 20
+
+// LimitFor returns how much may be uploaded in one message to a server with
+// the given boost tier.
+func LimitFor(tier discordgo.PremiumTier) int64 {
+	switch tier {
+	case discordgo.PremiumTier2:
+		return 50 << 20
+	case discordgo.PremiumTier3:
+		return 100 << 20
+	}
+	return Limit
+}
+
+// maxFiles is how many attachments one message can have.
+const maxFiles = 10
+
+// fetchTimeout bounds downloading one file.
+const fetchTimeout = 30 * time.Second
+
+// mediaTypes are the content types mirrored; anything else is skipped.
+var mediaTypes = []string{"image/*", "video/*"}
+
+// Downloader fetches media for reuploading. A nil Downloader fetches nothing.
+type Downloader struct {
+	client *http.Client
+}
+
+// New returns a Downloader using client, or one refusing private addresses if nil.
+func New(client *http.Client) *Downloader {
+	if client == nil {
+		client = safehttp.Client(0)
+	}
+	return &Downloader{client: client}
+}
+
+// Source is a media file to mirror, with URLs of smaller versions of it,
+// largest first, to upload instead if it's too big.
+type Source struct {
+	URL     string
+	Smaller []string
+}
+
+// Fetch downloads the files of sources, in order, as attachments adding up to
+// at most limit bytes, each in the largest version that fits. It returns the
+// URLs of those too big to fit in any version, which can be linked instead.
+// Files that fail to download are skipped and logged.
+func (d *Downloader) Fetch(ctx context.Context, sources []Source, limit int64) (files []*discordgo.File, tooBig []string) {
+	if d == nil {
+		return nil, nil
+	}
+	for _, src := range sources {
+		if len(files) == maxFiles {
+			break
+		}
+		var data []byte
+		var contentType, name string
+		var err error
+		for _, u := range append([]string{src.URL}, src.Smaller...) {
+			data, contentType, err = d.fetch(ctx, u, limit)
+			if err != nil || data != nil {
+				name = filename(u)
+				break
+			}
+		}
+		if err != nil {
+			log.Println("Error mirroring media:", err)
+			continue
+		}
+		if data == nil {
+			tooBig = append(tooBig, src.URL)
+			continue
+		}
+		limit -= int64(len(data))
+		files = append(files, &discordgo.File{Name: name, ContentType: contentType, Reader: bytes.NewReader(data)})
+	}
+	return files, tooBig
+}
+
+// fetch downloads one file and returns it with its content type, or nil if
+// it's bigger than limit.
+func (d *Downloader) fetch(ctx context.Context, u string, limit int64) ([]byte, string, error) {
+	resp, err := safehttp.Fetcher{
+		Client:  d.client,
+		Timeout: fetchTimeout,
+		MaxSize: limit,
+		Types:   mediaTypes,
+	}.Get(ctx, u)
+	if errors.Is(err, safehttp.ErrTooLarge) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Body, resp.ContentType, nil
+}
+
+// filename returns the name a media URL's file is uploaded under.
+func filename(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "media"
+	}
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" {
+		return "media"
+	}
+	return name
+}
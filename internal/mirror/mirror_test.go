@@ -0,0 +1,93 @@
+package mirror
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page.jpg":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>"))
+		case "/small.jpg", "/other.png":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("12345"))
+		case "/big.mp4", "/huge.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte("1234567890"))
+		case "/small.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte("12345"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	d := New(server.Client())
+	sources := []Source{
+		{URL: server.URL + "/small.jpg"},
+		{URL: server.URL + "/big.mp4"},
+		{URL: server.URL + "/gone.jpg"},
+		{URL: server.URL + "/page.jpg"},
+		{URL: server.URL + "/huge.mp4", Smaller: []string{server.URL + "/big.mp4", server.URL + "/small.mp4"}},
+		{URL: server.URL + "/other.png?name=orig"},
+	}
+	files, tooBig := d.Fetch(context.Background(), sources, 12)
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+		if data, _ := io.ReadAll(f.Reader); string(data) != "12345" || !strings.Contains(f.ContentType, "/") {
+			t.Errorf("%s holds %q as %q", f.Name, data, f.ContentType)
+		}
+	}
+	// big.mp4 would go over what's left of the limit, gone.jpg doesn't exist,
+	// page.jpg isn't media, huge.mp4 only fits scaled down and other.png
+	// doesn't fit after it
+	if !slices.Equal(names, []string{"small.jpg", "small.mp4"}) {
+		t.Errorf("fetched %q; want small.jpg and small.mp4", names)
+	}
+	if !slices.Equal(tooBig, []string{server.URL + "/big.mp4", server.URL + "/other.png?name=orig"}) {
+		t.Errorf("too big %q; want big.mp4 and other.png", tooBig)
+	}
+}
+
+func TestFetchStopsAtMaxFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("x"))
+	}))
+	defer server.Close()
+
+	sources := slices.Repeat([]Source{{URL: server.URL + "/a.jpg"}}, maxFiles+2)
+	if files, _ := New(server.Client()).Fetch(context.Background(), sources, Limit); len(files) != maxFiles {
+		t.Errorf("fetched %d files; want %d", len(files), maxFiles)
+	}
+	var d *Downloader
+	if files, _ := d.Fetch(context.Background(), sources, Limit); files != nil {
+		t.Errorf("nil Downloader fetched %d files", len(files))
+	}
+}
+
+func TestLimitFor(t *testing.T) {
+	for tier, want := range map[discordgo.PremiumTier]int64{
+		discordgo.PremiumTierNone: Limit,
+		discordgo.PremiumTier1:    Limit,
+		discordgo.PremiumTier2:    50 << 20,
+		discordgo.PremiumTier3:    100 << 20,
+	} {
+		if got := LimitFor(tier); got != want {
+			t.Errorf("LimitFor(%d) = %d; want %d", tier, got, want)
+		}
+	}
+}
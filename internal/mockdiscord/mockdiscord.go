@@ -0,0 +1,457 @@
+// Package mockdiscord is a fake Discord for end-to-end tests. It serves enough
+// of the gateway and REST API for a discordgo session to connect, receive
+// replayed gateway events and send, edit and delete messages, and it records
+// what the bot did so tests can check it.
+package mockdiscord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+)
+
+// heartbeatInterval is the heartbeat interval, in milliseconds, sent to
+// sessions. Tests are over long before it matters.
+const heartbeatInterval = 45000
+
+// BotUser is the user sessions connected to the server log in as.
+var BotUser = &discordgo.User{ID: "100000000000000001", Username: "bot", Bot: true}
+
+// Server is a fake Discord gateway and REST API.
+type Server struct {
+	srv      *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	conns    []*gatewayConn
+	seq      int64
+	nextID   int
+	channels map[string]*discordgo.Channel
+	messages map[string]*discordgo.Message
+	sent     []*discordgo.Message
+	requests []string
+	// changed is closed and replaced whenever the server's state changes,
+	// waking anything in Wait.
+	changed chan struct{}
+}
+
+// gatewayConn is one session's gateway connection.
+type gatewayConn struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+// New starts a Server. Close it when done.
+func New() *Server {
+	s := &Server{
+		channels: make(map[string]*discordgo.Channel),
+		messages: make(map[string]*discordgo.Message),
+		changed:  make(chan struct{}),
+	}
+	api := "/api/v" + discordgo.APIVersion
+	mux := http.NewServeMux()
+	// discordgo adds a trailing slash to the gateway URL
+	mux.HandleFunc("GET /gateway/", s.serveGateway)
+	mux.HandleFunc("GET "+api+"/gateway", s.handleGatewayURL)
+	mux.HandleFunc("GET "+api+"/gateway/bot", s.handleGatewayURL)
+	mux.HandleFunc("GET "+api+"/channels/{channel}", s.handleChannel)
+	mux.HandleFunc("GET "+api+"/channels/{channel}/messages/{message}", s.handleMessage)
+	mux.HandleFunc("POST "+api+"/channels/{channel}/messages", s.handleSend)
+	mux.HandleFunc("PATCH "+api+"/channels/{channel}/messages/{message}", s.handleEdit)
+	mux.HandleFunc("DELETE "+api+"/channels/{channel}/messages/{message}", s.handleDelete)
+	mux.HandleFunc(api+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, 0, "404: Not Found")
+	})
+	s.srv = httptest.NewServer(s.record(mux))
+	return s
+}
+
+// Close disconnects every session and stops the server.
+func (s *Server) Close() {
+	s.mu.Lock()
+	for _, c := range s.conns {
+		c.ws.Close()
+	}
+	s.conns = nil
+	s.mu.Unlock()
+	s.srv.Close()
+}
+
+// Session returns a discordgo session whose REST calls and gateway connection
+// go to the server instead of Discord. It isn't open yet.
+func (s *Server) Session() (*discordgo.Session, error) {
+	sess, err := discordgo.New("Bot mock")
+	if err != nil {
+		return nil, err
+	}
+	target, _ := url.Parse(s.srv.URL)
+	sess.Client = &http.Client{Transport: redirect{target: target}}
+	return sess, nil
+}
+
+// AddChannel makes a channel known to the REST API.
+func (s *Server) AddChannel(c *discordgo.Channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels[c.ID] = c
+	s.notify()
+}
+
+// Message returns a message as the REST API would serve it, or nil.
+func (s *Server) Message(id string) *discordgo.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages[id]
+}
+
+// Sent returns the messages sessions sent, in order, as they are now.
+func (s *Server) Sent() []*discordgo.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sent := make([]*discordgo.Message, 0, len(s.sent))
+	for _, m := range s.sent {
+		if current := s.messages[m.ID]; current != nil {
+			m = current
+		}
+		sent = append(sent, m)
+	}
+	return sent
+}
+
+// Requests returns the REST requests sessions made, like
+// "GET /channels/1/messages/2", in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Wait blocks until done reports true, checking it whenever the server's
+// state changes, or until ctx is done.
+func (s *Server) Wait(ctx context.Context, done func() bool) error {
+	for {
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+		if done() {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Dispatch sends a gateway event to every connected session. Message events
+// also update the messages served by the REST API, the way Discord's would.
+func (s *Server) Dispatch(eventType string, data json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.apply(eventType, data); err != nil {
+		return fmt.Errorf("applying %s: %w", eventType, err)
+	}
+	s.seq++
+	payload, err := json.Marshal(map[string]any{"op": 0, "s": s.seq, "t": eventType, "d": data})
+	if err != nil {
+		return err
+	}
+	for _, c := range s.conns {
+		if err := c.write(payload); err != nil {
+			return fmt.Errorf("dispatching %s: %w", eventType, err)
+		}
+	}
+	s.notify()
+	return nil
+}
+
+// apply updates the stored messages for a gateway event. Callers must hold s.mu.
+func (s *Server) apply(eventType string, data json.RawMessage) error {
+	switch eventType {
+	case "MESSAGE_CREATE":
+		var m discordgo.Message
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		s.messages[m.ID] = &m
+	case "MESSAGE_UPDATE":
+		// Updates, like Discord adding embeds, may carry only the changed
+		// fields, so they're merged into the stored message field by field
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		var id string
+		json.Unmarshal(fields["id"], &id)
+		merged := make(map[string]json.RawMessage)
+		if current := s.messages[id]; current != nil {
+			stored, err := json.Marshal(current)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(stored, &merged); err != nil {
+				return err
+			}
+		}
+		for name, value := range fields {
+			merged[name] = value
+		}
+		encoded, err := json.Marshal(merged)
+		if err != nil {
+			return err
+		}
+		var m discordgo.Message
+		if err := json.Unmarshal(encoded, &m); err != nil {
+			return err
+		}
+		s.messages[m.ID] = &m
+	case "MESSAGE_DELETE":
+		var m discordgo.Message
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		delete(s.messages, m.ID)
+	}
+	return nil
+}
+
+// notify wakes everything waiting on a state change. Callers must hold s.mu.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// id returns a new snowflake-like ID. Callers must hold s.mu.
+func (s *Server) id() string {
+	s.nextID++
+	return strconv.Itoa(900000000000000000 + s.nextID)
+}
+
+// record notes every REST request before handling it.
+func (s *Server) record(next http.Handler) http.Handler {
+	prefix := "/api/v" + discordgo.APIVersion
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path, ok := strings.CutPrefix(r.URL.Path, prefix); ok && !strings.HasPrefix(path, "/gateway") {
+			s.mu.Lock()
+			s.requests = append(s.requests, r.Method+" "+path)
+			s.notify()
+			s.mu.Unlock()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleGatewayURL points sessions at the server's gateway.
+func (s *Server) handleGatewayURL(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{
+		"url":                 "ws" + strings.TrimPrefix(s.srv.URL, "http") + "/gateway",
+		"shards":              1,
+		"session_start_limit": map[string]int{"total": 1000, "remaining": 1000, "max_concurrency": 1},
+	})
+}
+
+// serveGateway says hello, waits for the session to identify, sends READY and
+// then acknowledges heartbeats until the connection closes.
+func (s *Server) serveGateway(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &gatewayConn{ws: ws}
+	if err := c.writeJSON(map[string]any{"op": 10, "d": map[string]int{"heartbeat_interval": heartbeatInterval}}); err != nil {
+		ws.Close()
+		return
+	}
+	var identify struct {
+		Op int `json:"op"`
+	}
+	if err := ws.ReadJSON(&identify); err != nil || identify.Op != 2 {
+		log.Println("Error reading identify from mock gateway session:", err)
+		ws.Close()
+		return
+	}
+
+	s.mu.Lock()
+	s.seq++
+	err = c.writeJSON(map[string]any{"op": 0, "s": s.seq, "t": "READY", "d": map[string]any{
+		"v":          10,
+		"user":       BotUser,
+		"session_id": "mock",
+		"guilds":     []any{},
+	}})
+	if err == nil {
+		s.conns = append(s.conns, c)
+		s.notify()
+	}
+	s.mu.Unlock()
+	if err != nil {
+		ws.Close()
+		return
+	}
+
+	for {
+		var packet struct {
+			Op int `json:"op"`
+		}
+		if err := ws.ReadJSON(&packet); err != nil {
+			break
+		}
+		if packet.Op == 1 {
+			c.writeJSON(map[string]int{"op": 11})
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for n, conn := range s.conns {
+		if conn == c {
+			s.conns = append(s.conns[:n], s.conns[n+1:]...)
+			break
+		}
+	}
+}
+
+func (s *Server) handleChannel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	c := s.channels[r.PathValue("channel")]
+	s.mu.Unlock()
+	if c == nil {
+		writeError(w, http.StatusNotFound, discordgo.ErrCodeUnknownChannel, "Unknown Channel")
+		return
+	}
+	writeJSON(w, c)
+}
+
+func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	m := s.messages[r.PathValue("message")]
+	s.mu.Unlock()
+	if m == nil || m.ChannelID != r.PathValue("channel") {
+		writeError(w, http.StatusNotFound, discordgo.ErrCodeUnknownMessage, "Unknown Message")
+		return
+	}
+	writeJSON(w, m)
+}
+
+// messageBody is the part of a message send or edit the server keeps.
+// Components aren't kept, since discordgo can't decode them generically.
+type messageBody struct {
+	Content   *string                     `json:"content"`
+	Embeds    *[]*discordgo.MessageEmbed  `json:"embeds"`
+	Reference *discordgo.MessageReference `json:"message_reference"`
+	Flags     *discordgo.MessageFlags     `json:"flags"`
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	var body messageBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := &discordgo.Message{ID: s.id(), ChannelID: r.PathValue("channel"), Author: BotUser, MessageReference: body.Reference}
+	if c := s.channels[m.ChannelID]; c != nil {
+		m.GuildID = c.GuildID
+	}
+	body.applyTo(m)
+	s.messages[m.ID] = m
+	s.sent = append(s.sent, m)
+	s.notify()
+	writeJSON(w, m)
+}
+
+func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
+	var body messageBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.messages[r.PathValue("message")]
+	if current == nil || current.ChannelID != r.PathValue("channel") {
+		writeError(w, http.StatusNotFound, discordgo.ErrCodeUnknownMessage, "Unknown Message")
+		return
+	}
+	m := *current
+	body.applyTo(&m)
+	s.messages[m.ID] = &m
+	s.notify()
+	writeJSON(w, &m)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.messages[r.PathValue("message")]
+	if m == nil || m.ChannelID != r.PathValue("channel") {
+		writeError(w, http.StatusNotFound, discordgo.ErrCodeUnknownMessage, "Unknown Message")
+		return
+	}
+	delete(s.messages, m.ID)
+	s.notify()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyTo sets the fields present in the body on m.
+func (b messageBody) applyTo(m *discordgo.Message) {
+	if b.Content != nil {
+		m.Content = *b.Content
+	}
+	if b.Embeds != nil {
+		m.Embeds = *b.Embeds
+	}
+	if b.Flags != nil {
+		m.Flags = *b.Flags
+	}
+}
+
+func (c *gatewayConn) write(payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteMessage(websocket.TextMessage, payload)
+}
+
+func (c *gatewayConn) writeJSON(v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.write(payload)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with a Discord API error.
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"code": code, "message": message})
+}
+
+// redirect sends every request to target instead of the host it names.
+type redirect struct {
+	target *url.URL
+}
+
+func (t redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	req.Host = ""
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,71 @@
+package mockdiscord
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv := New()
+	defer srv.Close()
+	srv.AddChannel(&discordgo.Channel{ID: "10", GuildID: "20", Type: discordgo.ChannelTypeGuildText})
+
+	s, err := srv.Session()
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := make(chan *discordgo.MessageCreate, 1)
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) { created <- m })
+	if err := s.Open(); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	if s.State.User.ID != BotUser.ID {
+		t.Errorf("logged in as %q; want %q", s.State.User.ID, BotUser.ID)
+	}
+
+	err = srv.Replay(ctx, []Event{
+		{Type: "MESSAGE_CREATE", Data: json.RawMessage(`{"id":"30","channel_id":"10","guild_id":"20","content":"hi","author":{"id":"40"}}`)},
+		{AfterMS: 1, Type: "MESSAGE_UPDATE", Data: json.RawMessage(`{"id":"30","channel_id":"10","embeds":[{"title":"Embedded"}]}`)},
+	})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	select {
+	case m := <-created:
+		if m.Content != "hi" {
+			t.Errorf("received %q; want %q", m.Content, "hi")
+		}
+	case <-ctx.Done():
+		t.Fatal("MESSAGE_CREATE never arrived")
+	}
+
+	// The REST API serves the message with the update applied
+	m, err := s.ChannelMessage("10", "30")
+	if err != nil {
+		t.Fatalf("ChannelMessage: %v", err)
+	}
+	if m.Content != "hi" || len(m.Embeds) != 1 {
+		t.Errorf("ChannelMessage = %q with %d embeds; want %q with 1", m.Content, len(m.Embeds), "hi")
+	}
+
+	sent, err := s.ChannelMessageSend("10", "hello")
+	if err != nil {
+		t.Fatalf("ChannelMessageSend: %v", err)
+	}
+	if _, err := s.ChannelMessageEdit("10", sent.ID, "edited"); err != nil {
+		t.Fatalf("ChannelMessageEdit: %v", err)
+	}
+	if got := srv.Sent(); len(got) != 1 || got[0].Content != "edited" || got[0].GuildID != "20" {
+		t.Errorf("Sent() = %+v; want the edited message", got)
+	}
+	if _, err := s.ChannelMessage("10", "missing"); err == nil {
+		t.Error("ChannelMessage of a missing message succeeded")
+	}
+}
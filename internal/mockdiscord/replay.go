@@ -0,0 +1,51 @@
+package mockdiscord
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// Event is a gateway event recorded from Discord, to replay.
+type Event struct {
+	// AfterMS is how long, in milliseconds, to wait after the previous event
+	// before sending this one, such as the delay before Discord embeds a link.
+	AfterMS int `json:"after_ms,omitempty"`
+	// Type is the dispatch event name, like MESSAGE_CREATE.
+	Type string `json:"t"`
+	// Data is the event payload as Discord sent it.
+	Data json.RawMessage `json:"d"`
+}
+
+// LoadRecording reads events from a JSON file holding an array of them.
+func LoadRecording(path string) ([]Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Replay dispatches events in order, waiting between them as recorded.
+func (s *Server) Replay(ctx context.Context, events []Event) error {
+	for _, e := range events {
+		if e.AfterMS > 0 {
+			timer := time.NewTimer(time.Duration(e.AfterMS) * time.Millisecond)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		if err := s.Dispatch(e.Type, e.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Package nitter tracks which of the configured Nitter instances are up, so
+// Twitter links can be rewritten to one that works.
+package nitter
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkTimeout bounds a single health check.
+const checkTimeout = 5 * time.Second
+
+// Instances is a rotating list of Nitter instances. Links go to the current
+// instance until a health check finds it down, then to the next healthy one.
+// A nil Instances has no instance available.
+type Instances struct {
+	client *http.Client
+	urls   []string
+
+	mu      sync.RWMutex
+	healthy []bool
+	current int
+}
+
+// New returns the given instances, all assumed healthy until checked.
+// Each is a base URL such as https://nitter.net; a bare host gets https://.
+func New(urls []string, client *http.Client) *Instances {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	in := &Instances{client: client}
+	for _, u := range urls {
+		u = strings.TrimSuffix(strings.TrimSpace(u), "/")
+		if u == "" {
+			continue
+		}
+		if !strings.Contains(u, "://") {
+			u = "https://" + u
+		}
+		in.urls = append(in.urls, u)
+		in.healthy = append(in.healthy, true)
+	}
+	return in
+}
+
+// Current returns the base URL of the instance to use, or false if none is up.
+func (in *Instances) Current() (string, bool) {
+	if in == nil {
+		return "", false
+	}
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	for n := range in.urls {
+		i := (in.current + n) % len(in.urls)
+		if in.healthy[i] {
+			return in.urls[i], true
+		}
+	}
+	return "", false
+}
+
+// Check probes every instance once and moves off the current one if it's down.
+func (in *Instances) Check(ctx context.Context) {
+	if in == nil {
+		return
+	}
+	healthy := make([]bool, len(in.urls))
+	for i, u := range in.urls {
+		healthy[i] = in.probe(ctx, u)
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for i, up := range healthy {
+		if in.healthy[i] && !up {
+			log.Println("Nitter instance is down:", in.urls[i])
+		} else if !in.healthy[i] && up {
+			log.Println("Nitter instance is back up:", in.urls[i])
+		}
+	}
+	in.healthy = healthy
+	for n := range in.urls {
+		if i := (in.current + n) % len(in.urls); healthy[i] {
+			in.current = i
+			break
+		}
+	}
+}
+
+// Run checks the instances every interval until ctx is done.
+func (in *Instances) Run(ctx context.Context, interval time.Duration) {
+	if in == nil || len(in.urls) == 0 || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		in.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe reports whether the instance at base answers its front page.
+func (in *Instances) probe(ctx context.Context, base string) bool {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := in.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusBadRequest
+}
@@ -0,0 +1,62 @@
+package nitter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInstancesRotate(t *testing.T) {
+	var firstDown atomic.Bool
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if firstDown.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer first.Close()
+	second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer second.Close()
+
+	in := New([]string{first.URL + "/", "", second.URL}, first.Client())
+
+	testCases := []struct {
+		name      string
+		firstDown bool
+		expected  string
+	}{
+		{name: "First instance up", firstDown: false, expected: first.URL},
+		{name: "First instance down", firstDown: true, expected: second.URL},
+		{name: "Stays on second after recovery", firstDown: false, expected: second.URL},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			firstDown.Store(tc.firstDown)
+			in.Check(context.Background())
+			if got, ok := in.Current(); !ok || got != tc.expected {
+				t.Errorf("Current = %q, %v; want %q", got, ok, tc.expected)
+			}
+		})
+	}
+
+	second.Close()
+	firstDown.Store(true)
+	in.Check(context.Background())
+	if got, ok := in.Current(); ok {
+		t.Errorf("Current = %q with every instance down; want none", got)
+	}
+}
+
+func TestNewAddsScheme(t *testing.T) {
+	in := New([]string{"nitter.example"}, nil)
+	if got, _ := in.Current(); got != "https://nitter.example" {
+		t.Errorf("Current = %q; want https://nitter.example", got)
+	}
+
+	var none *Instances
+	if _, ok := none.Current(); ok {
+		t.Errorf("nil Instances returned an instance")
+	}
+}
@@ -0,0 +1,116 @@
+// Package notify tells whoever runs the bot about things needing their
+// attention, such as watchdog warnings, errors and reconnects, through the
+// sinks they set up for each severity: a Discord channel, a webhook or email.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sendTimeout bounds sending one alert to one sink.
+const sendTimeout = 10 * time.Second
+
+// Severity is how urgent an alert is.
+type Severity int
+
+const (
+	// Info is worth knowing, such as a shard reconnecting.
+	Info Severity = iota
+	// Warning may need looking into, such as the watchdog seeing a leak.
+	Warning
+	// Error is a failure the bot couldn't handle by itself.
+	Error
+)
+
+// severityNames are the names severities are configured with.
+var severityNames = []string{"info", "warning", "error"}
+
+// String returns the severity's name, such as "warning".
+func (s Severity) String() string {
+	if s < Info || s > Error {
+		return fmt.Sprintf("severity %d", int(s))
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity reads a severity's name, such as "warning".
+func ParseSeverity(name string) (Severity, error) {
+	for n, s := range severityNames {
+		if strings.EqualFold(name, s) {
+			return Severity(n), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q, want info, warning or error", name)
+}
+
+// Alert is one thing to tell whoever runs the bot.
+type Alert struct {
+	Severity Severity
+	// Source is what raised the alert, such as "Watchdog".
+	Source string
+	Text   string
+}
+
+// String formats the alert as a line of text, such as "⚠️ Watchdog: ...".
+func (a Alert) String() string {
+	icon := map[Severity]string{Info: "ℹ️", Warning: "⚠️", Error: "🚨"}[a.Severity]
+	return fmt.Sprintf("%s %s: %s", icon, a.Source, a.Text)
+}
+
+// Sink is somewhere alerts are sent.
+type Sink interface {
+	Send(ctx context.Context, a Alert) error
+}
+
+// Notifier sends each alert to the sinks whose threshold it reaches. A nil
+// *Notifier drops alerts.
+type Notifier struct {
+	mu    sync.Mutex
+	sinks []route
+}
+
+// route is a sink and the least severe alert it gets.
+type route struct {
+	name string
+	min  Severity
+	sink Sink
+}
+
+// New returns a Notifier with no sinks.
+func New() *Notifier {
+	return &Notifier{}
+}
+
+// Add has alerts of severity min or above sent to sink. name labels its
+// errors in the log.
+func (n *Notifier) Add(name string, min Severity, sink Sink) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sinks = append(n.sinks, route{name: name, min: min, sink: sink})
+}
+
+// Notify sends a to every sink it's severe enough for. A sink failing is
+// logged and doesn't stop the others.
+func (n *Notifier) Notify(ctx context.Context, a Alert) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	sinks := n.sinks
+	n.mu.Unlock()
+	for _, r := range sinks {
+		if a.Severity < r.min {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+		if err := r.sink.Send(ctx, a); err != nil {
+			log.Printf("Error sending alert to %s: %v\n", r.name, err)
+		}
+		cancel()
+	}
+}
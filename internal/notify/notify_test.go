@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeSink records the alerts sent to it.
+type fakeSink struct {
+	sent []Alert
+	err  error
+}
+
+func (f *fakeSink) Send(_ context.Context, a Alert) error {
+	f.sent = append(f.sent, a)
+	return f.err
+}
+
+func TestNotify(t *testing.T) {
+	testCases := []struct {
+		severity Severity
+		expected []int
+	}{
+		{severity: Info, expected: []int{1, 0, 0}},
+		{severity: Warning, expected: []int{1, 1, 0}},
+		{severity: Error, expected: []int{1, 1, 1}},
+	}
+
+	for _, tc := range testCases {
+		sinks := []*fakeSink{{}, {err: errors.New("down")}, {}}
+		n := New()
+		n.Add("info", Info, sinks[0])
+		n.Add("warning", Warning, sinks[1])
+		n.Add("error", Error, sinks[2])
+		n.Notify(context.Background(), Alert{Severity: tc.severity, Source: "Test", Text: "text"})
+		for i, sink := range sinks {
+			if len(sink.sent) != tc.expected[i] {
+				t.Errorf("%v alert reached sink %d %d times; want %d", tc.severity, i, len(sink.sent), tc.expected[i])
+			}
+		}
+	}
+
+	var nilNotifier *Notifier
+	nilNotifier.Notify(context.Background(), Alert{Severity: Error})
+}
+
+func TestParseSeverity(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected Severity
+		err      bool
+	}{
+		{name: "info", expected: Info},
+		{name: "Warning", expected: Warning},
+		{name: "error", expected: Error},
+		{name: "critical", err: true},
+		{name: "", err: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseSeverity(tc.name)
+		if (err != nil) != tc.err {
+			t.Errorf("ParseSeverity(%q) error = %v; want error %v", tc.name, err, tc.err)
+			continue
+		}
+		if err == nil && got != tc.expected {
+			t.Errorf("ParseSeverity(%q) = %v; want %v", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding webhook body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	alert := Alert{Severity: Warning, Source: "Watchdog", Text: "goroutines at 5000"}
+	if err := (Webhook{URL: srv.URL}).Send(context.Background(), alert); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got["severity"] != "warning" || got["source"] != "Watchdog" || got["text"] != "goroutines at 5000" {
+		t.Errorf("webhook got %v", got)
+	}
+	if content, _ := got["content"].(string); !strings.HasSuffix(content, "Watchdog: goroutines at 5000") {
+		t.Errorf("webhook content = %q", content)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := (Webhook{URL: failing.URL}).Send(context.Background(), alert); err == nil {
+		t.Error("Send to a failing webhook succeeded")
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	e := Email{From: "bot@example.com", To: []string{"a@example.com", "b@example.com"}}
+	msg := string(e.message(Alert{Severity: Error, Source: "Error", Text: "one\ntwo"}))
+	for _, want := range []string{
+		"From: bot@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: [bot error] Error\r\n",
+		"\r\n\r\none\r\ntwo\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message is missing %q:\n%s", want, msg)
+		}
+	}
+}
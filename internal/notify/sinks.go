@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Sender is the part of *discordgo.Session the Discord sink uses.
+type Sender interface {
+	ChannelMessageSend(channelID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// Discord posts alerts in a channel.
+type Discord struct {
+	Session   Sender
+	ChannelID string
+}
+
+// Send posts a in the channel.
+func (d Discord) Send(ctx context.Context, a Alert) error {
+	_, err := d.Session.ChannelMessageSend(d.ChannelID, a.String(), discordgo.WithContext(ctx))
+	return err
+}
+
+// Webhook posts alerts as JSON to a URL, such as a chat or paging service's
+// incoming webhook: {"severity": "warning", "source": ..., "text": ...,
+// "content": ..., "at": ...}, where content is the alert as one line.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// Send posts a to the URL.
+func (w Webhook) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(map[string]any{
+		"severity": a.Severity.String(),
+		"source":   a.Source,
+		"text":     a.Text,
+		"content":  a.String(),
+		"at":       time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Email sends alerts by SMTP.
+type Email struct {
+	// Addr is the SMTP server, such as "smtp.example.com:587". Username and
+	// Password log in to it, empty to send without logging in.
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Send emails a to every recipient, over TLS if the server offers it.
+func (e Email) Send(ctx context.Context, a Alert) error {
+	host, _, err := net.SplitHostPort(e.Addr)
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", e.Addr)
+	if err != nil {
+		return err
+	}
+	// smtp has no contexts, so the deadline bounds the whole exchange
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.Username, e.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.message(a)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message lays a out as an email.
+func (e Email) message(a Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: [bot %s] %s\r\n", a.Severity, a.Source)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(a.Text, "\n", "\r\n") + "\r\n")
+	return []byte(b.String())
+}
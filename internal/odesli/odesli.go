@@ -0,0 +1,104 @@
+// Package odesli looks up songs and albums linked from music streaming
+// services through the song.link (Odesli) API, which finds the same release on
+// the other services.
+package odesli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL is the public Odesli API, which allows 10 lookups a minute
+// without a key.
+const DefaultBaseURL = "https://api.song.link/v1-alpha.1"
+
+// Platforms, as the API names them.
+const (
+	Spotify      = "spotify"
+	AppleMusic   = "appleMusic"
+	YouTubeMusic = "youtubeMusic"
+	Tidal        = "tidal"
+	Deezer       = "deezer"
+	AmazonMusic  = "amazonMusic"
+)
+
+// ErrNotFound is returned for links the API can't match to a song or album.
+var ErrNotFound = errors.New("song not found")
+
+// Song is a song or album as found on every service that has it.
+type Song struct {
+	// PageURL is the song.link page listing every service.
+	PageURL string
+	// Links maps platforms, such as Spotify, to the song's link there.
+	Links map[string]string
+}
+
+// Client looks songs up on an Odesli API instance.
+type Client struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// New returns a client for the API at baseURL, or DefaultBaseURL if it's
+// empty. apiKey lifts the rate limit and may be empty. A nil client uses
+// http.DefaultClient.
+func New(baseURL, apiKey string, client *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, client: client}
+}
+
+// Lookup finds the song or album at link, a link to it on any service.
+func (c *Client) Lookup(ctx context.Context, link string) (*Song, error) {
+	query := url.Values{"url": {link}}
+	if c.apiKey != "" {
+		query.Set("key", c.apiKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/links?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	// The API answers links it can't resolve with 400
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("odesli returned %s", resp.Status)
+	}
+	var body struct {
+		PageURL         string `json:"pageUrl"`
+		LinksByPlatform map[string]struct {
+			URL string `json:"url"`
+		} `json:"linksByPlatform"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.PageURL == "" {
+		return nil, ErrNotFound
+	}
+
+	song := &Song{PageURL: body.PageURL, Links: make(map[string]string, len(body.LinksByPlatform))}
+	for platform, link := range body.LinksByPlatform {
+		if link.URL != "" {
+			song.Links[platform] = link.URL
+		}
+	}
+	return song, nil
+}
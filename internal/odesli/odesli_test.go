@@ -0,0 +1,60 @@
+package odesli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/links" || r.URL.Query().Get("key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Query().Get("url") {
+		case "https://open.spotify.com/track/abc":
+			w.Write([]byte(`{"pageUrl":"https://song.link/s/abc","linksByPlatform":{"spotify":{"url":"https://open.spotify.com/track/abc"},"appleMusic":{"url":"https://music.apple.com/us/album/x/1?i=2"},"deezer":{"url":""}}}`))
+		case "https://open.spotify.com/track/gone":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, "secret", server.Client())
+
+	testCases := []struct {
+		name     string
+		link     string
+		expected *Song
+		notFound bool
+		fails    bool
+	}{
+		{
+			name: "Found",
+			link: "https://open.spotify.com/track/abc",
+			expected: &Song{PageURL: "https://song.link/s/abc", Links: map[string]string{
+				Spotify:    "https://open.spotify.com/track/abc",
+				AppleMusic: "https://music.apple.com/us/album/x/1?i=2",
+			}},
+		},
+		{name: "Unresolved", link: "https://open.spotify.com/track/gone", notFound: true, fails: true},
+		{name: "Server error", link: "https://open.spotify.com/track/500", fails: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			song, err := c.Lookup(context.Background(), tc.link)
+			if tc.fails != (err != nil) || tc.notFound != errors.Is(err, ErrNotFound) {
+				t.Errorf("Lookup(%s) error = %v; want failure %v, ErrNotFound %v", tc.link, err, tc.fails, tc.notFound)
+			}
+			if !reflect.DeepEqual(song, tc.expected) {
+				t.Errorf("Lookup(%s) = %+v; want %+v", tc.link, song, tc.expected)
+			}
+		})
+	}
+}
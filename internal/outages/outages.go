@@ -0,0 +1,125 @@
+// Package outages keeps track of the guilds Discord can't reach right now,
+// either because the guild is unavailable or because the shard it's on lost
+// its connection, and holds the work due in them until they're back.
+package outages
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxHeld is how many jobs one guild's outage holds. Later ones are dropped.
+	maxHeld = 100
+	// maxOutage is how long an outage is waited out. Guilds gone for longer
+	// than that may never come back, and their held work would only be noise.
+	maxOutage = 24 * time.Hour
+)
+
+// outage is one guild being unreachable.
+type outage struct {
+	since time.Time
+	held  []func()
+}
+
+// Tracker remembers the guilds that are down. A nil Tracker considers every
+// guild up. It is safe for concurrent use.
+type Tracker struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	guilds map[string]*outage
+}
+
+// New returns a Tracker with every guild up.
+func New() *Tracker {
+	return &Tracker{now: time.Now, guilds: make(map[string]*outage)}
+}
+
+// Start notes that guildID went down, and reports whether it was up before.
+func (t *Tracker) Start(guildID string) bool {
+	if t == nil || guildID == "" {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, down := t.guilds[guildID]; down {
+		return false
+	}
+	t.guilds[guildID] = &outage{since: t.now()}
+	return true
+}
+
+// End notes that guildID is back, returning the jobs held while it was down,
+// oldest first, and how long it was gone. It reports false if it wasn't down.
+func (t *Tracker) End(guildID string) ([]func(), time.Duration, bool) {
+	if t == nil {
+		return nil, 0, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	o, down := t.guilds[guildID]
+	if !down {
+		return nil, 0, false
+	}
+	delete(t.guilds, guildID)
+	return o.held, t.now().Sub(o.since), true
+}
+
+// Down reports whether guildID is down.
+func (t *Tracker) Down(guildID string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, down := t.guilds[guildID]
+	return down
+}
+
+// Hold keeps job to run once guildID is back, and reports whether it did:
+// false if the guild is up, so job should run now. Jobs past maxHeld are
+// dropped, but still reported as held.
+func (t *Tracker) Hold(guildID string, job func()) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	o, down := t.guilds[guildID]
+	if !down {
+		return false
+	}
+	if len(o.held) < maxHeld {
+		o.held = append(o.held, job)
+	}
+	return true
+}
+
+// Prune forgets outages that lasted longer than maxOutage at now, along with
+// the jobs they held, and returns how many it forgot.
+func (t *Tracker) Prune(now time.Time) int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pruned := 0
+	for id, o := range t.guilds {
+		if now.Sub(o.since) >= maxOutage {
+			delete(t.guilds, id)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many guilds are down.
+func (t *Tracker) Len() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.guilds)
+}
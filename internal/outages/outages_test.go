@@ -0,0 +1,54 @@
+package outages
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	clock := time.Date(2024, 8, 19, 12, 0, 0, 0, time.UTC)
+	tr := New()
+	tr.now = func() time.Time { return clock }
+
+	ran := 0
+	job := func() { ran++ }
+	if tr.Hold("guild", job) {
+		t.Error("Hold held a job for a guild that's up")
+	}
+	if !tr.Start("guild") || tr.Start("guild") {
+		t.Error("Start should report only the first outage")
+	}
+	if !tr.Down("guild") || tr.Down("other") {
+		t.Error("Down disagrees with Start")
+	}
+	for range maxHeld + 1 {
+		if !tr.Hold("guild", job) {
+			t.Fatal("Hold didn't hold a job for a guild that's down")
+		}
+	}
+
+	clock = clock.Add(time.Minute)
+	held, lasted, ok := tr.End("guild")
+	if !ok || lasted != time.Minute || len(held) != maxHeld {
+		t.Errorf("End = %d jobs, %s, %v; want %d jobs, 1m0s, true", len(held), lasted, ok, maxHeld)
+	}
+	for _, job := range held {
+		job()
+	}
+	if ran != maxHeld {
+		t.Errorf("ran %d held jobs; want %d", ran, maxHeld)
+	}
+	if _, _, ok := tr.End("guild"); ok {
+		t.Error("End reported an outage twice")
+	}
+
+	tr.Start("gone")
+	if n := tr.Prune(clock.Add(maxOutage)); n != 1 || tr.Len() != 0 {
+		t.Errorf("Prune forgot %d outages, %d left; want 1, 0", n, tr.Len())
+	}
+
+	var off *Tracker
+	if off.Start("guild") || off.Hold("guild", job) || off.Down("guild") {
+		t.Error("nil Tracker considered a guild down")
+	}
+}
@@ -0,0 +1,281 @@
+// Package outbound paces the bot's requests to Discord, so bursts such as a
+// backfill or a digest are smoothed out under Discord's rate limits rather
+// than running into them, and urgent requests go first.
+package outbound
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority orders requests waiting to be sent.
+type Priority int
+
+const (
+	// Low is for bulk work nobody is waiting on, such as backfills and digests.
+	Low Priority = iota - 1
+	// Normal is for everything else, such as reposts.
+	Normal
+	// High is for replies someone is waiting on, such as command responses,
+	// which Discord only accepts for a few seconds.
+	High
+)
+
+// priorityKey is the context key of a request's priority.
+type priorityKey struct{}
+
+// WithPriority returns ctx carrying p, which requests made with it, as with
+// discordgo.WithContext, are sent at.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// Discord's limits: 50 requests a second for the whole bot, and 5 messages
+// every 5 seconds in a channel.
+const (
+	DefaultInterval        = 20 * time.Millisecond
+	DefaultBurst           = 10
+	DefaultChannelInterval = time.Second
+	DefaultChannelBurst    = 5
+)
+
+// Queue holds the requests of one bot, shared by all of its shards, until
+// they can be sent. Every request counts against a global limit, and
+// messages also against a limit for their channel; each limit lets a burst
+// through at once, then one request per interval. A nil *Queue sends
+// requests right away.
+type Queue struct {
+	// channel is the limit every channel starts with.
+	channel limit
+	now     func() time.Time
+
+	mu       sync.Mutex
+	global   limit
+	channels map[string]*limit
+	waiting  []*ticket
+	// changed is closed when a request leaves the queue.
+	changed chan struct{}
+}
+
+// New returns a Queue pacing requests to one per interval with bursts of
+// burst, and messages in a channel to one per channelInterval with bursts of
+// channelBurst.
+func New(interval time.Duration, burst int, channelInterval time.Duration, channelBurst int) *Queue {
+	return &Queue{
+		global:   limit{interval: interval, burst: burst},
+		channel:  limit{interval: channelInterval, burst: channelBurst},
+		channels: map[string]*limit{},
+		changed:  make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// Transport returns a RoundTripper sending requests through base, default
+// http.DefaultTransport, once the queue lets them.
+func (q *Queue) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return transport{queue: q, base: base}
+}
+
+// transport is a RoundTripper waiting its turn in a queue.
+type transport struct {
+	queue *Queue
+	base  http.RoundTripper
+}
+
+// RoundTrip waits for the request's turn, then sends it.
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.queue.Wait(req.Context(), priority(req), messageChannel(req)); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// priority returns the priority of req: its context's, or High for
+// interaction responses and Normal for the rest.
+func priority(req *http.Request) Priority {
+	if p, ok := req.Context().Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	if strings.Contains(req.URL.Path, "/interactions/") || strings.Contains(req.URL.Path, "/webhooks/") {
+		return High
+	}
+	return Normal
+}
+
+// messageChannel returns the channel req posts a message in, empty if it
+// doesn't post one.
+func messageChannel(req *http.Request) string {
+	if req.Method != http.MethodPost {
+		return ""
+	}
+	// Paths look like /api/v9/channels/<id>/messages
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for n := 0; n+2 < len(parts); n++ {
+		if parts[n] == "channels" && parts[n+2] == "messages" && n+3 == len(parts) {
+			return parts[n+1]
+		}
+	}
+	return ""
+}
+
+// ticket is a request waiting in the queue.
+type ticket struct {
+	priority Priority
+	channel  string
+}
+
+// Wait blocks until a request at priority p, posting a message in channelID
+// or nothing if it's empty, may be sent, or ctx is done. Requests of higher
+// priority go first, then requests in the order they came, skipping those
+// whose channel has to wait.
+func (q *Queue) Wait(ctx context.Context, p Priority, channelID string) error {
+	if q == nil {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t := &ticket{priority: p, channel: channelID}
+	q.mu.Lock()
+	q.waiting = append(q.waiting, t)
+	q.mu.Unlock()
+
+	for {
+		q.mu.Lock()
+		now := q.now()
+		ready := q.global.ready()
+		if c := q.channels[t.channel]; c != nil && c.ready().After(ready) {
+			ready = c.ready()
+		}
+		if q.next(now) == t && !ready.After(now) {
+			q.take(t, now)
+			q.mu.Unlock()
+			return nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		// Check again when it may be this request's turn, or when another
+		// request leaves the queue
+		timer := time.NewTimer(max(ready.Sub(now), time.Millisecond))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			q.mu.Lock()
+			q.remove(t)
+			q.mu.Unlock()
+			return ctx.Err()
+		case <-timer.C:
+		case <-changed:
+			timer.Stop()
+		}
+	}
+}
+
+// next returns the request to send next: the first of the highest priority
+// whose channel isn't waiting, nil if every channel is.
+func (q *Queue) next(now time.Time) *ticket {
+	var best *ticket
+	for _, t := range q.waiting {
+		if best != nil && t.priority <= best.priority {
+			continue
+		}
+		if c := q.channels[t.channel]; c != nil && c.ready().After(now) {
+			continue
+		}
+		best = t
+	}
+	return best
+}
+
+// take sends t: it leaves the queue and counts against the limits.
+func (q *Queue) take(t *ticket, now time.Time) {
+	q.remove(t)
+	q.global.take(now)
+	if t.channel == "" {
+		return
+	}
+	c := q.channels[t.channel]
+	if c == nil {
+		c = &limit{interval: q.channel.interval, burst: q.channel.burst}
+		q.channels[t.channel] = c
+	}
+	c.take(now)
+}
+
+// remove takes t out of the queue and wakes the requests waiting behind it.
+func (q *Queue) remove(t *ticket) {
+	for n, w := range q.waiting {
+		if w == t {
+			q.waiting = append(q.waiting[:n], q.waiting[n+1:]...)
+			break
+		}
+	}
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// Prune forgets the channels whose limit has fully recovered by now, and
+// returns how many it forgot.
+func (q *Queue) Prune(now time.Time) int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pruned := 0
+	for id, c := range q.channels {
+		if !c.due.After(now) {
+			delete(q.channels, id)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many channels the queue is pacing.
+func (q *Queue) Len() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.channels)
+}
+
+// Waiting returns how many requests are waiting to be sent.
+func (q *Queue) Waiting() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiting)
+}
+
+// limit lets burst requests through at once, then one every interval. due is
+// when it will have fully recovered from the requests let through so far.
+type limit struct {
+	interval time.Duration
+	burst    int
+	due      time.Time
+}
+
+// ready returns when the limit lets the next request through.
+func (l *limit) ready() time.Time {
+	return l.due.Add(-time.Duration(max(l.burst, 1)-1) * l.interval)
+}
+
+// take counts a request let through at now.
+func (l *limit) take(now time.Time) {
+	if l.due.Before(now) {
+		l.due = now
+	}
+	l.due = l.due.Add(l.interval)
+}
@@ -0,0 +1,102 @@
+package outbound
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRequestKind(t *testing.T) {
+	testCases := []struct {
+		method   string
+		url      string
+		ctx      context.Context
+		channel  string
+		priority Priority
+	}{
+		{method: "POST", url: "https://discord.com/api/v9/channels/123/messages", channel: "123", priority: Normal},
+		{method: "POST", url: "https://discord.com/api/v9/channels/123/messages", ctx: WithPriority(context.Background(), Low), channel: "123", priority: Low},
+		{method: "PATCH", url: "https://discord.com/api/v9/channels/123/messages/456", priority: Normal},
+		{method: "POST", url: "https://discord.com/api/v9/channels/123/messages/456/crosspost", priority: Normal},
+		{method: "POST", url: "https://discord.com/api/v9/interactions/1/token/callback", priority: High},
+	}
+
+	for _, tc := range testCases {
+		ctx := tc.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		req, _ := http.NewRequestWithContext(ctx, tc.method, tc.url, nil)
+		if got := messageChannel(req); got != tc.channel {
+			t.Errorf("messageChannel(%s %s) = %q; want %q", tc.method, tc.url, got, tc.channel)
+		}
+		if got := priority(req); got != tc.priority {
+			t.Errorf("priority(%s %s) = %d; want %d", tc.method, tc.url, got, tc.priority)
+		}
+	}
+}
+
+func TestQueueChannels(t *testing.T) {
+	q := New(0, 1, 100*time.Millisecond, 2)
+	ctx := context.Background()
+	start := time.Now()
+	for range 2 {
+		q.Wait(ctx, Normal, "busy")
+	}
+	q.Wait(ctx, Normal, "quiet")
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("a burst took %v; want it sent right away", elapsed)
+	}
+	q.Wait(ctx, Normal, "busy")
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("a message past the channel's burst went after %v; want it paced", elapsed)
+	}
+
+	if q.Len() != 2 {
+		t.Errorf("Len() = %d; want 2 channels", q.Len())
+	}
+	if pruned := q.Prune(time.Now().Add(time.Second)); pruned != 2 || q.Len() != 0 {
+		t.Errorf("Prune() = %d, leaving %d; want every channel forgotten", pruned, q.Len())
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	q.Wait(ctx, Normal, "busy")
+	if err := q.Wait(cancelled, Normal, "busy"); err == nil {
+		t.Error("Wait with a cancelled context succeeded")
+	}
+	if q.Waiting() != 0 {
+		t.Errorf("Waiting() = %d after a cancelled wait; want 0", q.Waiting())
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	q := New(50*time.Millisecond, 1, 0, 1)
+	ctx := context.Background()
+	q.Wait(ctx, Normal, "")
+
+	order := make(chan Priority, 2)
+	for _, p := range []Priority{Low, High} {
+		go func() {
+			q.Wait(ctx, p, "")
+			order <- p
+		}()
+		// Queue Low first
+		time.Sleep(10 * time.Millisecond)
+	}
+	if first := <-order; first != High {
+		t.Errorf("sent %d first; want High", first)
+	}
+	<-order
+}
+
+func TestNilQueue(t *testing.T) {
+	var q *Queue
+	if err := q.Wait(context.Background(), Low, "chan"); err != nil {
+		t.Errorf("Wait on a nil Queue: %v", err)
+	}
+	if q.Len() != 0 || q.Prune(time.Now()) != 0 || q.Waiting() != 0 {
+		t.Error("a nil Queue reports channels")
+	}
+}
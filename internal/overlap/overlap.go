@@ -0,0 +1,199 @@
+// Package overlap notices other link-fixing bots in a guild, by catching a bot
+// reposting a tweet through an embed proxy moments after someone shared it,
+// so the bot can leave links to them instead of both reposting every link.
+package overlap
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding the bots noticed fixing links, keyed by
+// guild ID.
+const Bucket = "fixer_bots"
+
+const (
+	// Window is how soon after someone shares a tweet a bot's repost of it
+	// counts as fixing it.
+	Window = 30 * time.Second
+	// MaxShares bounds how many recently shared tweets are remembered. Once
+	// it's reached, new shares are ignored until old ones are pruned.
+	MaxShares = 5000
+)
+
+// share is a tweet someone linked.
+type share struct {
+	authorID string
+	at       time.Time
+}
+
+// Detector remembers recently shared tweets and the bots seen fixing them. A
+// nil Detector notices nothing. It is safe for concurrent use.
+type Detector struct {
+	store storage.Store
+	now   func() time.Time
+
+	mu sync.Mutex
+	// ours are the bot's own accounts, which are never other fixers
+	ours   map[string]bool
+	shares map[string]share
+}
+
+// New returns a Detector keeping the bots it notices in st.
+func New(st storage.Store) *Detector {
+	return &Detector{store: st, now: time.Now, ours: make(map[string]bool), shares: make(map[string]share)}
+}
+
+// Ours marks userID as one of the bot's own accounts.
+func (d *Detector) Ours(userID string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ours[userID] = true
+}
+
+// shareKey is where a share of tweetID in a channel is remembered.
+func shareKey(guildID, channelID, tweetID string) string {
+	return guildID + "/" + channelID + "/" + tweetID
+}
+
+// Shared remembers the tweets authorID linked to in content.
+func (d *Detector) Shared(guildID, channelID, authorID, content string) {
+	if d == nil || guildID == "" {
+		return
+	}
+	links := patterns.TwitterStatusLink.FindAllString(content, -1)
+	if len(links) == 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, link := range links {
+		if len(d.shares) >= MaxShares {
+			return
+		}
+		if id := patterns.TweetID.FindStringSubmatch(link); id != nil {
+			d.shares[shareKey(guildID, channelID, id[1])] = share{authorID: authorID, at: d.now()}
+		}
+	}
+}
+
+// Observe checks a message from the bot botID for tweets someone else shared
+// in the channel moments ago, linked through an embed proxy. It reports
+// whether that made botID a newly noticed fixer in the guild.
+func (d *Detector) Observe(guildID, channelID, botID, content string) bool {
+	if d == nil || guildID == "" {
+		return false
+	}
+	d.mu.Lock()
+	if d.ours[botID] {
+		d.mu.Unlock()
+		return false
+	}
+	fixed := false
+	for _, match := range patterns.TwitterProxyStatus.FindAllStringSubmatch(content, -1) {
+		s, ok := d.shares[shareKey(guildID, channelID, match[1])]
+		if ok && s.authorID != botID && d.now().Sub(s.at) < Window {
+			fixed = true
+			break
+		}
+	}
+	d.mu.Unlock()
+	if !fixed || slices.Contains(d.Detected(guildID), botID) {
+		return false
+	}
+
+	bots := append(d.Detected(guildID), botID)
+	if err := d.store.Put(Bucket, guildID, bots); err != nil {
+		log.Println("Error saving other fixer bots:", err)
+		return false
+	}
+	log.Printf("Bot %s fixes links in guild %s too\n", botID, guildID)
+	return true
+}
+
+// Detected returns the bots noticed fixing links in guildID.
+func (d *Detector) Detected(guildID string) []string {
+	if d == nil {
+		return nil
+	}
+	var bots []string
+	if _, err := d.store.Get(Bucket, guildID, &bots); err != nil {
+		log.Println("Error loading other fixer bots:", err)
+	}
+	return bots
+}
+
+// Forget stops counting botID as a fixer in guildID, such as when it leaves
+// or admins say it doesn't fix links anymore. It reports whether botID was
+// counted.
+func (d *Detector) Forget(guildID, botID string) bool {
+	if d == nil {
+		return false
+	}
+	bots := d.Detected(guildID)
+	n := slices.Index(bots, botID)
+	if n < 0 {
+		return false
+	}
+	bots = slices.Delete(bots, n, n+1)
+	var err error
+	if len(bots) == 0 {
+		err = d.store.Delete(Bucket, guildID)
+	} else {
+		err = d.store.Put(Bucket, guildID, bots)
+	}
+	if err != nil {
+		log.Println("Error saving other fixer bots:", err)
+		return false
+	}
+	return true
+}
+
+// Forget deletes from st the bots noticed fixing links in guildID, for
+// purges, when userID is empty, and returns how many records it deleted. The
+// bots noticed aren't members' data, so forgetting a user removes nothing.
+func Forget(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" || userID != "" {
+		return 0, nil
+	}
+	if ok, err := st.Get(Bucket, guildID, &[]string{}); err != nil || !ok {
+		return 0, err
+	}
+	return 1, st.Delete(Bucket, guildID)
+}
+
+// Prune forgets tweets shared longer than Window before now, and returns how
+// many it removed. It implements janitor.Pruner.
+func (d *Detector) Prune(now time.Time) int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pruned := 0
+	for key, s := range d.shares {
+		if now.Sub(s.at) >= Window {
+			delete(d.shares, key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many shared tweets are remembered.
+func (d *Detector) Len() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.shares)
+}
@@ -0,0 +1,61 @@
+package overlap
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go-discord-bot/internal/storage"
+)
+
+func TestObserve(t *testing.T) {
+	now := time.Unix(1000, 0)
+	d := New(storage.NewMemory())
+	d.now = func() time.Time { return now }
+	d.Ours("us")
+
+	testCases := []struct {
+		name     string
+		share    string
+		advance  time.Duration
+		channel  string
+		botID    string
+		content  string
+		expected bool
+	}{
+		{name: "Proxy repost", share: "https://x.com/user/status/1", channel: "chan", botID: "rival", content: "https://fxtwitter.com/user/status/1", expected: true},
+		{name: "Noticed already", share: "https://x.com/user/status/2", channel: "chan", botID: "rival", content: "https://vxtwitter.com/user/status/2"},
+		{name: "Our own bot", share: "https://x.com/user/status/3", channel: "chan", botID: "us", content: "https://fixupx.com/user/status/3"},
+		{name: "Other tweet", share: "https://x.com/user/status/4", channel: "chan", botID: "other", content: "https://fixupx.com/user/status/5"},
+		{name: "Other channel", share: "https://x.com/user/status/6", channel: "elsewhere", botID: "other", content: "https://fixupx.com/user/status/6"},
+		{name: "Too late", share: "https://x.com/user/status/7", advance: Window, channel: "chan", botID: "other", content: "https://fixupx.com/user/status/7"},
+		{name: "Original link", share: "https://x.com/user/status/8", channel: "chan", botID: "other", content: "https://x.com/user/status/8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d.Shared("guild", "chan", "person", tc.share)
+			now = now.Add(tc.advance)
+			if got := d.Observe("guild", tc.channel, tc.botID, tc.content); got != tc.expected {
+				t.Errorf("Observe = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+
+	if bots := d.Detected("guild"); !slices.Equal(bots, []string{"rival"}) {
+		t.Errorf("Detected = %q; want the rival", bots)
+	}
+	if !d.Forget("guild", "rival") || d.Forget("guild", "rival") || len(d.Detected("guild")) != 0 {
+		t.Error("Forget didn't forget the rival exactly once")
+	}
+	shares := d.Len()
+	if pruned := d.Prune(now.Add(Window)); pruned != shares || d.Len() != 0 {
+		t.Errorf("Prune = %d of %d shares, leaving %d", pruned, shares, d.Len())
+	}
+
+	var none *Detector
+	none.Shared("guild", "chan", "person", "https://x.com/user/status/1")
+	if none.Observe("guild", "chan", "rival", "https://fxtwitter.com/user/status/1") || none.Detected("guild") != nil {
+		t.Error("nil Detector noticed a bot")
+	}
+}
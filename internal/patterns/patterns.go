@@ -0,0 +1,78 @@
+// Package patterns holds the precompiled regular expressions used to find links in messages.
+// Everything here is compiled once at package init and is safe for concurrent use.
+package patterns
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// TwitterStatus matches a Twitter/X status link anywhere in a message.
+	// Besides /<user>/status/<id> it accepts the /i/web/status/<id> form and the
+	// mobile.twitter.com host.
+	TwitterStatus = regexp.MustCompile(`https?:\/\/(www\.|mobile\.)?(twitter\.com|x\.com)\/(?:i\/web|[a-zA-Z0-9_]+)\/status\/[0-9]+`)
+
+	// TwitterStatusLink matches just the status link itself, used to pull links out of a message.
+	TwitterStatusLink = regexp.MustCompile(`https?://(www\.|mobile\.)?(twitter\.com|x\.com)/(?:i/web|[A-Za-z0-9_]+)/status/\d+`)
+
+	// TwitterRewritable matches a Twitter/X status link including any query string and
+	// a surrounding pair of angle brackets, so callers can leave bracketed links alone.
+	TwitterRewritable = regexp.MustCompile(`(<)?https?://(www\.|mobile\.)?(twitter\.com|x\.com)/(?:i/web|[A-Za-z0-9_]+)/status/\d+(\?[^\s<>]*)?([^<\s]*)>?`)
+
+	// TwitterProxyStatus matches a status link on one of the embed-fixing proxies,
+	// such as fxtwitter.com or fixupx.com, capturing the tweet ID in group 1.
+	TwitterProxyStatus = regexp.MustCompile(`https?://(?:[a-z]+\.)?(?:fxtwitter|vxtwitter|fixupx|fixvx|twittpr)\.com/[A-Za-z0-9_]+/status/(\d+)`)
+
+	// TwitterProfile matches a whole link to a Twitter/X profile, capturing the
+	// screen name in group 1. Some of the paths it matches, such as /home, are
+	// the site's own pages rather than accounts.
+	TwitterProfile = regexp.MustCompile(`^https?://(?:www\.|mobile\.)?(?:twitter\.com|x\.com)/([A-Za-z0-9_]{1,15})/?(?:\?[^\s<>]*)?$`)
+
+	// TwitterCommunityOrSpace matches a link to a Twitter/X community or Space.
+	TwitterCommunityOrSpace = regexp.MustCompile(`^https?://(?:www\.|mobile\.)?(?:twitter\.com|x\.com)/i/(?:communities|spaces)/`)
+
+	// TweetID captures the status ID of a Twitter/X link, or of a fixed mirror of one, in group 1.
+	TweetID = regexp.MustCompile(`/status/(\d+)`)
+
+	// TweetAuthor captures the screen name of a Twitter/X status link, or of a
+	// fixed mirror of one, in group 1. Links without one, like /i/web/status
+	// links, don't match.
+	TweetAuthor = regexp.MustCompile(`https?://[^/\s<>]+/([A-Za-z0-9_]{1,15})/status/\d+`)
+
+	// TwitchClip matches clips.twitch.tv/<slug> and twitch.tv/<channel>/clip/<slug> links,
+	// optionally wrapped in angle brackets. The clip slug is capture group 2.
+	TwitchClip = regexp.MustCompile(`(<)?https?://(?:(?:www\.|m\.)?twitch\.tv/[A-Za-z0-9_]+/clip|clips\.twitch\.tv)/([A-Za-z0-9_-]+)(\?[^\s<>]*)?>?`)
+
+	// MessageLink matches a link to a Discord message, optionally wrapped in
+	// angle brackets, capturing the guild, channel and message IDs in groups
+	// 2 to 4.
+	MessageLink = regexp.MustCompile(`(<)?https?://(?:(?:canary|ptb)\.)?discord(?:app)?\.com/channels/(\d+)/(\d+)/(\d+)>?`)
+
+	// SteamApp matches a link to a game on the Steam store, capturing its app
+	// ID in group 1.
+	SteamApp = regexp.MustCompile(`^https?://store\.steampowered\.com/app/(\d+)`)
+
+	// ItchGame matches a whole link to a game on itch.io, capturing the
+	// creator in group 1 and the game in group 2.
+	ItchGame = regexp.MustCompile(`^https?://([a-z0-9][a-z0-9-]*)\.itch\.io/([a-z0-9][a-z0-9_-]*)/?(?:[?#][^\s<>]*)?$`)
+
+	// MusicLink matches a link to a song or album on Spotify, Apple Music,
+	// YouTube Music, Tidal or Deezer.
+	MusicLink = regexp.MustCompile(`https?://(?:open\.spotify\.com/(?:intl-[a-z-]+/)?(?:track|album)/[A-Za-z0-9]+|music\.apple\.com/[a-z]{2}/(?:album|song)/[^\s<>]+|music\.youtube\.com/(?:watch\?v=|playlist\?list=)[\w-]+|(?:listen\.)?tidal\.com/(?:browse/)?(?:track|album)/\d+|(?:www\.)?deezer\.com/(?:[a-z]{2}/)?(?:track|album)/\d+)[^\s<>]*`)
+
+	// Code matches a code block or an inline code span, whose links Discord
+	// shows as plain text.
+	Code = regexp.MustCompile("(?s)```.*?```|`[^`]+`")
+
+	// URL matches any http(s) link, optionally wrapped in angle brackets.
+	URL = regexp.MustCompile(`<?https?://[^\s<>]+>?`)
+)
+
+// HasLink reports whether s could hold a link. Patterns that allow an opening
+// angle bracket, like URL, have no literal prefix to skip ahead to, so they
+// cost as much on a long message without links as on one with them; checking
+// this first keeps plain chat cheap.
+func HasLink(s string) bool {
+	return strings.Contains(s, "://")
+}
@@ -0,0 +1,110 @@
+package patterns
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPatterns(t *testing.T) {
+	testCases := []struct {
+		name    string
+		re      *regexp.Regexp
+		input   string
+		matches bool
+	}{
+		{name: "Twitter status", re: TwitterStatus, input: "see https://twitter.com/user/status/123", matches: true},
+		{name: "X status with www", re: TwitterStatus, input: "https://www.x.com/user_1/status/9", matches: true},
+		{name: "Twitter profile", re: TwitterStatus, input: "https://twitter.com/user", matches: false},
+		{name: "Other host", re: TwitterStatus, input: "https://nottwitter.org/user/status/1", matches: false},
+		{name: "Status link", re: TwitterStatusLink, input: "https://x.com/us_er/status/1", matches: true},
+		{name: "Status link with spaces in username", re: TwitterStatusLink, input: "https://x.com/a b/status/1", matches: false},
+		{name: "Status link with escape in username", re: TwitterStatusLink, input: "http://twitter.com/00%0X/status/000", matches: false},
+		{name: "Bracketed rewritable link", re: TwitterRewritable, input: "<https://x.com/user/status/1?s=20>", matches: true},
+		{name: "Web status link", re: TwitterStatus, input: "https://twitter.com/i/web/status/1", matches: true},
+		{name: "Mobile status link", re: TwitterStatusLink, input: "https://mobile.twitter.com/user/status/1", matches: true},
+		{name: "Other subdomain", re: TwitterStatus, input: "https://api.twitter.com/user/status/1", matches: false},
+		{name: "Profile link", re: TwitterProfile, input: "https://x.com/user_1?s=20", matches: true},
+		{name: "Profile link with a path", re: TwitterProfile, input: "https://x.com/user/media", matches: false},
+		{name: "Status link isn't a profile", re: TwitterProfile, input: "https://x.com/user/status/1", matches: false},
+		{name: "Community link", re: TwitterCommunityOrSpace, input: "https://x.com/i/communities/123", matches: true},
+		{name: "Space link", re: TwitterCommunityOrSpace, input: "https://twitter.com/i/spaces/1abc", matches: true},
+		{name: "Twitch clip subdomain", re: TwitchClip, input: "https://clips.twitch.tv/Slug-1", matches: true},
+		{name: "Twitch channel clip", re: TwitchClip, input: "https://twitch.tv/chan/clip/Slug", matches: true},
+		{name: "Twitch channel", re: TwitchClip, input: "https://twitch.tv/chan", matches: false},
+		{name: "Any URL", re: URL, input: "go to http://example.com/a?b=c now", matches: true},
+		{name: "No URL", re: URL, input: "example.com", matches: false},
+		{name: "Inline code", re: Code, input: "see `https://x.com/user/status/1`", matches: true},
+		{name: "Code block", re: Code, input: "```\nhttps://x.com/user/status/1\n```", matches: true},
+		{name: "Lone backtick", re: Code, input: "it`s https://x.com/user/status/1", matches: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.re.MatchString(tc.input); got != tc.matches {
+				t.Errorf("%s.MatchString(%q) = %v; want %v", tc.name, tc.input, got, tc.matches)
+			}
+		})
+	}
+}
+
+func TestTwitchClipSlugGroup(t *testing.T) {
+	groups := TwitchClip.FindStringSubmatch("https://www.twitch.tv/chan/clip/Funny-Slug_1?filter=clips")
+	if groups == nil || groups[2] != "Funny-Slug_1" {
+		t.Errorf("TwitchClip slug group = %q; want %q", groups, "Funny-Slug_1")
+	}
+}
+
+func TestTweetAuthorGroup(t *testing.T) {
+	groups := TweetAuthor.FindStringSubmatch("https://fxtwitter.com/Some_One/status/123?s=19")
+	if groups == nil || groups[1] != "Some_One" {
+		t.Errorf("TweetAuthor screen name group = %q; want %q", groups, "Some_One")
+	}
+	if TweetAuthor.MatchString("https://x.com/i/web/status/123") {
+		t.Errorf("TweetAuthor matched a link without a screen name")
+	}
+}
+
+func TestMessageLinkGroups(t *testing.T) {
+	groups := MessageLink.FindStringSubmatch("see https://ptb.discord.com/channels/1/22/333 there")
+	if groups == nil || groups[1] != "" || groups[2] != "1" || groups[3] != "22" || groups[4] != "333" {
+		t.Errorf("MessageLink groups = %q; want guild 1, channel 22 and message 333", groups)
+	}
+	if groups := MessageLink.FindStringSubmatch("<https://discord.com/channels/1/22/333>"); groups == nil || groups[1] != "<" {
+		t.Errorf("MessageLink groups = %q; want the opening angle bracket", groups)
+	}
+	if MessageLink.MatchString("https://discord.com/channels/@me/22/333") {
+		t.Errorf("MessageLink matched a link to a DM")
+	}
+}
+
+const benchMessage = "lol look at this https://x.com/someone/status/1827343634091409773?t=abc&s=19 so good"
+
+// BenchmarkMatchStringCompiled shows the cost of compiling the pattern for every
+// message, which is what the handlers did before this package existed.
+func BenchmarkMatchStringCompiled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		regexp.MatchString(TwitterStatus.String(), benchMessage)
+	}
+}
+
+func BenchmarkMatchStringPrecompiled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		TwitterStatus.MatchString(benchMessage)
+	}
+}
+
+func BenchmarkRewritableCompiled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		regexp.MustCompile(TwitterRewritable.String()).FindAllString(benchMessage, -1)
+	}
+}
+
+func BenchmarkRewritablePrecompiled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		TwitterRewritable.FindAllString(benchMessage, -1)
+	}
+}
@@ -0,0 +1,93 @@
+// Package pending holds fixed messages that guilds in reaction or confirm mode
+// haven't asked to see yet.
+package pending
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Repost is a fixed message waiting for someone to ask for it.
+type Repost struct {
+	// Message is the original message.
+	Message *discordgo.Message
+	// Content is the message with its links fixed.
+	Content string
+	// Origins maps each fixed link to the fixer that fixed it.
+	Origins map[string]string
+	At      time.Time
+}
+
+// Tracker remembers pending reposts by the ID of the original message for TTL.
+// A nil Tracker remembers nothing.
+type Tracker struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	reposts map[string]Repost
+}
+
+// New returns a Tracker that remembers pending reposts for ttl.
+func New(ttl time.Duration) *Tracker {
+	return &Tracker{ttl: ttl, now: time.Now, reposts: make(map[string]Repost)}
+}
+
+// Add remembers that m can be reposted as content, whose links were fixed by
+// the fixers in origins.
+func (t *Tracker) Add(m *discordgo.Message, content string, origins map[string]string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reposts[m.ID] = Repost{Message: m, Content: content, Origins: origins, At: t.now()}
+}
+
+// Take returns and forgets the pending repost of a message, so it's only
+// posted once however many people react.
+func (t *Tracker) Take(messageID string) (Repost, bool) {
+	if t == nil {
+		return Repost{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r, ok := t.reposts[messageID]
+	if !ok {
+		return Repost{}, false
+	}
+	delete(t.reposts, messageID)
+	if t.now().Sub(r.At) >= t.ttl {
+		return Repost{}, false
+	}
+	return r, true
+}
+
+// Prune forgets reposts older than the TTL at now and returns how many it forgot.
+func (t *Tracker) Prune(now time.Time) int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pruned := 0
+	for id, r := range t.reposts {
+		if now.Sub(r.At) >= t.ttl {
+			delete(t.reposts, id)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many fixes the tracker is holding.
+func (t *Tracker) Len() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.reposts)
+}
@@ -0,0 +1,62 @@
+package pending
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestTracker(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := New(time.Hour)
+	tr.now = func() time.Time { return clock }
+
+	tr.Add(&discordgo.Message{ID: "1"}, "fixed 1", nil)
+	tr.Add(&discordgo.Message{ID: "2"}, "fixed 2", nil)
+
+	testCases := []struct {
+		name     string
+		advance  time.Duration
+		id       string
+		expected bool
+	}{
+		{name: "Pending", advance: 30 * time.Minute, id: "1", expected: true},
+		{name: "Already taken", id: "1", expected: false},
+		{name: "Unknown", id: "3", expected: false},
+		{name: "Expired", advance: 30 * time.Minute, id: "2", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock = clock.Add(tc.advance)
+			r, ok := tr.Take(tc.id)
+			if ok != tc.expected {
+				t.Fatalf("Take(%q) found = %v; want %v", tc.id, ok, tc.expected)
+			}
+			if ok && r.Content != "fixed "+tc.id {
+				t.Errorf("Take returned %+v", r)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := New(time.Hour)
+	tr.now = func() time.Time { return start }
+	tr.Add(&discordgo.Message{ID: "1"}, "fixed", nil)
+
+	if n := tr.Prune(start.Add(time.Minute)); n != 0 {
+		t.Errorf("Prune before the TTL forgot %d; want 0", n)
+	}
+	if n := tr.Prune(start.Add(time.Hour)); n != 1 {
+		t.Errorf("Prune after the TTL forgot %d; want 1", n)
+	}
+
+	var nilTracker *Tracker
+	nilTracker.Add(&discordgo.Message{ID: "1"}, "fixed", nil)
+	if _, ok := nilTracker.Take("1"); ok {
+		t.Errorf("nil tracker found a repost")
+	}
+}
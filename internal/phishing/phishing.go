@@ -0,0 +1,126 @@
+// Package phishing checks links against blocklists of phishing and malware
+// sites: a local list of domains, plus Google Safe Browsing when a key is set.
+package phishing
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Checker looks links up in the blocklists. It is safe for concurrent use,
+// and a nil Checker flags nothing.
+type Checker struct {
+	path string
+	// SafeBrowsing is also asked about links the local list doesn't flag. Nil skips it.
+	SafeBrowsing *SafeBrowsing
+
+	mu      sync.RWMutex
+	domains map[string]bool
+}
+
+// New loads the local blocklist at path. An empty path means no local list.
+func New(path string) (*Checker, error) {
+	c := &Checker{path: path}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload re-reads the local blocklist. On error the previous list is kept.
+//
+// The list has one domain per line; blank lines and lines starting with # are
+// skipped, and hosts-file lines such as "0.0.0.0 example.com" are accepted.
+// Listing a domain also blocks its subdomains.
+func (c *Checker) Reload() error {
+	if c.path == "" {
+		return nil
+	}
+	f, err := os.Open(c.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	domains := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		domains[normalizeHost(fields[len(fields)-1])] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", c.path, err)
+	}
+
+	c.mu.Lock()
+	c.domains = domains
+	c.mu.Unlock()
+	return nil
+}
+
+// Check returns the links that are on a blocklist. If Safe Browsing can't be
+// reached, it returns what the local list flagged along with the error.
+func (c *Checker) Check(ctx context.Context, links []string) ([]string, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	var flagged, unknown []string
+	for _, link := range links {
+		if c.listed(link) {
+			flagged = append(flagged, link)
+		} else {
+			unknown = append(unknown, link)
+		}
+	}
+	if c.SafeBrowsing == nil || len(unknown) == 0 {
+		return flagged, nil
+	}
+
+	matches, err := c.SafeBrowsing.Lookup(ctx, unknown)
+	return append(flagged, matches...), err
+}
+
+// listed reports whether link's host, or a domain it belongs to, is on the local list.
+func (c *Checker) listed(link string) bool {
+	host := Host(link)
+	if host == "" {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for {
+		if c.domains[host] {
+			return true
+		}
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return false
+		}
+		host = parent
+	}
+}
+
+// Host returns the normalized host name of link, or "" if it isn't a URL.
+func Host(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return normalizeHost(u.Hostname())
+}
+
+// normalizeHost lowercases a host and drops a trailing dot and leading "www.".
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return strings.TrimPrefix(host, "www.")
+}
@@ -0,0 +1,104 @@
+package phishing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newTestChecker(t *testing.T, list string) *Checker {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte(list), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestCheck(t *testing.T) {
+	c := newTestChecker(t, "# phishing\nsteamcommunity-gift.com\n\n0.0.0.0 Discord-Nitro.example.\n")
+
+	testCases := []struct {
+		name     string
+		links    []string
+		expected []string
+	}{
+		{name: "Listed domain", links: []string{"https://steamcommunity-gift.com/login"}, expected: []string{"https://steamcommunity-gift.com/login"}},
+		{name: "Subdomain", links: []string{"http://free.steamcommunity-gift.com/"}, expected: []string{"http://free.steamcommunity-gift.com/"}},
+		{name: "Hosts file line", links: []string{"https://www.discord-nitro.example/claim"}, expected: []string{"https://www.discord-nitro.example/claim"}},
+		{name: "Unlisted", links: []string{"https://steamcommunity.com/", "https://example.com/"}, expected: nil},
+		{name: "Lookalike suffix", links: []string{"https://notsteamcommunity-gift.com/"}, expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			flagged, err := c.Check(context.Background(), tc.links)
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if !slices.Equal(flagged, tc.expected) {
+				t.Errorf("Check(%q) = %q; want %q", tc.links, flagged, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCheckNil(t *testing.T) {
+	var c *Checker
+	if flagged, err := c.Check(context.Background(), []string{"https://example.com"}); flagged != nil || err != nil {
+		t.Errorf("nil Check() = %q, %v; want nothing", flagged, err)
+	}
+}
+
+func TestSafeBrowsing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v4/threatMatches:find" || r.URL.Query().Get("key") != "key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var req findRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var resp findResponse
+		for _, entry := range req.ThreatInfo.ThreatEntries {
+			if entry.URL == "https://malware.example/" {
+				resp.Matches = append(resp.Matches, struct {
+					Threat threatEntry `json:"threat"`
+				}{Threat: entry})
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	c := newTestChecker(t, "listed.example\n")
+	c.SafeBrowsing = NewSafeBrowsing("key", server.URL, server.Client())
+	flagged, err := c.Check(context.Background(), []string{"https://listed.example/", "https://malware.example/", "https://fine.example/"})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if expected := []string{"https://listed.example/", "https://malware.example/"}; !slices.Equal(flagged, expected) {
+		t.Errorf("Check() = %q; want %q", flagged, expected)
+	}
+
+	// A failed lookup still reports the local list's matches
+	c.SafeBrowsing = NewSafeBrowsing("wrong", server.URL, server.Client())
+	flagged, err = c.Check(context.Background(), []string{"https://listed.example/", "https://malware.example/"})
+	if err == nil {
+		t.Error("Check with a bad key succeeded")
+	}
+	if expected := []string{"https://listed.example/"}; !slices.Equal(flagged, expected) {
+		t.Errorf("Check() = %q; want %q", flagged, expected)
+	}
+}
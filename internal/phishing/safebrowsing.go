@@ -0,0 +1,117 @@
+package phishing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"go-discord-bot/internal/version"
+)
+
+// DefaultSafeBrowsingURL is Google's Safe Browsing API.
+const DefaultSafeBrowsingURL = "https://safebrowsing.googleapis.com"
+
+// maxLookupURLs is how many URLs the Safe Browsing API accepts in one request.
+const maxLookupURLs = 500
+
+// SafeBrowsing is a client for the Safe Browsing Lookup API (v4).
+type SafeBrowsing struct {
+	key     string
+	baseURL string
+	client  *http.Client
+}
+
+// NewSafeBrowsing returns a client using the API key key against the API at
+// baseURL, or DefaultSafeBrowsingURL if it's empty. A nil client uses
+// http.DefaultClient.
+func NewSafeBrowsing(key, baseURL string, client *http.Client) *SafeBrowsing {
+	if baseURL == "" {
+		baseURL = DefaultSafeBrowsingURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &SafeBrowsing{key: key, baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+type threatEntry struct {
+	URL string `json:"url"`
+}
+
+type findRequest struct {
+	Client struct {
+		ClientID      string `json:"clientId"`
+		ClientVersion string `json:"clientVersion"`
+	} `json:"client"`
+	ThreatInfo struct {
+		ThreatTypes      []string      `json:"threatTypes"`
+		PlatformTypes    []string      `json:"platformTypes"`
+		ThreatEntryTypes []string      `json:"threatEntryTypes"`
+		ThreatEntries    []threatEntry `json:"threatEntries"`
+	} `json:"threatInfo"`
+}
+
+type findResponse struct {
+	Matches []struct {
+		Threat threatEntry `json:"threat"`
+	} `json:"matches"`
+}
+
+// Lookup returns the links Safe Browsing knows as phishing, malware or unwanted software.
+func (sb *SafeBrowsing) Lookup(ctx context.Context, links []string) ([]string, error) {
+	if len(links) > maxLookupURLs {
+		links = links[:maxLookupURLs]
+	}
+
+	var body findRequest
+	body.Client.ClientID = "go-discord-bot"
+	body.Client.ClientVersion = version.Version
+	body.ThreatInfo.ThreatTypes = []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"}
+	body.ThreatInfo.PlatformTypes = []string{"ANY_PLATFORM"}
+	body.ThreatInfo.ThreatEntryTypes = []string{"URL"}
+	for _, link := range links {
+		body.ThreatInfo.ThreatEntries = append(body.ThreatInfo.ThreatEntries, threatEntry{URL: link})
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := sb.baseURL + "/v4/threatMatches:find?key=" + url.QueryEscape(sb.key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := sb.client.Do(req)
+	if err != nil {
+		// Drop the request URL from the error, it includes the key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("looking up links: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("looking up links: %s", resp.Status)
+	}
+
+	var result findResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding lookup response: %w", err)
+	}
+	var matches []string
+	for _, m := range result.Matches {
+		if !slices.Contains(matches, m.Threat.URL) {
+			matches = append(matches, m.Threat.URL)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,79 @@
+package preview
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go-discord-bot/internal/safehttp"
+)
+
+var (
+	metaTag   = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attribute = regexp.MustCompile(`(?s)([a-zA-Z_:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	titleTag  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	headEnd   = regexp.MustCompile(`(?i)</head\s*>`)
+	spaces    = regexp.MustCompile(`\s+`)
+)
+
+// parseCard reads the OpenGraph and Twitter card metadata from the head of a
+// page, preferring OpenGraph. page is the page's URL, used to resolve a
+// relative image link.
+func parseCard(body string, page *url.URL) Card {
+	if loc := headEnd.FindStringIndex(body); loc != nil {
+		body = body[:loc[0]]
+	}
+
+	meta := make(map[string]string)
+	for _, tag := range metaTag.FindAllString(body, -1) {
+		attrs := make(map[string]string)
+		for _, m := range attribute.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(m[1])] = m[2] + m[3] + m[4]
+		}
+		key := attrs["property"]
+		if key == "" {
+			key = attrs["name"]
+		}
+		key = strings.ToLower(key)
+		if _, seen := meta[key]; key != "" && !seen {
+			meta[key] = clean(attrs["content"])
+		}
+	}
+
+	card := Card{
+		Title:       first(meta, "og:title", "twitter:title"),
+		Description: first(meta, "og:description", "twitter:description", "description"),
+		SiteName:    first(meta, "og:site_name"),
+	}
+	if card.Title == "" {
+		if m := titleTag.FindStringSubmatch(body); m != nil {
+			card.Title = clean(m[1])
+		}
+	}
+	card.Title = truncate(card.Title, maxTitle)
+	card.Description = truncate(card.Description, maxDescription)
+	card.SiteName = truncate(card.SiteName, maxTitle)
+
+	if image := first(meta, "og:image:secure_url", "og:image", "og:image:url", "twitter:image", "twitter:image:src"); image != "" {
+		if u, err := page.Parse(image); err == nil && safehttp.WebURL(u) {
+			card.Image = u.String()
+		}
+	}
+	return card
+}
+
+// first returns the first non-empty value among keys.
+func first(meta map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if v := meta[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// clean unescapes HTML entities and collapses whitespace.
+func clean(s string) string {
+	return strings.TrimSpace(spaces.ReplaceAllString(html.UnescapeString(s), " "))
+}
@@ -0,0 +1,201 @@
+// Package preview builds link previews from a page's OpenGraph and Twitter card
+// metadata, for links Discord couldn't embed itself.
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/safehttp"
+)
+
+const (
+	// UserAgent identifies the scraper to the sites it visits.
+	UserAgent = "go-discord-bot/1.0 (link previews)"
+	// robotsAgent is the product token matched against robots.txt user-agent lines.
+	robotsAgent = "go-discord-bot"
+
+	// MaxPageSize is how much of a page is read looking for metadata.
+	MaxPageSize = 512 << 10
+	// maxRobotsSize is how much of a robots.txt file is read.
+	maxRobotsSize = 64 << 10
+	// maxRedirects is how many redirects a page fetch may follow.
+	maxRedirects = 3
+	// robotsTTL is how long a site's robots.txt is cached.
+	robotsTTL = time.Hour
+	// maxRobotsHosts bounds the robots.txt cache.
+	maxRobotsHosts = 1000
+
+	maxTitle       = 256
+	maxDescription = 350
+)
+
+var (
+	// ErrDisallowed is returned for pages the site's robots.txt keeps the scraper out of.
+	ErrDisallowed = errors.New("disallowed by robots.txt")
+	// ErrNoMetadata is returned for pages with no title or description to show.
+	ErrNoMetadata = errors.New("page has no preview metadata")
+)
+
+// Card is the metadata shown in a preview.
+type Card struct {
+	URL         string
+	Title       string
+	Description string
+	Image       string
+	SiteName    string
+}
+
+// Embed renders the card as a Discord embed.
+func (c Card) Embed() *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Type:        discordgo.EmbedTypeRich,
+		URL:         c.URL,
+		Title:       c.Title,
+		Description: c.Description,
+	}
+	if c.Image != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: c.Image}
+	}
+	if c.SiteName != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: c.SiteName}
+	}
+	return embed
+}
+
+// Scraper fetches pages and reads their preview metadata, honoring robots.txt.
+// It is safe for concurrent use.
+type Scraper struct {
+	client *http.Client
+
+	mu     sync.Mutex
+	robots map[string]robotsEntry
+	now    func() time.Time
+}
+
+// robotsEntry is a cached robots.txt.
+type robotsEntry struct {
+	rules   robotsRules
+	expires time.Time
+}
+
+// New returns a scraper using client. A nil client uses safehttp.Client,
+// which refuses to connect to private and internal addresses; pass your own
+// only in tests.
+func New(client *http.Client) *Scraper {
+	if client == nil {
+		client = safehttp.Client(10 * time.Second)
+	}
+	return &Scraper{client: client, robots: make(map[string]robotsEntry), now: time.Now}
+}
+
+// Fetch returns the preview card for the page at link.
+func (s *Scraper) Fetch(ctx context.Context, link string) (Card, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return Card{}, err
+	}
+	if !safehttp.WebURL(u) {
+		return Card{}, fmt.Errorf("unsupported link %q", link)
+	}
+	if err := s.checkRobots(ctx, u); err != nil {
+		return Card{}, err
+	}
+
+	page, err := safehttp.Fetcher{
+		Client: s.client,
+		// The metadata is in the head, at the start of the page
+		MaxSize:      MaxPageSize,
+		Truncate:     true,
+		Types:        []string{"text/html", "application/xhtml+xml"},
+		MaxRedirects: maxRedirects,
+		CheckRedirect: func(req *http.Request) error {
+			return s.checkRobots(req.Context(), req.URL)
+		},
+		UserAgent: UserAgent,
+	}.Get(ctx, link)
+	if err != nil {
+		return Card{}, err
+	}
+
+	card := parseCard(string(page.Body), page.URL)
+	if card.Title == "" && card.Description == "" {
+		return Card{}, ErrNoMetadata
+	}
+	card.URL = link
+	return card, nil
+}
+
+// checkRobots returns ErrDisallowed if u's robots.txt keeps the scraper out.
+func (s *Scraper) checkRobots(ctx context.Context, u *url.URL) error {
+	key := u.Scheme + "://" + u.Host
+	s.mu.Lock()
+	entry, ok := s.robots[key]
+	s.mu.Unlock()
+
+	if !ok || s.now().After(entry.expires) {
+		entry = robotsEntry{rules: s.fetchRobots(ctx, key), expires: s.now().Add(robotsTTL)}
+		s.mu.Lock()
+		if len(s.robots) >= maxRobotsHosts {
+			clear(s.robots)
+		}
+		s.robots[key] = entry
+		s.mu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if !entry.rules.allowed(path) {
+		return ErrDisallowed
+	}
+	return nil
+}
+
+// fetchRobots downloads and parses the robots.txt at origin. A missing file
+// allows everything; a server error or unreachable site disallows everything.
+func (s *Scraper) fetchRobots(ctx context.Context, origin string) robotsRules {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return disallowAll
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return disallowAll
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return disallowAll
+	case resp.StatusCode >= 400:
+		return nil
+	case resp.StatusCode != http.StatusOK:
+		return disallowAll
+	}
+	return parseRobots(io.LimitReader(resp.Body, maxRobotsSize), robotsAgent)
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
@@ -0,0 +1,165 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseCard(t *testing.T) {
+	page, _ := url.Parse("https://example.com/articles/1")
+	testCases := []struct {
+		name string
+		body string
+		want Card
+	}{
+		{
+			name: "OpenGraph",
+			body: `<html><head>
+				<meta property="og:title" content="Hello &amp; welcome">
+				<meta property="og:description" content="A  long
+					description">
+				<meta property="og:image" content="/img/cover.png">
+				<meta property="og:site_name" content='Example'>
+				</head><body></body></html>`,
+			want: Card{Title: "Hello & welcome", Description: "A long description", Image: "https://example.com/img/cover.png", SiteName: "Example"},
+		},
+		{
+			name: "Twitter card",
+			body: `<meta name="twitter:title" content="Card title"><meta name="twitter:image" content="https://cdn.example.com/a.jpg">`,
+			want: Card{Title: "Card title", Image: "https://cdn.example.com/a.jpg"},
+		},
+		{
+			name: "OpenGraph preferred over Twitter card",
+			body: `<meta name="twitter:title" content="Twitter"><meta content="OG" property="og:title">`,
+			want: Card{Title: "OG"},
+		},
+		{
+			name: "Title tag and description fallback",
+			body: `<title> Page title </title><meta name="description" content="Plain description">`,
+			want: Card{Title: "Page title", Description: "Plain description"},
+		},
+		{
+			name: "Body ignored",
+			body: `<head></head><body><meta property="og:title" content="Injected"></body>`,
+			want: Card{},
+		},
+		{
+			name: "Non-web image dropped",
+			body: `<meta property="og:title" content="T"><meta property="og:image" content="javascript:alert(1)">`,
+			want: Card{Title: "T"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseCard(tc.body, page); got != tc.want {
+				t.Errorf("parseCard() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRobots(t *testing.T) {
+	const robots = `
+# comment
+User-agent: *
+Disallow: /private
+Allow: /private/public
+
+User-agent: other-bot
+Disallow: /
+
+User-agent: go-discord-bot
+Disallow: /nobots
+Disallow: /*.pdf$
+`
+	testCases := []struct {
+		name  string
+		agent string
+		path  string
+		want  bool
+	}{
+		{name: "Generic group allows", agent: "someone", path: "/", want: true},
+		{name: "Generic group disallows", agent: "someone", path: "/private/x", want: false},
+		{name: "Longer allow wins", agent: "someone", path: "/private/public/x", want: true},
+		{name: "Named group replaces generic", agent: "go-discord-bot", path: "/private/x", want: true},
+		{name: "Named group disallows", agent: "go-discord-bot", path: "/nobots/1", want: false},
+		{name: "Wildcard and anchor", agent: "go-discord-bot", path: "/files/a.pdf", want: false},
+		{name: "Anchor not at end", agent: "go-discord-bot", path: "/files/a.pdf.html", want: true},
+		{name: "Other group", agent: "other-bot", path: "/anything", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(robots), tc.agent)
+			if got := rules.allowed(tc.path); got != tc.want {
+				t.Errorf("allowed(%q) = %v, want %v", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /secret\n"))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<meta property="og:title" content="Page">`))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/secret", http.StatusFound)
+	})
+	mux.HandleFunc("/secret", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<meta property="og:title" content="Secret">`))
+	})
+	mux.HandleFunc("/file", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+	})
+	mux.HandleFunc("/bare", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<p>nothing here</p>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	testCases := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+		// is, if set, is the error wantErr must wrap
+		is error
+	}{
+		{name: "Page", path: "/page", want: "Page"},
+		{name: "Disallowed", path: "/secret", wantErr: true, is: ErrDisallowed},
+		{name: "Redirect to disallowed", path: "/moved", wantErr: true, is: ErrDisallowed},
+		{name: "Not HTML", path: "/file", wantErr: true},
+		{name: "No metadata", path: "/bare", wantErr: true, is: ErrNoMetadata},
+	}
+
+	s := New(srv.Client())
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			card, err := s.Fetch(context.Background(), srv.URL+tc.path)
+			if tc.wantErr {
+				if err == nil || tc.is != nil && !errors.Is(err, tc.is) {
+					t.Errorf("Fetch() error = %v, want %v", err, tc.is)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if card.Title != tc.want || card.URL != srv.URL+tc.path {
+				t.Errorf("Fetch() = %+v, want title %q", card, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package preview
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// robotsRule is one Allow or Disallow line of a robots.txt group.
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// robotsRules are the rules that apply to the scraper on one site. Nil allows everything.
+type robotsRules []robotsRule
+
+// disallowAll keeps the scraper off a whole site.
+var disallowAll = robotsRules{{pattern: "/", re: regexp.MustCompile(`^/`)}}
+
+// parseRobots returns the rules in a robots.txt file for agent, falling back
+// to the "*" group when no group names agent.
+func parseRobots(r io.Reader, agent string) robotsRules {
+	var specific, generic robotsRules
+	var named, matchesAgent, matchesAny, inRules bool
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if inRules {
+				matchesAgent, matchesAny, inRules = false, false, false
+			}
+			value = strings.ToLower(value)
+			if value == "*" {
+				matchesAny = true
+			} else if value == agent {
+				matchesAgent, named = true, true
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				// An empty Disallow allows everything
+				continue
+			}
+			rule := robotsRule{allow: key == "allow", pattern: value, re: robotsPattern(value)}
+			if matchesAgent {
+				specific = append(specific, rule)
+			}
+			if matchesAny {
+				generic = append(generic, rule)
+			}
+		}
+	}
+
+	if named {
+		return specific
+	}
+	return generic
+}
+
+// robotsPattern compiles a robots.txt path pattern, where * matches anything
+// and a trailing $ anchors the end of the path.
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed reports whether the scraper may fetch path. The longest matching
+// rule wins, and Allow wins a tie.
+func (rules robotsRules) allowed(path string) bool {
+	allow, longest := true, -1
+	for _, rule := range rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			allow, longest = rule.allow, n
+		}
+	}
+	return allow
+}
@@ -0,0 +1,96 @@
+// Package purge deletes what the bot has stored about a guild or a user, for
+// servers and members who ask for their data to be removed.
+//
+// Each part of the bot that stores records says how to forget them with a
+// Forgetter, and a purge saves the store once for all of them.
+package purge
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go-discord-bot/internal/stats"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/trends"
+)
+
+// Report counts the records removed from each bucket.
+type Report map[string]int
+
+// Total is how many records were removed across every bucket.
+func (r Report) Total() int {
+	total := 0
+	for _, n := range r {
+		total += n
+	}
+	return total
+}
+
+// String lists the buckets records were removed from, such as
+// "guild_config: 1, feeds: 2", or "nothing" if none were.
+func (r Report) String() string {
+	if len(r) == 0 {
+		return "nothing"
+	}
+	parts := make([]string, 0, len(r))
+	for _, bucket := range slices.Sorted(maps.Keys(r)) {
+		parts = append(parts, fmt.Sprintf("%s: %d", bucket, r[bucket]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Forgetter deletes from st what one part of the bot stored about userID in
+// guildID, or in every guild if guildID is empty, or with userID empty
+// everything it stored about guildID. It returns how many records it deleted.
+type Forgetter func(st storage.Store, guildID, userID string) (int, error)
+
+// Purger deletes guilds' and users' records with the forgetters it's given.
+type Purger struct {
+	Store storage.Store
+	// Forgetters forget the records in each bucket, by bucket name.
+	Forgetters map[string]Forgetter
+	// Stats and Trends have their counts not yet stored forgotten first, so
+	// they aren't stored again after the purge. Nil skips them.
+	Stats  *stats.Collector
+	Trends *trends.Counter
+}
+
+// Guild deletes every record belonging to guildID.
+func (p *Purger) Guild(guildID string) (Report, error) {
+	if guildID == "" {
+		return Report{}, nil
+	}
+	return p.forget(guildID, "")
+}
+
+// User deletes the records of what userID posted in guildID, or in every
+// guild if guildID is empty. Lists of records, such as a guild's deleted
+// messages, keep the entries of other users.
+func (p *Purger) User(guildID, userID string) (Report, error) {
+	if userID == "" {
+		return Report{}, nil
+	}
+	return p.forget(guildID, userID)
+}
+
+// forget runs every forgetter in one batch, bucket by bucket in order.
+func (p *Purger) forget(guildID, userID string) (Report, error) {
+	p.Stats.Forget(guildID, userID)
+	p.Trends.Forget(guildID, userID)
+	report := Report{}
+	err := p.Store.Batch(func(tx storage.Store) error {
+		for _, bucket := range slices.Sorted(maps.Keys(p.Forgetters)) {
+			n, err := p.Forgetters[bucket](tx, guildID, userID)
+			if n > 0 {
+				report[bucket] += n
+			}
+			if err != nil {
+				return fmt.Errorf("forgetting %s: %w", bucket, err)
+			}
+		}
+		return nil
+	})
+	return report, err
+}
@@ -0,0 +1,132 @@
+package purge
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/feeds"
+	"go-discord-bot/internal/stats"
+	"go-discord-bot/internal/storage"
+	"go-discord-bot/internal/trash"
+)
+
+// seed stores settings, feeds, deleted messages and daily stats for guilds
+// "1" and "2", and returns a purger forgetting them.
+func seed(t *testing.T) (*storage.FileStore, *Purger) {
+	t.Helper()
+	st := storage.NewMemory()
+	puts := []struct {
+		bucket, key string
+		value       any
+	}{
+		{config.GuildBucket, "1", config.Guild{}},
+		{config.GuildBucket, "2", config.Guild{}},
+		{feeds.Bucket, "1/feed", feeds.Subscription{GuildID: "1"}},
+		{feeds.Bucket, "10/feed", feeds.Subscription{GuildID: "10"}},
+		{trash.Bucket, "1", []trash.Message{{AuthorID: "alice"}, {AuthorID: "bob"}}},
+		{trash.Bucket, "2", []trash.Message{{AuthorID: "alice"}}},
+		{stats.Bucket, "1", map[string]stats.Day{"2024-05-01": {Users: map[string]int{"alice": 1, "bob": 1}}}},
+		{stats.Bucket, "2", map[string]stats.Day{"2024-05-01": {Users: map[string]int{"alice": 1}}}},
+	}
+	for _, p := range puts {
+		if err := st.Put(p.bucket, p.key, p.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return st, &Purger{Store: st, Forgetters: map[string]Forgetter{
+		config.GuildBucket: config.Forget,
+		feeds.Bucket:       feeds.Forget,
+		trash.Bucket:       trash.Forget,
+		stats.Bucket:       stats.Forget,
+	}}
+}
+
+func TestGuild(t *testing.T) {
+	st, p := seed(t)
+	p.Stats = stats.New()
+	p.Stats.RecordRepost("1", "alice", []string{"https://x.com/a/status/1"})
+
+	report, err := p.Guild("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Report{config.GuildBucket: 1, feeds.Bucket: 1, trash.Bucket: 2, stats.Bucket: 1}
+	if report.String() != expected.String() {
+		t.Errorf("Guild(1) = %s; want %s", report, expected)
+	}
+	for bucket, keys := range map[string][]string{config.GuildBucket: {"2"}, feeds.Bucket: {"10/feed"}, trash.Bucket: {"2"}, stats.Bucket: {"2"}} {
+		if got := st.Keys(bucket); !slices.Equal(got, keys) {
+			t.Errorf("%s keys = %v; want %v", bucket, got, keys)
+		}
+	}
+	if p.Stats.Guild("1").Reposts != 0 {
+		t.Error("guild 1's stats weren't forgotten")
+	}
+}
+
+func TestUser(t *testing.T) {
+	testCases := []struct {
+		name     string
+		guildID  string
+		expected Report
+		// deleted maps the keys left in the deleted messages bucket to their
+		// authors, and users those of the stats bucket to the users counted.
+		deleted map[string][]string
+		users   map[string][]string
+	}{
+		{
+			name:     "One guild",
+			guildID:  "1",
+			expected: Report{trash.Bucket: 1, stats.Bucket: 1},
+			deleted:  map[string][]string{"1": {"bob"}, "2": {"alice"}},
+			users:    map[string][]string{"1": {"bob"}, "2": {"alice"}},
+		},
+		{
+			name:     "Every guild",
+			expected: Report{trash.Bucket: 2, stats.Bucket: 2},
+			deleted:  map[string][]string{"1": {"bob"}},
+			users:    map[string][]string{"1": {"bob"}, "2": nil},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st, p := seed(t)
+			report, err := p.User(tc.guildID, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.String() != tc.expected.String() {
+				t.Errorf("User(%q, alice) = %s; want %s", tc.guildID, report, tc.expected)
+			}
+			deleted := map[string][]string{}
+			for _, key := range st.Keys(trash.Bucket) {
+				var kept []trash.Message
+				st.Get(trash.Bucket, key, &kept)
+				for _, m := range kept {
+					deleted[key] = append(deleted[key], m.AuthorID)
+				}
+			}
+			if fmt.Sprint(deleted) != fmt.Sprint(tc.deleted) {
+				t.Errorf("deleted messages by author = %v; want %v", deleted, tc.deleted)
+			}
+			users := map[string][]string{}
+			for _, key := range st.Keys(stats.Bucket) {
+				var days map[string]stats.Day
+				st.Get(stats.Bucket, key, &days)
+				users[key] = nil
+				for user := range days["2024-05-01"].Users {
+					users[key] = append(users[key], user)
+				}
+			}
+			if fmt.Sprint(users) != fmt.Sprint(tc.users) {
+				t.Errorf("users counted = %v; want %v", users, tc.users)
+			}
+			if len(st.Keys(config.GuildBucket)) != 2 {
+				t.Error("settings were deleted for a user")
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer answers the commands the client sends from an in-memory map,
+// without expiring keys.
+type fakeServer struct {
+	username string
+	password string
+
+	mu   sync.Mutex
+	keys map[string]string
+	ttls map[string]string
+}
+
+func (f *fakeServer) serve(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(c)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (f *fakeServer) handle(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	authed := f.password == ""
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]any) {
+			args = append(args, arg.(string))
+		}
+		if args[0] == "AUTH" {
+			// AUTH takes a password, or a user name and password
+			user, password := "", args[len(args)-1]
+			if len(args) == 3 {
+				user = args[1]
+			}
+			authed = user == f.username && password == f.password
+		}
+		if !authed {
+			fmt.Fprint(c, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		fmt.Fprint(c, f.run(args))
+	}
+}
+
+func (f *fakeServer) run(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch args[0] {
+	case "PING":
+		return "+PONG\r\n"
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.keys[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "SET":
+		if _, ok := f.keys[args[1]]; ok && args[3] == "NX" {
+			return "$-1\r\n"
+		}
+		f.keys[args[1]] = args[2]
+		f.ttls[args[1]] = args[len(args)-1]
+		return "+OK\r\n"
+	case "EVAL":
+		// Only the client's incrScript, with its key and TTL
+		if args[1] != incrScript {
+			return "-ERR unknown script\r\n"
+		}
+		key := args[3]
+		if _, ok := f.keys[key]; !ok {
+			f.keys[key], f.ttls[key] = "0", args[4]
+		}
+		n, _ := strconv.Atoi(f.keys[key])
+		f.keys[key] = strconv.Itoa(n + 1)
+		return fmt.Sprintf(":%d\r\n", n+1)
+	}
+	return "-ERR unknown command '" + strings.ToLower(args[0]) + "'\r\n"
+}
+
+// stored returns a key's value and TTL as the client set them.
+func (f *fakeServer) stored(key string) (string, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.keys[key], f.ttls[key]
+}
+
+func TestClient(t *testing.T) {
+	server := &fakeServer{password: "secret", keys: make(map[string]string), ttls: make(map[string]string)}
+	addr := server.serve(t)
+	client, err := New("redis://:secret@" + addr + "/2")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer client.Close()
+	c := client.WithPrefix("bot:")
+	ctx := context.Background()
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if _, ok, err := c.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Get of a missing key = %v, %v; want not found", ok, err)
+	}
+	if err := c.Set(ctx, "repost", "value", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, ok, err := c.Get(ctx, "repost"); v != "value" || !ok || err != nil {
+		t.Errorf("Get = %q, %v, %v; want value", v, ok, err)
+	}
+	if v, ttl := server.stored("bot:repost"); v != "value" || ttl != "60000" {
+		t.Errorf("server has bot:repost = %q for %qms; want value for 60000ms", v, ttl)
+	}
+
+	for i, expected := range []bool{true, false} {
+		if set, err := c.SetNX(ctx, "claim", "replica", time.Minute); set != expected || err != nil {
+			t.Errorf("SetNX #%d = %v, %v; want %v", i+1, set, err, expected)
+		}
+	}
+	for i := int64(1); i <= 2; i++ {
+		if n, err := c.Incr(ctx, "count", time.Second); n != i || err != nil {
+			t.Errorf("Incr = %d, %v; want %d", n, err, i)
+		}
+	}
+	if _, ttl := server.stored("bot:count"); ttl != "1000" {
+		t.Errorf("count TTL = %q; want 1000", ttl)
+	}
+
+	if _, err := c.do(ctx, "NOPE"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("unknown command error = %v", err)
+	}
+	// The connection survives errors Redis replies with
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("Ping after an error reply: %v", err)
+	}
+
+	wrong, _ := New("redis://:wrong@" + addr)
+	if err := wrong.Ping(ctx); err == nil {
+		t.Error("Ping with the wrong password succeeded")
+	}
+}
+
+func TestClientACLUser(t *testing.T) {
+	server := &fakeServer{username: "bot", password: "secret", keys: make(map[string]string), ttls: make(map[string]string)}
+	addr := server.serve(t)
+	ctx := context.Background()
+
+	client, _ := New("redis://bot:secret@" + addr)
+	defer client.Close()
+	if err := client.Ping(ctx); err != nil {
+		t.Errorf("Ping as the ACL user: %v", err)
+	}
+	other, _ := New("redis://:secret@" + addr)
+	defer other.Close()
+	if err := other.Ping(ctx); err == nil {
+		t.Error("Ping as the default user succeeded")
+	}
+}
+
+func TestReadReplyErrorInArray(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("*3\r\n-ERR first\r\n:2\r\n*1\r\n-ERR nested\r\n+NEXT\r\n"))
+	if _, err := readReply(r); err == nil || err.Error() != "redis: ERR first" {
+		t.Errorf("array with errors read as %v; want its first error", err)
+	}
+	// The whole array was read, leaving the next reply
+	if reply, err := readReply(r); reply != "NEXT" || err != nil {
+		t.Errorf("next reply = %v, %v; want NEXT", reply, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		url      string
+		addr     string
+		db       int
+		username string
+		password string
+		valid    bool
+	}{
+		{url: "redis://localhost", addr: "localhost:6379", valid: true},
+		{url: "redis://:pw@cache:6380/3", addr: "cache:6380", db: 3, password: "pw", valid: true},
+		{url: "redis://bot:pw@cache", addr: "cache:6379", username: "bot", password: "pw", valid: true},
+		{url: "http://localhost"},
+		{url: "redis://localhost/db"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			c, err := New(tc.url)
+			if (err == nil) != tc.valid {
+				t.Fatalf("New(%q) error = %v; want valid %v", tc.url, err, tc.valid)
+			}
+			if err == nil && (c.pool.addr != tc.addr || c.pool.db != tc.db || c.pool.username != tc.username || c.pool.password != tc.password) {
+				t.Errorf("New(%q) = %+v", tc.url, c.pool)
+			}
+		})
+	}
+}
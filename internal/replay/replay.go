@@ -0,0 +1,144 @@
+// Package replay runs captured gateway payloads through the message handler
+// against a mock Discord and reports what the bot decided to do, so a bug
+// report like "this link wasn't fixed" can become a regression fixture.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/config"
+	"go-discord-bot/internal/handlers"
+	"go-discord-bot/internal/mockdiscord"
+	"go-discord-bot/internal/storage"
+)
+
+// settle is how long Run waits for work started by the last event, on top of
+// the preview delay.
+const settle = 250 * time.Millisecond
+
+// Fixture is a captured sequence of gateway events, with the guild settings
+// they ran under and, for regression fixtures, what the bot should do.
+type Fixture struct {
+	Description string `json:"description,omitempty"`
+	// Guilds holds the settings of the guilds in the events, by guild ID.
+	// Guilds left out use the defaults.
+	Guilds map[string]config.Guild `json:"guilds,omitempty"`
+	Events []mockdiscord.Event     `json:"events"`
+	// Expected lists the decisions Run should report, in order. Nil means
+	// the fixture has no expectations; an empty list expects no action.
+	Expected []string `json:"expected"`
+}
+
+// Load reads a fixture from a JSON file.
+func Load(path string) (Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, err
+	}
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Fixture{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Store returns an in-memory store holding the fixture's guild settings.
+func (f Fixture) Store() (storage.Store, error) {
+	st := storage.NewMemory()
+	for guildID, cfg := range f.Guilds {
+		if err := config.SaveGuild(st, guildID, cfg); err != nil {
+			return nil, fmt.Errorf("saving settings for guild %s: %w", guildID, err)
+		}
+	}
+	return st, nil
+}
+
+// Run replays the fixture's events to h over a mock Discord and returns the
+// bot's decisions, one line per message sent, edited or deleted. It waits for
+// delayed work such as previews and stops h.Pool before returning.
+func Run(ctx context.Context, h *handlers.Handler, f Fixture) ([]string, error) {
+	srv := mockdiscord.New()
+	defer srv.Close()
+	for _, e := range f.Events {
+		if e.Type != "MESSAGE_CREATE" {
+			continue
+		}
+		var m discordgo.Message
+		if err := json.Unmarshal(e.Data, &m); err != nil {
+			return nil, fmt.Errorf("parsing MESSAGE_CREATE: %w", err)
+		}
+		srv.AddChannel(&discordgo.Channel{ID: m.ChannelID, GuildID: m.GuildID, Type: discordgo.ChannelTypeGuildText})
+	}
+
+	s, err := srv.Session()
+	if err != nil {
+		return nil, err
+	}
+	s.AddHandler(h.MessageCreateEvent)
+	if err := s.Open(); err != nil {
+		return nil, fmt.Errorf("connecting to the mock gateway: %w", err)
+	}
+	defer s.Close()
+
+	if err := srv.Replay(ctx, f.Events); err != nil {
+		return nil, err
+	}
+	delay := h.PreviewDelay
+	if delay <= 0 {
+		delay = handlers.DefaultPreviewDelay
+	}
+	select {
+	case <-time.After(delay + settle):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	h.Pool.Stop()
+
+	return decisions(srv.Requests(), srv.Sent()), nil
+}
+
+// decisions describes the REST calls that changed something, pairing sends
+// with the messages they created.
+func decisions(requests []string, sent []*discordgo.Message) []string {
+	lines := []string{}
+	for _, req := range requests {
+		method, path, _ := strings.Cut(req, " ")
+		switch {
+		case method == "GET":
+		case method == "POST" && strings.HasSuffix(path, "/messages") && len(sent) > 0:
+			lines = append(lines, describe(sent[0]))
+			sent = sent[1:]
+		case method == "PATCH" && strings.Contains(path, "/messages/"):
+			lines = append(lines, "edit "+path[strings.LastIndex(path, "/")+1:])
+		case method == "DELETE" && strings.Contains(path, "/messages/"):
+			lines = append(lines, "delete "+path[strings.LastIndex(path, "/")+1:])
+		default:
+			lines = append(lines, req)
+		}
+	}
+	return lines
+}
+
+// describe summarises a message the bot sent.
+func describe(m *discordgo.Message) string {
+	var b strings.Builder
+	if m.MessageReference != nil && m.MessageReference.MessageID != "" {
+		fmt.Fprintf(&b, "reply to %s:", m.MessageReference.MessageID)
+	} else {
+		fmt.Fprintf(&b, "send in %s:", m.ChannelID)
+	}
+	if m.Content != "" {
+		b.WriteString(" " + m.Content)
+	}
+	for _, e := range m.Embeds {
+		fmt.Fprintf(&b, " [embed %q]", e.Title)
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package replay
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"go-discord-bot/internal/fixers"
+	"go-discord-bot/internal/handlers"
+	"go-discord-bot/internal/preview"
+	"go-discord-bot/internal/workerpool"
+)
+
+// TestCorpus replays every fixture in testdata and checks the bot still makes
+// the decisions it expects.
+func TestCorpus(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no fixtures found: %v", err)
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			t.Parallel()
+			f, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Expected == nil {
+				t.Fatal("fixture has no expected decisions")
+			}
+			st, err := f.Store()
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := &handlers.Handler{
+				Fixers:       fixers.Pipeline{fixers.Twitter{}, fixers.Custom{Store: st}, fixers.Cleaner{}},
+				Pool:         workerpool.New(1, 10),
+				Store:        st,
+				Previews:     preview.New(nil),
+				PreviewDelay: 100 * time.Millisecond,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			got, err := Run(ctx, h, f)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if !slices.Equal(got, f.Expected) {
+				t.Errorf("%s\ndecisions %q; want %q", f.Description, got, f.Expected)
+			}
+		})
+	}
+}
+
+func TestDecisions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		requests []string
+		expected []string
+	}{
+		{name: "Nothing", requests: []string{"GET /channels/1/messages/2"}, expected: []string{}},
+		{name: "Edit and delete", requests: []string{"PATCH /channels/1/messages/2", "DELETE /channels/1/messages/3"}, expected: []string{"edit 2", "delete 3"}},
+		{name: "Other calls", requests: []string{"PUT /channels/1/messages/2/reactions/x/@me"}, expected: []string{"PUT /channels/1/messages/2/reactions/x/@me"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := decisions(tc.requests, nil); !slices.Equal(got, tc.expected) {
+				t.Errorf("decisions = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
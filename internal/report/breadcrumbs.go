@@ -0,0 +1,88 @@
+package report
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultBreadcrumbs is how many breadcrumbs a message's trail keeps.
+const DefaultBreadcrumbs = 20
+
+// Crumb is one thing that happened while handling a message, such as a fixer
+// matching or an API answering.
+type Crumb struct {
+	At       time.Time
+	Category string
+	Message  string
+}
+
+// Breadcrumbs is a ring buffer of the latest crumbs left while handling one
+// message, reported with the errors that happen along the way. Its methods
+// may be called on a nil Breadcrumbs, which keeps nothing.
+type Breadcrumbs struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	crumbs []Crumb
+	// next is where the next crumb goes once crumbs is full.
+	next int
+}
+
+// NewBreadcrumbs returns a trail keeping the last size crumbs, or nil if size
+// isn't positive.
+func NewBreadcrumbs(size int) *Breadcrumbs {
+	if size <= 0 {
+		return nil
+	}
+	return &Breadcrumbs{now: time.Now, crumbs: make([]Crumb, 0, size)}
+}
+
+// Add leaves a crumb, forgetting the oldest one if the trail is full.
+func (b *Breadcrumbs) Add(category, message string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := Crumb{At: b.now(), Category: category, Message: message}
+	if len(b.crumbs) < cap(b.crumbs) {
+		b.crumbs = append(b.crumbs, c)
+		return
+	}
+	b.crumbs[b.next] = c
+	b.next = (b.next + 1) % len(b.crumbs)
+}
+
+// List returns the crumbs kept, oldest first.
+func (b *Breadcrumbs) List() []Crumb {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append(append([]Crumb(nil), b.crumbs[b.next:]...), b.crumbs[:b.next]...)
+}
+
+type breadcrumbsKey struct{}
+
+// WithBreadcrumbs returns a context carrying b, so the work it's passed to can
+// leave crumbs and errors reported from it come with them. A nil b leaves ctx
+// as it is.
+func WithBreadcrumbs(ctx context.Context, b *Breadcrumbs) context.Context {
+	if b == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, breadcrumbsKey{}, b)
+}
+
+// BreadcrumbsFrom returns the trail ctx carries, or nil.
+func BreadcrumbsFrom(ctx context.Context) *Breadcrumbs {
+	b, _ := ctx.Value(breadcrumbsKey{}).(*Breadcrumbs)
+	return b
+}
+
+// Leave adds a crumb to the trail ctx carries, if any.
+func Leave(ctx context.Context, category, message string) {
+	BreadcrumbsFrom(ctx).Add(category, message)
+}
@@ -0,0 +1,34 @@
+// Package report collects errors that operators should know about.
+package report
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"go-discord-bot/internal/correlation"
+)
+
+// Reporter receives failures that couldn't be handled automatically.
+// op names the operation that failed, such as "send fixed message".
+type Reporter interface {
+	Report(ctx context.Context, op string, err error)
+}
+
+// Log is a Reporter that writes errors to the standard logger, with the
+// correlation ID and followed by the breadcrumbs ctx carries.
+type Log struct{}
+
+// Report implements Reporter.
+func (Log) Report(ctx context.Context, op string, err error) {
+	var b strings.Builder
+	if id := correlation.From(ctx); id != "" {
+		fmt.Fprintf(&b, "correlation=%s ", id)
+	}
+	fmt.Fprintf(&b, "Error: %s: %v\n", op, err)
+	for _, c := range BreadcrumbsFrom(ctx).List() {
+		fmt.Fprintf(&b, "  %s [%s] %s\n", c.At.Format("15:04:05.000"), c.Category, c.Message)
+	}
+	log.Print(b.String())
+}
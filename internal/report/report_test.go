@@ -0,0 +1,48 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBreadcrumbs(t *testing.T) {
+	b := NewBreadcrumbs(3)
+	b.now = func() time.Time { return time.Date(2024, 8, 19, 12, 0, 0, 0, time.UTC) }
+	for _, message := range []string{"1", "2", "3", "4", "5"} {
+		b.Add("step", message)
+	}
+	var messages []string
+	for _, c := range b.List() {
+		messages = append(messages, c.Message)
+	}
+	if expected := []string{"3", "4", "5"}; !slices.Equal(messages, expected) {
+		t.Errorf("List = %q; want %q", messages, expected)
+	}
+
+	var buf bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	}()
+	Log{}.Report(WithBreadcrumbs(context.Background(), b), "send fixed message", errors.New("500 Internal Server Error"))
+	expected := "Error: send fixed message: 500 Internal Server Error\n  12:00:00.000 [step] 3\n  12:00:00.000 [step] 4\n  12:00:00.000 [step] 5\n"
+	if buf.String() != expected {
+		t.Errorf("logged %q; want %q", buf.String(), expected)
+	}
+
+	var off *Breadcrumbs
+	off.Add("step", "1")
+	Leave(context.Background(), "step", "1")
+	if crumbs := off.List(); crumbs != nil {
+		t.Errorf("nil List = %v; want nil", crumbs)
+	}
+}
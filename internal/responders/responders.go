@@ -0,0 +1,192 @@
+// Package responders matches messages against a guild's auto-responses, the
+// replies admins set up for messages with given words in them, and keeps each
+// from being posted again and again in a channel.
+package responders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/config"
+)
+
+// Limits on a guild's auto-responses. Go's regular expressions run in time
+// linear in the message, so capping a trigger's length caps what it costs.
+const (
+	// MaxResponses caps how many auto-responses a single guild can define.
+	MaxResponses = 25
+	// MaxTriggerLength caps the length of a trigger.
+	MaxTriggerLength = 300
+	// MaxResponseLength caps the length of a response.
+	MaxResponseLength = 1000
+)
+
+// DefaultCooldown is how long an auto-response waits before it's posted in a
+// channel again.
+const DefaultCooldown = 30 * time.Second
+
+// Greeting is the auto-response of the greeting module.
+var Greeting = config.AutoResponse{Trigger: "hello", Match: config.MatchExact, Response: "world!"}
+
+// compiled caches the patterns of regex triggers so they aren't recompiled for every message.
+var compiled sync.Map
+
+// Validate checks that an auto-response can be saved: its trigger and
+// response aren't empty or too long, and a regex trigger compiles and doesn't
+// match every message.
+func Validate(r config.AutoResponse) error {
+	if strings.TrimSpace(r.Trigger) == "" || strings.TrimSpace(r.Response) == "" {
+		return fmt.Errorf("the trigger and response must not be empty")
+	}
+	if len(r.Trigger) > MaxTriggerLength {
+		return fmt.Errorf("the trigger must be at most %d characters", MaxTriggerLength)
+	}
+	if len(r.Response) > MaxResponseLength {
+		return fmt.Errorf("the response must be at most %d characters", MaxResponseLength)
+	}
+	switch r.Match {
+	case "", config.MatchExact, config.MatchContains:
+	case config.MatchRegex:
+		re, err := regexp.Compile(r.Trigger)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		if re.MatchString("") {
+			return fmt.Errorf("the pattern must not match empty text")
+		}
+	default:
+		return fmt.Errorf("unknown match %q", r.Match)
+	}
+	return nil
+}
+
+// Match returns the first auto-response whose trigger matches content, and
+// whether there is one.
+func Match(responses []config.AutoResponse, content string) (config.AutoResponse, bool) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return config.AutoResponse{}, false
+	}
+	lower := strings.ToLower(content)
+	i := slices.IndexFunc(responses, func(r config.AutoResponse) bool {
+		switch r.Match {
+		case "", config.MatchExact:
+			return strings.EqualFold(content, strings.TrimSpace(r.Trigger))
+		case config.MatchContains:
+			return strings.Contains(lower, strings.ToLower(r.Trigger))
+		case config.MatchRegex:
+			re := compile(r.Trigger)
+			return re != nil && re.MatchString(content)
+		}
+		return false
+	})
+	if i < 0 {
+		return config.AutoResponse{}, false
+	}
+	return responses[i], true
+}
+
+// compile returns the cached compiled pattern of a regex trigger, or nil if
+// it's invalid.
+func compile(pattern string) *regexp.Regexp {
+	if re, ok := compiled.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	err := Validate(config.AutoResponse{Trigger: pattern, Match: config.MatchRegex, Response: "-"})
+	if err != nil {
+		log.Printf("Skipping invalid auto-response trigger %q: %v\n", pattern, err)
+		return nil
+	}
+	re := regexp.MustCompile(pattern)
+	compiled.Store(pattern, re)
+	return re
+}
+
+// sharedTimeout is how long a call to the Shared store may take.
+const sharedTimeout = 2 * time.Second
+
+// Shared is a store several replicas of the bot share, such as Redis, so an
+// auto-response one of them posted is cooling down in all of them.
+type Shared interface {
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+}
+
+// Cooldowns keeps auto-responses from being posted again in a channel until
+// their cooldown has passed. A nil Cooldowns never holds them back.
+type Cooldowns struct {
+	// Shared, if not nil, keeps the cooldowns for every replica instead.
+	// When it fails the cooldowns are kept here.
+	Shared Shared
+
+	period time.Duration
+	now    func() time.Time
+
+	mu sync.Mutex
+	// until maps a channel and trigger to when its cooldown ends.
+	until map[string]time.Time
+}
+
+// New returns Cooldowns that hold each auto-response back in a channel for
+// period after it's posted there.
+func New(period time.Duration) *Cooldowns {
+	return &Cooldowns{period: period, now: time.Now, until: make(map[string]time.Time)}
+}
+
+// Take reports whether r may be posted in channelID now, and if so starts its
+// cooldown there.
+func (c *Cooldowns) Take(channelID string, r config.AutoResponse) bool {
+	if c == nil {
+		return true
+	}
+	key := channelID + "/" + r.Match + "/" + r.Trigger
+	if c.Shared != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), sharedTimeout)
+		defer cancel()
+		taken, err := c.Shared.SetNX(ctx, "responders:cooldown:"+key, "1", c.period)
+		if err == nil {
+			return taken
+		}
+		log.Println("Error taking shared auto-response cooldown:", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if now.Before(c.until[key]) {
+		return false
+	}
+	c.until[key] = now.Add(c.period)
+	return true
+}
+
+// Prune forgets cooldowns that have ended and returns how many it forgot.
+func (c *Cooldowns) Prune(now time.Time) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pruned := 0
+	for key, until := range c.until {
+		if !now.Before(until) {
+			delete(c.until, key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many cooldowns are being tracked.
+func (c *Cooldowns) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.until)
+}
@@ -0,0 +1,134 @@
+package responders
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-discord-bot/internal/config"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		r       config.AutoResponse
+		wantErr bool
+	}{
+		{name: "Exact", r: config.AutoResponse{Trigger: "hi", Response: "hey"}},
+		{name: "Contains", r: config.AutoResponse{Trigger: "rules", Match: config.MatchContains, Response: "See #rules"}},
+		{name: "Regex", r: config.AutoResponse{Trigger: `^when (is|does) .+ stream`, Match: config.MatchRegex, Response: "Fridays"}},
+		{name: "Empty trigger", r: config.AutoResponse{Trigger: " ", Response: "hey"}, wantErr: true},
+		{name: "Empty response", r: config.AutoResponse{Trigger: "hi"}, wantErr: true},
+		{name: "Long trigger", r: config.AutoResponse{Trigger: strings.Repeat("a", MaxTriggerLength+1), Response: "hey"}, wantErr: true},
+		{name: "Long response", r: config.AutoResponse{Trigger: "hi", Response: strings.Repeat("a", MaxResponseLength+1)}, wantErr: true},
+		{name: "Invalid regex", r: config.AutoResponse{Trigger: "(", Match: config.MatchRegex, Response: "hey"}, wantErr: true},
+		{name: "Regex matching anything", r: config.AutoResponse{Trigger: "a*", Match: config.MatchRegex, Response: "hey"}, wantErr: true},
+		{name: "Unknown match", r: config.AutoResponse{Trigger: "hi", Match: "fuzzy", Response: "hey"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Validate(tc.r); (err != nil) != tc.wantErr {
+				t.Errorf("Validate(%+v) = %v; want error %v", tc.r, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	responses := []config.AutoResponse{
+		{Trigger: "hello", Response: "exact"},
+		{Trigger: "Rules", Match: config.MatchContains, Response: "contains"},
+		{Trigger: `\bping\b`, Match: config.MatchRegex, Response: "regex"},
+		{Trigger: "(", Match: config.MatchRegex, Response: "invalid"},
+	}
+	testCases := []struct {
+		content string
+		want    string
+	}{
+		{content: "hello", want: "exact"},
+		{content: " HELLO ", want: "exact"},
+		{content: "hello there"},
+		{content: "where are the rules?", want: "contains"},
+		{content: "ping me", want: "regex"},
+		{content: "pinging"},
+		{content: "("},
+		{content: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.content, func(t *testing.T) {
+			r, ok := Match(responses, tc.content)
+			if ok != (tc.want != "") || r.Response != tc.want {
+				t.Errorf("Match(%q) = %+v, %v; want %q", tc.content, r, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestCooldowns(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := New(time.Minute)
+	c.now = func() time.Time { return now }
+
+	if !c.Take("chan", Greeting) {
+		t.Fatal("first response held back")
+	}
+	if c.Take("chan", Greeting) {
+		t.Error("response posted again within its cooldown")
+	}
+	if !c.Take("other", Greeting) {
+		t.Error("response held back in another channel")
+	}
+	now = now.Add(time.Minute)
+	if !c.Take("chan", Greeting) {
+		t.Error("response held back after its cooldown")
+	}
+	if pruned := c.Prune(now.Add(time.Minute)); pruned != 2 || c.Len() != 0 {
+		t.Errorf("Prune = %d, leaving %d; want 2, leaving 0", pruned, c.Len())
+	}
+
+	var none *Cooldowns
+	if !none.Take("chan", Greeting) || !none.Take("chan", Greeting) {
+		t.Error("nil Cooldowns held a response back")
+	}
+}
+
+// memoryShared is a Shared store in memory that never expires keys.
+type memoryShared struct {
+	mu   sync.Mutex
+	keys map[string]bool
+	err  error
+}
+
+func (m *memoryShared) SetNX(_ context.Context, key, _ string, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil || m.keys[key] {
+		return false, m.err
+	}
+	m.keys[key] = true
+	return true, nil
+}
+
+func TestCooldownsShared(t *testing.T) {
+	shared := &memoryShared{keys: make(map[string]bool)}
+	first, second := New(time.Minute), New(time.Minute)
+	first.Shared, second.Shared = shared, shared
+
+	if !first.Take("chan", Greeting) {
+		t.Fatal("first response held back")
+	}
+	if second.Take("chan", Greeting) {
+		t.Error("other replica posted the response within its cooldown")
+	}
+
+	// Replicas go on alone while the store fails
+	shared.err = errors.New("down")
+	if !second.Take("chan", Greeting) {
+		t.Error("response held back while the store is down")
+	}
+}
@@ -0,0 +1,138 @@
+// Package retry retries transient Discord API failures with jittered exponential backoff.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/report"
+	"go-discord-bot/internal/tracing"
+)
+
+// Policy describes how often and how patiently to retry.
+type Policy struct {
+	// MaxAttempts is the total number of tries, including the first.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles each time.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts.
+	MaxDelay time.Duration
+	// Reporter receives failures that were permanent or ran out of attempts.
+	// Nil reports to the standard logger.
+	Reporter report.Reporter
+}
+
+// Default is a policy suited to sending chat messages.
+var Default = Policy{MaxAttempts: 4, BaseDelay: 500 * time.Millisecond, MaxDelay: 10 * time.Second}
+
+// Do calls fn until it succeeds, fails permanently, runs out of attempts, or ctx is done.
+func (p Policy) Do(ctx context.Context, op string, fn func() error) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	ctx, span := tracing.Start(ctx, op)
+	tries := 0
+	defer func() {
+		span.Set(tracing.Int("attempts", tries))
+		span.End()
+	}()
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		tries++
+		if err = fn(); err == nil {
+			report.Leave(ctx, op, "ok")
+			return nil
+		}
+		report.Leave(ctx, op, fmt.Sprintf("attempt %d failed: %v", tries, err))
+
+		transient, retryAfter := Classify(err)
+		if !transient || attempt == attempts-1 {
+			break
+		}
+
+		wait := p.backoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			err = fmt.Errorf("%w (gave up retrying: %v)", err, ctx.Err())
+			span.Fail(err)
+			p.report(ctx, op, err)
+			return err
+		case <-time.After(wait):
+		}
+	}
+
+	span.Fail(err)
+	p.report(ctx, op, err)
+	return err
+}
+
+// backoff returns a random delay in [0, min(MaxDelay, BaseDelay*2^attempt)).
+func (p Policy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay << attempt
+	if ceiling <= 0 || (p.MaxDelay > 0 && ceiling > p.MaxDelay) {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+func (p Policy) report(ctx context.Context, op string, err error) {
+	if p.Reporter == nil {
+		report.Log{}.Report(ctx, op, err)
+		return
+	}
+	p.Reporter.Report(ctx, op, err)
+}
+
+// Classify reports whether err is worth retrying and how long Discord asked us to wait.
+// Server errors, rate limits, and network failures are transient; other API errors
+// (missing permissions, unknown channel, ...) and cancellations are permanent.
+func Classify(err error) (transient bool, retryAfter time.Duration) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0
+	}
+
+	var rateLimit *discordgo.RateLimitError
+	if errors.As(err, &rateLimit) {
+		return true, rateLimit.RetryAfter
+	}
+
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		status := restErr.Response.StatusCode
+		if status == http.StatusTooManyRequests {
+			return true, parseRetryAfter(restErr.Response.Header.Get("Retry-After"))
+		}
+		return status >= 500, 0
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true, 0
+	}
+	return false, 0
+}
+
+// parseRetryAfter reads a Retry-After header given in (possibly fractional) seconds.
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
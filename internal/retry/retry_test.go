@@ -0,0 +1,115 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/report"
+)
+
+func restError(status int, header http.Header) error {
+	return &discordgo.RESTError{Response: &http.Response{StatusCode: status, Header: header}}
+}
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		transient  bool
+		retryAfter time.Duration
+	}{
+		{name: "Server error", err: restError(502, http.Header{}), transient: true},
+		{name: "Not found", err: restError(404, http.Header{}), transient: false},
+		{name: "Forbidden", err: restError(403, http.Header{}), transient: false},
+		{name: "Too many requests", err: restError(429, http.Header{"Retry-After": {"1.5"}}), transient: true, retryAfter: 1500 * time.Millisecond},
+		{name: "Rate limit error", err: &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{TooManyRequests: &discordgo.TooManyRequests{RetryAfter: time.Second}}}, transient: true, retryAfter: time.Second},
+		{name: "Network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, transient: true},
+		{name: "Cancelled", err: context.Canceled, transient: false},
+		{name: "Other error", err: errors.New("boom"), transient: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transient, retryAfter := Classify(tc.err)
+			if transient != tc.transient || retryAfter != tc.retryAfter {
+				t.Errorf("Classify(%v) = %v, %v; want %v, %v", tc.err, transient, retryAfter, tc.transient, tc.retryAfter)
+			}
+		})
+	}
+}
+
+type recordingReporter struct {
+	errs []error
+	// crumbs are the breadcrumbs the last error was reported with.
+	crumbs []report.Crumb
+}
+
+func (r *recordingReporter) Report(ctx context.Context, _ string, err error) {
+	r.errs = append(r.errs, err)
+	r.crumbs = report.BreadcrumbsFrom(ctx).List()
+}
+
+func TestDo(t *testing.T) {
+	transient := restError(500, http.Header{})
+	permanent := restError(403, http.Header{})
+
+	testCases := []struct {
+		name      string
+		results   []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "Success", results: []error{nil}, wantCalls: 1},
+		{name: "Recovers from transient errors", results: []error{transient, transient, nil}, wantCalls: 3},
+		{name: "Stops on permanent error", results: []error{transient, permanent, nil}, wantCalls: 2, wantErr: true},
+		{name: "Runs out of attempts", results: []error{transient, transient, transient, transient}, wantCalls: 3, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reporter := &recordingReporter{}
+			p := Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Reporter: reporter}
+
+			calls := 0
+			ctx := report.WithBreadcrumbs(context.Background(), report.NewBreadcrumbs(report.DefaultBreadcrumbs))
+			err := p.Do(ctx, "test", func() error {
+				calls++
+				return tc.results[calls-1]
+			})
+
+			if calls != tc.wantCalls {
+				t.Errorf("fn called %d times; want %d", calls, tc.wantCalls)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Do error = %v; wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr != (len(reporter.errs) == 1) {
+				t.Errorf("reported %d errors; want reported only on failure", len(reporter.errs))
+			}
+			if tc.wantErr && len(reporter.crumbs) != tc.wantCalls {
+				t.Errorf("reported with %d breadcrumbs; want one per attempt", len(reporter.crumbs))
+			}
+		})
+	}
+}
+
+func TestDoStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := Policy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	calls := 0
+	err := p.Do(ctx, "test", func() error {
+		calls++
+		cancel()
+		return restError(500, http.Header{})
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Do after cancel = %v with %d calls; want an error after 1 call", err, calls)
+	}
+}
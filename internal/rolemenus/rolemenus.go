@@ -0,0 +1,174 @@
+// Package rolemenus keeps the role menus admins post: messages with buttons
+// or a select menu that members use to give themselves roles and take them
+// away again.
+package rolemenus
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding role menus, keyed by guild and message ID.
+const Bucket = "role_menus"
+
+// MaxRoles is how many roles a menu can offer: Discord's limit on both the
+// buttons on a message and the options of a select menu.
+const MaxRoles = 25
+
+// storeMu serializes read-modify-write updates to menus.
+var storeMu sync.Mutex
+
+// Role is a role a menu offers, with the name it's labelled with.
+type Role struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Menu is a posted role menu.
+type Menu struct {
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+	Title     string `json:"title"`
+	// Select offers the roles in a select menu rather than as buttons.
+	Select bool   `json:"select,omitempty"`
+	Roles  []Role `json:"roles"`
+}
+
+// key is where a menu is stored.
+func key(guildID, messageID string) string {
+	return guildID + "/" + messageID
+}
+
+// Get returns the menu posted as messageID in a guild, and whether there is one.
+func Get(st storage.Store, guildID, messageID string) (Menu, bool, error) {
+	var m Menu
+	ok, err := st.Get(Bucket, key(guildID, messageID), &m)
+	return m, ok, err
+}
+
+// List returns a guild's menus, oldest first.
+func List(st storage.Store, guildID string) ([]Menu, error) {
+	var menus []Menu
+	for _, k := range st.Keys(Bucket) {
+		if !strings.HasPrefix(k, guildID+"/") {
+			continue
+		}
+		var m Menu
+		if _, err := st.Get(Bucket, k, &m); err != nil {
+			return nil, err
+		}
+		menus = append(menus, m)
+	}
+	// Snowflakes sort by age once they're the same length
+	slices.SortFunc(menus, func(a, b Menu) int {
+		if len(a.MessageID) != len(b.MessageID) {
+			return len(a.MessageID) - len(b.MessageID)
+		}
+		return strings.Compare(a.MessageID, b.MessageID)
+	})
+	return menus, nil
+}
+
+// Forget deletes guildID's role menus from st, for purges, when userID is
+// empty, and returns how many it deleted. They hold nothing about members.
+func Forget(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" || userID != "" {
+		return 0, nil
+	}
+	deleted := 0
+	for _, k := range st.Keys(Bucket) {
+		if !strings.HasPrefix(k, guildID+"/") {
+			continue
+		}
+		if err := st.Delete(Bucket, k); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Save adds or replaces a menu.
+func Save(st storage.Store, m Menu) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	return st.Put(Bucket, key(m.GuildID, m.MessageID), m)
+}
+
+// Update loads a guild's menu, applies change to it and saves it. It reports
+// whether the menu exists; change returning an error leaves it as it was.
+func Update(st storage.Store, guildID, messageID string, change func(m *Menu) error) (Menu, bool, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	m, ok, err := Get(st, guildID, messageID)
+	if err != nil || !ok {
+		return m, ok, err
+	}
+	if err := change(&m); err != nil {
+		return m, true, err
+	}
+	return m, true, st.Put(Bucket, key(guildID, messageID), m)
+}
+
+// Remove deletes a guild's menu and reports whether it existed.
+func Remove(st storage.Store, guildID, messageID string) (bool, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	ok, err := st.Get(Bucket, key(guildID, messageID), &Menu{})
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, st.Delete(Bucket, key(guildID, messageID))
+}
+
+// Has reports whether the menu offers roleID.
+func (m Menu) Has(roleID string) bool {
+	return slices.ContainsFunc(m.Roles, func(r Role) bool { return r.ID == roleID })
+}
+
+// Add offers r in the menu too.
+func (m *Menu) Add(r Role) error {
+	if m.Has(r.ID) {
+		return fmt.Errorf("it already offers %s", r.Name)
+	}
+	if len(m.Roles) >= MaxRoles {
+		return fmt.Errorf("it already offers %d roles, the most it can", MaxRoles)
+	}
+	m.Roles = append(m.Roles, r)
+	return nil
+}
+
+// Drop stops offering roleID in the menu. A menu can't be left with no roles.
+func (m *Menu) Drop(roleID string) error {
+	if !m.Has(roleID) {
+		return errors.New("it doesn't offer that role")
+	}
+	if len(m.Roles) == 1 {
+		return errors.New("it's the menu's only role, delete the menu instead")
+	}
+	m.Roles = slices.DeleteFunc(m.Roles, func(r Role) bool { return r.ID == roleID })
+	return nil
+}
+
+// Changes returns the roles to give and take away from a member who holds the
+// roles held and picked the roles picked in the menu's select menu: the
+// picked ones they don't hold yet, and the menu's other ones they do. Roles
+// the menu doesn't offer are ignored.
+func (m Menu) Changes(held, picked []string) (give, take []string) {
+	for _, r := range m.Roles {
+		has, wants := slices.Contains(held, r.ID), slices.Contains(picked, r.ID)
+		switch {
+		case wants && !has:
+			give = append(give, r.ID)
+		case !wants && has:
+			take = append(take, r.ID)
+		}
+	}
+	return give, take
+}
@@ -0,0 +1,87 @@
+package rolemenus
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"go-discord-bot/internal/storage"
+)
+
+func TestStore(t *testing.T) {
+	st := storage.NewMemory()
+	for _, m := range []Menu{
+		{GuildID: "g", MessageID: "20", Title: "Newer", Roles: []Role{{ID: "a", Name: "A"}}},
+		{GuildID: "g", MessageID: "3", Title: "Older", Roles: []Role{{ID: "b", Name: "B"}}},
+		{GuildID: "other", MessageID: "1", Title: "Elsewhere"},
+	} {
+		if err := Save(st, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	menus, err := List(st, "g")
+	if err != nil || len(menus) != 2 || menus[0].Title != "Older" || menus[1].Title != "Newer" {
+		t.Fatalf("List = %+v, %v; want Older then Newer", menus, err)
+	}
+
+	m, ok, err := Update(st, "g", "3", func(m *Menu) error { return m.Add(Role{ID: "c", Name: "C"}) })
+	if err != nil || !ok || len(m.Roles) != 2 {
+		t.Errorf("Update = %+v, %v, %v; want two roles", m, ok, err)
+	}
+	if _, _, err := Update(st, "g", "3", func(m *Menu) error { return m.Add(Role{ID: "c", Name: "C"}) }); err == nil {
+		t.Error("adding a role twice succeeded")
+	}
+	if _, ok, _ := Update(st, "g", "missing", func(*Menu) error { return errors.New("ran") }); ok {
+		t.Error("updated a missing menu")
+	}
+	if m, _, _ := Get(st, "g", "3"); len(m.Roles) != 2 {
+		t.Errorf("saved menu has roles %+v; want two", m.Roles)
+	}
+
+	if removed, err := Remove(st, "g", "3"); !removed || err != nil {
+		t.Errorf("Remove = %v, %v; want true", removed, err)
+	}
+	if removed, _ := Remove(st, "g", "3"); removed {
+		t.Error("removed a menu twice")
+	}
+}
+
+func TestDrop(t *testing.T) {
+	m := Menu{Roles: []Role{{ID: "a"}, {ID: "b"}}}
+	if err := m.Drop("c"); err == nil {
+		t.Error("dropped a role the menu doesn't offer")
+	}
+	if err := m.Drop("a"); err != nil || m.Has("a") {
+		t.Errorf("Drop(a) = %v, roles %+v", err, m.Roles)
+	}
+	if err := m.Drop("b"); err == nil {
+		t.Error("dropped the menu's only role")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	m := Menu{Roles: []Role{{ID: "a"}, {ID: "b"}, {ID: "c"}}}
+	testCases := []struct {
+		name   string
+		held   []string
+		picked []string
+		give   []string
+		take   []string
+	}{
+		{name: "Nothing", held: []string{"other"}},
+		{name: "Pick", held: []string{"other"}, picked: []string{"a", "c"}, give: []string{"a", "c"}},
+		{name: "Swap", held: []string{"a", "other"}, picked: []string{"b"}, give: []string{"b"}, take: []string{"a"}},
+		{name: "Keep", held: []string{"a"}, picked: []string{"a"}},
+		{name: "Foreign pick", picked: []string{"admin"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			give, take := m.Changes(tc.held, tc.picked)
+			if !slices.Equal(give, tc.give) || !slices.Equal(take, tc.take) {
+				t.Errorf("Changes(%v, %v) = %v, %v; want %v, %v", tc.held, tc.picked, give, take, tc.give, tc.take)
+			}
+		})
+	}
+}
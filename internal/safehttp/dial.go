@@ -0,0 +1,136 @@
+// Package safehttp fetches links that come from users, such as links posted in
+// chat or tweet media, without letting them reach the bot's own network or
+// wear it out: only public addresses are dialled, redirects are held to the
+// same rules, and bodies are checked for size and type.
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go-discord-bot/internal/logging"
+)
+
+// ErrBlockedAddress is returned when a link resolves to an address that won't
+// be connected to, such as one on a private network.
+var ErrBlockedAddress = errors.New("address not allowed")
+
+// blockedPrefixes are reserved ranges not covered by the netip.Addr checks in publicAddr.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// Client returns an HTTP client for fetching user-supplied links. It only
+// connects to public addresses on ports 80 and 443, checked after DNS
+// resolution and again for every redirect, so a hostname can't point it at
+// the bot's own network, and ignores proxy settings from the environment.
+func Client(timeout time.Duration) *http.Client {
+	return ClientVia(timeout, nil)
+}
+
+// ClientVia is Client sending requests through the proxy via, or connecting
+// directly if via is nil.
+func ClientVia(timeout time.Duration, via *url.URL) *http.Client {
+	transport := Transport(via)
+	transport.ResponseHeaderTimeout = timeout
+	return &http.Client{Timeout: timeout, Transport: logging.Transport(transport)}
+}
+
+// Transport returns a transport held to the same rules as Client, sending
+// requests through the proxy via or connecting directly if via is nil. The
+// proxy resolves hostnames itself, so they're resolved and checked before each
+// request is handed to it instead; a host whose DNS changes in between could
+// still get through.
+func Transport(via *url.URL) *http.Transport {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: checkAddress}
+	transport := &http.Transport{
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+	}
+	if via != nil {
+		// The proxy is the operator's choice, so only the destination is checked
+		dialer.Control = nil
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if err := checkDestination(req.Context(), req.URL); err != nil {
+				return nil, err
+			}
+			return via, nil
+		}
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// checkAddress is a net.Dialer Control function rejecting non-public addresses.
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !allowed(addrPort) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// checkDestination resolves u's host and rejects it unless every address it
+// has is allowed.
+func checkDestination(ctx context.Context, u *url.URL) error {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return ErrBlockedAddress
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !allowed(netip.AddrPortFrom(addr, uint16(n))) {
+			return ErrBlockedAddress
+		}
+	}
+	return nil
+}
+
+// allowed reports whether addrPort is a public address on port 80 or 443.
+func allowed(addrPort netip.AddrPort) bool {
+	if port := addrPort.Port(); port != 80 && port != 443 {
+		return false
+	}
+	return publicAddr(addrPort.Addr())
+}
+
+// publicAddr reports whether addr is a public unicast address.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
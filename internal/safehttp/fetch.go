@@ -0,0 +1,153 @@
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTooLarge is returned for bodies bigger than a Fetcher's MaxSize.
+	ErrTooLarge = errors.New("response too large")
+	// ErrContentType is returned for bodies of a type a Fetcher doesn't accept.
+	ErrContentType = errors.New("unexpected content type")
+)
+
+// defaultClient is used by Fetchers without a Client of their own.
+var defaultClient = Client(0)
+
+// Fetcher downloads user-supplied links within limits. The zero value reads
+// bodies of any size and type and follows no redirects.
+type Fetcher struct {
+	// Client makes the requests. Nil uses one from Client; pass your own only
+	// in tests.
+	Client *http.Client
+	// Timeout bounds a whole fetch, body included, 0 for no limit.
+	Timeout time.Duration
+	// MaxSize is how much of a body is read, 0 for no limit. Bigger bodies
+	// fail with ErrTooLarge, or are cut short if Truncate is set.
+	MaxSize  int64
+	Truncate bool
+	// Types are the media types accepted, such as "text/html" or "image/*",
+	// and sent as the Accept header. Empty accepts any type.
+	Types []string
+	// MaxRedirects is how many redirects are followed.
+	MaxRedirects int
+	// CheckRedirect, if set, vets each redirect on top of the usual rules,
+	// such as against robots.txt.
+	CheckRedirect func(req *http.Request) error
+	// UserAgent identifies the bot to the sites it fetches from.
+	UserAgent string
+}
+
+// Response is a fetched body.
+type Response struct {
+	// URL is where the body came from, after redirects.
+	URL *url.URL
+	// ContentType is the body's media type, without parameters.
+	ContentType string
+	Body        []byte
+}
+
+// Get fetches link, which must be an http or https URL.
+func (f Fetcher) Get(ctx context.Context, link string) (*Response, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	if !WebURL(u) {
+		return nil, fmt.Errorf("unsupported link %q", link)
+	}
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	client := defaultClient
+	if f.Client != nil {
+		client = f.Client
+	}
+	// Redirects are held to the same rules as the link itself
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > f.MaxRedirects {
+			return errors.New("too many redirects")
+		}
+		if !WebURL(req.URL) {
+			return fmt.Errorf("redirect to unsupported link %q", req.URL)
+		}
+		if f.CheckRedirect != nil {
+			return f.CheckRedirect(req)
+		}
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+	if len(f.Types) > 0 {
+		req.Header.Set("Accept", strings.Join(f.Types, ", "))
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", u.Host, resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !f.accepts(mediaType) {
+		return nil, fmt.Errorf("fetching %s: %w %q", u.Host, ErrContentType, mediaType)
+	}
+	if f.MaxSize > 0 && resp.ContentLength > f.MaxSize && !f.Truncate {
+		return nil, fmt.Errorf("fetching %s: %w", u.Host, ErrTooLarge)
+	}
+
+	body := io.Reader(resp.Body)
+	if f.MaxSize > 0 {
+		body = io.LimitReader(resp.Body, f.MaxSize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if f.MaxSize > 0 && int64(len(data)) > f.MaxSize {
+		if !f.Truncate {
+			return nil, fmt.Errorf("fetching %s: %w", u.Host, ErrTooLarge)
+		}
+		data = data[:f.MaxSize]
+	}
+	return &Response{URL: resp.Request.URL, ContentType: mediaType, Body: data}, nil
+}
+
+// accepts reports whether bodies of mediaType are wanted.
+func (f Fetcher) accepts(mediaType string) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, pattern := range f.Types {
+		if ok, _ := path.Match(pattern, mediaType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WebURL reports whether u is an absolute http(s) URL without credentials.
+func WebURL(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil
+}
@@ -0,0 +1,138 @@
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckAddress(t *testing.T) {
+	testCases := []struct {
+		address string
+		allowed bool
+	}{
+		{address: "93.184.216.34:443", allowed: true},
+		{address: "93.184.216.34:80", allowed: true},
+		{address: "93.184.216.34:8080", allowed: false},
+		{address: "127.0.0.1:80", allowed: false},
+		{address: "10.1.2.3:443", allowed: false},
+		{address: "192.168.0.1:80", allowed: false},
+		{address: "169.254.169.254:80", allowed: false},
+		{address: "100.64.0.1:80", allowed: false},
+		{address: "0.0.0.0:80", allowed: false},
+		{address: "[::1]:443", allowed: false},
+		{address: "[fd00::1]:443", allowed: false},
+		{address: "[::ffff:127.0.0.1]:80", allowed: false},
+		{address: "[2606:4700::1111]:443", allowed: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			err := checkAddress("tcp", tc.address, nil)
+			if (err == nil) != tc.allowed {
+				t.Errorf("checkAddress(%q) = %v, want allowed %v", tc.address, err, tc.allowed)
+			}
+		})
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := Client(0).Get(srv.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Get(%s) error = %v, want ErrBlockedAddress", srv.URL, err)
+	}
+}
+
+func TestClientViaChecksDestination(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.Host)
+	}))
+	defer proxy.Close()
+	via, _ := url.Parse(proxy.URL)
+	client := ClientVia(0, via)
+
+	if _, err := client.Get("http://127.0.0.1/"); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Get of a loopback address error = %v, want ErrBlockedAddress", err)
+	}
+	if _, err := client.Get("http://93.184.216.34:8080/"); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Get of another port error = %v, want ErrBlockedAddress", err)
+	}
+	if _, err := client.Get("http://93.184.216.34/"); err != nil {
+		t.Errorf("Get of a public address through the proxy: %v", err)
+	}
+	if !slices.Equal(proxied, []string{"93.184.216.34"}) {
+		t.Errorf("proxy was asked for %q; want just the public address", proxied)
+	}
+}
+
+func TestGet(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("0123456789"))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html>"))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/image", http.StatusFound)
+	})
+	mux.HandleFunc("/moved-twice", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusFound)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	images := Fetcher{Client: srv.Client(), MaxSize: 10, Types: []string{"image/*"}, MaxRedirects: 1}
+	testCases := []struct {
+		name     string
+		fetcher  Fetcher
+		path     string
+		expected string
+		// is, if set, is the error the fetch must fail with
+		is      error
+		wantErr bool
+	}{
+		{name: "Image", fetcher: images, path: "/image", expected: "0123456789"},
+		{name: "Wrong type", fetcher: images, path: "/page", wantErr: true, is: ErrContentType},
+		{name: "Redirect", fetcher: images, path: "/moved", expected: "0123456789"},
+		{name: "Too many redirects", fetcher: images, path: "/moved-twice", wantErr: true},
+		{name: "Not found", fetcher: images, path: "/missing", wantErr: true},
+		{name: "Too large", fetcher: Fetcher{Client: srv.Client(), MaxSize: 5}, path: "/image", wantErr: true, is: ErrTooLarge},
+		{name: "Truncated", fetcher: Fetcher{Client: srv.Client(), MaxSize: 5, Truncate: true}, path: "/image", expected: "01234"},
+		{name: "Not a web link", fetcher: images, path: "ftp://example.com/image", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			link := tc.path
+			if !strings.Contains(link, "://") {
+				link = srv.URL + link
+			}
+			resp, err := tc.fetcher.Get(context.Background(), link)
+			if tc.wantErr {
+				if err == nil || tc.is != nil && !errors.Is(err, tc.is) {
+					t.Errorf("Get error = %v; want %v", err, tc.is)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if string(resp.Body) != tc.expected || resp.ContentType != "image/png" {
+				t.Errorf("Get = %q as %q; want %q", resp.Body, resp.ContentType, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package secrets fetches settings such as the bot token from a secrets
+// store, so they needn't sit in a plaintext .env file.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fetchTimeout bounds a single fetch.
+const fetchTimeout = 10 * time.Second
+
+// Source is somewhere secrets are kept. Fetch returns them keyed by the
+// environment variable each one stands in for, such as DISCORD_BOT_TOKEN.
+type Source interface {
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// FromEnv returns the source SECRETS_SOURCE selects, read with getenv:
+// "dir" for files mounted in SECRETS_DIR, such as a Kubernetes secret,
+// "vault" for a HashiCorp Vault secret, or "aws" for AWS Secrets Manager.
+// It returns nil when SECRETS_SOURCE is empty.
+func FromEnv(getenv func(string) string) (Source, error) {
+	client := &http.Client{Timeout: fetchTimeout}
+	switch source := getenv("SECRETS_SOURCE"); source {
+	case "":
+		return nil, nil
+	case "dir":
+		if getenv("SECRETS_DIR") == "" {
+			return nil, errors.New("SECRETS_SOURCE=dir needs SECRETS_DIR")
+		}
+		return Dir(getenv("SECRETS_DIR")), nil
+	case "vault":
+		v := &Vault{Addr: getenv("VAULT_ADDR"), Token: getenv("VAULT_TOKEN"), Path: getenv("VAULT_SECRET_PATH"), Client: client}
+		if v.Addr == "" || v.Token == "" || v.Path == "" {
+			return nil, errors.New("SECRETS_SOURCE=vault needs VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH")
+		}
+		return v, nil
+	case "aws":
+		a := &AWS{
+			Region:          getenv("AWS_REGION"),
+			SecretID:        getenv("AWS_SECRET_ID"),
+			AccessKeyID:     getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    getenv("AWS_SESSION_TOKEN"),
+			Client:          client,
+		}
+		if a.Region == "" || a.SecretID == "" || a.AccessKeyID == "" || a.SecretAccessKey == "" {
+			return nil, errors.New("SECRETS_SOURCE=aws needs AWS_REGION, AWS_SECRET_ID, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_SOURCE %q, want dir, vault or aws", source)
+	}
+}
+
+// Dir is a directory holding one file per secret, named after its variable,
+// the way Kubernetes mounts a secret as a volume.
+type Dir string
+
+// Fetch reads every file in the directory, trimming surrounding whitespace.
+// Hidden entries, such as Kubernetes' ..data link, are skipped.
+func (d Dir) Fetch(ctx context.Context) (map[string]string, error) {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(string(d), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		values[entry.Name()] = strings.TrimSpace(string(data))
+	}
+	return values, nil
+}
+
+// Vault reads a secret from HashiCorp Vault's key/value engine.
+type Vault struct {
+	// Addr is Vault's base URL, such as "https://vault.example.com:8200".
+	Addr string
+	// Token authenticates the request.
+	Token string
+	// Path is the secret's API path, such as "secret/data/bot" for version 2
+	// of the engine or "secret/bot" for version 1.
+	Path   string
+	Client *http.Client
+}
+
+// Fetch reads the secret's keys and values.
+func (v *Vault) Fetch(ctx context.Context) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	url := strings.TrimRight(v.Addr, "/") + "/v1/" + strings.TrimLeft(v.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned %s", resp.Status)
+	}
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding vault secret: %w", err)
+	}
+	data := body.Data
+	// Version 2 of the engine nests the values beside their metadata
+	if _, ok := data["metadata"]; ok && data["data"] != nil {
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(data["data"], &nested); err != nil {
+			return nil, fmt.Errorf("decoding vault secret: %w", err)
+		}
+		data = nested
+	}
+	return stringValues(data)
+}
+
+// stringValues decodes a secret's values, which must all be strings.
+func stringValues(raw map[string]json.RawMessage) (map[string]string, error) {
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			// Don't echo the value, it's a secret
+			return nil, fmt.Errorf("secret %s isn't a string", key)
+		}
+		values[key] = s
+	}
+	return values, nil
+}
@@ -0,0 +1,124 @@
+package secrets
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "DISCORD_BOT_TOKEN"), []byte("abc\n"), 0o600)
+	os.WriteFile(filepath.Join(dir, "..data"), []byte("ignored"), 0o600)
+	os.Mkdir(filepath.Join(dir, "nested"), 0o700)
+
+	values, err := Dir(dir).Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if fmt.Sprint(values) != "map[DISCORD_BOT_TOKEN:abc]" {
+		t.Errorf("Fetch() = %v", values)
+	}
+}
+
+func TestVault(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "version 2", body: `{"data":{"data":{"API_TOKEN":"abc"},"metadata":{"version":3}}}`, expected: "map[API_TOKEN:abc]"},
+		{name: "version 1", body: `{"data":{"API_TOKEN":"abc"}}`, expected: "map[API_TOKEN:abc]"},
+		{name: "not a string", body: `{"data":{"WORKER_COUNT":4}}`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/secret/data/bot" || r.Header.Get("X-Vault-Token") != "token" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(tc.body))
+		}))
+		v := &Vault{Addr: server.URL + "/", Token: "token", Path: "secret/data/bot", Client: server.Client()}
+		values, err := v.Fetch(context.Background())
+		server.Close()
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: Fetch() error = %v; wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if err == nil && fmt.Sprint(values) != tc.expected {
+			t.Errorf("%s: Fetch() = %v; want %s", tc.name, values, tc.expected)
+		}
+	}
+}
+
+func TestAWS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || !strings.Contains(r.Header.Get("Authorization"), "Credential=AKID/") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"Name":"bot","SecretString":"{\"DISCORD_BOT_TOKEN\":\"abc\"}"}`))
+	}))
+	defer server.Close()
+
+	a := &AWS{Region: "us-east-1", SecretID: "bot", AccessKeyID: "AKID", SecretAccessKey: "secret", Client: server.Client(), Endpoint: server.URL}
+	values, err := a.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if fmt.Sprint(values) != "map[DISCORD_BOT_TOKEN:abc]" {
+		t.Errorf("Fetch() = %v", values)
+	}
+}
+
+// TestSign checks the signer against the example in AWS's Signature Version 4
+// documentation.
+func TestSign(t *testing.T) {
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+	if got := hex.EncodeToString(key); got != "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9" {
+		t.Errorf("signingKey() = %s", got)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	sign(req, nil, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Authorization = %s; want %s", got, expected)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	testCases := []struct {
+		env     map[string]string
+		wantNil bool
+		wantErr bool
+	}{
+		{env: map[string]string{}, wantNil: true},
+		{env: map[string]string{"SECRETS_SOURCE": "dir", "SECRETS_DIR": "/run/secrets"}},
+		{env: map[string]string{"SECRETS_SOURCE": "dir"}, wantErr: true},
+		{env: map[string]string{"SECRETS_SOURCE": "vault", "VAULT_ADDR": "http://vault", "VAULT_TOKEN": "t"}, wantErr: true},
+		{env: map[string]string{"SECRETS_SOURCE": "gcp"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		source, err := FromEnv(func(key string) string { return tc.env[key] })
+		if (err != nil) != tc.wantErr {
+			t.Errorf("FromEnv(%v) error = %v; wantErr %v", tc.env, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && (source == nil) != tc.wantNil {
+			t.Errorf("FromEnv(%v) = %v; wantNil %v", tc.env, source, tc.wantNil)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package service
+
+import "errors"
+
+// Workdir does nothing outside Windows, where supervisors such as systemd
+// set the working directory themselves.
+func Workdir() error {
+	return nil
+}
+
+// Run runs the bot. Outside Windows there's no service manager to answer.
+func Run(run func() error) error {
+	return run()
+}
+
+// Install is only supported on Windows. Elsewhere, write a systemd Unit.
+func Install(name, description, exe string, args ...string) error {
+	return errors.New("registering a service is only supported on Windows")
+}
@@ -0,0 +1,93 @@
+// Package service lets the bot run under a supervisor that restarts it:
+// systemd, which it tells when it's ready and still alive, or the Windows
+// service manager.
+package service
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Notify sends state, such as "READY=1", to systemd. It does nothing when
+// the bot isn't run by systemd with Type=notify.
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// A leading @ names an abstract socket, which net handles
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// WatchdogInterval returns how often systemd expects to hear the bot is
+// alive, 0 if it isn't watching.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.Atoi(os.Getenv("WATCHDOG_USEC"))
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// Watchdog tells systemd the bot is alive twice every WatchdogInterval, as
+// long as alive says so, until ctx is done. If alive stays false too long,
+// systemd restarts the bot.
+func Watchdog(ctx context.Context, alive func() bool) {
+	interval := WatchdogInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !alive() {
+			continue
+		}
+		if err := Notify("WATCHDOG=1"); err != nil {
+			return
+		}
+	}
+}
+
+var (
+	mu       sync.Mutex
+	stopping []chan<- os.Signal
+)
+
+// Forward has stop requests from the Windows service manager delivered to c
+// as os.Interrupt, alongside the signals c gets from signal.Notify.
+func Forward(c chan<- os.Signal) {
+	mu.Lock()
+	defer mu.Unlock()
+	stopping = append(stopping, c)
+}
+
+// stop delivers a stop request to every channel passed to Forward.
+func stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, c := range stopping {
+		select {
+		case c <- os.Interrupt:
+		default:
+		}
+	}
+}
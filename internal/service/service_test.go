@@ -0,0 +1,85 @@
+package service
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := Notify("READY=1"); err != nil {
+		t.Errorf("Notify without systemd: %v", err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skip("unix datagram sockets unavailable:", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+	if err := Notify("READY=1"); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got := string(buf[:n]); got != "READY=1" {
+		t.Errorf("systemd got %q; want READY=1", got)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	testCases := []struct {
+		usec     string
+		pid      string
+		expected time.Duration
+	}{
+		{usec: "", expected: 0},
+		{usec: "30000000", expected: 30 * time.Second},
+		{usec: "30000000", pid: "1", expected: 0},
+		{usec: "30000000", pid: "self", expected: 30 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		if tc.pid == "self" {
+			tc.pid = strconv.Itoa(os.Getpid())
+		}
+		t.Setenv("WATCHDOG_USEC", tc.usec)
+		t.Setenv("WATCHDOG_PID", tc.pid)
+		if got := WatchdogInterval(); got != tc.expected {
+			t.Errorf("WatchdogInterval() with usec %q, pid %q = %v; want %v", tc.usec, tc.pid, got, tc.expected)
+		}
+	}
+}
+
+func TestUnitRender(t *testing.T) {
+	unit := Unit{
+		Description: "Discord bot",
+		Exec:        "/opt/my bot/bot",
+		Dir:         "/opt/my bot",
+		User:        "bot",
+		StopTimeout: 30 * time.Second,
+		Watchdog:    5 * time.Minute,
+	}.Render()
+	for _, line := range []string{
+		"Type=notify",
+		`ExecStart="/opt/my bot/bot" run`,
+		"WorkingDirectory=/opt/my bot",
+		"User=bot",
+		"TimeoutStopSec=30",
+		"WatchdogSec=300",
+	} {
+		if !strings.Contains(unit, "\n"+line+"\n") {
+			t.Errorf("unit is missing %q:\n%s", line, unit)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Unit describes the systemd unit running the bot.
+type Unit struct {
+	// Description is shown by systemctl status.
+	Description string
+	// Exec is the bot's executable, and Dir the folder it runs in, holding
+	// its .env file.
+	Exec string
+	Dir  string
+	// User runs the bot, empty for root.
+	User string
+	// StopTimeout is how long systemd waits for the bot to finish its work
+	// and exit before killing it.
+	StopTimeout time.Duration
+	// Watchdog is how long the bot may go without telling systemd it's alive
+	// before being restarted, 0 to not watch it.
+	Watchdog time.Duration
+}
+
+// Render lays the unit out as a systemd unit file, restarting the bot when it
+// fails and reloading it with SIGHUP.
+func (u Unit) Render() string {
+	var b strings.Builder
+	b.WriteString("# Written by `bot install-service`.\n")
+	fmt.Fprintf(&b, "[Unit]\nDescription=%s\nWants=network-online.target\nAfter=network-online.target\n\n", u.Description)
+	b.WriteString("[Service]\nType=notify\nNotifyAccess=main\n")
+	fmt.Fprintf(&b, "ExecStart=%s run\n", quote(u.Exec))
+	b.WriteString("ExecReload=/bin/kill -HUP $MAINPID\n")
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", u.Dir)
+	if u.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", u.User)
+	}
+	b.WriteString("Restart=on-failure\nRestartSec=5\n")
+	fmt.Fprintf(&b, "TimeoutStopSec=%d\n", int(u.StopTimeout.Seconds()))
+	if u.Watchdog > 0 {
+		fmt.Fprintf(&b, "WatchdogSec=%d\n", int(u.Watchdog.Seconds()))
+	}
+	b.WriteString("\n[Install]\nWantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// quote quotes a path holding spaces the way systemd reads it.
+func quote(path string) string {
+	if !strings.ContainsAny(path, " \t\"") {
+		return path
+	}
+	return `"` + strings.ReplaceAll(path, `"`, `\"`) + `"`
+}
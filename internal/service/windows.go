@@ -0,0 +1,94 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Workdir moves a bot started by the Windows service manager, which starts
+// services in the system folder, to the folder holding its executable, so
+// it finds its .env file there.
+func Workdir() error {
+	if ok, err := svc.IsWindowsService(); err != nil || !ok {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return os.Chdir(filepath.Dir(exe))
+}
+
+// Run runs the bot, as a service if the Windows service manager started it,
+// passing its stop requests to the channels given to Forward.
+func Run(run func() error) error {
+	if ok, err := svc.IsWindowsService(); err != nil || !ok {
+		return run()
+	}
+	h := &handler{run: run}
+	if err := svc.Run("", h); err != nil {
+		return err
+	}
+	return h.err
+}
+
+// handler reports the bot's state to the Windows service manager.
+type handler struct {
+	run func() error
+	err error
+}
+
+// Execute runs the bot until it exits, asking it to stop when the service
+// manager does.
+func (h *handler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	done := make(chan error, 1)
+	go func() { done <- h.run() }()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case h.err = <-done:
+			if h.err != nil {
+				return false, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				stop()
+			}
+		}
+	}
+}
+
+// Install registers exe as a Windows service called name, started with args
+// when Windows boots and restarted when it fails.
+func Install(name, description, exe string, args ...string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the service manager, which needs an administrator: %w", err)
+	}
+	defer m.Disconnect()
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", name)
+	}
+	s, err := m.CreateService(name, exe, mgr.Config{DisplayName: name, Description: description, StartType: mgr.StartAutomatic}, args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	restart := mgr.RecoveryAction{Type: mgr.ServiceRestart, Delay: 5 * time.Second}
+	// Forget failures after a day without any
+	return s.SetRecoveryActions([]mgr.RecoveryAction{restart, restart, restart}, uint32((24 * time.Hour).Seconds()))
+}
@@ -0,0 +1,122 @@
+// Package setup helps run the bot for the first time: it asks for the
+// settings it needs and writes them to a .env file.
+package setup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Setting is one line of a .env file.
+type Setting struct {
+	// Key is the environment variable, such as "DISCORD_BOT_TOKEN".
+	Key string
+	// Comment explains the setting above it.
+	Comment string
+	// Value is the setting's value. Empty values are written commented out,
+	// so the bot uses its default.
+	Value string
+}
+
+// Render lays settings out as a .env file, each under its comment, with
+// values quoted so they read back exactly as given.
+func Render(settings []Setting) (string, error) {
+	var b strings.Builder
+	b.WriteString("# Written by `bot init`. See the README for every setting.\n")
+	for _, s := range settings {
+		b.WriteString("\n")
+		for _, line := range strings.Split(s.Comment, "\n") {
+			fmt.Fprintf(&b, "# %s\n", line)
+		}
+		if s.Value == "" {
+			fmt.Fprintf(&b, "# %s=\n", s.Key)
+			continue
+		}
+		line, err := godotenv.Marshal(map[string]string{s.Key: s.Value})
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(line + "\n")
+	}
+
+	// Make sure the file reads back as written
+	parsed, err := godotenv.Unmarshal(b.String())
+	if err != nil {
+		return "", fmt.Errorf("rendered settings don't parse: %w", err)
+	}
+	for _, s := range settings {
+		if s.Value != "" && parsed[s.Key] != s.Value {
+			return "", fmt.Errorf("%s doesn't read back as written", s.Key)
+		}
+	}
+	return b.String(), nil
+}
+
+// ErrExists is returned by Write when the file is already there.
+var ErrExists = errors.New("file already exists")
+
+// Write saves content to path, readable by its owner only since it holds the
+// bot's token. It won't replace an existing file unless overwrite is set, and
+// never leaves a half-written one.
+func Write(path, content string, overwrite bool) error {
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return fmt.Errorf("%s: %w", path, ErrExists)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".env-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Prompter asks questions on a terminal.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewPrompter returns a Prompter reading answers from in and asking on out.
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out}
+}
+
+// Ask asks question and returns the trimmed answer, or def if the answer is
+// empty. The default is shown after the question when there is one.
+func (p *Prompter) Ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	answer, err := p.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || answer == "") {
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
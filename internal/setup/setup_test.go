@@ -0,0 +1,72 @@
+package setup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joho/godotenv"
+)
+
+func TestRender(t *testing.T) {
+	settings := []Setting{
+		{Key: "DISCORD_BOT_TOKEN", Comment: "The token.", Value: `abc"def#ghi`},
+		{Key: "DATA_FILE", Comment: "Where data goes.\nSecond line.", Value: "my data.json"},
+		{Key: "LOG_FILE", Comment: "Logs."},
+	}
+	content, err := Render(settings)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	env, err := godotenv.Unmarshal(content)
+	if err != nil {
+		t.Fatalf("rendered file doesn't parse: %v", err)
+	}
+	if env["DISCORD_BOT_TOKEN"] != `abc"def#ghi` || env["DATA_FILE"] != "my data.json" {
+		t.Errorf("read back %v", env)
+	}
+	if _, ok := env["LOG_FILE"]; ok {
+		t.Error("empty LOG_FILE was set instead of left commented out")
+	}
+	if !strings.Contains(content, "# Second line.\n") {
+		t.Errorf("multi-line comment lost in:\n%s", content)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := Write(path, "A=1\n", false); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("written file mode %v, %v; want 0600", info.Mode(), err)
+	}
+
+	if err := Write(path, "A=2\n", false); !errors.Is(err, ErrExists) {
+		t.Errorf("Write over an existing file = %v; want ErrExists", err)
+	}
+	if err := Write(path, "A=3\n", true); err != nil {
+		t.Fatalf("Write with overwrite: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "A=3\n" {
+		t.Errorf("file holds %q; want A=3", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("left %d files behind; want just .env", len(entries))
+	}
+}
+
+func TestAsk(t *testing.T) {
+	p := NewPrompter(strings.NewReader("  token  \n\nlast"), &strings.Builder{})
+	for _, expected := range []string{"token", "default", "last"} {
+		answer, err := p.Ask("Question", "default")
+		if err != nil || answer != expected {
+			t.Errorf("Ask = %q, %v; want %q", answer, err, expected)
+		}
+	}
+	if _, err := p.Ask("Question", ""); err == nil {
+		t.Error("Ask after the end of input succeeded")
+	}
+}
@@ -0,0 +1,216 @@
+package shards
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/storage"
+)
+
+// SessionBucket is the store bucket holding the shards' gateway sessions,
+// saved when the bot stops so the next start can resume them.
+const SessionBucket = "gateway_sessions"
+
+// resumableClose is the close code Suspend disconnects shards with. Discord
+// ends a session closed with 1000 or 1001, but keeps it for a while after any
+// other code.
+const resumableClose = 4000
+
+// gateway is what a shard needs to resume its session: the ID and resume URL
+// from its last READY and the sequence of the last event it got.
+type gateway struct {
+	mu        sync.Mutex
+	sessionID string
+	resumeURL string
+	sequence  int64
+	// restored holds the IDs of the guilds restored into the shard's state,
+	// until its saved session resumes or Discord turns it down.
+	restored []string
+}
+
+// savedSession is a shard's gateway session as saved in SessionBucket.
+type savedSession struct {
+	ShardCount int    `json:"shard_count"`
+	SessionID  string `json:"session_id"`
+	Sequence   int64  `json:"sequence"`
+	ResumeURL  string `json:"resume_url"`
+	// User and Guilds are the shard's state, which a resumed session isn't
+	// sent again.
+	User   *discordgo.User    `json:"user"`
+	Guilds []*discordgo.Guild `json:"guilds"`
+	Saved  time.Time          `json:"saved"`
+}
+
+// sessionKey is where a bot's shard is saved in SessionBucket.
+func sessionKey(name string, shardID int) string {
+	return fmt.Sprintf("%s/%d", name, shardID)
+}
+
+// track keeps up with the session of the shard sess from its events, and
+// finishes restoring one once Discord answers whether it resumed.
+func (m *Manager) track(g *gateway, sess *discordgo.Session, e *discordgo.Event) {
+	g.mu.Lock()
+	g.sequence = max(g.sequence, e.Sequence)
+	restored := g.restored
+	switch e.Type {
+	case "READY":
+		var ready struct {
+			SessionID string `json:"session_id"`
+			ResumeURL string `json:"resume_gateway_url"`
+		}
+		if err := json.Unmarshal(e.RawData, &ready); err == nil {
+			g.sessionID, g.resumeURL = ready.SessionID, ready.ResumeURL
+		}
+		g.restored = nil
+	case "RESUMED":
+		g.restored = nil
+	}
+	g.mu.Unlock()
+	if restored == nil {
+		return
+	}
+
+	switch e.Type {
+	case "READY":
+		// The saved session had expired, so the shard identified; guilds the
+		// bot left meanwhile won't come back in a GUILD_CREATE to replace
+		// the restored ones
+		log.Printf("%sShard %d couldn't resume its saved session and identified again\n", m.Label, sess.ShardID)
+		r, _ := e.Struct.(*discordgo.Ready)
+		for _, id := range restored {
+			if r == nil || !slices.ContainsFunc(r.Guilds, func(g *discordgo.Guild) bool { return g.ID == id }) {
+				sess.State.GuildRemove(&discordgo.Guild{ID: id})
+			}
+		}
+	case "RESUMED":
+		log.Printf("%sShard %d resumed its saved session\n", m.Label, sess.ShardID)
+		// Ready handlers set up what a start needs, like the commands and
+		// presence, whether the shard identified or resumed
+		sess.State.RLock()
+		ready := &discordgo.Ready{SessionID: sess.State.SessionID, User: sess.State.User, Guilds: slices.Clone(sess.State.Guilds)}
+		sess.State.RUnlock()
+		for _, handler := range m.readies {
+			handler(sess, ready)
+		}
+	}
+}
+
+// Suspend disconnects every shard for a restart, leaving its session open on
+// Discord's side, and saves the sessions in st under the bot's name for
+// Restore, with the guilds in the shards' state.
+func (m *Manager) Suspend(st storage.Store, name string) {
+	now := time.Now()
+	for i, sess := range m.Sessions {
+		if err := sess.CloseWithCode(resumableClose); err != nil {
+			log.Printf("%sError closing shard %d: %v\n", m.Label, sess.ShardID, err)
+		}
+		g := m.gateways[i]
+		g.mu.Lock()
+		saved := savedSession{ShardCount: m.Count, SessionID: g.sessionID, Sequence: g.sequence, ResumeURL: g.resumeURL, Saved: now}
+		g.mu.Unlock()
+		if saved.SessionID == "" {
+			continue
+		}
+		// copy the guilds so the state isn't locked while the store writes
+		sess.State.RLock()
+		saved.User = sess.State.User
+		saved.Guilds = make([]*discordgo.Guild, len(sess.State.Guilds))
+		for i, guild := range sess.State.Guilds {
+			g := *guild
+			saved.Guilds[i] = &g
+		}
+		sess.State.RUnlock()
+		if err := st.Put(SessionBucket, sessionKey(name, sess.ShardID), saved); err != nil {
+			log.Printf("%sError saving the session of shard %d: %v\n", m.Label, sess.ShardID, err)
+		}
+	}
+}
+
+// Restore sets up the shards to resume the sessions Suspend saved in st under
+// the bot's name, if they were saved within window, instead of identifying.
+// Saved sessions are forgotten either way, since each resumes only once.
+// It returns how many shards will resume.
+func (m *Manager) Restore(st storage.Store, name string, window time.Duration) int {
+	resuming := 0
+	for i, sess := range m.Sessions {
+		key := sessionKey(name, sess.ShardID)
+		var saved savedSession
+		found, err := st.Get(SessionBucket, key, &saved)
+		if err != nil {
+			log.Printf("%sError loading the session of shard %d: %v\n", m.Label, sess.ShardID, err)
+		}
+		if !found {
+			continue
+		}
+		if err := st.Delete(SessionBucket, key); err != nil {
+			log.Printf("%sError forgetting the session of shard %d: %v\n", m.Label, sess.ShardID, err)
+		}
+		if saved.ShardCount != m.Count || time.Since(saved.Saved) > window || !restore(sess, saved) {
+			continue
+		}
+		g := m.gateways[i]
+		g.mu.Lock()
+		g.sessionID, g.resumeURL, g.sequence = saved.SessionID, saved.ResumeURL, saved.Sequence
+		g.restored = []string{}
+		for _, guild := range saved.Guilds {
+			sess.State.GuildAdd(guild)
+			g.restored = append(g.restored, guild.ID)
+		}
+		g.mu.Unlock()
+		sess.State.Lock()
+		sess.State.SessionID, sess.State.User = saved.SessionID, saved.User
+		sess.State.Unlock()
+		resuming++
+	}
+	return resuming
+}
+
+// resuming reports whether sess will resume a restored session when opened,
+// rather than identify.
+func (m *Manager) resuming(sess *discordgo.Session) bool {
+	i := slices.Index(m.Sessions, sess)
+	g := m.gateways[i]
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.restored != nil
+}
+
+// restore makes sess resume saved when opened. discordgo only resumes the
+// sessions it started itself, keeping their ID, sequence and gateway in
+// unexported fields, so they're set through reflection; if a discordgo
+// upgrade changes those fields it reports false and the shard identifies.
+func restore(sess *discordgo.Session, saved savedSession) bool {
+	v := reflect.ValueOf(sess).Elem()
+	sessionID, ok1 := unexported(v, "sessionID", reflect.TypeFor[string]())
+	sequence, ok2 := unexported(v, "sequence", reflect.TypeFor[*int64]())
+	gw, ok3 := unexported(v, "gateway", reflect.TypeFor[string]())
+	if !ok1 || !ok2 || !ok3 || sequence.IsNil() {
+		return false
+	}
+	sessionID.SetString(saved.SessionID)
+	atomic.StoreInt64(sequence.Interface().(*int64), saved.Sequence)
+	if saved.ResumeURL != "" {
+		// discordgo adds these itself only to the gateway it looks up
+		gw.SetString(saved.ResumeURL + "?v=" + discordgo.APIVersion + "&encoding=json")
+	}
+	return true
+}
+
+// unexported returns the unexported field name of the struct v as a settable
+// value, reporting false if v has no such field of type t.
+func unexported(v reflect.Value, name string, t reflect.Type) (reflect.Value, bool) {
+	f := v.FieldByName(name)
+	if !f.IsValid() || f.Type() != t {
+		return reflect.Value{}, false
+	}
+	return reflect.NewAt(t, unsafe.Pointer(f.UnsafeAddr())).Elem(), true
+}
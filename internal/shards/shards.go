@@ -0,0 +1,398 @@
+// Package shards runs one gateway session per shard so the bot can serve
+// more guilds than a single connection allows.
+package shards
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/eventqueue"
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/proxy"
+)
+
+// identifyInterval is how long Discord wants between identify batches.
+const identifyInterval = 5 * time.Second
+
+// Manager owns the sessions for the shards this process runs.
+type Manager struct {
+	// Count is the total number of shards across every process.
+	Count int
+	// Sessions holds one session per shard run by this process.
+	Sessions []*discordgo.Session
+	// Label prefixes the manager's log lines, such as "[staging] ", to tell bots
+	// apart when one process runs several.
+	Label string
+
+	maxConcurrency int
+	via            *url.URL
+	// events takes the handlers' events off the gateway reader, nil to let
+	// discordgo start a goroutine per event.
+	events *eventqueue.Queue
+	// gateways holds the session of each shard in Sessions, to resume it.
+	gateways []*gateway
+	// readies are the Ready handlers, run again when a shard resumes a
+	// session saved by an earlier process.
+	readies []func(*discordgo.Session, *discordgo.Ready)
+}
+
+// New creates sessions for the given shards. A count of 0 asks Discord for the
+// recommended shard count; empty ids means this process runs every shard.
+// Sessions reach Discord through the proxy via, or directly if it's nil.
+func New(token string, count int, ids []int, via *url.URL) (*Manager, error) {
+	probe, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, err
+	}
+	proxy.Session(probe, via)
+
+	m := &Manager{Count: count, maxConcurrency: 1, via: via}
+	gw, err := probe.GatewayBot()
+	if err != nil {
+		if count == 0 {
+			return nil, fmt.Errorf("discovering shard count: %w", err)
+		}
+		log.Println("Error fetching gateway info, identifying one shard at a time:", err)
+	} else {
+		if m.Count == 0 {
+			m.Count = gw.Shards
+		}
+		if gw.SessionStartLimit.MaxConcurrency > 0 {
+			m.maxConcurrency = gw.SessionStartLimit.MaxConcurrency
+		}
+	}
+	if m.Count < 1 {
+		m.Count = 1
+	}
+
+	if len(ids) == 0 {
+		for id := 0; id < m.Count; id++ {
+			ids = append(ids, id)
+		}
+	}
+
+	for _, id := range ids {
+		if id < 0 || id >= m.Count {
+			return nil, fmt.Errorf("shard ID %d is outside the shard count %d", id, m.Count)
+		}
+		sess, err := discordgo.New("Bot " + token)
+		if err != nil {
+			return nil, err
+		}
+		proxy.Session(sess, via)
+		sess.ShardID = id
+		sess.ShardCount = m.Count
+		g := &gateway{}
+		sess.AddHandler(func(s *discordgo.Session, e *discordgo.Event) {
+			logging.Debugf(logging.Gateway, "%sshard %d got op %d %s, sequence %d", m.Label, s.ShardID, e.Operation, e.Type, e.Sequence)
+			m.track(g, s, e)
+		})
+		m.Sessions = append(m.Sessions, sess)
+		m.gateways = append(m.gateways, g)
+	}
+	return m, nil
+}
+
+// GuildCount returns how many guilds the shards run by this process are in.
+func (m *Manager) GuildCount() int {
+	count := 0
+	for _, sess := range m.Sessions {
+		sess.State.RLock()
+		count += len(sess.State.Guilds)
+		sess.State.RUnlock()
+	}
+	return count
+}
+
+// Guild returns a guild from the state of whichever shard it's on.
+func (m *Manager) Guild(guildID string) (*discordgo.Guild, bool) {
+	for _, sess := range m.Sessions {
+		if g, err := sess.State.Guild(guildID); err == nil {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// Guilds returns the guilds the shards run by this process are in.
+func (m *Manager) Guilds() []*discordgo.Guild {
+	var guilds []*discordgo.Guild
+	for _, sess := range m.Sessions {
+		sess.State.RLock()
+		guilds = append(guilds, sess.State.Guilds...)
+		sess.State.RUnlock()
+	}
+	return guilds
+}
+
+// Permissions returns the bot's permissions in a guild, or in one of its
+// channels if channelID isn't empty, from the state of whichever shard the
+// guild is on. It reports false if the guild or channel isn't in the state.
+func (m *Manager) Permissions(guildID, channelID string) (int64, bool) {
+	for _, sess := range m.Sessions {
+		g, err := sess.State.Guild(guildID)
+		if err != nil || sess.State.User == nil {
+			continue
+		}
+		if channelID != "" {
+			if c, err := sess.State.Channel(channelID); err != nil || c.GuildID != guildID {
+				return 0, false
+			}
+			perms, err := sess.State.UserChannelPermissions(sess.State.User.ID, channelID)
+			return perms, err == nil
+		}
+		member, err := sess.State.Member(guildID, sess.State.User.ID)
+		if err != nil {
+			return 0, false
+		}
+		sess.State.RLock()
+		defer sess.State.RUnlock()
+		return memberPermissions(g, member), true
+	}
+	return 0, false
+}
+
+// AuthorPermissions returns the permissions the author of msg, with the roles
+// they sent it with, has in channelID, from the state of whichever shard the
+// channel is on. Threads take the permissions of the channel they're in. It
+// reports false if the channel isn't in the state or is in another guild.
+func (m *Manager) AuthorPermissions(msg *discordgo.Message, channelID string) (int64, bool) {
+	if msg.Author == nil || msg.Member == nil {
+		return 0, false
+	}
+	for _, sess := range m.Sessions {
+		c, err := sess.State.Channel(channelID)
+		if err != nil {
+			continue
+		}
+		if c.GuildID != msg.GuildID {
+			return 0, false
+		}
+		if c.IsThread() {
+			channelID = c.ParentID
+		}
+		perms, err := sess.State.MessagePermissions(&discordgo.Message{ChannelID: channelID, Author: msg.Author, Member: msg.Member})
+		return perms, err == nil
+	}
+	return 0, false
+}
+
+// allPermissions is every permission, including those newer than
+// discordgo.PermissionAll, which owners and administrators have.
+const allPermissions = ^int64(0)
+
+// memberPermissions returns the permissions member has across guild g, from
+// its roles, before channel overwrites.
+func memberPermissions(g *discordgo.Guild, member *discordgo.Member) int64 {
+	if member.User != nil && member.User.ID == g.OwnerID {
+		return allPermissions
+	}
+	var perms int64
+	for _, role := range g.Roles {
+		// The @everyone role has the guild's ID
+		if role.ID == g.ID || slices.Contains(member.Roles, role.ID) {
+			perms |= role.Permissions
+		}
+	}
+	if perms&discordgo.PermissionAdministrator != 0 {
+		return allPermissions
+	}
+	return perms
+}
+
+// Alive reports whether Discord has acknowledged a heartbeat from every shard
+// within the last window, meaning they're all connected.
+func (m *Manager) Alive(window time.Duration) bool {
+	for _, sess := range m.Sessions {
+		sess.RLock()
+		last := sess.LastHeartbeatAck
+		sess.RUnlock()
+		if time.Since(last) > window {
+			return false
+		}
+	}
+	return true
+}
+
+// Connected returns how many shards Discord acknowledged a heartbeat from
+// within the last window, out of how many, and the slowest heartbeat's
+// latency.
+func (m *Manager) Connected(window time.Duration) (connected, total int, latency time.Duration) {
+	for _, sess := range m.Sessions {
+		sess.RLock()
+		last, sent := sess.LastHeartbeatAck, sess.LastHeartbeatSent
+		sess.RUnlock()
+		if time.Since(last) <= window {
+			connected++
+			latency = max(latency, last.Sub(sent))
+		}
+	}
+	return connected, len(m.Sessions), latency
+}
+
+// Queue makes handlers added afterwards queue their events on q, so the
+// shards hand events off in order without waiting for them to be handled.
+func (m *Manager) Queue(q *eventqueue.Queue) {
+	m.events = q
+	for _, sess := range m.Sessions {
+		sess.SyncEvents = true
+	}
+}
+
+// AddHandler adds an event handler to every shard. With a queue its events
+// wait their turn there, and may be dropped in a flood.
+func (m *Manager) AddHandler(handler any) {
+	if m.events != nil {
+		handler = m.events.Handler(handler)
+	}
+	m.addReady(handler)
+	for _, sess := range m.Sessions {
+		sess.AddHandler(handler)
+	}
+}
+
+// AddUrgentHandler adds an event handler for events that mustn't wait behind
+// a flood or be dropped, such as interactions or disconnects. Each event is
+// handled on a goroutine of its own.
+func (m *Manager) AddUrgentHandler(handler any) {
+	if m.events != nil {
+		handler = eventqueue.Async(handler)
+	}
+	m.addReady(handler)
+	for _, sess := range m.Sessions {
+		sess.AddHandler(handler)
+	}
+}
+
+// addReady remembers handler if it handles Ready events.
+func (m *Manager) addReady(handler any) {
+	if ready, ok := handler.(func(*discordgo.Session, *discordgo.Ready)); ok {
+		m.readies = append(m.readies, ready)
+	}
+}
+
+// AddEventHandler adds a handler for raw gateway events of the given types,
+// such as "MESSAGE_CREATE", so other events don't take up room in the queue.
+func (m *Manager) AddEventHandler(handler func(*discordgo.Session, *discordgo.Event), types ...string) {
+	queued := handler
+	if m.events != nil {
+		queued = m.events.Handler(handler).(func(*discordgo.Session, *discordgo.Event))
+	}
+	for _, sess := range m.Sessions {
+		sess.AddHandler(func(s *discordgo.Session, e *discordgo.Event) {
+			if slices.Contains(types, e.Type) {
+				queued(s, e)
+			}
+		})
+	}
+}
+
+// SetIntents sets the gateway intents of every shard.
+func (m *Manager) SetIntents(intents discordgo.Intent) {
+	for _, sess := range m.Sessions {
+		sess.Identify.Intents = intents
+	}
+}
+
+// Open connects every shard, resuming restored sessions first and then
+// identifying in batches of the bot's max concurrency, since resuming doesn't
+// count against the identify limit. If any shard fails to connect, the ones
+// already open are closed again.
+func (m *Manager) Open() error {
+	var identifying []*discordgo.Session
+	for _, sess := range m.Sessions {
+		if !m.resuming(sess) {
+			identifying = append(identifying, sess)
+			continue
+		}
+		if err := sess.Open(); err != nil {
+			m.Close()
+			return fmt.Errorf("opening shard %d: %w", sess.ShardID, err)
+		}
+		log.Printf("%sShard %d/%d connected\n", m.Label, sess.ShardID, m.Count)
+	}
+	batches := identifyBatches(identifying, m.maxConcurrency)
+	for n, batch := range batches {
+		if n > 0 {
+			time.Sleep(identifyInterval)
+		}
+		for _, sess := range batch {
+			if err := sess.Open(); err != nil {
+				m.Close()
+				return fmt.Errorf("opening shard %d: %w", sess.ShardID, err)
+			}
+			log.Printf("%sShard %d/%d connected\n", m.Label, sess.ShardID, m.Count)
+		}
+	}
+	return nil
+}
+
+// Rotate switches every shard to a new token, such as after the old one
+// leaked. The token is checked first, so a bad one leaves the shards running
+// on the old; then the shards reconnect one identify batch at a time, while
+// the rest keep handling events.
+func (m *Manager) Rotate(token string) error {
+	probe, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return err
+	}
+	proxy.Session(probe, m.via)
+	user, err := probe.User("@me")
+	if err != nil {
+		return fmt.Errorf("checking the new token: %w", err)
+	}
+	if current := m.Sessions[0].State.User; current != nil && current.ID != user.ID {
+		return fmt.Errorf("the new token belongs to %s, not %s", user.Username, current.Username)
+	}
+
+	for n, batch := range identifyBatches(m.Sessions, m.maxConcurrency) {
+		if n > 0 {
+			time.Sleep(identifyInterval)
+		}
+		for _, sess := range batch {
+			if err := sess.Close(); err != nil {
+				log.Printf("%sError closing shard %d: %v\n", m.Label, sess.ShardID, err)
+			}
+			sess.Token = "Bot " + token
+			sess.Identify.Token = sess.Token
+			if err := sess.Open(); err != nil {
+				return fmt.Errorf("reopening shard %d: %w", sess.ShardID, err)
+			}
+			log.Printf("%sShard %d/%d reconnected with the new token\n", m.Label, sess.ShardID, m.Count)
+		}
+	}
+	return nil
+}
+
+// Close disconnects every shard.
+func (m *Manager) Close() {
+	for _, sess := range m.Sessions {
+		sess.Close()
+	}
+}
+
+// identifyBatches groups sessions so that each batch holds at most one shard per
+// rate-limit bucket (shard ID modulo max concurrency), in shard order.
+func identifyBatches(sessions []*discordgo.Session, maxConcurrency int) [][]*discordgo.Session {
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	var batches [][]*discordgo.Session
+	index := map[int]int{}
+	for _, sess := range sessions {
+		round := sess.ShardID / maxConcurrency
+		i, ok := index[round]
+		if !ok {
+			i = len(batches)
+			index[round] = i
+			batches = append(batches, nil)
+		}
+		batches[i] = append(batches[i], sess)
+	}
+	return batches
+}
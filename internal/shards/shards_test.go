@@ -0,0 +1,162 @@
+package shards
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/storage"
+)
+
+func TestIdentifyBatches(t *testing.T) {
+	var sessions []*discordgo.Session
+	for _, id := range []int{0, 1, 2, 3, 5} {
+		sessions = append(sessions, &discordgo.Session{ShardID: id})
+	}
+
+	testCases := []struct {
+		name           string
+		maxConcurrency int
+		expected       [][]int
+	}{
+		{name: "One at a time", maxConcurrency: 1, expected: [][]int{{0}, {1}, {2}, {3}, {5}}},
+		{name: "Pairs", maxConcurrency: 2, expected: [][]int{{0, 1}, {2, 3}, {5}}},
+		{name: "Large bucket", maxConcurrency: 16, expected: [][]int{{0, 1, 2, 3, 5}}},
+		{name: "Invalid concurrency", maxConcurrency: 0, expected: [][]int{{0}, {1}, {2}, {3}, {5}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			batches := identifyBatches(sessions, tc.maxConcurrency)
+			if len(batches) != len(tc.expected) {
+				t.Fatalf("got %d batches; want %d", len(batches), len(tc.expected))
+			}
+			for i, batch := range batches {
+				if len(batch) != len(tc.expected[i]) {
+					t.Fatalf("batch %d has %d shards; want %v", i, len(batch), tc.expected[i])
+				}
+				for j, sess := range batch {
+					if sess.ShardID != tc.expected[i][j] {
+						t.Errorf("batch %d shard %d = %d; want %d", i, j, sess.ShardID, tc.expected[i][j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMemberPermissions(t *testing.T) {
+	g := &discordgo.Guild{ID: "guild", OwnerID: "owner", Roles: []*discordgo.Role{
+		{ID: "guild", Permissions: discordgo.PermissionViewChannel},
+		{ID: "mods", Permissions: discordgo.PermissionManageMessages},
+		{ID: "admins", Permissions: discordgo.PermissionAdministrator},
+	}}
+
+	testCases := []struct {
+		name     string
+		member   *discordgo.Member
+		expected int64
+	}{
+		{name: "Everyone", member: &discordgo.Member{User: &discordgo.User{ID: "bot"}}, expected: discordgo.PermissionViewChannel},
+		{name: "With role", member: &discordgo.Member{User: &discordgo.User{ID: "bot"}, Roles: []string{"mods"}}, expected: discordgo.PermissionViewChannel | discordgo.PermissionManageMessages},
+		{name: "Administrator", member: &discordgo.Member{User: &discordgo.User{ID: "bot"}, Roles: []string{"admins"}}, expected: allPermissions},
+		{name: "Owner", member: &discordgo.Member{User: &discordgo.User{ID: "owner"}}, expected: allPermissions},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if perms := memberPermissions(g, tc.member); perms != tc.expected {
+				t.Errorf("memberPermissions = %b; want %b", perms, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSessionFields(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected reflect.Type
+	}{
+		{name: "sessionID", expected: reflect.TypeFor[string]()},
+		{name: "sequence", expected: reflect.TypeFor[*int64]()},
+		{name: "gateway", expected: reflect.TypeFor[string]()},
+	}
+
+	sess, err := discordgo.New("Bot token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := reflect.ValueOf(sess).Elem()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, ok := unexported(v, tc.name, tc.expected); !ok {
+				t.Errorf("discordgo.Session has no field %s of type %v; restore can't resume sessions", tc.name, tc.expected)
+			}
+		})
+	}
+	if sequence, _ := unexported(v, "sequence", reflect.TypeFor[*int64]()); sequence.IsValid() && sequence.IsNil() {
+		t.Error("discordgo.New left sequence nil; restore can't resume sessions")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	testCases := []struct {
+		name       string
+		saved      time.Duration
+		shardCount int
+		expected   int
+	}{
+		{name: "Fresh", saved: time.Minute, shardCount: 1, expected: 1},
+		{name: "Expired", saved: 10 * time.Minute, shardCount: 1},
+		{name: "Resharded", saved: time.Minute, shardCount: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := storage.NewMemory()
+			st.Put(SessionBucket, "main/0", savedSession{
+				ShardCount: tc.shardCount,
+				SessionID:  "session",
+				Sequence:   42,
+				ResumeURL:  "wss://resume.example",
+				User:       &discordgo.User{ID: "bot"},
+				Guilds:     []*discordgo.Guild{{ID: "guild", Name: "Guild"}},
+				Saved:      time.Now().Add(-tc.saved),
+			})
+			sess, err := discordgo.New("Bot token")
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := &Manager{Count: 1, Sessions: []*discordgo.Session{sess}, gateways: []*gateway{{}}}
+			var readies []*discordgo.Ready
+			m.addReady(func(_ *discordgo.Session, r *discordgo.Ready) { readies = append(readies, r) })
+
+			if n := m.Restore(st, "main", 5*time.Minute); n != tc.expected {
+				t.Errorf("Restore = %d; want %d", n, tc.expected)
+			}
+			if keys := st.Keys(SessionBucket); len(keys) != 0 {
+				t.Errorf("saved sessions left: %v", keys)
+			}
+			if m.resuming(sess) != (tc.expected == 1) {
+				t.Errorf("resuming = %v; want %v", m.resuming(sess), tc.expected == 1)
+			}
+			if tc.expected == 0 {
+				return
+			}
+			v := reflect.ValueOf(sess).Elem()
+			if id, gw := v.FieldByName("sessionID").String(), v.FieldByName("gateway").String(); id != "session" || gw != "wss://resume.example?v="+discordgo.APIVersion+"&encoding=json" {
+				t.Errorf("session %q at %q; want session at the resume URL", id, gw)
+			}
+
+			m.track(m.gateways[0], sess, &discordgo.Event{Type: "RESUMED", Sequence: 43})
+			if len(readies) != 1 || readies[0].User.ID != "bot" || len(readies[0].Guilds) != 1 || readies[0].Guilds[0].Name != "Guild" {
+				t.Errorf("Ready handlers got %+v; want the restored user and guild", readies)
+			}
+			if m.resuming(sess) || m.gateways[0].sequence != 43 {
+				t.Errorf("resuming = %v at sequence %d; want done at 43", m.resuming(sess), m.gateways[0].sequence)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+// Package stats counts what the bot does in each guild since it started, and
+// day by day for the last KeptDays days, kept in a store across restarts.
+// Nothing is counted for guilds in privacy mode.
+package stats
+
+import (
+	"cmp"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/storage"
+)
+
+// Guild is what the bot has done in one guild.
+type Guild struct {
+	// Reposts is how many messages the bot reposted with fixed links.
+	Reposts int `json:"reposts"`
+	// LinksFixed is how many links those reposts fixed.
+	LinksFixed int `json:"links_fixed"`
+	// Duplicates is how many times the bot pointed at an earlier fix instead of reposting.
+	Duplicates int `json:"duplicates"`
+	// LastFix is when the bot last reposted a message, zero if never.
+	LastFix time.Time `json:"last_fix,omitempty"`
+}
+
+// Count is how many links one user or platform accounts for.
+type Count struct {
+	Name  string `json:"name"`
+	Links int    `json:"links"`
+}
+
+// Leaderboard ranks who posted the links the bot fixed in a guild, and where
+// they linked to, most links first.
+type Leaderboard struct {
+	Users     []Count `json:"users"`
+	Platforms []Count `json:"platforms"`
+}
+
+// Use is how many times a command was used.
+type Use struct {
+	Name string `json:"name"`
+	Uses int    `json:"uses"`
+}
+
+// KeptDays is how many days of daily counts a guild keeps.
+const KeptDays = 90
+
+// Bucket is the store bucket holding each guild's daily counts by date,
+// keyed by guild ID.
+const Bucket = "stats"
+
+// dateLayout is how days are named, in UTC.
+const dateLayout = "2006-01-02"
+
+// Day is what the bot did in a guild on one day, in UTC.
+type Day struct {
+	// Date is the day, such as "2024-05-01".
+	Date       string `json:"date"`
+	Reposts    int    `json:"reposts"`
+	LinksFixed int    `json:"links_fixed"`
+	Duplicates int    `json:"duplicates"`
+	// Users counts the links fixed by user ID.
+	Users map[string]int `json:"users,omitempty"`
+	// Commands counts command uses by command name.
+	Commands map[string]int `json:"commands,omitempty"`
+}
+
+// counts is everything counted for one guild.
+type counts struct {
+	Guild
+	// users and platforms count fixed links by user ID and platform name.
+	users     map[string]int
+	platforms map[string]int
+	// commands counts command uses by command name.
+	commands map[string]int
+	// days holds the daily counts not yet flushed to the store, by date.
+	days map[string]*Day
+}
+
+// day returns the counts for the day of now, dropping days too old to keep.
+func (g *counts) day(now time.Time) *Day {
+	date := now.UTC().Format(dateLayout)
+	d := g.days[date]
+	if d != nil {
+		return d
+	}
+	oldest := now.UTC().AddDate(0, 0, -KeptDays+1).Format(dateLayout)
+	for kept := range g.days {
+		if kept < oldest {
+			delete(g.days, kept)
+		}
+	}
+	d = &Day{Date: date, Users: make(map[string]int), Commands: make(map[string]int)}
+	g.days[date] = d
+	return d
+}
+
+// Collector counts per-guild activity. A nil Collector counts nothing.
+type Collector struct {
+	// Store keeps the daily counts once flushed. Nil keeps them in memory,
+	// for as long as the bot runs.
+	Store storage.Store
+
+	now func() time.Time
+
+	mu     sync.Mutex
+	guilds map[string]*counts
+}
+
+// New returns an empty Collector.
+func New() *Collector {
+	return &Collector{now: time.Now, guilds: make(map[string]*counts)}
+}
+
+// RecordRepost counts a repost of a message by userID that fixed links.
+func (c *Collector) RecordRepost(guildID, userID string, links []string) {
+	c.update(guildID, func(g *counts) {
+		g.Reposts++
+		g.LinksFixed += len(links)
+		g.LastFix = c.now()
+		g.users[userID] += len(links)
+		for _, link := range links {
+			g.platforms[platform(link)]++
+		}
+		d := g.day(c.now())
+		d.Reposts++
+		d.LinksFixed += len(links)
+		d.Users[userID] += len(links)
+	})
+}
+
+// RecordDuplicate counts a reply pointing at an earlier fix.
+func (c *Collector) RecordDuplicate(guildID string) {
+	c.update(guildID, func(g *counts) {
+		g.Duplicates++
+		g.day(c.now()).Duplicates++
+	})
+}
+
+// RecordCommand counts a use of the named command.
+func (c *Collector) RecordCommand(guildID, name string) {
+	c.update(guildID, func(g *counts) {
+		g.commands[name]++
+		g.day(c.now()).Commands[name]++
+	})
+}
+
+// update applies fn to a guild's counts, unless the guild is private.
+func (c *Collector) update(guildID string, fn func(g *counts)) {
+	if c == nil || guildID == "" || logging.Private(guildID) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g := c.guilds[guildID]
+	if g == nil {
+		g = &counts{users: make(map[string]int), platforms: make(map[string]int), commands: make(map[string]int), days: make(map[string]*Day)}
+		c.guilds[guildID] = g
+	}
+	fn(g)
+}
+
+// Forget drops what's counted in memory for a guild, or with userID the
+// links counted for that user in the guild, or in every guild if guildID is
+// empty. Forget what's in the store with the package's Forget.
+func (c *Collector) Forget(guildID, userID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if userID == "" {
+		delete(c.guilds, guildID)
+		return
+	}
+	for id, g := range c.guilds {
+		if guildID != "" && id != guildID {
+			continue
+		}
+		delete(g.users, userID)
+		for _, d := range g.days {
+			delete(d.Users, userID)
+		}
+	}
+}
+
+// Forget deletes from st, for purges, the daily counts of guildID, or with
+// userID the links counted for that user in guildID, or in every guild if
+// guildID is empty. It returns how many guilds it deleted counts from.
+func Forget(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" && userID == "" {
+		return 0, nil
+	}
+	keys := []string{guildID}
+	if guildID == "" {
+		keys = st.Keys(Bucket)
+	}
+	forgotten := 0
+	for _, id := range keys {
+		var days map[string]Day
+		if ok, err := st.Get(Bucket, id, &days); err != nil || !ok {
+			return forgotten, err
+		}
+		if userID == "" {
+			if err := st.Delete(Bucket, id); err != nil {
+				return forgotten, err
+			}
+			forgotten++
+			continue
+		}
+		changed := false
+		for date, d := range days {
+			if _, ok := d.Users[userID]; ok {
+				delete(d.Users, userID)
+				days[date], changed = d, true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if err := st.Put(Bucket, id, days); err != nil {
+			return forgotten, err
+		}
+		forgotten++
+	}
+	return forgotten, nil
+}
+
+// Flush adds the daily counts made since the last flush to the store, one
+// write per guild, dropping days older than KeptDays before now. Guilds that
+// fail to save are kept for the next flush. Without a Store it does nothing.
+func (c *Collector) Flush(now time.Time) error {
+	if c == nil || c.Store == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	oldest := now.UTC().AddDate(0, 0, -KeptDays+1).Format(dateLayout)
+	var errs []error
+	for guildID, g := range c.guilds {
+		if len(g.days) == 0 {
+			continue
+		}
+		days := make(map[string]Day)
+		if _, err := c.Store.Get(Bucket, guildID, &days); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for date, d := range g.days {
+			days[date] = addDays(days[date], *d)
+		}
+		for date := range days {
+			if date < oldest {
+				delete(days, date)
+			}
+		}
+		if err := c.Store.Put(Bucket, guildID, days); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		g.days = make(map[string]*Day)
+	}
+	return errors.Join(errs...)
+}
+
+// addDays returns the counts of a and b, two counts of the same day, added up.
+func addDays(a, b Day) Day {
+	sum := Day{Date: b.Date, Reposts: a.Reposts + b.Reposts, LinksFixed: a.LinksFixed + b.LinksFixed, Duplicates: a.Duplicates + b.Duplicates}
+	for _, counts := range []map[string]int{a.Users, b.Users} {
+		for id, n := range counts {
+			if sum.Users == nil {
+				sum.Users = make(map[string]int)
+			}
+			sum.Users[id] += n
+		}
+	}
+	for _, counts := range []map[string]int{a.Commands, b.Commands} {
+		for name, n := range counts {
+			if sum.Commands == nil {
+				sum.Commands = make(map[string]int)
+			}
+			sum.Commands[name] += n
+		}
+	}
+	return sum
+}
+
+// Guild returns the counts for a guild.
+func (c *Collector) Guild(guildID string) Guild {
+	if c == nil {
+		return Guild{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if g := c.guilds[guildID]; g != nil {
+		return g.Guild
+	}
+	return Guild{}
+}
+
+// Days returns a guild's daily counts from the day of from to the day of to,
+// oldest first, both those in the store and those not yet flushed. Days the
+// bot did nothing on are left out.
+func (c *Collector) Days(guildID string, from, to time.Time) ([]Day, error) {
+	if c == nil {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	byDate := make(map[string]Day)
+	if c.Store != nil {
+		if _, err := c.Store.Get(Bucket, guildID, &byDate); err != nil {
+			return nil, err
+		}
+	}
+	if g := c.guilds[guildID]; g != nil {
+		for date, d := range g.days {
+			byDate[date] = addDays(byDate[date], *d)
+		}
+	}
+	first, last := from.UTC().Format(dateLayout), to.UTC().Format(dateLayout)
+	var days []Day
+	for date, d := range byDate {
+		if date < first || date > last {
+			continue
+		}
+		d.Date = date
+		days = append(days, d)
+	}
+	slices.SortFunc(days, func(a, b Day) int { return strings.Compare(a.Date, b.Date) })
+	return days, nil
+}
+
+// Leaderboard returns who posted the links fixed in a guild and which
+// platforms they were on.
+func (c *Collector) Leaderboard(guildID string) Leaderboard {
+	if c == nil {
+		return Leaderboard{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g := c.guilds[guildID]
+	if g == nil {
+		return Leaderboard{}
+	}
+	return Leaderboard{Users: ranked(g.users), Platforms: ranked(g.platforms)}
+}
+
+// Platforms returns how many links were fixed on each platform across every
+// guild, most links first.
+func (c *Collector) Platforms() []Count {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := make(map[string]int)
+	for _, g := range c.guilds {
+		for name, n := range g.platforms {
+			total[name] += n
+		}
+	}
+	return ranked(total)
+}
+
+// Commands returns how many times each command was used in a guild, or
+// across every guild for an empty guildID, most used first.
+func (c *Collector) Commands(guildID string) []Use {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := make(map[string]int)
+	for id, g := range c.guilds {
+		if guildID != "" && id != guildID {
+			continue
+		}
+		for name, n := range g.commands {
+			total[name] += n
+		}
+	}
+	uses := make([]Use, 0, len(total))
+	for _, count := range ranked(total) {
+		uses = append(uses, Use{Name: count.Name, Uses: count.Links})
+	}
+	return uses
+}
+
+// ranked sorts counts by links, most first, breaking ties by name.
+func ranked(links map[string]int) []Count {
+	list := make([]Count, 0, len(links))
+	for name, n := range links {
+		list = append(list, Count{Name: name, Links: n})
+	}
+	slices.SortFunc(list, func(a, b Count) int {
+		return cmp.Or(cmp.Compare(b.Links, a.Links), strings.Compare(a.Name, b.Name))
+	})
+	return list
+}
+
+// platform names the site a fixed link points to. Fixed tweets and clips
+// point at proxies, so those are named for the site they mirror.
+func platform(link string) string {
+	switch {
+	case patterns.TweetID.MatchString(link):
+		return "Twitter"
+	case strings.Contains(link, "twitch"):
+		return "Twitch"
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return "Other"
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
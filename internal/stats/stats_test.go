@@ -0,0 +1,196 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/storage"
+)
+
+func TestCollector(t *testing.T) {
+	logging.SetPrivacy(func(guildID string) bool { return guildID == "private" })
+	defer logging.SetPrivacy(nil)
+
+	c := New()
+	c.RecordRepost("guild", "1", []string{"https://fixupx.com/a/status/1", "https://clips.fxtwitch.tv/Slug"})
+	c.RecordRepost("guild", "2", []string{"https://fixupx.com/b/status/2"})
+	c.RecordDuplicate("guild")
+	c.RecordRepost("private", "1", []string{"https://fixupx.com/a/status/1"})
+	c.RecordRepost("", "1", []string{"https://fixupx.com/a/status/1"})
+
+	if g := c.Guild("guild"); g.Reposts != 2 || g.LinksFixed != 3 || g.Duplicates != 1 || g.LastFix.IsZero() {
+		t.Errorf("Guild(guild) = %+v; want 2 reposts of 3 links and 1 duplicate", g)
+	}
+	if g := c.Guild("private"); g != (Guild{}) {
+		t.Errorf("Guild(private) = %+v; want nothing counted", g)
+	}
+
+	var none *Collector
+	none.RecordRepost("guild", "1", []string{"https://fixupx.com/a/status/1"})
+	if g := none.Guild("guild"); g != (Guild{}) {
+		t.Errorf("nil Collector returned %+v", g)
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	c := New()
+	c.RecordRepost("guild", "1", []string{"https://fixupx.com/a/status/1", "https://www.example.com/page"})
+	c.RecordRepost("guild", "2", []string{"https://fixupx.com/b/status/2"})
+	c.RecordRepost("guild", "3", []string{"https://clips.fxtwitch.tv/Slug", "https://nitter.net/c/status/3", "https://example.com/other"})
+	c.RecordRepost("other", "4", []string{"https://fixupx.com/d/status/4"})
+
+	expected := Leaderboard{
+		Users:     []Count{{Name: "3", Links: 3}, {Name: "1", Links: 2}, {Name: "2", Links: 1}},
+		Platforms: []Count{{Name: "Twitter", Links: 3}, {Name: "example.com", Links: 2}, {Name: "Twitch", Links: 1}},
+	}
+	if got := c.Leaderboard("guild"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Leaderboard(guild) = %+v; want %+v", got, expected)
+	}
+	if got := c.Leaderboard("empty"); len(got.Users) != 0 || len(got.Platforms) != 0 {
+		t.Errorf("Leaderboard(empty) = %+v; want nothing", got)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	logging.SetPrivacy(func(guildID string) bool { return guildID == "private" })
+	defer logging.SetPrivacy(nil)
+
+	c := New()
+	c.RecordCommand("guild", "help")
+	c.RecordCommand("guild", "stats")
+	c.RecordCommand("guild", "help")
+	c.RecordCommand("other", "stats")
+	c.RecordCommand("other", "about")
+	c.RecordCommand("private", "help")
+
+	testCases := []struct {
+		name     string
+		guildID  string
+		expected []Use
+	}{
+		{name: "One guild", guildID: "guild", expected: []Use{{Name: "help", Uses: 2}, {Name: "stats", Uses: 1}}},
+		{name: "Every guild", expected: []Use{{Name: "help", Uses: 2}, {Name: "stats", Uses: 2}, {Name: "about", Uses: 1}}},
+		{name: "Private guild", guildID: "private", expected: []Use{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := c.Commands(tc.guildID); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Commands(%q) = %+v; want %+v", tc.guildID, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestForget(t *testing.T) {
+	st := storage.NewMemory()
+	c := New()
+	c.Store = st
+	c.RecordRepost("1", "alice", []string{"https://x.com/a/status/1"})
+	c.RecordRepost("1", "bob", []string{"https://x.com/b/status/1"})
+	c.RecordRepost("2", "alice", []string{"https://x.com/a/status/2"})
+	if err := c.Flush(time.Now()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	// Counted after the flush, so only in memory
+	c.RecordRepost("1", "alice", []string{"https://x.com/a/status/3"})
+
+	c.Forget("1", "alice")
+	if n, err := Forget(st, "1", "alice"); n != 1 || err != nil {
+		t.Errorf("Forget(1, alice) = %d, %v; want 1", n, err)
+	}
+	if users := c.Leaderboard("1").Users; len(users) != 1 || users[0].Name != "bob" {
+		t.Errorf("guild 1 leaderboard = %+v; want only bob", users)
+	}
+	if days, _ := c.Days("1", time.Now(), time.Now()); len(days) != 1 || !reflect.DeepEqual(days[0].Users, map[string]int{"bob": 1}) {
+		t.Errorf("guild 1 days = %+v; want only bob's links", days)
+	}
+
+	c.Forget("2", "")
+	if n, err := Forget(st, "2", ""); n != 1 || err != nil {
+		t.Errorf("Forget(2) = %d, %v; want 1", n, err)
+	}
+	if days, _ := c.Days("2", time.Now(), time.Now()); len(days) != 0 || c.Guild("2").Reposts != 0 {
+		t.Errorf("guild 2 days = %+v; want none", days)
+	}
+	if n, _ := Forget(st, "", "alice"); n != 0 {
+		t.Errorf("Forget(\"\", alice) = %d after forgetting; want 0", n)
+	}
+}
+
+func TestDays(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	c := New()
+	// Old enough to be dropped once later days are counted
+	c.now = func() time.Time { return now.AddDate(0, 0, -KeptDays) }
+	c.RecordRepost("guild", "1", []string{"https://fixupx.com/a/status/1"})
+	c.now = func() time.Time { return now.AddDate(0, 0, -1) }
+	c.RecordRepost("guild", "1", []string{"https://fixupx.com/a/status/1", "https://fixupx.com/a/status/2"})
+	c.RecordCommand("guild", "stats")
+	c.now = func() time.Time { return now }
+	c.RecordRepost("guild", "2", []string{"https://fixupx.com/b/status/3"})
+	c.RecordDuplicate("guild")
+	c.RecordCommand("guild", "stats")
+
+	testCases := []struct {
+		name     string
+		from, to time.Time
+		expected []Day
+	}{
+		{name: "Both days", from: now.AddDate(0, 0, -KeptDays), to: now, expected: []Day{
+			{Date: "2024-04-30", Reposts: 1, LinksFixed: 2, Users: map[string]int{"1": 2}, Commands: map[string]int{"stats": 1}},
+			{Date: "2024-05-01", Reposts: 1, LinksFixed: 1, Duplicates: 1, Users: map[string]int{"2": 1}, Commands: map[string]int{"stats": 1}},
+		}},
+		{name: "One day", from: now, to: now, expected: []Day{
+			{Date: "2024-05-01", Reposts: 1, LinksFixed: 1, Duplicates: 1, Users: map[string]int{"2": 1}, Commands: map[string]int{"stats": 1}},
+		}},
+		{name: "Before anything", from: now.AddDate(0, 0, -30), to: now.AddDate(0, 0, -2)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, _ := c.Days("guild", tc.from, tc.to); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Days() = %+v; want %+v", got, tc.expected)
+			}
+		})
+	}
+
+	c.Forget("guild", "1")
+	if days, _ := c.Days("guild", now.AddDate(0, 0, -1), now); len(days[0].Users) != 0 {
+		t.Errorf("user 1's daily links weren't forgotten: %+v", days[0].Users)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := storage.NewMemory()
+	st.Put(Bucket, "guild", map[string]Day{
+		"2024-01-01": {Reposts: 5},
+		"2024-05-01": {Reposts: 2, LinksFixed: 2, Users: map[string]int{"1": 2}},
+	})
+
+	// A restarted bot adds to what the last one counted
+	c := New()
+	c.Store = st
+	c.now = func() time.Time { return now }
+	c.RecordRepost("guild", "1", []string{"https://fixupx.com/a/status/1"})
+	c.RecordCommand("guild", "stats")
+	if err := c.Flush(now); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	c.RecordDuplicate("guild")
+
+	expected := []Day{{Date: "2024-05-01", Reposts: 3, LinksFixed: 3, Duplicates: 1, Users: map[string]int{"1": 3}, Commands: map[string]int{"stats": 1}}}
+	if got, err := c.Days("guild", now.AddDate(0, 0, -KeptDays), now); err != nil || !reflect.DeepEqual(got, expected) {
+		t.Errorf("Days() = %+v, %v; want %+v", got, err, expected)
+	}
+
+	c.Flush(now)
+	var stored map[string]Day
+	st.Get(Bucket, "guild", &stored)
+	if len(stored) != 1 || stored["2024-05-01"].Duplicates != 1 {
+		t.Errorf("stored %+v; want only the kept day, with the duplicate", stored)
+	}
+}
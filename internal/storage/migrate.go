@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+)
+
+const (
+	// metaBucket holds bookkeeping about the store itself.
+	metaBucket = "meta"
+	// schemaVersionKey is the metaBucket key recording the last applied migration.
+	schemaVersionKey = "schema_version"
+)
+
+// Migration upgrades stored data from Version-1 to Version.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(st Store) error
+}
+
+// Migrations lists every schema migration in order. Append new ones to the end.
+var Migrations = []Migration{
+	{Version: 1, Name: "initial schema", Up: func(Store) error { return nil }},
+}
+
+// LatestVersion is the schema version after every migration has run.
+func LatestVersion() int {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// SchemaVersion returns the store's current schema version, 0 if it was never migrated.
+func SchemaVersion(st Store) (int, error) {
+	var version int
+	_, err := st.Get(metaBucket, schemaVersionKey, &version)
+	return version, err
+}
+
+// Pending returns the migrations that haven't been applied to st yet.
+func Pending(st Store) ([]Migration, error) {
+	version, err := SchemaVersion(st)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range Migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies every pending migration in order and returns the ones it ran.
+// The schema version is saved after each step, so a failed run can be resumed.
+func Migrate(st Store) ([]Migration, error) {
+	pending, err := Pending(st)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range pending {
+		if err := m.Up(st); err != nil {
+			return pending[:i], fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		if err := st.Put(metaBucket, schemaVersionKey, m.Version); err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewStoreIsCurrent(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "data.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	pending, err := Pending(st)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("Pending on a new store = %v, %v; want none", pending, err)
+	}
+}
+
+func TestMigrateLegacyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte(`{"guild_config":{"1":{}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if v, _ := SchemaVersion(st); v != 0 {
+		t.Fatalf("SchemaVersion of legacy store = %d; want 0", v)
+	}
+
+	applied, err := Migrate(st)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(applied) != len(Migrations) {
+		t.Errorf("Migrate applied %d migrations; want %d", len(applied), len(Migrations))
+	}
+	if v, _ := SchemaVersion(st); v != LatestVersion() {
+		t.Errorf("SchemaVersion after Migrate = %d; want %d", v, LatestVersion())
+	}
+	if keys := st.Keys("guild_config"); len(keys) != 1 {
+		t.Errorf("existing data lost during migration: %v", keys)
+	}
+
+	applied, err = Migrate(st)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("second Migrate = %v, %v; want nothing to do", applied, err)
+	}
+}
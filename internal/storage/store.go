@@ -0,0 +1,236 @@
+// Package storage persists the bot's state as bucketed JSON values.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go-discord-bot/internal/logging"
+)
+
+// Store is a bucketed key/value store. Values are encoded as JSON so each
+// feature can keep its own types without the store knowing about them.
+type Store interface {
+	// Get decodes the value stored under bucket/key into v and reports whether it was present.
+	Get(bucket, key string, v any) (bool, error)
+	// Put stores v under bucket/key.
+	Put(bucket, key string, v any) error
+	// Delete removes bucket/key. Deleting a missing key is not an error.
+	Delete(bucket, key string) error
+	// Keys returns the sorted keys of a bucket.
+	Keys(bucket string) []string
+	// Batch calls fn with a Store whose changes are persisted together once
+	// fn returns, rather than one by one. Changes fn made before failing are
+	// kept.
+	Batch(fn func(tx Store) error) error
+}
+
+// FileStore is a Store persisted as a single JSON file.
+// A FileStore with an empty path lives only in memory, which is handy for tests.
+type FileStore struct {
+	mu      sync.RWMutex
+	path    string
+	buckets map[string]map[string]json.RawMessage
+	// saveErr is why the last save failed, nil if it worked.
+	saveErr error
+}
+
+// Open loads the store at path, creating an empty one if the file doesn't exist yet.
+func Open(path string) (*FileStore, error) {
+	if path == "" {
+		return NewMemory(), nil
+	}
+
+	st := &FileStore{path: path, buckets: make(map[string]map[string]json.RawMessage)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// A brand new store already has the latest schema
+		return st, st.Put(metaBucket, schemaVersionKey, LatestVersion())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading store: %w", err)
+	}
+	if err := json.Unmarshal(data, &st.buckets); err != nil {
+		return nil, fmt.Errorf("decoding store: %w", err)
+	}
+	if st.buckets == nil {
+		st.buckets = make(map[string]map[string]json.RawMessage)
+	}
+	return st, nil
+}
+
+// NewMemory returns an empty store at the latest schema version that is never written to disk.
+func NewMemory() *FileStore {
+	st := &FileStore{buckets: make(map[string]map[string]json.RawMessage)}
+	st.Put(metaBucket, schemaVersionKey, LatestVersion())
+	return st
+}
+
+// Get decodes the value stored under bucket/key into v.
+// It reports whether the key was present.
+func (st *FileStore) Get(bucket, key string, v any) (bool, error) {
+	st.mu.RLock()
+	raw, ok := st.buckets[bucket][key]
+	st.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Put stores v under bucket/key and persists the store.
+func (st *FileStore) Put(bucket, key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.buckets[bucket] == nil {
+		st.buckets[bucket] = make(map[string]json.RawMessage)
+	}
+	st.buckets[bucket][key] = raw
+	logging.Debugf(logging.Storage, "put %s/%s, %d bytes", bucket, key, len(raw))
+	return st.save()
+}
+
+// Delete removes bucket/key and persists the store.
+func (st *FileStore) Delete(bucket, key string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.buckets[bucket][key]; !ok {
+		return nil
+	}
+	delete(st.buckets[bucket], key)
+	logging.Debugf(logging.Storage, "deleted %s/%s", bucket, key)
+	return st.save()
+}
+
+// Keys returns the sorted keys of a bucket.
+func (st *FileStore) Keys(bucket string) []string {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	keys := make([]string, 0, len(st.buckets[bucket]))
+	for k := range st.buckets[bucket] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Batch implements Store, writing the store once for every change fn makes.
+// Other goroutines wait for fn to finish before using the store.
+func (st *FileStore) Batch(fn func(tx Store) error) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	tx := &batch{st: st}
+	err := fn(tx)
+	if tx.changed {
+		err = errors.Join(err, st.save())
+	}
+	return err
+}
+
+// batch is the Store a FileStore's Batch hands out. Its caller holds st.mu.
+type batch struct {
+	st      *FileStore
+	changed bool
+}
+
+// Get implements Store.
+func (b *batch) Get(bucket, key string, v any) (bool, error) {
+	raw, ok := b.st.buckets[bucket][key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Put implements Store, leaving the write to Batch.
+func (b *batch) Put(bucket, key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if b.st.buckets[bucket] == nil {
+		b.st.buckets[bucket] = make(map[string]json.RawMessage)
+	}
+	b.st.buckets[bucket][key] = raw
+	b.changed = true
+	logging.Debugf(logging.Storage, "put %s/%s in a batch, %d bytes", bucket, key, len(raw))
+	return nil
+}
+
+// Delete implements Store, leaving the write to Batch.
+func (b *batch) Delete(bucket, key string) error {
+	if _, ok := b.st.buckets[bucket][key]; !ok {
+		return nil
+	}
+	delete(b.st.buckets[bucket], key)
+	b.changed = true
+	logging.Debugf(logging.Storage, "deleted %s/%s in a batch", bucket, key)
+	return nil
+}
+
+// Keys implements Store.
+func (b *batch) Keys(bucket string) []string {
+	keys := make([]string, 0, len(b.st.buckets[bucket]))
+	for k := range b.st.buckets[bucket] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Batch implements Store. Batches within a batch are part of it.
+func (b *batch) Batch(fn func(tx Store) error) error {
+	return fn(b)
+}
+
+// Err returns why the store last failed to be written to disk, or nil if the
+// last write worked.
+func (st *FileStore) Err() error {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.saveErr
+}
+
+// save writes the store to disk and records whether that worked. Callers must
+// hold st.mu.
+func (st *FileStore) save() error {
+	st.saveErr = st.write()
+	return st.saveErr
+}
+
+// write writes the store to disk atomically. Callers must hold st.mu.
+func (st *FileStore) write() error {
+	if st.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(st.buckets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(st.path), ".store-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), st.path)
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestFileStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+
+	st, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	want := testValue{Name: "a", Count: 2}
+	if err := st.Put("bucket", "key", want); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopening store: %v", err)
+	}
+	var got testValue
+	ok, err := reopened.Get("bucket", "key", &got)
+	if err != nil || !ok {
+		t.Fatalf("Get = %v, %v; want present", ok, err)
+	}
+	if got != want {
+		t.Errorf("Get returned %+v; want %+v", got, want)
+	}
+
+	if err := reopened.Delete("bucket", "key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if keys := reopened.Keys("bucket"); len(keys) != 0 {
+		t.Errorf("Keys after delete = %v; want none", keys)
+	}
+}
+
+func TestMemoryStoreMissingKey(t *testing.T) {
+	st := NewMemory()
+
+	var v testValue
+	ok, err := st.Get("bucket", "missing", &v)
+	if ok || err != nil {
+		t.Errorf("Get of missing key = %v, %v; want false, nil", ok, err)
+	}
+	if err := st.Delete("bucket", "missing"); err != nil {
+		t.Errorf("Delete of missing key: %v", err)
+	}
+}
+
+func TestFileStoreErr(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	st, err := Open(filepath.Join(dir, "data.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if err := st.Put("bucket", "key", 1); err == nil || st.Err() != err {
+		t.Errorf("Put = %v, Err = %v; want the same failure", err, st.Err())
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if err := st.Put("bucket", "key", 2); err != nil || st.Err() != nil {
+		t.Errorf("Put = %v, Err = %v; want both nil", err, st.Err())
+	}
+}
+
+func TestBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	st, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	st.Put("bucket", "old", testValue{Name: "old"})
+
+	err = st.Batch(func(tx Store) error {
+		if err := tx.Put("bucket", "new", testValue{Name: "new"}); err != nil {
+			return err
+		}
+		if err := tx.Delete("bucket", "old"); err != nil {
+			return err
+		}
+		// Nothing is written until the batch is done
+		if reopened, _ := Open(path); len(reopened.Keys("bucket")) != 1 || reopened.Keys("bucket")[0] != "old" {
+			t.Errorf("store on disk changed during the batch: %v", reopened.Keys("bucket"))
+		}
+		var v testValue
+		if ok, _ := tx.Get("bucket", "new", &v); !ok || v.Name != "new" {
+			t.Errorf("batch Get = %+v, %v; want its own Put", v, ok)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopening store: %v", err)
+	}
+	if keys := reopened.Keys("bucket"); len(keys) != 1 || keys[0] != "new" {
+		t.Errorf("keys after Batch = %v; want [new]", keys)
+	}
+}
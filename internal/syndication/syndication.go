@@ -0,0 +1,113 @@
+// Package syndication fetches the text of tweets from the API behind Twitter's
+// embeddable tweets. It is separate from the fixing proxies, so tweets stay
+// readable while those are down.
+package syndication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is Twitter's syndication API.
+const DefaultBaseURL = "https://cdn.syndication.twimg.com"
+
+// ErrNotFound is returned for tweets that don't exist or can't be embedded.
+var ErrNotFound = errors.New("tweet not found")
+
+// Client fetches tweets from the syndication API.
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// New returns a client for the API at baseURL, or DefaultBaseURL if it's empty.
+// A nil client uses http.DefaultClient.
+func New(baseURL string, client *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// Tweet is the part of a syndicated tweet the bot uses.
+type Tweet struct {
+	ID        string    `json:"id_str"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	User      User      `json:"user"`
+}
+
+// User is the account that posted a tweet.
+type User struct {
+	Name       string `json:"name"`
+	ScreenName string `json:"screen_name"`
+}
+
+// Tweet fetches the tweet with the given ID.
+func (c *Client) Tweet(ctx context.Context, id string) (*Tweet, error) {
+	query := url.Values{"id": {id}, "token": {token(id)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/tweet-result?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("syndication API returned %s", resp.Status)
+	}
+
+	var body struct {
+		Typename string `json:"__typename"`
+		Tweet
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding syndication response: %w", err)
+	}
+	// Deleted and protected tweets come back as tombstones
+	if body.Typename != "Tweet" || body.Text == "" {
+		return nil, ErrNotFound
+	}
+	if body.ID == "" {
+		body.ID = id
+	}
+	return &body.Tweet, nil
+}
+
+// token derives the token the API asks for from a tweet ID, the way Twitter's
+// embed script does: the ID over 1e15 times π, in base 36, without its zeros
+// and point.
+func token(id string) string {
+	n, err := strconv.ParseFloat(id, 64)
+	if err != nil {
+		return ""
+	}
+	x := n / 1e15 * math.Pi
+	whole := math.Floor(x)
+	digits := strconv.FormatInt(int64(whole), 36)
+	frac := x - whole
+	for i := 0; i < 10 && frac > 0; i++ {
+		frac *= 36
+		d := math.Floor(frac)
+		digits += strconv.FormatInt(int64(d), 36)
+		frac -= d
+	}
+	return strings.ReplaceAll(digits, "0", "")
+}
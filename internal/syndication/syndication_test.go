@@ -0,0 +1,60 @@
+package syndication
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTweet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tweet-result" || r.URL.Query().Get("token") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Query().Get("id") {
+		case "1":
+			w.Write([]byte(`{"__typename":"Tweet","id_str":"1","text":"hello there","created_at":"2024-08-24T01:02:03.000Z","user":{"name":"Some One","screen_name":"someone"}}`))
+		case "2":
+			w.Write([]byte(`{"__typename":"TweetTombstone","tombstone":{"text":{"text":"This Post was deleted"}}}`))
+		case "3":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, server.Client())
+
+	testCases := []struct {
+		name     string
+		id       string
+		err      bool
+		notFound bool
+	}{
+		{name: "Tweet", id: "1"},
+		{name: "Deleted tweet", id: "2", err: true, notFound: true},
+		{name: "Server error", id: "3", err: true},
+		{name: "Missing tweet", id: "4", err: true, notFound: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tweet, err := c.Tweet(context.Background(), tc.id)
+			if (err != nil) != tc.err {
+				t.Fatalf("Tweet(%s) error = %v; want error %v", tc.id, err, tc.err)
+			}
+			if tc.notFound && !errors.Is(err, ErrNotFound) {
+				t.Errorf("Tweet(%s) error = %v; want ErrNotFound", tc.id, err)
+			}
+			if err != nil {
+				return
+			}
+			if tweet.Text != "hello there" || tweet.User.ScreenName != "someone" || tweet.CreatedAt.Year() != 2024 {
+				t.Errorf("Tweet = %+v", tweet)
+			}
+		})
+	}
+}
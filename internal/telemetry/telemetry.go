@@ -0,0 +1,138 @@
+// Package telemetry reports anonymous usage counts, such as how many links
+// were fixed on each platform, to an endpoint whoever runs the bot picked, so
+// the maintainers know which platforms matter. It's off unless turned on.
+// Nothing identifying a guild, user or link is sent.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/stats"
+)
+
+// sendTimeout bounds a single report.
+const sendTimeout = 10 * time.Second
+
+// Platforms reported by name. Links fixed anywhere else are counted as Other,
+// since a site's name could say something about who posted it.
+var Platforms = []string{"Twitter", "Twitch"}
+
+// Other is what links fixed on platforms not in Platforms are counted as.
+const Other = "Other"
+
+// Report is what is sent to the endpoint.
+type Report struct {
+	// Version is the version of the bot.
+	Version string `json:"version"`
+	// Guilds is how many guilds the bot is in.
+	Guilds int `json:"guilds"`
+	// LinksFixed counts the links fixed on each platform since the last
+	// report, or since the bot started for the first one.
+	LinksFixed map[string]int `json:"links_fixed"`
+}
+
+// Reporter sends a Report every interval.
+type Reporter struct {
+	// Guilds returns how many guilds the bot is in. Nil reports none.
+	Guilds func() int
+	// Stats counts the fixed links. Nil reports none.
+	Stats *stats.Collector
+
+	url     string
+	version string
+	client  *http.Client
+
+	mu   sync.Mutex
+	sent map[string]int
+}
+
+// New returns a Reporter sending reports for a bot running version to url.
+func New(url, version string, client *http.Client) *Reporter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Reporter{url: url, version: version, client: client, sent: make(map[string]int)}
+}
+
+// totals returns the links fixed on each platform since the bot started,
+// with the ones not in Platforms counted as Other.
+func (r *Reporter) totals() map[string]int {
+	totals := make(map[string]int)
+	for _, c := range r.Stats.Platforms() {
+		name := Other
+		for _, p := range Platforms {
+			if c.Name == p {
+				name = p
+			}
+		}
+		totals[name] += c.Links
+	}
+	return totals
+}
+
+// Send reports the counts since the last report that went through.
+func (r *Reporter) Send(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	totals := r.totals()
+	report := Report{Version: r.version, LinksFixed: make(map[string]int)}
+	if r.Guilds != nil {
+		report.Guilds = r.Guilds()
+	}
+	for name, n := range totals {
+		if n > r.sent[name] {
+			report.LinksFixed[name] = n - r.sent[name]
+		}
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	// Counts that didn't go through are sent with the next report
+	r.sent = totals
+	return nil
+}
+
+// Run sends a report every interval until ctx is done, the first one an
+// interval after it starts.
+func (r *Reporter) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := r.Send(ctx); err != nil {
+			log.Println("Error sending usage report:", err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go-discord-bot/internal/stats"
+)
+
+func TestSend(t *testing.T) {
+	var reports []Report
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var report Report
+		if err := json.NewDecoder(req.Body).Decode(&report); err != nil {
+			t.Errorf("decoding report: %v", err)
+		}
+		reports = append(reports, report)
+	}))
+	defer srv.Close()
+
+	collector := stats.New()
+	r := New(srv.URL, "v1.0.0", srv.Client())
+	r.Stats = collector
+	r.Guilds = func() int { return 3 }
+
+	collector.RecordRepost("guild", "user", []string{"https://fixupx.com/a/status/1", "https://clips.fxtwitch.tv/clip", "https://example.com/private"})
+	if err := r.Send(context.Background()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	collector.RecordRepost("other", "user", []string{"https://fixupx.com/a/status/2"})
+	fail = true
+	if err := r.Send(context.Background()); err == nil {
+		t.Error("Send to a failing endpoint succeeded")
+	}
+	fail = false
+	collector.RecordRepost("guild", "user", []string{"https://fixupx.com/a/status/3"})
+	if err := r.Send(context.Background()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	expected := []Report{
+		{Version: "v1.0.0", Guilds: 3, LinksFixed: map[string]int{"Twitter": 1, "Twitch": 1, "Other": 1}},
+		{Version: "v1.0.0", Guilds: 3, LinksFixed: map[string]int{"Twitter": 2}},
+	}
+	if !reflect.DeepEqual(reports, expected) {
+		t.Errorf("reports = %+v; want %+v", reports, expected)
+	}
+}
@@ -0,0 +1,178 @@
+// Package templates renders the text guilds can put around the bot's reposts,
+// like "🔧 Fixed link from {user}: {links}", and welcome new members with,
+// like "Welcome to {server}, {user}!".
+//
+// Placeholders are names in braces; "{{" and "}}" stand for literal braces.
+// Templates are validated when they're saved, so rendering never fails.
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLength caps how long a repost template may be, in characters, and
+// MaxWelcomeLength a welcome message.
+const (
+	MaxLength        = 200
+	MaxWelcomeLength = 1000
+)
+
+// Placeholders a template can use.
+const (
+	// User is a mention of whoever posted the original message. Reposts
+	// don't ping them.
+	User = "user"
+	// Name is the name whoever posted the original message goes by in the
+	// server, as plain text.
+	Name = "name"
+	// Links is the fixed links, separated by spaces.
+	Links = "links"
+	// Message is the whole original message with its links fixed.
+	Message = "message"
+	// Channel is a mention of the channel the message was posted in.
+	Channel = "channel"
+	// Server is the name of the server a member joined.
+	Server = "server"
+	// Count is how many members the server has with the one who joined.
+	Count = "count"
+)
+
+// Placeholders lists the placeholders repost templates can use, in the order
+// they're documented.
+var Placeholders = []string{User, Name, Links, Message, Channel}
+
+// WelcomePlaceholders lists the placeholders welcome messages can use, where
+// {user} and {name} are the member who joined.
+var WelcomePlaceholders = []string{User, Name, Server, Count}
+
+// allPlaceholders are the placeholders Render fills in.
+var allPlaceholders = slices.Concat(Placeholders, WelcomePlaceholders)
+
+// Vars are the values placeholders are replaced with.
+type Vars struct {
+	UserID    string
+	Name      string
+	ChannelID string
+	Links     []string
+	Message   string
+	Server    string
+	Count     int
+}
+
+// segment is a run of literal text or a placeholder.
+type segment struct {
+	text        string
+	placeholder string
+}
+
+// Validate checks that a template parses, only uses known placeholders and
+// includes the fixed links, through {links} or {message}.
+func Validate(tmpl string) error {
+	if utf8.RuneCountInString(tmpl) > MaxLength {
+		return fmt.Errorf("templates can be at most %d characters", MaxLength)
+	}
+	segments, err := parse(tmpl, Placeholders)
+	if err != nil {
+		return err
+	}
+	hasLinks := false
+	for _, seg := range segments {
+		if seg.placeholder == Links || seg.placeholder == Message {
+			hasLinks = true
+		}
+	}
+	if !hasLinks {
+		return errors.New("templates must include {links} or {message}")
+	}
+	return nil
+}
+
+// ValidateWelcome checks that a welcome message parses and only uses
+// WelcomePlaceholders.
+func ValidateWelcome(tmpl string) error {
+	if utf8.RuneCountInString(tmpl) > MaxWelcomeLength {
+		return fmt.Errorf("welcome messages can be at most %d characters", MaxWelcomeLength)
+	}
+	_, err := parse(tmpl, WelcomePlaceholders)
+	return err
+}
+
+// Render fills in a template. Templates that don't validate render as the
+// fixed message on its own.
+func Render(tmpl string, vars Vars) string {
+	segments, err := parse(tmpl, allPlaceholders)
+	if err != nil {
+		return vars.Message
+	}
+	var b strings.Builder
+	for _, seg := range segments {
+		switch seg.placeholder {
+		case "":
+			b.WriteString(seg.text)
+		case User:
+			if vars.UserID != "" {
+				b.WriteString("<@" + vars.UserID + ">")
+			}
+		case Name:
+			b.WriteString(vars.Name)
+		case Links:
+			b.WriteString(strings.Join(vars.Links, " "))
+		case Message:
+			b.WriteString(vars.Message)
+		case Channel:
+			if vars.ChannelID != "" {
+				b.WriteString("<#" + vars.ChannelID + ">")
+			}
+		case Server:
+			b.WriteString(vars.Server)
+		case Count:
+			if vars.Count > 0 {
+				b.WriteString(strconv.Itoa(vars.Count))
+			}
+		}
+	}
+	return b.String()
+}
+
+// parse splits a template into literal text and placeholders, which must be
+// among allowed.
+func parse(tmpl string, allowed []string) ([]segment, error) {
+	var segments []segment
+	var text strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		c := tmpl[i]
+		switch {
+		case c == '{' && strings.HasPrefix(tmpl[i:], "{{"), c == '}' && strings.HasPrefix(tmpl[i:], "}}"):
+			text.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(tmpl[i:], '}')
+			if end < 0 {
+				return nil, errors.New("a { isn't closed, use {{ for a literal brace")
+			}
+			name := tmpl[i+1 : i+end]
+			if !slices.Contains(allowed, name) {
+				return nil, fmt.Errorf("unknown placeholder {%s}, use one of {%s}", name, strings.Join(allowed, "}, {"))
+			}
+			if text.Len() > 0 {
+				segments = append(segments, segment{text: text.String()})
+				text.Reset()
+			}
+			segments = append(segments, segment{placeholder: name})
+			i += end
+		case c == '}':
+			return nil, errors.New("a } isn't opened, use }} for a literal brace")
+		default:
+			text.WriteByte(c)
+		}
+	}
+	if text.Len() > 0 {
+		segments = append(segments, segment{text: text.String()})
+	}
+	return segments, nil
+}
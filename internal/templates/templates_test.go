@@ -0,0 +1,84 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tmpl    string
+		wantErr bool
+	}{
+		{name: "Links", tmpl: "🔧 Fixed link from {user}: {links}"},
+		{name: "Message", tmpl: "{message} (from {user} in {channel})"},
+		{name: "Literal braces", tmpl: "{{fixed}} {links}"},
+		{name: "No links", tmpl: "Fixed by the bot, {user}", wantErr: true},
+		{name: "Unknown placeholder", tmpl: "{links} {author}", wantErr: true},
+		{name: "Unclosed", tmpl: "{links} {user", wantErr: true},
+		{name: "Unopened", tmpl: "{links} user}", wantErr: true},
+		{name: "Too long", tmpl: "{links}" + strings.Repeat("x", MaxLength), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Validate(tc.tmpl); (err != nil) != tc.wantErr {
+				t.Errorf("Validate(%q) error = %v; wantErr %v", tc.tmpl, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWelcome(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tmpl    string
+		wantErr bool
+	}{
+		{name: "Welcome", tmpl: "Welcome to {server}, {user}! You're member #{count}."},
+		{name: "No placeholders", tmpl: "Welcome!"},
+		{name: "Repost placeholder", tmpl: "Welcome {user}: {links}", wantErr: true},
+		{name: "Unclosed", tmpl: "Welcome {user", wantErr: true},
+		{name: "Too long", tmpl: strings.Repeat("x", MaxWelcomeLength+1), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ValidateWelcome(tc.tmpl); (err != nil) != tc.wantErr {
+				t.Errorf("ValidateWelcome(%q) error = %v; wantErr %v", tc.tmpl, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	vars := Vars{
+		UserID:    "42",
+		Name:      "Al",
+		ChannelID: "7",
+		Links:     []string{"https://fixupx.com/a/status/1", "https://fixupx.com/b/status/2"},
+		Message:   "look https://fixupx.com/a/status/1 and https://fixupx.com/b/status/2",
+		Server:    "Cats",
+		Count:     12,
+	}
+	testCases := []struct {
+		tmpl     string
+		expected string
+	}{
+		{tmpl: "🔧 Fixed link from {user}: {links}", expected: "🔧 Fixed link from <@42>: https://fixupx.com/a/status/1 https://fixupx.com/b/status/2"},
+		{tmpl: "{message} (in {channel})", expected: "look https://fixupx.com/a/status/1 and https://fixupx.com/b/status/2 (in <#7>)"},
+		{tmpl: "{links} shared by {name}", expected: "https://fixupx.com/a/status/1 https://fixupx.com/b/status/2 shared by Al"},
+		{tmpl: "{{{links}}}", expected: "{https://fixupx.com/a/status/1 https://fixupx.com/b/status/2}"},
+		{tmpl: "Welcome to {server}, {name}, member #{count}", expected: "Welcome to Cats, Al, member #12"},
+		{tmpl: "broken {", expected: vars.Message},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tmpl, func(t *testing.T) {
+			if got := Render(tc.tmpl, vars); got != tc.expected {
+				t.Errorf("Render(%q) = %q; want %q", tc.tmpl, got, tc.expected)
+			}
+		})
+	}
+}
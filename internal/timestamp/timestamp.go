@@ -0,0 +1,42 @@
+// Package timestamp writes times the way Discord shows them: as timestamp
+// markup, which each reader sees in their own time zone and language, and as
+// days in a guild's time zone for what has to be decided server-side, such as
+// when a daily digest is due.
+package timestamp
+
+import (
+	"fmt"
+	"time"
+)
+
+// Style is how Discord shows a timestamp.
+type Style string
+
+// Styles, as shown for 20 April 2021 16:20 to an English reader.
+const (
+	// ShortTime is "16:20".
+	ShortTime Style = "t"
+	// LongTime is "16:20:30".
+	LongTime Style = "T"
+	// ShortDate is "20/04/2021".
+	ShortDate Style = "d"
+	// LongDate is "20 April 2021".
+	LongDate Style = "D"
+	// ShortDateTime is "20 April 2021 16:20", Discord's default.
+	ShortDateTime Style = "f"
+	// LongDateTime is "Tuesday, 20 April 2021 16:20".
+	LongDateTime Style = "F"
+	// Relative is "2 months ago" or "in 3 days".
+	Relative Style = "R"
+)
+
+// Format returns the markup showing t in style.
+func Format(t time.Time, style Style) string {
+	return fmt.Sprintf("<t:%d:%s>", t.Unix(), style)
+}
+
+// StartOfDay returns midnight at the start of the day t falls on in loc.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,35 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	at := time.Date(2021, 4, 20, 16, 20, 30, 0, time.UTC)
+	if markup := Format(at, Relative); markup != "<t:1618935630:R>" {
+		t.Errorf("Format = %q", markup)
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	tokyo := time.FixedZone("Tokyo", 9*60*60)
+	newYork := time.FixedZone("New York", -5*60*60)
+	at := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		loc      *time.Location
+		expected time.Time
+	}{
+		{name: "UTC", loc: time.UTC, expected: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Ahead of UTC", loc: tokyo, expected: time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC)},
+		{name: "Behind UTC", loc: newYork, expected: time.Date(2024, 5, 1, 5, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		if day := StartOfDay(at, tc.loc); !day.Equal(tc.expected) {
+			t.Errorf("%s: StartOfDay = %v; want %v", tc.name, day.UTC(), tc.expected)
+		}
+	}
+}
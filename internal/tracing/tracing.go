@@ -0,0 +1,328 @@
+// Package tracing records spans of work, such as handling a message and the
+// API calls it makes, and exports them to an OpenTelemetry collector over
+// OTLP/HTTP, so operators can see where a slow fix spent its time.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBuffered is how many ended spans are kept for the next export. Spans
+// ended while the buffer is full are dropped.
+const maxBuffered = 4096
+
+// Tracer starts spans and exports them in batches. A nil Tracer records
+// nothing.
+type Tracer struct {
+	url     string
+	service string
+	client  *http.Client
+	now     func() time.Time
+
+	mu      sync.Mutex
+	ended   []*Span
+	dropped int
+}
+
+// New returns a Tracer exporting to the OTLP/HTTP collector at endpoint, such
+// as "http://localhost:4318", naming the spans' source service. A nil client
+// uses http.DefaultClient.
+func New(endpoint, service string, client *http.Client) *Tracer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Tracer{
+		url:     strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		service: service,
+		client:  client,
+		now:     time.Now,
+	}
+}
+
+// Attr is a key and value describing a span, such as the guild it's for.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Span is one timed piece of work. Its methods may be called on a nil Span,
+// which records nothing, so callers don't need to check if tracing is on.
+type Span struct {
+	tracer  *Tracer
+	traceID [16]byte
+	id      [8]byte
+	parent  [8]byte
+	name    string
+	start   time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs []Attr
+	err   string
+	ended bool
+}
+
+type spanKey struct{}
+
+// Start begins a span named name. It's a child of the span in ctx if there is
+// one, and otherwise starts a new trace. The returned context carries the new
+// span to the work it covers.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{tracer: t, name: name, start: t.now(), attrs: attrs}
+	rand.Read(span.id[:])
+	if parent := FromContext(ctx); parent != nil {
+		span.traceID, span.parent = parent.traceID, parent.id
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	return WithSpan(ctx, span), span
+}
+
+// Start begins a child of the span in ctx. Without one it does nothing, so
+// code deep in a call doesn't need the Tracer to be traced.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, attrs...)
+}
+
+// WithSpan returns a context carrying span, so work started from it is traced
+// as part of span. A nil span leaves ctx as it is.
+func WithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// FromContext returns the span ctx carries, or nil.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Set adds attributes to the span.
+func (s *Span) Set(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// Fail marks the span as failed with err, unless err is nil.
+func (s *Span) Fail(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End finishes the span and queues it for export. Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = s.tracer.now()
+	s.mu.Unlock()
+
+	t := s.tracer
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.ended) >= maxBuffered {
+		t.dropped++
+		return
+	}
+	t.ended = append(t.ended, s)
+}
+
+// Run exports ended spans every interval until ctx is done.
+func (t *Tracer) Run(ctx context.Context, interval time.Duration) {
+	if t == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Flush(ctx); err != nil {
+				log.Println("Error exporting traces:", err)
+			}
+		}
+	}
+}
+
+// Flush exports every span ended so far. Spans that fail to export are
+// dropped rather than retried, so a collector that's down can't pile them up.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans, dropped := t.ended, t.dropped
+	t.ended, t.dropped = nil, 0
+	t.mu.Unlock()
+	if dropped > 0 {
+		log.Printf("Dropped %d spans while the trace buffer was full\n", dropped)
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(t.export(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+// The OTLP/HTTP JSON encoding of spans. IDs are hex and times are nanoseconds
+// since the epoch, written as strings.
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []spanJSON `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	spanJSON struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            status     `json:"status"`
+	}
+	keyValue struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+	status struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// OTLP span kind and status codes.
+const (
+	kindInternal = 1
+	statusError  = 2
+)
+
+// export encodes spans as an OTLP export request.
+func (t *Tracer) export(spans []*Span) exportRequest {
+	encoded := make([]spanJSON, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := spanJSON{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.id[:]),
+			Name:              s.name,
+			Kind:              kindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        keyValues(s.attrs),
+		}
+		if s.parent != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		if s.err != "" {
+			span.Status = status{Code: statusError, Message: s.err}
+		}
+		s.mu.Unlock()
+		encoded = append(encoded, span)
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: keyValues([]Attr{String("service.name", t.service)})},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: "go-discord-bot"}, Spans: encoded}},
+	}}}
+}
+
+// keyValues encodes attributes as OTLP key-values.
+func keyValues(attrs []Attr) []keyValue {
+	kvs := make([]keyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var value map[string]any
+		switch v := a.Value.(type) {
+		case int:
+			// 64-bit integers are strings in OTLP JSON
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		kvs = append(kvs, keyValue{Key: a.Key, Value: value})
+	}
+	return kvs
+}
@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// collector is an OTLP/HTTP endpoint that keeps the spans exported to it.
+type collector struct {
+	spans []spanJSON
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "bad export", http.StatusBadRequest)
+		return
+	}
+	var req exportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+func TestTracer(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	tracer := New(srv.URL+"/", "test", srv.Client())
+
+	ctx, root := tracer.Start(context.Background(), "message", String("guild.id", "guild"))
+	_, child := Start(ctx, "send", Int("attempts", 2))
+	child.Fail(errors.New("missing access"))
+	child.End()
+	child.End()
+	root.End()
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(c.spans) != 2 {
+		t.Fatalf("exported %d spans; want 2", len(c.spans))
+	}
+	send, message := c.spans[0], c.spans[1]
+	if send.TraceID != message.TraceID || send.ParentSpanID != message.SpanID || message.ParentSpanID != "" {
+		t.Errorf("send span %+v isn't a child of message span %+v", send, message)
+	}
+	if send.Status.Code != statusError || send.Status.Message != "missing access" {
+		t.Errorf("send status = %+v; want the error", send.Status)
+	}
+	if len(send.Attributes) != 1 || send.Attributes[0].Value["intValue"] != "2" {
+		t.Errorf("send attributes = %+v; want attempts 2", send.Attributes)
+	}
+
+	if err := tracer.Flush(context.Background()); err != nil || len(c.spans) != 2 {
+		t.Errorf("second Flush exported again: %v, %d spans", err, len(c.spans))
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "message")
+	if span != nil || FromContext(ctx) != nil {
+		t.Fatal("nil Tracer started a span")
+	}
+	if _, child := Start(ctx, "send"); child != nil {
+		t.Error("Start without a span in ctx started one")
+	}
+	span.Set(String("key", "value"))
+	span.Fail(errors.New("failed"))
+	span.End()
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Errorf("Flush = %v", err)
+	}
+}
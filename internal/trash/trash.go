@@ -0,0 +1,239 @@
+// Package trash keeps copies of the messages the bot deletes for a while, so
+// moderators can see what was removed and put back what shouldn't have been.
+package trash
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding each guild's deleted messages, keyed by
+// guild ID.
+const Bucket = "deleted_messages"
+
+// maxKept is how many deleted messages a guild keeps; the oldest go first.
+const maxKept = 200
+
+// Attachment is a file a deleted message had.
+type Attachment struct {
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+}
+
+// Message is a copy of a deleted message.
+type Message struct {
+	ID          string       `json:"id"`
+	ChannelID   string       `json:"channel_id"`
+	AuthorID    string       `json:"author_id"`
+	AuthorName  string       `json:"author_name"`
+	Content     string       `json:"content"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// Reason is why the bot deleted it, such as "phishing".
+	Reason    string    `json:"reason"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Bin keeps deleted messages for its retention period. A nil Bin keeps
+// nothing, so deletions are final.
+type Bin struct {
+	store     storage.Store
+	retention time.Duration
+	now       func() time.Time
+	// mu serializes updates to the guilds' lists.
+	mu sync.Mutex
+}
+
+// New returns a Bin keeping deleted messages in st for retention. It returns
+// nil if retention isn't positive.
+func New(st storage.Store, retention time.Duration) *Bin {
+	if retention <= 0 {
+		return nil
+	}
+	return &Bin{store: st, retention: retention, now: time.Now}
+}
+
+// Retention is how long deleted messages are kept.
+func (b *Bin) Retention() time.Duration {
+	if b == nil {
+		return 0
+	}
+	return b.retention
+}
+
+// Keep saves a copy of m, deleted from guildID for reason.
+func (b *Bin) Keep(guildID string, m *discordgo.Message, reason string) error {
+	if b == nil || guildID == "" {
+		return nil
+	}
+	kept := Message{
+		ID:        m.ID,
+		ChannelID: m.ChannelID,
+		Content:   m.Content,
+		Reason:    reason,
+		DeletedAt: b.now(),
+	}
+	if m.Author != nil {
+		kept.AuthorID, kept.AuthorName = m.Author.ID, m.Author.Username
+	}
+	for _, a := range m.Attachments {
+		kept.Attachments = append(kept.Attachments, Attachment{Filename: a.Filename, URL: a.URL})
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	messages, err := b.load(guildID)
+	if err != nil {
+		return err
+	}
+	messages = append(messages, kept)
+	if len(messages) > maxKept {
+		messages = messages[len(messages)-maxKept:]
+	}
+	return b.store.Put(Bucket, guildID, messages)
+}
+
+// List returns a guild's deleted messages still kept, newest first.
+func (b *Bin) List(guildID string) ([]Message, error) {
+	if b == nil {
+		return nil, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	messages, err := b.load(guildID)
+	if err != nil {
+		return nil, err
+	}
+	messages = b.fresh(messages, b.now())
+	slices.Reverse(messages)
+	return messages, nil
+}
+
+// Take returns and forgets a guild's deleted message, so it's only restored once.
+func (b *Bin) Take(guildID, messageID string) (Message, bool, error) {
+	if b == nil {
+		return Message{}, false, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	messages, err := b.load(guildID)
+	if err != nil {
+		return Message{}, false, err
+	}
+	messages = b.fresh(messages, b.now())
+	i := slices.IndexFunc(messages, func(m Message) bool { return m.ID == messageID })
+	if i < 0 {
+		return Message{}, false, nil
+	}
+	taken := messages[i]
+	if err := b.save(guildID, slices.Delete(messages, i, i+1)); err != nil {
+		return Message{}, false, err
+	}
+	return taken, true, nil
+}
+
+// Return puts back a message taken from a guild's list, such as one that
+// couldn't be restored after all.
+func (b *Bin) Return(guildID string, m Message) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	messages, err := b.load(guildID)
+	if err != nil {
+		return err
+	}
+	i, _ := slices.BinarySearchFunc(messages, m.DeletedAt, func(kept Message, at time.Time) int { return kept.DeletedAt.Compare(at) })
+	return b.save(guildID, slices.Insert(messages, i, m))
+}
+
+// Prune forgets messages deleted longer than the retention period before now
+// and returns how many it forgot.
+func (b *Bin) Prune(now time.Time) int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pruned := 0
+	for _, guildID := range b.store.Keys(Bucket) {
+		messages, err := b.load(guildID)
+		if err != nil {
+			continue
+		}
+		fresh := b.fresh(messages, now)
+		if len(fresh) == len(messages) {
+			continue
+		}
+		if err := b.save(guildID, fresh); err == nil {
+			pruned += len(messages) - len(fresh)
+		}
+	}
+	return pruned
+}
+
+// Forget deletes from st, for purges, the deleted messages of guildID, or with
+// userID those of that member, in guildID or in every guild if guildID is
+// empty. It returns how many it deleted.
+func Forget(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" && userID == "" {
+		return 0, nil
+	}
+	keys := []string{guildID}
+	if guildID == "" {
+		keys = st.Keys(Bucket)
+	}
+	deleted := 0
+	for _, k := range keys {
+		var kept []Message
+		if ok, err := st.Get(Bucket, k, &kept); err != nil || !ok {
+			return deleted, err
+		}
+		n := len(kept)
+		if userID == "" {
+			kept = nil
+		} else {
+			kept = slices.DeleteFunc(kept, func(e Message) bool { return e.AuthorID == userID })
+		}
+		if len(kept) == n {
+			continue
+		}
+		deleted += n - len(kept)
+		var err error
+		if len(kept) == 0 {
+			err = st.Delete(Bucket, k)
+		} else {
+			err = st.Put(Bucket, k, kept)
+		}
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// load returns a guild's kept messages, oldest first. b.mu must be held.
+func (b *Bin) load(guildID string) ([]Message, error) {
+	var messages []Message
+	_, err := b.store.Get(Bucket, guildID, &messages)
+	return messages, err
+}
+
+// save stores a guild's kept messages, dropping the guild when there are
+// none. b.mu must be held.
+func (b *Bin) save(guildID string, messages []Message) error {
+	if len(messages) == 0 {
+		return b.store.Delete(Bucket, guildID)
+	}
+	return b.store.Put(Bucket, guildID, messages)
+}
+
+// fresh returns the messages still inside the retention period at now.
+func (b *Bin) fresh(messages []Message, now time.Time) []Message {
+	return slices.DeleteFunc(messages, func(m Message) bool { return now.Sub(m.DeletedAt) >= b.retention })
+}
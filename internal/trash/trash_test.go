@@ -0,0 +1,82 @@
+package trash
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"go-discord-bot/internal/storage"
+)
+
+func deleted(id string) *discordgo.Message {
+	return &discordgo.Message{
+		ID:          id,
+		ChannelID:   "chan",
+		Content:     "https://login.evil.example",
+		Author:      &discordgo.User{ID: "user", Username: "someone"},
+		Attachments: []*discordgo.MessageAttachment{{Filename: "a.png", URL: "https://cdn.example/a.png"}},
+	}
+}
+
+func TestBin(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b := New(storage.NewMemory(), time.Hour)
+	b.now = func() time.Time { return now }
+
+	for _, id := range []string{"old", "msg1", "msg2"} {
+		if err := b.Keep("guild", deleted(id), "phishing"); err != nil {
+			t.Fatalf("Keep: %v", err)
+		}
+		if id == "old" {
+			now = now.Add(45 * time.Minute)
+		}
+	}
+	now = now.Add(30 * time.Minute)
+
+	kept, err := b.List("guild")
+	if err != nil || len(kept) != 2 || kept[0].ID != "msg2" || kept[1].ID != "msg1" {
+		t.Fatalf("List = %+v, %v; want msg2 then msg1", kept, err)
+	}
+	if m := kept[0]; m.AuthorName != "someone" || len(m.Attachments) != 1 || m.Attachments[0].URL != "https://cdn.example/a.png" {
+		t.Errorf("kept %+v", m)
+	}
+
+	m, ok, err := b.Take("guild", "msg1")
+	if err != nil || !ok || m.ID != "msg1" {
+		t.Fatalf("Take = %+v, %v, %v", m, ok, err)
+	}
+	if _, ok, _ := b.Take("guild", "msg1"); ok {
+		t.Error("took msg1 twice")
+	}
+	if _, ok, _ := b.Take("guild", "old"); ok {
+		t.Error("took a message past its retention")
+	}
+
+	if err := b.Return("guild", m); err != nil {
+		t.Fatalf("Return: %v", err)
+	}
+	if kept, _ := b.List("guild"); len(kept) != 2 || kept[1].ID != "msg1" {
+		t.Errorf("after Return, List = %+v", kept)
+	}
+
+	if pruned := b.Prune(now.Add(time.Hour)); pruned != 2 {
+		t.Errorf("Prune = %d; want 2", pruned)
+	}
+	if keys := b.store.Keys(Bucket); len(keys) != 0 {
+		t.Errorf("guilds left after pruning everything: %q", keys)
+	}
+}
+
+func TestNilBin(t *testing.T) {
+	if b := New(storage.NewMemory(), 0); b != nil {
+		t.Fatal("New with no retention returned a Bin")
+	}
+	var b *Bin
+	if err := b.Keep("guild", deleted("msg"), "phishing"); err != nil {
+		t.Errorf("Keep on a nil Bin = %v", err)
+	}
+	if kept, err := b.List("guild"); kept != nil || err != nil {
+		t.Errorf("List on a nil Bin = %v, %v", kept, err)
+	}
+}
@@ -0,0 +1,249 @@
+// Package trends counts the domains and tweets shared in each guild per day,
+// so /trends can show what a community has been linking to lately. Counts
+// are kept in memory until flushed, then in the store for Days days. Nothing
+// is counted for guilds in privacy mode.
+package trends
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/logging"
+	"go-discord-bot/internal/patterns"
+	"go-discord-bot/internal/storage"
+)
+
+// Bucket is the store bucket holding each guild's daily counts, keyed by guild ID.
+const Bucket = "trends"
+
+// Days is how many days of counts are kept and shown.
+const Days = 7
+
+// dayFormat is how days are keyed in a guild's counts.
+const dayFormat = "2006-01-02"
+
+// maxCounted is how many domains, and how many tweets, are kept for each day
+// once flushed; the least shared go first.
+const maxCounted = 100
+
+// day is what was shared in a guild on one day.
+type day struct {
+	// Domains counts links by host, and Tweets counts tweet links by ID.
+	Domains map[string]int `json:"domains,omitempty"`
+	Tweets  map[string]int `json:"tweets,omitempty"`
+}
+
+// Count is how often one domain or tweet was shared.
+type Count struct {
+	Name   string
+	Shares int
+}
+
+// Trends are a guild's most shared domains and tweets, most shared first.
+// Tweets are named by their ID.
+type Trends struct {
+	Domains []Count
+	Tweets  []Count
+}
+
+// Counter counts shared links in a store. A nil Counter counts nothing.
+type Counter struct {
+	store storage.Store
+	// mu serializes updates to the daily counts.
+	mu sync.Mutex
+	// pending holds the counts not yet flushed, by guild ID and day.
+	pending map[string]map[string]day
+}
+
+// New returns a Counter keeping its counts in st.
+func New(st storage.Store) *Counter {
+	return &Counter{store: st, pending: make(map[string]map[string]day)}
+}
+
+// Record counts links shared in a guild at the given time, in the guild's
+// time zone loc. The counts are kept in memory until Flush.
+func (c *Counter) Record(guildID string, links []string, at time.Time, loc *time.Location) {
+	if c == nil || guildID == "" || len(links) == 0 || logging.Private(guildID) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	days := c.pending[guildID]
+	if days == nil {
+		days = make(map[string]day)
+		c.pending[guildID] = days
+	}
+	key := at.In(loc).Format(dayFormat)
+	today := days[key]
+	if today.Domains == nil {
+		today.Domains = make(map[string]int)
+	}
+	if today.Tweets == nil {
+		today.Tweets = make(map[string]int)
+	}
+	for _, link := range links {
+		if host := host(link); host != "" {
+			today.Domains[host]++
+		}
+		// Tweets count the same whether posted as is or already fixed
+		if patterns.TwitterStatusLink.MatchString(link) || patterns.TwitterProxyStatus.MatchString(link) {
+			today.Tweets[patterns.TweetID.FindStringSubmatch(link)[1]]++
+		}
+	}
+	days[key] = today
+}
+
+// Flush adds the counts recorded since the last flush to the store, one
+// write per guild, keeping the maxCounted most shared domains and tweets of
+// each day. Days older than Days before now are dropped as it goes. Guilds
+// that fail to save are kept for the next flush.
+func (c *Counter) Flush(now time.Time) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A day of slack covers guilds whose time zone is ahead of now's
+	oldest := now.AddDate(0, 0, -Days).Format(dayFormat)
+	var errs []error
+	for guildID, pending := range c.pending {
+		days := make(map[string]day)
+		if _, err := c.store.Get(Bucket, guildID, &days); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for k, d := range pending {
+			days[k] = merge(days[k], d)
+		}
+		for k := range days {
+			// Keys sort by date
+			if k < oldest {
+				delete(days, k)
+			}
+		}
+		if err := c.store.Put(Bucket, guildID, days); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(c.pending, guildID)
+	}
+	return errors.Join(errs...)
+}
+
+// merge returns the counts of a and b added up, keeping the maxCounted
+// largest of each.
+func merge(a, b day) day {
+	domains := maps.Clone(a.Domains)
+	if domains == nil {
+		domains = make(map[string]int)
+	}
+	for name, shares := range b.Domains {
+		domains[name] += shares
+	}
+	tweets := maps.Clone(a.Tweets)
+	if tweets == nil {
+		tweets = make(map[string]int)
+	}
+	for id, shares := range b.Tweets {
+		tweets[id] += shares
+	}
+	return day{Domains: capped(domains), Tweets: capped(tweets)}
+}
+
+// capped returns counts without all but its maxCounted largest.
+func capped(counts map[string]int) map[string]int {
+	if len(counts) <= maxCounted {
+		return counts
+	}
+	kept := make(map[string]int, maxCounted)
+	for _, c := range top(counts, maxCounted) {
+		kept[c.Name] = c.Shares
+	}
+	return kept
+}
+
+// Forget drops the counts of guildID not yet flushed when userID is empty.
+// Trends don't count who shared what, so forgetting a user drops nothing.
+func (c *Counter) Forget(guildID, userID string) {
+	if c == nil || userID != "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, guildID)
+}
+
+// Forget deletes guildID's stored counts from st, for purges, when userID is
+// empty, and returns how many records it deleted.
+func Forget(st storage.Store, guildID, userID string) (int, error) {
+	if guildID == "" || userID != "" {
+		return 0, nil
+	}
+	if ok, err := st.Get(Bucket, guildID, &map[string]day{}); err != nil || !ok {
+		return 0, err
+	}
+	return 1, st.Delete(Bucket, guildID)
+}
+
+// Top returns the n most shared domains and tweets in a guild over the last
+// Days days up to now, in the guild's time zone loc, including counts not
+// yet flushed.
+func (c *Counter) Top(guildID string, n int, now time.Time, loc *time.Location) (Trends, error) {
+	if c == nil {
+		return Trends{}, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var days map[string]day
+	if _, err := c.store.Get(Bucket, guildID, &days); err != nil {
+		return Trends{}, err
+	}
+	oldest := now.In(loc).AddDate(0, 0, -Days+1).Format(dayFormat)
+	domains := make(map[string]int)
+	tweets := make(map[string]int)
+	for _, counted := range []map[string]day{days, c.pending[guildID]} {
+		for k, d := range counted {
+			if k < oldest {
+				continue
+			}
+			for name, shares := range d.Domains {
+				domains[name] += shares
+			}
+			for id, shares := range d.Tweets {
+				tweets[id] += shares
+			}
+		}
+	}
+	return Trends{Domains: top(domains, n), Tweets: top(tweets, n)}, nil
+}
+
+// top returns the n largest counts, breaking ties by name.
+func top(counts map[string]int, n int) []Count {
+	list := make([]Count, 0, len(counts))
+	for name, shares := range counts {
+		list = append(list, Count{Name: name, Shares: shares})
+	}
+	slices.SortFunc(list, func(a, b Count) int {
+		return cmp.Or(cmp.Compare(b.Shares, a.Shares), strings.Compare(a.Name, b.Name))
+	})
+	return list[:min(len(list), n)]
+}
+
+// host returns the lowercased host of link without a leading "www.", or ""
+// if it isn't a URL.
+func host(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
@@ -0,0 +1,80 @@
+package trends
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-discord-bot/internal/storage"
+)
+
+func TestCounter(t *testing.T) {
+	c := New(storage.NewMemory())
+	monday := time.Date(2024, 8, 19, 12, 0, 0, 0, time.UTC)
+	record := func(at time.Time, links ...string) {
+		c.Record("guild", links, at, time.UTC)
+	}
+	record(monday.AddDate(0, 0, -7), "https://old.example/a")
+	record(monday, "https://news.example/a", "https://x.com/user/status/1")
+	record(monday.AddDate(0, 0, 2), "https://www.news.example/b", "https://x.com/user/status/2")
+	if err := c.Flush(monday.AddDate(0, 0, 2)); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	// Counts not yet flushed add to the stored ones
+	record(monday.AddDate(0, 0, 2), "https://fixupx.com/user/status/1")
+	record(monday.AddDate(0, 0, 3), "https://blog.example/c")
+
+	top, err := c.Top("guild", 2, monday.AddDate(0, 0, 3), time.UTC)
+	if err != nil {
+		t.Fatalf("Top: %v", err)
+	}
+	expected := Trends{
+		Domains: []Count{{Name: "news.example", Shares: 2}, {Name: "x.com", Shares: 2}},
+		Tweets:  []Count{{Name: "1", Shares: 2}, {Name: "2", Shares: 1}},
+	}
+	if !reflect.DeepEqual(top, expected) {
+		t.Errorf("Top = %+v; want %+v", top, expected)
+	}
+
+	// A week later only the last day counts
+	later, _ := c.Top("guild", 10, monday.AddDate(0, 0, 9), time.UTC)
+	if expected := []Count{{Name: "blog.example", Shares: 1}}; !reflect.DeepEqual(later.Domains, expected) {
+		t.Errorf("Top a week later = %+v; want %+v", later.Domains, expected)
+	}
+
+	var off *Counter
+	off.Record("guild", []string{"https://news.example/a"}, monday, time.UTC)
+	if err := off.Flush(monday); err != nil {
+		t.Errorf("nil Flush: %v", err)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	st := storage.NewMemory()
+	c := New(st)
+	monday := time.Date(2024, 8, 19, 12, 0, 0, 0, time.UTC)
+	c.Record("guild", []string{"https://old.example/a"}, monday.AddDate(0, 0, -8), time.UTC)
+	for n := range maxCounted + 5 {
+		// Domain n is shared n+1 times
+		for range n + 1 {
+			c.Record("guild", []string{fmt.Sprintf("https://site%d.example/", n)}, monday, time.UTC)
+		}
+	}
+	if err := c.Flush(monday); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	var days map[string]day
+	st.Get(Bucket, "guild", &days)
+	if len(days) != 1 {
+		t.Errorf("stored %d days; want only the last week", len(days))
+	}
+	today := days[monday.Format(dayFormat)]
+	if len(today.Domains) != maxCounted || today.Domains["site0.example"] != 0 || today.Domains["site104.example"] != 105 {
+		t.Errorf("stored %d domains; want the %d most shared", len(today.Domains), maxCounted)
+	}
+	if len(c.pending) != 0 {
+		t.Errorf("%d guilds still pending after Flush", len(c.pending))
+	}
+}
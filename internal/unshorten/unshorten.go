@@ -0,0 +1,182 @@
+// Package unshorten expands links from URL shorteners such as bit.ly, so
+// people can see where a link goes before they open it.
+package unshorten
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-discord-bot/internal/preview"
+	"go-discord-bot/internal/safehttp"
+)
+
+const (
+	// MaxDepth is how many shortener redirects are followed for one link.
+	MaxDepth = 5
+	// cacheTTL is how long an expanded link is remembered.
+	cacheTTL = 24 * time.Hour
+	// maxCached bounds the cache; when it's full the cache is emptied.
+	maxCached = 10000
+)
+
+var (
+	// ErrNotRedirect is returned when a shortener doesn't redirect the link anywhere.
+	ErrNotRedirect = errors.New("link doesn't redirect")
+	// ErrTooDeep is returned when a link is still shortened after MaxDepth redirects.
+	ErrTooDeep = errors.New("too many redirects")
+)
+
+// Shorteners are the shortener hosts links are expanded for.
+var Shorteners = []string{
+	"bit.ly", "bitly.com", "tinyurl.com", "t.co", "goo.gl", "ow.ly", "is.gd",
+	"buff.ly", "rebrand.ly", "cutt.ly", "t.ly", "shorturl.at", "tiny.cc",
+	"rb.gy", "bl.ink", "lnkd.in", "dlvr.it", "s.id", "v.gd",
+}
+
+// Expander follows shortener redirects and caches where they lead. Only the
+// shorteners themselves are contacted, never the destination. It is safe for
+// concurrent use.
+type Expander struct {
+	client  *http.Client
+	domains map[string]bool
+
+	mu    sync.Mutex
+	cache map[string]entry
+	now   func() time.Time
+}
+
+// entry is a cached expansion.
+type entry struct {
+	destination string
+	at          time.Time
+}
+
+// New returns an expander for links on hosts, or on Shorteners if none are
+// given, using client. A nil client uses safehttp.Client, so shortened
+// links can't reach private addresses.
+func New(client *http.Client, hosts ...string) *Expander {
+	if client == nil {
+		client = safehttp.Client(5 * time.Second)
+	}
+	// Redirects are followed one at a time, to stop at the first non-shortener
+	c := *client
+	c.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	if len(hosts) == 0 {
+		hosts = Shorteners
+	}
+	domains := make(map[string]bool, len(hosts))
+	for _, d := range hosts {
+		domains[d] = true
+	}
+	return &Expander{client: &c, domains: domains, cache: make(map[string]entry), now: time.Now}
+}
+
+// Shortened reports whether link points at a known shortener.
+func (e *Expander) Shortened(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return e.domains[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")]
+}
+
+// Expand returns where a shortened link leads.
+func (e *Expander) Expand(ctx context.Context, link string) (string, error) {
+	e.mu.Lock()
+	cached, ok := e.cache[link]
+	e.mu.Unlock()
+	if ok && e.now().Sub(cached.at) < cacheTTL {
+		return cached.destination, nil
+	}
+
+	current := link
+	for depth := 0; e.Shortened(current); depth++ {
+		if depth == MaxDepth {
+			return "", ErrTooDeep
+		}
+		next, err := e.follow(ctx, current)
+		if err != nil {
+			return "", err
+		}
+		current = next
+	}
+	if current == link {
+		return "", fmt.Errorf("%q is not a shortened link", link)
+	}
+
+	e.mu.Lock()
+	if len(e.cache) >= maxCached {
+		clear(e.cache)
+	}
+	e.cache[link] = entry{destination: current, at: e.now()}
+	e.mu.Unlock()
+	return current, nil
+}
+
+// follow returns the target of one redirect. Some shorteners refuse HEAD
+// requests, so it falls back to GET without reading the body.
+func (e *Expander) follow(ctx context.Context, link string) (string, error) {
+	var lastErr error
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, link, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", preview.UserAgent)
+		resp, err := e.client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			lastErr = ErrNotRedirect
+			continue
+		}
+		next, err := req.URL.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("bad redirect: %w", err)
+		}
+		if !safehttp.WebURL(next) {
+			return "", fmt.Errorf("redirect to unsupported link %q", next)
+		}
+		return next.String(), nil
+	}
+	return "", lastErr
+}
+
+// Prune forgets expansions older than the cache lifetime and returns how many
+// it removed. It implements janitor.Pruner.
+func (e *Expander) Prune(now time.Time) int {
+	if e == nil {
+		return 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	pruned := 0
+	for link, c := range e.cache {
+		if now.Sub(c.at) >= cacheTTL {
+			delete(e.cache, link)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len returns how many expanded links are cached.
+func (e *Expander) Len() int {
+	if e == nil {
+		return 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.cache)
+}
@@ -0,0 +1,119 @@
+package unshorten
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+		case "/b":
+			http.Redirect(w, r, "https://example.com/destination?x=1", http.StatusFound)
+		case "/head-refused":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			http.Redirect(w, r, "https://example.com/from-get", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/js":
+			http.Redirect(w, r, "javascript:alert(1)", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected string
+		wantErr  bool
+		// is, if set, is the error wantErr must wrap
+		is error
+	}{
+		{name: "Chain", path: "/a", expected: "https://example.com/destination?x=1"},
+		{name: "HEAD refused", path: "/head-refused", expected: "https://example.com/from-get"},
+		{name: "Loop", path: "/loop", wantErr: true, is: ErrTooDeep},
+		{name: "Not found", path: "/missing", wantErr: true, is: ErrNotRedirect},
+		{name: "Unsupported scheme", path: "/js", wantErr: true},
+	}
+
+	host, _ := url.Parse(server.URL)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := New(server.Client(), host.Hostname())
+
+			got, err := e.Expand(context.Background(), server.URL+tc.path)
+			if tc.wantErr {
+				if err == nil || tc.is != nil && !errors.Is(err, tc.is) {
+					t.Errorf("Expand() = %q, %v; want error %v", got, err, tc.is)
+				}
+				return
+			}
+			if err != nil || got != tc.expected {
+				t.Errorf("Expand() = %q, %v; want %q", got, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestExpandCaches(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Redirect(w, r, "https://example.com/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	host, _ := url.Parse(server.URL)
+	e := New(server.Client(), host.Hostname())
+	clock := time.Now()
+	e.now = func() time.Time { return clock }
+
+	for range 2 {
+		if _, err := e.Expand(context.Background(), server.URL+"/x"); err != nil {
+			t.Fatalf("Expand: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests; want 1", requests)
+	}
+
+	clock = clock.Add(cacheTTL)
+	if pruned := e.Prune(clock); pruned != 1 {
+		t.Errorf("Prune() = %d; want 1", pruned)
+	}
+}
+
+func TestShortened(t *testing.T) {
+	e := New(nil)
+	testCases := []struct {
+		link     string
+		expected bool
+	}{
+		{link: "https://bit.ly/abc", expected: true},
+		{link: "http://www.TinyURL.com/xyz", expected: true},
+		{link: "https://t.co/123", expected: true},
+		{link: "https://example.com/bit.ly", expected: false},
+		{link: "https://notbit.ly/abc", expected: false},
+		{link: "ftp://bit.ly/abc", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.link, func(t *testing.T) {
+			if got := e.Shortened(tc.link); got != tc.expected {
+				t.Errorf("Shortened(%q) = %v; want %v", tc.link, got, tc.expected)
+			}
+		})
+	}
+}
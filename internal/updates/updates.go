@@ -0,0 +1,204 @@
+// Package updates checks for newer releases of the bot, so whoever runs it
+// hears about them.
+package updates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkTimeout bounds a single check.
+const checkTimeout = 10 * time.Second
+
+// summaryLines is how many lines of release notes a notice includes.
+const summaryLines = 8
+
+// Release is the latest release, as described by GitHub's releases API. A
+// version endpoint serving the same fields works too.
+type Release struct {
+	Version string `json:"tag_name"`
+	Name    string `json:"name"`
+	URL     string `json:"html_url"`
+	Notes   string `json:"body"`
+}
+
+// Summary returns the first lines of the release notes, skipping blank ones.
+func (r Release) Summary() string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(r.Notes, "\r\n", "\n"), "\n") {
+		if line = strings.TrimRight(line, " "); strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(lines) == summaryLines {
+			lines = append(lines, "…")
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Notice describes r to someone running the current version.
+func (r Release) Notice(current string) string {
+	notice := fmt.Sprintf("Version %s is out, this bot is running %s.", r.Version, current)
+	if r.URL != "" {
+		notice += " " + r.URL
+	}
+	if summary := r.Summary(); summary != "" {
+		notice += "\n" + summary
+	}
+	return notice
+}
+
+// Checker polls for the latest release and reports each newer one once.
+type Checker struct {
+	// Notify is told about each newer release, as well as the log. Nil only logs.
+	Notify func(ctx context.Context, r Release)
+
+	url     string
+	current string
+	client  *http.Client
+
+	mu       sync.Mutex
+	notified string
+}
+
+// New returns a Checker fetching the latest release from url for a bot
+// running the version current.
+func New(url, current string, client *http.Client) *Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Checker{url: url, current: current, client: client}
+}
+
+// Latest fetches the latest release.
+func (c *Checker) Latest(ctx context.Context) (Release, error) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return Release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Release{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("release check returned %s", resp.Status)
+	}
+	var r Release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return Release{}, fmt.Errorf("decoding release: %w", err)
+	}
+	return r, nil
+}
+
+// Check fetches the latest release and, the first time it's newer than the
+// running version, logs it and passes it to Notify. It reports whether it did.
+func (c *Checker) Check(ctx context.Context) (bool, error) {
+	r, err := c.Latest(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !Newer(r.Version, c.current) {
+		return false, nil
+	}
+	c.mu.Lock()
+	seen := c.notified == r.Version
+	c.notified = r.Version
+	c.mu.Unlock()
+	if seen {
+		return false, nil
+	}
+
+	log.Println("Update available:", r.Notice(c.current))
+	if c.Notify != nil {
+		c.Notify(ctx, r)
+	}
+	return true, nil
+}
+
+// Run checks every interval until ctx is done, starting right away.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.Check(ctx); err != nil {
+			log.Println("Error checking for updates:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Newer reports whether version latest comes after current. Both are semantic
+// versions such as "v1.2.0" or "1.3.0-rc.1"; a version that isn't, such as a
+// "dev" build, is never older or newer than another.
+func Newer(latest, current string) bool {
+	l, ok := parse(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parse(current)
+	if !ok {
+		return false
+	}
+	for i := range l.numbers {
+		if l.numbers[i] != c.numbers[i] {
+			return l.numbers[i] > c.numbers[i]
+		}
+	}
+	// A pre-release comes before its release
+	switch {
+	case l.pre == c.pre:
+		return false
+	case l.pre == "":
+		return true
+	case c.pre == "":
+		return false
+	}
+	return l.pre > c.pre
+}
+
+// semver is a parsed semantic version.
+type semver struct {
+	numbers [3]int
+	pre     string
+}
+
+// parse reads a version such as "v1.2.3-rc.1", ignoring build metadata.
+func parse(version string) (semver, bool) {
+	var v semver
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	version, _, _ = strings.Cut(version, "+")
+	version, v.pre, _ = strings.Cut(version, "-")
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return semver{}, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		v.numbers[i] = n
+	}
+	return v, true
+}
@@ -0,0 +1,76 @@
+package updates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	testCases := []struct {
+		latest, current string
+		expected        bool
+	}{
+		{latest: "v1.3.0", current: "v1.2.9", expected: true},
+		{latest: "v1.10.0", current: "v1.9.0", expected: true},
+		{latest: "v1.2.0", current: "v1.2.0"},
+		{latest: "v1.2.0", current: "v1.3.0"},
+		{latest: "2.0", current: "v1.9.9", expected: true},
+		{latest: "v1.3.0", current: "v1.3.0-rc.1", expected: true},
+		{latest: "v1.3.0-rc.2", current: "v1.3.0-rc.1", expected: true},
+		{latest: "v1.3.0-rc.1", current: "v1.3.0"},
+		{latest: "v1.3.0+build.5", current: "v1.3.0"},
+		{latest: "v1.3.0", current: "dev"},
+		{latest: "nightly", current: "v1.0.0"},
+	}
+
+	for _, tc := range testCases {
+		if newer := Newer(tc.latest, tc.current); newer != tc.expected {
+			t.Errorf("Newer(%q, %q) = %v; want %v", tc.latest, tc.current, newer, tc.expected)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	r := Release{Notes: "## Changes\r\n\r\n- one\r\n- two\n\n- three\n- four\n- five\n- six\n- seven\n- eight\n"}
+	expected := "## Changes\n- one\n- two\n- three\n- four\n- five\n- six\n- seven\n…"
+	if summary := r.Summary(); summary != expected {
+		t.Errorf("Summary = %q; want %q", summary, expected)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	latest := "v1.1.0"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "` + latest + `", "html_url": "https://example.com/release", "body": "- fixed things"}`))
+	}))
+	defer srv.Close()
+
+	var notices []string
+	c := New(srv.URL, "v1.1.0", srv.Client())
+	c.Notify = func(ctx context.Context, r Release) { notices = append(notices, r.Notice("v1.1.0")) }
+
+	testCases := []struct {
+		name     string
+		latest   string
+		expected bool
+	}{
+		{name: "Up to date", latest: "v1.1.0"},
+		{name: "New release", latest: "v1.2.0", expected: true},
+		{name: "Same release again", latest: "v1.2.0"},
+		{name: "Another release", latest: "v1.3.0", expected: true},
+	}
+
+	for _, tc := range testCases {
+		latest = tc.latest
+		notified, err := c.Check(context.Background())
+		if err != nil || notified != tc.expected {
+			t.Errorf("%s: Check = %v, %v; want %v", tc.name, notified, err, tc.expected)
+		}
+	}
+	if len(notices) != 2 || !strings.Contains(notices[0], "v1.2.0 is out") || !strings.HasSuffix(notices[0], "\n- fixed things") {
+		t.Errorf("notices = %q", notices)
+	}
+}
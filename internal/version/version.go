@@ -0,0 +1,44 @@
+// Package version reports which build of the bot is running.
+//
+// The values are set at build time, for example:
+//
+//	go build -ldflags "-X go-discord-bot/internal/version.Version=v1.2.0 -X go-discord-bot/internal/version.Commit=$(git rev-parse --short HEAD)" ./cmd/bot
+package version
+
+import (
+	"runtime/debug"
+)
+
+var (
+	// Version is the release version, "dev" for local builds.
+	Version = "dev"
+	// Commit is the git commit the binary was built from.
+	Commit = ""
+)
+
+// String describes the build as "version (commit)".
+// Without an injected commit it falls back to the VCS revision Go embeds in the binary.
+func String() string {
+	commit := Commit
+	if commit == "" {
+		commit = vcsRevision()
+	}
+	if commit == "" {
+		return Version
+	}
+	return Version + " (" + commit + ")"
+}
+
+// vcsRevision returns the short commit recorded by the Go toolchain, if any.
+func vcsRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 7 {
+			return setting.Value[:7]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package voice
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MaxFrames caps how long a sound may be, at 20ms of audio per frame.
+const MaxFrames = 1500
+
+// dcaMagic starts DCA1 files, which carry JSON metadata before the frames.
+var dcaMagic = []byte("DCA1")
+
+var (
+	// ErrEmpty is returned for audio without any frames.
+	ErrEmpty = errors.New("no audio frames")
+	// ErrTooLong is returned for audio over MaxFrames frames.
+	ErrTooLong = errors.New("audio too long")
+)
+
+// LoadDCA reads the Opus frames of a DCA file.
+func LoadDCA(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadDCA(f)
+}
+
+// ReadDCA reads Opus frames in DCA format: each frame is a little-endian
+// int16 length followed by that many bytes. DCA1 metadata is skipped.
+func ReadDCA(r io.Reader) ([][]byte, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(len(dcaMagic)); err == nil && bytes.Equal(magic, dcaMagic) {
+		br.Discard(len(dcaMagic))
+		var size int32
+		if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+			return nil, fmt.Errorf("reading DCA metadata: %w", err)
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("bad DCA metadata length %d", size)
+		}
+		if _, err := br.Discard(int(size)); err != nil {
+			return nil, fmt.Errorf("reading DCA metadata: %w", err)
+		}
+	}
+
+	var frames [][]byte
+	for {
+		var size int16
+		err := binary.Read(br, binary.LittleEndian, &size)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading DCA frame: %w", err)
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("bad DCA frame length %d", size)
+		}
+		if len(frames) == MaxFrames {
+			return nil, ErrTooLong
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(br, frame); err != nil {
+			return nil, fmt.Errorf("reading DCA frame: %w", err)
+		}
+		frames = append(frames, frame)
+	}
+	if len(frames) == 0 {
+		return nil, ErrEmpty
+	}
+	return frames, nil
+}
@@ -0,0 +1,254 @@
+// Package voice plays short notices in voice channels, such as a chime or a
+// spoken message when a link is fixed. It is experimental.
+//
+// The bot doesn't encode audio itself: sounds are Opus frames in DCA files, as
+// made by the dca tool, and text to speech runs an external command that
+// writes DCA to its standard output.
+package voice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Cooldown is the least time between notices in one guild, so a burst
+	// of links doesn't play a burst of sounds.
+	Cooldown = 10 * time.Second
+	// IdleTimeout is how long the bot stays in a voice channel after playing.
+	IdleTimeout = 5 * time.Minute
+	// ttsTimeout bounds how long the text to speech command may run.
+	ttsTimeout = 15 * time.Second
+	// frameTimeout is how long a frame may wait for the connection to send it
+	// before playback is given up.
+	frameTimeout = time.Second
+)
+
+// Joiner joins voice channels. *discordgo.Session satisfies it.
+type Joiner interface {
+	ChannelVoiceJoin(gID, cID string, mute, deaf bool) (*discordgo.VoiceConnection, error)
+}
+
+// Conn is a voice connection notices are played on.
+type Conn interface {
+	Speaking(bool) error
+	Disconnect() error
+	// Opus returns the channel Opus frames are sent on.
+	Opus() chan<- []byte
+}
+
+// discordConn adapts a discordgo voice connection to Conn.
+type discordConn struct {
+	*discordgo.VoiceConnection
+}
+
+func (c discordConn) Opus() chan<- []byte {
+	return c.OpusSend
+}
+
+// Announcer plays a notice in a guild's voice channel, one at a time per
+// guild. A nil Announcer plays nothing.
+type Announcer struct {
+	// frames are the Opus frames of the sound file, played when there's no
+	// text to speak or speaking it fails.
+	frames [][]byte
+	// tts is the text to speech command and its arguments; the text is added
+	// as the last argument.
+	tts []string
+
+	join func(s Joiner, guildID, channelID string) (Conn, error)
+	now  func() time.Time
+
+	mu     sync.Mutex
+	guilds map[string]*guildVoice
+}
+
+// guildVoice is the state of one guild's voice connection.
+type guildVoice struct {
+	conn      Conn
+	channelID string
+	playing   bool
+	last      time.Time
+	idle      *time.Timer
+}
+
+// New returns an Announcer playing the DCA file at soundFile and speaking text
+// with ttsCommand, either of which may be empty. It returns nil if both are.
+func New(soundFile, ttsCommand string) (*Announcer, error) {
+	if soundFile == "" && ttsCommand == "" {
+		return nil, nil
+	}
+	a := &Announcer{
+		tts:    strings.Fields(ttsCommand),
+		join:   joinDiscord,
+		now:    time.Now,
+		guilds: make(map[string]*guildVoice),
+	}
+	if soundFile != "" {
+		frames, err := LoadDCA(soundFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", soundFile, err)
+		}
+		a.frames = frames
+	}
+	return a, nil
+}
+
+// joinDiscord joins a voice channel deafened, since the bot only talks.
+func joinDiscord(s Joiner, guildID, channelID string) (Conn, error) {
+	vc, err := s.ChannelVoiceJoin(guildID, channelID, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return discordConn{vc}, nil
+}
+
+// Announce plays a notice in a guild's voice channel in the background,
+// speaking text if there's a text to speech command. It reports false without
+// playing anything if the guild is already playing or within Cooldown of
+// its last notice.
+func (a *Announcer) Announce(ctx context.Context, s Joiner, guildID, channelID, text string) bool {
+	if a == nil || guildID == "" || channelID == "" {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	g := a.guilds[guildID]
+	if g == nil {
+		g = &guildVoice{}
+		a.guilds[guildID] = g
+	}
+	if g.playing || a.now().Sub(g.last) < Cooldown {
+		return false
+	}
+	g.playing = true
+	if g.idle != nil {
+		g.idle.Stop()
+	}
+
+	go a.play(ctx, s, guildID, channelID, text, g)
+	return true
+}
+
+// play joins channelID if the guild isn't in it already, plays the notice and
+// schedules leaving once the guild has been quiet for IdleTimeout.
+func (a *Announcer) play(ctx context.Context, s Joiner, guildID, channelID, text string, g *guildVoice) {
+	frames := a.notice(ctx, text)
+
+	a.mu.Lock()
+	conn := g.conn
+	if conn != nil && g.channelID != channelID {
+		conn.Disconnect()
+		conn, g.conn = nil, nil
+	}
+	a.mu.Unlock()
+
+	var err error
+	if conn == nil && len(frames) > 0 {
+		conn, err = a.join(s, guildID, channelID)
+	}
+	if err != nil {
+		log.Println("Error joining voice channel:", err)
+	} else if conn != nil {
+		if err := send(ctx, conn, frames); err != nil {
+			log.Println("Error playing voice notice:", err)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	g.conn, g.channelID = conn, channelID
+	g.playing = false
+	g.last = a.now()
+	if conn != nil {
+		g.idle = time.AfterFunc(IdleTimeout, func() { a.leave(g, conn) })
+	}
+}
+
+// notice returns the frames to play for text: spoken if there's a text to
+// speech command and it works, otherwise the sound file.
+func (a *Announcer) notice(ctx context.Context, text string) [][]byte {
+	if len(a.tts) == 0 || text == "" {
+		return a.frames
+	}
+	frames, err := a.speak(ctx, text)
+	if err != nil {
+		log.Println("Error running text to speech:", err)
+		return a.frames
+	}
+	return frames
+}
+
+// speak runs the text to speech command and reads the DCA it writes.
+func (a *Announcer) speak(ctx context.Context, text string) ([][]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, ttsTimeout)
+	defer cancel()
+	args := append(append([]string(nil), a.tts[1:]...), text)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, a.tts[0], args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return ReadDCA(bytes.NewReader(out))
+}
+
+// send plays frames on conn, marking the bot as speaking while it does.
+func send(ctx context.Context, conn Conn, frames [][]byte) error {
+	if err := conn.Speaking(true); err != nil {
+		return err
+	}
+	defer conn.Speaking(false)
+	timer := time.NewTimer(frameTimeout)
+	defer timer.Stop()
+	for _, frame := range frames {
+		timer.Reset(frameTimeout)
+		select {
+		case conn.Opus() <- frame:
+		case <-timer.C:
+			return errors.New("voice connection stopped taking audio")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// leave disconnects conn if it's still the guild's idle connection.
+func (a *Announcer) leave(g *guildVoice, conn Conn) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if g.playing || g.conn != conn {
+		return
+	}
+	conn.Disconnect()
+	g.conn = nil
+}
+
+// Close leaves every voice channel.
+func (a *Announcer) Close() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, g := range a.guilds {
+		if g.idle != nil {
+			g.idle.Stop()
+		}
+		if g.conn != nil {
+			g.conn.Disconnect()
+			g.conn = nil
+		}
+	}
+}
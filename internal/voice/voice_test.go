@@ -0,0 +1,149 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dca encodes frames as DCA, with DCA1 metadata if meta is set.
+func dca(meta string, frames ...string) []byte {
+	var b bytes.Buffer
+	if meta != "" {
+		b.Write(dcaMagic)
+		binary.Write(&b, binary.LittleEndian, int32(len(meta)))
+		b.WriteString(meta)
+	}
+	for _, f := range frames {
+		binary.Write(&b, binary.LittleEndian, int16(len(f)))
+		b.WriteString(f)
+	}
+	return b.Bytes()
+}
+
+func TestReadDCA(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    []byte
+		expected []string
+		wantErr  bool
+		is       error
+	}{
+		{name: "DCA0", input: dca("", "one", "two"), expected: []string{"one", "two"}},
+		{name: "DCA1", input: dca(`{"opus":{"sample_rate":48000}}`, "one"), expected: []string{"one"}},
+		{name: "Empty", input: nil, wantErr: true, is: ErrEmpty},
+		{name: "Truncated frame", input: dca("", "one")[:4], wantErr: true},
+		{name: "Too long", input: dca("", slices.Repeat([]string{"x"}, MaxFrames+1)...), wantErr: true, is: ErrTooLong},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frames, err := ReadDCA(bytes.NewReader(tc.input))
+			if tc.wantErr {
+				if err == nil || (tc.is != nil && !errors.Is(err, tc.is)) {
+					t.Errorf("ReadDCA() error = %v; want %v", err, tc.is)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadDCA: %v", err)
+			}
+			got := make([]string, len(frames))
+			for n, f := range frames {
+				got[n] = string(f)
+			}
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("ReadDCA() = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+// fakeConn collects the frames played on it and signals when playback ends.
+type fakeConn struct {
+	opus   chan []byte
+	played []string
+	done   chan struct{}
+	// collected is closed once opus is closed and every frame is in played.
+	collected chan struct{}
+}
+
+func newFakeConn() *fakeConn {
+	c := &fakeConn{opus: make(chan []byte), done: make(chan struct{}, 1), collected: make(chan struct{})}
+	go func() {
+		defer close(c.collected)
+		for frame := range c.opus {
+			c.played = append(c.played, string(frame))
+		}
+	}()
+	return c
+}
+
+func (c *fakeConn) Speaking(speaking bool) error {
+	if !speaking {
+		c.done <- struct{}{}
+	}
+	return nil
+}
+
+func (c *fakeConn) Disconnect() error   { return nil }
+func (c *fakeConn) Opus() chan<- []byte { return c.opus }
+
+// waitIdle waits for a guild's notice to finish playing.
+func waitIdle(a *Announcer, guildID string) {
+	for {
+		a.mu.Lock()
+		playing := a.guilds[guildID].playing
+		a.mu.Unlock()
+		if !playing {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	conn := newFakeConn()
+	var joined []string
+	a := &Announcer{
+		frames: [][]byte{[]byte("ding"), []byte("dong")},
+		join: func(s Joiner, guildID, channelID string) (Conn, error) {
+			joined = append(joined, channelID)
+			return conn, nil
+		},
+		now:    func() time.Time { return now },
+		guilds: make(map[string]*guildVoice),
+	}
+	defer a.Close()
+
+	if !a.Announce(context.Background(), nil, "guild", "voice", "") {
+		t.Fatal("Announce() = false; want true")
+	}
+	<-conn.done
+	waitIdle(a, "guild")
+
+	if a.Announce(context.Background(), nil, "guild", "voice", "") {
+		t.Error("Announce() within the cooldown = true; want false")
+	}
+	now = now.Add(Cooldown)
+	if !a.Announce(context.Background(), nil, "guild", "voice", "") {
+		t.Fatal("Announce() after the cooldown = false; want true")
+	}
+	<-conn.done
+	waitIdle(a, "guild")
+	close(conn.opus)
+	<-conn.collected
+
+	if !slices.Equal(joined, []string{"voice"}) {
+		t.Errorf("joined %q; want the channel joined once", joined)
+	}
+	if expected := "ding dong ding dong"; strings.Join(conn.played, " ") != expected {
+		t.Errorf("played %q; want %q", conn.played, expected)
+	}
+}
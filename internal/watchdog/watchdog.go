@@ -0,0 +1,110 @@
+// Package watchdog samples the bot's resource counts, such as goroutines,
+// timers and cache sizes, and warns when one grows past its limit, as an
+// early sign of a leak.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Goroutines is a sample function counting the process's goroutines.
+func Goroutines() int {
+	return runtime.NumGoroutine()
+}
+
+// gauge is one resource count the watchdog samples.
+type gauge struct {
+	limit  int
+	sample func() int
+}
+
+// Watchdog samples its gauges on a schedule. Each gauge warns once when it
+// goes over its limit, and again only after it's been back under it.
+type Watchdog struct {
+	// Alert receives every warning, as well as the log. Nil only logs.
+	Alert func(ctx context.Context, warning string)
+
+	names  []string
+	gauges map[string]gauge
+
+	mu   sync.Mutex
+	over map[string]bool
+}
+
+// New returns a Watchdog with no gauges.
+func New() *Watchdog {
+	return &Watchdog{gauges: make(map[string]gauge), over: make(map[string]bool)}
+}
+
+// Add registers a gauge under a name used in warnings. sample returns its
+// current count, which should stay at or under limit; a limit of 0 or less
+// never warns. Gauges must be added before Run is called.
+func (w *Watchdog) Add(name string, limit int, sample func() int) {
+	if _, ok := w.gauges[name]; !ok {
+		w.names = append(w.names, name)
+	}
+	w.gauges[name] = gauge{limit: limit, sample: sample}
+}
+
+// Check samples every gauge once and returns the warnings for gauges that
+// have just gone over their limits, after logging them and passing them to
+// Alert.
+func (w *Watchdog) Check(ctx context.Context) []string {
+	var warnings []string
+	w.mu.Lock()
+	for _, name := range w.names {
+		g := w.gauges[name]
+		if g.limit <= 0 {
+			continue
+		}
+		n := g.sample()
+		switch {
+		case n > g.limit && !w.over[name]:
+			w.over[name] = true
+			warnings = append(warnings, fmt.Sprintf("%s is at %d, over its limit of %d; something may be leaking", name, n, g.limit))
+		case n <= g.limit && w.over[name]:
+			w.over[name] = false
+			log.Printf("Watchdog: %s is back down to %d\n", name, n)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, warning := range warnings {
+		log.Println("Watchdog:", warning)
+		if w.Alert != nil {
+			w.Alert(ctx, warning)
+		}
+	}
+	return warnings
+}
+
+// Samples returns the current count of every gauge.
+func (w *Watchdog) Samples() map[string]int {
+	samples := make(map[string]int, len(w.names))
+	for _, name := range w.names {
+		samples[name] = w.gauges[name].sample()
+	}
+	return samples
+}
+
+// Run checks every interval until ctx is done.
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check(ctx)
+		}
+	}
+}
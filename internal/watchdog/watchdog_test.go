@@ -0,0 +1,41 @@
+package watchdog
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	timers, cache := 0, 0
+	var alerts []string
+	w := New()
+	w.Alert = func(ctx context.Context, warning string) { alerts = append(alerts, warning) }
+	w.Add("timers", 10, func() int { return timers })
+	w.Add("cache", 0, func() int { return cache })
+
+	testCases := []struct {
+		name     string
+		timers   int
+		expected []string
+	}{
+		{name: "Under the limit", timers: 10},
+		{name: "Over the limit", timers: 11, expected: []string{"timers is at 11, over its limit of 10; something may be leaking"}},
+		{name: "Still over", timers: 50},
+		{name: "Back under", timers: 3},
+		{name: "Over again", timers: 12, expected: []string{"timers is at 12, over its limit of 10; something may be leaking"}},
+	}
+
+	for _, tc := range testCases {
+		timers, cache = tc.timers, 1_000_000
+		if warnings := w.Check(context.Background()); !slices.Equal(warnings, tc.expected) {
+			t.Errorf("%s: Check = %q; want %q", tc.name, warnings, tc.expected)
+		}
+	}
+	if len(alerts) != 2 {
+		t.Errorf("alerted %q; want 2 alerts", alerts)
+	}
+	if samples := w.Samples(); samples["timers"] != 12 || samples["cache"] != 1_000_000 {
+		t.Errorf("Samples = %v", samples)
+	}
+}
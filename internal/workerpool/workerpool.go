@@ -0,0 +1,82 @@
+// Package workerpool runs jobs on a bounded set of goroutines with per-key ordering.
+package workerpool
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// Pool runs jobs on a fixed number of goroutines.
+// Jobs submitted with the same key always run on the same worker, in
+// submission order, so messages from one channel are handled in sequence
+// while different channels proceed in parallel.
+type Pool struct {
+	mu     sync.RWMutex
+	closed bool
+	queues []chan func()
+	wg     sync.WaitGroup
+}
+
+// New starts workers goroutines, each with a queue of queueSize jobs.
+func New(workers, queueSize int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{queues: make([]chan func(), workers)}
+	for i := range p.queues {
+		q := make(chan func(), queueSize)
+		p.queues[i] = q
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for job := range q {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// Submit queues job on the worker owning key. It never blocks: if that
+// worker's queue is full, or the pool is stopped, the job is dropped and
+// Submit returns false.
+func (p *Pool) Submit(key string, job func()) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	select {
+	case p.queues[h.Sum32()%uint32(len(p.queues))] <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Len returns how many jobs are queued, and how many the queues hold at most.
+func (p *Pool) Len() (queued, capacity int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, q := range p.queues {
+		queued += len(q)
+		capacity += cap(q)
+	}
+	return queued, capacity
+}
+
+// Stop stops accepting jobs and waits for queued jobs to finish.
+func (p *Pool) Stop() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, q := range p.queues {
+			close(q)
+		}
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
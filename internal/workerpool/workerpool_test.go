@@ -0,0 +1,59 @@
+package workerpool
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPoolPreservesOrderPerKey(t *testing.T) {
+	p := New(4, 100)
+
+	var mu sync.Mutex
+	seen := map[string][]int{}
+	for n := 0; n < 50; n++ {
+		for _, key := range []string{"a", "b", "c"} {
+			if !p.Submit(key, func() {
+				mu.Lock()
+				seen[key] = append(seen[key], n)
+				mu.Unlock()
+			}) {
+				t.Fatalf("Submit(%q, %d) was dropped", key, n)
+			}
+		}
+	}
+	p.Stop()
+
+	for key, order := range seen {
+		if len(order) != 50 {
+			t.Errorf("key %q ran %d jobs; want 50", key, len(order))
+		}
+		for i, n := range order {
+			if n != i {
+				t.Errorf("key %q ran job %d at position %d", key, n, i)
+				break
+			}
+		}
+	}
+}
+
+func TestPoolDropsWhenFullOrStopped(t *testing.T) {
+	p := New(1, 1)
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	p.Submit("k", func() { close(started); <-block })
+	<-started
+
+	if !p.Submit("k", func() {}) {
+		t.Error("Submit into empty queue slot was dropped")
+	}
+	if p.Submit("k", func() {}) {
+		t.Error("Submit into full queue was accepted")
+	}
+
+	close(block)
+	p.Stop()
+	if p.Submit("k", func() {}) {
+		t.Error("Submit after Stop was accepted")
+	}
+}